@@ -1,8 +1,9 @@
 // Package auth is the public auth API for the restish config and CLI.
 //
-// The token cache (CachedToken, TokenStore, TokenCache) and the
-// auth-handler interfaces (Handler, Param, AuthContext, Prompter, Logger,
-// ForceCapable) are part of the supported public surface. External tools
+// The token cache (CachedToken, TokenStore, TokenCache, and the encrypted
+// variant from NewEncryptedTokenCache) and the auth-handler interfaces
+// (Handler, Param, AuthContext, Prompter, Logger, ForceCapable) are part of
+// the supported public surface. External tools
 // that need to share the restish OAuth token cache, or embed restish in
 // a Go binary and register custom auth handlers, use this package.
 //
@@ -61,6 +62,11 @@ type AuthContext struct {
 	HTTPClient  *http.Client
 	Logger      Logger
 	Force       bool // bypass cached access tokens for a single retry
+	// Challenges holds the WWW-Authenticate values from the 401 response that
+	// triggered a Force retry, for challenge-driven schemes such as Digest.
+	Challenges []string
+	// DPoPNonce is the DPoP-Nonce value from that 401 response, if any.
+	DPoPNonce string
 }
 
 // Handler is implemented by each auth mechanism.
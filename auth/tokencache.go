@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -16,12 +17,20 @@ import (
 
 var renameTokenCacheFile = os.Rename
 
-// CachedToken holds a cached OAuth2 access token and optional refresh token.
+// CachedToken holds a cached OAuth2 access token and optional refresh and
+// OpenID Connect ID tokens. TokenType is "DPoP" for sender-constrained tokens
+// (RFC 9449), which must be presented with proofs signed by DPoPKey.
 type CachedToken struct {
 	AccessToken  string    `cbor:"access_token" json:"access_token"`
 	TokenType    string    `cbor:"token_type,omitempty" json:"token_type,omitempty"`
 	RefreshToken string    `cbor:"refresh_token,omitempty" json:"refresh_token,omitempty"`
+	IDToken      string    `cbor:"id_token,omitempty" json:"id_token,omitempty"`
 	Expiry       time.Time `cbor:"expiry,omitempty" json:"expiry,omitempty"`
+	// DPoPKey is the base64 PKCS #8 private key the tokens are bound to.
+	DPoPKey string `cbor:"dpop_key,omitempty" json:"dpop_key,omitempty"`
+	// DPoPNonces maps each origin to the latest DPoP-Nonce it issued to this
+	// credential, so later runs send it up front instead of collecting a 401.
+	DPoPNonces map[string]string `cbor:"dpop_nonces,omitempty" json:"dpop_nonces,omitempty"`
 }
 
 // IsExpired reports whether the token is expired (or will expire within 30s).
@@ -32,8 +41,9 @@ func (t *CachedToken) IsExpired() bool {
 	return time.Now().Add(30 * time.Second).After(t.Expiry)
 }
 
-// TokenCache persists OAuth2 tokens as a flat CBOR map at a given file path.
-// All operations are safe for concurrent use.
+// TokenCache persists OAuth2 tokens as a flat CBOR map at a given file path,
+// optionally encrypted (see NewEncryptedTokenCache). All operations are safe
+// for concurrent use.
 type TokenCache struct {
 	path    string
 	mu      sync.Mutex
@@ -41,6 +51,11 @@ type TokenCache struct {
 	cache   map[string]CachedToken
 	modTime time.Time
 	size    int64
+
+	key     TokenCacheKey
+	salt    []byte
+	kdf     KDFParams
+	derived []byte
 }
 
 // NewTokenCache returns a TokenCache that stores tokens at path.
@@ -200,6 +215,12 @@ func (c *TokenCache) loadLocked() (map[string]CachedToken, error) {
 	if err != nil {
 		return nil, err
 	}
+	encrypted := bytes.HasPrefix(data, encryptedTokenCacheMagic)
+	if encrypted {
+		if data, err = c.decrypt(data); err != nil {
+			return nil, err
+		}
+	}
 	var m map[string]CachedToken
 	if err := cbor.Unmarshal(data, &m); err != nil {
 		if jsonErr := json.Unmarshal(data, &m); jsonErr != nil {
@@ -209,6 +230,13 @@ func (c *TokenCache) loadLocked() (map[string]CachedToken, error) {
 	if m == nil {
 		m = map[string]CachedToken{}
 	}
+	if c.key != nil && !encrypted {
+		// Migrate a plaintext cache the first time an encrypted store reads it.
+		if err := c.saveLocked(m); err != nil {
+			return nil, fmt.Errorf("encrypting token cache %s: %w", c.path, err)
+		}
+		return c.cache, nil
+	}
 	info, statErr := os.Stat(c.path)
 	if statErr == nil {
 		c.modTime = info.ModTime()
@@ -224,6 +252,11 @@ func (c *TokenCache) saveLocked(m map[string]CachedToken) error {
 	if err != nil {
 		return err
 	}
+	if c.key != nil {
+		if data, err = c.encrypt(data); err != nil {
+			return err
+		}
+	}
 	if err := fileutil.AtomicWriteFile(c.path, data, fileutil.AtomicWriteOptions{
 		FileMode:    0o600,
 		DirMode:     0o700,
@@ -251,8 +284,9 @@ func DefaultTokenCachePath() string {
 }
 
 // LoadTokenCache reads the full token cache at path into a map. Returns an
-// empty map when the file does not exist. External readers that do not need
-// the in-process locking of TokenCache can call this directly.
+// empty map when the file does not exist, and an error wrapping
+// ErrTokenCacheEncrypted when the file is encrypted. External readers that do
+// not need the in-process locking of TokenCache can call this directly.
 func LoadTokenCache(path string) (map[string]CachedToken, error) {
 	c := NewTokenCache(path)
 	lock, err := fileutil.LockSiblingFile(path)
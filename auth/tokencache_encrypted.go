@@ -0,0 +1,291 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/rest-sh/restish/v2/internal/fileutil"
+	"golang.org/x/crypto/argon2"
+)
+
+// encryptedTokenCacheMagic prefixes encrypted token cache files. Plaintext
+// caches are a bare CBOR or JSON map, which never starts with these bytes.
+var encryptedTokenCacheMagic = []byte("RSHTC\x00\x01\n")
+
+// ErrTokenCacheEncrypted reports that a token cache file is encrypted but the
+// reader was not given a key.
+var ErrTokenCacheEncrypted = errors.New("token cache is encrypted")
+
+// Argon2id parameters for passphrase-derived keys. They are recorded in each
+// file so they can be raised later without breaking existing caches.
+const (
+	tokenCacheArgonTime    = 3
+	tokenCacheArgonMemory  = 64 * 1024
+	tokenCacheArgonThreads = 4
+	tokenCacheKeySize      = 32
+	tokenCacheSaltSize     = 16
+)
+
+// TokenCacheKey supplies the AES-256 key for an encrypted token cache.
+type TokenCacheKey interface {
+	// Backend names the key source, such as "keyring", "passphrase", or
+	// "key-file". It is recorded in the cache file and reported by doctor.
+	Backend() string
+	// Key returns a 32-byte key. salt is the per-file salt; backends that
+	// hold a full-entropy key ignore it.
+	Key(salt []byte, kdf KDFParams) ([]byte, error)
+}
+
+// KDFParams describes how a passphrase-derived key was stretched.
+type KDFParams struct {
+	Name    string `cbor:"name"`
+	Time    uint32 `cbor:"time,omitempty"`
+	Memory  uint32 `cbor:"memory,omitempty"`
+	Threads uint8  `cbor:"threads,omitempty"`
+}
+
+type encryptedTokenCacheFile struct {
+	Backend    string    `cbor:"backend"`
+	KDF        KDFParams `cbor:"kdf"`
+	Salt       []byte    `cbor:"salt"`
+	Nonce      []byte    `cbor:"nonce"`
+	Ciphertext []byte    `cbor:"ciphertext"`
+}
+
+// NewEncryptedTokenCache returns a TokenCache that encrypts the file at path
+// with AES-256-GCM using key. An existing plaintext cache at path is read
+// once and rewritten encrypted, so enabling encryption keeps cached logins.
+func NewEncryptedTokenCache(path string, key TokenCacheKey) *TokenCache {
+	return &TokenCache{path: path, key: key}
+}
+
+// Backend returns the key backend of an encrypted cache, or "plaintext".
+func (c *TokenCache) Backend() string {
+	if c.key == nil {
+		return "plaintext"
+	}
+	return c.key.Backend()
+}
+
+// TokenCacheFileBackend reports how the token cache file at path is stored:
+// "" when the file does not exist, "plaintext", or the key backend recorded
+// in an encrypted file. It does not need the key.
+func TokenCacheFileBackend(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if !bytes.HasPrefix(data, encryptedTokenCacheMagic) {
+		return "plaintext", nil
+	}
+	var file encryptedTokenCacheFile
+	if err := cbor.Unmarshal(data[len(encryptedTokenCacheMagic):], &file); err != nil {
+		return "", fmt.Errorf("decoding encrypted token cache %s: %w", path, err)
+	}
+	return file.Backend, nil
+}
+
+func (c *TokenCache) decrypt(data []byte) ([]byte, error) {
+	if c.key == nil {
+		return nil, fmt.Errorf("%w: %s; configure token_cache.encryption to read it", ErrTokenCacheEncrypted, c.path)
+	}
+	var file encryptedTokenCacheFile
+	if err := cbor.Unmarshal(data[len(encryptedTokenCacheMagic):], &file); err != nil {
+		return nil, fmt.Errorf("decoding encrypted token cache %s: %w", c.path, err)
+	}
+	if file.Backend != c.key.Backend() {
+		return nil, fmt.Errorf("token cache %s is encrypted with the %s backend, but %s is configured", c.path, file.Backend, c.key.Backend())
+	}
+	key, err := c.cipherKey(file.Salt, file.KDF)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newTokenCacheGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := gcm.Open(nil, file.Nonce, file.Ciphertext, encryptedTokenCacheMagic)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting token cache %s: wrong key or corrupted file", c.path)
+	}
+	return plain, nil
+}
+
+func (c *TokenCache) encrypt(plain []byte) ([]byte, error) {
+	if c.salt == nil {
+		c.salt = make([]byte, tokenCacheSaltSize)
+		if _, err := rand.Read(c.salt); err != nil {
+			return nil, err
+		}
+		c.kdf = defaultTokenCacheKDF(c.key)
+	}
+	key, err := c.cipherKey(c.salt, c.kdf)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newTokenCacheGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	envelope, err := cbor.Marshal(encryptedTokenCacheFile{
+		Backend:    c.key.Backend(),
+		KDF:        c.kdf,
+		Salt:       c.salt,
+		Nonce:      nonce,
+		Ciphertext: gcm.Seal(nil, nonce, plain, encryptedTokenCacheMagic),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]byte(nil), encryptedTokenCacheMagic...), envelope...), nil
+}
+
+// cipherKey returns the key for salt, reusing the last derivation so a
+// passphrase is stretched once per process rather than once per read.
+func (c *TokenCache) cipherKey(salt []byte, kdf KDFParams) ([]byte, error) {
+	if c.derived != nil && bytes.Equal(c.salt, salt) && c.kdf == kdf {
+		return c.derived, nil
+	}
+	key, err := c.key.Key(salt, kdf)
+	if err != nil {
+		return nil, fmt.Errorf("token cache key (%s): %w", c.key.Backend(), err)
+	}
+	if len(key) != tokenCacheKeySize {
+		return nil, fmt.Errorf("token cache key (%s): got %d bytes, want %d", c.key.Backend(), len(key), tokenCacheKeySize)
+	}
+	c.salt = append([]byte(nil), salt...)
+	c.kdf = kdf
+	c.derived = key
+	return key, nil
+}
+
+func newTokenCacheGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func defaultTokenCacheKDF(key TokenCacheKey) KDFParams {
+	if _, ok := key.(*passphraseTokenCacheKey); ok {
+		return KDFParams{Name: "argon2id", Time: tokenCacheArgonTime, Memory: tokenCacheArgonMemory, Threads: tokenCacheArgonThreads}
+	}
+	return KDFParams{Name: "none"}
+}
+
+type passphraseTokenCacheKey struct {
+	passphrase func() (string, error)
+	once       sync.Once
+	value      string
+	err        error
+}
+
+// PassphraseTokenCacheKey derives the cache key from a passphrase with
+// Argon2id. passphrase is called at most once, on first use.
+func PassphraseTokenCacheKey(passphrase func() (string, error)) TokenCacheKey {
+	return &passphraseTokenCacheKey{passphrase: passphrase}
+}
+
+func (k *passphraseTokenCacheKey) Backend() string { return "passphrase" }
+
+func (k *passphraseTokenCacheKey) Key(salt []byte, kdf KDFParams) ([]byte, error) {
+	if kdf.Name != "argon2id" {
+		return nil, fmt.Errorf("unsupported key derivation %q", kdf.Name)
+	}
+	k.once.Do(func() {
+		k.value, k.err = k.passphrase()
+		if k.err == nil && k.value == "" {
+			k.err = errors.New("passphrase is empty")
+		}
+	})
+	if k.err != nil {
+		return nil, k.err
+	}
+	return argon2.IDKey([]byte(k.value), salt, kdf.Time, kdf.Memory, kdf.Threads, tokenCacheKeySize), nil
+}
+
+type staticTokenCacheKey struct {
+	backend string
+	load    func() ([]byte, error)
+}
+
+// StaticTokenCacheKey uses a full-entropy 32-byte key returned by load, such
+// as one held in the OS keyring. backend names the source for diagnostics.
+func StaticTokenCacheKey(backend string, load func() ([]byte, error)) TokenCacheKey {
+	return &staticTokenCacheKey{backend: backend, load: load}
+}
+
+func (k *staticTokenCacheKey) Backend() string { return k.backend }
+
+func (k *staticTokenCacheKey) Key(_ []byte, _ KDFParams) ([]byte, error) {
+	return k.load()
+}
+
+// KeyFileTokenCacheKey reads a base64-encoded 32-byte key from path. When
+// the file does not exist a new random key is written there with 0600
+// permissions.
+func KeyFileTokenCacheKey(path string) TokenCacheKey {
+	return StaticTokenCacheKey("key-file", func() ([]byte, error) {
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			key, encoded, err := NewTokenCacheKey()
+			if err != nil {
+				return nil, err
+			}
+			if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+				return nil, err
+			}
+			if err := fileutil.AtomicWriteFile(path, []byte(encoded+"\n"), fileutil.AtomicWriteOptions{
+				FileMode:    0o600,
+				DirMode:     0o700,
+				TempPattern: "token-key-*.tmp",
+			}); err != nil {
+				return nil, err
+			}
+			return key, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return DecodeTokenCacheKey(string(data))
+	})
+}
+
+// NewTokenCacheKey returns a random 32-byte key and its base64 encoding.
+func NewTokenCacheKey() ([]byte, string, error) {
+	key := make([]byte, tokenCacheKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, "", err
+	}
+	return key, base64.StdEncoding.EncodeToString(key), nil
+}
+
+// DecodeTokenCacheKey parses a base64-encoded 32-byte key.
+func DecodeTokenCacheKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("key is not valid base64: %w", err)
+	}
+	if len(key) != tokenCacheKeySize {
+		return nil, fmt.Errorf("key is %d bytes, want %d", len(key), tokenCacheKeySize)
+	}
+	return key, nil
+}
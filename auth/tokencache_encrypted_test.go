@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testStaticKey(t *testing.T) TokenCacheKey {
+	t.Helper()
+	key, _, err := NewTokenCacheKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return StaticTokenCacheKey("keyring", func() ([]byte, error) { return key, nil })
+}
+
+func TestEncryptedTokenCache_RoundTripHidesTokens(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.cbor")
+	key := testStaticKey(t)
+	if err := NewEncryptedTokenCache(path, key).Set("k", CachedToken{AccessToken: "access-secret", RefreshToken: "refresh-secret"}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(data, encryptedTokenCacheMagic) || bytes.Contains(data, []byte("secret")) {
+		t.Fatalf("cache file is not encrypted: %q", data)
+	}
+	if backend, err := TokenCacheFileBackend(path); err != nil || backend != "keyring" {
+		t.Fatalf("TokenCacheFileBackend = %q, %v", backend, err)
+	}
+
+	got, err := NewEncryptedTokenCache(path, key).Get("k")
+	if err != nil || got == nil || got.RefreshToken != "refresh-secret" {
+		t.Fatalf("Get = %+v, %v", got, err)
+	}
+}
+
+func TestEncryptedTokenCache_MigratesPlaintext(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.cbor")
+	if err := NewTokenCache(path).Set("k", CachedToken{AccessToken: "plain-token"}); err != nil {
+		t.Fatal(err)
+	}
+	if backend, _ := TokenCacheFileBackend(path); backend != "plaintext" {
+		t.Fatalf("backend before migration = %q", backend)
+	}
+
+	got, err := NewEncryptedTokenCache(path, testStaticKey(t)).Get("k")
+	if err != nil || got == nil || got.AccessToken != "plain-token" {
+		t.Fatalf("Get = %+v, %v", got, err)
+	}
+	data, _ := os.ReadFile(path)
+	if !bytes.HasPrefix(data, encryptedTokenCacheMagic) || bytes.Contains(data, []byte("plain-token")) {
+		t.Fatalf("plaintext cache was not rewritten encrypted: %q", data)
+	}
+}
+
+func TestEncryptedTokenCache_Errors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.cbor")
+	if err := NewEncryptedTokenCache(path, testStaticKey(t)).Set("k", CachedToken{AccessToken: "a"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewTokenCache(path).Get("k"); !errors.Is(err, ErrTokenCacheEncrypted) {
+		t.Fatalf("plaintext reader err = %v, want ErrTokenCacheEncrypted", err)
+	}
+	if _, err := LoadTokenCache(path); !errors.Is(err, ErrTokenCacheEncrypted) {
+		t.Fatalf("LoadTokenCache err = %v, want ErrTokenCacheEncrypted", err)
+	}
+	if _, err := NewEncryptedTokenCache(path, testStaticKey(t)).Get("k"); err == nil || !strings.Contains(err.Error(), "wrong key") {
+		t.Fatalf("wrong key err = %v", err)
+	}
+	other := StaticTokenCacheKey("key-file", func() ([]byte, error) { return make([]byte, 32), nil })
+	if _, err := NewEncryptedTokenCache(path, other).Get("k"); err == nil || !strings.Contains(err.Error(), "encrypted with the keyring backend") {
+		t.Fatalf("backend mismatch err = %v", err)
+	}
+	short := StaticTokenCacheKey("keyring", func() ([]byte, error) { return []byte("short"), nil })
+	if err := NewEncryptedTokenCache(filepath.Join(t.TempDir(), "t.cbor"), short).Set("k", CachedToken{}); err == nil || !strings.Contains(err.Error(), "want 32") {
+		t.Fatalf("short key err = %v", err)
+	}
+}
+
+func TestEncryptedTokenCache_PassphrasePromptsOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.cbor")
+	calls := 0
+	key := PassphraseTokenCacheKey(func() (string, error) {
+		calls++
+		return "correct horse", nil
+	})
+	tc := NewEncryptedTokenCache(path, key)
+	for _, k := range []string{"a", "b"} {
+		if err := tc.Set(k, CachedToken{AccessToken: k}); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("passphrase requested %d times, want 1", calls)
+	}
+
+	wrong := NewEncryptedTokenCache(path, PassphraseTokenCacheKey(func() (string, error) { return "wrong", nil }))
+	if _, err := wrong.Get("a"); err == nil || !strings.Contains(err.Error(), "wrong key") {
+		t.Fatalf("wrong passphrase err = %v", err)
+	}
+	right := NewEncryptedTokenCache(path, PassphraseTokenCacheKey(func() (string, error) { return "correct horse", nil }))
+	if got, err := right.Get("b"); err != nil || got == nil || got.AccessToken != "b" {
+		t.Fatalf("Get = %+v, %v", got, err)
+	}
+}
+
+func TestKeyFileTokenCacheKey_CreatesKey(t *testing.T) {
+	dir := t.TempDir()
+	keyPath := filepath.Join(dir, "token-cache.key")
+	path := filepath.Join(dir, "tokens.cbor")
+	if err := NewEncryptedTokenCache(path, KeyFileTokenCacheKey(keyPath)).Set("k", CachedToken{AccessToken: "a"}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	info, err := os.Stat(keyPath)
+	if err != nil {
+		t.Fatalf("key file not created: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 && os.PathSeparator == '/' {
+		t.Fatalf("key file mode = %v, want 0600", perm)
+	}
+	if got, err := NewEncryptedTokenCache(path, KeyFileTokenCacheKey(keyPath)).Get("k"); err != nil || got == nil {
+		t.Fatalf("Get = %+v, %v", got, err)
+	}
+}
@@ -32,6 +32,9 @@ type Config struct {
 	// Cache holds global cache settings.
 	Cache CacheConfig `json:"cache,omitempty"`
 
+	// TokenCache controls how cached OAuth tokens are stored at rest.
+	TokenCache TokenCacheConfig `json:"token_cache,omitempty"`
+
 	// Theme customizes syntax highlighting for readable terminal output.
 	// Keys are Chroma token names or Restish theme aliases; values are Chroma
 	// style descriptors such as "#afd787" or "bold #ff5f87".
@@ -79,8 +82,15 @@ type APIConfig struct {
 	AllowCrossOriginSpec bool `json:"allow_cross_origin_spec,omitempty"`
 	// SpecFiles is an ordered list of local file paths or URLs to load the API
 	// spec from. Multiple files are deep-merged in order (later entries win on
-	// conflict). When set, network spec discovery is skipped entirely.
+	// conflict). When set, network spec discovery is skipped entirely. A single
+	// entry may also be a Postman or Insomnia collection export, a Bruno
+	// collection directory, an AsyncAPI document, a protobuf descriptor set,
+	// or OData v4 `$metadata` XML, which is converted to OpenAPI.
 	SpecFiles []string `json:"spec_files,omitempty"`
+	// OverlayFiles is an ordered list of local file paths or URLs of OpenAPI
+	// Overlay documents applied to the loaded spec before generated commands
+	// are built. Use them to patch third-party specs without forking them.
+	OverlayFiles []string `json:"overlay_files,omitempty"`
 	// OperationBase, when set, is an absolute path resolved against base_url for
 	// paths generated from OpenAPI operations. Useful when operation paths should
 	// escape or replace a sub-path in base_url.
@@ -104,6 +114,15 @@ type APIConfig struct {
 	Profiles map[string]*ProfileConfig `json:"profiles,omitempty"`
 	// Pagination holds optional per-API pagination configuration.
 	Pagination *PaginationConfig `json:"pagination,omitempty"`
+	// Normalize selects a response normalization for this API. "jsonapi"
+	// flattens JSON:API documents into plain records and wraps shorthand
+	// request bodies in the JSON:API envelope.
+	Normalize string `json:"normalize,omitempty"`
+	// RPCProtocol selects the wire protocol for commands generated from a
+	// protobuf descriptor set: "connect" or "twirp". When empty, a "twirp"
+	// path segment in the base URL selects Twirp and any other base URL uses
+	// Connect.
+	RPCProtocol string `json:"rpc_protocol,omitempty"`
 	// RetryMaxWait caps Retry-After/X-Retry-In delays for this API when no
 	// command-line or environment override is supplied.
 	RetryMaxWait string `json:"retry_max_wait,omitempty"`
@@ -134,8 +153,9 @@ type ProfileConfig struct {
 	BaseURL string `json:"base_url,omitempty"`
 	// OperationBase overrides API-level operation_base when this profile is active.
 	OperationBase string `json:"operation_base,omitempty"`
-	// Headers is a list of persistent "Name: Value" headers sent with every request.
-	Headers []string `json:"headers,omitempty"`
+	// Headers is a list of persistent "Name: Value" headers sent with every
+	// request. Values may be secret references.
+	Headers HeaderList `json:"headers,omitempty"`
 	// Query is a list of persistent "key=value" query params sent with every request.
 	Query []string `json:"query,omitempty"`
 	// CACertPath is an optional PEM CA bundle for this profile.
@@ -147,7 +167,8 @@ type ProfileConfig struct {
 	// TLSSigner selects a tls-signer plugin for mTLS client certificate signing.
 	TLSSigner string `json:"tls_signer,omitempty"`
 	// TLSSignerParams passes plugin-specific configuration to the tls-signer.
-	TLSSignerParams map[string]string `json:"tls_signer_params,omitempty"`
+	// Values may be secret references.
+	TLSSignerParams ParamMap `json:"tls_signer_params,omitempty"`
 	// ServerVariables overrides API-level OpenAPI server URL variables for this
 	// profile when generating operation paths.
 	ServerVariables map[string]string `json:"server_variables,omitempty"`
@@ -180,7 +201,8 @@ type AuthConfig struct {
 	// Type identifies the auth mechanism (e.g. "http-basic", "oauth-client-credentials").
 	Type string `json:"type,omitempty"`
 	// Params holds handler-specific configuration, e.g. {"username": "alice"}.
-	Params map[string]string `json:"params,omitempty"`
+	// Values may be secret references such as {"$env": "API_TOKEN"}.
+	Params ParamMap `json:"params,omitempty"`
 }
 
 // CacheConfig holds cache settings.
@@ -189,6 +211,16 @@ type CacheConfig struct {
 	MaxSize string `json:"max_size,omitempty"`
 }
 
+// TokenCacheConfig controls at-rest encryption of the OAuth token cache.
+type TokenCacheConfig struct {
+	// Encryption selects the key backend: "" or "none" (plaintext, the
+	// default), "keyring", "passphrase", or "key-file".
+	Encryption string `json:"encryption,omitempty"`
+	// KeyFile is the key path for "key-file" encryption, which requires it.
+	// It must be outside the token cache's directory.
+	KeyFile string `json:"key_file,omitempty"`
+}
+
 // DefaultPath returns the path to the default config file, honoring
 // the RSH_CONFIG_DIR and XDG environment variable overrides.
 func DefaultPath() string {
@@ -363,6 +395,14 @@ func Validate(cfg *Config) error {
 	if cfg == nil {
 		return nil
 	}
+	switch cfg.TokenCache.Encryption {
+	case "", "none", "keyring", "passphrase", "key-file":
+	default:
+		return fmt.Errorf("token_cache.encryption: unsupported value %q (use none, keyring, passphrase, or key-file)", cfg.TokenCache.Encryption)
+	}
+	if cfg.TokenCache.Encryption == "key-file" && strings.TrimSpace(cfg.TokenCache.KeyFile) == "" {
+		return fmt.Errorf("token_cache.key_file is required for key-file encryption; keep the key away from the token cache, such as on a separate volume")
+	}
 	for name, api := range cfg.APIs {
 		if err := ValidateAPIName(name); err != nil {
 			return fmt.Errorf("apis.%s: invalid API name: %w", name, err)
@@ -376,6 +416,12 @@ func Validate(cfg *Config) error {
 		if err := ValidateCommandLayout(api.CommandLayout); err != nil {
 			return fmt.Errorf("apis.%s.command_layout: %w", name, err)
 		}
+		if err := ValidateNormalize(api.Normalize); err != nil {
+			return fmt.Errorf("apis.%s.normalize: %w", name, err)
+		}
+		if err := ValidateRPCProtocol(api.RPCProtocol); err != nil {
+			return fmt.Errorf("apis.%s.rpc_protocol: %w", name, err)
+		}
 		if err := ValidateRetryMaxWait(api.RetryMaxWait); err != nil {
 			return fmt.Errorf("apis.%s.retry_max_wait: %w", name, err)
 		}
@@ -483,6 +529,26 @@ func ValidateCommandLayout(raw string) error {
 	}
 }
 
+// ValidateRPCProtocol enforces supported RPC wire protocols.
+func ValidateRPCProtocol(raw string) error {
+	switch raw {
+	case "", "connect", "twirp":
+		return nil
+	default:
+		return fmt.Errorf("must be \"connect\" or \"twirp\"")
+	}
+}
+
+// ValidateNormalize enforces supported response normalizations.
+func ValidateNormalize(raw string) error {
+	switch raw {
+	case "", "jsonapi", "none":
+		return nil
+	default:
+		return fmt.Errorf("must be \"jsonapi\" or \"none\"")
+	}
+}
+
 // ValidateRetryMaxWait enforces the retry_max_wait duration contract.
 func ValidateRetryMaxWait(raw string) error {
 	raw = strings.TrimSpace(raw)
@@ -130,6 +130,22 @@ func TestValidate_AuthRefRequiresKnownProfile(t *testing.T) {
 	}
 }
 
+func TestValidate_TokenCacheEncryption(t *testing.T) {
+	for _, value := range []string{"", "none", "keyring", "passphrase", "key-file"} {
+		if err := config.Validate(&config.Config{TokenCache: config.TokenCacheConfig{Encryption: value, KeyFile: "/mnt/keys/restish.key"}}); err != nil {
+			t.Fatalf("Validate(%q): %v", value, err)
+		}
+	}
+	err := config.Validate(&config.Config{TokenCache: config.TokenCacheConfig{Encryption: "key-file"}})
+	if err == nil || !strings.Contains(err.Error(), "token_cache.key_file is required") {
+		t.Fatalf("Validate(key-file without key_file) err = %v", err)
+	}
+	err = config.Validate(&config.Config{TokenCache: config.TokenCacheConfig{Encryption: "rot13"}})
+	if err == nil || !strings.Contains(err.Error(), "token_cache.encryption") {
+		t.Fatalf("Validate(rot13) err = %v", err)
+	}
+}
+
 func TestValidate_AuthAndAuthRefAreMutuallyExclusive(t *testing.T) {
 	cfg := &config.Config{
 		AuthProfiles: map[string]*config.AuthConfig{
@@ -0,0 +1,317 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SecretRef points at a secret stored outside the config file. Exactly one
+// field is set. References are resolved at request time and the resolved
+// value is never written back to config.
+//
+// In config files a reference replaces a string value:
+//
+//	{"$env": "API_TOKEN"}
+//	{"$file": "~/.secrets/api-token"}
+//	{"$cmd": ["op", "read", "op://vault/api/token"]}
+//	{"$keyring": "restish/api-token"}
+type SecretRef struct {
+	// Env names an environment variable.
+	Env string `json:"$env,omitempty"`
+	// File is a path whose contents, minus trailing newlines, are the secret.
+	File string `json:"$file,omitempty"`
+	// Cmd is an argv run without a shell; its trimmed stdout is the secret.
+	Cmd []string `json:"$cmd,omitempty"`
+	// Keyring is "service/account" in the OS keyring.
+	Keyring string `json:"$keyring,omitempty"`
+}
+
+// Kind returns "$env", "$file", "$cmd", or "$keyring".
+func (r SecretRef) Kind() string {
+	switch {
+	case r.Env != "":
+		return "$env"
+	case r.File != "":
+		return "$file"
+	case len(r.Cmd) > 0:
+		return "$cmd"
+	case r.Keyring != "":
+		return "$keyring"
+	default:
+		return ""
+	}
+}
+
+// Source describes where the secret comes from without revealing it, e.g.
+// "$env API_TOKEN" or "$keyring restish/api-token".
+func (r SecretRef) Source() string {
+	switch r.Kind() {
+	case "$env":
+		return "$env " + r.Env
+	case "$file":
+		return "$file " + r.File
+	case "$cmd":
+		return "$cmd " + strings.Join(r.Cmd, " ")
+	case "$keyring":
+		return "$keyring " + r.Keyring
+	default:
+		return ""
+	}
+}
+
+// KeyringService splits a $keyring reference into service and account at the
+// last slash, so service names may themselves contain slashes.
+func (r SecretRef) KeyringService() (service, account string, err error) {
+	idx := strings.LastIndex(r.Keyring, "/")
+	if idx <= 0 || idx == len(r.Keyring)-1 {
+		return "", "", fmt.Errorf("$keyring reference %q must be service/account", r.Keyring)
+	}
+	return r.Keyring[:idx], r.Keyring[idx+1:], nil
+}
+
+// secretRefMarker prefixes encoded references in string-valued config fields.
+// Decoding rejects literal strings that start with it, so a real value can
+// never be mistaken for a reference, even one that looks like JSON.
+const secretRefMarker = "\x00secret-ref:"
+
+// Encode returns the form used to carry a reference inside string-valued
+// config fields such as AuthConfig.Params. Only ParseSecretRef reads it back.
+func (r SecretRef) Encode() string {
+	data, _ := json.Marshal(r)
+	return secretRefMarker + string(data)
+}
+
+func (r SecretRef) validate() error {
+	set := 0
+	if r.Env != "" {
+		set++
+	}
+	if r.File != "" {
+		set++
+	}
+	if len(r.Cmd) > 0 {
+		set++
+		if strings.TrimSpace(r.Cmd[0]) == "" {
+			return fmt.Errorf("$cmd reference must start with a program name")
+		}
+	}
+	if r.Keyring != "" {
+		set++
+		if _, _, err := r.KeyringService(); err != nil {
+			return err
+		}
+	}
+	if set != 1 {
+		return fmt.Errorf("secret reference must set exactly one of $env, $file, $cmd, or $keyring")
+	}
+	return nil
+}
+
+// ParseSecretRef reports whether value is a secret reference encoded by
+// Encode, as stored in ParamMap and HeaderList values after decoding config.
+// Literal values are never references, whatever they contain.
+func ParseSecretRef(value string) (SecretRef, bool) {
+	encoded, ok := strings.CutPrefix(value, secretRefMarker)
+	if !ok {
+		return SecretRef{}, false
+	}
+	ref, err := decodeSecretRef([]byte(encoded))
+	if err != nil {
+		return SecretRef{}, false
+	}
+	return ref, true
+}
+
+func decodeSecretRef(data []byte) (SecretRef, error) {
+	var ref SecretRef
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&ref); err != nil {
+		return SecretRef{}, fmt.Errorf("invalid secret reference: %w", err)
+	}
+	if err := ref.validate(); err != nil {
+		return SecretRef{}, err
+	}
+	return ref, nil
+}
+
+// decodeSecretString accepts either a JSON string or a secret reference
+// object and returns the string form stored in memory.
+func decodeSecretString(data []byte) (string, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '{' {
+		ref, err := decodeSecretRef(data)
+		if err != nil {
+			return "", err
+		}
+		return ref.Encode(), nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return "", err
+	}
+	if err := checkLiteralSecretString(s); err != nil {
+		return "", err
+	}
+	return s, nil
+}
+
+// checkLiteralSecretString rejects literal values that would decode as an
+// encoded reference.
+func checkLiteralSecretString(s string) error {
+	if strings.Contains(s, secretRefMarker) {
+		return fmt.Errorf("value must not contain the reserved secret reference marker")
+	}
+	return nil
+}
+
+// ParamMap is a string map whose values may be written in config as secret
+// references. Decoded references are held in their encoded form (see
+// ParseSecretRef) and are written back as reference objects.
+type ParamMap map[string]string
+
+// UnmarshalJSON accepts string values and secret reference objects.
+func (m *ParamMap) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if raw == nil {
+		*m = nil
+		return nil
+	}
+	out := make(ParamMap, len(raw))
+	for key, value := range raw {
+		s, err := decodeSecretString(value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		out[key] = s
+	}
+	*m = out
+	return nil
+}
+
+// MarshalJSON writes secret references back as objects.
+func (m ParamMap) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return []byte("null"), nil
+	}
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, _ := json.Marshal(key)
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(marshalSecretString(m[key]))
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func marshalSecretString(value string) []byte {
+	if ref, ok := ParseSecretRef(value); ok {
+		data, _ := json.Marshal(ref)
+		return data
+	}
+	data, _ := json.Marshal(value)
+	return data
+}
+
+// HeaderList is a list of "Name: Value" headers. In config an entry may also
+// be an object whose value is a secret reference:
+//
+//	{"name": "X-API-Key", "value": {"$env": "API_KEY"}}
+type HeaderList []string
+
+type headerEntry struct {
+	Name  string          `json:"name"`
+	Value json.RawMessage `json:"value"`
+}
+
+// UnmarshalJSON accepts "Name: Value" strings and name/value objects.
+func (l *HeaderList) UnmarshalJSON(data []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if raw == nil {
+		*l = nil
+		return nil
+	}
+	out := make(HeaderList, 0, len(raw))
+	for i, item := range raw {
+		item = bytes.TrimSpace(item)
+		if len(item) == 0 || item[0] != '{' {
+			var s string
+			if err := json.Unmarshal(item, &s); err != nil {
+				return fmt.Errorf("[%d]: %w", i, err)
+			}
+			if err := checkLiteralSecretString(s); err != nil {
+				return fmt.Errorf("[%d]: %w", i, err)
+			}
+			out = append(out, s)
+			continue
+		}
+		var entry headerEntry
+		dec := json.NewDecoder(bytes.NewReader(item))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&entry); err != nil {
+			return fmt.Errorf("[%d]: %w", i, err)
+		}
+		if strings.TrimSpace(entry.Name) == "" {
+			return fmt.Errorf("[%d]: header name must not be empty", i)
+		}
+		value, err := decodeSecretString(entry.Value)
+		if err != nil {
+			return fmt.Errorf("[%d]: %w", i, err)
+		}
+		out = append(out, entry.Name+": "+value)
+	}
+	*l = out
+	return nil
+}
+
+// MarshalJSON writes headers with secret reference values back as objects.
+func (l HeaderList) MarshalJSON() ([]byte, error) {
+	if l == nil {
+		return []byte("null"), nil
+	}
+	items := make([]json.RawMessage, len(l))
+	for i, header := range l {
+		if name, value, ok := SplitSecretRefHeader(header); ok {
+			nameJSON, _ := json.Marshal(name)
+			valueJSON, _ := json.Marshal(value)
+			items[i] = json.RawMessage(`{"name":` + string(nameJSON) + `,"value":` + string(valueJSON) + `}`)
+			continue
+		}
+		data, _ := json.Marshal(header)
+		items[i] = data
+	}
+	return json.Marshal(items)
+}
+
+// SplitSecretRefHeader reports whether a "Name: Value" header carries a secret
+// reference value and returns its parts.
+func SplitSecretRefHeader(header string) (string, SecretRef, bool) {
+	name, value, ok := strings.Cut(header, ":")
+	if !ok {
+		return "", SecretRef{}, false
+	}
+	ref, ok := ParseSecretRef(strings.TrimSpace(value))
+	if !ok {
+		return "", SecretRef{}, false
+	}
+	return strings.TrimSpace(name), ref, true
+}
@@ -0,0 +1,125 @@
+package config_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/rest-sh/restish/v2/config"
+)
+
+func TestSecretReferencesRoundTrip(t *testing.T) {
+	path := writeConfig(t, `{
+  "apis": {
+    "svc": {
+      "base_url": "https://api.example.com",
+      "profiles": {
+        "default": {
+          "headers": [
+            "X-Trace: visible",
+            {"name": "X-API-Key", "value": {"$keyring": "restish/svc-key"}}
+          ],
+          "tls_signer_params": {"pin": {"$cmd": ["pass", "show", "hsm-pin"]}},
+          "auth": {
+            "type": "http-basic",
+            "params": {
+              "username": "alice",
+              "password": {"$env": "SVC_PASSWORD"}
+            }
+          }
+        }
+      }
+    }
+  }
+}`)
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	prof := cfg.APIs["svc"].Profiles["default"]
+	ref, ok := config.ParseSecretRef(prof.Auth.Params["password"])
+	if !ok || ref.Env != "SVC_PASSWORD" || ref.Source() != "$env SVC_PASSWORD" {
+		t.Fatalf("password ref = %#v, %v", ref, ok)
+	}
+	if prof.Auth.Params["username"] != "alice" {
+		t.Fatalf("username = %q", prof.Auth.Params["username"])
+	}
+	name, headerRef, ok := config.SplitSecretRefHeader(prof.Headers[1])
+	if !ok || name != "X-API-Key" || headerRef.Keyring != "restish/svc-key" {
+		t.Fatalf("header ref = %q %#v %v", name, headerRef, ok)
+	}
+	if _, _, ok := config.SplitSecretRefHeader(prof.Headers[0]); ok {
+		t.Fatal("plain header parsed as a secret reference")
+	}
+	if ref, ok := config.ParseSecretRef(prof.TLSSignerParams["pin"]); !ok || strings.Join(ref.Cmd, " ") != "pass show hsm-pin" {
+		t.Fatalf("tls signer ref = %#v, %v", ref, ok)
+	}
+
+	if err := config.Save(path, cfg); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	saved, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	raw, _ := json.Marshal(saved.APIs["svc"].Profiles["default"])
+	for _, want := range []string{
+		`{"name":"X-API-Key","value":{"$keyring":"restish/svc-key"}}`,
+		`"password":{"$env":"SVC_PASSWORD"}`,
+		`"pin":{"$cmd":["pass","show","hsm-pin"]}`,
+	} {
+		if !strings.Contains(string(raw), want) {
+			t.Errorf("saved profile %s missing %s", raw, want)
+		}
+	}
+}
+
+func TestSecretReferencesRejectInvalidShapes(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"two kinds", `{"$env": "A", "$file": "b"}`, "exactly one"},
+		{"unknown kind", `{"$vault": "x"}`, "unknown field"},
+		{"empty", `{}`, "exactly one"},
+		{"keyring without account", `{"$keyring": "service"}`, "service/account"},
+		{"empty cmd", `{"$cmd": [""]}`, "program name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeConfig(t, `{"apis": {"svc": {"base_url": "https://api.example.com", "profiles": {"default": {"auth": {"type": "bearer", "params": {"token": `+tt.value+`}}}}}}}`)
+			_, err := config.Load(path)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Load err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSecretRefKeyringServiceSplitsAtLastSlash(t *testing.T) {
+	service, account, err := config.SecretRef{Keyring: "restish/api.example.com/alice"}.KeyringService()
+	if err != nil || service != "restish/api.example.com" || account != "alice" {
+		t.Fatalf("KeyringService = %q, %q, %v", service, account, err)
+	}
+}
+
+func TestSecretRefLiteralJSONStringIsNotAReference(t *testing.T) {
+	path := writeConfig(t, `{"apis": {"svc": {"base_url": "https://api.example.com", "profiles": {"default": {
+  "headers": ["X-Literal: {\"$env\": \"HOME\"}"],
+  "auth": {"type": "bearer", "params": {"token": "{\"$x\": 1}"}}}}}}}`)
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	prof := cfg.APIs["svc"].Profiles["default"]
+	if _, ok := config.ParseSecretRef(prof.Auth.Params["token"]); ok || prof.Auth.Params["token"] != `{"$x": 1}` {
+		t.Fatalf("token = %q parsed as a reference", prof.Auth.Params["token"])
+	}
+	if _, _, ok := config.SplitSecretRefHeader(prof.Headers[0]); ok {
+		t.Fatalf("header %q parsed as a reference", prof.Headers[0])
+	}
+	if _, ok := config.ParseSecretRef(`{"$env": "HOME"}`); ok {
+		t.Fatal("literal JSON parsed as a reference")
+	}
+}
@@ -27,9 +27,11 @@ require (
 	github.com/tidwall/jsonc v0.3.3
 	github.com/zeebo/xxh3 v1.0.2
 	go.yaml.in/yaml/v3 v3.0.4
+	golang.org/x/crypto v0.48.0
 	golang.org/x/net v0.50.0
 	golang.org/x/sys v0.42.0
 	golang.org/x/term v0.41.0
+	google.golang.org/protobuf v1.36.11
 )
 
 require (
@@ -30,5 +30,8 @@ func authParams(ac auth.AuthContext) map[string]string {
 	if ac.BaseURL != "" {
 		params["_base_url"] = ac.BaseURL
 	}
+	if ac.DPoPNonce != "" {
+		params[dpopNonceParam] = ac.DPoPNonce
+	}
 	return params
 }
@@ -0,0 +1,292 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"maps"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/rest-sh/restish/v2/auth"
+)
+
+const (
+	dpopTokenType  = "DPoP"
+	dpopNonceParam = "_dpop_nonce"
+	dpopDefaultAlg = "ES256"
+)
+
+// dpopSession is the DPoP key (RFC 9449) used for one authenticated request:
+// it signs proofs for the token endpoint and for the API request itself.
+type dpopSession struct {
+	alg     string
+	signer  crypto.Signer
+	encoded string
+	jwk     map[string]string
+	// tokenType is the type of the access token being presented. Providers
+	// that ignore DPoP issue Bearer tokens, which are sent without proofs.
+	tokenType string
+	// nonces is the latest DPoP-Nonce per origin for this credential. It is
+	// loaded from the cached token and saved back to it under cacheKey.
+	nonces   map[string]string
+	cache    auth.TokenStore
+	cacheKey string
+}
+
+type dpopContextKey struct{}
+
+func withDPoPSession(ctx context.Context, s *dpopSession) context.Context {
+	return context.WithValue(ctx, dpopContextKey{}, s)
+}
+
+func dpopSessionFromContext(ctx context.Context) *dpopSession {
+	if ctx == nil {
+		return nil
+	}
+	s, _ := ctx.Value(dpopContextKey{}).(*dpopSession)
+	return s
+}
+
+func appendOAuthDPoPParams(params []auth.Param) []auth.Param {
+	return append(params,
+		auth.Param{Name: "dpop", Description: "Set to \"true\" to request DPoP sender-constrained tokens (RFC 9449)", Required: false},
+		auth.Param{Name: "dpop_alg", Description: "DPoP key algorithm: ES256 (default), ES384, EdDSA, RS256, or PS256", Required: false},
+	)
+}
+
+// startDPoP attaches the profile's DPoP key to ctx when params enable DPoP or
+// the cached token is already DPoP-bound. The key is persisted with the cached
+// token, so refresh tokens stay usable across runs; a new key is generated
+// when there is none. Otherwise ctx is returned without a session, which also
+// hides any session inherited from a calling handler.
+func startDPoP(ctx context.Context, cache auth.TokenStore, params map[string]string) (context.Context, *dpopSession, error) {
+	var cached *auth.CachedToken
+	if cache != nil && params["_cache_key"] != "" {
+		cached, _ = cache.Get(params["_cache_key"])
+	}
+	enabled := strings.EqualFold(params["dpop"], "true")
+	if !enabled && (cached == nil || cached.DPoPKey == "") {
+		return withDPoPSession(ctx, nil), nil, nil
+	}
+	var s *dpopSession
+	if cached != nil && cached.DPoPKey != "" {
+		s, _ = parseDPoPKey(cached.DPoPKey, params["dpop_alg"])
+	}
+	if s == nil {
+		var err error
+		if s, err = newDPoPKey(params["dpop_alg"]); err != nil {
+			return ctx, nil, err
+		}
+	}
+	if cached != nil {
+		s.tokenType = cached.TokenType
+		s.nonces = maps.Clone(cached.DPoPNonces)
+	}
+	s.cache, s.cacheKey = cache, params["_cache_key"]
+	return withDPoPSession(ctx, s), s, nil
+}
+
+// newDPoPKey generates a key pair for alg.
+func newDPoPKey(alg string) (*dpopSession, error) {
+	if alg = strings.TrimSpace(alg); alg == "" {
+		alg = dpopDefaultAlg
+	}
+	var (
+		key any
+		err error
+	)
+	switch strings.ToUpper(alg) {
+	case "ES256":
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case "ES384":
+		key, err = ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case "EDDSA":
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	case "RS256", "PS256":
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+	default:
+		return nil, fmt.Errorf("dpop: unsupported dpop_alg %q", alg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("dpop: generating key: %w", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("dpop: encoding key: %w", err)
+	}
+	return parseDPoPKey(base64.StdEncoding.EncodeToString(der), alg)
+}
+
+// parseDPoPKey loads a persisted key. It fails when the key does not fit alg,
+// so changing dpop_alg rotates the key.
+func parseDPoPKey(encoded, alg string) (*dpopSession, error) {
+	der, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("dpop: decoding key: %w", err)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("dpop: parsing key: %w", err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("dpop: unsupported key type %T", key)
+	}
+	alg, err = jwsAlgForKey("dpop", "dpop_alg", alg, signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	jwk, err := publicJWK(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	return &dpopSession{alg: alg, signer: signer, encoded: encoded, jwk: jwk}, nil
+}
+
+// publicJWK returns the RFC 7517 public JWK for pub.
+func publicJWK(pub crypto.PublicKey) (map[string]string, error) {
+	b64 := base64.RawURLEncoding.EncodeToString
+	switch k := pub.(type) {
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		x, y := make([]byte, size), make([]byte, size)
+		k.X.FillBytes(x)
+		k.Y.FillBytes(y)
+		return map[string]string{"kty": "EC", "crv": k.Curve.Params().Name, "x": b64(x), "y": b64(y)}, nil
+	case *rsa.PublicKey:
+		return map[string]string{"kty": "RSA", "n": b64(k.N.Bytes()), "e": b64(big.NewInt(int64(k.E)).Bytes())}, nil
+	case ed25519.PublicKey:
+		return map[string]string{"kty": "OKP", "crv": "Ed25519", "x": b64(k)}, nil
+	default:
+		return nil, fmt.Errorf("dpop: unsupported key type %T", pub)
+	}
+}
+
+// proof returns a DPoP proof JWT for a request. accessToken is set for API
+// requests and bound through the ath claim; nonce is the server's latest
+// DPoP-Nonce for the origin, if any.
+func (s *dpopSession) proof(method string, target *url.URL, accessToken, nonce string) (string, error) {
+	jti, err := randomJTI()
+	if err != nil {
+		return "", fmt.Errorf("dpop: %w", err)
+	}
+	claims := map[string]any{
+		"jti": jti,
+		"htm": method,
+		"htu": dpopHTU(target),
+		"iat": time.Now().Unix(),
+	}
+	if accessToken != "" {
+		sum := sha256.Sum256([]byte(accessToken))
+		claims["ath"] = base64.RawURLEncoding.EncodeToString(sum[:])
+	}
+	if nonce != "" {
+		claims["nonce"] = nonce
+	}
+	header := map[string]any{"typ": "dpop+jwt", "alg": s.alg, "jwk": s.jwk}
+	proof, err := signCompactJWS(s.signer, s.alg, header, claims)
+	if err != nil {
+		return "", fmt.Errorf("dpop: signing proof: %w", err)
+	}
+	return proof, nil
+}
+
+// dpopHTU is the htu claim: the request URI without query and fragment.
+func dpopHTU(u *url.URL) string {
+	htu := *u
+	htu.User = nil
+	htu.RawQuery = ""
+	htu.ForceQuery = false
+	htu.Fragment = ""
+	htu.RawFragment = ""
+	return htu.String()
+}
+
+func dpopOrigin(u *url.URL) string {
+	return strings.ToLower(u.Scheme + "://" + u.Host)
+}
+
+// nonce returns the latest DPoP-Nonce u's origin issued to this credential.
+func (s *dpopSession) nonce(u *url.URL) string {
+	return s.nonces[dpopOrigin(u)]
+}
+
+// rememberNonce records a DPoP-Nonce from u's origin and reports whether it
+// changed. Token requests only record nonces: they can run inside a token
+// cache refresh, and the token they return carries the nonces anyway.
+func (s *dpopSession) rememberNonce(u *url.URL, nonce string) bool {
+	origin := dpopOrigin(u)
+	if nonce = strings.TrimSpace(nonce); nonce == "" || s.nonces[origin] == nonce {
+		return false
+	}
+	if s.nonces == nil {
+		s.nonces = make(map[string]string)
+	}
+	s.nonces[origin] = nonce
+	return true
+}
+
+// saveNonces stores the session's nonces with the cached token bound to its
+// key, if there is one.
+func (s *dpopSession) saveNonces() {
+	if s.cache == nil || s.cacheKey == "" {
+		return
+	}
+	cached, err := s.cache.Get(s.cacheKey)
+	if err != nil || cached == nil || cached.DPoPKey != s.encoded {
+		return
+	}
+	cached.DPoPNonces = maps.Clone(s.nonces)
+	_ = s.cache.Set(s.cacheKey, *cached)
+}
+
+// applyAccessToken adds token to req as a DPoP-bound token with a proof when
+// the session holds a DPoP token, or as a bearer token otherwise.
+func applyAccessToken(req *http.Request, s *dpopSession, token string, params map[string]string) error {
+	if s == nil || !strings.EqualFold(s.tokenType, dpopTokenType) {
+		bearerAuth(req, token)
+		return nil
+	}
+	if getHeaderCaseInsensitive(req.Header, "Authorization") != "" {
+		return nil
+	}
+	if s.rememberNonce(req.URL, params[dpopNonceParam]) {
+		s.saveNonces()
+	}
+	proof, err := s.proof(req.Method, req.URL, token, s.nonce(req.URL))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", dpopTokenType+" "+token)
+	req.Header.Set("DPoP", proof)
+	return nil
+}
+
+// oauthForce reports whether an unauthorized retry should replace the cached
+// token. A 401 that only asks for a fresh DPoP nonce is answered with a new
+// proof for the same token.
+func oauthForce(ac auth.AuthContext) bool {
+	if !ac.Force || ac.DPoPNonce == "" {
+		return ac.Force
+	}
+	for _, value := range ac.Challenges {
+		for _, challenge := range parseAuthChallenges(value) {
+			if strings.EqualFold(challenge.scheme, dpopTokenType) && challenge.params["error"] == "use_dpop_nonce" {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,219 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/base64"
+	"math/big"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rest-sh/restish/v2/auth"
+)
+
+// verifyDPoPProof checks an ES256 proof against its embedded JWK and returns
+// its claims.
+func verifyDPoPProof(t *testing.T, proof string) map[string]any {
+	t.Helper()
+	parts := strings.Split(proof, ".")
+	if len(parts) != 3 {
+		t.Fatalf("proof = %q", proof)
+	}
+	var header struct {
+		Typ string            `json:"typ"`
+		Alg string            `json:"alg"`
+		JWK map[string]string `json:"jwk"`
+	}
+	var claims map[string]any
+	decodeTestJWTPart(t, parts[0], &header)
+	decodeTestJWTPart(t, parts[1], &claims)
+	if header.Typ != "dpop+jwt" || header.Alg != "ES256" || header.JWK["kty"] != "EC" || header.JWK["d"] != "" {
+		t.Fatalf("header = %#v", header)
+	}
+	x, _ := base64.RawURLEncoding.DecodeString(header.JWK["x"])
+	y, _ := base64.RawURLEncoding.DecodeString(header.JWK["y"])
+	pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+	sig, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if len(sig) != 64 || !ecdsa.Verify(pub, digest[:], new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])) {
+		t.Fatal("DPoP proof signature does not verify")
+	}
+	return claims
+}
+
+func TestClientCredentials_DPoPBindsTokenAndSignsRequests(t *testing.T) {
+	var tokenProofs []map[string]any
+	h := &ClientCredentials{
+		Cache: auth.NewTokenCache(filepath.Join(t.TempDir(), "tokens.json")),
+		HTTPClient: testHTTPClient(func(r *http.Request) (*http.Response, error) {
+			tokenProofs = append(tokenProofs, verifyDPoPProof(t, r.Header.Get("DPoP")))
+			if len(tokenProofs) == 1 {
+				resp := testResponse(400, "application/json", `{"error":"use_dpop_nonce"}`)
+				resp.Header.Set("DPoP-Nonce", "token-nonce")
+				return resp, nil
+			}
+			return testResponse(200, "application/json", `{"access_token":"bound","token_type":"DPoP","expires_in":600}`), nil
+		}),
+	}
+	params := map[string]string{
+		"client_id":     "svc",
+		"client_secret": "sec",
+		"dpop":          "true",
+		"token_url":     "https://dpop-auth.example.com/token",
+	}
+	ac := auth.AuthContext{CacheKey: "dpop-test", Params: params}
+	req, _ := http.NewRequest("GET", "https://dpop-api.example.com/items?page=2", nil)
+	if err := h.Authenticate(req.Context(), req, ac); err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+
+	if len(tokenProofs) != 2 || tokenProofs[0]["nonce"] != nil || tokenProofs[1]["nonce"] != "token-nonce" {
+		t.Fatalf("token proofs = %#v", tokenProofs)
+	}
+	if tokenProofs[1]["htm"] != "POST" || tokenProofs[1]["htu"] != "https://dpop-auth.example.com/token" || tokenProofs[1]["ath"] != nil {
+		t.Fatalf("token proof claims = %#v", tokenProofs[1])
+	}
+	if got := req.Header.Get("Authorization"); got != "DPoP bound" {
+		t.Fatalf("Authorization = %q", got)
+	}
+	claims := verifyDPoPProof(t, req.Header.Get("DPoP"))
+	sum := sha256.Sum256([]byte("bound"))
+	if claims["htm"] != "GET" || claims["htu"] != "https://dpop-api.example.com/items" || claims["ath"] != base64.RawURLEncoding.EncodeToString(sum[:]) {
+		t.Fatalf("request proof claims = %#v", claims)
+	}
+	cached, err := h.Cache.Get("dpop-test")
+	if err != nil || cached == nil || cached.TokenType != "DPoP" || cached.DPoPKey == "" ||
+		cached.DPoPNonces["https://dpop-auth.example.com"] != "token-nonce" {
+		t.Fatalf("cached = %#v, err = %v", cached, err)
+	}
+
+	// A nonce challenge from the API re-signs with the cached token and key.
+	retry, _ := http.NewRequest("GET", "https://dpop-api.example.com/items", nil)
+	ac.Force = true
+	ac.Challenges = []string{`DPoP error="use_dpop_nonce", error_description="nonce required"`}
+	ac.DPoPNonce = "api-nonce"
+	if err := h.Authenticate(retry.Context(), retry, ac); err != nil {
+		t.Fatalf("Authenticate retry: %v", err)
+	}
+	if len(tokenProofs) != 2 {
+		t.Fatalf("nonce retry fetched a new token: %d token requests", len(tokenProofs))
+	}
+	retryClaims := verifyDPoPProof(t, retry.Header.Get("DPoP"))
+	if retry.Header.Get("Authorization") != "DPoP bound" || retryClaims["nonce"] != "api-nonce" {
+		t.Fatalf("retry Authorization = %q, claims = %#v", retry.Header.Get("Authorization"), retryClaims)
+	}
+	if claims["jti"] == retryClaims["jti"] {
+		t.Fatal("DPoP proofs reused a jti")
+	}
+
+	// Later requests with the same credential send the nonce up front.
+	next, _ := http.NewRequest("GET", "https://dpop-api.example.com/items", nil)
+	if err := h.Authenticate(next.Context(), next, auth.AuthContext{CacheKey: "dpop-test", Params: params}); err != nil {
+		t.Fatalf("Authenticate next: %v", err)
+	}
+	if nextClaims := verifyDPoPProof(t, next.Header.Get("DPoP")); nextClaims["nonce"] != "api-nonce" {
+		t.Fatalf("next request claims = %#v", nextClaims)
+	}
+}
+
+func TestDPoPNoncesAreKeptPerCredential(t *testing.T) {
+	var tokenProofs []map[string]any
+	h := &ClientCredentials{
+		Cache: auth.NewTokenCache(filepath.Join(t.TempDir(), "tokens.json")),
+		HTTPClient: testHTTPClient(func(r *http.Request) (*http.Response, error) {
+			tokenProofs = append(tokenProofs, verifyDPoPProof(t, r.Header.Get("DPoP")))
+			resp := testResponse(200, "application/json", `{"access_token":"bound","token_type":"DPoP","expires_in":600}`)
+			resp.Header.Set("DPoP-Nonce", "nonce-"+r.PostFormValue("client_id"))
+			return resp, nil
+		}),
+	}
+	for _, client := range []string{"alice", "bob"} {
+		params := map[string]string{
+			"client_id":     client,
+			"client_secret": "sec",
+			"dpop":          "true",
+			"token_url":     "https://dpop-shared.example.com/token",
+		}
+		req, _ := http.NewRequest("GET", "https://dpop-shared.example.com/items", nil)
+		if err := h.Authenticate(req.Context(), req, auth.AuthContext{CacheKey: client, Params: params}); err != nil {
+			t.Fatalf("Authenticate %s: %v", client, err)
+		}
+		if claims := verifyDPoPProof(t, req.Header.Get("DPoP")); claims["nonce"] != "nonce-"+client {
+			t.Fatalf("%s request claims = %#v", client, claims)
+		}
+	}
+	if len(tokenProofs) != 2 || tokenProofs[1]["nonce"] != nil {
+		t.Fatalf("second credential reused the first credential's nonce: %#v", tokenProofs)
+	}
+}
+
+func TestClientCredentials_DPoPFallsBackToBearerTokens(t *testing.T) {
+	h := &ClientCredentials{HTTPClient: testHTTPClient(func(r *http.Request) (*http.Response, error) {
+		if r.Header.Get("DPoP") == "" {
+			t.Fatal("token request has no DPoP proof")
+		}
+		return testResponse(200, "application/json", `{"access_token":"plain","token_type":"Bearer"}`), nil
+	})}
+	req, _ := http.NewRequest("GET", "https://api.example.com/items", nil)
+	err := h.OnRequest(req, map[string]string{"client_id": "svc", "client_secret": "sec", "dpop": "true", "token_url": "https://auth.example.com/token"})
+	if err != nil {
+		t.Fatalf("OnRequest: %v", err)
+	}
+	if req.Header.Get("Authorization") != "Bearer plain" || req.Header.Get("DPoP") != "" {
+		t.Fatalf("headers = %#v", req.Header)
+	}
+}
+
+func TestFetchTokenRejectsDPoPTokenWithoutProof(t *testing.T) {
+	client := testHTTPClient(func(r *http.Request) (*http.Response, error) {
+		return testResponse(200, "application/json", `{"access_token":"bound","token_type":"DPoP"}`), nil
+	})
+	_, err := FetchToken(context.Background(), client, "https://auth.example.com/token", nil, nil)
+	if err == nil || !strings.Contains(err.Error(), `unsupported token_type "DPoP"`) {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestOAuthForceKeepsTokenForDPoPNonceChallenge(t *testing.T) {
+	tests := []struct {
+		name string
+		ac   auth.AuthContext
+		want bool
+	}{
+		{"not forced", auth.AuthContext{}, false},
+		{"no nonce", auth.AuthContext{Force: true, Challenges: []string{`DPoP error="use_dpop_nonce"`}}, true},
+		{"nonce challenge", auth.AuthContext{Force: true, DPoPNonce: "n", Challenges: []string{`Bearer realm="x", DPoP error="use_dpop_nonce"`}}, false},
+		{"invalid token", auth.AuthContext{Force: true, DPoPNonce: "n", Challenges: []string{`DPoP error="invalid_token"`}}, true},
+	}
+	for _, tt := range tests {
+		if got := oauthForce(tt.ac); got != tt.want {
+			t.Errorf("%s: oauthForce = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDPoPKeyRotatesWhenAlgorithmChanges(t *testing.T) {
+	s, err := newDPoPKey("")
+	if err != nil {
+		t.Fatalf("newDPoPKey: %v", err)
+	}
+	if _, err := parseDPoPKey(s.encoded, "ES256"); err != nil {
+		t.Fatalf("parseDPoPKey: %v", err)
+	}
+	if _, err := parseDPoPKey(s.encoded, "EdDSA"); err == nil {
+		t.Fatal("expected an ES256 key to be rejected for EdDSA")
+	}
+	for _, alg := range []string{"ES384", "EdDSA", "PS256"} {
+		key, err := newDPoPKey(alg)
+		if err != nil || key.alg != alg {
+			t.Fatalf("newDPoPKey(%s) = %#v, %v", alg, key, err)
+		}
+	}
+	if _, err := newDPoPKey("HS256"); err == nil {
+		t.Fatal("expected HS256 to be rejected")
+	}
+}
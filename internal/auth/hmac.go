@@ -0,0 +1,319 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rest-sh/restish/v2/auth"
+)
+
+// HMAC signs requests with a shared secret over a declarative canonical
+// string. It covers the many vendor-specific schemes that hash some mix of
+// timestamp, nonce, method, path, query, and body without needing a plugin
+// executable per vendor.
+//
+// Config params:
+//
+//	secret            (required) shared secret
+//	canonical         (required) canonical string template, see below
+//	secret_encoding   (optional) raw (default), base64, or hex
+//	algorithm         (optional) sha256 (default), sha384, sha512, or sha1
+//	encoding          (optional) signature encoding: hex (default), base64,
+//	                  or base64url
+//	signature_header  (one of)   header that receives the signature
+//	signature_query   (one of)   query param that receives the signature
+//	signature_format  (optional) template for the sent value; defaults to
+//	                  {signature}
+//	timestamp_header / timestamp_query   (optional) where to send {timestamp}
+//	timestamp_format  (optional) unix (default), unix_ms, rfc3339, or http
+//	nonce_header / nonce_query           (optional) where to send {nonce}
+//
+// Templates expand {method}, {host}, {path}, {query}, {sorted_query}, {url},
+// {body}, {body_sha256}, {body_sha512}, {body_md5}, {timestamp}, {nonce},
+// {header:Name}, and {param:name} (another auth param such as an API key).
+// A literal backslash-n or backslash-t in a template is a newline or tab, so
+// templates can be written on one line with `api set`. Unknown placeholders
+// are errors so a typo never silently signs the wrong string.
+type HMAC struct {
+	// Now returns the signing time. Defaults to time.Now.
+	Now func() time.Time
+	// Nonce returns a fresh nonce. Defaults to 16 random bytes, hex encoded.
+	Nonce func() (string, error)
+}
+
+func (h *HMAC) Parameters() []auth.Param {
+	return []auth.Param{
+		{Name: "secret", Description: "Shared HMAC secret", Required: true, Secret: true},
+		{Name: "canonical", Description: "Canonical string template, e.g. {timestamp}\\n{method}\\n{path}\\n{body_sha256}", Required: true},
+		{Name: "secret_encoding", Description: "Secret encoding: raw (default), base64, or hex"},
+		{Name: "algorithm", Description: "HMAC hash: sha256 (default), sha384, sha512, or sha1"},
+		{Name: "encoding", Description: "Signature encoding: hex (default), base64, or base64url"},
+		{Name: "signature_header", Description: "Header that receives the signature"},
+		{Name: "signature_query", Description: "Query parameter that receives the signature"},
+		{Name: "signature_format", Description: "Template for the sent signature value (default {signature})"},
+		{Name: "timestamp_header", Description: "Header that receives the timestamp"},
+		{Name: "timestamp_query", Description: "Query parameter that receives the timestamp"},
+		{Name: "timestamp_format", Description: "Timestamp format: unix (default), unix_ms, rfc3339, or http"},
+		{Name: "nonce_header", Description: "Header that receives the nonce"},
+		{Name: "nonce_query", Description: "Query parameter that receives the nonce"},
+	}
+}
+
+func (h *HMAC) Authenticate(_ context.Context, req *http.Request, ac auth.AuthContext) error {
+	return h.sign(req, ac.Params)
+}
+
+func (h *HMAC) sign(req *http.Request, params map[string]string) error {
+	if params["secret"] == "" {
+		return fmt.Errorf("hmac: secret is required")
+	}
+	canonical := params["canonical"]
+	if canonical == "" {
+		return fmt.Errorf("hmac: canonical is required")
+	}
+	sigHeader := params["signature_header"]
+	sigQuery := params["signature_query"]
+	if sigHeader == "" && sigQuery == "" {
+		return fmt.Errorf("hmac: signature_header or signature_query is required")
+	}
+	secret, err := decodeSharedSecret("hmac", []byte(params["secret"]), params["secret_encoding"])
+	if err != nil {
+		return err
+	}
+	newHash, err := hmacHashFunc(params["algorithm"])
+	if err != nil {
+		return err
+	}
+	encode, err := hmacEncoder(params["encoding"])
+	if err != nil {
+		return err
+	}
+
+	now := time.Now
+	if h.Now != nil {
+		now = h.Now
+	}
+	timestamp, err := hmacTimestamp(now(), params["timestamp_format"])
+	if err != nil {
+		return err
+	}
+	nonce := ""
+	if templateUses(params, "{nonce}") || params["nonce_header"] != "" || params["nonce_query"] != "" {
+		newNonce := h.Nonce
+		if newNonce == nil {
+			newNonce = randomHMACNonce
+		}
+		if nonce, err = newNonce(); err != nil {
+			return fmt.Errorf("hmac: generating nonce: %w", err)
+		}
+	}
+	body, err := signingRequestBody("hmac", req)
+	if err != nil {
+		return err
+	}
+
+	// Timestamp and nonce land on the request first so templates that cover
+	// the query string or those headers see the values actually sent.
+	setHMACValue(req, params["timestamp_header"], params["timestamp_query"], timestamp)
+	if nonce != "" {
+		setHMACValue(req, params["nonce_header"], params["nonce_query"], nonce)
+	}
+
+	vars := hmacTemplateVars{req: req, params: params, body: body, timestamp: timestamp, nonce: nonce}
+	message, err := vars.expand(canonical)
+	if err != nil {
+		return err
+	}
+	mac := hmac.New(newHash, secret)
+	mac.Write([]byte(message))
+	vars.signature = encode(mac.Sum(nil))
+
+	format := params["signature_format"]
+	if format == "" {
+		format = "{signature}"
+	}
+	value, err := vars.expand(format)
+	if err != nil {
+		return err
+	}
+	if sigHeader != "" {
+		req.Header.Set(sigHeader, value)
+	}
+	if sigQuery != "" {
+		q := req.URL.Query()
+		q.Set(sigQuery, value)
+		req.URL.RawQuery = q.Encode()
+	}
+	return nil
+}
+
+func templateUses(params map[string]string, placeholder string) bool {
+	return strings.Contains(params["canonical"], placeholder) || strings.Contains(params["signature_format"], placeholder)
+}
+
+func setHMACValue(req *http.Request, header, query, value string) {
+	if header != "" {
+		req.Header.Set(header, value)
+	}
+	if query != "" {
+		q := req.URL.Query()
+		q.Set(query, value)
+		req.URL.RawQuery = q.Encode()
+	}
+}
+
+func hmacHashFunc(name string) (func() hash.Hash, error) {
+	switch strings.ToLower(strings.ReplaceAll(strings.TrimSpace(name), "-", "")) {
+	case "", "sha256":
+		return sha256.New, nil
+	case "sha384":
+		return sha512.New384, nil
+	case "sha512":
+		return sha512.New, nil
+	case "sha1":
+		return sha1.New, nil
+	default:
+		return nil, fmt.Errorf("hmac: unsupported algorithm %q (supported: sha256, sha384, sha512, sha1)", name)
+	}
+}
+
+func hmacEncoder(name string) (func([]byte) string, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "hex":
+		return hex.EncodeToString, nil
+	case "base64":
+		return base64.StdEncoding.EncodeToString, nil
+	case "base64url":
+		return base64.RawURLEncoding.EncodeToString, nil
+	default:
+		return nil, fmt.Errorf("hmac: unsupported encoding %q (supported: hex, base64, base64url)", name)
+	}
+}
+
+func hmacTimestamp(now time.Time, format string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "", "unix":
+		return strconv.FormatInt(now.Unix(), 10), nil
+	case "unix_ms":
+		return strconv.FormatInt(now.UnixMilli(), 10), nil
+	case "rfc3339":
+		return now.UTC().Format(time.RFC3339), nil
+	case "http":
+		return now.UTC().Format(http.TimeFormat), nil
+	default:
+		return "", fmt.Errorf("hmac: unsupported timestamp_format %q (supported: unix, unix_ms, rfc3339, http)", format)
+	}
+}
+
+func randomHMACNonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+type hmacTemplateVars struct {
+	req       *http.Request
+	params    map[string]string
+	body      []byte
+	timestamp string
+	nonce     string
+	signature string
+}
+
+func (v hmacTemplateVars) expand(template string) (string, error) {
+	template = strings.NewReplacer(`\n`, "\n", `\t`, "\t").Replace(template)
+	var out strings.Builder
+	for i := 0; i < len(template); {
+		if template[i] != '{' {
+			out.WriteByte(template[i])
+			i++
+			continue
+		}
+		end := strings.IndexByte(template[i+1:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("hmac: unterminated placeholder in template %q", template)
+		}
+		name := template[i+1 : i+1+end]
+		value, err := v.lookup(name)
+		if err != nil {
+			return "", err
+		}
+		out.WriteString(value)
+		i += end + 2
+	}
+	return out.String(), nil
+}
+
+func (v hmacTemplateVars) lookup(name string) (string, error) {
+	switch name {
+	case "method":
+		return strings.ToUpper(v.req.Method), nil
+	case "host":
+		if v.req.Host != "" {
+			return v.req.Host, nil
+		}
+		return v.req.URL.Host, nil
+	case "path":
+		if p := v.req.URL.EscapedPath(); p != "" {
+			return p, nil
+		}
+		return "/", nil
+	case "query":
+		return v.req.URL.RawQuery, nil
+	case "sorted_query":
+		// url.Values.Encode sorts by key.
+		return v.req.URL.Query().Encode(), nil
+	case "url":
+		return v.req.URL.String(), nil
+	case "body":
+		return string(v.body), nil
+	case "body_sha256":
+		sum := sha256.Sum256(v.body)
+		return hex.EncodeToString(sum[:]), nil
+	case "body_sha512":
+		sum := sha512.Sum512(v.body)
+		return hex.EncodeToString(sum[:]), nil
+	case "body_md5":
+		sum := md5.Sum(v.body)
+		return hex.EncodeToString(sum[:]), nil
+	case "timestamp":
+		return v.timestamp, nil
+	case "nonce":
+		return v.nonce, nil
+	case "signature":
+		if v.signature == "" {
+			return "", fmt.Errorf("hmac: {signature} is only available in signature_format")
+		}
+		return v.signature, nil
+	}
+	if header, ok := strings.CutPrefix(name, "header:"); ok {
+		return getHeaderCaseInsensitive(v.req.Header, header), nil
+	}
+	if param, ok := strings.CutPrefix(name, "param:"); ok {
+		switch param {
+		case "secret", "_cache_key", "_base_url":
+			return "", fmt.Errorf("hmac: {param:%s} cannot be used in templates", param)
+		}
+		value, ok := v.params[param]
+		if !ok {
+			return "", fmt.Errorf("hmac: template references unset param %q", param)
+		}
+		return value, nil
+	}
+	return "", fmt.Errorf("hmac: unknown template placeholder {%s}", name)
+}
@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/rest-sh/restish/v2/auth"
+)
+
+func fixedHMACNonce() (string, error) { return "n0nce", nil }
+
+func expectedHMAC(secret, message string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(message))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestHMACSignsCanonicalStringIntoHeaders(t *testing.T) {
+	req, _ := http.NewRequest("POST", "https://api.exchange.test/v1/orders?symbol=BTC", strings.NewReader(`{"qty":1}`))
+	h := &HMAC{Now: fixedSignatureTime, Nonce: fixedHMACNonce}
+	err := h.Authenticate(context.Background(), req, auth.AuthContext{Params: map[string]string{
+		"secret":           "s3cr3t",
+		"canonical":        `{timestamp}\n{nonce}\n{method}\n{path}?{query}\n{body_sha256}`,
+		"signature_header": "X-Signature",
+		"timestamp_header": "X-Timestamp",
+		"nonce_header":     "X-Nonce",
+	}})
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	bodySum := sha256.Sum256([]byte(`{"qty":1}`))
+	message := "1618884473\nn0nce\nPOST\n/v1/orders?symbol=BTC\n" + hex.EncodeToString(bodySum[:])
+	if got, want := req.Header.Get("X-Signature"), expectedHMAC("s3cr3t", message); got != want {
+		t.Fatalf("X-Signature = %q, want %q", got, want)
+	}
+	if got := req.Header.Get("X-Timestamp"); got != "1618884473" {
+		t.Fatalf("X-Timestamp = %q", got)
+	}
+	if got := req.Header.Get("X-Nonce"); got != "n0nce" {
+		t.Fatalf("X-Nonce = %q", got)
+	}
+}
+
+func TestHMACQueryPlacementAndSignatureFormat(t *testing.T) {
+	req, _ := http.NewRequest("GET", "https://api.exchange.test/v1/balance?b=2&a=1", nil)
+	h := &HMAC{Now: fixedSignatureTime}
+	err := h.Authenticate(context.Background(), req, auth.AuthContext{Params: map[string]string{
+		"secret":           base64.StdEncoding.EncodeToString([]byte("raw-secret")),
+		"secret_encoding":  "base64",
+		"algorithm":        "sha512",
+		"encoding":         "base64",
+		"canonical":        "{method} {sorted_query}",
+		"timestamp_query":  "ts",
+		"timestamp_format": "unix_ms",
+		"signature_header": "Authorization",
+		"signature_format": "HMAC {param:api_key}:{signature}",
+		"api_key":          "AK123",
+	}})
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if got := req.URL.Query().Get("ts"); got != "1618884473000" {
+		t.Fatalf("ts = %q", got)
+	}
+	mac := hmac.New(sha512.New, []byte("raw-secret"))
+	mac.Write([]byte("GET a=1&b=2&ts=1618884473000"))
+	want := "HMAC AK123:" + base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Fatalf("Authorization = %q, want %q", got, want)
+	}
+}
+
+func TestHMACSignatureQueryParam(t *testing.T) {
+	req, _ := http.NewRequest("GET", "https://api.example.test/items", nil)
+	req.Header.Set("X-Client", "cli")
+	err := (&HMAC{Now: fixedSignatureTime}).Authenticate(context.Background(), req, auth.AuthContext{Params: map[string]string{
+		"secret":          "k",
+		"canonical":       "{host}|{header:x-client}|{timestamp}",
+		"signature_query": "sig",
+	}})
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if got, want := req.URL.Query().Get("sig"), expectedHMAC("k", "api.example.test|cli|1618884473"); got != want {
+		t.Fatalf("sig = %q, want %q", got, want)
+	}
+}
+
+func TestHMACErrors(t *testing.T) {
+	base := func(extra map[string]string) map[string]string {
+		params := map[string]string{"secret": "k", "canonical": "{method}", "signature_header": "X-Sig"}
+		for k, v := range extra {
+			params[k] = v
+		}
+		return params
+	}
+	tests := []struct {
+		name   string
+		params map[string]string
+		want   string
+	}{
+		{"missing secret", map[string]string{"canonical": "{method}", "signature_header": "X-Sig"}, "secret is required"},
+		{"missing canonical", map[string]string{"secret": "k", "signature_header": "X-Sig"}, "canonical is required"},
+		{"missing target", map[string]string{"secret": "k", "canonical": "{method}"}, "signature_header or signature_query is required"},
+		{"unknown placeholder", base(map[string]string{"canonical": "{methd}"}), "unknown template placeholder {methd}"},
+		{"unterminated", base(map[string]string{"canonical": "{method"}), "unterminated placeholder"},
+		{"unset param", base(map[string]string{"canonical": "{param:api_key}"}), `unset param "api_key"`},
+		{"secret param", base(map[string]string{"canonical": "{param:secret}"}), "cannot be used in templates"},
+		{"signature in canonical", base(map[string]string{"canonical": "{signature}"}), "only available in signature_format"},
+		{"bad algorithm", base(map[string]string{"algorithm": "md4"}), "unsupported algorithm"},
+		{"bad encoding", base(map[string]string{"encoding": "base32"}), "unsupported encoding"},
+		{"bad timestamp", base(map[string]string{"timestamp_format": "iso"}), "unsupported timestamp_format"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "https://example.com/items", nil)
+			err := (&HMAC{}).Authenticate(context.Background(), req, auth.AuthContext{Params: tt.params})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
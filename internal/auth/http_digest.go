@@ -0,0 +1,390 @@
+package auth
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/rest-sh/restish/v2/auth"
+)
+
+// HTTPDigest implements HTTP Digest access authentication (RFC 7616) with
+// MD5, SHA-256, and SHA-512-256, their -sess variants, and qop=auth or
+// auth-int.
+//
+// Digest is challenge driven: the first request goes out without credentials,
+// and the handler answers the 401 challenge through the one-shot
+// unauthorized-retry path (Force with AuthContext.Challenges). The accepted
+// challenge is remembered per cache key for the life of the handler, so later
+// requests in the same invocation, such as pagination, authenticate up front
+// with an incrementing nonce count instead of taking another 401.
+type HTTPDigest struct {
+	// Prompter is called when "password" is absent from params. If nil, the
+	// AuthContext prompter is used.
+	Prompter func(prompt string) (string, error)
+	// CNonce returns a fresh client nonce. Defaults to 16 random bytes, hex
+	// encoded.
+	CNonce func() (string, error)
+
+	mu       sync.Mutex
+	sessions map[string]*digestSession
+}
+
+type digestChallenge struct {
+	realm     string
+	nonce     string
+	opaque    string
+	algorithm string
+	qop       []string
+	userhash  bool
+}
+
+type digestSession struct {
+	challenge digestChallenge
+	nc        uint32
+	// password caches a prompted password so later requests in the same
+	// invocation do not prompt again.
+	password string
+}
+
+func (h *HTTPDigest) Parameters() []auth.Param {
+	return []auth.Param{
+		{Name: "username", Description: "HTTP Digest auth username", Required: true},
+		{Name: "password", Description: "HTTP Digest auth password (prompted if omitted)", Required: false, Secret: true},
+		{Name: "qop", Description: "Preferred quality of protection when the server offers both: auth (default) or auth-int", Required: false},
+	}
+}
+
+func (h *HTTPDigest) SupportsForce() {}
+
+func (h *HTTPDigest) Authenticate(_ context.Context, req *http.Request, ac auth.AuthContext) error {
+	user := ac.Params["username"]
+	if user == "" {
+		return fmt.Errorf("http-digest: username is required")
+	}
+	if getHeaderCaseInsensitive(req.Header, "Authorization") != "" {
+		return nil
+	}
+	key := authParams(ac)["_cache_key"]
+
+	if ac.Force {
+		challenge, ok := selectDigestChallenge(ac.Challenges)
+		if !ok {
+			return fmt.Errorf("http-digest: server did not send a supported Digest challenge")
+		}
+		h.storeSession(key, challenge)
+	}
+	challenge, nc, cachedPass, ok := h.nextNonceCount(key)
+	if !ok {
+		// No challenge yet: send the request bare and answer the 401.
+		return nil
+	}
+
+	pass := ac.Params["password"]
+	if pass == "" {
+		pass = cachedPass
+	}
+	if pass == "" {
+		prompter := h.Prompter
+		if prompter == nil && ac.Prompter != nil {
+			prompter = ac.Prompter.PromptSecret
+		}
+		if prompter == nil {
+			return fmt.Errorf("http-digest: password is required (no prompter configured)")
+		}
+		var err error
+		pass, err = prompter("Password: ")
+		if err != nil {
+			return fmt.Errorf("http-digest: prompting for password: %w", err)
+		}
+		h.rememberPassword(key, pass)
+	}
+
+	value, err := h.authorization(req, challenge, nc, user, pass, ac.Params["qop"])
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", value)
+	return nil
+}
+
+func (h *HTTPDigest) storeSession(key string, challenge digestChallenge) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.sessions == nil {
+		h.sessions = map[string]*digestSession{}
+	}
+	password := ""
+	if previous := h.sessions[key]; previous != nil {
+		password = previous.password
+	}
+	h.sessions[key] = &digestSession{challenge: challenge, password: password}
+}
+
+func (h *HTTPDigest) nextNonceCount(key string) (digestChallenge, uint32, string, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	session := h.sessions[key]
+	if session == nil {
+		return digestChallenge{}, 0, "", false
+	}
+	session.nc++
+	return session.challenge, session.nc, session.password, true
+}
+
+func (h *HTTPDigest) rememberPassword(key, password string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if session := h.sessions[key]; session != nil {
+		session.password = password
+	}
+}
+
+func (h *HTTPDigest) authorization(req *http.Request, c digestChallenge, nc uint32, user, pass, preferredQOP string) (string, error) {
+	newHash, sess := digestHashFunc(c.algorithm)
+	hexHash := func(s string) string {
+		hh := newHash()
+		hh.Write([]byte(s))
+		return hex.EncodeToString(hh.Sum(nil))
+	}
+
+	qop := chooseDigestQOP(c.qop, preferredQOP)
+	cnonce := ""
+	if qop != "" || sess {
+		newCNonce := h.CNonce
+		if newCNonce == nil {
+			newCNonce = randomDigestCNonce
+		}
+		var err error
+		if cnonce, err = newCNonce(); err != nil {
+			return "", fmt.Errorf("http-digest: generating cnonce: %w", err)
+		}
+	}
+
+	uri := req.URL.RequestURI()
+	ha1 := hexHash(user + ":" + c.realm + ":" + pass)
+	if sess {
+		ha1 = hexHash(ha1 + ":" + c.nonce + ":" + cnonce)
+	}
+	ha2 := hexHash(req.Method + ":" + uri)
+	if qop == "auth-int" {
+		body, err := signingRequestBody("http-digest", req)
+		if err != nil {
+			return "", err
+		}
+		ha2 = hexHash(req.Method + ":" + uri + ":" + hexHash(string(body)))
+	}
+	ncValue := fmt.Sprintf("%08x", nc)
+	var response string
+	if qop == "" {
+		response = hexHash(ha1 + ":" + c.nonce + ":" + ha2)
+	} else {
+		response = hexHash(ha1 + ":" + c.nonce + ":" + ncValue + ":" + cnonce + ":" + qop + ":" + ha2)
+	}
+
+	username := user
+	if c.userhash {
+		username = hexHash(user + ":" + c.realm)
+	}
+	parts := []string{
+		"username=" + quoteDigestValue(username),
+		"realm=" + quoteDigestValue(c.realm),
+		"uri=" + quoteDigestValue(uri),
+		"algorithm=" + c.algorithm,
+		"nonce=" + quoteDigestValue(c.nonce),
+	}
+	if qop != "" {
+		parts = append(parts, "nc="+ncValue, "cnonce="+quoteDigestValue(cnonce), "qop="+qop)
+	}
+	parts = append(parts, "response="+quoteDigestValue(response))
+	if c.opaque != "" {
+		parts = append(parts, "opaque="+quoteDigestValue(c.opaque))
+	}
+	if c.userhash {
+		parts = append(parts, "userhash=true")
+	}
+	return "Digest " + strings.Join(parts, ", "), nil
+}
+
+func digestHashFunc(algorithm string) (func() hash.Hash, bool) {
+	upper := strings.ToUpper(algorithm)
+	sess := strings.HasSuffix(upper, "-SESS")
+	switch strings.TrimSuffix(upper, "-SESS") {
+	case "SHA-256":
+		return sha256.New, sess
+	case "SHA-512-256":
+		return sha512.New512_256, sess
+	default:
+		return md5.New, sess
+	}
+}
+
+func digestAlgorithmRank(algorithm string) int {
+	switch strings.TrimSuffix(strings.ToUpper(algorithm), "-SESS") {
+	case "SHA-512-256":
+		return 3
+	case "SHA-256":
+		return 2
+	case "MD5":
+		return 1
+	default:
+		return 0
+	}
+}
+
+func chooseDigestQOP(offered []string, preferred string) string {
+	if len(offered) == 0 {
+		return ""
+	}
+	preferred = strings.ToLower(strings.TrimSpace(preferred))
+	if preferred == "" {
+		preferred = "auth"
+	}
+	for _, q := range offered {
+		if q == preferred {
+			return q
+		}
+	}
+	for _, q := range offered {
+		if q == "auth" || q == "auth-int" {
+			return q
+		}
+	}
+	return ""
+}
+
+func randomDigestCNonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func quoteDigestValue(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
+
+// selectDigestChallenge picks the strongest supported Digest challenge from
+// WWW-Authenticate values.
+func selectDigestChallenge(values []string) (digestChallenge, bool) {
+	var best digestChallenge
+	found := false
+	for _, value := range values {
+		for _, challenge := range parseAuthChallenges(value) {
+			if !strings.EqualFold(challenge.scheme, "Digest") {
+				continue
+			}
+			c := digestChallenge{
+				realm:     challenge.params["realm"],
+				nonce:     challenge.params["nonce"],
+				opaque:    challenge.params["opaque"],
+				algorithm: challenge.params["algorithm"],
+				userhash:  strings.EqualFold(challenge.params["userhash"], "true"),
+			}
+			if c.algorithm == "" {
+				c.algorithm = "MD5"
+			}
+			if c.nonce == "" || digestAlgorithmRank(c.algorithm) == 0 {
+				continue
+			}
+			for _, q := range strings.Split(challenge.params["qop"], ",") {
+				if q = strings.ToLower(strings.TrimSpace(q)); q != "" {
+					c.qop = append(c.qop, q)
+				}
+			}
+			if len(c.qop) > 0 && chooseDigestQOP(c.qop, "") == "" {
+				continue
+			}
+			if !found || digestAlgorithmRank(c.algorithm) > digestAlgorithmRank(best.algorithm) {
+				best = c
+				found = true
+			}
+		}
+	}
+	return best, found
+}
+
+type authChallenge struct {
+	scheme string
+	params map[string]string
+}
+
+// parseAuthChallenges splits one WWW-Authenticate field value into its
+// challenges (RFC 9110 §11.6.1). Commas separate both challenges and
+// auth-params, so a new challenge starts at an item that begins with a bare
+// scheme token.
+func parseAuthChallenges(value string) []authChallenge {
+	var challenges []authChallenge
+	for _, item := range splitAuthParamList(value) {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, rest, hasSpace := strings.Cut(item, " ")
+		if !strings.Contains(name, "=") {
+			challenges = append(challenges, authChallenge{scheme: name, params: map[string]string{}})
+			if !hasSpace {
+				continue
+			}
+			item = strings.TrimSpace(rest)
+		}
+		if len(challenges) == 0 {
+			continue
+		}
+		key, val, ok := strings.Cut(item, "=")
+		if !ok {
+			continue
+		}
+		challenges[len(challenges)-1].params[strings.ToLower(strings.TrimSpace(key))] = unquoteAuthParam(strings.TrimSpace(val))
+	}
+	return challenges
+}
+
+func splitAuthParamList(value string) []string {
+	var items []string
+	var cur strings.Builder
+	inQuotes := false
+	escaped := false
+	for _, r := range value {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\' && inQuotes:
+			escaped = true
+		case r == '"':
+			inQuotes = !inQuotes
+		case r == ',' && !inQuotes:
+			items = append(items, cur.String())
+			cur.Reset()
+			continue
+		}
+		cur.WriteRune(r)
+	}
+	return append(items, cur.String())
+}
+
+func unquoteAuthParam(value string) string {
+	if len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+		return value
+	}
+	var b strings.Builder
+	escaped := false
+	for _, r := range value[1 : len(value)-1] {
+		if !escaped && r == '\\' {
+			escaped = true
+			continue
+		}
+		escaped = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
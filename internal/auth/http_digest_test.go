@@ -0,0 +1,191 @@
+package auth
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/rest-sh/restish/v2/auth"
+)
+
+// RFC 7616 §3.9.1 example values.
+const (
+	digestTestChallenge = `Digest realm="http-auth@example.org", qop="auth, auth-int", algorithm=%s, nonce="7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v", opaque="FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS"`
+	digestTestCNonce    = "f2/wE4q74E6zIJEtWaHKaf5wv/H5QzzpXusqGemxURZJ"
+)
+
+func digestTestParams() map[string]string {
+	return map[string]string{"username": "Mufasa", "password": "Circle of Life"}
+}
+
+func newDigestTestHandler() *HTTPDigest {
+	return &HTTPDigest{CNonce: func() (string, error) { return digestTestCNonce, nil }}
+}
+
+func digestParam(t *testing.T, header, name string) string {
+	t.Helper()
+	challenges := parseAuthChallenges(header)
+	if len(challenges) != 1 || challenges[0].scheme != "Digest" {
+		t.Fatalf("Authorization = %q, want a single Digest credential", header)
+	}
+	return challenges[0].params[name]
+}
+
+func TestHTTPDigestRFC7616Examples(t *testing.T) {
+	tests := []struct {
+		algorithm string
+		response  string
+	}{
+		{"MD5", "8ca523f5e9506fed4657c9700eebdbec"},
+		{"SHA-256", "753927fa0e85d155564e2e272a28d1802ca10daf4496794697cf8db5856cb6c1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.algorithm, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "http://www.example.org/dir/index.html", nil)
+			err := newDigestTestHandler().Authenticate(context.Background(), req, auth.AuthContext{
+				Params:     digestTestParams(),
+				Force:      true,
+				Challenges: []string{strings.Replace(digestTestChallenge, "%s", tt.algorithm, 1)},
+			})
+			if err != nil {
+				t.Fatalf("Authenticate: %v", err)
+			}
+			header := req.Header.Get("Authorization")
+			if got := digestParam(t, header, "response"); got != tt.response {
+				t.Fatalf("response = %q, want %q (header %q)", got, tt.response, header)
+			}
+			for name, want := range map[string]string{"nc": "00000001", "qop": "auth", "uri": "/dir/index.html", "algorithm": tt.algorithm, "opaque": "FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS"} {
+				if got := digestParam(t, header, name); got != want {
+					t.Fatalf("%s = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestHTTPDigestReusesChallengeWithIncrementingNonceCount(t *testing.T) {
+	h := newDigestTestHandler()
+	ac := auth.AuthContext{APIName: "demo", ProfileName: "default", Params: digestTestParams()}
+
+	first, _ := http.NewRequest("GET", "http://www.example.org/items", nil)
+	if err := h.Authenticate(context.Background(), first, ac); err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if got := first.Header.Get("Authorization"); got != "" {
+		t.Fatalf("Authorization = %q before any challenge, want none", got)
+	}
+
+	forced := ac
+	forced.Force = true
+	forced.Challenges = []string{`Basic realm="x", ` + strings.Replace(digestTestChallenge, "%s", "SHA-512-256", 1)}
+	retry, _ := http.NewRequest("GET", "http://www.example.org/items", nil)
+	if err := h.Authenticate(context.Background(), retry, forced); err != nil {
+		t.Fatalf("Authenticate retry: %v", err)
+	}
+	if got := digestParam(t, retry.Header.Get("Authorization"), "nc"); got != "00000001" {
+		t.Fatalf("retry nc = %q", got)
+	}
+
+	next, _ := http.NewRequest("GET", "http://www.example.org/items?page=2", nil)
+	if err := h.Authenticate(context.Background(), next, ac); err != nil {
+		t.Fatalf("Authenticate next page: %v", err)
+	}
+	header := next.Header.Get("Authorization")
+	if got := digestParam(t, header, "nc"); got != "00000002" {
+		t.Fatalf("next page nc = %q, want 00000002", got)
+	}
+	if got := digestParam(t, header, "algorithm"); got != "SHA-512-256" {
+		t.Fatalf("algorithm = %q", got)
+	}
+	if got := digestParam(t, header, "uri"); got != "/items?page=2" {
+		t.Fatalf("uri = %q", got)
+	}
+}
+
+func TestHTTPDigestAuthIntAndLegacyChallenge(t *testing.T) {
+	req, _ := http.NewRequest("PUT", "http://example.org/doc", strings.NewReader("payload"))
+	params := digestTestParams()
+	params["qop"] = "auth-int"
+	err := newDigestTestHandler().Authenticate(context.Background(), req, auth.AuthContext{
+		Params:     params,
+		Force:      true,
+		Challenges: []string{`Digest realm="r", nonce="n", qop="auth,auth-int"`},
+	})
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	md5hex := func(s string) string {
+		sum := md5.Sum([]byte(s))
+		return hex.EncodeToString(sum[:])
+	}
+	ha1 := md5hex("Mufasa:r:Circle of Life")
+	ha2 := md5hex("PUT:/doc:" + md5hex("payload"))
+	want := md5hex(ha1 + ":n:00000001:" + digestTestCNonce + ":auth-int:" + ha2)
+	if got := digestParam(t, req.Header.Get("Authorization"), "response"); got != want {
+		t.Fatalf("response = %q, want %q", got, want)
+	}
+
+	// RFC 2069 servers send no qop; the response omits nc and cnonce.
+	legacy, _ := http.NewRequest("GET", "http://example.org/doc", nil)
+	err = newDigestTestHandler().Authenticate(context.Background(), legacy, auth.AuthContext{
+		Params:     digestTestParams(),
+		Force:      true,
+		Challenges: []string{`Digest realm="r", nonce="n"`},
+	})
+	if err != nil {
+		t.Fatalf("Authenticate legacy: %v", err)
+	}
+	header := legacy.Header.Get("Authorization")
+	if strings.Contains(header, "nc=") || strings.Contains(header, "cnonce=") {
+		t.Fatalf("Authorization = %q, want no nc/cnonce without qop", header)
+	}
+	if got, want := digestParam(t, header, "response"), md5hex(ha1+":n:"+md5hex("GET:/doc")); got != want {
+		t.Fatalf("response = %q, want %q", got, want)
+	}
+}
+
+func TestHTTPDigestUserhash(t *testing.T) {
+	req, _ := http.NewRequest("GET", "http://example.org/", nil)
+	err := newDigestTestHandler().Authenticate(context.Background(), req, auth.AuthContext{
+		Params:     digestTestParams(),
+		Force:      true,
+		Challenges: []string{`Digest realm="r", nonce="n", qop="auth", userhash=true`},
+	})
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	sum := md5.Sum([]byte("Mufasa:r"))
+	header := req.Header.Get("Authorization")
+	if got := digestParam(t, header, "username"); got != hex.EncodeToString(sum[:]) {
+		t.Fatalf("username = %q, want hashed", got)
+	}
+	if got := digestParam(t, header, "userhash"); got != "true" {
+		t.Fatalf("userhash = %q", got)
+	}
+}
+
+func TestHTTPDigestErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		params     map[string]string
+		challenges []string
+		want       string
+	}{
+		{"missing username", map[string]string{"password": "p"}, nil, "username is required"},
+		{"no challenge", digestTestParams(), []string{`Basic realm="x"`}, "did not send a supported Digest challenge"},
+		{"unsupported algorithm", digestTestParams(), []string{`Digest realm="x", nonce="n", algorithm=SHA-1`}, "did not send a supported Digest challenge"},
+		{"no prompter", map[string]string{"username": "u"}, []string{`Digest realm="x", nonce="n"`}, "no prompter configured"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "http://example.org/", nil)
+			err := (&HTTPDigest{}).Authenticate(context.Background(), req, auth.AuthContext{Params: tt.params, Force: true, Challenges: tt.challenges})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
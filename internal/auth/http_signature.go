@@ -0,0 +1,351 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rest-sh/restish/v2/auth"
+)
+
+const defaultSignatureLabel = "sig1"
+
+// HTTPSignature signs outbound requests with HTTP Message Signatures
+// (RFC 9421). When the request has a body, or when content-digest is a
+// covered component, a Content-Digest header (RFC 9530) is computed first so
+// the signature covers the exact bytes sent.
+//
+// Config params:
+//
+//	key_id            (required) keyid signature parameter
+//	algorithm         (required) ed25519, ecdsa-p256-sha256, rsa-pss-sha512,
+//	                  rsa-v1_5-sha256, or hmac-sha256
+//	key / key_file    (one required) PEM private key, or the shared secret for
+//	                  hmac-sha256
+//	key_encoding      (optional) hmac-sha256 secret encoding: raw (default),
+//	                  base64, or hex
+//	components        (optional) space- or comma-separated covered components;
+//	                  defaults to "@method @target-uri" plus content-digest
+//	                  when the request has a body
+//	label             (optional) signature label; defaults to sig1
+//	tag               (optional) tag signature parameter
+//	digest_algorithm  (optional) sha-256 (default) or sha-512
+type HTTPSignature struct {
+	// Now returns the signature creation time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+func (h *HTTPSignature) Parameters() []auth.Param {
+	return []auth.Param{
+		{Name: "key_id", Description: "Key identifier sent as the keyid signature parameter", Required: true},
+		{Name: "algorithm", Description: "Signature algorithm: ed25519, ecdsa-p256-sha256, rsa-pss-sha512, rsa-v1_5-sha256, or hmac-sha256", Required: true},
+		{Name: "key", Description: "PEM private key, or the shared secret for hmac-sha256", Secret: true},
+		{Name: "key_file", Description: "Path to a PEM private key or shared secret file"},
+		{Name: "key_encoding", Description: "hmac-sha256 secret encoding: raw (default), base64, or hex"},
+		{Name: "components", Description: "Space-separated covered components such as @method @target-uri content-digest"},
+		{Name: "label", Description: "Signature label (default sig1)"},
+		{Name: "tag", Description: "Application-specific tag signature parameter"},
+		{Name: "digest_algorithm", Description: "Content-Digest algorithm: sha-256 (default) or sha-512"},
+	}
+}
+
+func (h *HTTPSignature) Authenticate(_ context.Context, req *http.Request, ac auth.AuthContext) error {
+	return h.sign(req, ac.Params)
+}
+
+func (h *HTTPSignature) sign(req *http.Request, params map[string]string) error {
+	keyID := params["key_id"]
+	if keyID == "" {
+		return fmt.Errorf("http-signature: key_id is required")
+	}
+	alg, err := parseSignatureAlgorithm(params["algorithm"])
+	if err != nil {
+		return err
+	}
+	keyMaterial, err := signatureKeyMaterial(params)
+	if err != nil {
+		return err
+	}
+	signer, err := newSignatureSigner(alg, keyMaterial, params["key_encoding"])
+	if err != nil {
+		return err
+	}
+
+	body, err := signingRequestBody("http-signature", req)
+	if err != nil {
+		return err
+	}
+	components := signatureComponents(params["components"], len(body) > 0)
+	if len(body) > 0 || containsComponent(components, "content-digest") {
+		if err := applyContentDigest(req, body, params["digest_algorithm"]); err != nil {
+			return err
+		}
+	}
+
+	label := params["label"]
+	if label == "" {
+		label = defaultSignatureLabel
+	}
+	now := time.Now
+	if h.Now != nil {
+		now = h.Now
+	}
+	sigParams := signatureParams(components, now().Unix(), keyID, alg, params["tag"])
+	base, err := signatureBase(req, components, sigParams)
+	if err != nil {
+		return err
+	}
+	sig, err := signer([]byte(base))
+	if err != nil {
+		return fmt.Errorf("http-signature: signing request: %w", err)
+	}
+	req.Header.Set("Signature-Input", label+"="+sigParams)
+	req.Header.Set("Signature", label+"=:"+base64.StdEncoding.EncodeToString(sig)+":")
+	return nil
+}
+
+func parseSignatureAlgorithm(value string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "ed25519":
+		return "ed25519", nil
+	case "ecdsa-p256-sha256", "ecdsa-p256":
+		return "ecdsa-p256-sha256", nil
+	case "rsa-pss-sha512", "rsa-pss":
+		return "rsa-pss-sha512", nil
+	case "rsa-v1_5-sha256":
+		return "rsa-v1_5-sha256", nil
+	case "hmac-sha256":
+		return "hmac-sha256", nil
+	case "":
+		return "", fmt.Errorf("http-signature: algorithm is required")
+	default:
+		return "", fmt.Errorf("http-signature: unsupported algorithm %q (supported: ed25519, ecdsa-p256-sha256, rsa-pss-sha512, rsa-v1_5-sha256, hmac-sha256)", value)
+	}
+}
+
+func signatureKeyMaterial(params map[string]string) ([]byte, error) {
+	key := params["key"]
+	keyFile := params["key_file"]
+	switch {
+	case key != "" && keyFile != "":
+		return nil, fmt.Errorf("http-signature: set only one of key or key_file")
+	case key != "":
+		return []byte(key), nil
+	case keyFile != "":
+		data, err := os.ReadFile(keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("http-signature: reading key_file: %w", err)
+		}
+		return data, nil
+	default:
+		return nil, fmt.Errorf("http-signature: key or key_file is required")
+	}
+}
+
+func newSignatureSigner(alg string, keyMaterial []byte, encoding string) (func([]byte) ([]byte, error), error) {
+	if alg == "hmac-sha256" {
+		secret, err := decodeSharedSecret("http-signature", keyMaterial, encoding)
+		if err != nil {
+			return nil, err
+		}
+		return func(base []byte) ([]byte, error) {
+			mac := hmac.New(sha256.New, secret)
+			mac.Write(base)
+			return mac.Sum(nil), nil
+		}, nil
+	}
+	key, err := parsePEMPrivateKey("http-signature", keyMaterial)
+	if err != nil {
+		return nil, err
+	}
+	switch alg {
+	case "ed25519":
+		k, ok := key.(ed25519.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("http-signature: algorithm ed25519 requires an Ed25519 private key")
+		}
+		return func(base []byte) ([]byte, error) {
+			return ed25519.Sign(k, base), nil
+		}, nil
+	case "ecdsa-p256-sha256":
+		k, ok := key.(*ecdsa.PrivateKey)
+		if !ok || k.Curve.Params().Name != "P-256" {
+			return nil, fmt.Errorf("http-signature: algorithm ecdsa-p256-sha256 requires a P-256 private key")
+		}
+		return func(base []byte) ([]byte, error) {
+			digest := sha256.Sum256(base)
+			r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+			if err != nil {
+				return nil, err
+			}
+			// RFC 9421 §3.3.4: r and s as fixed-width big-endian integers.
+			sig := make([]byte, 64)
+			r.FillBytes(sig[:32])
+			s.FillBytes(sig[32:])
+			return sig, nil
+		}, nil
+	case "rsa-pss-sha512":
+		k, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("http-signature: algorithm rsa-pss-sha512 requires an RSA private key")
+		}
+		return func(base []byte) ([]byte, error) {
+			digest := sha512.Sum512(base)
+			return rsa.SignPSS(rand.Reader, k, crypto.SHA512, digest[:], &rsa.PSSOptions{SaltLength: 64})
+		}, nil
+	case "rsa-v1_5-sha256":
+		k, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("http-signature: algorithm rsa-v1_5-sha256 requires an RSA private key")
+		}
+		return func(base []byte) ([]byte, error) {
+			digest := sha256.Sum256(base)
+			return rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+		}, nil
+	default:
+		return nil, fmt.Errorf("http-signature: unsupported algorithm %q", alg)
+	}
+}
+
+func applyContentDigest(req *http.Request, body []byte, algorithm string) error {
+	if getHeaderCaseInsensitive(req.Header, "Content-Digest") != "" {
+		return nil
+	}
+	var h hash.Hash
+	name := strings.ToLower(strings.TrimSpace(algorithm))
+	switch name {
+	case "", "sha-256":
+		name = "sha-256"
+		h = sha256.New()
+	case "sha-512":
+		h = sha512.New()
+	default:
+		return fmt.Errorf("http-signature: unsupported digest_algorithm %q (supported: sha-256, sha-512)", algorithm)
+	}
+	h.Write(body)
+	req.Header.Set("Content-Digest", name+"=:"+base64.StdEncoding.EncodeToString(h.Sum(nil))+":")
+	return nil
+}
+
+func signatureComponents(raw string, hasBody bool) []string {
+	fields := strings.FieldsFunc(raw, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+	if len(fields) == 0 {
+		fields = []string{"@method", "@target-uri"}
+		if hasBody {
+			fields = append(fields, "content-digest")
+		}
+	}
+	components := make([]string, 0, len(fields))
+	for _, field := range fields {
+		components = append(components, strings.ToLower(strings.Trim(field, `"`)))
+	}
+	return components
+}
+
+func containsComponent(components []string, name string) bool {
+	for _, component := range components {
+		if component == name {
+			return true
+		}
+	}
+	return false
+}
+
+func signatureParams(components []string, created int64, keyID, alg, tag string) string {
+	quoted := make([]string, len(components))
+	for i, component := range components {
+		quoted[i] = strconv.Quote(component)
+	}
+	var b strings.Builder
+	b.WriteString("(" + strings.Join(quoted, " ") + ")")
+	b.WriteString(";created=" + strconv.FormatInt(created, 10))
+	b.WriteString(";keyid=" + strconv.Quote(keyID))
+	b.WriteString(";alg=" + strconv.Quote(alg))
+	if tag != "" {
+		b.WriteString(";tag=" + strconv.Quote(tag))
+	}
+	return b.String()
+}
+
+// signatureBase builds the RFC 9421 §2.5 signature base for req.
+func signatureBase(req *http.Request, components []string, sigParams string) (string, error) {
+	var b strings.Builder
+	for _, component := range components {
+		value, err := signatureComponentValue(req, component)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(strconv.Quote(component) + ": " + value + "\n")
+	}
+	b.WriteString(`"@signature-params": ` + sigParams)
+	return b.String(), nil
+}
+
+func signatureComponentValue(req *http.Request, component string) (string, error) {
+	switch component {
+	case "@method":
+		return strings.ToUpper(req.Method), nil
+	case "@target-uri":
+		return req.URL.String(), nil
+	case "@authority":
+		return signatureAuthority(req), nil
+	case "@scheme":
+		return strings.ToLower(req.URL.Scheme), nil
+	case "@request-target":
+		return req.URL.RequestURI(), nil
+	case "@path":
+		if p := req.URL.EscapedPath(); p != "" {
+			return p, nil
+		}
+		return "/", nil
+	case "@query":
+		return "?" + req.URL.RawQuery, nil
+	}
+	if strings.HasPrefix(component, "@") {
+		return "", fmt.Errorf("http-signature: unsupported derived component %q", component)
+	}
+	var values []string
+	for name, headerValues := range req.Header {
+		if !strings.EqualFold(name, component) {
+			continue
+		}
+		for _, v := range headerValues {
+			values = append(values, strings.TrimSpace(v))
+		}
+	}
+	if len(values) == 0 {
+		return "", fmt.Errorf("http-signature: covered header %q is not present on the request", component)
+	}
+	return strings.Join(values, ", "), nil
+}
+
+func signatureAuthority(req *http.Request) string {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	host = strings.ToLower(host)
+	switch {
+	case req.URL.Scheme == "https" && strings.HasSuffix(host, ":443"):
+		host = strings.TrimSuffix(host, ":443")
+	case req.URL.Scheme == "http" && strings.HasSuffix(host, ":80"):
+		host = strings.TrimSuffix(host, ":80")
+	}
+	return host
+}
@@ -0,0 +1,214 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rest-sh/restish/v2/auth"
+)
+
+func fixedSignatureTime() time.Time {
+	return time.Unix(1618884473, 0)
+}
+
+func signatureTestPEM(t *testing.T, key any) string {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey: %v", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+}
+
+func signatureValue(t *testing.T, req *http.Request, label string) []byte {
+	t.Helper()
+	raw := req.Header.Get("Signature")
+	prefix := label + "=:"
+	if !strings.HasPrefix(raw, prefix) || !strings.HasSuffix(raw, ":") {
+		t.Fatalf("Signature = %q, want %s=:...: form", raw, label)
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSuffix(strings.TrimPrefix(raw, prefix), ":"))
+	if err != nil {
+		t.Fatalf("decoding signature: %v", err)
+	}
+	return sig
+}
+
+func TestHTTPSignatureEd25519WithContentDigest(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest("POST", "https://example.com/foo?param=Value&Pet=dog", strings.NewReader(`{"hello": "world"}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	h := &HTTPSignature{Now: fixedSignatureTime}
+	err = h.Authenticate(context.Background(), req, auth.AuthContext{Params: map[string]string{
+		"key_id":    "test-key-ed25519",
+		"algorithm": "ed25519",
+		"key":       signatureTestPEM(t, priv),
+	}})
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+
+	wantDigest := "sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:"
+	if got := req.Header.Get("Content-Digest"); got != wantDigest {
+		t.Fatalf("Content-Digest = %q, want %q", got, wantDigest)
+	}
+	wantParams := `("@method" "@target-uri" "content-digest");created=1618884473;keyid="test-key-ed25519";alg="ed25519"`
+	if got := req.Header.Get("Signature-Input"); got != "sig1="+wantParams {
+		t.Fatalf("Signature-Input = %q", got)
+	}
+	base := "\"@method\": POST\n" +
+		"\"@target-uri\": https://example.com/foo?param=Value&Pet=dog\n" +
+		"\"content-digest\": " + wantDigest + "\n" +
+		"\"@signature-params\": " + wantParams
+	if !ed25519.Verify(pub, []byte(base), signatureValue(t, req, "sig1")) {
+		t.Fatal("signature did not verify against the expected signature base")
+	}
+}
+
+func TestHTTPSignatureDefaultComponentsWithoutBody(t *testing.T) {
+	_, priv, _ := ed25519.GenerateKey(rand.Reader)
+	req, _ := http.NewRequest("GET", "https://example.com/items", nil)
+	err := (&HTTPSignature{Now: fixedSignatureTime}).Authenticate(context.Background(), req, auth.AuthContext{Params: map[string]string{
+		"key_id":    "k",
+		"algorithm": "ed25519",
+		"key":       signatureTestPEM(t, priv),
+	}})
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if got := req.Header.Get("Content-Digest"); got != "" {
+		t.Fatalf("Content-Digest = %q, want none for bodyless request", got)
+	}
+	if got := req.Header.Get("Signature-Input"); !strings.HasPrefix(got, `sig1=("@method" "@target-uri");`) {
+		t.Fatalf("Signature-Input = %q", got)
+	}
+}
+
+func TestHTTPSignatureHMACCustomComponents(t *testing.T) {
+	secret := []byte("shared-secret")
+	req, _ := http.NewRequest("GET", "https://Example.com:443/foo?a=1", nil)
+	req.Header.Set("Date", "Tue, 20 Apr 2021 02:07:55 GMT")
+	req.Header.Set("Content-Type", "application/json")
+
+	err := (&HTTPSignature{Now: fixedSignatureTime}).Authenticate(context.Background(), req, auth.AuthContext{Params: map[string]string{
+		"key_id":       "test-shared-secret",
+		"algorithm":    "hmac-sha256",
+		"key":          base64.StdEncoding.EncodeToString(secret),
+		"key_encoding": "base64",
+		"components":   "date, @authority, content-type @path @query",
+		"label":        "sig-b25",
+		"tag":          "app",
+	}})
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	wantParams := `("date" "@authority" "content-type" "@path" "@query");created=1618884473;keyid="test-shared-secret";alg="hmac-sha256";tag="app"`
+	if got := req.Header.Get("Signature-Input"); got != "sig-b25="+wantParams {
+		t.Fatalf("Signature-Input = %q", got)
+	}
+	base := "\"date\": Tue, 20 Apr 2021 02:07:55 GMT\n" +
+		"\"@authority\": example.com\n" +
+		"\"content-type\": application/json\n" +
+		"\"@path\": /foo\n" +
+		"\"@query\": ?a=1\n" +
+		"\"@signature-params\": " + wantParams
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(base))
+	if !hmac.Equal(mac.Sum(nil), signatureValue(t, req, "sig-b25")) {
+		t.Fatal("HMAC signature did not match the expected signature base")
+	}
+}
+
+func TestHTTPSignatureECDSAKeyFile(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(keyFile, []byte(signatureTestPEM(t, priv)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest("GET", "https://example.com/items", nil)
+	err = (&HTTPSignature{Now: fixedSignatureTime}).Authenticate(context.Background(), req, auth.AuthContext{Params: map[string]string{
+		"key_id":     "ec",
+		"algorithm":  "ecdsa-p256",
+		"key_file":   keyFile,
+		"components": "@method",
+	}})
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	sig := signatureValue(t, req, "sig1")
+	if len(sig) != 64 {
+		t.Fatalf("signature length = %d, want 64", len(sig))
+	}
+	base := "\"@method\": GET\n\"@signature-params\": (\"@method\");created=1618884473;keyid=\"ec\";alg=\"ecdsa-p256-sha256\""
+	digest := sha256.Sum256([]byte(base))
+	r := new(big.Int).SetBytes(sig[:32])
+	s := new(big.Int).SetBytes(sig[32:])
+	if !ecdsa.Verify(&priv.PublicKey, digest[:], r, s) {
+		t.Fatal("ECDSA signature did not verify")
+	}
+}
+
+func TestHTTPSignatureKeepsExistingContentDigest(t *testing.T) {
+	req, _ := http.NewRequest("PUT", "https://example.com/items", strings.NewReader("body"))
+	req.Header.Set("Content-Digest", "sha-512=:abc=:")
+	err := (&HTTPSignature{Now: fixedSignatureTime}).Authenticate(context.Background(), req, auth.AuthContext{Params: map[string]string{
+		"key_id":    "k",
+		"algorithm": "hmac-sha256",
+		"key":       "secret",
+	}})
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if got := req.Header.Get("Content-Digest"); got != "sha-512=:abc=:" {
+		t.Fatalf("Content-Digest = %q, want caller value", got)
+	}
+}
+
+func TestHTTPSignatureErrors(t *testing.T) {
+	_, priv, _ := ed25519.GenerateKey(rand.Reader)
+	rsaLike := signatureTestPEM(t, priv)
+	tests := []struct {
+		name   string
+		params map[string]string
+		want   string
+	}{
+		{"missing key id", map[string]string{"algorithm": "ed25519", "key": rsaLike}, "key_id is required"},
+		{"unsupported algorithm", map[string]string{"key_id": "k", "algorithm": "dsa", "key": rsaLike}, "unsupported algorithm"},
+		{"missing key", map[string]string{"key_id": "k", "algorithm": "ed25519"}, "key or key_file is required"},
+		{"wrong key type", map[string]string{"key_id": "k", "algorithm": "rsa-pss-sha512", "key": rsaLike}, "requires an RSA private key"},
+		{"not pem", map[string]string{"key_id": "k", "algorithm": "ed25519", "key": "nope"}, "not PEM encoded"},
+		{"missing header", map[string]string{"key_id": "k", "algorithm": "hmac-sha256", "key": "s", "components": "x-missing"}, `covered header "x-missing" is not present`},
+		{"bad derived", map[string]string{"key_id": "k", "algorithm": "hmac-sha256", "key": "s", "components": "@status"}, "unsupported derived component"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "https://example.com/items", nil)
+			err := (&HTTPSignature{}).Authenticate(context.Background(), req, auth.AuthContext{Params: tt.params})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -80,13 +80,13 @@ type AuthorizationCode struct {
 }
 
 func (h *AuthorizationCode) Parameters() []auth.Param {
-	return appendOAuthPassthroughParams([]auth.Param{
+	return appendOAuthPassthroughParams(appendOAuthLogoutParams(appendOAuthDPoPParams(appendOAuthClientAuthParams([]auth.Param{
 		{Name: "client_id", Description: "OAuth2 client ID", Required: true},
 		{Name: "client_secret", Description: "OAuth2 client secret (optional for public clients)", Required: false, Secret: true},
-		{Name: "auth_method", Description: "OAuth2 client auth method: client_secret_post (default) or client_secret_basic", Required: false},
 		{Name: "authorize_url", Description: "OAuth2 authorization endpoint URL", Required: false},
 		{Name: "token_url", Description: "OAuth2 token endpoint URL", Required: false},
 		{Name: "issuer_url", Description: "OIDC issuer URL (used for discovery when authorize_url/token_url are absent)", Required: false},
+		{Name: "oauth2_metadata_url", Description: "OAuth authorization server metadata URL (RFC 8414), used for discovery when issuer_url is absent", Required: false},
 		{Name: "scopes", Description: "Space-separated OAuth2 scopes to request; some providers require offline_access for refresh tokens", Required: false},
 		{Name: "redirect_scheme", Description: "Local callback URL scheme: http (default) or https", Required: false},
 		{Name: "redirect_port", Description: fmt.Sprintf("Local port for the redirect callback (default %s)", defaultRedirectPort), Required: false},
@@ -95,7 +95,7 @@ func (h *AuthorizationCode) Parameters() []auth.Param {
 		{Name: "redirect_key", Description: "Path to the PEM private key for an HTTPS local callback", Required: false, Secret: true},
 		{Name: callbackSuccessHTMLParam, Description: "Custom HTML for the successful browser callback page", Required: false},
 		{Name: callbackErrorHTMLParam, Description: "Custom HTML for the failed browser callback page; supports $ERROR, $TITLE, and $DETAILS placeholders", Required: false},
-	})
+	}))))
 }
 
 func (h *AuthorizationCode) OnRequest(req *http.Request, params map[string]string) error {
@@ -103,12 +103,15 @@ func (h *AuthorizationCode) OnRequest(req *http.Request, params map[string]strin
 }
 
 func (h *AuthorizationCode) authenticateRequest(req *http.Request, params map[string]string, force bool) error {
-	token, err := h.resolveToken(req.Context(), params, force)
+	ctx, dpop, err := startDPoP(req.Context(), h.Cache, params)
 	if err != nil {
 		return err
 	}
-	bearerAuth(req, token)
-	return nil
+	token, err := h.resolveToken(ctx, params, force)
+	if err != nil {
+		return err
+	}
+	return applyAccessToken(req, dpop, token, params)
 }
 
 func (h *AuthorizationCode) resolveToken(ctx context.Context, params map[string]string, force bool) (string, error) {
@@ -196,7 +199,7 @@ func (h *AuthorizationCode) Authenticate(ctx context.Context, req *http.Request,
 		h2.Prompt = ac.Prompter.Prompt
 	}
 	req = requestWithContext(req, ctx)
-	return h2.authenticateRequest(req, authParams(ac), ac.Force)
+	return h2.authenticateRequest(req, authParams(ac), oauthForce(ac))
 }
 
 func (h *AuthorizationCode) SupportsForce() {}
@@ -231,17 +234,13 @@ func (h *AuthorizationCode) resolveEndpoints(ctx context.Context, params map[str
 		tokenURL = resolved
 	}
 	if authorizeURL == "" || tokenURL == "" {
-		issuer := params["issuer_url"]
-		if issuer == "" {
+		if !hasOAuthDiscovery(params) {
 			return "", "", fmt.Errorf("oauth-authorization-code: (authorize_url and token_url) or issuer_url is required")
 		}
-		oidc, e := DiscoverOIDC(ctx, h.HTTPClient, issuer)
+		oidc, _, e := discoverOAuthServer(ctx, h.HTTPClient, params)
 		if e != nil {
 			return "", "", e
 		}
-		if e := validateOIDCEndpoints(issuer, oidc); e != nil {
-			return "", "", e
-		}
 		if authorizeURL == "" {
 			authorizeURL = oidc.AuthorizationEndpoint
 		}
@@ -0,0 +1,252 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/tls"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rest-sh/restish/v2/auth"
+)
+
+const (
+	clientAssertionType     = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+	clientAssertionLifetime = time.Minute
+)
+
+// ClientCertificateFunc returns the TLS client identity configured for the
+// active profile, from client certificate files or a tls-signer plugin.
+type ClientCertificateFunc func() (*tls.Certificate, error)
+
+type clientCertificateContextKey struct{}
+
+// WithClientCertificate attaches the profile's TLS client identity to ctx.
+// private_key_jwt signs client assertions with its private key when no
+// client_assertion_key is configured, so keys held in an HSM behind a
+// tls-signer plugin never leave it.
+func WithClientCertificate(ctx context.Context, fn ClientCertificateFunc) context.Context {
+	return context.WithValue(ctx, clientCertificateContextKey{}, fn)
+}
+
+func clientCertificateFromContext(ctx context.Context) ClientCertificateFunc {
+	if ctx == nil {
+		return nil
+	}
+	fn, _ := ctx.Value(clientCertificateContextKey{}).(ClientCertificateFunc)
+	return fn
+}
+
+func appendOAuthClientAuthParams(params []auth.Param) []auth.Param {
+	return append(params,
+		auth.Param{Name: "auth_method", Description: "OAuth2 client auth method: client_secret_post (default), client_secret_basic, private_key_jwt, tls_client_auth, or self_signed_tls_client_auth", Required: false},
+		auth.Param{Name: "client_assertion_key", Description: "PEM private key that signs private_key_jwt client assertions", Required: false, Secret: true},
+		auth.Param{Name: "client_assertion_key_file", Description: "Path to a PEM private key that signs private_key_jwt client assertions", Required: false},
+		auth.Param{Name: "client_assertion_alg", Description: "JWS algorithm for private_key_jwt: RS256, PS256, ES256, ES384, or EdDSA (default from the key type)", Required: false},
+		auth.Param{Name: "client_assertion_kid", Description: "Key ID to put in the private_key_jwt assertion header", Required: false},
+		auth.Param{Name: "client_assertion_audience", Description: "Audience for private_key_jwt assertions (default token endpoint URL)", Required: false},
+	)
+}
+
+// clientAssertion builds a signed private_key_jwt client assertion (RFC 7523
+// §2.2, OIDC Core §9) for a request to tokenURL.
+func clientAssertion(ctx context.Context, params map[string]string, tokenURL string) (string, error) {
+	clientID := params["client_id"]
+	if clientID == "" {
+		return "", fmt.Errorf("private_key_jwt: client_id is required")
+	}
+	signer, cert, err := clientAssertionSigner(ctx, params)
+	if err != nil {
+		return "", err
+	}
+	alg, err := jwsAlgForKey("private_key_jwt", "client_assertion_alg", params["client_assertion_alg"], signer.Public())
+	if err != nil {
+		return "", err
+	}
+	header := map[string]string{"alg": alg, "typ": "JWT"}
+	if kid := params["client_assertion_kid"]; kid != "" {
+		header["kid"] = kid
+	} else if cert != nil && len(cert.Certificate) > 0 {
+		sum := sha256.Sum256(cert.Certificate[0])
+		header["x5t#S256"] = base64.RawURLEncoding.EncodeToString(sum[:])
+	}
+	audience := params["client_assertion_audience"]
+	if audience == "" {
+		audience = tokenURL
+	}
+	jti, err := randomJTI()
+	if err != nil {
+		return "", fmt.Errorf("private_key_jwt: %w", err)
+	}
+	now := time.Now()
+	claims := map[string]any{
+		"iss": clientID,
+		"sub": clientID,
+		"aud": audience,
+		"jti": jti,
+		"iat": now.Unix(),
+		"exp": now.Add(clientAssertionLifetime).Unix(),
+	}
+	assertion, err := signCompactJWS(signer, alg, header, claims)
+	if err != nil {
+		return "", fmt.Errorf("private_key_jwt: signing client assertion: %w", err)
+	}
+	return assertion, nil
+}
+
+// randomJTI returns a random JWT ID.
+func randomJTI() (string, error) {
+	var jti [16]byte
+	if _, err := rand.Read(jti[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(jti[:]), nil
+}
+
+// signCompactJWS encodes header and claims as JSON and returns the compact
+// JWS serialization signed with signer.
+func signCompactJWS(signer crypto.Signer, alg string, header, claims any) (string, error) {
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(headerJSON) + "." + base64.RawURLEncoding.EncodeToString(claimsJSON)
+	sig, err := signJWS(signer, alg, []byte(signingInput))
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// clientAssertionSigner returns the key from client_assertion_key or
+// client_assertion_key_file, falling back to the profile's TLS client
+// identity. The certificate is returned when the key came from it.
+func clientAssertionSigner(ctx context.Context, params map[string]string) (crypto.Signer, *tls.Certificate, error) {
+	key := params["client_assertion_key"]
+	keyFile := params["client_assertion_key_file"]
+	var material []byte
+	switch {
+	case key != "" && keyFile != "":
+		return nil, nil, fmt.Errorf("private_key_jwt: set only one of client_assertion_key or client_assertion_key_file")
+	case key != "":
+		material = []byte(key)
+	case keyFile != "":
+		data, err := os.ReadFile(keyFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("private_key_jwt: reading client_assertion_key_file: %w", err)
+		}
+		material = data
+	default:
+		fn := clientCertificateFromContext(ctx)
+		if fn == nil {
+			return nil, nil, fmt.Errorf("private_key_jwt: client_assertion_key, client_assertion_key_file, or a profile client certificate or tls_signer is required")
+		}
+		cert, err := fn()
+		if err != nil {
+			return nil, nil, fmt.Errorf("private_key_jwt: %w", err)
+		}
+		signer, ok := cert.PrivateKey.(crypto.Signer)
+		if !ok {
+			return nil, nil, fmt.Errorf("private_key_jwt: client certificate key cannot sign")
+		}
+		return signer, cert, nil
+	}
+	parsed, err := parsePEMPrivateKey("private_key_jwt", material)
+	if err != nil {
+		return nil, nil, err
+	}
+	signer, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, nil, fmt.Errorf("private_key_jwt: unsupported private key type %T", parsed)
+	}
+	return signer, nil, nil
+}
+
+// jwsAlgForKey validates alg against the key type, or picks the usual
+// algorithm for it when alg is empty. handler and param name the setting in
+// errors.
+func jwsAlgForKey(handler, param, alg string, pub crypto.PublicKey) (string, error) {
+	alg = strings.TrimSpace(alg)
+	var supported []string
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		supported = []string{"RS256", "PS256"}
+	case *ecdsa.PublicKey:
+		switch k.Curve.Params().Name {
+		case "P-256":
+			supported = []string{"ES256"}
+		case "P-384":
+			supported = []string{"ES384"}
+		default:
+			return "", fmt.Errorf("%s: unsupported ECDSA curve %s", handler, k.Curve.Params().Name)
+		}
+	case ed25519.PublicKey:
+		supported = []string{"EdDSA"}
+	default:
+		return "", fmt.Errorf("%s: unsupported key type %T", handler, pub)
+	}
+	if alg == "" {
+		return supported[0], nil
+	}
+	for _, candidate := range supported {
+		if strings.EqualFold(alg, candidate) {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("%s: %s %q does not match the key (supported: %s)", handler, param, alg, strings.Join(supported, ", "))
+}
+
+// signJWS signs a JWS signing input with signer, returning the signature in
+// JWS form. ECDSA signatures are converted from ASN.1 to fixed-width r||s.
+func signJWS(signer crypto.Signer, alg string, input []byte) ([]byte, error) {
+	switch alg {
+	case "RS256":
+		digest := sha256.Sum256(input)
+		return signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	case "PS256":
+		digest := sha256.Sum256(input)
+		return signer.Sign(rand.Reader, digest[:], &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: crypto.SHA256})
+	case "ES256":
+		digest := sha256.Sum256(input)
+		return signECDSAJWS(signer, digest[:], crypto.SHA256, 32)
+	case "ES384":
+		digest := sha512.Sum384(input)
+		return signECDSAJWS(signer, digest[:], crypto.SHA384, 48)
+	case "EdDSA":
+		return signer.Sign(rand.Reader, input, crypto.Hash(0))
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", alg)
+	}
+}
+
+func signECDSAJWS(signer crypto.Signer, digest []byte, hash crypto.Hash, size int) ([]byte, error) {
+	der, err := signer.Sign(rand.Reader, digest, hash)
+	if err != nil {
+		return nil, err
+	}
+	var parsed struct{ R, S *big.Int }
+	if _, err := asn1.Unmarshal(der, &parsed); err != nil {
+		return nil, fmt.Errorf("decoding ECDSA signature: %w", err)
+	}
+	sig := make([]byte, 2*size)
+	parsed.R.FillBytes(sig[:size])
+	parsed.S.FillBytes(sig[size:])
+	return sig, nil
+}
@@ -0,0 +1,178 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestClientCredentials_PrivateKeyJWTSignsAssertion(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	var got url.Values
+	h := &ClientCredentials{HTTPClient: testHTTPClient(func(r *http.Request) (*http.Response, error) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatalf("ParseForm: %v", err)
+		}
+		got = r.PostForm
+		return testResponse(200, "application/json", `{"access_token":"tok","token_type":"Bearer","expires_in":600}`), nil
+	})}
+	req, _ := http.NewRequest("GET", "https://api.example.com/items", nil)
+	err = h.OnRequest(req, map[string]string{
+		"client_id":            "svc",
+		"client_secret":        "unused",
+		"auth_method":          "private_key_jwt",
+		"client_assertion_key": string(keyPEM),
+		"client_assertion_alg": "ps256",
+		"client_assertion_kid": "key-1",
+		"token_url":            "https://auth.example.com/token",
+	})
+	if err != nil {
+		t.Fatalf("OnRequest: %v", err)
+	}
+	if got.Get("client_secret") != "" || got.Get("client_assertion_type") != clientAssertionType {
+		t.Fatalf("unexpected form values: %#v", got)
+	}
+	for name := range got {
+		if strings.HasPrefix(name, "client_assertion_") && name != "client_assertion_type" {
+			t.Fatalf("client assertion config leaked into the form: %#v", got)
+		}
+	}
+	parts := strings.Split(got.Get("client_assertion"), ".")
+	if len(parts) != 3 {
+		t.Fatalf("client_assertion = %q", got.Get("client_assertion"))
+	}
+	var header map[string]string
+	var claims map[string]any
+	decodeTestJWTPart(t, parts[0], &header)
+	decodeTestJWTPart(t, parts[1], &claims)
+	if header["alg"] != "PS256" || header["kid"] != "key-1" {
+		t.Fatalf("header = %#v", header)
+	}
+	if claims["iss"] != "svc" || claims["sub"] != "svc" || claims["aud"] != "https://auth.example.com/token" || claims["jti"] == "" {
+		t.Fatalf("claims = %#v", claims)
+	}
+	if exp, iat := claims["exp"].(float64), claims["iat"].(float64); exp-iat != clientAssertionLifetime.Seconds() {
+		t.Fatalf("exp - iat = %v", exp-iat)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		t.Fatalf("decode signature: %v", err)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPSS(&key.PublicKey, crypto.SHA256, digest[:], sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}); err != nil {
+		t.Fatalf("VerifyPSS: %v", err)
+	}
+}
+
+func TestClientAssertionES256Signature(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey: %v", err)
+	}
+	assertion, err := clientAssertion(context.Background(), map[string]string{
+		"client_id":                 "svc",
+		"client_assertion_key":      string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"client_assertion_audience": "https://issuer.example.com",
+	}, "https://auth.example.com/token")
+	if err != nil {
+		t.Fatalf("clientAssertion: %v", err)
+	}
+	parts := strings.Split(assertion, ".")
+	var claims map[string]any
+	decodeTestJWTPart(t, parts[1], &claims)
+	if claims["aud"] != "https://issuer.example.com" {
+		t.Fatalf("aud = %v", claims["aud"])
+	}
+	sig, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	if len(sig) != 64 {
+		t.Fatalf("signature length = %d, want 64", len(sig))
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+	if !ecdsa.Verify(&key.PublicKey, digest[:], r, s) {
+		t.Fatal("ES256 signature does not verify")
+	}
+}
+
+func TestClientAssertionRejectsMismatchedAlgAndMissingKey(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der, _ := x509.MarshalPKCS8PrivateKey(key)
+	_, err := clientAssertion(context.Background(), map[string]string{
+		"client_id":            "svc",
+		"client_assertion_key": string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"client_assertion_alg": "RS256",
+	}, "https://auth.example.com/token")
+	if err == nil || !strings.Contains(err.Error(), "does not match the key") {
+		t.Fatalf("err = %v", err)
+	}
+	_, err = clientAssertion(context.Background(), map[string]string{"client_id": "svc"}, "https://auth.example.com/token")
+	if err == nil || !strings.Contains(err.Error(), "client_assertion_key") {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestClientCredentials_TLSClientAuthSendsClientIDOnly(t *testing.T) {
+	var got url.Values
+	var authz string
+	h := &ClientCredentials{HTTPClient: testHTTPClient(func(r *http.Request) (*http.Response, error) {
+		authz = r.Header.Get("Authorization")
+		if err := r.ParseForm(); err != nil {
+			t.Fatalf("ParseForm: %v", err)
+		}
+		got = r.PostForm
+		return testResponse(200, "application/json", `{"access_token":"tok","token_type":"Bearer","expires_in":600}`), nil
+	})}
+	req, _ := http.NewRequest("GET", "https://api.example.com/items", nil)
+	err := h.OnRequest(req, map[string]string{
+		"client_id":     "svc",
+		"client_secret": "unused",
+		"auth_method":   "tls_client_auth",
+		"token_url":     "https://auth.example.com/token",
+	})
+	if err != nil {
+		t.Fatalf("OnRequest: %v", err)
+	}
+	if got.Get("client_id") != "svc" || got.Get("client_secret") != "" || authz != "" {
+		t.Fatalf("form = %#v, Authorization = %q", got, authz)
+	}
+	if !UsesProfileClientCertificate(map[string]string{"auth_method": "tls_client_auth"}) ||
+		!UsesProfileClientCertificate(map[string]string{"auth_method": "private_key_jwt"}) ||
+		UsesProfileClientCertificate(map[string]string{"auth_method": "private_key_jwt", "client_assertion_key_file": "key.pem"}) {
+		t.Fatal("UsesProfileClientCertificate returned an unexpected result")
+	}
+	if !UsesClientSecret(map[string]string{}) || UsesClientSecret(map[string]string{"auth_method": "tls_client_auth"}) {
+		t.Fatal("UsesClientSecret returned an unexpected result")
+	}
+}
+
+func decodeTestJWTPart(t *testing.T, part string, v any) {
+	t.Helper()
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		t.Fatalf("decode JWT part: %v", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatalf("unmarshal JWT part: %v", err)
+	}
+}
@@ -19,14 +19,14 @@ type ClientCredentials struct {
 }
 
 func (h *ClientCredentials) Parameters() []auth.Param {
-	return appendOAuthPassthroughParams([]auth.Param{
+	return appendOAuthPassthroughParams(appendOAuthLogoutParams(appendOAuthDPoPParams(appendOAuthClientAuthParams([]auth.Param{
 		{Name: "client_id", Description: "OAuth2 client ID", Required: true},
 		{Name: "client_secret", Description: "OAuth2 client secret", Required: true, Secret: true},
-		{Name: "auth_method", Description: "OAuth2 client auth method: client_secret_post (default) or client_secret_basic", Required: false},
 		{Name: "token_url", Description: "OAuth2 token endpoint URL", Required: false},
 		{Name: "issuer_url", Description: "OIDC issuer URL (used for discovery when token_url is absent)", Required: false},
+		{Name: "oauth2_metadata_url", Description: "OAuth authorization server metadata URL (RFC 8414), used for discovery when issuer_url is absent", Required: false},
 		{Name: "scopes", Description: "Space-separated OAuth2 scopes to request", Required: false},
-	})
+	}))))
 }
 
 func (h *ClientCredentials) OnRequest(req *http.Request, params map[string]string) error {
@@ -34,12 +34,15 @@ func (h *ClientCredentials) OnRequest(req *http.Request, params map[string]strin
 }
 
 func (h *ClientCredentials) authenticateRequest(req *http.Request, params map[string]string, force bool) error {
-	token, err := h.resolveToken(req.Context(), params, force)
+	ctx, dpop, err := startDPoP(req.Context(), h.Cache, params)
+	if err != nil {
+		return err
+	}
+	token, err := h.resolveToken(ctx, params, force)
 	if err != nil {
 		return err
 	}
-	bearerAuth(req, token)
-	return nil
+	return applyAccessToken(req, dpop, token, params)
 }
 
 func (h *ClientCredentials) Authenticate(ctx context.Context, req *http.Request, ac auth.AuthContext) error {
@@ -54,62 +57,28 @@ func (h *ClientCredentials) Authenticate(ctx context.Context, req *http.Request,
 		h2.HTTPClient = ac.HTTPClient
 	}
 	req = requestWithContext(req, ctx)
-	return h2.authenticateRequest(req, authParams(ac), ac.Force)
+	return h2.authenticateRequest(req, authParams(ac), oauthForce(ac))
 }
 
 func (h *ClientCredentials) SupportsForce() {}
 
 func (h *ClientCredentials) resolveToken(ctx context.Context, params map[string]string, force bool) (string, error) {
-	cacheKey := params["_cache_key"]
-
-	// Check cache.
-	if !force && h.Cache != nil && cacheKey != "" {
-		cached, err := h.Cache.Get(cacheKey)
-		if err == nil && cached != nil && !cached.IsExpired() {
-			return cached.AccessToken, nil
-		}
-	}
-
-	// Resolve token URL (possibly via OIDC discovery).
-	tokenURL := params["token_url"]
-	if tokenURL != "" {
-		resolved, err := resolveOAuthEndpoint("token_url", tokenURL, params["_base_url"])
+	return cachedGrantToken(h.Cache, params, force, func() (auth.CachedToken, error) {
+		tokenURL, err := oauthTokenEndpoint(ctx, h.HTTPClient, "oauth-client-credentials", params)
 		if err != nil {
-			return "", err
+			return auth.CachedToken{}, err
 		}
-		tokenURL = resolved
-	} else {
-		issuer := params["issuer_url"]
-		if issuer == "" {
-			return "", fmt.Errorf("oauth-client-credentials: token_url or issuer_url is required")
+		form := url.Values{
+			"grant_type": {"client_credentials"},
+			"client_id":  {params["client_id"]},
 		}
-		oidc, err := DiscoverOIDC(ctx, h.HTTPClient, issuer)
-		if err != nil {
-			return "", err
+		if scopes := params["scopes"]; scopes != "" {
+			form.Set("scope", scopes)
 		}
-		if err := validateOIDCEndpoints(issuer, oidc); err != nil {
-			return "", err
+		ct, err := FetchToken(ctx, h.HTTPClient, tokenURL, form, params)
+		if err != nil {
+			return auth.CachedToken{}, fmt.Errorf("oauth-client-credentials: %w", err)
 		}
-		tokenURL = oidc.TokenEndpoint
-	}
-
-	// Fetch a new token.
-	form := url.Values{
-		"grant_type": {"client_credentials"},
-		"client_id":  {params["client_id"]},
-	}
-	if scopes := params["scopes"]; scopes != "" {
-		form.Set("scope", scopes)
-	}
-
-	ct, err := FetchToken(ctx, h.HTTPClient, tokenURL, form, params)
-	if err != nil {
-		return "", fmt.Errorf("oauth-client-credentials: %w", err)
-	}
-
-	if h.Cache != nil && cacheKey != "" {
-		_ = h.Cache.Set(cacheKey, ct)
-	}
-
-	return ct.AccessToken, nil
+		return ct, nil
+	})
 }
@@ -198,6 +198,79 @@ func TestClientCredentials_OIDCDiscovery(t *testing.T) {
 	}
 }
 
+func TestClientCredentials_OAuthMetadataDiscovery(t *testing.T) {
+	client := testHTTPClient(func(r *http.Request) (*http.Response, error) {
+		switch r.URL.String() {
+		case "https://auth.example.com/.well-known/oauth-authorization-server/tenant":
+			return testResponse(200, "application/json", `{"issuer":"https://auth.example.com/tenant","token_endpoint":"https://auth.example.com/tenant/token"}`), nil
+		case "https://auth.example.com/tenant/token":
+			return testResponse(200, "application/json", `{"access_token":"metadata-token","token_type":"bearer","expires_in":3600}`), nil
+		default:
+			t.Fatalf("unexpected URL %q", r.URL.String())
+			return nil, nil
+		}
+	})
+
+	h := &ClientCredentials{HTTPClient: client}
+	req, _ := http.NewRequest("GET", "https://api.example.com", nil)
+	params := map[string]string{
+		"client_id":           "id1",
+		"client_secret":       "sec1",
+		"oauth2_metadata_url": "https://auth.example.com/.well-known/oauth-authorization-server/tenant",
+	}
+	if err := h.OnRequest(req, params); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := req.Header.Get("Authorization"); got != "Bearer metadata-token" {
+		t.Errorf("Authorization: got %q, want %q", got, "Bearer metadata-token")
+	}
+}
+
+func TestClientCredentials_OAuthMetadataRejectsForeignIssuer(t *testing.T) {
+	client := testHTTPClient(func(r *http.Request) (*http.Response, error) {
+		if r.URL.String() != "https://auth.example.com/.well-known/oauth-authorization-server" {
+			t.Fatalf("unexpected URL %q", r.URL.String())
+		}
+		return testResponse(200, "application/json", `{"issuer":"https://evil.example.net","token_endpoint":"https://evil.example.net/token"}`), nil
+	})
+
+	h := &ClientCredentials{HTTPClient: client}
+	req, _ := http.NewRequest("GET", "https://api.example.com", nil)
+	params := map[string]string{
+		"client_id":           "id1",
+		"client_secret":       "sec1",
+		"oauth2_metadata_url": "https://auth.example.com/.well-known/oauth-authorization-server",
+	}
+	err := h.OnRequest(req, params)
+	if err == nil || !strings.Contains(err.Error(), "not on the metadata host") {
+		t.Fatalf("expected foreign issuer error, got %v", err)
+	}
+}
+
+func TestClientCredentials_OAuthMetadataFailureNamesURL(t *testing.T) {
+	const metadataURL = "https://auth.example.com/.well-known/oauth-authorization-server"
+	for name, resp := range map[string]*http.Response{
+		"status": testResponse(404, "text/plain", "not found"),
+		"json":   testResponse(200, "application/json", `{"issuer":`),
+	} {
+		t.Run(name, func(t *testing.T) {
+			client := testHTTPClient(func(r *http.Request) (*http.Response, error) {
+				return resp, nil
+			})
+			h := &ClientCredentials{HTTPClient: client}
+			req, _ := http.NewRequest("GET", "https://api.example.com", nil)
+			err := h.OnRequest(req, map[string]string{
+				"client_id":           "id1",
+				"client_secret":       "sec1",
+				"oauth2_metadata_url": metadataURL,
+			})
+			if err == nil || !strings.Contains(err.Error(), "oauth2MetadataUrl "+metadataURL+":") {
+				t.Fatalf("expected error naming oauth2MetadataUrl and %s, got %v", metadataURL, err)
+			}
+		})
+	}
+}
+
 func TestClientCredentials_SendsExpectedFormFields(t *testing.T) {
 	var got url.Values
 	client := testHTTPClient(func(r *http.Request) (*http.Response, error) {
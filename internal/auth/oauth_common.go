@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"github.com/rest-sh/restish/v2/auth"
 	"io"
+	"maps"
 	"net"
 	"net/http"
 	"net/url"
@@ -21,9 +22,12 @@ import (
 )
 
 const (
-	authMethodClientSecretPost  = "client_secret_post"
-	authMethodClientSecretBasic = "client_secret_basic"
-	maxOAuthEndpointBodyBytes   = 1 << 20
+	authMethodClientSecretPost        = "client_secret_post"
+	authMethodClientSecretBasic       = "client_secret_basic"
+	authMethodPrivateKeyJWT           = "private_key_jwt"
+	authMethodTLSClientAuth           = "tls_client_auth"
+	authMethodSelfSignedTLSClientAuth = "self_signed_tls_client_auth"
+	maxOAuthEndpointBodyBytes         = 1 << 20
 )
 
 func appendOAuthPassthroughParams(params []auth.Param) []auth.Param {
@@ -56,6 +60,7 @@ type tokenResponse struct {
 	TokenType    string          `json:"token_type"`
 	ExpiresIn    secondsOrString `json:"expires_in"` // seconds; 0 means no expiry info
 	RefreshToken string          `json:"refresh_token,omitempty"`
+	IDToken      string          `json:"id_token,omitempty"`
 	Scope        string          `json:"scope,omitempty"`
 }
 
@@ -84,6 +89,8 @@ type OIDCConfig struct {
 	AuthorizationEndpoint       string `json:"authorization_endpoint"`
 	DeviceAuthorizationEndpoint string `json:"device_authorization_endpoint"`
 	TokenEndpoint               string `json:"token_endpoint"`
+	RevocationEndpoint          string `json:"revocation_endpoint"`
+	EndSessionEndpoint          string `json:"end_session_endpoint"`
 }
 
 type tokenEndpointError struct {
@@ -139,6 +146,105 @@ func DiscoverOIDC(ctx context.Context, client *http.Client, issuerURL string) (*
 	return &cfg, nil
 }
 
+// discoverOAuthMetadata fetches RFC 8414 authorization server metadata from
+// metadataURL, such as an OpenAPI 3.2 oauth2MetadataUrl. The document's
+// issuer must be on the metadata URL's host, and its endpoints are validated
+// against that issuer the same way as OIDC discovery results. Errors name the
+// oauth2MetadataUrl field and the URL so a bad spec value is easy to find.
+func discoverOAuthMetadata(ctx context.Context, client *http.Client, metadataURL, baseURL string) (*OIDCConfig, string, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resolved, err := resolveOAuthEndpoint("oauth2_metadata_url", metadataURL, baseURL)
+	if err != nil {
+		return nil, "", fmt.Errorf("OAuth metadata from oauth2MetadataUrl %s: %w", metadataURL, err)
+	}
+	cfg, issuer, err := fetchOAuthMetadata(ctx, client, resolved)
+	if err != nil {
+		return nil, "", fmt.Errorf("OAuth metadata from oauth2MetadataUrl %s: %w", resolved, err)
+	}
+	return cfg, issuer, nil
+}
+
+func fetchOAuthMetadata(ctx context.Context, client *http.Client, metadataURL string) (*OIDCConfig, string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", metadataURL, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, "", fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	body, err := readOAuthEndpointBody(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	var doc struct {
+		Issuer string `json:"issuer"`
+		OIDCConfig
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, "", fmt.Errorf("invalid metadata document: %w", err)
+	}
+	if doc.Issuer == "" {
+		return nil, "", errors.New("missing issuer")
+	}
+	if err := validateOAuthIssuerURL(doc.Issuer); err != nil {
+		return nil, "", err
+	}
+	if !sameOAuthHost(doc.Issuer, metadataURL) {
+		return nil, "", fmt.Errorf("issuer %q is not on the metadata host", doc.Issuer)
+	}
+	if err := validateOIDCEndpoints(doc.Issuer, &doc.OIDCConfig); err != nil {
+		return nil, "", err
+	}
+	return &doc.OIDCConfig, doc.Issuer, nil
+}
+
+// sameOAuthHost reports whether two absolute URLs share a scheme and
+// hostname. RFC 8414 metadata paths sit outside the issuer path, so only the
+// host is compared.
+func sameOAuthHost(a, b string) bool {
+	ua, errA := url.Parse(a)
+	ub, errB := url.Parse(b)
+	if errA != nil || errB != nil || ua.Scheme != ub.Scheme {
+		return false
+	}
+	hostA, errA := canonicalOIDCHostname(ua.Hostname())
+	hostB, errB := canonicalOIDCHostname(ub.Hostname())
+	return errA == nil && errB == nil && hostA == hostB
+}
+
+// hasOAuthDiscovery reports whether params name an authorization server to
+// discover endpoints from.
+func hasOAuthDiscovery(params map[string]string) bool {
+	return params["issuer_url"] != "" || params["oauth2_metadata_url"] != ""
+}
+
+// discoverOAuthServer loads and validates authorization server metadata from
+// issuer_url with OIDC discovery or, when that is absent, from
+// oauth2_metadata_url. It also returns the issuer the endpoints were
+// validated against.
+func discoverOAuthServer(ctx context.Context, client *http.Client, params map[string]string) (*OIDCConfig, string, error) {
+	issuer := params["issuer_url"]
+	if issuer == "" {
+		return discoverOAuthMetadata(ctx, client, params["oauth2_metadata_url"], params["_base_url"])
+	}
+	oidc, err := DiscoverOIDC(ctx, client, issuer)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := validateOIDCEndpoints(issuer, oidc); err != nil {
+		return nil, "", err
+	}
+	return oidc, issuer, nil
+}
+
 func validateOAuthIssuerURL(rawURL string) error {
 	u, err := url.Parse(rawURL)
 	if err != nil {
@@ -176,12 +282,19 @@ func validateOAuthIssuerURL(rawURL string) error {
 // relaxed when issuerURL itself uses http:// loopback (e.g. local dev), but
 // those endpoints must also stay on loopback.
 func validateOIDCEndpoints(issuerURL string, cfg *OIDCConfig) error {
+	return validateOIDCEndpointURLs(issuerURL, cfg.AuthorizationEndpoint, cfg.DeviceAuthorizationEndpoint, cfg.TokenEndpoint)
+}
+
+// validateOIDCEndpointURLs applies the validateOIDCEndpoints checks to
+// endpoints. Logout endpoints are validated separately, when they are used, so
+// an unusual end_session_endpoint never breaks token requests.
+func validateOIDCEndpointURLs(issuerURL string, endpoints ...string) error {
 	issuer, err := url.Parse(issuerURL)
 	if err != nil {
 		return fmt.Errorf("OIDC: invalid issuer URL %q: %w", issuerURL, err)
 	}
 	if issuer.Scheme == "http" && isLoopbackOAuthHost(issuer.Hostname()) {
-		for _, endpoint := range []string{cfg.AuthorizationEndpoint, cfg.DeviceAuthorizationEndpoint, cfg.TokenEndpoint} {
+		for _, endpoint := range endpoints {
 			if endpoint == "" {
 				continue
 			}
@@ -198,7 +311,7 @@ func validateOIDCEndpoints(issuerURL string, cfg *OIDCConfig) error {
 	if issuer.Scheme != "https" {
 		return fmt.Errorf("OIDC: issuer URL %q must use https unless the host is localhost or loopback http", issuerURL)
 	}
-	for _, endpoint := range []string{cfg.AuthorizationEndpoint, cfg.DeviceAuthorizationEndpoint, cfg.TokenEndpoint} {
+	for _, endpoint := range endpoints {
 		if endpoint == "" {
 			continue
 		}
@@ -328,8 +441,48 @@ func readOAuthEndpointBody(r io.Reader) ([]byte, error) {
 	return body, nil
 }
 
+// oauthTokenEndpoint resolves the token endpoint from token_url, falling back
+// to discovery on issuer_url or oauth2_metadata_url when token_url is absent.
+func oauthTokenEndpoint(ctx context.Context, client *http.Client, authType string, params map[string]string) (string, error) {
+	if tokenURL := params["token_url"]; tokenURL != "" {
+		return resolveOAuthEndpoint("token_url", tokenURL, params["_base_url"])
+	}
+	if !hasOAuthDiscovery(params) {
+		return "", fmt.Errorf("%s: token_url or issuer_url is required", authType)
+	}
+	oidc, _, err := discoverOAuthServer(ctx, client, params)
+	if err != nil {
+		return "", err
+	}
+	return oidc.TokenEndpoint, nil
+}
+
+// cachedGrantToken returns the cached access token for params["_cache_key"],
+// or runs fetch and caches its result. It serves grants that need no user
+// interaction and are simply re-run when the cached token expires.
+func cachedGrantToken(cache auth.TokenStore, params map[string]string, force bool, fetch func() (auth.CachedToken, error)) (string, error) {
+	cacheKey := params["_cache_key"]
+	if !force && cache != nil && cacheKey != "" {
+		cached, err := cache.Get(cacheKey)
+		if err == nil && cached != nil && !cached.IsExpired() {
+			return cached.AccessToken, nil
+		}
+	}
+	ct, err := fetch()
+	if err != nil {
+		return "", err
+	}
+	if cache != nil && cacheKey != "" {
+		_ = cache.Set(cacheKey, ct)
+	}
+	return ct.AccessToken, nil
+}
+
 // FetchToken posts a token request to tokenURL and returns a auth.CachedToken.
-// Pass nil for client to use http.DefaultClient.
+// Pass nil for client to use http.DefaultClient. When ctx carries a DPoP
+// session the request includes a proof, is retried once with a fresh
+// DPoP-Nonce when the server asks for one, and the returned token records the
+// key it is bound to.
 func FetchToken(ctx context.Context, client *http.Client, tokenURL string, form url.Values, params map[string]string) (auth.CachedToken, error) {
 	if client == nil {
 		client = http.DefaultClient
@@ -338,29 +491,20 @@ func FetchToken(ctx context.Context, client *http.Client, tokenURL string, form
 	if form == nil {
 		form = url.Values{}
 	}
-	applyOAuthTokenExtraParams(form, params)
-	if err := applyTokenAuthHeaders(form, params); err != nil {
-		return auth.CachedToken{}, err
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "POST", tokenURL, strings.NewReader(form.Encode()))
-	if err != nil {
-		return auth.CachedToken{}, err
-	}
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	req.Header.Set("Accept", "application/json")
-	applyTokenAuthHeader(req, params)
-	resp, err := client.Do(req)
-	if err != nil {
-		return auth.CachedToken{}, err
-	}
-	defer resp.Body.Close()
-	body, err := readOAuthEndpointBody(resp.Body)
-	if err != nil {
-		return auth.CachedToken{}, fmt.Errorf("reading token endpoint response: %w", err)
-	}
-	if resp.StatusCode != 200 {
-		return auth.CachedToken{}, parseTokenEndpointError(resp.StatusCode, body)
+	dpop := dpopSessionFromContext(ctx)
+	var (
+		body []byte
+		err  error
+	)
+	for attempt := 0; ; attempt++ {
+		var nonce string
+		body, nonce, err = postTokenRequest(ctx, client, tokenURL, form, params, dpop)
+		if err == nil {
+			break
+		}
+		if dpop == nil || attempt > 0 || nonce == "" || !isTokenEndpointErrorCode(err, "use_dpop_nonce") {
+			return auth.CachedToken{}, err
+		}
 	}
 	var tok tokenResponse
 	if err := json.Unmarshal(body, &tok); err != nil {
@@ -369,20 +513,70 @@ func FetchToken(ctx context.Context, client *http.Client, tokenURL string, form
 	if strings.TrimSpace(tok.AccessToken) == "" {
 		return auth.CachedToken{}, fmt.Errorf("token endpoint response missing access_token")
 	}
-	if tt := strings.TrimSpace(tok.TokenType); tt != "" && !strings.EqualFold(tt, "bearer") {
+	// RFC 8693 issues "N_A" for exchanged tokens that are not access tokens.
+	if tt := strings.TrimSpace(tok.TokenType); tt != "" && !strings.EqualFold(tt, "bearer") &&
+		!(tt == "N_A" && form.Get("grant_type") == tokenExchangeGrantType) &&
+		!(dpop != nil && strings.EqualFold(tt, dpopTokenType)) {
 		return auth.CachedToken{}, fmt.Errorf("token endpoint response has unsupported token_type %q", tok.TokenType)
 	}
 	ct := auth.CachedToken{
 		AccessToken:  tok.AccessToken,
 		TokenType:    tok.TokenType,
 		RefreshToken: tok.RefreshToken,
+		IDToken:      tok.IDToken,
 	}
 	if tok.ExpiresIn > 0 {
 		ct.Expiry = time.Now().Add(time.Duration(tok.ExpiresIn) * time.Second)
 	}
+	if dpop != nil {
+		ct.DPoPKey = dpop.encoded
+		ct.DPoPNonces = maps.Clone(dpop.nonces)
+		dpop.tokenType = ct.TokenType
+	}
 	return ct, nil
 }
 
+// postTokenRequest sends one token request and returns the 200 response body,
+// along with any DPoP-Nonce the endpoint returned.
+func postTokenRequest(ctx context.Context, client *http.Client, tokenURL string, form url.Values, params map[string]string, dpop *dpopSession) ([]byte, string, error) {
+	applyOAuthTokenExtraParams(form, params)
+	if err := applyTokenAuthHeaders(ctx, form, params, tokenURL); err != nil {
+		return nil, "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	applyTokenAuthHeader(req, params)
+	if dpop != nil {
+		proof, err := dpop.proof(req.Method, req.URL, "", dpop.nonce(req.URL))
+		if err != nil {
+			return nil, "", err
+		}
+		req.Header.Set("DPoP", proof)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	nonce := resp.Header.Get("DPoP-Nonce")
+	if dpop != nil {
+		dpop.rememberNonce(req.URL, nonce)
+	}
+	body, err := readOAuthEndpointBody(resp.Body)
+	if err != nil {
+		return nil, nonce, fmt.Errorf("reading token endpoint response: %w", err)
+	}
+	if resp.StatusCode != 200 {
+		return nil, nonce, parseTokenEndpointError(resp.StatusCode, body)
+	}
+	return body, nonce, nil
+}
+
 func oauthTokenHTTPClient(src *http.Client) *http.Client {
 	if src == nil {
 		src = http.DefaultClient
@@ -449,21 +643,63 @@ func oauthAuthMethod(params map[string]string) (string, error) {
 	switch method := params["auth_method"]; method {
 	case "", authMethodClientSecretPost:
 		return authMethodClientSecretPost, nil
-	case authMethodClientSecretBasic:
-		return authMethodClientSecretBasic, nil
+	case authMethodClientSecretBasic, authMethodPrivateKeyJWT, authMethodTLSClientAuth, authMethodSelfSignedTLSClientAuth:
+		return method, nil
 	default:
 		return "", fmt.Errorf("unsupported auth_method %q", method)
 	}
 }
 
-func applyTokenAuthHeaders(form url.Values, params map[string]string) error {
+// IsTLSClientAuthMethod reports whether auth_method authenticates the client
+// with a TLS client certificate (RFC 8705), so token requests need mTLS.
+func IsTLSClientAuthMethod(method string) bool {
+	return method == authMethodTLSClientAuth || method == authMethodSelfSignedTLSClientAuth
+}
+
+// UsesClientSecret reports whether auth_method sends client_secret to the
+// token endpoint. Key-based methods authenticate without one.
+func UsesClientSecret(params map[string]string) bool {
+	method, err := oauthAuthMethod(params)
+	return err != nil || method == authMethodClientSecretPost || method == authMethodClientSecretBasic
+}
+
+// UsesProfileClientCertificate reports whether the auth params authenticate to
+// the token endpoint with the profile's TLS client identity, either over mTLS
+// or by signing private_key_jwt assertions with its key.
+func UsesProfileClientCertificate(params map[string]string) bool {
+	method := params["auth_method"]
+	if IsTLSClientAuthMethod(method) {
+		return true
+	}
+	return method == authMethodPrivateKeyJWT && params["client_assertion_key"] == "" && params["client_assertion_key_file"] == ""
+}
+
+func applyTokenAuthHeaders(ctx context.Context, form url.Values, params map[string]string, tokenURL string) error {
 	method, err := oauthAuthMethod(params)
 	if err != nil {
 		return err
 	}
-	if method == authMethodClientSecretBasic {
+	switch method {
+	case authMethodClientSecretBasic:
 		form.Del("client_secret")
 		return nil
+	case authMethodTLSClientAuth, authMethodSelfSignedTLSClientAuth:
+		// RFC 8705 §2: the client is authenticated by the TLS handshake and
+		// identified by client_id.
+		form.Del("client_secret")
+		if form.Get("client_id") == "" && params["client_id"] != "" {
+			form.Set("client_id", params["client_id"])
+		}
+		return nil
+	case authMethodPrivateKeyJWT:
+		form.Del("client_secret")
+		assertion, err := clientAssertion(ctx, params, tokenURL)
+		if err != nil {
+			return err
+		}
+		form.Set("client_assertion_type", clientAssertionType)
+		form.Set("client_assertion", assertion)
+		return nil
 	}
 	clientSecret := params["client_secret"]
 	if clientSecret != "" && form.Get("client_secret") == "" {
@@ -485,20 +721,24 @@ func applyOAuthTokenExtraParams(form url.Values, params map[string]string) {
 	for key, value := range extraOAuthParams(params, map[string]bool{
 		"_cache_key":             true,
 		"_base_url":              true,
+		"actor_token_profile":    true,
+		"actor_token_type":       true,
+		"assertion_profile":      true,
 		"authorize_url":          true,
 		"cache_key":              true,
+		dpopNonceParam:           true,
 		callbackErrorHTMLParam:   true,
 		callbackSuccessHTMLParam: true,
 		"issuer_url":             true,
-		// TODO(openapi-3.2): use oauth2_metadata_url for RFC 8414 metadata
-		// discovery in place of, or alongside, issuer_url.
-		"oauth2_metadata_url": true,
-		"redirect_cert":       true,
-		"redirect_key":        true,
-		"redirect_path":       true,
-		"redirect_port":       true,
-		"redirect_scheme":     true,
-		"token_url":           true,
+		"redirect_cert":          true,
+		"redirect_key":           true,
+		"redirect_path":          true,
+		"redirect_port":          true,
+		"redirect_scheme":        true,
+		"requested_token_type":   true,
+		"subject_token_profile":  true,
+		"subject_token_type":     true,
+		"token_url":              true,
 	}) {
 		if form.Get(key) == "" {
 			form.Set(key, value)
@@ -602,7 +842,9 @@ func extraOAuthParams(params map[string]string, reserved map[string]bool) map[st
 			continue
 		}
 		switch key {
-		case "auth_method", "client_id", "client_secret", "scopes":
+		case "auth_method", "client_id", "client_secret", "dpop", "dpop_alg", "scopes",
+			"client_assertion_alg", "client_assertion_audience", "client_assertion_key", "client_assertion_key_file", "client_assertion_kid",
+			"end_session_url", "oauth2_metadata_url", "post_logout_redirect_uri", "revocation_url":
 			continue
 		}
 		extra[key] = value
@@ -33,15 +33,15 @@ type DeviceCode struct {
 }
 
 func (h *DeviceCode) Parameters() []auth.Param {
-	return appendOAuthPassthroughParams([]auth.Param{
+	return appendOAuthPassthroughParams(appendOAuthLogoutParams(appendOAuthDPoPParams(appendOAuthClientAuthParams([]auth.Param{
 		{Name: "client_id", Description: "OAuth2 client ID", Required: true},
 		{Name: "client_secret", Description: "OAuth2 client secret (optional)", Required: false, Secret: true},
-		{Name: "auth_method", Description: "OAuth2 client auth method: client_secret_post (default) or client_secret_basic", Required: false},
 		{Name: "device_authorization_url", Description: "OAuth2 device authorization endpoint URL", Required: false},
 		{Name: "token_url", Description: "OAuth2 token endpoint URL", Required: false},
 		{Name: "issuer_url", Description: "OIDC issuer URL (used for discovery when endpoints are absent)", Required: false},
+		{Name: "oauth2_metadata_url", Description: "OAuth authorization server metadata URL (RFC 8414), used for discovery when issuer_url is absent", Required: false},
 		{Name: "scopes", Description: "Space-separated OAuth2 scopes to request; some providers require offline_access for refresh tokens", Required: false},
-	})
+	}))))
 }
 
 func (h *DeviceCode) OnRequest(req *http.Request, params map[string]string) error {
@@ -49,12 +49,15 @@ func (h *DeviceCode) OnRequest(req *http.Request, params map[string]string) erro
 }
 
 func (h *DeviceCode) authenticateRequest(req *http.Request, params map[string]string, force bool) error {
-	token, err := h.resolveToken(req.Context(), params, force)
+	ctx, dpop, err := startDPoP(req.Context(), h.Cache, params)
+	if err != nil {
+		return err
+	}
+	token, err := h.resolveToken(ctx, params, force)
 	if err != nil {
 		return err
 	}
-	bearerAuth(req, token)
-	return nil
+	return applyAccessToken(req, dpop, token, params)
 }
 
 func (h *DeviceCode) resolveToken(ctx context.Context, params map[string]string, force bool) (string, error) {
@@ -110,17 +113,13 @@ func (h *DeviceCode) resolveTokenURL(ctx context.Context, params map[string]stri
 		}
 		return resolved, nil
 	}
-	issuer := params["issuer_url"]
-	if issuer == "" {
+	if !hasOAuthDiscovery(params) {
 		return "", fmt.Errorf("oauth-device-code: token_url or issuer_url is required for token refresh")
 	}
-	oidc, err := DiscoverOIDC(ctx, h.HTTPClient, issuer)
+	oidc, _, err := discoverOAuthServer(ctx, h.HTTPClient, params)
 	if err != nil {
 		return "", err
 	}
-	if err := validateOIDCEndpoints(issuer, oidc); err != nil {
-		return "", err
-	}
 	if oidc.TokenEndpoint == "" {
 		return "", fmt.Errorf("oauth-device-code: issuer discovery did not provide token_endpoint")
 	}
@@ -147,17 +146,13 @@ func (h *DeviceCode) resolveEndpoints(ctx context.Context, params map[string]str
 	if deviceURL != "" && tokenURL != "" {
 		return deviceURL, tokenURL, nil
 	}
-	issuer := params["issuer_url"]
-	if issuer == "" {
+	if !hasOAuthDiscovery(params) {
 		return "", "", fmt.Errorf("oauth-device-code: (device_authorization_url and token_url) or issuer_url is required")
 	}
-	oidc, err := DiscoverOIDC(ctx, h.HTTPClient, issuer)
+	oidc, _, err := discoverOAuthServer(ctx, h.HTTPClient, params)
 	if err != nil {
 		return "", "", err
 	}
-	if err := validateOIDCEndpoints(issuer, oidc); err != nil {
-		return "", "", err
-	}
 	if deviceURL == "" {
 		deviceURL = oidc.DeviceAuthorizationEndpoint
 	}
@@ -319,7 +314,7 @@ func (h *DeviceCode) Authenticate(ctx context.Context, req *http.Request, ac aut
 		h2.Stderr = ac.Stderr
 	}
 	req = requestWithContext(req, ctx)
-	return h2.authenticateRequest(req, authParams(ac), ac.Force)
+	return h2.authenticateRequest(req, authParams(ac), oauthForce(ac))
 }
 
 func (h *DeviceCode) SupportsForce() {}
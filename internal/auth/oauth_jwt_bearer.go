@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"github.com/rest-sh/restish/v2/auth"
+	"net/http"
+	"net/url"
+)
+
+const jwtBearerGrantType = "urn:ietf:params:oauth:grant-type:jwt-bearer"
+
+// JWTBearer implements the JWT bearer authorization grant (RFC 7523 §2.1). A
+// JWT assertion, typically a CI or workload identity token, is presented to
+// the token endpoint in exchange for an access token. Tokens are cached under
+// params["_cache_key"] and the grant is repeated when they expire.
+type JWTBearer struct {
+	// Cache stores fetched tokens. If nil, tokens are not cached.
+	Cache auth.TokenStore
+	// HTTPClient is used for token requests. Defaults to http.DefaultClient when nil.
+	HTTPClient *http.Client
+	// ProfileToken resolves assertion_profile. When nil, it is rejected.
+	ProfileToken ProfileTokenFunc
+}
+
+func (h *JWTBearer) Parameters() []auth.Param {
+	return appendOAuthPassthroughParams(appendOAuthLogoutParams(appendOAuthDPoPParams(appendOAuthClientAuthParams([]auth.Param{
+		{Name: "assertion", Description: "JWT assertion; use a secret reference to read it from a file or command", Required: false, Secret: true},
+		{Name: "assertion_profile", Description: "API profile (api or api:profile) whose token is the assertion, instead of assertion", Required: false},
+		{Name: "client_id", Description: "OAuth2 client ID, when the provider authenticates the grant", Required: false},
+		{Name: "client_secret", Description: "OAuth2 client secret", Required: false, Secret: true},
+		{Name: "token_url", Description: "OAuth2 token endpoint URL", Required: false},
+		{Name: "issuer_url", Description: "OIDC issuer URL (used for discovery when token_url is absent)", Required: false},
+		{Name: "oauth2_metadata_url", Description: "OAuth authorization server metadata URL (RFC 8414), used for discovery when issuer_url is absent", Required: false},
+		{Name: "scopes", Description: "Space-separated OAuth2 scopes to request", Required: false},
+	}))))
+}
+
+func (h *JWTBearer) OnRequest(req *http.Request, params map[string]string) error {
+	return h.authenticateRequest(req, params, false)
+}
+
+func (h *JWTBearer) authenticateRequest(req *http.Request, params map[string]string, force bool) error {
+	ctx, dpop, err := startDPoP(req.Context(), h.Cache, params)
+	if err != nil {
+		return err
+	}
+	token, err := h.resolveToken(ctx, params, force)
+	if err != nil {
+		return err
+	}
+	return applyAccessToken(req, dpop, token, params)
+}
+
+func (h *JWTBearer) Authenticate(ctx context.Context, req *http.Request, ac auth.AuthContext) error {
+	h2 := &JWTBearer{
+		Cache:        h.Cache,
+		HTTPClient:   h.HTTPClient,
+		ProfileToken: h.ProfileToken,
+	}
+	if ac.TokenStore != nil {
+		h2.Cache = ac.TokenStore
+	}
+	if ac.HTTPClient != nil {
+		h2.HTTPClient = ac.HTTPClient
+	}
+	req = requestWithContext(req, ctx)
+	return h2.authenticateRequest(req, authParams(ac), oauthForce(ac))
+}
+
+func (h *JWTBearer) SupportsForce() {}
+
+func (h *JWTBearer) resolveToken(ctx context.Context, params map[string]string, force bool) (string, error) {
+	return cachedGrantToken(h.Cache, params, force, func() (auth.CachedToken, error) {
+		tokenURL, err := oauthTokenEndpoint(ctx, h.HTTPClient, "oauth-jwt-bearer", params)
+		if err != nil {
+			return auth.CachedToken{}, err
+		}
+		assertion, err := grantInputToken(ctx, h.ProfileToken, params, "assertion", TokenTypeAccessToken)
+		if err != nil {
+			return auth.CachedToken{}, fmt.Errorf("oauth-jwt-bearer: %w", err)
+		}
+		if assertion == "" {
+			return auth.CachedToken{}, fmt.Errorf("oauth-jwt-bearer: assertion or assertion_profile is required")
+		}
+		form := url.Values{
+			"grant_type": {jwtBearerGrantType},
+			"assertion":  {assertion},
+		}
+		if clientID := params["client_id"]; clientID != "" {
+			form.Set("client_id", clientID)
+		}
+		if scopes := params["scopes"]; scopes != "" {
+			form.Set("scope", scopes)
+		}
+		ct, err := FetchToken(ctx, h.HTTPClient, tokenURL, form, params)
+		if err != nil {
+			return auth.CachedToken{}, fmt.Errorf("oauth-jwt-bearer: %w", err)
+		}
+		return ct, nil
+	})
+}
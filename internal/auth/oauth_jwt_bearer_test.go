@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestJWTBearer_SendsAssertionGrant(t *testing.T) {
+	var got url.Values
+	var authz string
+	h := &JWTBearer{HTTPClient: testHTTPClient(func(r *http.Request) (*http.Response, error) {
+		authz = r.Header.Get("Authorization")
+		if err := r.ParseForm(); err != nil {
+			t.Fatalf("ParseForm: %v", err)
+		}
+		got = r.Form
+		return testResponse(200, "application/json", `{"access_token":"ci-token","token_type":"bearer","expires_in":600}`), nil
+	})}
+	req, _ := http.NewRequest("GET", "https://api.example.com/items", nil)
+	err := h.OnRequest(req, map[string]string{
+		"assertion":     "header.payload.signature",
+		"client_id":     "ci",
+		"client_secret": "sec",
+		"auth_method":   "client_secret_basic",
+		"scopes":        "deploy",
+		"token_url":     "https://auth.example.com/token",
+	})
+	if err != nil {
+		t.Fatalf("OnRequest: %v", err)
+	}
+	if got := req.Header.Get("Authorization"); got != "Bearer ci-token" {
+		t.Fatalf("Authorization = %q", got)
+	}
+	if got.Get("grant_type") != jwtBearerGrantType || got.Get("assertion") != "header.payload.signature" ||
+		got.Get("scope") != "deploy" || got.Get("client_id") != "ci" || got.Get("client_secret") != "" {
+		t.Fatalf("unexpected form values: %#v", got)
+	}
+	if !strings.HasPrefix(authz, "Basic ") {
+		t.Fatalf("token request Authorization = %q, want client_secret_basic", authz)
+	}
+}
+
+func TestJWTBearer_RequiresAssertion(t *testing.T) {
+	h := &JWTBearer{}
+	req, _ := http.NewRequest("GET", "https://api.example.com/items", nil)
+	err := h.OnRequest(req, map[string]string{"token_url": "https://auth.example.com/token"})
+	if err == nil || !strings.Contains(err.Error(), "assertion or assertion_profile is required") {
+		t.Fatalf("err = %v", err)
+	}
+}
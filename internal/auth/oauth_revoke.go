@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/rest-sh/restish/v2/auth"
+)
+
+// RevocationResult is the outcome of revoking one cached token.
+type RevocationResult struct {
+	// TokenType is the RFC 7009 token_type_hint: "refresh_token" or
+	// "access_token".
+	TokenType string
+	Err       error
+}
+
+func appendOAuthLogoutParams(params []auth.Param) []auth.Param {
+	return append(params,
+		auth.Param{Name: "revocation_url", Description: "OAuth2 token revocation endpoint (RFC 7009) for `api auth logout --revoke`; discovered from issuer_url when omitted", Required: false},
+		auth.Param{Name: "end_session_url", Description: "OIDC end-session endpoint for `api auth logout --end-session`; discovered from issuer_url when omitted", Required: false},
+		auth.Param{Name: "post_logout_redirect_uri", Description: "Where the provider redirects the browser after `api auth logout --end-session`", Required: false},
+	)
+}
+
+// RevokeOAuthTokens revokes the refresh token and then the access token in
+// token at the profile's revocation endpoint. Client authentication matches
+// the token endpoint's auth_method. The returned error reports a missing or
+// invalid endpoint; per-token failures are reported in the results.
+func RevokeOAuthTokens(ctx context.Context, ac auth.AuthContext, token auth.CachedToken) ([]RevocationResult, error) {
+	params := authParams(ac)
+	client := oauthTokenHTTPClient(ac.HTTPClient)
+	endpoint, err := oauthLogoutEndpoint(ctx, client, params, "revocation_url", func(cfg *OIDCConfig) string { return cfg.RevocationEndpoint })
+	if err != nil {
+		return nil, err
+	}
+	var results []RevocationResult
+	for _, t := range []struct{ hint, value string }{
+		{"refresh_token", token.RefreshToken},
+		{"access_token", token.AccessToken},
+	} {
+		if strings.TrimSpace(t.value) == "" {
+			continue
+		}
+		results = append(results, RevocationResult{
+			TokenType: t.hint,
+			Err:       revokeOAuthToken(ctx, client, endpoint, t.value, t.hint, params),
+		})
+	}
+	return results, nil
+}
+
+func revokeOAuthToken(ctx context.Context, client *http.Client, endpoint, token, hint string, params map[string]string) error {
+	form := url.Values{
+		"token":           {token},
+		"token_type_hint": {hint},
+	}
+	if params["client_id"] != "" {
+		form.Set("client_id", params["client_id"])
+	}
+	if err := applyTokenAuthHeaders(ctx, form, params, endpoint); err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	applyTokenAuthHeader(req, params)
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := readOAuthEndpointBody(resp.Body)
+	if err != nil {
+		return fmt.Errorf("reading revocation endpoint response: %w", err)
+	}
+	// RFC 7009 §2.2: the server answers 200 for revoked and for already
+	// invalid tokens alike.
+	if resp.StatusCode != http.StatusOK {
+		msg := fmt.Sprintf("revocation endpoint returned %d", resp.StatusCode)
+		if redacted := redactTokenEndpointBody(body); redacted != "" {
+			msg += ": " + redacted
+		}
+		return errors.New(msg)
+	}
+	return nil
+}
+
+// EndSessionURL returns the OIDC RP-initiated logout URL for token. The
+// browser has to visit it, because the provider ends its own session cookie.
+func EndSessionURL(ctx context.Context, ac auth.AuthContext, token auth.CachedToken) (string, error) {
+	params := authParams(ac)
+	endpoint, err := oauthLogoutEndpoint(ctx, oauthTokenHTTPClient(ac.HTTPClient), params, "end_session_url", func(cfg *OIDCConfig) string { return cfg.EndSessionEndpoint })
+	if err != nil {
+		return "", err
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	if token.IDToken != "" {
+		query.Set("id_token_hint", token.IDToken)
+	}
+	if params["client_id"] != "" {
+		query.Set("client_id", params["client_id"])
+	}
+	if redirect := params["post_logout_redirect_uri"]; redirect != "" {
+		query.Set("post_logout_redirect_uri", redirect)
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// oauthLogoutEndpoint resolves the endpoint named by param, falling back to
+// authorization server discovery.
+func oauthLogoutEndpoint(ctx context.Context, client *http.Client, params map[string]string, param string, discovered func(*OIDCConfig) string) (string, error) {
+	if rawURL := params[param]; rawURL != "" {
+		return resolveOAuthEndpoint(param, rawURL, params["_base_url"])
+	}
+	if !hasOAuthDiscovery(params) {
+		return "", fmt.Errorf("%s or issuer_url is required", param)
+	}
+	oidc, issuer, err := discoverOAuthServer(ctx, client, params)
+	if err != nil {
+		return "", err
+	}
+	endpoint := discovered(oidc)
+	if endpoint == "" {
+		return "", fmt.Errorf("discovery from %s does not advertise an endpoint for %s; set %s", issuer, param, param)
+	}
+	if err := validateOIDCEndpointURLs(issuer, endpoint); err != nil {
+		return "", err
+	}
+	return endpoint, nil
+}
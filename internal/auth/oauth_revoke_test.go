@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/rest-sh/restish/v2/auth"
+)
+
+func TestRevokeOAuthTokensUsesDiscoveredEndpointAndClientAuth(t *testing.T) {
+	var hints []string
+	client := testHTTPClient(func(r *http.Request) (*http.Response, error) {
+		switch r.URL.String() {
+		case "https://id.example.com/.well-known/openid-configuration":
+			return testResponse(200, "application/json", `{
+				"token_endpoint": "https://id.example.com/token",
+				"revocation_endpoint": "https://id.example.com/revoke",
+				"end_session_endpoint": "https://id.example.com/logout?ui=1"
+			}`), nil
+		case "https://id.example.com/revoke":
+			if user, pass, ok := r.BasicAuth(); !ok || user != "app" || pass != "sec" {
+				t.Errorf("revocation request has no client credentials")
+			}
+			if err := r.ParseForm(); err != nil {
+				t.Fatalf("ParseForm: %v", err)
+			}
+			if r.PostForm.Get("client_secret") != "" {
+				t.Errorf("client_secret sent in the form with client_secret_basic")
+			}
+			hints = append(hints, r.PostForm.Get("token_type_hint")+"="+r.PostForm.Get("token"))
+			return testResponse(200, "", ""), nil
+		}
+		return testResponse(404, "", ""), nil
+	})
+	ac := auth.AuthContext{HTTPClient: client, Params: map[string]string{
+		"client_id":     "app",
+		"client_secret": "sec",
+		"auth_method":   "client_secret_basic",
+		"issuer_url":    "https://id.example.com",
+	}}
+	token := auth.CachedToken{AccessToken: "at", RefreshToken: "rt", IDToken: "idt"}
+
+	results, err := RevokeOAuthTokens(context.Background(), ac, token)
+	if err != nil {
+		t.Fatalf("RevokeOAuthTokens: %v", err)
+	}
+	if len(results) != 2 || results[0].TokenType != "refresh_token" || results[0].Err != nil || results[1].Err != nil {
+		t.Fatalf("results = %#v", results)
+	}
+	if strings.Join(hints, ",") != "refresh_token=rt,access_token=at" {
+		t.Fatalf("revoked = %v", hints)
+	}
+
+	logoutURL, err := EndSessionURL(context.Background(), ac, token)
+	if err != nil {
+		t.Fatalf("EndSessionURL: %v", err)
+	}
+	if logoutURL != "https://id.example.com/logout?client_id=app&id_token_hint=idt&ui=1" {
+		t.Fatalf("logout URL = %q", logoutURL)
+	}
+}
+
+func TestRevokeOAuthTokensRequiresAnEndpoint(t *testing.T) {
+	_, err := RevokeOAuthTokens(context.Background(), auth.AuthContext{Params: map[string]string{"client_id": "app"}}, auth.CachedToken{AccessToken: "at"})
+	if err == nil || !strings.Contains(err.Error(), "revocation_url or issuer_url") {
+		t.Fatalf("err = %v", err)
+	}
+
+	client := testHTTPClient(func(r *http.Request) (*http.Response, error) {
+		return testResponse(200, "application/json", `{"token_endpoint":"https://id.example.com/token","revocation_endpoint":"https://evil.example.net/revoke"}`), nil
+	})
+	ac := auth.AuthContext{HTTPClient: client, Params: map[string]string{"issuer_url": "https://id.example.com"}}
+	if _, err := RevokeOAuthTokens(context.Background(), ac, auth.CachedToken{AccessToken: "at"}); err == nil || !strings.Contains(err.Error(), "does not match issuer hostname") {
+		t.Fatalf("err = %v", err)
+	}
+}
@@ -0,0 +1,171 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"github.com/rest-sh/restish/v2/auth"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	tokenExchangeGrantType = "urn:ietf:params:oauth:grant-type:token-exchange"
+	tokenTypeURNPrefix     = "urn:ietf:params:oauth:token-type:"
+
+	// TokenTypeAccessToken is the RFC 8693 token type URN for access tokens.
+	TokenTypeAccessToken = tokenTypeURNPrefix + "access_token"
+	// TokenTypeIDToken is the RFC 8693 token type URN for OIDC ID tokens.
+	TokenTypeIDToken = tokenTypeURNPrefix + "id_token"
+)
+
+// ProfileTokenFunc returns a token issued to another configured API profile.
+// ref names the profile as "api" or "api:profile"; tokenType is the RFC 8693
+// token type URN the caller needs, such as TokenTypeAccessToken.
+type ProfileTokenFunc func(ctx context.Context, ref, tokenType string) (string, error)
+
+// TokenExchange implements OAuth 2.0 Token Exchange (RFC 8693). It trades a
+// subject token, and optionally an actor token, for a token accepted by the
+// target API. Exchanged tokens are cached under params["_cache_key"] and the
+// exchange is repeated when they expire.
+type TokenExchange struct {
+	// Cache stores exchanged tokens. If nil, tokens are not cached.
+	Cache auth.TokenStore
+	// HTTPClient is used for token requests. Defaults to http.DefaultClient when nil.
+	HTTPClient *http.Client
+	// ProfileToken resolves subject_token_profile and actor_token_profile.
+	// When nil, those params are rejected.
+	ProfileToken ProfileTokenFunc
+}
+
+func (h *TokenExchange) Parameters() []auth.Param {
+	return appendOAuthPassthroughParams(appendOAuthLogoutParams(appendOAuthDPoPParams(appendOAuthClientAuthParams([]auth.Param{
+		{Name: "subject_token", Description: "Token to exchange; use a secret reference to read it from a file or command", Required: false, Secret: true},
+		{Name: "subject_token_profile", Description: "API profile (api or api:profile) whose token is exchanged, instead of subject_token", Required: false},
+		{Name: "subject_token_type", Description: "Subject token type URN or short name such as access_token, id_token, or jwt (default access_token)", Required: false},
+		{Name: "actor_token", Description: "Optional token of the party acting on behalf of the subject", Required: false, Secret: true},
+		{Name: "actor_token_profile", Description: "API profile (api or api:profile) whose token is the actor token, instead of actor_token", Required: false},
+		{Name: "actor_token_type", Description: "Actor token type URN or short name (default access_token)", Required: false},
+		{Name: "requested_token_type", Description: "Token type URN or short name to request from the provider", Required: false},
+		{Name: "client_id", Description: "OAuth2 client ID, when the provider authenticates the exchange", Required: false},
+		{Name: "client_secret", Description: "OAuth2 client secret", Required: false, Secret: true},
+		{Name: "token_url", Description: "OAuth2 token endpoint URL", Required: false},
+		{Name: "issuer_url", Description: "OIDC issuer URL (used for discovery when token_url is absent)", Required: false},
+		{Name: "oauth2_metadata_url", Description: "OAuth authorization server metadata URL (RFC 8414), used for discovery when issuer_url is absent", Required: false},
+		{Name: "scopes", Description: "Space-separated OAuth2 scopes to request", Required: false},
+	}))))
+}
+
+func (h *TokenExchange) OnRequest(req *http.Request, params map[string]string) error {
+	return h.authenticateRequest(req, params, false)
+}
+
+func (h *TokenExchange) authenticateRequest(req *http.Request, params map[string]string, force bool) error {
+	ctx, dpop, err := startDPoP(req.Context(), h.Cache, params)
+	if err != nil {
+		return err
+	}
+	token, err := h.resolveToken(ctx, params, force)
+	if err != nil {
+		return err
+	}
+	return applyAccessToken(req, dpop, token, params)
+}
+
+func (h *TokenExchange) Authenticate(ctx context.Context, req *http.Request, ac auth.AuthContext) error {
+	h2 := &TokenExchange{
+		Cache:        h.Cache,
+		HTTPClient:   h.HTTPClient,
+		ProfileToken: h.ProfileToken,
+	}
+	if ac.TokenStore != nil {
+		h2.Cache = ac.TokenStore
+	}
+	if ac.HTTPClient != nil {
+		h2.HTTPClient = ac.HTTPClient
+	}
+	req = requestWithContext(req, ctx)
+	return h2.authenticateRequest(req, authParams(ac), oauthForce(ac))
+}
+
+func (h *TokenExchange) SupportsForce() {}
+
+func (h *TokenExchange) resolveToken(ctx context.Context, params map[string]string, force bool) (string, error) {
+	return cachedGrantToken(h.Cache, params, force, func() (auth.CachedToken, error) {
+		tokenURL, err := oauthTokenEndpoint(ctx, h.HTTPClient, "oauth-token-exchange", params)
+		if err != nil {
+			return auth.CachedToken{}, err
+		}
+		subjectType := tokenTypeURN(params["subject_token_type"], TokenTypeAccessToken)
+		subject, err := grantInputToken(ctx, h.ProfileToken, params, "subject_token", subjectType)
+		if err != nil {
+			return auth.CachedToken{}, fmt.Errorf("oauth-token-exchange: %w", err)
+		}
+		if subject == "" {
+			return auth.CachedToken{}, fmt.Errorf("oauth-token-exchange: subject_token or subject_token_profile is required")
+		}
+		form := url.Values{
+			"grant_type":         {tokenExchangeGrantType},
+			"subject_token":      {subject},
+			"subject_token_type": {subjectType},
+		}
+		actorType := tokenTypeURN(params["actor_token_type"], TokenTypeAccessToken)
+		actor, err := grantInputToken(ctx, h.ProfileToken, params, "actor_token", actorType)
+		if err != nil {
+			return auth.CachedToken{}, fmt.Errorf("oauth-token-exchange: %w", err)
+		}
+		if actor != "" {
+			form.Set("actor_token", actor)
+			form.Set("actor_token_type", actorType)
+		}
+		if requested := tokenTypeURN(params["requested_token_type"], ""); requested != "" {
+			form.Set("requested_token_type", requested)
+		}
+		if clientID := params["client_id"]; clientID != "" {
+			form.Set("client_id", clientID)
+		}
+		if scopes := params["scopes"]; scopes != "" {
+			form.Set("scope", scopes)
+		}
+		ct, err := FetchToken(ctx, h.HTTPClient, tokenURL, form, params)
+		if err != nil {
+			return auth.CachedToken{}, fmt.Errorf("oauth-token-exchange: %w", err)
+		}
+		return ct, nil
+	})
+}
+
+// grantInputToken returns the token a grant sends on the user's behalf, read
+// from params[name] or, when params[name+"_profile"] is set, from another
+// profile through profileToken.
+func grantInputToken(ctx context.Context, profileToken ProfileTokenFunc, params map[string]string, name, tokenType string) (string, error) {
+	ref := strings.TrimSpace(params[name+"_profile"])
+	if ref == "" {
+		return strings.TrimSpace(params[name]), nil
+	}
+	if params[name] != "" {
+		return "", fmt.Errorf("set %s or %s_profile, not both", name, name)
+	}
+	if profileToken == nil {
+		return "", fmt.Errorf("%s_profile is not supported by this auth handler", name)
+	}
+	token, err := profileToken(ctx, ref, tokenType)
+	if err != nil {
+		return "", fmt.Errorf("%s_profile %q: %w", name, ref, err)
+	}
+	return token, nil
+}
+
+// tokenTypeURN expands short token type names such as "id_token" to their
+// RFC 8693 URN. Values that already look like URIs pass through unchanged.
+func tokenTypeURN(value, fallback string) string {
+	value = strings.TrimSpace(value)
+	switch {
+	case value == "":
+		return fallback
+	case strings.Contains(value, ":"):
+		return value
+	default:
+		return tokenTypeURNPrefix + value
+	}
+}
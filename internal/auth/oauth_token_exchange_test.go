@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"context"
+	"github.com/rest-sh/restish/v2/auth"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTokenExchange_SendsRFC8693Form(t *testing.T) {
+	var got url.Values
+	calls := 0
+	h := &TokenExchange{
+		Cache: auth.NewTokenCache(filepath.Join(t.TempDir(), "tokens.cbor")),
+		HTTPClient: testHTTPClient(func(r *http.Request) (*http.Response, error) {
+			calls++
+			if err := r.ParseForm(); err != nil {
+				t.Fatalf("ParseForm: %v", err)
+			}
+			got = r.Form
+			return testResponse(200, "application/json", `{"access_token":"downstream","issued_token_type":"urn:ietf:params:oauth:token-type:jwt","token_type":"N_A","expires_in":3600}`), nil
+		}),
+	}
+	params := map[string]string{
+		"subject_token":        "sso-token",
+		"subject_token_type":   "id_token",
+		"actor_token":          "actor-token",
+		"requested_token_type": "urn:ietf:params:oauth:token-type:jwt",
+		"audience":             "orders",
+		"scopes":               "orders.read",
+		"token_url":            "https://auth.example.com/token",
+		"_cache_key":           "myapi:default",
+	}
+	for range 2 {
+		req, _ := http.NewRequest("GET", "https://api.example.com/items", nil)
+		if err := h.OnRequest(req, params); err != nil {
+			t.Fatalf("OnRequest: %v", err)
+		}
+		if got := req.Header.Get("Authorization"); got != "Bearer downstream" {
+			t.Fatalf("Authorization = %q", got)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("token endpoint called %d times, want the exchanged token cached", calls)
+	}
+	want := map[string]string{
+		"grant_type":           tokenExchangeGrantType,
+		"subject_token":        "sso-token",
+		"subject_token_type":   TokenTypeIDToken,
+		"actor_token":          "actor-token",
+		"actor_token_type":     TokenTypeAccessToken,
+		"requested_token_type": "urn:ietf:params:oauth:token-type:jwt",
+		"audience":             "orders",
+		"scope":                "orders.read",
+	}
+	for key, value := range want {
+		if got.Get(key) != value {
+			t.Errorf("form %s = %q, want %q", key, got.Get(key), value)
+		}
+	}
+	if _, ok := got["client_id"]; ok {
+		t.Errorf("client_id sent without being configured: %#v", got)
+	}
+}
+
+func TestTokenExchange_SubjectTokenFromProfile(t *testing.T) {
+	var gotSubject string
+	h := &TokenExchange{
+		HTTPClient: testHTTPClient(func(r *http.Request) (*http.Response, error) {
+			_ = r.ParseForm()
+			gotSubject = r.Form.Get("subject_token")
+			return testResponse(200, "application/json", `{"access_token":"downstream","token_type":"Bearer"}`), nil
+		}),
+		ProfileToken: func(ctx context.Context, ref, tokenType string) (string, error) {
+			if ref != "sso:default" || tokenType != TokenTypeAccessToken {
+				t.Fatalf("ProfileToken(%q, %q)", ref, tokenType)
+			}
+			return "profile-token", nil
+		},
+	}
+	req, _ := http.NewRequest("GET", "https://api.example.com/items", nil)
+	if err := h.OnRequest(req, map[string]string{"subject_token_profile": "sso:default", "token_url": "https://auth.example.com/token"}); err != nil {
+		t.Fatalf("OnRequest: %v", err)
+	}
+	if gotSubject != "profile-token" {
+		t.Fatalf("subject_token = %q", gotSubject)
+	}
+}
+
+func TestTokenExchange_Errors(t *testing.T) {
+	h := &TokenExchange{HTTPClient: testHTTPClient(func(r *http.Request) (*http.Response, error) {
+		t.Fatal("token endpoint should not be called")
+		return nil, nil
+	})}
+	for name, tc := range map[string]struct {
+		params map[string]string
+		want   string
+	}{
+		"missing subject": {map[string]string{"token_url": "https://auth.example.com/token"}, "subject_token or subject_token_profile is required"},
+		"both sources":    {map[string]string{"token_url": "https://auth.example.com/token", "subject_token": "a", "subject_token_profile": "sso"}, "not both"},
+		"no resolver":     {map[string]string{"token_url": "https://auth.example.com/token", "subject_token_profile": "sso"}, "not supported"},
+		"no endpoint":     {map[string]string{"subject_token": "a"}, "token_url or issuer_url is required"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "https://api.example.com/items", nil)
+			if err := h.OnRequest(req, tc.params); err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("err = %v, want %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestTokenExchange_RejectsNAOutsideExchange(t *testing.T) {
+	h := &ClientCredentials{HTTPClient: testHTTPClient(func(r *http.Request) (*http.Response, error) {
+		return testResponse(200, "application/json", `{"access_token":"abc","token_type":"N_A"}`), nil
+	})}
+	req, _ := http.NewRequest("GET", "https://api.example.com/items", nil)
+	err := h.OnRequest(req, map[string]string{"client_id": "id", "token_url": "https://auth.example.com/token"})
+	if err == nil || !strings.Contains(err.Error(), "unsupported token_type") {
+		t.Fatalf("err = %v", err)
+	}
+}
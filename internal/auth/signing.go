@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const maxSigningBodyBytes = 16 << 20
+
+// signingRequestBody returns the request body bytes for request-signing
+// handlers, restoring req.Body so the request can still be sent.
+func signingRequestBody(handler string, req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody != nil {
+		rc, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("%s: reading request body: %w", handler, err)
+		}
+		defer rc.Close()
+		return readSigningBody(handler, rc)
+	}
+	body, err := readSigningBody(handler, req.Body)
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	return body, nil
+}
+
+func readSigningBody(handler string, r io.Reader) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(r, maxSigningBodyBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("%s: reading request body: %w", handler, err)
+	}
+	if len(body) > maxSigningBodyBytes {
+		return nil, fmt.Errorf("%s: request body exceeds %d bytes", handler, maxSigningBodyBytes)
+	}
+	return body, nil
+}
+
+// decodeSharedSecret decodes an HMAC shared secret stored as raw text,
+// base64, or hex.
+func decodeSharedSecret(handler string, keyMaterial []byte, encoding string) ([]byte, error) {
+	value := strings.TrimSpace(string(keyMaterial))
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "", "raw":
+		return []byte(value), nil
+	case "base64":
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: decoding base64 key: %w", handler, err)
+		}
+		return decoded, nil
+	case "hex":
+		decoded, err := hex.DecodeString(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: decoding hex key: %w", handler, err)
+		}
+		return decoded, nil
+	default:
+		return nil, fmt.Errorf("%s: unsupported key_encoding %q (supported: raw, base64, hex)", handler, encoding)
+	}
+}
+
+// parsePEMPrivateKey parses a PKCS#8, PKCS#1, or SEC 1 PEM private key.
+func parsePEMPrivateKey(handler string, keyMaterial []byte) (crypto.PrivateKey, error) {
+	block, _ := pem.Decode(keyMaterial)
+	if block == nil {
+		return nil, fmt.Errorf("%s: key is not PEM encoded", handler)
+	}
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("%s: unsupported private key format %q", handler, block.Type)
+}
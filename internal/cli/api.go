@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -14,7 +15,6 @@ import (
 	"strings"
 	"time"
 
-	"github.com/rest-sh/restish/v2/auth"
 	"github.com/rest-sh/restish/v2/config"
 	"github.com/rest-sh/restish/v2/internal/cache"
 	internalconfig "github.com/rest-sh/restish/v2/internal/config"
@@ -66,12 +66,14 @@ func (c *CLI) addAPICommand(root *cobra.Command) {
 		Example: fmt.Sprintf(`  %s api sync demo
   %s api sync demo --yes
   %s api sync demo --allow-cross-origin-spec
-  %s api sync api-one api-two api-three`, c.commandNameOrDefault(), c.commandNameOrDefault(), c.commandNameOrDefault(), c.commandNameOrDefault()),
+  %s api sync demo --overlay ./demo-fixes.yaml
+  %s api sync api-one api-two api-three`, c.commandNameOrDefault(), c.commandNameOrDefault(), c.commandNameOrDefault(), c.commandNameOrDefault(), c.commandNameOrDefault()),
 		Args: usageMinimumNArgs(1),
 		RunE: c.runAPISync,
 	}
 	syncCmd.Flags().Bool("allow-cross-origin-spec", false, "Allow safe Link-header spec discovery from another host for this sync run")
 	syncCmd.Flags().Bool("yes", false, "Accept safe api sync prompts without asking")
+	syncCmd.Flags().StringArray("overlay", nil, "OpenAPI Overlay file or URL to apply to the spec; repeat to apply several in order and replace the saved overlay_files")
 	apiCmd.AddCommand(syncCmd)
 	connectCmd := &cobra.Command{
 		Use:   "connect <name> <url> [setup-expression ...]",
@@ -79,13 +81,15 @@ func (c *CLI) addAPICommand(root *cobra.Command) {
 		Long:  apiConnectLong,
 		Example: fmt.Sprintf(`  %s api connect demo https://api.example.com
   %s api connect demo https://api.example.com 'prompt.api_key: env:DEMO_API_KEY'
-  %s api connect demo https://api.example.com --spec ./openapi.yaml`, c.commandNameOrDefault(), c.commandNameOrDefault(), c.commandNameOrDefault()),
+  %s api connect demo https://api.example.com --spec ./openapi.yaml
+  %s api connect demo https://api.example.com --overlay ./demo-fixes.yaml`, c.commandNameOrDefault(), c.commandNameOrDefault(), c.commandNameOrDefault(), c.commandNameOrDefault()),
 		Args: usageMinimumNArgs(2),
 		RunE: c.runAPIConnect,
 	}
 	connectCmd.Flags().Bool("allow-cross-origin-spec", false, "Allow safe Link-header spec discovery from another host; private/local follow targets are still rejected")
 	connectCmd.Flags().Bool("no-discover", false, "Register the API locally without network spec discovery")
 	connectCmd.Flags().String("spec", "", "OpenAPI spec URL or local file to use instead of discovery")
+	connectCmd.Flags().StringArray("overlay", nil, "OpenAPI Overlay file or URL to apply to the spec; repeat to apply several in order")
 	connectCmd.Flags().Bool("replace", false, "Replace existing profiles with discovered OpenAPI/x-cli-config defaults")
 	connectCmd.Flags().Bool("yes", false, "Accept safe api connect prompts without asking")
 	apiCmd.AddCommand(connectCmd)
@@ -116,6 +120,7 @@ func (c *CLI) addAPICommand(root *cobra.Command) {
 		Args: usageMinimumNArgs(2),
 		RunE: c.runAPISet,
 	})
+	apiCmd.AddCommand(c.newAPIExportCommand())
 	root.AddCommand(apiCmd)
 }
 
@@ -142,9 +147,14 @@ Archived v1 docs: https://rest.sh/v1/`, replacement)
 }
 
 // runAPIAuthLogout deletes the token cache entry for the named API+profile.
+// With --revoke or --end-session it first logs the cached OAuth tokens out at
+// the provider; the local entries are deleted even when that fails.
 func (c *CLI) runAPIAuthLogout(cmd *cobra.Command, args []string) error {
 	authProfile, _ := cmd.Flags().GetString("auth-profile")
-	tc := auth.NewTokenCache(c.tokenCachePath())
+	revoke, _ := cmd.Flags().GetBool("revoke")
+	endSession, _ := cmd.Flags().GetBool("end-session")
+	tc := c.tokenStore()
+	var logoutErr error
 	if authProfile != "" {
 		if len(args) > 0 {
 			return fmt.Errorf("--auth-profile cannot be used with an API argument")
@@ -152,11 +162,18 @@ func (c *CLI) runAPIAuthLogout(cmd *cobra.Command, args []string) error {
 		if c.cfg == nil || c.cfg.AuthProfiles == nil || c.cfg.AuthProfiles[authProfile] == nil {
 			return fmt.Errorf("unknown auth profile %q", authProfile)
 		}
+		if revoke || endSession {
+			targets, err := c.authProfileLogoutTargets(authProfile)
+			if err != nil {
+				return err
+			}
+			logoutErr = c.revokeLogoutTargets(requestContext(cmd), targets, revoke, endSession)
+		}
 		if err := tc.DeletePrefix("auth_profile:" + authProfile + ":"); err != nil {
 			return fmt.Errorf("auth logout: %w", err)
 		}
 		fmt.Fprintf(c.Stdout, "Cleared auth cache for auth profile %q\n", authProfile)
-		return nil
+		return logoutErr
 	}
 	if len(args) != 1 {
 		return fmt.Errorf("api auth logout requires an API name or --auth-profile <name>")
@@ -171,6 +188,13 @@ func (c *CLI) runAPIAuthLogout(cmd *cobra.Command, args []string) error {
 	allProfiles, _ := cmd.Flags().GetBool("all-profiles")
 
 	if allProfiles {
+		if revoke || endSession {
+			targets, err := c.apiLogoutTargets(apiName, apiCfg)
+			if err != nil {
+				return err
+			}
+			logoutErr = c.revokeLogoutTargets(requestContext(cmd), targets, revoke, endSession)
+		}
 		if err := tc.DeletePrefix(c.apiStateName(apiName) + ":"); err != nil {
 			return fmt.Errorf("auth logout: %w", err)
 		}
@@ -186,7 +210,14 @@ func (c *CLI) runAPIAuthLogout(cmd *cobra.Command, args []string) error {
 			}
 		}
 		fmt.Fprintf(c.Stdout, "Cleared auth cache for %q (all profiles)\n", apiName)
-		return nil
+		return logoutErr
+	}
+	if revoke || endSession {
+		targets, err := c.profileLogoutTargets(apiName, profileName, apiCfg.Profiles[profileName])
+		if err != nil {
+			return err
+		}
+		logoutErr = c.revokeLogoutTargets(requestContext(cmd), targets, revoke, endSession)
 	}
 	key := c.apiCacheNamespace(apiName, profileName)
 	if err := tc.Delete(key); err != nil {
@@ -204,7 +235,7 @@ func (c *CLI) runAPIAuthLogout(cmd *cobra.Command, args []string) error {
 		}
 	}
 	fmt.Fprintf(c.Stdout, "Cleared auth cache for %q (profile %q)\n", apiName, profileName)
-	return nil
+	return logoutErr
 }
 
 // runAPISync force-invalidates the cached spec for one or more APIs, fetches a
@@ -217,6 +248,9 @@ func (c *CLI) runAPISync(cmd *cobra.Command, args []string) error {
 	// one transport up front so the plugin subprocess — and the PKCS#11 session
 	// it holds — is shared across every sync. This means the user is prompted
 	// for the hardware-token PIN exactly once instead of once per API.
+	if cmd.Flags().Changed("overlay") && len(args) > 1 {
+		return newUsageError(errors.New("--overlay can only be used when syncing one API"))
+	}
 	var shared http.RoundTripper
 	if len(args) > 1 {
 		tr, closer, err := c.sharedDiscoveryTransport(cmd, args)
@@ -292,6 +326,13 @@ func (c *CLI) syncOneAPI(cmd *cobra.Command, apiName string, sharedTransport htt
 	allowCrossOrigin, _ := cmd.Flags().GetBool("allow-cross-origin-spec")
 	yes, _ := cmd.Flags().GetBool("yes")
 	profileName := c.profileFromCmd(cmd)
+	overlayFiles := apiCfg.OverlayFiles
+	if cmd.Flags().Changed("overlay") {
+		if c.projectAPI(apiName) {
+			return fmt.Errorf("--overlay cannot update read-only project API %q; edit overlay_files in %s", apiName, c.projectConfig.Path)
+		}
+		overlayFiles, _ = cmd.Flags().GetStringArray("overlay")
+	}
 	var transport http.RoundTripper
 	if sharedTransport != nil {
 		transport = sharedTransport
@@ -311,12 +352,14 @@ func (c *CLI) syncOneAPI(cmd *cobra.Command, apiName string, sharedTransport htt
 		BaseURL:          effectiveProfileBaseURL(apiCfg, profileName),
 		SpecURL:          apiCfg.SpecURL,
 		SpecFiles:        apiCfg.SpecFiles,
+		OverlayFiles:     overlayFiles,
 		CacheDir:         c.specCacheDir(),
 		OperationBase:    effectiveOperationBase(apiCfg, profileName),
 		ServerVariables:  effectiveServerVariables(apiCfg, profileName),
 		Version:          Version,
 		Transport:        transport,
 		Fetch:            fetch,
+		Post:             c.discoveryPoster(apiName, apiCfg, profileName),
 		AllowCrossOrigin: apiCfg.AllowCrossOriginSpec || allowCrossOrigin,
 		ForceRefresh:     true,
 		Trace:            c.discoveryTrace(cmd),
@@ -334,6 +377,7 @@ func (c *CLI) syncOneAPI(cmd *cobra.Command, apiName string, sharedTransport htt
 		if syncedCfg.SpecURL == "" && len(syncedCfg.SpecFiles) == 0 && apiSpec.SourceURL != "" {
 			syncedCfg.SpecURL = apiSpec.SourceURL
 		}
+		syncedCfg.OverlayFiles = overlayFiles
 		if err := c.configureAllowedOperationOrigins(cmd, apiName, syncedCfg, apiSpec, profileName, yes); err != nil {
 			return err
 		}
@@ -376,6 +420,7 @@ func (c *CLI) runAPIConnect(cmd *cobra.Command, args []string) error {
 	allowCrossOrigin, _ := cmd.Flags().GetBool("allow-cross-origin-spec")
 	noDiscover, _ := cmd.Flags().GetBool("no-discover")
 	explicitSpec, _ := cmd.Flags().GetString("spec")
+	overlayFiles, _ := cmd.Flags().GetStringArray("overlay")
 	replaceProfiles, _ := cmd.Flags().GetBool("replace")
 	yes, _ := cmd.Flags().GetBool("yes")
 	promptAnswers, setupExprs, err := parseAPIConfigureSetupExpressions(args[2:])
@@ -385,6 +430,9 @@ func (c *CLI) runAPIConnect(cmd *cobra.Command, args []string) error {
 	if noDiscover && explicitSpec != "" {
 		return fmt.Errorf("--no-discover cannot be used with --spec")
 	}
+	if noDiscover && len(overlayFiles) > 0 {
+		return fmt.Errorf("--no-discover cannot be used with --overlay")
+	}
 
 	if isBuiltinCommandName(apiName) {
 		return fmt.Errorf("API name %q conflicts with a built-in command; choose a different name", apiName)
@@ -409,6 +457,7 @@ func (c *CLI) runAPIConnect(cmd *cobra.Command, args []string) error {
 	apiCfg := &config.APIConfig{
 		BaseURL:              baseURL,
 		AllowCrossOriginSpec: allowCrossOrigin,
+		OverlayFiles:         overlayFiles,
 	}
 	applyExplicitSpec(apiCfg, explicitSpec)
 
@@ -427,11 +476,13 @@ func (c *CLI) runAPIConnect(cmd *cobra.Command, args []string) error {
 			BaseURL:          baseURL,
 			SpecURL:          apiCfg.SpecURL,
 			SpecFiles:        apiCfg.SpecFiles,
+			OverlayFiles:     apiCfg.OverlayFiles,
 			CacheDir:         c.specCacheDir(),
 			ServerVariables:  nil,
 			Version:          Version,
 			Transport:        transport,
 			Fetch:            fetch,
+			Post:             c.discoveryPoster(apiName, apiCfg, "default"),
 			AllowCrossOrigin: allowCrossOrigin,
 			ForceRefresh:     true,
 			Trace:            c.discoveryTrace(cmd),
@@ -1194,7 +1245,11 @@ func (c *CLI) discoveryTransportOptions(ctx context.Context, apiCfg *config.APIC
 			if opts.TLSSignerName == "" {
 				opts.TLSSignerName = prof.TLSSigner
 			}
-			opts.TLSSignerParams = mergeTLSSignerParams(opts.TLSSignerParams, prof.TLSSignerParams)
+			signerParams, err := c.resolveSecretParams(prof.TLSSignerParams)
+			if err != nil {
+				return request.Options{}, err
+			}
+			opts.TLSSignerParams = mergeTLSSignerParams(opts.TLSSignerParams, signerParams)
 			if opts.CACertPath == "" {
 				opts.CACertPath = prof.CACertPath
 			}
@@ -1262,7 +1317,23 @@ func (c *CLI) discoveryFetcher(ctx context.Context, apiName string, apiCfg *conf
 		if discoveryAuthAllowed(authOrigins, rawURL) {
 			opts = authOpts
 		}
-		return c.doDiscoveryRequest(ctx, http.MethodGet, rawURL, opts)
+		return c.doDiscoveryRequest(ctx, http.MethodGet, rawURL, nil, opts)
+	}
+}
+
+// discoveryPoster sends GraphQL introspection queries with the same
+// same-origin auth rules as discoveryFetcher.
+func (c *CLI) discoveryPoster(apiName string, apiCfg *config.APIConfig, profileName string) spec.HTTPPoster {
+	authOrigins := discoveryAuthOrigins(apiCfg, profileName)
+	return func(ctx context.Context, rawURL, contentType string, body []byte, transport http.RoundTripper) (*http.Response, error) {
+		baseOpts, authOpts := c.discoveryRequestOptions(ctx, apiName, apiCfg, profileName, transport)
+		opts := baseOpts
+		if discoveryAuthAllowed(authOrigins, rawURL) {
+			opts = authOpts
+		}
+		opts.ContentType = contentType
+		opts.AcceptHeader = graphQLAccept
+		return c.doDiscoveryRequest(ctx, http.MethodPost, rawURL, body, opts)
 	}
 }
 
@@ -1280,13 +1351,23 @@ func (c *CLI) discoveryRequestOptions(ctx context.Context, apiName string, apiCf
 			authOpts.PreserveHeaderCase = true
 		}
 		prof := profileForName(apiCfg, profileName)
+		var headerErr error
 		if prof != nil {
-			authOpts.Headers = append([]string(nil), prof.Headers...)
+			headers, credentialHeaders, err := c.resolveProfileHeaders(prof.Headers)
+			if err != nil {
+				headerErr = err
+			}
+			authOpts.Headers = append([]string(nil), headers...)
+			authOpts.CredentialHeaders = credentialHeaders
 			authOpts.Query = append([]string(nil), prof.Query...)
 		}
 		callbacks := c.authOnRequest(apiName, profileName, prof, authHandlerOptionsFromContext(ctx))
 		authOpts.OnRequest = callbacks.OnRequest
 		authOpts.OnUnauthorized = callbacks.OnUnauthorized
+		if headerErr != nil {
+			authOpts.OnRequest = func(*http.Request) error { return headerErr }
+			authOpts.OnUnauthorized = nil
+		}
 	}
 	if authOpts.OnRequest != nil || len(c.pluginsByHook["request-middleware"]) > 0 {
 		origOnRequest := authOpts.OnRequest
@@ -1302,8 +1383,8 @@ func (c *CLI) discoveryRequestOptions(ctx context.Context, apiName string, apiCf
 	return baseOpts, authOpts
 }
 
-func (c *CLI) doDiscoveryRequest(ctx context.Context, method, rawURL string, opts request.Options) (*http.Response, error) {
-	resp, err := request.Do(ctx, method, rawURL, nil, opts)
+func (c *CLI) doDiscoveryRequest(ctx context.Context, method, rawURL string, body []byte, opts request.Options) (*http.Response, error) {
+	resp, err := request.Do(ctx, method, rawURL, discoveryRequestBody(body), opts)
 	if err != nil || resp == nil || resp.StatusCode != http.StatusUnauthorized || opts.OnUnauthorized == nil {
 		return resp, err
 	}
@@ -1320,7 +1401,16 @@ func (c *CLI) doDiscoveryRequest(ctx context.Context, method, rawURL string, opt
 		return c.runRequestMiddlewarePlugins(req)
 	}
 	retryOpts.OnUnauthorized = nil
-	return request.Do(ctx, method, rawURL, nil, retryOpts)
+	return request.Do(withAuthChallenges(ctx, resp), method, rawURL, discoveryRequestBody(body), retryOpts)
+}
+
+// discoveryRequestBody returns a fresh reader per attempt, or nil for
+// bodiless requests.
+func discoveryRequestBody(body []byte) io.Reader {
+	if body == nil {
+		return nil
+	}
+	return bytes.NewReader(body)
 }
 
 func authHandlerOptionsFromContext(ctx context.Context) authHandlerOptions {
@@ -1616,7 +1706,8 @@ func apiSpecCacheRelevantFieldsChanged(oldAPI, newAPI *config.APIConfig) bool {
 	}
 	return oldAPI.BaseURL != newAPI.BaseURL ||
 		oldAPI.SpecURL != newAPI.SpecURL ||
-		!reflect.DeepEqual(oldAPI.SpecFiles, newAPI.SpecFiles)
+		!reflect.DeepEqual(oldAPI.SpecFiles, newAPI.SpecFiles) ||
+		!reflect.DeepEqual(oldAPI.OverlayFiles, newAPI.OverlayFiles)
 }
 
 func parseShorthandAssignment(expr string) (string, string, bool, error) {
@@ -1802,7 +1893,7 @@ func (c *CLI) removeAPILocalState(apiName string, sharedAuthRefs []string) error
 		return fmt.Errorf("api remove: clear HTTP cache for %q: %w", apiName, err)
 	}
 
-	tc := auth.NewTokenCache(c.tokenCachePath())
+	tc := c.tokenStore()
 	if err := tc.DeletePrefix(namespace + ":"); err != nil {
 		return fmt.Errorf("api remove: clear auth cache for %q: %w", apiName, err)
 	}
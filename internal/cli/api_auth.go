@@ -2,11 +2,13 @@ package cli
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/rest-sh/restish/v2/config"
 	"github.com/rest-sh/restish/v2/internal/spec"
@@ -51,7 +53,8 @@ func (c *CLI) newAPIAuthCommand() *cobra.Command {
 		Long:  apiAuthLogoutLong,
 		Example: fmt.Sprintf(`  %s api auth logout demo
   %s api auth logout demo --all-profiles
-  %s api auth logout --auth-profile shared-oauth`, c.commandNameOrDefault(), c.commandNameOrDefault(), c.commandNameOrDefault()),
+  %s api auth logout --auth-profile shared-oauth
+  %s api auth logout demo --revoke --end-session`, c.commandNameOrDefault(), c.commandNameOrDefault(), c.commandNameOrDefault(), c.commandNameOrDefault()),
 		Args: usageMaximumNArgs(1),
 		RunE: c.runAPIAuthLogout,
 	}
@@ -71,19 +74,22 @@ func (c *CLI) newAPIAuthCommand() *cobra.Command {
 	}
 	getCmd.Flags().String("operation", "", "Operation ID or command name to inspect")
 	getCmd.Flags().Bool("print-header", false, "Print the single resolved header as 'Name: value' on stdout and exit non-zero for any non-header auth")
+	getCmd.Flags().Bool("claims", false, "Print the decoded JWT header and claims as JSON instead of the auth material")
 	cmd.AddCommand(getCmd)
 	inspectCmd := &cobra.Command{
 		Use:   "inspect <api>",
 		Short: "Inspect the auth material applied for an API profile",
 		Long:  apiAuthInspectLong,
 		Example: fmt.Sprintf(`  %s api auth inspect demo
-  %s api auth inspect demo --operation list-items --redact`, c.commandNameOrDefault(), c.commandNameOrDefault()),
+  %s api auth inspect demo --operation list-items --redact
+  %s api auth inspect demo --claims`, c.commandNameOrDefault(), c.commandNameOrDefault(), c.commandNameOrDefault()),
 		Args: usageExactArgs(1),
 		RunE: c.runAPIAuthInspect,
 	}
 	inspectCmd.Flags().String("credential", "", "Credential ID to inspect instead of profile-level auth")
 	inspectCmd.Flags().String("operation", "", "Operation ID or command name to inspect")
 	inspectCmd.Flags().Bool("redact", false, "Redact sensitive auth values for shareable output")
+	inspectCmd.Flags().Bool("claims", false, "Print decoded JWT headers and claims for each auth target as JSON")
 	cmd.AddCommand(inspectCmd)
 	return cmd
 }
@@ -91,6 +97,8 @@ func (c *CLI) newAPIAuthCommand() *cobra.Command {
 func addAPIAuthLogoutFlags(cmd *cobra.Command) {
 	cmd.Flags().Bool("all-profiles", false, "Delete cached auth tokens for every profile of the named API")
 	cmd.Flags().String("auth-profile", "", "Delete cached auth tokens for a shared auth profile instead of an API")
+	cmd.Flags().Bool("revoke", false, "Revoke cached OAuth refresh and access tokens at the provider before deleting them")
+	cmd.Flags().Bool("end-session", false, "Print the OIDC provider logout URL that ends the browser session")
 }
 
 func (c *CLI) printAPIAuthOverview(cmd *cobra.Command, apiName, profileName string, apiCfg *config.APIConfig, prof *config.ProfileConfig) {
@@ -114,6 +122,12 @@ func (c *CLI) printAPIAuthOverview(cmd *cobra.Command, apiName, profileName stri
 	} else {
 		fmt.Fprintf(c.Stdout, "Generic request auth: %s\n", style.warn("none"))
 	}
+	if sources := c.profileSecretSources(apiName, profileName, prof); len(sources) > 0 {
+		fmt.Fprintln(c.Stdout, "Secret sources:")
+		for _, source := range sources {
+			fmt.Fprintf(c.Stdout, "  %s\n", source)
+		}
+	}
 	if hasOps {
 		fmt.Fprintf(c.Stdout, "Callable secured operations: %d/%d\n", coverage.Callable, coverage.Secured)
 	} else {
@@ -224,18 +238,22 @@ func (c *CLI) runAPIAuthInspect(cmd *cobra.Command, args []string) error {
 	}
 	credentialID, _ := cmd.Flags().GetString("credential")
 	redact, _ := cmd.Flags().GetBool("redact")
+	claimsOnly, _ := cmd.Flags().GetBool("claims")
 	focused := credentialID != ""
 	if operation, _ := cmd.Flags().GetString("operation"); operation != "" {
 		if credentialID != "" {
 			return fmt.Errorf("--operation and --credential are mutually exclusive")
 		}
-		return c.runAPIAuthInspectOperation(cmd, apiName, profileName, apiCfg, prof, operation, redact)
+		return c.runAPIAuthInspectOperation(cmd, apiName, profileName, apiCfg, prof, operation, redact, claimsOnly)
 	}
 
 	targets, err := c.authInspectionTargets(apiName, profileName, prof, credentialID)
 	if err != nil {
 		return err
 	}
+	if claimsOnly {
+		return c.printAPIAuthInspectClaims(cmd, apiName, profileName, targets)
+	}
 	if len(targets) == 0 {
 		if focused {
 			return fmt.Errorf("profile %q of API %q has no auth config", profileName, apiName)
@@ -273,10 +291,33 @@ func (c *CLI) runAPIAuthInspect(cmd *cobra.Command, args []string) error {
 			return err
 		}
 		c.printAuthInspectionRequest(req, []*config.AuthConfig{target.Resolved.Config}, redact)
+		printAuthTokenClaims(c.Stdout, c.authTokenClaimsFor(req, apiName, profileName, target.Resolved), time.Now())
 	}
 	return nil
 }
 
+// printAPIAuthInspectClaims writes decoded JWT claims for every usable auth
+// target as a JSON array. Targets without JWTs are omitted.
+func (c *CLI) printAPIAuthInspectClaims(cmd *cobra.Command, apiName, profileName string, targets []authInspectionTarget) error {
+	all := []authTokenClaims{}
+	for _, target := range targets {
+		if target.Resolved.Config == nil || !c.resolvedAuthReadiness(apiName, profileName, target.Resolved).Usable {
+			continue
+		}
+		req, err := c.authInspectionRequest(cmd, apiName, profileName, target.Resolved)
+		if err != nil {
+			return err
+		}
+		claims := c.authTokenClaimsFor(req, apiName, profileName, target.Resolved)
+		if claims.empty() {
+			continue
+		}
+		claims.Credential = target.CredentialID
+		all = append(all, claims)
+	}
+	return c.writePrettyJSON(all)
+}
+
 type authInspectionTarget struct {
 	Label        string
 	CredentialID string
@@ -319,7 +360,7 @@ func (c *CLI) authInspectionTargets(apiName, profileName string, prof *config.Pr
 	return targets, nil
 }
 
-func (c *CLI) runAPIAuthInspectOperation(cmd *cobra.Command, apiName, profileName string, apiCfg *config.APIConfig, prof *config.ProfileConfig, operationName string, redact bool) error {
+func (c *CLI) runAPIAuthInspectOperation(cmd *cobra.Command, apiName, profileName string, apiCfg *config.APIConfig, prof *config.ProfileConfig, operationName string, redact, claimsOnly bool) error {
 	op, ok, err := c.cachedOperationForAPI(requestContext(cmd), apiName, apiCfg, profileName, operationName)
 	if err != nil {
 		return err
@@ -365,10 +406,20 @@ func (c *CLI) runAPIAuthInspectOperation(cmd *cobra.Command, apiName, profileNam
 	if err != nil {
 		return err
 	}
+	claims := c.authTokenClaimsFor(req, apiName, profileName, selectedOperationResolvedConfigs(selected)...)
+	if claimsOnly {
+		all := []authTokenClaims{}
+		if !claims.empty() {
+			claims.Credential = strings.Join(selectedOperationCredentialIDs(selected), "+")
+			all = append(all, claims)
+		}
+		return c.writePrettyJSON(all)
+	}
 	fmt.Fprintf(c.Stdout, "Operation: %s\n", op.ID)
 	fmt.Fprintf(c.Stdout, "Credentials: %s\n", strings.Join(selectedOperationCredentialIDs(selected), ", "))
 	fmt.Fprintf(c.Stdout, "Source: %s\n", strings.Join(selectedOperationSources(selected), ", "))
 	c.printAuthInspectionRequest(req, selectedOperationAuthConfigs(selected), redact)
+	printAuthTokenClaims(c.Stdout, claims, time.Now())
 	return nil
 }
 
@@ -381,16 +432,34 @@ func (c *CLI) runAPIAuthGet(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// authGetMode selects what api auth get prints for the resolved auth.
+type authGetMode int
+
+const (
+	authGetFragmentMode authGetMode = iota
+	authGetHeaderMode
+	authGetClaimsMode
+)
+
 func (c *CLI) apiAuthGetFragment(cmd *cobra.Command, args []string) (string, error) {
 	printHeader, _ := cmd.Flags().GetBool("print-header")
-	return c.apiAuthGetFragmentMode(cmd, args, printHeader)
+	claims, _ := cmd.Flags().GetBool("claims")
+	switch {
+	case printHeader && claims:
+		return "", fmt.Errorf("--print-header and --claims are mutually exclusive")
+	case printHeader:
+		return c.apiAuthGetFragmentMode(cmd, args, authGetHeaderMode)
+	case claims:
+		return c.apiAuthGetFragmentMode(cmd, args, authGetClaimsMode)
+	}
+	return c.apiAuthGetFragmentMode(cmd, args, authGetFragmentMode)
 }
 
 func (c *CLI) apiAuthGetHeaderFragment(cmd *cobra.Command, args []string) (string, error) {
-	return c.apiAuthGetFragmentMode(cmd, args, true)
+	return c.apiAuthGetFragmentMode(cmd, args, authGetHeaderMode)
 }
 
-func (c *CLI) apiAuthGetFragmentMode(cmd *cobra.Command, args []string, printHeader bool) (string, error) {
+func (c *CLI) apiAuthGetFragmentMode(cmd *cobra.Command, args []string, mode authGetMode) (string, error) {
 	apiName := args[0]
 	if looksLikeURLArgument(apiName) {
 		return "", fmt.Errorf("api auth get expects an API name, not a URL")
@@ -408,7 +477,7 @@ func (c *CLI) apiAuthGetFragmentMode(cmd *cobra.Command, args []string, printHea
 		if credentialID != "" {
 			return "", fmt.Errorf("--operation and credential ID are mutually exclusive")
 		}
-		return c.apiAuthGetOperationFragment(cmd, apiName, profileName, apiCfg, prof, operation, printHeader)
+		return c.apiAuthGetOperationFragment(cmd, apiName, profileName, apiCfg, prof, operation, mode)
 	}
 	if credentialID == "" {
 		resolvedProfile, err := c.resolveProfileAuth(apiName, profileName, prof)
@@ -435,8 +504,11 @@ func (c *CLI) apiAuthGetFragmentMode(cmd *cobra.Command, args []string, printHea
 		return "", err
 	}
 	configs := []*config.AuthConfig{resolved.Config}
-	if printHeader {
+	switch mode {
+	case authGetHeaderMode:
 		return authHeaderFragment(req, configs, apiName, profileName)
+	case authGetClaimsMode:
+		return authClaimsFragment(c.authTokenClaimsFor(req, apiName, profileName, resolved), apiName, profileName)
 	}
 	fragment, err := authGetFragment(req, configs)
 	if err != nil {
@@ -445,8 +517,8 @@ func (c *CLI) apiAuthGetFragmentMode(cmd *cobra.Command, args []string, printHea
 	return fragment, nil
 }
 
-func (c *CLI) runAPIAuthGetOperation(cmd *cobra.Command, apiName, profileName string, apiCfg *config.APIConfig, prof *config.ProfileConfig, operationName string, printHeader bool) error {
-	fragment, err := c.apiAuthGetOperationFragment(cmd, apiName, profileName, apiCfg, prof, operationName, printHeader)
+func (c *CLI) runAPIAuthGetOperation(cmd *cobra.Command, apiName, profileName string, apiCfg *config.APIConfig, prof *config.ProfileConfig, operationName string, mode authGetMode) error {
+	fragment, err := c.apiAuthGetOperationFragment(cmd, apiName, profileName, apiCfg, prof, operationName, mode)
 	if err != nil {
 		return err
 	}
@@ -506,7 +578,7 @@ func authInspectionAppliesCookie(configs []*config.AuthConfig) bool {
 	return false
 }
 
-func (c *CLI) apiAuthGetOperationFragment(cmd *cobra.Command, apiName, profileName string, apiCfg *config.APIConfig, prof *config.ProfileConfig, operationName string, printHeader bool) (string, error) {
+func (c *CLI) apiAuthGetOperationFragment(cmd *cobra.Command, apiName, profileName string, apiCfg *config.APIConfig, prof *config.ProfileConfig, operationName string, mode authGetMode) (string, error) {
 	op, ok, err := c.cachedOperationForAPI(requestContext(cmd), apiName, apiCfg, profileName, operationName)
 	if err != nil {
 		return "", err
@@ -550,8 +622,11 @@ func (c *CLI) apiAuthGetOperationFragment(cmd *cobra.Command, apiName, profileNa
 		return "", err
 	}
 	configs := selectedOperationAuthConfigs(selected)
-	if printHeader {
+	switch mode {
+	case authGetHeaderMode:
 		return authHeaderFragment(req, configs, apiName, profileName)
+	case authGetClaimsMode:
+		return authClaimsFragment(c.authTokenClaimsFor(req, apiName, profileName, selectedOperationResolvedConfigs(selected)...), apiName, profileName)
 	}
 	fragment, err := authGetFragment(req, configs)
 	if err != nil {
@@ -560,6 +635,19 @@ func (c *CLI) apiAuthGetOperationFragment(cmd *cobra.Command, apiName, profileNa
 	return fragment, nil
 }
 
+// authClaimsFragment renders decoded JWT claims as indented JSON, failing
+// when the auth material carries no JWT.
+func authClaimsFragment(claims authTokenClaims, apiName, profileName string) (string, error) {
+	if claims.empty() {
+		return "", fmt.Errorf("auth for %s:%s is not a JWT; --claims needs a JWT access or ID token", apiName, profileName)
+	}
+	data, err := json.MarshalIndent(claims, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
 func authGetFragment(req *http.Request, configs []*config.AuthConfig) (string, error) {
 	var fragments []string
 	for _, name := range sortedHeaderKeys(req.Header) {
@@ -635,6 +723,9 @@ func configuredCredentialIDs(prof *config.ProfileConfig) []string {
 }
 
 func isAuthInspectionSensitiveHeader(ac *config.AuthConfig, name string) bool {
+	if ac != nil && ac.Type == "hmac" {
+		return strings.EqualFold(ac.Params["signature_header"], name)
+	}
 	return ac != nil &&
 		ac.Type == "api-key" &&
 		strings.EqualFold(ac.Params["in"], "header") &&
@@ -642,6 +733,9 @@ func isAuthInspectionSensitiveHeader(ac *config.AuthConfig, name string) bool {
 }
 
 func isAuthInspectionSensitiveQueryParam(ac *config.AuthConfig, name string) bool {
+	if ac != nil && ac.Type == "hmac" {
+		return strings.EqualFold(ac.Params["signature_query"], name)
+	}
 	return ac != nil &&
 		ac.Type == "api-key" &&
 		strings.EqualFold(ac.Params["in"], "query") &&
@@ -676,7 +770,8 @@ func (c *CLI) applyOperationAuthInspectionStep(req *http.Request, s operationAut
 			return err
 		}
 	}
-	return s.handler.Authenticate(req.Context(), req, c.authContext(req.Context(), s.apiName, s.profileName, params, s.cacheKey, false))
+	authCtx, ac := c.authContext(req.Context(), s.apiName, s.profileName, params, s.cacheKey, false)
+	return s.handler.Authenticate(authCtx, req, ac)
 }
 
 func (c *CLI) authInspectionRequest(cmd *cobra.Command, apiName, profileName string, resolved resolvedAuthConfig) (*http.Request, error) {
@@ -693,7 +788,8 @@ func (c *CLI) authInspectionRequest(cmd *cobra.Command, apiName, profileName str
 		return nil, err
 	}
 	req, _ := http.NewRequestWithContext(requestContext(cmd), "GET", "http://example.com", nil)
-	if err := handler.Authenticate(requestContext(cmd), req, c.authContext(requestContext(cmd), apiName, profileName, params, resolved.CacheKey, false)); err != nil {
+	authCtx, ac := c.authContext(requestContext(cmd), apiName, profileName, params, resolved.CacheKey, false)
+	if err := handler.Authenticate(authCtx, req, ac); err != nil {
 		return nil, fmt.Errorf("building auth inspection: %w", err)
 	}
 	return req, nil
@@ -788,6 +884,14 @@ func selectedOperationSources(selected []selectedOperationAuth) []string {
 	return sources
 }
 
+func selectedOperationResolvedConfigs(selected []selectedOperationAuth) []resolvedAuthConfig {
+	resolved := make([]resolvedAuthConfig, 0, len(selected))
+	for _, item := range selected {
+		resolved = append(resolved, item.resolved)
+	}
+	return resolved
+}
+
 func selectedOperationAuthConfigs(selected []selectedOperationAuth) []*config.AuthConfig {
 	configs := make([]*config.AuthConfig, 0, len(selected))
 	for _, item := range selected {
@@ -889,6 +993,7 @@ func (c *CLI) operationSetForAPI(ctx context.Context, apiName string, apiCfg *co
 			APIName:         c.apiStateName(apiName),
 			BaseURL:         effectiveProfileBaseURL(apiCfg, profileName),
 			SpecFiles:       apiCfg.SpecFiles,
+			OverlayFiles:    apiCfg.OverlayFiles,
 			CacheDir:        c.specCacheDir(),
 			OperationBase:   effectiveOperationBase(apiCfg, profileName),
 			ServerVariables: effectiveServerVariables(apiCfg, profileName),
@@ -1018,7 +1123,7 @@ func (c *CLI) printAPIAuthRequirementSummary(apiName, profileName string, ops []
 
 func nextMissingCredentialID(ops []spec.Operation, prof *config.ProfileConfig, coverage operationAuthCoverage) string {
 	for _, summary := range authRequirementSummaries(ops) {
-		if !authRequirementKindSupported(summary.kind) || summary.undeclared || summary.external {
+		if !authRequirementKindSupported(summary.kind) || summary.undeclared {
 			continue
 		}
 		if coverage.FallbackByID[summary.id] > 0 {
@@ -1088,7 +1193,7 @@ func mergeStringSet(existing, values []string) []string {
 
 func authRequirementKindSupported(kind string) bool {
 	switch kind {
-	case "api-key", "http-basic", "http-bearer", "oauth2", "mtls":
+	case "api-key", "http-basic", "http-digest", "http-bearer", "oauth2", "mtls":
 		return true
 	default:
 		return false
@@ -10,6 +10,7 @@ import (
 
 	"github.com/rest-sh/restish/v2/auth"
 	"github.com/rest-sh/restish/v2/config"
+	authpkg "github.com/rest-sh/restish/v2/internal/auth"
 	"github.com/rest-sh/restish/v2/internal/spec"
 )
 
@@ -268,6 +269,9 @@ func (c *CLI) promptAuthParams(ctx context.Context, profileName, credentialID st
 		return err
 	}
 	promptParams := configureAuthPromptParams(handler, defaultNeeds)
+	if !authpkg.UsesClientSecret(ac.Params) {
+		promptParams = withoutAuthParam(promptParams, "client_secret")
+	}
 	if !c.canPromptInteractively() {
 		missing := missingAuthSetupExpressionKeys(profileName, credentialID, ac, promptParams, answers)
 		if len(missing) > 0 {
@@ -371,6 +375,16 @@ func configureAuthPromptParams(handler auth.Handler, defaultNeeds []string) []au
 	return out
 }
 
+func withoutAuthParam(params []auth.Param, name string) []auth.Param {
+	out := params[:0:0]
+	for _, p := range params {
+		if p.Name != name {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
 func (c *CLI) readAuthParam(ctx context.Context, p auth.Param, defaultNeeds []string) (string, error) {
 	label := authParamLabel(p, defaultNeeds)
 	var (
@@ -0,0 +1,521 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/rest-sh/restish/v2/config"
+	"github.com/rest-sh/restish/v2/internal/secrets"
+	"github.com/rest-sh/restish/v2/internal/spec"
+	"github.com/spf13/cobra"
+)
+
+// apiExportFormats lists the collection formats api export writes.
+var apiExportFormats = []string{"postman", "insomnia", "bruno", "http"}
+
+func (c *CLI) newAPIExportCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export <name>",
+		Short: "Export a registered API as a Postman, Insomnia, Bruno, or .http collection",
+		Long:  apiExportLong,
+		Example: fmt.Sprintf(`  %s api export demo --format postman > demo.postman_collection.json
+  %s api export demo --format insomnia --out demo-insomnia.json
+  %s api export demo --format bruno --out ./demo-bruno
+  %s api export demo --format http --out demo.http`, c.commandNameOrDefault(), c.commandNameOrDefault(), c.commandNameOrDefault(), c.commandNameOrDefault()),
+		Args: usageExactArgs(1),
+		RunE: c.runAPIExport,
+	}
+	cmd.Flags().String("format", "", "Collection format: "+strings.Join(apiExportFormats, ", "))
+	cmd.Flags().String("out", "", "File to write, or directory for bruno; Postman and .http environments are written next to it")
+	return cmd
+}
+
+func (c *CLI) runAPIExport(cmd *cobra.Command, args []string) error {
+	apiName := args[0]
+	format, _ := cmd.Flags().GetString("format")
+	out, _ := cmd.Flags().GetString("out")
+	switch format {
+	case "postman", "insomnia", "bruno", "http":
+	case "":
+		return newUsageError(fmt.Errorf("--format is required; use one of %s", strings.Join(apiExportFormats, ", ")))
+	default:
+		return newUsageError(fmt.Errorf("unsupported --format %q; use one of %s", format, strings.Join(apiExportFormats, ", ")))
+	}
+	if format == "bruno" && out == "" {
+		return newUsageError(errors.New("--format bruno writes a collection directory; set --out"))
+	}
+	apiCfg, err := c.requireAPI(apiName)
+	if err != nil {
+		return err
+	}
+	profileName := c.profileFromCmd(cmd)
+	if profileName != "default" && apiCfg.Profiles[profileName] == nil {
+		return fmt.Errorf("API %q has no profile %q; configured profiles: %s", apiName, profileName, profileNames(apiCfg.Profiles))
+	}
+	set, ok := c.cachedOperationSetForAPI(requestContext(cmd), apiName, apiCfg, profileName)
+	if !ok {
+		return fmt.Errorf("no cached spec for API %q; run %q first", apiName, c.commandNameOrDefault()+" api sync "+apiName)
+	}
+	export, err := newAPIExport(apiName, apiCfg, profileName, set)
+	if err != nil {
+		return err
+	}
+	for _, warning := range export.warnings {
+		c.warnf("%s", warning)
+	}
+
+	var files []apiExportFile
+	switch format {
+	case "postman":
+		files, err = export.postman()
+	case "insomnia":
+		files, err = export.insomnia()
+	case "bruno":
+		files, err = export.bruno()
+	case "http":
+		files, err = export.http(out != "")
+	}
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		_, err := c.Stdout.Write(files[0].Data)
+		return err
+	}
+	return writeAPIExportFiles(out, format, files)
+}
+
+// apiExportFile is one generated file. The first file of an export is the
+// collection itself; Name is relative to the --out directory for bruno and a
+// suffix for the sibling environment files of other formats.
+type apiExportFile struct {
+	Name string
+	Data []byte
+}
+
+func writeAPIExportFiles(out, format string, files []apiExportFile) error {
+	if format == "bruno" {
+		if entries, err := os.ReadDir(out); err == nil && len(entries) > 0 {
+			return fmt.Errorf("--out %s already exists and is not empty", out)
+		}
+		for _, f := range files {
+			p := filepath.Join(out, filepath.FromSlash(f.Name))
+			if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+				return err
+			}
+			if err := os.WriteFile(p, f.Data, 0o644); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := os.WriteFile(out, files[0].Data, 0o644); err != nil {
+		return err
+	}
+	for _, f := range files[1:] {
+		if err := os.WriteFile(filepath.Join(filepath.Dir(out), f.Name), f.Data, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// apiExport is the format-neutral collection built from a cached operation
+// set and the API's saved config. URLs use a {{baseUrl}} variable unless a
+// url_overrides entry or an operation server sends the request elsewhere.
+type apiExport struct {
+	Name         string
+	Title        string
+	Description  string
+	Auth         *apiExportAuth
+	Environments []apiExportEnvironment
+	Requests     []apiExportRequest
+	// Headers and Query are profile defaults sent with every request. Their
+	// values are variables so each environment can supply its own.
+	Headers []apiExportParam
+	Query   []apiExportParam
+
+	warnings []string
+}
+
+type apiExportEnvironment struct {
+	Name string
+	Vars []apiExportVar
+}
+
+type apiExportVar struct {
+	Name   string
+	Value  string
+	Secret bool
+}
+
+type apiExportRequest struct {
+	ID          string
+	Name        string
+	Description string
+	Folder      string
+	Method      string
+	// URL is the request URL with {name} path templates and, unless the
+	// request targets another origin, a {{baseUrl}} prefix.
+	URL       string
+	Path      []apiExportParam
+	Query     []apiExportParam
+	Headers   []apiExportParam
+	MediaType string
+	Body      string
+	NoAuth    bool
+}
+
+type apiExportParam struct {
+	Name        string
+	Value       string
+	Description string
+	Required    bool
+}
+
+// apiExportAuth is the selected profile's auth mapped to collection auth.
+// Values are variable names, except for non-secret endpoint settings.
+type apiExportAuth struct {
+	Type         string // bearer, basic, digest, apikey, oauth2
+	In           string
+	Name         string
+	GrantType    string
+	AuthorizeURL string
+	TokenURL     string
+	Scopes       string
+}
+
+func newAPIExport(apiName string, apiCfg *config.APIConfig, profileName string, set spec.OperationSet) (*apiExport, error) {
+	e := &apiExport{Name: apiName, Title: set.Info.Title, Description: set.Info.Description}
+	if e.Title == "" {
+		e.Title = apiName
+	}
+	selected := profileForName(apiCfg, profileName)
+	if selected != nil && selected.Auth != nil {
+		e.Auth = e.exportAuth(selected.Auth)
+	} else if selected != nil && selected.AuthRef != "" {
+		e.warnf("profile %q uses auth_ref %q; configure auth in the exported collection by hand", profileName, selected.AuthRef)
+	}
+
+	baseURL := effectiveProfileBaseURL(apiCfg, profileName)
+	resolvedBase, err := config.ResolveOperationBaseURL(baseURL, effectiveOperationBase(apiCfg, profileName))
+	if err != nil {
+		return nil, fmt.Errorf("operation_base: %w", err)
+	}
+	resolvedBase = strings.TrimRight(resolvedBase, "/")
+	overrides := effectiveURLOverrides(apiCfg, profileName)
+
+	headerVars, queryVars := e.profileDefaults(apiCfg)
+	e.environments(apiCfg, profileName, headerVars, queryVars)
+
+	for _, op := range set.Operations {
+		req := apiExportRequest{
+			ID:          op.ID,
+			Name:        op.Summary,
+			Description: op.Description,
+			Method:      strings.ToUpper(op.Method),
+			MediaType:   op.RequestMediaType,
+			NoAuth:      op.NoAuth,
+		}
+		if req.Name == "" {
+			req.Name = operationCommandName(op, effectiveOperationBase(apiCfg, profileName))
+		}
+		if len(op.Tags) > 0 {
+			req.Folder = op.Tags[0]
+		}
+		req.URL = "{{baseUrl}}" + op.Path
+		literal := resolvedBase + op.Path
+		if op.OperationServer != "" {
+			literal = strings.TrimRight(op.OperationServer, "/") + op.Path
+			req.URL = literal
+		}
+		rewritten, ok, err := config.ApplyURLOverrides(literal, overrides)
+		if err != nil {
+			return nil, fmt.Errorf("url_overrides: %w", err)
+		}
+		if ok {
+			req.URL = strings.NewReplacer("%7B", "{", "%7D", "}").Replace(rewritten)
+		}
+		for _, p := range op.Parameters {
+			if p.XCLI.Ignore {
+				continue
+			}
+			param := apiExportParam{Name: p.Name, Value: paramExample(p), Description: p.Desc, Required: p.Required}
+			switch p.In {
+			case "path":
+				req.Path = append(req.Path, param)
+			case "query":
+				req.Query = append(req.Query, param)
+			case "header":
+				req.Headers = append(req.Headers, param)
+			}
+		}
+		if op.Help.Request != nil {
+			req.MediaType = op.Help.Request.MediaType
+			if !op.Help.Request.RawBinary {
+				req.Body = op.Help.Request.Example
+			}
+		}
+		e.Requests = append(e.Requests, req)
+	}
+	return e, nil
+}
+
+func (e *apiExport) warnf(format string, args ...any) {
+	e.warnings = append(e.warnings, fmt.Sprintf(format, args...))
+}
+
+// exportAuth maps restish auth to collection auth. Handlers without a
+// collection equivalent, such as external tools, are left for the user.
+func (e *apiExport) exportAuth(ac *config.AuthConfig) *apiExportAuth {
+	switch ac.Type {
+	case "bearer":
+		return &apiExportAuth{Type: "bearer"}
+	case "http-basic":
+		return &apiExportAuth{Type: "basic"}
+	case "http-digest":
+		return &apiExportAuth{Type: "digest"}
+	case "api-key":
+		in := ac.Params["in"]
+		if in == "" {
+			in = "header"
+		}
+		return &apiExportAuth{Type: "apikey", In: in, Name: ac.Params["name"]}
+	case "oauth-authorization-code":
+		return &apiExportAuth{Type: "oauth2", GrantType: "authorization_code", AuthorizeURL: ac.Params["authorize_url"], TokenURL: ac.Params["token_url"], Scopes: ac.Params["scopes"]}
+	case "oauth-client-credentials":
+		return &apiExportAuth{Type: "oauth2", GrantType: "client_credentials", TokenURL: ac.Params["token_url"], Scopes: ac.Params["scopes"]}
+	}
+	e.warnf("auth type %q has no collection equivalent; configure auth in the exported collection by hand", ac.Type)
+	return nil
+}
+
+// apiExportAuthVars maps collection auth variables to restish auth params.
+var apiExportAuthVars = []struct{ Var, Param string }{
+	{"token", "token"},
+	{"username", "username"},
+	{"password", "password"},
+	{"apiKey", "value"},
+	{"clientId", "client_id"},
+	{"clientSecret", "client_secret"},
+}
+
+// vars returns the variables the collection auth references.
+func (a *apiExportAuth) vars() []string {
+	if a == nil {
+		return nil
+	}
+	switch a.Type {
+	case "bearer":
+		return []string{"token"}
+	case "basic", "digest":
+		return []string{"username", "password"}
+	case "apikey":
+		return []string{"apiKey"}
+	case "oauth2":
+		if a.GrantType == "client_credentials" {
+			return []string{"clientId", "clientSecret"}
+		}
+		return []string{"clientId"}
+	}
+	return nil
+}
+
+// profileDefaults collects the persistent headers and query parameters of
+// every profile. Each becomes a request header or query parameter whose value
+// is an environment variable.
+func (e *apiExport) profileDefaults(apiCfg *config.APIConfig) (headerVars, queryVars map[string]string) {
+	headerVars, queryVars = map[string]string{}, map[string]string{}
+	for _, name := range sortedProfileNames(apiCfg) {
+		prof := apiCfg.Profiles[name]
+		for _, h := range prof.Headers {
+			key, _, ok := strings.Cut(h, ":")
+			key = strings.TrimSpace(key)
+			if !ok || headerVars[strings.ToLower(key)] != "" {
+				continue
+			}
+			v := apiExportVarName("header", key)
+			headerVars[strings.ToLower(key)] = v
+			e.Headers = append(e.Headers, apiExportParam{Name: key, Value: "{{" + v + "}}"})
+		}
+		for _, q := range prof.Query {
+			key, _, ok := strings.Cut(q, "=")
+			key = strings.TrimSpace(key)
+			if !ok || queryVars[key] != "" {
+				continue
+			}
+			v := apiExportVarName("query", key)
+			queryVars[key] = v
+			e.Query = append(e.Query, apiExportParam{Name: key, Value: "{{" + v + "}}"})
+		}
+	}
+	return headerVars, queryVars
+}
+
+// environments maps each profile to an environment, selected profile first.
+// Secrets, secret references, and credential-like header and query values
+// are exported as empty placeholders.
+func (e *apiExport) environments(apiCfg *config.APIConfig, selected string, headerVars, queryVars map[string]string) {
+	names := sortedProfileNames(apiCfg)
+	if len(names) == 0 {
+		names = []string{"default"}
+	}
+	sort.SliceStable(names, func(i, j int) bool { return names[i] == selected && names[j] != selected })
+	for _, name := range names {
+		prof := profileForName(apiCfg, name)
+		env := apiExportEnvironment{Name: name}
+		base := effectiveProfileBaseURL(apiCfg, name)
+		if resolved, err := config.ResolveOperationBaseURL(base, effectiveOperationBase(apiCfg, name)); err == nil {
+			base = resolved
+		}
+		env.Vars = append(env.Vars, apiExportVar{Name: "baseUrl", Value: strings.TrimRight(base, "/")})
+		var params config.ParamMap
+		if prof != nil && prof.Auth != nil {
+			params = prof.Auth.Params
+		}
+		for _, v := range e.Auth.vars() {
+			for _, m := range apiExportAuthVars {
+				if m.Var == v {
+					value := params[m.Param]
+					secret := isSensitiveConfigKey(m.Param) || m.Param == "value"
+					env.Vars = append(env.Vars, apiExportVar{Name: v, Value: exportableValue(value, secret), Secret: secret})
+				}
+			}
+		}
+		values := map[string]string{}
+		if prof != nil {
+			for _, h := range prof.Headers {
+				key, value, _ := strings.Cut(h, ":")
+				key = strings.TrimSpace(key)
+				values["header:"+strings.ToLower(key)] = exportableValue(strings.TrimSpace(value), secrets.IsHeaderName(key))
+			}
+			for _, q := range prof.Query {
+				key, value, _ := strings.Cut(q, "=")
+				key = strings.TrimSpace(key)
+				values["query:"+key] = exportableValue(strings.TrimSpace(value), secrets.IsQueryParamName(key))
+			}
+		}
+		for _, h := range e.Headers {
+			key := strings.ToLower(h.Name)
+			env.Vars = append(env.Vars, apiExportVar{Name: headerVars[key], Value: values["header:"+key], Secret: secrets.IsHeaderName(h.Name)})
+		}
+		for _, q := range e.Query {
+			env.Vars = append(env.Vars, apiExportVar{Name: queryVars[q.Name], Value: values["query:"+q.Name], Secret: secrets.IsQueryParamName(q.Name)})
+		}
+		e.Environments = append(e.Environments, env)
+	}
+}
+
+// exportableValue returns value unless it is secret or a secret reference.
+func exportableValue(value string, secret bool) string {
+	if secret {
+		return ""
+	}
+	if _, ok := config.ParseSecretRef(value); ok || secrets.LooksSensitiveValue(value) {
+		return ""
+	}
+	return value
+}
+
+func sortedProfileNames(apiCfg *config.APIConfig) []string {
+	names := make([]string, 0, len(apiCfg.Profiles))
+	for name := range apiCfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if (names[i] == "default") != (names[j] == "default") {
+			return names[i] == "default"
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+func apiExportVarName(prefix, name string) string {
+	var b strings.Builder
+	b.WriteString(prefix)
+	upper := true
+	for _, r := range name {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			if upper {
+				b.WriteString(strings.ToUpper(string(r)))
+			} else {
+				b.WriteRune(r)
+			}
+			upper = false
+			continue
+		}
+		upper = true
+	}
+	return b.String()
+}
+
+// paramExample returns a parameter example from its schema, default, or enum.
+func paramExample(p spec.Param) string {
+	if v, ok := p.JSONSchema["example"]; ok {
+		return exportScalar(v)
+	}
+	if values, ok := p.JSONSchema["examples"].([]any); ok && len(values) > 0 {
+		return exportScalar(values[0])
+	}
+	if p.HasDefault {
+		return p.Default
+	}
+	if len(p.Enum) > 0 {
+		return p.Enum[0]
+	}
+	return ""
+}
+
+func exportScalar(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case nil:
+		return ""
+	}
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+// withPathStyle rewrites {name} path templates, e.g. to :name or {{name}}.
+func withPathStyle(rawURL string, params []apiExportParam, style func(name string) string) string {
+	for _, p := range params {
+		rawURL = strings.ReplaceAll(rawURL, "{"+p.Name+"}", style(p.Name))
+	}
+	return rawURL
+}
+
+// withQuery appends enabled query parameters to a URL.
+func withQuery(rawURL string, params []apiExportParam, enabled func(apiExportParam) bool) string {
+	var parts []string
+	for _, p := range params {
+		if enabled(p) {
+			parts = append(parts, url.QueryEscape(p.Name)+"="+p.Value)
+		}
+	}
+	if len(parts) == 0 {
+		return rawURL
+	}
+	return rawURL + "?" + strings.Join(parts, "&")
+}
+
+// queryEnabled reports whether an exported query parameter is sent by
+// default: required parameters and those with an example value.
+func queryEnabled(p apiExportParam) bool {
+	return p.Required || p.Value != ""
+}
+
+func marshalExportJSON(v any) ([]byte, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
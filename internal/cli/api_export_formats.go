@@ -0,0 +1,720 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const postmanCollectionSchema = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+// postman renders a Postman Collection v2.1 document. Collection variables
+// hold the selected profile's values; every profile is also written as a
+// sibling <api>.<profile>.postman_environment.json file.
+func (e *apiExport) postman() ([]apiExportFile, error) {
+	collection := map[string]any{
+		"info": map[string]any{
+			"name":        e.Title,
+			"description": e.Description,
+			"schema":      postmanCollectionSchema,
+		},
+	}
+	if auth := e.postmanAuth(); auth != nil {
+		collection["auth"] = auth
+	}
+	if len(e.Environments) > 0 {
+		collection["variable"] = postmanVariables(e.Environments[0].Vars, "type")
+	}
+	var items []any
+	folders := map[string]map[string]any{}
+	for _, req := range e.Requests {
+		item := e.postmanItem(req)
+		if req.Folder == "" {
+			items = append(items, item)
+			continue
+		}
+		folder := folders[req.Folder]
+		if folder == nil {
+			folder = map[string]any{"name": req.Folder, "item": []any{}}
+			folders[req.Folder] = folder
+			items = append(items, folder)
+		}
+		folder["item"] = append(folder["item"].([]any), item)
+	}
+	collection["item"] = items
+	data, err := marshalExportJSON(collection)
+	if err != nil {
+		return nil, err
+	}
+	files := []apiExportFile{{Name: e.Name + ".postman_collection.json", Data: data}}
+	for _, env := range e.Environments {
+		data, err := marshalExportJSON(map[string]any{
+			"name":                    e.Name + " " + env.Name,
+			"values":                  postmanVariables(env.Vars, "type"),
+			"_postman_variable_scope": "environment",
+		})
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, apiExportFile{Name: e.Name + "." + env.Name + ".postman_environment.json", Data: data})
+	}
+	return files, nil
+}
+
+func postmanVariables(vars []apiExportVar, typeKey string) []any {
+	out := make([]any, 0, len(vars))
+	for _, v := range vars {
+		kind := "default"
+		if v.Secret {
+			kind = "secret"
+		}
+		out = append(out, map[string]any{"key": v.Name, "value": v.Value, typeKey: kind, "enabled": true})
+	}
+	return out
+}
+
+func (e *apiExport) postmanAuth() map[string]any {
+	a := e.Auth
+	if a == nil {
+		return nil
+	}
+	kv := func(pairs ...string) []any {
+		var out []any
+		for i := 0; i+1 < len(pairs); i += 2 {
+			out = append(out, map[string]any{"key": pairs[i], "value": pairs[i+1], "type": "string"})
+		}
+		return out
+	}
+	switch a.Type {
+	case "bearer":
+		return map[string]any{"type": "bearer", "bearer": kv("token", "{{token}}")}
+	case "basic", "digest":
+		return map[string]any{"type": a.Type, a.Type: kv("username", "{{username}}", "password", "{{password}}")}
+	case "apikey":
+		return map[string]any{"type": "apikey", "apikey": kv("key", a.Name, "value", "{{apiKey}}", "in", a.In)}
+	case "oauth2":
+		pairs := []string{"grant_type", a.GrantType, "accessTokenUrl", a.TokenURL, "clientId", "{{clientId}}", "scope", a.Scopes}
+		if a.GrantType == "client_credentials" {
+			pairs = append(pairs, "clientSecret", "{{clientSecret}}")
+		} else {
+			pairs = append(pairs, "authUrl", a.AuthorizeURL)
+		}
+		return map[string]any{"type": "oauth2", "oauth2": kv(pairs...)}
+	}
+	return nil
+}
+
+func (e *apiExport) postmanItem(req apiExportRequest) map[string]any {
+	rawURL := withPathStyle(req.URL, req.Path, func(name string) string { return ":" + name })
+	query := append(append([]apiExportParam(nil), e.Query...), req.Query...)
+	urlObject := map[string]any{"raw": withQuery(rawURL, query, queryEnabled)}
+	if host, path := postmanURLParts(rawURL); host != nil {
+		urlObject["host"] = host
+		urlObject["path"] = path
+	}
+	if len(query) > 0 {
+		var list []any
+		for _, p := range query {
+			list = append(list, postmanKeyValue(p, !queryEnabled(p)))
+		}
+		urlObject["query"] = list
+	}
+	if len(req.Path) > 0 {
+		var list []any
+		for _, p := range req.Path {
+			list = append(list, postmanKeyValue(p, false))
+		}
+		urlObject["variable"] = list
+	}
+	request := map[string]any{"method": req.Method, "url": urlObject}
+	if req.Description != "" {
+		request["description"] = req.Description
+	}
+	var headers []any
+	for _, p := range e.requestHeaders(req) {
+		headers = append(headers, postmanKeyValue(p, !p.Required && p.Value == ""))
+	}
+	if headers != nil {
+		request["header"] = headers
+	}
+	if req.Body != "" {
+		body := map[string]any{"mode": "raw", "raw": req.Body}
+		if lang := postmanRawLanguage(req.MediaType); lang != "" {
+			body["options"] = map[string]any{"raw": map[string]any{"language": lang}}
+		}
+		request["body"] = body
+	}
+	if req.NoAuth && e.Auth != nil {
+		request["auth"] = map[string]any{"type": "noauth"}
+	}
+	return map[string]any{"name": req.Name, "request": request}
+}
+
+func postmanKeyValue(p apiExportParam, disabled bool) map[string]any {
+	out := map[string]any{"key": p.Name, "value": p.Value}
+	if p.Description != "" {
+		out["description"] = p.Description
+	}
+	if disabled {
+		out["disabled"] = true
+	}
+	return out
+}
+
+// postmanURLParts splits a request URL into Postman host and path segments.
+func postmanURLParts(rawURL string) ([]string, []string) {
+	var host string
+	rest := rawURL
+	if strings.HasPrefix(rawURL, "{{baseUrl}}") {
+		host, rest = "{{baseUrl}}", strings.TrimPrefix(rawURL, "{{baseUrl}}")
+	} else {
+		u, err := url.Parse(rawURL)
+		if err != nil || u.Host == "" {
+			return nil, nil
+		}
+		host, rest = u.Scheme+"://"+u.Host, strings.TrimPrefix(rawURL, u.Scheme+"://"+u.Host)
+	}
+	return []string{host}, strings.Split(strings.TrimPrefix(rest, "/"), "/")
+}
+
+func postmanRawLanguage(mediaType string) string {
+	switch {
+	case strings.Contains(mediaType, "json"):
+		return "json"
+	case strings.Contains(mediaType, "xml"):
+		return "xml"
+	case strings.HasPrefix(mediaType, "text/"):
+		return "text"
+	}
+	return ""
+}
+
+// requestHeaders returns profile default headers, operation header
+// parameters, and a Content-Type header for requests with a body.
+func (e *apiExport) requestHeaders(req apiExportRequest) []apiExportParam {
+	var headers []apiExportParam
+	if req.Body != "" && req.MediaType != "" {
+		headers = append(headers, apiExportParam{Name: "Content-Type", Value: req.MediaType, Required: true})
+	}
+	for _, h := range e.Headers {
+		h.Required = true
+		headers = append(headers, h)
+	}
+	return append(headers, req.Headers...)
+}
+
+var exportVarPattern = regexp.MustCompile(`\{\{([A-Za-z0-9_]+)\}\}`)
+
+// insomniaVars rewrites {{name}} variables to Insomnia's {{ _.name }} form.
+func insomniaVars(s string) string {
+	return exportVarPattern.ReplaceAllString(s, "{{ _.$1 }}")
+}
+
+// insomnia renders an Insomnia v4 export. The base environment holds the
+// selected profile's values and each profile becomes a sub-environment.
+func (e *apiExport) insomnia() ([]apiExportFile, error) {
+	workspaceID := "wrk_" + exportID(e.Name)
+	resources := []any{map[string]any{
+		"_id":         workspaceID,
+		"_type":       "workspace",
+		"name":        e.Title,
+		"description": e.Description,
+		"scope":       "collection",
+	}}
+	envData := func(vars []apiExportVar) map[string]any {
+		data := map[string]any{}
+		for _, v := range vars {
+			data[v.Name] = v.Value
+		}
+		return data
+	}
+	baseEnv := map[string]any{}
+	if len(e.Environments) > 0 {
+		baseEnv = envData(e.Environments[0].Vars)
+	}
+	resources = append(resources, map[string]any{
+		"_id":      "env_" + exportID(e.Name),
+		"_type":    "environment",
+		"parentId": workspaceID,
+		"name":     "Base Environment",
+		"data":     baseEnv,
+	})
+	for i, env := range e.Environments {
+		resources = append(resources, map[string]any{
+			"_id":         fmt.Sprintf("env_%s_%d", exportID(e.Name), i+1),
+			"_type":       "environment",
+			"parentId":    "env_" + exportID(e.Name),
+			"name":        env.Name,
+			"data":        envData(env.Vars),
+			"metaSortKey": i,
+		})
+	}
+	folders := map[string]string{}
+	for i, req := range e.Requests {
+		parentID := workspaceID
+		if req.Folder != "" {
+			if folders[req.Folder] == "" {
+				folders[req.Folder] = fmt.Sprintf("fld_%s_%d", exportID(e.Name), len(folders)+1)
+				resources = append(resources, map[string]any{
+					"_id":         folders[req.Folder],
+					"_type":       "request_group",
+					"parentId":    workspaceID,
+					"name":        req.Folder,
+					"metaSortKey": len(folders),
+				})
+			}
+			parentID = folders[req.Folder]
+		}
+		resource := map[string]any{
+			"_id":         fmt.Sprintf("req_%s_%d", exportID(e.Name), i+1),
+			"_type":       "request",
+			"parentId":    parentID,
+			"name":        req.Name,
+			"description": req.Description,
+			"method":      req.Method,
+			"url":         insomniaVars(withPathStyle(req.URL, req.Path, func(name string) string { return ":" + name })),
+			"metaSortKey": i,
+		}
+		params := []any{}
+		for _, p := range append(append([]apiExportParam(nil), e.Query...), req.Query...) {
+			params = append(params, insomniaParam(p, !queryEnabled(p)))
+		}
+		resource["parameters"] = params
+		var pathParams []any
+		for _, p := range req.Path {
+			pathParams = append(pathParams, insomniaParam(p, false))
+		}
+		if pathParams != nil {
+			resource["pathParameters"] = pathParams
+		}
+		headers := []any{}
+		for _, h := range e.requestHeaders(req) {
+			headers = append(headers, insomniaParam(h, !h.Required && h.Value == ""))
+		}
+		resource["headers"] = headers
+		if req.Body != "" {
+			resource["body"] = map[string]any{"mimeType": req.MediaType, "text": req.Body}
+		}
+		resource["authentication"] = e.insomniaAuth(req.NoAuth)
+		resources = append(resources, resource)
+	}
+	data, err := marshalExportJSON(map[string]any{
+		"_type":           "export",
+		"__export_format": 4,
+		"__export_source": "restish",
+		"resources":       resources,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return []apiExportFile{{Name: e.Name + ".insomnia.json", Data: data}}, nil
+}
+
+func insomniaParam(p apiExportParam, disabled bool) map[string]any {
+	out := map[string]any{"name": p.Name, "value": insomniaVars(p.Value)}
+	if p.Description != "" {
+		out["description"] = p.Description
+	}
+	if disabled {
+		out["disabled"] = true
+	}
+	return out
+}
+
+func (e *apiExport) insomniaAuth(noAuth bool) map[string]any {
+	a := e.Auth
+	if a == nil {
+		return map[string]any{}
+	}
+	if noAuth {
+		return map[string]any{"type": "none"}
+	}
+	switch a.Type {
+	case "bearer":
+		return map[string]any{"type": "bearer", "token": "{{ _.token }}"}
+	case "basic", "digest":
+		return map[string]any{"type": a.Type, "username": "{{ _.username }}", "password": "{{ _.password }}"}
+	case "apikey":
+		addTo := map[string]string{"header": "header", "query": "queryParams", "cookie": "cookie"}[a.In]
+		return map[string]any{"type": "apikey", "key": a.Name, "value": "{{ _.apiKey }}", "addTo": addTo}
+	case "oauth2":
+		out := map[string]any{
+			"type":           "oauth2",
+			"grantType":      a.GrantType,
+			"accessTokenUrl": a.TokenURL,
+			"clientId":       "{{ _.clientId }}",
+			"scope":          a.Scopes,
+		}
+		if a.GrantType == "client_credentials" {
+			out["clientSecret"] = "{{ _.clientSecret }}"
+		} else {
+			out["authorizationUrl"] = a.AuthorizeURL
+		}
+		return out
+	}
+	return map[string]any{}
+}
+
+func exportID(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// bruno renders a Bruno collection directory: bruno.json, collection.bru with
+// auth and default headers, one environment per profile, and one .bru file
+// per operation in a folder per tag.
+func (e *apiExport) bruno() ([]apiExportFile, error) {
+	meta, err := marshalExportJSON(map[string]any{
+		"version": "1",
+		"name":    e.Title,
+		"type":    "collection",
+		"ignore":  []string{"node_modules", ".git"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	files := []apiExportFile{{Name: "bruno.json", Data: meta}}
+
+	var root brunoWriter
+	mode := "none"
+	if e.Auth != nil {
+		mode = e.Auth.Type
+	}
+	root.dict("auth", []apiExportParam{{Name: "mode", Value: mode}})
+	e.brunoAuth(&root)
+	if len(e.Headers) > 0 {
+		root.dict("headers", e.Headers)
+	}
+	if e.Description != "" {
+		root.text("docs", e.Description)
+	}
+	files = append(files, apiExportFile{Name: "collection.bru", Data: root.bytes()})
+
+	for _, env := range e.Environments {
+		var w brunoWriter
+		var vars []apiExportParam
+		var secretVars []string
+		for _, v := range env.Vars {
+			if v.Secret {
+				secretVars = append(secretVars, v.Name)
+				continue
+			}
+			vars = append(vars, apiExportParam{Name: v.Name, Value: v.Value})
+		}
+		w.dict("vars", vars)
+		if len(secretVars) > 0 {
+			w.list("vars:secret", secretVars)
+		}
+		files = append(files, apiExportFile{Name: "environments/" + brunoFileName(env.Name) + ".bru", Data: w.bytes()})
+	}
+
+	folders := map[string]string{}
+	used := map[string]bool{}
+	seq := map[string]int{}
+	for _, req := range e.Requests {
+		dir := ""
+		if req.Folder != "" {
+			if folders[req.Folder] == "" {
+				folders[req.Folder] = uniqueBrunoName(brunoFileName(req.Folder), used)
+				var w brunoWriter
+				w.dict("meta", []apiExportParam{{Name: "name", Value: req.Folder}, {Name: "seq", Value: fmt.Sprint(len(folders))}})
+				files = append(files, apiExportFile{Name: folders[req.Folder] + "/folder.bru", Data: w.bytes()})
+			}
+			dir = folders[req.Folder] + "/"
+		}
+		seq[dir]++
+		name := uniqueBrunoName(dir+brunoFileName(req.Name), used)
+		files = append(files, apiExportFile{Name: name + ".bru", Data: e.brunoRequest(req, seq[dir])})
+	}
+	return files, nil
+}
+
+func (e *apiExport) brunoAuth(w *brunoWriter) {
+	a := e.Auth
+	if a == nil {
+		return
+	}
+	switch a.Type {
+	case "bearer":
+		w.dict("auth:bearer", []apiExportParam{{Name: "token", Value: "{{token}}"}})
+	case "basic", "digest":
+		w.dict("auth:"+a.Type, []apiExportParam{{Name: "username", Value: "{{username}}"}, {Name: "password", Value: "{{password}}"}})
+	case "apikey":
+		placement := "header"
+		if a.In == "query" {
+			placement = "queryparams"
+		}
+		w.dict("auth:apikey", []apiExportParam{{Name: "key", Value: a.Name}, {Name: "value", Value: "{{apiKey}}"}, {Name: "placement", Value: placement}})
+	case "oauth2":
+		params := []apiExportParam{
+			{Name: "grant_type", Value: a.GrantType},
+			{Name: "access_token_url", Value: a.TokenURL},
+			{Name: "client_id", Value: "{{clientId}}"},
+		}
+		if a.GrantType == "client_credentials" {
+			params = append(params, apiExportParam{Name: "client_secret", Value: "{{clientSecret}}"})
+		} else {
+			params = append(params, apiExportParam{Name: "authorization_url", Value: a.AuthorizeURL})
+		}
+		params = append(params, apiExportParam{Name: "scope", Value: a.Scopes})
+		w.dict("auth:oauth2", params)
+	}
+}
+
+func (e *apiExport) brunoRequest(req apiExportRequest, seq int) []byte {
+	var w brunoWriter
+	w.dict("meta", []apiExportParam{{Name: "name", Value: req.Name}, {Name: "type", Value: "http"}, {Name: "seq", Value: fmt.Sprint(seq)}})
+	bodyMode, bodyBlock := brunoBodyMode(req.MediaType)
+	if req.Body == "" {
+		bodyMode = "none"
+	}
+	auth := "inherit"
+	if req.NoAuth {
+		auth = "none"
+	}
+	rawURL := withPathStyle(req.URL, req.Path, func(name string) string { return ":" + name })
+	query := append(append([]apiExportParam(nil), e.Query...), req.Query...)
+	w.dict(strings.ToLower(req.Method), []apiExportParam{
+		{Name: "url", Value: withQuery(rawURL, query, queryEnabled)},
+		{Name: "body", Value: bodyMode},
+		{Name: "auth", Value: auth},
+	})
+	if len(query) > 0 {
+		w.toggles("params:query", query, queryEnabled)
+	}
+	if len(req.Path) > 0 {
+		w.dict("params:path", req.Path)
+	}
+	var headers []apiExportParam
+	for _, h := range req.Headers {
+		headers = append(headers, h)
+	}
+	if len(headers) > 0 {
+		w.toggles("headers", headers, func(p apiExportParam) bool { return p.Required || p.Value != "" })
+	}
+	if bodyMode != "none" {
+		if fields, ok := brunoFormFields(bodyMode, req.Body); ok {
+			w.dict(bodyBlock, fields)
+		} else {
+			w.text(bodyBlock, req.Body)
+		}
+	}
+	if req.Description != "" {
+		w.text("docs", req.Description)
+	}
+	return w.bytes()
+}
+
+func brunoBodyMode(mediaType string) (string, string) {
+	switch {
+	case strings.Contains(mediaType, "json"):
+		return "json", "body:json"
+	case strings.Contains(mediaType, "xml"):
+		return "xml", "body:xml"
+	case mediaType == "application/x-www-form-urlencoded":
+		return "form-urlencoded", "body:form-urlencoded"
+	case strings.HasPrefix(mediaType, "multipart/"):
+		return "multipart-form", "body:multipart-form"
+	}
+	return "text", "body:text"
+}
+
+// brunoFormFields turns a JSON object example into form fields.
+func brunoFormFields(mode, body string) ([]apiExportParam, bool) {
+	if mode != "form-urlencoded" && mode != "multipart-form" {
+		return nil, false
+	}
+	var object map[string]any
+	if err := json.Unmarshal([]byte(body), &object); err != nil {
+		return nil, false
+	}
+	var fields []apiExportParam
+	for _, key := range sortedKeys(object) {
+		fields = append(fields, apiExportParam{Name: key, Value: exportScalar(object[key])})
+	}
+	return fields, true
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// brunoWriter writes Bru markup blocks.
+type brunoWriter struct {
+	b strings.Builder
+}
+
+func (w *brunoWriter) open(name, bracket string) {
+	if w.b.Len() > 0 {
+		w.b.WriteString("\n")
+	}
+	w.b.WriteString(name + " " + bracket + "\n")
+}
+
+func (w *brunoWriter) dict(name string, params []apiExportParam) {
+	w.toggles(name, params, func(apiExportParam) bool { return true })
+}
+
+// toggles writes a dictionary block, prefixing disabled pairs with "~".
+func (w *brunoWriter) toggles(name string, params []apiExportParam, enabled func(apiExportParam) bool) {
+	w.open(name, "{")
+	for _, p := range params {
+		prefix := ""
+		if !enabled(p) {
+			prefix = "~"
+		}
+		w.b.WriteString("  " + prefix + p.Name + ": " + strings.ReplaceAll(p.Value, "\n", " ") + "\n")
+	}
+	w.b.WriteString("}\n")
+}
+
+func (w *brunoWriter) text(name, text string) {
+	w.open(name, "{")
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		if line == "" {
+			w.b.WriteString("\n")
+			continue
+		}
+		w.b.WriteString("  " + line + "\n")
+	}
+	w.b.WriteString("}\n")
+}
+
+func (w *brunoWriter) list(name string, items []string) {
+	w.open(name, "[")
+	for i, item := range items {
+		sep := ","
+		if i == len(items)-1 {
+			sep = ""
+		}
+		w.b.WriteString("  " + item + sep + "\n")
+	}
+	w.b.WriteString("]\n")
+}
+
+func (w *brunoWriter) bytes() []byte {
+	return []byte(w.b.String())
+}
+
+// brunoFileName makes a portable file name from a display name.
+func brunoFileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|':
+			return '-'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	name = strings.Trim(name, ". ")
+	if name == "" {
+		name = "request"
+	}
+	return name
+}
+
+func uniqueBrunoName(name string, used map[string]bool) string {
+	candidate := name
+	for i := 2; used[strings.ToLower(candidate)]; i++ {
+		candidate = fmt.Sprintf("%s %d", name, i)
+	}
+	used[strings.ToLower(candidate)] = true
+	return candidate
+}
+
+// http renders a .http file for the VS Code REST Client and JetBrains HTTP
+// Client. Written to stdout, the file declares the selected profile's values
+// as file variables; written with --out, the variables instead go to a
+// sibling http-client.env.json with one environment per profile.
+func (e *apiExport) http(withEnvFile bool) ([]apiExportFile, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n", e.Title)
+	if !withEnvFile && len(e.Environments) > 0 {
+		fmt.Fprintf(&b, "# Variables from profile %q.\n\n", e.Environments[0].Name)
+		for _, v := range e.Environments[0].Vars {
+			fmt.Fprintf(&b, "%s\n", strings.TrimSpace("@"+v.Name+" = "+v.Value))
+		}
+	}
+	for _, req := range e.Requests {
+		fmt.Fprintf(&b, "\n### %s\n", req.Name)
+		if req.Description != "" {
+			for _, line := range strings.Split(strings.TrimSpace(req.Description), "\n") {
+				fmt.Fprintf(&b, "# %s\n", strings.TrimSpace(line))
+			}
+		}
+		rawURL := withPathStyle(req.URL, req.Path, func(name string) string {
+			for _, p := range req.Path {
+				if p.Name == name && p.Value != "" {
+					return url.PathEscape(p.Value)
+				}
+			}
+			return "{{" + name + "}}"
+		})
+		query := append(append([]apiExportParam(nil), e.Query...), req.Query...)
+		if !req.NoAuth && e.Auth != nil && e.Auth.Type == "apikey" && e.Auth.In == "query" {
+			query = append(query, apiExportParam{Name: e.Auth.Name, Value: "{{apiKey}}", Required: true})
+		}
+		fmt.Fprintf(&b, "%s %s\n", req.Method, withQuery(rawURL, query, queryEnabled))
+		if !req.NoAuth {
+			if header := e.httpAuthHeader(); header != "" {
+				b.WriteString(header + "\n")
+			}
+		}
+		for _, h := range e.requestHeaders(req) {
+			if h.Required || h.Value != "" {
+				fmt.Fprintf(&b, "%s: %s\n", h.Name, h.Value)
+			}
+		}
+		if req.Body != "" {
+			fmt.Fprintf(&b, "\n%s\n", strings.TrimRight(req.Body, "\n"))
+		}
+	}
+	files := []apiExportFile{{Name: e.Name + ".http", Data: []byte(b.String())}}
+	if withEnvFile {
+		envs := map[string]map[string]string{}
+		for _, env := range e.Environments {
+			values := map[string]string{}
+			for _, v := range env.Vars {
+				values[v.Name] = v.Value
+			}
+			envs[env.Name] = values
+		}
+		data, err := marshalExportJSON(envs)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, apiExportFile{Name: "http-client.env.json", Data: data})
+	}
+	return files, nil
+}
+
+func (e *apiExport) httpAuthHeader() string {
+	a := e.Auth
+	if a == nil {
+		return ""
+	}
+	switch a.Type {
+	case "bearer":
+		return "Authorization: Bearer {{token}}"
+	case "basic":
+		return "Authorization: Basic {{username}} {{password}}"
+	case "digest":
+		return "Authorization: Digest {{username}} {{password}}"
+	case "apikey":
+		if a.In == "header" {
+			return a.Name + ": {{apiKey}}"
+		}
+	}
+	return ""
+}
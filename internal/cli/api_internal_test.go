@@ -54,7 +54,7 @@ func TestAPIConnectRemovedCommandNamesAllowed(t *testing.T) {
 
 // TestIsBuiltinCommandName verifies the helper covers the expected set of names.
 func TestIsBuiltinCommandName(t *testing.T) {
-	builtins := []string{"api", "cache", "cert", "completion", "config", "delete", "doctor", "edit", "get", "head", "help", "links", "options", "patch", "plugin", "post", "put", "shell", "version"}
+	builtins := []string{"api", "cache", "cert", "completion", "config", "delete", "doctor", "edit", "get", "head", "help", "links", "options", "patch", "plugin", "post", "put", "run", "shell", "version", "workflow"}
 	for _, name := range builtins {
 		if !isBuiltinCommandName(name) {
 			t.Errorf("isBuiltinCommandName(%q) = false, want true", name)
@@ -12,6 +12,7 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"strconv"
 	"strings"
 	"sync"
 	"testing"
@@ -338,7 +339,7 @@ func TestAPIConnectReportsMutualTLSAsTransportAuth(t *testing.T) {
 	}
 }
 
-func TestAPIConnectExplicitSwaggerSpecReportsUnsupported(t *testing.T) {
+func TestAPIConnectExplicitSwaggerSpecConvertsToOpenAPI(t *testing.T) {
 	cfgFile := t.TempDir() + "/restish.json"
 	c, _, _ := newTestCLI(t)
 	c.Hooks().ConfigPath = cfgFile
@@ -350,7 +351,15 @@ func TestAPIConnectExplicitSwaggerSpecReportsUnsupported(t *testing.T) {
 				StatusCode: 200,
 				Proto:      "HTTP/1.1",
 				Header:     http.Header{"Content-Type": []string{"application/json"}},
-				Body:       io.NopCloser(strings.NewReader(`{"swagger":"2.0","info":{"title":"Old","version":"1.0"},"paths":{}}`)),
+				Body:       io.NopCloser(strings.NewReader(`{"swagger":"2.0","info":{"title":"Old","version":"1.0"},"basePath":"/v1","paths":{"/items":{"get":{"operationId":"listItems","responses":{"200":{"description":"OK"}}}}}}`)),
+				Request:    r,
+			}, nil
+		case "https://api.example.com/v1/items":
+			return &http.Response{
+				StatusCode: 200,
+				Proto:      "HTTP/1.1",
+				Header:     http.Header{"Content-Type": []string{"application/json"}},
+				Body:       io.NopCloser(strings.NewReader(`[]`)),
 				Request:    r,
 			}, nil
 		default:
@@ -364,12 +373,305 @@ func TestAPIConnectExplicitSwaggerSpecReportsUnsupported(t *testing.T) {
 		}
 	})
 
-	err := c.Run([]string{"restish", "api", "connect", "oldapi", "https://api.example.com", "--spec", "https://api.example.com/swagger.json"})
-	if err == nil {
-		t.Fatal("expected unsupported Swagger error")
+	if err := c.Run([]string{"restish", "api", "connect", "oldapi", "https://api.example.com", "--spec", "https://api.example.com/swagger.json"}); err != nil {
+		t.Fatalf("api connect: %v", err)
 	}
-	if !strings.Contains(err.Error(), "Swagger/OpenAPI 2.0 is not supported") {
-		t.Fatalf("unexpected error: %v", err)
+	data, err := os.ReadFile(cfgFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"spec_url": "https://api.example.com/swagger.json"`) {
+		t.Fatalf("expected Swagger spec_url in config, got:\n%s", data)
+	}
+	if err := c.Run([]string{"restish", "oldapi", "list-items"}); err != nil {
+		t.Fatalf("generated command from converted Swagger spec: %v", err)
+	}
+}
+
+func TestAPIConnectAndSyncApplyOverlays(t *testing.T) {
+	dir := t.TempDir()
+	cfgFile := dir + "/restish.json"
+	specPath := filepath.Join(dir, "vendor.yaml")
+	renamePath := filepath.Join(dir, "rename.yaml")
+	aliasPath := filepath.Join(dir, "alias.yaml")
+	if err := os.WriteFile(specPath, []byte(`openapi: "3.1.0"
+info: {title: Vendor, version: "1.0"}
+paths:
+  /items:
+    get:
+      operationId: listItemsV2Beta
+      responses:
+        "200": {description: OK}
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(renamePath, []byte(`overlay: 1.0.0
+info: {title: Rename, version: "1"}
+actions:
+  - target: $.paths['/items'].get
+    update:
+      x-cli-name: list-items
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(aliasPath, []byte(`overlay: 1.0.0
+info: {title: Alias, version: "1"}
+actions:
+  - target: $.paths['/items'].get
+    update:
+      x-cli-name: items
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c, _, _ := newTestCLI(t)
+	c.Hooks().ConfigPath = cfgFile
+	c.Hooks().SpecCachePath = t.TempDir()
+	var paths []string
+	useTransport(c, func(r *http.Request) (*http.Response, error) {
+		paths = append(paths, r.URL.Path)
+		return &http.Response{
+			StatusCode: 200,
+			Proto:      "HTTP/1.1",
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`[]`)),
+			Request:    r,
+		}, nil
+	})
+
+	if err := c.Run([]string{"restish", "api", "connect", "vendor", "https://api.example.com", "--spec", specPath, "--overlay", renamePath}); err != nil {
+		t.Fatalf("api connect: %v", err)
+	}
+	data, err := os.ReadFile(cfgFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"overlay_files": [`) || !strings.Contains(string(data), renamePath) {
+		t.Fatalf("expected overlay_files in config, got:\n%s", data)
+	}
+	if err := c.Run([]string{"restish", "vendor", "list-items"}); err != nil {
+		t.Fatalf("overlay-renamed command: %v", err)
+	}
+
+	if err := c.Run([]string{"restish", "api", "sync", "vendor", "--overlay", aliasPath}); err != nil {
+		t.Fatalf("api sync: %v", err)
+	}
+	data, err = os.ReadFile(cfgFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), renamePath) || !strings.Contains(string(data), aliasPath) {
+		t.Fatalf("api sync --overlay should replace overlay_files, got:\n%s", data)
+	}
+	if err := c.Run([]string{"restish", "vendor", "items"}); err != nil {
+		t.Fatalf("command renamed by synced overlay: %v", err)
+	}
+	if len(paths) != 2 || paths[0] != "/items" || paths[1] != "/items" {
+		t.Fatalf("requests = %#v", paths)
+	}
+}
+
+func TestAPIConnectFromPostmanCollection(t *testing.T) {
+	dir := t.TempDir()
+	cfgFile := dir + "/restish.json"
+	collectionPath := filepath.Join(dir, "partner.postman_collection.json")
+	if err := os.WriteFile(collectionPath, []byte(`{
+  "info": {"name": "Partner", "schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"},
+  "variable": [{"key": "baseUrl", "value": "https://api.example.com/v1"}, {"key": "tenant", "value": "acme"}],
+  "item": [{
+    "name": "List widgets",
+    "request": {
+      "method": "GET",
+      "header": [{"key": "X-Tenant", "value": "{{tenant}}"}],
+      "url": "{{baseUrl}}/widgets?limit=10"
+    }
+  }]
+}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c, _, _ := newTestCLI(t)
+	c.Hooks().ConfigPath = cfgFile
+	c.Hooks().SpecCachePath = t.TempDir()
+	var got *http.Request
+	useTransport(c, func(r *http.Request) (*http.Response, error) {
+		got = r
+		return &http.Response{
+			StatusCode: 200,
+			Proto:      "HTTP/1.1",
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`[]`)),
+			Request:    r,
+		}, nil
+	})
+
+	if err := c.Run([]string{"restish", "api", "connect", "partner", "https://api.example.com", "--spec", collectionPath}); err != nil {
+		t.Fatalf("api connect: %v", err)
+	}
+	if err := c.Run([]string{"restish", "partner", "list-widgets", "--limit", "5"}); err != nil {
+		t.Fatalf("list-widgets: %v", err)
+	}
+	if got == nil || got.URL.Path != "/v1/widgets" || got.URL.Query().Get("limit") != "5" {
+		t.Fatalf("request = %v", got)
+	}
+	if tenant := got.Header.Get("X-Tenant"); tenant != "acme" {
+		t.Fatalf("X-Tenant = %q, want profile header from the collection", tenant)
+	}
+}
+
+func TestAPIExportWritesCollections(t *testing.T) {
+	dir := t.TempDir()
+	specPath := filepath.Join(dir, "openapi.yaml")
+	if err := os.WriteFile(specPath, []byte(`openapi: "3.1.0"
+info:
+  title: Widgets
+  version: "1.0.0"
+components:
+  securitySchemes:
+    bearerAuth:
+      type: http
+      scheme: bearer
+security:
+  - bearerAuth: []
+paths:
+  /widgets/{id}:
+    get:
+      operationId: getWidget
+      summary: Get widget
+      tags: [widgets]
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema: {type: string, example: w-1}
+        - name: verbose
+          in: query
+          schema: {type: boolean, default: false}
+      responses:
+        "200":
+          description: OK
+  /widgets:
+    post:
+      operationId: createWidget
+      summary: Create widget
+      tags: [widgets]
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                name: {type: string, example: gear}
+      responses:
+        "201":
+          description: Created
+  /health:
+    get:
+      operationId: health
+      security: []
+      responses:
+        "200":
+          description: OK
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c, stdout, _ := newTestCLI(t)
+	c.Hooks().SpecCachePath = t.TempDir()
+	configBody := `{"apis":{"widgets":{"base_url":"https://api.example.com","spec_files":[` + strconv.Quote(specPath) + `],"profiles":{
+  "default":{"headers":["X-Tenant: acme"],"auth":{"type":"bearer","params":{"token":"s3cr3t-token"}},"url_overrides":{"https://api.example.com/health":"https://status.example.com/health"}},
+  "staging":{"base_url":"https://staging.example.com","auth":{"type":"bearer","params":{"token":"env:STAGING_TOKEN"}}}}}}}`
+	if err := os.WriteFile(c.Hooks().ConfigPath, []byte(configBody), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Run([]string{"restish", "api", "export", "widgets", "--format", "postman"}); err != nil {
+		t.Fatalf("api export: %v", err)
+	}
+	if strings.Contains(stdout.String(), "s3cr3t") {
+		t.Fatalf("export leaked a secret:\n%s", stdout.String())
+	}
+	var collection struct {
+		Auth     map[string]any `json:"auth"`
+		Variable []struct {
+			Key   string `json:"key"`
+			Value string `json:"value"`
+			Type  string `json:"type"`
+		} `json:"variable"`
+		Item []struct {
+			Name string `json:"name"`
+			Item []struct {
+				Name    string `json:"name"`
+				Request struct {
+					Method string `json:"method"`
+					URL    struct {
+						Raw string `json:"raw"`
+					} `json:"url"`
+					Body struct {
+						Raw string `json:"raw"`
+					} `json:"body"`
+				} `json:"request"`
+			} `json:"item"`
+			Request struct {
+				URL struct {
+					Raw string `json:"raw"`
+				} `json:"url"`
+				Auth map[string]any `json:"auth"`
+			} `json:"request"`
+		} `json:"item"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &collection); err != nil {
+		t.Fatalf("postman output: %v\n%s", err, stdout.String())
+	}
+	if collection.Auth["type"] != "bearer" {
+		t.Fatalf("auth = %v, want bearer", collection.Auth)
+	}
+	vars := map[string]string{}
+	for _, v := range collection.Variable {
+		vars[v.Key] = v.Value + "/" + v.Type
+	}
+	if vars["baseUrl"] != "https://api.example.com/default" || vars["token"] != "/secret" || vars["headerXTenant"] != "acme/default" {
+		t.Fatalf("variables = %v", vars)
+	}
+	if len(collection.Item) != 2 || collection.Item[0].Name != "widgets" || len(collection.Item[0].Item) != 2 {
+		t.Fatalf("items = %+v", collection.Item)
+	}
+	if got := collection.Item[0].Item[0].Request.URL.Raw; got != "{{baseUrl}}/widgets/:id?verbose=false" {
+		t.Fatalf("get widget URL = %q", got)
+	}
+	if got := collection.Item[0].Item[1].Request.Body.Raw; !strings.Contains(got, `"name": "gear"`) {
+		t.Fatalf("create widget body = %q", got)
+	}
+	if health := collection.Item[1].Request; health.URL.Raw != "https://status.example.com/health" || health.Auth["type"] != "noauth" {
+		t.Fatalf("health request = %+v, want url_overrides target without auth", health)
+	}
+
+	brunoDir := filepath.Join(dir, "bruno")
+	if err := c.Run([]string{"restish", "api", "export", "widgets", "--format", "bruno", "--out", brunoDir}); err != nil {
+		t.Fatalf("api export bruno: %v", err)
+	}
+	staging, err := os.ReadFile(filepath.Join(brunoDir, "environments", "staging.bru"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(staging), "baseUrl: https://staging.example.com") || strings.Contains(string(staging), "STAGING_TOKEN") {
+		t.Fatalf("staging environment:\n%s", staging)
+	}
+
+	// The Bruno export loads back as a spec for a new API.
+	var got *http.Request
+	useTransport(c, func(r *http.Request) (*http.Response, error) {
+		got = r
+		return jsonResponse(200, `{}`), nil
+	})
+	if err := c.Run([]string{"restish", "api", "connect", "reimported", "https://api.example.com", "--spec", brunoDir}); err != nil {
+		t.Fatalf("api connect: %v", err)
+	}
+	if err := c.Run([]string{"restish", "api", "set", "reimported", "profiles.default.credentials.bearerAuth.auth.params.token: t0ken"}); err != nil {
+		t.Fatalf("api set: %v", err)
+	}
+	if err := c.Run([]string{"restish", "reimported", "get-widget", "w-2"}); err != nil {
+		t.Fatalf("get-widget: %v", err)
+	}
+	if got == nil || got.URL.Path != "/widgets/w-2" || got.Header.Get("Authorization") != "Bearer t0ken" {
+		t.Fatalf("request = %v", got)
 	}
 }
 
@@ -1246,7 +1548,7 @@ func TestAPISyncUpdatesAllowedOperationOriginsAndPreservesProfiles(t *testing.T)
 	if got := api.AllowedOperationOrigins; !reflect.DeepEqual(got, []string{"https://*.do-ai.run"}) {
 		t.Fatalf("allowed_operation_origins = %#v, want DigitalOcean wildcard", got)
 	}
-	if got := api.Profiles["default"].Headers; !reflect.DeepEqual(got, []string{"Authorization: Bearer local-token"}) {
+	if got := []string(api.Profiles["default"].Headers); !reflect.DeepEqual(got, []string{"Authorization: Bearer local-token"}) {
 		t.Fatalf("profile headers = %#v, want local profile preserved", got)
 	}
 	if !strings.Contains(out.String(), "Wrote config: "+cfgFile) || !strings.Contains(out.String(), `Synced spec for "do".`) {
@@ -1357,7 +1659,7 @@ func TestAPISyncPersistsDiscoveredSpecURLAndPreservesProfiles(t *testing.T) {
 	if got := api.SpecURL; got != "https://api.example.com/linked-openapi.json" {
 		t.Fatalf("spec_url = %q, want discovered Link URL", got)
 	}
-	if got := api.Profiles["default"].Headers; !reflect.DeepEqual(got, []string{"X-API-Key: local-secret"}) {
+	if got := []string(api.Profiles["default"].Headers); !reflect.DeepEqual(got, []string{"X-API-Key: local-secret"}) {
 		t.Fatalf("profile headers = %#v, want local profile preserved", got)
 	}
 }
@@ -2084,7 +2386,7 @@ func TestAPISetRootedObjectPatch(t *testing.T) {
 	if err != nil {
 		t.Fatalf("reload config: %v", err)
 	}
-	got := written.APIs["myapi"].Profiles["demo"].Headers
+	got := []string(written.APIs["myapi"].Profiles["demo"].Headers)
 	if !reflect.DeepEqual(got, []string{"X-Debug: true"}) {
 		t.Fatalf("headers = %#v", got)
 	}
@@ -11,6 +11,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"os/exec"
 	"sort"
 	"strings"
 	"time"
@@ -70,6 +71,14 @@ func (c *CLI) authHandlerFor(ac *config.AuthConfig, opts authHandlerOptions) (au
 			return h, nil
 		}
 	}
+	// OAuth handlers cache tokens, and cache writes are best effort, so a
+	// refused key-file layout is reported here instead of silently leaving
+	// tokens uncached.
+	if strings.HasPrefix(ac.Type, "oauth-") && c.tokenCacheEncryption() == "key-file" {
+		if err := checkTokenCacheKeyFile(c.tokenCachePath(), c.tokenCacheKeyFile()); err != nil {
+			return nil, err
+		}
+	}
 	switch ac.Type {
 	case "api-key":
 		return &authpkg.APIKey{}, nil
@@ -77,14 +86,32 @@ func (c *CLI) authHandlerFor(ac *config.AuthConfig, opts authHandlerOptions) (au
 		return &authpkg.Bearer{}, nil
 	case "http-basic":
 		return &authpkg.HTTPBasic{}, nil
+	case "http-signature":
+		return &authpkg.HTTPSignature{}, nil
+	case "hmac":
+		return &authpkg.HMAC{}, nil
+	case "http-digest":
+		return &authpkg.HTTPDigest{}, nil
 	case "oauth-client-credentials":
 		return &authpkg.ClientCredentials{
-			Cache:      auth.NewTokenCache(c.tokenCachePath()),
+			Cache:      c.tokenStore(),
 			HTTPClient: &http.Client{Transport: c.baseHTTPTransport()},
 		}, nil
+	case "oauth-token-exchange":
+		return &authpkg.TokenExchange{
+			Cache:        c.tokenStore(),
+			HTTPClient:   &http.Client{Transport: c.baseHTTPTransport()},
+			ProfileToken: c.profileToken,
+		}, nil
+	case "oauth-jwt-bearer":
+		return &authpkg.JWTBearer{
+			Cache:        c.tokenStore(),
+			HTTPClient:   &http.Client{Transport: c.baseHTTPTransport()},
+			ProfileToken: c.profileToken,
+		}, nil
 	case "oauth-authorization-code":
 		return &authpkg.AuthorizationCode{
-			Cache:                auth.NewTokenCache(c.tokenCachePath()),
+			Cache:                c.tokenStore(),
 			HTTPClient:           &http.Client{Transport: c.baseHTTPTransport()},
 			Stderr:               c.Stderr,
 			CanPrompt:            c.canPromptCode(),
@@ -95,14 +122,14 @@ func (c *CLI) authHandlerFor(ac *config.AuthConfig, opts authHandlerOptions) (au
 		}, nil
 	case "oauth-device-code":
 		return &authpkg.DeviceCode{
-			Cache:      auth.NewTokenCache(c.tokenCachePath()),
+			Cache:      c.tokenStore(),
 			HTTPClient: &http.Client{Transport: c.baseHTTPTransport()},
 			Stderr:     c.Stderr,
 		}, nil
 	case "external-tool":
 		return &authpkg.ExternalTool{Stderr: c.Stderr}, nil
 	default:
-		return nil, fmt.Errorf("unknown auth type %q; supported: api-key, bearer, http-basic, oauth-client-credentials, oauth-authorization-code, oauth-device-code, external-tool", ac.Type)
+		return nil, fmt.Errorf("unknown auth type %q; supported: api-key, bearer, http-basic, http-digest, http-signature, hmac, oauth-client-credentials, oauth-token-exchange, oauth-jwt-bearer, oauth-authorization-code, oauth-device-code, external-tool", ac.Type)
 	}
 }
 
@@ -130,7 +157,7 @@ func (c *CLI) authOnRequest(apiName, profileName string, prof *config.ProfileCon
 			}
 		}
 		callbacks.OnRequest = func(req *http.Request) error {
-			if c.applyCachedOAuthClientCredentials(req, resolvedAuth.Config.Type, resolvedAuth.CacheKey, apiName, profileName, false) {
+			if c.applyCachedOAuthClientGrant(req, resolvedAuth.Config.Type, resolvedAuth.CacheKey, apiName, profileName, false) {
 				return c.runAuthHookPlugins(apiName, profileName, rawParams, secretKeys, req)
 			}
 			params, err := c.buildAuthParams(rawParams)
@@ -146,7 +173,8 @@ func (c *CLI) authOnRequest(apiName, profileName string, prof *config.ProfileCon
 				return err
 			}
 			preserveInsertedHeader := c.apiPreservesHeaderCase(apiName) && !authHeaderPresent(req.Header, resolvedAuth.Config.Type, params)
-			if err := handler.Authenticate(req.Context(), req, c.authContext(req.Context(), apiName, profileName, params, resolvedAuth.CacheKey, false)); err != nil {
+			authCtx, ac := c.authContext(req.Context(), apiName, profileName, params, resolvedAuth.CacheKey, false)
+			if err := handler.Authenticate(authCtx, req, ac); err != nil {
 				return err
 			}
 			if preserveInsertedHeader {
@@ -157,7 +185,7 @@ func (c *CLI) authOnRequest(apiName, profileName string, prof *config.ProfileCon
 		}
 		if _, ok := handler.(auth.ForceCapable); ok {
 			callbacks.OnUnauthorized = func(req *http.Request) error {
-				if c.applyCachedOAuthClientCredentials(req, resolvedAuth.Config.Type, resolvedAuth.CacheKey, apiName, profileName, true) {
+				if c.applyCachedOAuthClientGrant(req, resolvedAuth.Config.Type, resolvedAuth.CacheKey, apiName, profileName, true) {
 					return c.runAuthHookPlugins(apiName, profileName, rawParams, secretKeys, req)
 				}
 				params, err := c.buildAuthParams(rawParams)
@@ -173,7 +201,8 @@ func (c *CLI) authOnRequest(apiName, profileName string, prof *config.ProfileCon
 					return err
 				}
 				preserveInsertedHeader := c.apiPreservesHeaderCase(apiName) && !authHeaderPresent(req.Header, resolvedAuth.Config.Type, params)
-				if err := handler.Authenticate(req.Context(), req, c.authContext(req.Context(), apiName, profileName, params, resolvedAuth.CacheKey, true)); err != nil {
+				authCtx, ac := c.authContext(req.Context(), apiName, profileName, params, resolvedAuth.CacheKey, true)
+				if err := handler.Authenticate(authCtx, req, ac); err != nil {
 					return err
 				}
 				if preserveInsertedHeader {
@@ -244,7 +273,19 @@ func preservedHeaderValue(header http.Header, name string) string {
 }
 
 func markAuthCredentialTargets(req *http.Request, authType string, params map[string]string) {
-	if req == nil || authType != "api-key" {
+	if req == nil {
+		return
+	}
+	if authType == "hmac" {
+		if name := strings.TrimSpace(params["signature_header"]); name != "" {
+			request.MarkCredentialHeader(req, name)
+		}
+		if name := strings.TrimSpace(params["signature_query"]); name != "" {
+			request.MarkCredentialQueryParam(req, name)
+		}
+		return
+	}
+	if authType != "api-key" {
 		return
 	}
 	location := strings.ToLower(strings.TrimSpace(params["in"]))
@@ -259,22 +300,34 @@ func markAuthCredentialTargets(req *http.Request, authType string, params map[st
 	}
 }
 
-func (c *CLI) applyCachedOAuthClientCredentials(req *http.Request, authType string, cacheKey, apiName, profileName string, force bool) bool {
-	if force || authType != "oauth-client-credentials" {
+// isOAuthClientGrant reports whether authType obtains tokens without user
+// interaction, so a cached token can be applied before resolving auth params.
+// Skipping param resolution avoids running secret commands for every request.
+func isOAuthClientGrant(authType string) bool {
+	switch authType {
+	case "oauth-client-credentials", "oauth-token-exchange", "oauth-jwt-bearer":
+		return true
+	}
+	return false
+}
+
+func (c *CLI) applyCachedOAuthClientGrant(req *http.Request, authType string, cacheKey, apiName, profileName string, force bool) bool {
+	if force || !isOAuthClientGrant(authType) {
 		return false
 	}
-	token := c.cachedOAuthToken(authType, cacheKey, apiName, profileName)
-	if token == "" {
+	// DPoP-bound tokens need a fresh proof per request from the handler.
+	cached := c.cachedOAuthTokenEntry(authType, cacheKey, apiName, profileName)
+	if cached == nil || cached.IsExpired() || cached.AccessToken == "" || cached.DPoPKey != "" {
 		return false
 	}
 	if preservedHeaderValue(req.Header, "Authorization") == "" {
-		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Authorization", "Bearer "+cached.AccessToken)
 	}
 	return true
 }
 
-func (c *CLI) cachedOAuthClientCredentialsToken(authType string, cacheKey, apiName, profileName string) string {
-	if authType != "oauth-client-credentials" {
+func (c *CLI) cachedOAuthClientGrantToken(authType string, cacheKey, apiName, profileName string) string {
+	if !isOAuthClientGrant(authType) {
 		return ""
 	}
 	return c.cachedOAuthToken(authType, cacheKey, apiName, profileName)
@@ -290,7 +343,7 @@ func (c *CLI) cachedOAuthToken(authType string, cacheKey, apiName, profileName s
 
 func (c *CLI) cachedOAuthTokenEntry(authType string, cacheKey, apiName, profileName string) *auth.CachedToken {
 	switch authType {
-	case "oauth-authorization-code", "oauth-client-credentials", "oauth-device-code":
+	case "oauth-authorization-code", "oauth-client-credentials", "oauth-device-code", "oauth-token-exchange", "oauth-jwt-bearer":
 	default:
 		return nil
 	}
@@ -300,7 +353,7 @@ func (c *CLI) cachedOAuthTokenEntry(authType string, cacheKey, apiName, profileN
 	if cacheKey == ":" || cacheKey == "" {
 		return nil
 	}
-	tc := auth.NewTokenCache(c.tokenCachePath())
+	tc := c.tokenStore()
 	cached, err := tc.Get(cacheKey)
 	if err != nil {
 		return nil
@@ -396,6 +449,12 @@ func (c *CLI) buildAuthParams(rawParams map[string]string) (map[string]string, e
 func (c *CLI) authParamsReady(rawParams map[string]string) error {
 	var missing []string
 	for k, v := range rawParams {
+		if ref, ok := config.ParseSecretRef(v); ok {
+			if issue := secretRefReadinessIssue(ref); issue != "" {
+				missing = append(missing, k+" ("+issue+")")
+			}
+			continue
+		}
 		if !strings.HasPrefix(v, "env:") {
 			continue
 		}
@@ -419,13 +478,16 @@ func (c *CLI) resolvedAuthParamsReady(resolved resolvedAuthConfig, apiName, prof
 	if resolved.Config == nil {
 		return nil
 	}
-	if c.cachedOAuthClientCredentialsToken(resolved.Config.Type, resolved.CacheKey, apiName, profileName) != "" {
+	if c.cachedOAuthClientGrantToken(resolved.Config.Type, resolved.CacheKey, apiName, profileName) != "" {
 		return nil
 	}
 	return c.authParamsReady(resolved.Config.Params)
 }
 
 func (c *CLI) resolveAuthParam(value string) (string, error) {
+	if ref, ok := config.ParseSecretRef(value); ok {
+		return c.resolveSecretRef(ref)
+	}
 	switch {
 	case strings.HasPrefix(value, "env:"):
 		name := strings.TrimPrefix(value, "env:")
@@ -449,6 +511,14 @@ func (c *CLI) resolveAuthParam(value string) (string, error) {
 }
 
 func (c *CLI) runSecretCommand(commandLine string) (string, error) {
+	return c.runSecretProcess(func(ctx context.Context) *exec.Cmd {
+		return procutil.ShellCommand(ctx, commandLine)
+	})
+}
+
+// runSecretProcess runs a secret-producing command with a timeout and returns
+// its stdout without trailing newlines.
+func (c *CLI) runSecretProcess(build func(context.Context) *exec.Cmd) (string, error) {
 	parent := c.runCtx
 	if parent == nil {
 		parent = context.Background()
@@ -456,7 +526,7 @@ func (c *CLI) runSecretCommand(commandLine string) (string, error) {
 	ctx, cancel := context.WithTimeout(parent, 30*time.Second)
 	defer cancel()
 
-	cmd := procutil.ShellCommand(ctx, commandLine)
+	cmd := build(ctx)
 	procutil.ConfigureCommandTreeKill(ctx, cmd)
 	var stderr bytes.Buffer
 	cmd.Stderr = &limitedWriter{w: &stderr, limit: 4096}
@@ -477,20 +547,51 @@ func (c *CLI) runSecretCommand(commandLine string) (string, error) {
 	return strings.TrimRight(string(out), "\r\n"), nil
 }
 
-func (c *CLI) authContext(ctx context.Context, apiName, profileName string, params map[string]string, cacheKey string, force bool) auth.AuthContext {
-	return auth.AuthContext{
+func (c *CLI) authContext(ctx context.Context, apiName, profileName string, params map[string]string, cacheKey string, force bool) (context.Context, auth.AuthContext) {
+	ctx, httpClient := c.withTokenEndpointIdentity(ctx, apiName, profileName, params, c.authHTTPClient(ctx))
+	return ctx, auth.AuthContext{
 		APIName:     apiName,
 		ProfileName: profileName,
 		BaseURL:     c.authBaseURL(apiName, profileName),
 		CacheKey:    cacheKey,
 		Params:      params,
-		TokenStore:  auth.NewTokenCache(c.tokenCachePath()),
+		TokenStore:  c.tokenStore(),
 		Prompter:    cliPrompter{cli: c, ctx: ctx},
 		Stderr:      c.Stderr,
-		HTTPClient:  c.authHTTPClient(ctx),
+		HTTPClient:  httpClient,
 		Logger:      log.New(c.Stderr, "", 0),
 		Force:       force,
+		Challenges:  authChallengeFromContext(ctx).values,
+		DPoPNonce:   authChallengeFromContext(ctx).dpopNonce,
+	}
+}
+
+type authChallengeContextKey struct{}
+
+type authChallenge struct {
+	values    []string
+	dpopNonce string
+}
+
+// withAuthChallenges records the WWW-Authenticate values and DPoP-Nonce from
+// a 401 so the unauthorized retry can hand them to challenge-driven handlers.
+func withAuthChallenges(ctx context.Context, resp *http.Response) context.Context {
+	if resp == nil {
+		return ctx
+	}
+	challenge := authChallenge{values: resp.Header.Values("WWW-Authenticate"), dpopNonce: resp.Header.Get("DPoP-Nonce")}
+	if len(challenge.values) == 0 && challenge.dpopNonce == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, authChallengeContextKey{}, challenge)
+}
+
+func authChallengeFromContext(ctx context.Context) authChallenge {
+	if ctx == nil {
+		return authChallenge{}
 	}
+	challenge, _ := ctx.Value(authChallengeContextKey{}).(authChallenge)
+	return challenge
 }
 
 func (c *CLI) authBaseURL(apiName, profileName string) string {
@@ -512,7 +613,7 @@ func sharedAuthCacheKey(ref string, ac *config.AuthConfig, baseURL string) strin
 		return "auth_profile:" + ref + ":" + key
 	}
 	relevant := map[string]string{"type": ac.Type}
-	for _, name := range []string{"audience", "authorize_url", "client_id", "device_authorization_url", "issuer_url", "resource", "scopes", "token_url"} {
+	for _, name := range []string{"actor_token_profile", "assertion_profile", "audience", "authorize_url", "client_id", "device_authorization_url", "issuer_url", "oauth2_metadata_url", "requested_token_type", "resource", "scopes", "subject_token_profile", "token_url"} {
 		if value := ac.Params[name]; value != "" {
 			relevant[name] = value
 		}
@@ -567,7 +668,7 @@ func inlineAuthCodeCacheKeyMaterial(ac *config.AuthConfig) (string, bool) {
 	if clientID == "" {
 		return "", false
 	}
-	if !absoluteOAuthCacheKeyAnchor(tokenURL) && !absoluteOAuthCacheKeyAnchor(issuerURL) {
+	if !absoluteOAuthCacheKeyAnchor(tokenURL) && !absoluteOAuthCacheKeyAnchor(issuerURL) && !absoluteOAuthCacheKeyAnchor(ac.Params["oauth2_metadata_url"]) {
 		return "", false
 	}
 	relevant := map[string]string{"type": ac.Type}
@@ -615,7 +716,7 @@ func authConfigUsesRelativeOAuthEndpoint(ac *config.AuthConfig) bool {
 	if ac == nil {
 		return false
 	}
-	for _, name := range []string{"authorize_url", "device_authorization_url", "token_url"} {
+	for _, name := range []string{"authorize_url", "device_authorization_url", "oauth2_metadata_url", "token_url"} {
 		if value := ac.Params[name]; value != "" && isRelativeOAuthEndpointValue(value) {
 			return true
 		}
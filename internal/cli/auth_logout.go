@@ -0,0 +1,145 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/rest-sh/restish/v2/config"
+	authpkg "github.com/rest-sh/restish/v2/internal/auth"
+)
+
+// logoutTarget is one auth config whose cached token `api auth logout`
+// clears, along with the API profile it is used from.
+type logoutTarget struct {
+	apiName     string
+	profileName string
+	label       string
+	resolved    resolvedAuthConfig
+}
+
+// profileLogoutTargets returns the profile auth and the named credentials of
+// one API profile.
+func (c *CLI) profileLogoutTargets(apiName, profileName string, prof *config.ProfileConfig) ([]logoutTarget, error) {
+	if prof == nil {
+		return nil, nil
+	}
+	label := fmt.Sprintf("%q (profile %q)", apiName, profileName)
+	resolved, err := c.resolveProfileAuth(apiName, profileName, prof)
+	if err != nil {
+		return nil, err
+	}
+	targets := []logoutTarget{{apiName: apiName, profileName: profileName, label: label, resolved: resolved}}
+	ids := make([]string, 0, len(prof.Credentials))
+	for id := range prof.Credentials {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		resolved, err := c.resolveCredentialAuth(apiName, profileName, id, prof.Credentials[id])
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, logoutTarget{
+			apiName:     apiName,
+			profileName: profileName,
+			label:       fmt.Sprintf("%q (profile %q, credential %q)", apiName, profileName, id),
+			resolved:    resolved,
+		})
+	}
+	return targets, nil
+}
+
+// apiLogoutTargets returns the logout targets of every profile of an API.
+func (c *CLI) apiLogoutTargets(apiName string, apiCfg *config.APIConfig) ([]logoutTarget, error) {
+	names := make([]string, 0, len(apiCfg.Profiles))
+	for name := range apiCfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var targets []logoutTarget
+	for _, name := range names {
+		profileTargets, err := c.profileLogoutTargets(apiName, name, apiCfg.Profiles[name])
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, profileTargets...)
+	}
+	return targets, nil
+}
+
+// authProfileLogoutTargets returns the API profiles and credentials that use
+// a shared auth profile. Its tokens are only cached through those uses.
+func (c *CLI) authProfileLogoutTargets(ref string) ([]logoutTarget, error) {
+	apiNames := make([]string, 0, len(c.cfg.APIs))
+	for name := range c.cfg.APIs {
+		apiNames = append(apiNames, name)
+	}
+	sort.Strings(apiNames)
+	var targets []logoutTarget
+	for _, apiName := range apiNames {
+		apiTargets, err := c.apiLogoutTargets(apiName, c.cfg.APIs[apiName])
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range apiTargets {
+			if t.resolved.Ref == ref {
+				t.label = fmt.Sprintf("auth profile %q", ref)
+				targets = append(targets, t)
+			}
+		}
+	}
+	return targets, nil
+}
+
+// revokeLogoutTargets revokes the cached OAuth tokens of targets and, with
+// endSession, prints each provider's RP-initiated logout URL. It runs before
+// the cache entries are deleted and reports one line per token. Targets that
+// share a cache entry are handled once.
+func (c *CLI) revokeLogoutTargets(ctx context.Context, targets []logoutTarget, revoke, endSession bool) error {
+	seen := map[string]bool{}
+	failed := 0
+	for _, t := range targets {
+		if t.resolved.Config == nil || seen[t.resolved.CacheKey] {
+			continue
+		}
+		seen[t.resolved.CacheKey] = true
+		cached := c.cachedOAuthTokenEntry(t.resolved.Config.Type, t.resolved.CacheKey, t.apiName, t.profileName)
+		if cached == nil {
+			continue
+		}
+		params, err := c.buildAuthParams(t.resolved.Config.Params)
+		if err != nil {
+			return fmt.Errorf("auth logout: %w", err)
+		}
+		authCtx, ac := c.authContext(ctx, t.apiName, t.profileName, params, t.resolved.CacheKey, false)
+		if revoke {
+			results, err := authpkg.RevokeOAuthTokens(authCtx, ac, *cached)
+			if err != nil {
+				fmt.Fprintf(c.Stdout, "Could not revoke tokens for %s: %v\n", t.label, err)
+				failed++
+			}
+			for _, result := range results {
+				if result.Err != nil {
+					fmt.Fprintf(c.Stdout, "Could not revoke %s for %s: %v\n", result.TokenType, t.label, result.Err)
+					failed++
+					continue
+				}
+				fmt.Fprintf(c.Stdout, "Revoked %s for %s\n", result.TokenType, t.label)
+			}
+		}
+		if endSession {
+			logoutURL, err := authpkg.EndSessionURL(authCtx, ac, *cached)
+			if err != nil {
+				fmt.Fprintf(c.Stdout, "Could not build the provider logout URL for %s: %v\n", t.label, err)
+				failed++
+				continue
+			}
+			fmt.Fprintf(c.Stdout, "Open this URL to end the provider session for %s:\n  %s\n", t.label, logoutURL)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("auth logout: %d server-side logout step(s) failed; the local token cache was cleared", failed)
+	}
+	return nil
+}
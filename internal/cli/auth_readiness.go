@@ -5,6 +5,7 @@ import (
 	"os"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/rest-sh/restish/v2/config"
 	"github.com/rest-sh/restish/v2/internal/spec"
@@ -32,6 +33,12 @@ func (c *CLI) authConfigReadiness(ac *config.AuthConfig) authReadiness {
 	}
 	var issues []string
 	for _, value := range ac.Params {
+		if ref, ok := config.ParseSecretRef(value); ok {
+			if issue := secretRefReadinessIssue(ref); issue != "" {
+				issues = append(issues, issue)
+			}
+			continue
+		}
 		if !strings.HasPrefix(value, "env:") {
 			continue
 		}
@@ -57,10 +64,63 @@ func (c *CLI) resolvedAuthReadiness(apiName, profileName string, resolved resolv
 		!c.cachedOAuthAuthCodeUsable(resolved.Config.Type, resolved.CacheKey, apiName, profileName) {
 		readiness.Usable = false
 		readiness.Issues = append(readiness.Issues, "OAuth access token not cached")
+		return readiness
+	}
+	if issue := c.tokenExpiryReadinessIssue(apiName, profileName, resolved, time.Now()); issue != "" {
+		readiness.Issues = append(readiness.Issues, issue)
 	}
 	return readiness
 }
 
+// tokenExpiryReadinessIssue warns about a token that is expired or close to
+// expiry with no way to renew it mid-request. Such a token can pass a
+// readiness check and still fail on a later page of a paginated request.
+// Client-credentials tokens are re-fetched on demand and refreshable tokens
+// are refreshed, so only static bearer JWTs and non-refreshable cached user
+// tokens are checked.
+func (c *CLI) tokenExpiryReadinessIssue(apiName, profileName string, resolved resolvedAuthConfig, now time.Time) string {
+	switch resolved.Config.Type {
+	case "bearer":
+		token, ok := staticAuthParamValue(resolved.Config.Params["token"])
+		if !ok {
+			return ""
+		}
+		if jwt, ok := decodeJWT(strings.TrimSpace(strings.TrimPrefix(token, "Bearer "))); ok {
+			return jwt.expiryIssue(now)
+		}
+	case "oauth-authorization-code", "oauth-device-code":
+		cached := c.cachedOAuthTokenEntry(resolved.Config.Type, resolved.CacheKey, apiName, profileName)
+		if cached == nil || cached.RefreshToken != "" {
+			return ""
+		}
+		if !cached.Expiry.IsZero() {
+			return tokenExpiryIssue(cached.Expiry, now)
+		}
+		if jwt, ok := decodeJWT(cached.AccessToken); ok {
+			return jwt.expiryIssue(now)
+		}
+	}
+	return ""
+}
+
+// staticAuthParamValue resolves an auth param without running commands or
+// touching the keyring, reporting false when that is not possible.
+func staticAuthParamValue(value string) (string, bool) {
+	if ref, ok := config.ParseSecretRef(value); ok {
+		if ref.Kind() != "$env" {
+			return "", false
+		}
+		return os.LookupEnv(ref.Env)
+	}
+	switch {
+	case strings.HasPrefix(value, "env:"):
+		return os.LookupEnv(strings.TrimPrefix(value, "env:"))
+	case strings.HasPrefix(value, "command:"):
+		return "", false
+	}
+	return value, value != ""
+}
+
 func (c *CLI) credentialReadiness(apiName, profileName, credentialID string, credential *config.CredentialConfig) (resolvedAuthConfig, authReadiness, error) {
 	if credential == nil || (credential.Auth == nil && credential.AuthRef == "") {
 		return resolvedAuthConfig{}, authReadiness{}, nil
@@ -218,8 +278,8 @@ func (c *CLI) operationHasUsableNamedAlternative(apiName, profileName string, pr
 				}
 				continue
 			}
-			credential := prof.Credentials[requirement.ID]
-			resolved, ready, err := c.credentialReadiness(apiName, profileName, requirement.ID, credential)
+			credentialID, credential := requirementCredential(prof, requirement)
+			resolved, ready, err := c.credentialReadiness(apiName, profileName, credentialID, credential)
 			if err != nil || !ready.Usable || resolved.Config == nil {
 				ok = false
 				break
@@ -250,11 +310,13 @@ func profileFallbackObviouslyMatches(requirement spec.CredentialRequirement, ac
 	switch requirement.Kind {
 	case "http-bearer":
 		switch ac.Type {
-		case "bearer", "oauth-client-credentials", "oauth-authorization-code", "oauth-device-code", "external-tool":
+		case "bearer", "oauth-client-credentials", "oauth-token-exchange", "oauth-jwt-bearer", "oauth-authorization-code", "oauth-device-code", "external-tool":
 			return true
 		}
 	case "http-basic":
 		return ac.Type == "http-basic"
+	case "http-digest":
+		return ac.Type == "http-digest"
 	case "api-key":
 		return ac.Type == "api-key"
 	case "oauth2":
@@ -195,6 +195,216 @@ func TestAPIKeyAuthQuery(t *testing.T) {
 	}
 }
 
+// TestHTTPSignatureAuthSignsBody verifies that an http-signature profile adds
+// Content-Digest, Signature-Input, and Signature headers to a request body.
+func TestHTTPSignatureAuthSignsBody(t *testing.T) {
+	var rr requestRecorder
+	c, _, _ := newTestCLI(t)
+	useTransport(c, func(r *http.Request) (*http.Response, error) {
+		rr.capture(r)
+		return jsonResponse(200, `{}`), nil
+	})
+
+	cfg := `{
+		"apis": {
+			"myapi": {
+				"base_url": "https://api.example.com",
+				"profiles": {
+					"default": {
+						"auth": {
+							"type": "http-signature",
+							"params": {"key_id": "partner", "algorithm": "hmac-sha256", "key": "s3cr3t"}
+						}
+					}
+				}
+			}
+		}
+	}`
+	c.Hooks().ConfigPath = writeAPIConfig(t, cfg)
+
+	if err := c.Run([]string{"restish", "post", "myapi/items", "name: widget"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	last := rr.Last()
+	if got := last.Header.Get("Content-Digest"); !strings.HasPrefix(got, "sha-256=:") {
+		t.Errorf("Content-Digest: got %q, want sha-256 digest", got)
+	}
+	input := last.Header.Get("Signature-Input")
+	if !strings.HasPrefix(input, `sig1=("@method" "@target-uri" "content-digest");created=`) || !strings.Contains(input, `keyid="partner"`) {
+		t.Errorf("Signature-Input: got %q", input)
+	}
+	if got := last.Header.Get("Signature"); !strings.HasPrefix(got, "sig1=:") {
+		t.Errorf("Signature: got %q", got)
+	}
+}
+
+// TestHMACAuthVerboseRedactsSignature verifies that hmac auth signs the
+// request and that the configured signature header is redacted in verbose
+// diagnostics.
+func TestHMACAuthVerboseRedactsSignature(t *testing.T) {
+	var rr requestRecorder
+	c, _, errBuf := newTestCLI(t)
+	useTransport(c, func(r *http.Request) (*http.Response, error) {
+		rr.capture(r)
+		return &http.Response{
+			StatusCode: 200,
+			Proto:      "HTTP/1.1",
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       http.NoBody,
+			Request:    r,
+		}, nil
+	})
+
+	cfg := `{
+		"apis": {
+			"myapi": {
+				"base_url": "https://api.example.com",
+				"profiles": {
+					"default": {
+						"auth": {
+							"type": "hmac",
+							"params": {
+								"secret": "s3cr3t",
+								"canonical": "{timestamp}\\n{method}\\n{path}",
+								"signature_header": "X-Vendor-Sign",
+								"timestamp_header": "X-Vendor-Ts"
+							}
+						}
+					}
+				}
+			}
+		}
+	}`
+	c.Hooks().ConfigPath = writeAPIConfig(t, cfg)
+
+	if err := c.Run([]string{"restish", "get", "-v", "myapi/items"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	last := rr.Last()
+	sig := last.Header.Get("X-Vendor-Sign")
+	if len(sig) != 64 {
+		t.Fatalf("X-Vendor-Sign = %q, want hex sha256 HMAC", sig)
+	}
+	if last.Header.Get("X-Vendor-Ts") == "" {
+		t.Fatal("expected X-Vendor-Ts header")
+	}
+	stderr := errBuf.String()
+	if strings.Contains(stderr, sig) {
+		t.Fatalf("verbose output leaked signature:\n%s", stderr)
+	}
+	if !strings.Contains(stderr, "> X-Vendor-Sign: <redacted>") {
+		t.Fatalf("expected redacted signature header, got:\n%s", stderr)
+	}
+}
+
+// TestHTTPDigestAuthAnswersChallengeAndPaginates verifies that http-digest
+// answers the 401 challenge through the unauthorized retry and reuses the
+// nonce with an incrementing count for later pages.
+func TestHTTPDigestAuthAnswersChallengeAndPaginates(t *testing.T) {
+	var authorizations []string
+	c, _, _ := newTestCLI(t)
+	useTransport(c, func(r *http.Request) (*http.Response, error) {
+		authz := r.Header.Get("Authorization")
+		authorizations = append(authorizations, authz)
+		if authz == "" {
+			resp := jsonResponse(401, `{}`)
+			resp.Header.Set("WWW-Authenticate", `Digest realm="api", qop="auth", algorithm=SHA-256, nonce="abc123", opaque="xyz"`)
+			return resp, nil
+		}
+		resp := jsonResponse(200, `[1]`)
+		if r.URL.Query().Get("page") == "" {
+			resp.Header.Set("Link", `<https://api.example.com/items?page=2>; rel="next"`)
+		}
+		return resp, nil
+	})
+
+	cfg := `{
+		"apis": {
+			"myapi": {
+				"base_url": "https://api.example.com",
+				"profiles": {
+					"default": {
+						"auth": {
+							"type": "http-digest",
+							"params": {"username": "alice", "password": "secret"}
+						}
+					}
+				}
+			}
+		}
+	}`
+	c.Hooks().ConfigPath = writeAPIConfig(t, cfg)
+
+	if err := c.Run([]string{"restish", "get", "myapi/items", "-o", "json"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(authorizations) != 3 {
+		t.Fatalf("requests: got %d (%q), want challenge, retry, and page 2", len(authorizations), authorizations)
+	}
+	if authorizations[0] != "" {
+		t.Errorf("first request Authorization: got %q, want none", authorizations[0])
+	}
+	for i, wantNC := range map[int]string{1: "nc=00000001", 2: "nc=00000002"} {
+		got := authorizations[i]
+		if !strings.HasPrefix(got, `Digest username="alice", realm="api"`) || !strings.Contains(got, wantNC) || !strings.Contains(got, `opaque="xyz"`) {
+			t.Errorf("request %d Authorization: got %q, want Digest with %s", i+1, got, wantNC)
+		}
+	}
+}
+
+// TestOAuthDPoPAnswersNonceChallengeWithoutNewToken verifies that a DPoP
+// use_dpop_nonce 401 is retried with a proof carrying the nonce while the
+// cached DPoP-bound token is reused.
+func TestOAuthDPoPAnswersNonceChallengeWithoutNewToken(t *testing.T) {
+	var tokenRequests int
+	var authorizations, proofs []string
+	c, _, _ := newTestCLI(t)
+	useTransport(c, func(r *http.Request) (*http.Response, error) {
+		if r.URL.Host == "dpop-login.example.com" {
+			tokenRequests++
+			if r.Header.Get("DPoP") == "" {
+				t.Error("token request has no DPoP proof")
+			}
+			return jsonResponse(200, `{"access_token":"bound-token","token_type":"DPoP","expires_in":3600}`), nil
+		}
+		authorizations = append(authorizations, r.Header.Get("Authorization"))
+		proofs = append(proofs, r.Header.Get("DPoP"))
+		if len(authorizations) == 1 {
+			resp := jsonResponse(401, `{}`)
+			resp.Header.Set("WWW-Authenticate", `DPoP error="use_dpop_nonce", algs="ES256"`)
+			resp.Header.Set("DPoP-Nonce", "server-nonce")
+			return resp, nil
+		}
+		return jsonResponse(200, `{}`), nil
+	})
+	c.Hooks().ConfigPath = writeAPIConfig(t, `{
+		"apis": {
+			"bound": {
+				"base_url": "https://dpop-resource.example.com",
+				"profiles": {"default": {"auth": {"type": "oauth-client-credentials", "params": {"client_id": "id", "client_secret": "secret", "dpop": "true", "token_url": "https://dpop-login.example.com/token"}}}}
+			}
+		}
+	}`)
+
+	if err := c.Run([]string{"restish", "get", "bound/items"}); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if tokenRequests != 1 || len(authorizations) != 2 {
+		t.Fatalf("token requests = %d, API requests = %q", tokenRequests, authorizations)
+	}
+	for i, authz := range authorizations {
+		if authz != "DPoP bound-token" || proofs[i] == "" {
+			t.Fatalf("request %d Authorization = %q, DPoP = %q", i+1, authz, proofs[i])
+		}
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.Split(proofs[1], ".")[1])
+	if err != nil || !strings.Contains(string(payload), `"nonce":"server-nonce"`) {
+		t.Fatalf("retry proof payload = %s, err = %v", payload, err)
+	}
+}
+
 func TestAPIKeyAuthVerboseRedactsSecret(t *testing.T) {
 	c, _, errBuf := newTestCLI(t)
 	useTransport(c, func(r *http.Request) (*http.Response, error) {
@@ -303,7 +513,7 @@ func TestUnknownAuthTypeListsSupportedValues(t *testing.T) {
 	if err == nil {
 		t.Fatal("expected unknown auth type error")
 	}
-	for _, want := range []string{"api-key", "http-basic", "oauth-client-credentials", "oauth-authorization-code", "oauth-device-code", "external-tool"} {
+	for _, want := range []string{"api-key", "http-basic", "http-digest", "http-signature", "hmac", "oauth-client-credentials", "oauth-authorization-code", "oauth-device-code", "external-tool"} {
 		if !strings.Contains(err.Error(), want) {
 			t.Fatalf("expected supported auth type %q in error, got %v", want, err)
 		}
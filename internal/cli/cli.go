@@ -81,6 +81,10 @@ type testHooks struct {
 	AuthHookFunc func(apiName, profileName string, rawParams map[string]string, secretKeys map[string]bool, req *http.Request) error
 	// StdoutIsTerminal overrides terminal detection in tests.
 	StdoutIsTerminal func(io.Writer) bool
+	// KeyringFunc overrides OS keyring lookups for $keyring secret references.
+	KeyringFunc func(service, account string) (string, error)
+	// KeyringStoreFunc overrides OS keyring writes for token cache keys.
+	KeyringStoreFunc func(service, account, value string) error
 }
 
 func (c *CLI) stdoutIsTerminal() bool {
@@ -131,6 +135,9 @@ type CLI struct {
 	commandSurface          CommandSurface
 	runCtx                  context.Context
 	projectConfig           *projectConfigState
+	tokenCache              *auth.TokenCache
+	tokenCacheSig           string
+	tokenEndpointIdentities map[discoveryTransportShareKey]*tokenEndpointIdentity
 }
 
 // New returns a CLI wired to the real OS stdin/stdout/stderr.
@@ -241,8 +248,9 @@ func (c *CLI) flushStdout() error {
 
 // AddAuthHandler registers a custom auth handler under the given type name.
 // The name is used in the profile's auth.type config field.
-// Built-in names (http-basic, oauth-client-credentials,
-// oauth-authorization-code, oauth-device-code, external-tool) can be overridden.
+// Built-in names (http-basic, http-digest, http-signature, oauth-client-credentials,
+// oauth-token-exchange, oauth-jwt-bearer, oauth-authorization-code,
+// oauth-device-code, external-tool) can be overridden.
 // Call this before CLI.Run.
 //
 // Use the restish.AuthHandler / restish.AuthParam aliases on the embedded API
@@ -520,12 +528,14 @@ func (c *CLI) discoverSpecForProfile(ctx context.Context, apiName, profileName s
 		BaseURL:          effectiveProfileBaseURL(api, profileName),
 		SpecURL:          api.SpecURL,
 		SpecFiles:        api.SpecFiles,
+		OverlayFiles:     api.OverlayFiles,
 		CacheDir:         c.specCacheDir(),
 		OperationBase:    effectiveOperationBase(api, profileName),
 		ServerVariables:  effectiveServerVariables(api, profileName),
 		Version:          Version,
 		Transport:        transport,
 		Fetch:            fetch,
+		Post:             c.discoveryPoster(apiName, api, profileName),
 		AllowCrossOrigin: api.AllowCrossOriginSpec,
 		ForceRefresh:     forceRefresh,
 		Timeout:          timeout,
@@ -550,6 +560,7 @@ func (c *CLI) Run(args []string) error {
 	defer cancel()
 	c.runCtx = ctx
 	defer func() { c.runCtx = nil }()
+	defer c.closeTokenEndpointIdentities()
 
 	argScan := scanCLIArgs(args)
 	c.retryUnsafeWarned = false
@@ -1183,9 +1194,9 @@ func commandPathExists(cmd *cobra.Command, args []string) bool {
 // configured APIs.
 var builtinCommands = map[string]bool{
 	"api": true, "cache": true, "cert": true, "completion": true, "config": true,
-	"delete": true, "doctor": true, "edit": true, "get": true, "head": true,
+	"delete": true, "doctor": true, "edit": true, "get": true, "graphql": true, "head": true,
 	"help": true, "links": true, "options": true, "patch": true, "plugin": true,
-	"post": true, "put": true, "shell": true, "version": true,
+	"post": true, "put": true, "run": true, "shell": true, "version": true, "workflow": true, "ws": true,
 }
 
 // isBuiltinCommandName reports whether name collides with a top-level built-in
@@ -1271,7 +1282,7 @@ var boolLikeLongFlags = map[string]bool{
 	"rsh-silent": true, "rsh-headers": true,
 	"rsh-verbose": true, "rsh-insecure": true, "rsh-ignore-status-code": true,
 	"rsh-no-cache": true, "rsh-no-browser": true, "rsh-no-paginate": true,
-	"rsh-collect": true,
+	"rsh-collect": true, "rsh-reconnect": true,
 }
 
 var boolLikeShortFlags = map[rune]bool{
@@ -8,6 +8,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/rest-sh/restish/v2/config"
 	"github.com/rest-sh/restish/v2/internal/filter"
 	"github.com/rest-sh/restish/v2/internal/output"
 	"github.com/rest-sh/restish/v2/internal/spec"
@@ -488,7 +489,14 @@ func (c *CLI) handlePluginConfigRead(writer *commandPluginWriter, msg pluginwire
 			if prof.BaseURL != "" {
 				baseURL = prof.BaseURL
 			}
-			reply.Headers = prof.Headers
+			// Headers whose values are secret references are left out
+			// like auth secrets; the encoded reference is not the value
+			// and names where the secret comes from.
+			for _, header := range prof.Headers {
+				if _, _, ok := config.SplitSecretRefHeader(header); !ok {
+					reply.Headers = append(reply.Headers, header)
+				}
+			}
 			reply.Query = prof.Query
 		}
 	}
@@ -399,6 +399,32 @@ paths: {}`), 0o644); err != nil {
 	}
 }
 
+func TestHandlePluginConfigReadOmitsSecretRefHeaders(t *testing.T) {
+	c := New()
+	c.cfg = &config.Config{APIs: map[string]*config.APIConfig{
+		"svc": {
+			BaseURL: "https://api.example.com",
+			Profiles: map[string]*config.ProfileConfig{"default": {Headers: config.HeaderList{
+				"X-Tenant: acme",
+				"X-Token: " + config.SecretRef{Cmd: []string{"op", "read", "op://vault/api/token"}}.Encode(),
+			}}},
+		},
+	}}
+
+	var buf bytes.Buffer
+	msg := pluginwire.ConfigReadMsg{API: "svc", Profile: "default"}
+	if err := c.handlePluginConfigRead(&commandPluginWriter{w: &buf}, msg); err != nil {
+		t.Fatalf("handlePluginConfigRead: %v", err)
+	}
+	var reply pluginwire.ConfigReadResponseMsg
+	if err := pluginwire.ReadMessage(&buf, &reply); err != nil {
+		t.Fatalf("decode ConfigRead reply: %v", err)
+	}
+	if reply.Error != "" || strings.Join(reply.Headers, "\n") != "X-Tenant: acme" {
+		t.Fatalf("reply = %+v", reply)
+	}
+}
+
 func TestPluginOperationsFromSpecUsesFallbackOperationName(t *testing.T) {
 	ops := pluginOperationsFromSpec([]spec.Operation{{
 		Method: "GET",
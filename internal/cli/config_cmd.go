@@ -331,7 +331,10 @@ func redactSensitiveConfigValue(v any) {
 	case map[string]any:
 		if _, hasType := data["type"].(string); hasType {
 			if params, ok := data["params"].(map[string]any); ok {
-				for key := range params {
+				for key, value := range params {
+					if isSecretRefView(value) {
+						continue
+					}
 					if isSensitiveConfigKey(key) || key == "value" {
 						params[key] = "***"
 					}
@@ -343,6 +346,9 @@ func redactSensitiveConfigValue(v any) {
 				data[key] = redacted
 				continue
 			}
+			if isSecretRefView(value) {
+				continue
+			}
 			if isSensitiveConfigKey(key) {
 				data[key] = "***"
 				continue
@@ -356,6 +362,20 @@ func redactSensitiveConfigValue(v any) {
 	}
 }
 
+// isSecretRefView reports whether a decoded config value is a secret
+// reference object such as {"$env": "NAME"}. References name a source, not a
+// secret, so config show prints them as-is.
+func isSecretRefView(v any) bool {
+	obj, ok := v.(map[string]any)
+	if !ok || len(obj) != 1 {
+		return false
+	}
+	for key := range obj {
+		return strings.HasPrefix(key, "$")
+	}
+	return false
+}
+
 func redactSensitiveConfigStringList(key string, value any) ([]any, bool) {
 	items, ok := value.([]any)
 	if !ok {
@@ -421,6 +441,9 @@ func profileCredentialSettingSources(prof *config.ProfileConfig) []string {
 
 func persistentHeadersContainCredentials(headers []string) bool {
 	for _, header := range headers {
+		if _, _, ok := config.SplitSecretRefHeader(header); ok {
+			return true
+		}
 		name, _, ok := strings.Cut(header, ":")
 		if ok && secrets.IsHeaderName(strings.TrimSpace(name)) {
 			return true
@@ -13,6 +13,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/rest-sh/restish/v2/auth"
 	"github.com/rest-sh/restish/v2/config"
 	"github.com/rest-sh/restish/v2/internal/cache"
 	internalplugin "github.com/rest-sh/restish/v2/internal/plugin"
@@ -78,6 +79,14 @@ type doctorPermissionReport struct {
 	Remediation string `json:"remediation,omitempty"`
 }
 
+type doctorTokenCacheEncryption struct {
+	Backend     string `json:"backend"`
+	File        string `json:"file,omitempty"`
+	Status      string `json:"status"`
+	Error       string `json:"error,omitempty"`
+	Remediation string `json:"remediation,omitempty"`
+}
+
 type doctorShellSetupReport struct {
 	Status string `json:"status"`
 	Shell  string `json:"shell,omitempty"`
@@ -139,6 +148,7 @@ type doctorRootReport struct {
 	SpecCache             string                        `json:"spec_cache"`
 	TokenCache            string                        `json:"token_cache"`
 	TokenCachePermissions doctorPermissionReport        `json:"token_cache_permissions"`
+	TokenCacheEncryption  doctorTokenCacheEncryption    `json:"token_cache_encryption"`
 	Theme                 doctorThemeReport             `json:"theme"`
 	APIs                  doctorAPIInventoryReport      `json:"apis"`
 	PluginDirectory       string                        `json:"plugin_directory"`
@@ -164,10 +174,11 @@ type doctorGeneratedOperationsReport struct {
 }
 
 type doctorAuthReport struct {
-	Status  string   `json:"status"`
-	Sources []string `json:"sources,omitempty"`
-	Issues  []string `json:"issues,omitempty"`
-	Hint    string   `json:"hint,omitempty"`
+	Status        string   `json:"status"`
+	Sources       []string `json:"sources,omitempty"`
+	SecretSources []string `json:"secret_sources,omitempty"`
+	Issues        []string `json:"issues,omitempty"`
+	Hint          string   `json:"hint,omitempty"`
 }
 
 type doctorReachabilityReport struct {
@@ -187,6 +198,7 @@ type doctorAPIReport struct {
 	BaseURL             string                          `json:"base_url,omitempty"`
 	SpecURL             string                          `json:"spec_url,omitempty"`
 	SpecFiles           []string                        `json:"spec_files,omitempty"`
+	OverlayFiles        []string                        `json:"overlay_files,omitempty"`
 	SpecCache           doctorStatusReport              `json:"spec_cache"`
 	GeneratedOperations doctorGeneratedOperationsReport `json:"generated_operations"`
 	OpenAPIXCLI         *spec.XCLIExtensionReport       `json:"openapi_x_cli_extensions,omitempty"`
@@ -278,6 +290,16 @@ func (c *CLI) runDoctor(cmd *cobra.Command, args []string) error {
 	} else {
 		fmt.Fprintf(out, "Token cache permissions: %s\n", style.ok("ok"))
 	}
+	switch enc := c.doctorTokenCacheEncryptionReport(); enc.Status {
+	case "ok":
+		fmt.Fprintf(out, "Token cache encryption: %s\n", style.ok(enc.Backend))
+	case "plaintext":
+		fmt.Fprintf(out, "Token cache encryption: %s (%s)\n", style.warn("none"), style.hint(enc.Remediation))
+	case "migrate":
+		fmt.Fprintf(out, "Token cache encryption: %s (%s)\n", style.ok(enc.Backend), style.hint(enc.Remediation))
+	default:
+		fmt.Fprintf(out, "Token cache encryption: %s (%s; %s)\n", style.error(enc.Backend), enc.Error, style.hint(enc.Remediation))
+	}
 	fmt.Fprintf(out, "Plugin directory: %s\n", c.pluginDir())
 	c.printInstalledPlugins(out, style)
 	fmt.Fprintf(out, "Content types: %s\n", strings.Join(c.doctorContentTypeNames(), ", "))
@@ -321,6 +343,9 @@ func (c *CLI) runDoctorAPI(cmd *cobra.Command, args []string) error {
 	if len(api.SpecFiles) > 0 {
 		fmt.Fprintf(out, "Spec files: %v\n", api.SpecFiles)
 	}
+	if len(api.OverlayFiles) > 0 {
+		fmt.Fprintf(out, "Overlay files: %v\n", api.OverlayFiles)
+	}
 	profileName := c.profileFromCmd(cmd)
 	opInfo := c.doctorOperationSetStatus(requestContext(cmd), name, api, profileName)
 	if _, ok := configFileExists(filepath.Join(c.specCacheDir(), c.apiStateName(name)+".cbor")); ok {
@@ -347,7 +372,8 @@ func (c *CLI) runDoctorAPI(cmd *cobra.Command, args []string) error {
 	} else {
 		fmt.Fprintf(out, "Generated operations: %s (%s)\n", style.warn("unavailable"), style.hint("run \"restish api sync "+name+"\""))
 	}
-	if auth := c.doctorAuthForProfile(name, profileName, profileForName(api, profileName)); auth.Status == "configured" {
+	auth := c.doctorAuthForProfile(name, profileName, profileForName(api, profileName))
+	if auth.Status == "configured" {
 		if len(auth.Sources) > 0 {
 			fmt.Fprintf(out, "Auth: %s (%s)\n", style.ok("configured"), strings.Join(auth.Sources, ", "))
 		} else {
@@ -362,6 +388,9 @@ func (c *CLI) runDoctorAPI(cmd *cobra.Command, args []string) error {
 	} else {
 		fmt.Fprintf(out, "Auth: %s\n", style.warn("no profile auth configured"))
 	}
+	if len(auth.SecretSources) > 0 {
+		fmt.Fprintf(out, "Secret sources: %s\n", strings.Join(auth.SecretSources, ", "))
+	}
 	fmt.Fprintf(out, "Auth details: restish api auth inspect %s\n", name)
 	checkNetwork, _ := cmd.Flags().GetBool("check-network")
 	if checkNetwork {
@@ -462,6 +491,7 @@ func (c *CLI) doctorRootReport() doctorRootReport {
 		SpecCache:             c.specCacheDir(),
 		TokenCache:            c.tokenCachePath(),
 		TokenCachePermissions: doctorFilePermissionReport(c.tokenCachePath(), "run chmod 600 "+c.tokenCachePath()+" before the next OAuth request"),
+		TokenCacheEncryption:  c.doctorTokenCacheEncryptionReport(),
 		Theme:                 c.doctorThemeReport(),
 		APIs:                  c.doctorAPIInventoryReport(),
 		PluginDirectory:       c.pluginDir(),
@@ -714,6 +744,49 @@ func doctorFilePermissionReport(path, remediation string) doctorPermissionReport
 	return doctorPermissionReport{Status: "ok"}
 }
 
+// doctorTokenCacheEncryptionReport compares token_cache.encryption with how the
+// cache file is actually stored. It never unlocks the cache.
+func (c *CLI) doctorTokenCacheEncryptionReport() doctorTokenCacheEncryption {
+	backend := c.tokenCacheEncryption()
+	report := doctorTokenCacheEncryption{Backend: backend}
+	if backend == "" {
+		report.Backend = "none"
+	}
+	file, err := auth.TokenCacheFileBackend(c.tokenCachePath())
+	report.File = file
+	var keyFileErr error
+	if backend == "key-file" {
+		keyFileErr = checkTokenCacheKeyFile(c.tokenCachePath(), c.tokenCacheKeyFile())
+	}
+	switch {
+	case err != nil:
+		report.Status = "error"
+		report.Error = err.Error()
+		report.Remediation = "remove the token cache and log in again"
+	case backend == "" && file != "" && file != "plaintext":
+		report.Status = "error"
+		report.Error = "cache file is encrypted with " + file
+		report.Remediation = "set token_cache.encryption to " + file
+	case keyFileErr != nil:
+		report.Status = "error"
+		report.Error = keyFileErr.Error()
+		report.Remediation = "set token_cache.key_file to a path outside " + filepath.Dir(c.tokenCachePath())
+	case backend == "":
+		report.Status = "plaintext"
+		report.Remediation = "set token_cache.encryption to keyring, passphrase, or key-file to encrypt cached tokens"
+	case file == "plaintext":
+		report.Status = "migrate"
+		report.Remediation = "plaintext cache will be encrypted on next use"
+	case file != "" && file != backend:
+		report.Status = "error"
+		report.Error = "cache file is encrypted with " + file
+		report.Remediation = "set token_cache.encryption back to " + file + " or remove the token cache"
+	default:
+		report.Status = "ok"
+	}
+	return report
+}
+
 func doctorShellSetupReportValue() doctorShellSetupReport {
 	shell, source := detectRunningShell()
 	if shell == "" {
@@ -753,6 +826,7 @@ func (c *CLI) doctorAPIReport(cmd *cobra.Command, name string) doctorAPIReport {
 	report.BaseURL = api.BaseURL
 	report.SpecURL = api.SpecURL
 	report.SpecFiles = append([]string(nil), api.SpecFiles...)
+	report.OverlayFiles = append([]string(nil), api.OverlayFiles...)
 	profileName := c.profileFromCmd(cmd)
 	opInfo := c.doctorOperationSetStatus(requestContext(cmd), name, api, profileName)
 	if _, ok := configFileExists(filepath.Join(c.specCacheDir(), c.apiStateName(name)+".cbor")); ok {
@@ -835,11 +909,21 @@ func (c *CLI) doctorAuthForProfile(apiName, profileName string, prof *config.Pro
 	if len(sources) == 0 {
 		return doctorAuthReport{Status: "none"}
 	}
+	var headerIssues []string
+	for _, header := range prof.Headers {
+		if _, ref, ok := config.SplitSecretRefHeader(header); ok {
+			if issue := secretRefReadinessIssue(ref); issue != "" {
+				headerIssues = append(headerIssues, issue)
+			}
+		}
+	}
+	readiness = append(readiness, authReadiness{Issues: headerIssues})
+	secretSources := c.profileSecretSources(apiName, profileName, prof)
 	issues := authReadinessIssues(readiness...)
 	if len(issues) > 0 {
-		return doctorAuthReport{Status: "configured-but-unresolved", Sources: sources, Issues: issues}
+		return doctorAuthReport{Status: "configured-but-unresolved", Sources: sources, SecretSources: secretSources, Issues: issues}
 	}
-	return doctorAuthReport{Status: "configured", Sources: sources}
+	return doctorAuthReport{Status: "configured", Sources: sources, SecretSources: secretSources}
 }
 
 func (c *CLI) doctorPluginReport(name string) doctorPluginReport {
@@ -962,7 +1046,7 @@ func (c *CLI) checkAPIReachability(ctx context.Context, apiName, baseURL string,
 	if discoveryAuthAllowed(authOrigins, normalizedBaseURL) {
 		opts = authOpts
 	}
-	resp, err := c.doDiscoveryRequest(ctx, http.MethodHead, normalizedBaseURL, opts)
+	resp, err := c.doDiscoveryRequest(ctx, http.MethodHead, normalizedBaseURL, nil, opts)
 	if err != nil {
 		return doctorReachabilityReport{Status: "failed", Checked: true, Method: http.MethodHead, Error: secrets.RedactDiagnosticURLText(err.Error())}
 	}
@@ -19,7 +19,11 @@ import (
 // retrieve it via globalFlagsFromContext.
 type GlobalFlags struct {
 	Headers          []string
-	Query            []string
+	Query            []string // includes the JSON:API helper flags below
+	JSONAPIInclude   []string // --rsh-include
+	JSONAPIFields    []string // --rsh-fields
+	JSONAPIFilters   []string // --rsh-jsonapi-filter
+	Normalize        string
 	Server           string
 	OutputFormat     string
 	OutputFormatSet  bool
@@ -53,6 +57,7 @@ type GlobalFlags struct {
 	RetryMaxWaitSet  bool
 	NoPaginate       bool
 	Collect          bool
+	Reconnect        bool
 	MaxPages         int
 	MaxItems         int
 	MaxBodySize      int
@@ -70,6 +75,9 @@ func parseGlobalFlags(cmd *cobra.Command) (GlobalFlags, error) {
 	gf.Headers, _ = cmd.Flags().GetStringArray("rsh-header")
 	gf.Query, _ = cmd.Flags().GetStringArray("rsh-query")
 	gf.TLSSignerParams, _ = cmd.Flags().GetStringArray("rsh-tls-signer-param")
+	gf.JSONAPIInclude, _ = cmd.Flags().GetStringArray("rsh-include")
+	gf.JSONAPIFields, _ = cmd.Flags().GetStringArray("rsh-fields")
+	gf.JSONAPIFilters, _ = cmd.Flags().GetStringArray("rsh-jsonapi-filter")
 
 	// String flags
 	gf.Server, _ = cmd.Flags().GetString("rsh-server")
@@ -82,6 +90,7 @@ func parseGlobalFlags(cmd *cobra.Command) (GlobalFlags, error) {
 	gf.ContentType, _ = cmd.Flags().GetString("rsh-content-type")
 	gf.Filter, _ = cmd.Flags().GetString("rsh-filter")
 	gf.FilterLang, _ = cmd.Flags().GetString("rsh-filter-lang")
+	gf.Normalize, _ = cmd.Flags().GetString("rsh-normalize")
 	gf.ClientCert, _ = cmd.Flags().GetString("rsh-client-cert")
 	gf.ClientKey, _ = cmd.Flags().GetString("rsh-client-key")
 	gf.TLSSigner, _ = cmd.Flags().GetString("rsh-tls-signer")
@@ -102,6 +111,7 @@ func parseGlobalFlags(cmd *cobra.Command) (GlobalFlags, error) {
 	gf.RetryUnsafe, _ = cmd.Flags().GetBool("rsh-retry-unsafe")
 	gf.NoPaginate, _ = cmd.Flags().GetBool("rsh-no-paginate")
 	gf.Collect, _ = cmd.Flags().GetBool("rsh-collect")
+	gf.Reconnect, _ = cmd.Flags().GetBool("rsh-reconnect")
 
 	// Count flag
 	gf.Verbose, _ = cmd.Flags().GetCount("rsh-verbose")
@@ -141,6 +151,11 @@ func parseGlobalFlags(cmd *cobra.Command) (GlobalFlags, error) {
 		}
 		gf.Query = append(query, gf.Query...)
 	}
+	jsonAPIQuery, err := jsonAPIQuery(gf.JSONAPIInclude, gf.JSONAPIFields, gf.JSONAPIFilters)
+	if err != nil {
+		return gf, err
+	}
+	gf.Query = append(gf.Query, jsonAPIQuery...)
 	if v := os.Getenv("RSH_OUTPUT_FORMAT"); v != "" && !cmd.Flags().Changed("rsh-output-format") {
 		gf.OutputFormat = v
 		gf.OutputFormatSet = true
@@ -205,6 +220,9 @@ func parseGlobalFlags(cmd *cobra.Command) (GlobalFlags, error) {
 	if err := validateFilterLangFlag(cmd, gf); err != nil {
 		return gf, err
 	}
+	if err := validateNormalizeFlag(gf.Normalize); err != nil {
+		return gf, err
+	}
 	if err := validateTLSMinVersionFlag(cmd, gf); err != nil {
 		return gf, err
 	}
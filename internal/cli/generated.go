@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"mime"
@@ -19,6 +20,8 @@ import (
 
 	"github.com/rest-sh/restish/v2/config"
 	openapiparam "github.com/rest-sh/restish/v2/internal/openapi"
+	"github.com/rest-sh/restish/v2/internal/output"
+	"github.com/rest-sh/restish/v2/internal/request"
 	"github.com/rest-sh/restish/v2/internal/spec"
 )
 
@@ -438,7 +441,9 @@ func (c *CLI) buildOperationCommand(apiName, examplePrefix string, op spec.Opera
 	for _, p := range required {
 		use += " <" + p.flagName + ">"
 	}
-	if op.HasBody {
+	if op.WebSocket {
+		use += " [message...]"
+	} else if op.HasBody {
 		if op.BodyRequired {
 			use += " <body...>"
 		} else {
@@ -471,6 +476,7 @@ func (c *CLI) buildOperationCommand(apiName, examplePrefix string, op spec.Opera
 		long += argDocs.String()
 	}
 	long = appendGeneratedOperationHelp(long, required, optional, op.Help)
+	long = appendOperationLinksHelp(long, op.Links)
 
 	cmd := &cobra.Command{
 		Use:        use,
@@ -489,9 +495,19 @@ func (c *CLI) buildOperationCommand(apiName, examplePrefix string, op spec.Opera
 				gf := globalFlagsFromContext(requestContext(cmd))
 				return c.printGeneratedBodyExample(op.Help, gf.ContentType)
 			}
+			if op.GraphQL != nil {
+				return c.runGeneratedGraphQLOp(cmd, apiName, op, required, optional, args)
+			}
+			if op.WebSocket {
+				return c.runGeneratedWebSocketOp(cmd, apiName, op, required, optional, args)
+			}
+			follow, err := operationFollowFromFlags(cmd, apiName, op)
+			if err != nil {
+				return err
+			}
 			acceptOverride := c.generatedOperationAcceptHeader(op.ResponseMediaTypes, op.ResponseMediaType)
 			rawBinaryBody := op.Help.Request != nil && op.Help.Request.RawBinary
-			return c.runGeneratedOp(cmd, apiName, op.Path, op.OperationServer, op.Method, op.RequestMediaType, acceptOverride, op.RequestMultipartContentTypes, op.Help, op.BodyRequired, rawBinaryBody, op.NoAuth, op.OptionalAuth, op.CredentialAlternatives, required, optional, args)
+			return c.runGeneratedOp(cmd, apiName, op.Path, op.OperationServer, op.Method, op.RequestMediaType, acceptOverride, op.RequestMultipartContentTypes, op.Help, op.RPC, follow, op.BodyRequired, rawBinaryBody, op.NoAuth, op.OptionalAuth, op.CredentialAlternatives, required, optional, args)
 		},
 	}
 	if candidates := authOverrideCandidates(op.OptionalAuth, op.CredentialAlternatives); len(candidates) > 0 {
@@ -512,13 +528,23 @@ func (c *CLI) buildOperationCommand(apiName, examplePrefix string, op spec.Opera
 	}
 	cmd.Flags().Bool("help-all", false, "Show all inherited Restish flags in help")
 	cmd.SetUsageTemplate(generatedOperationUsageTemplate)
-	if !op.HasBody {
+	if op.WebSocket {
+		// Arguments after the required parameters are messages to send.
+		cmd.Args = generatedOperationArgs(required, true)
+		addWebSocketFlags(cmd)
+		if op.HasBody {
+			cmd.Flags().Bool("rsh-validate", false, "Validate each JSON message against the OpenAPI request schema before sending")
+		}
+	} else if !op.HasBody {
 		cmd.Args = generatedOperationArgs(required, false)
 	} else {
 		cmd.Args = generatedOperationArgs(required, true)
 		cmd.Flags().Bool("rsh-generate-body", false, "Print an example request body and exit")
 		cmd.Flags().Bool("rsh-validate", false, "Validate the JSON request body against the OpenAPI schema before sending")
 	}
+	if !op.WebSocket && op.GraphQL == nil {
+		addOperationFollowFlag(cmd, op.Links)
+	}
 
 	for _, p := range optional {
 		desc := generatedParamDescription(p)
@@ -1377,6 +1403,8 @@ func (c *CLI) runGeneratedOp(
 	apiName, opPath, operationServer, method, requestMediaType, responseMediaType string,
 	requestMultipartContentTypes map[string]string,
 	help spec.OperationHelp,
+	rpc *spec.RPCOperation,
+	follow *operationFollow,
 	bodyRequired bool,
 	rawBinaryBody bool,
 	noAuth bool,
@@ -1449,35 +1477,26 @@ func (c *CLI) runGeneratedOp(
 		}
 	}
 
-	// Build the raw URL. When operation_base is set, resolve its absolute path
-	// against base_url using v1 semantics so generated operations can escape a
-	// base URL sub-path.
-	var rawURL string
-	baseURL, operationBase := c.generatedOperationBase(cmd, apiName)
-	if operationServer != "" {
-		apiCfg, err := c.requireAPI(apiName)
-		if err != nil {
-			return err
-		}
-		rawURL = strings.TrimRight(operationServer, "/") + path
-		_, rewritten, err := config.ApplyURLOverrides(rawURL, effectiveURLOverrides(apiCfg, c.profileFromCmd(cmd)))
-		if err != nil {
-			return fmt.Errorf("url_overrides: %w", err)
+	rawURL, err := c.operationURL(cmd, apiName, operationServer, path, query)
+	if err != nil {
+		return err
+	}
+
+	var rpcReq *rpcRequest
+	if rpc != nil {
+		rpcBase, _ := c.generatedOperationBase(cmd, apiName)
+		if operationServer != "" {
+			rpcBase = operationServer
 		}
-		if !rewritten && !config.OperationOriginAllowed(operationServer, apiCfg.AllowedOperationOrigins) {
-			return fmt.Errorf("operation server %s is outside API base_url and is not allowed; add allowed_operation_origins[]: %s", operationServerOrigin(operationServer), suggestedOperationOrigin(operationServer))
+		var protocol string
+		if c.cfg != nil && c.cfg.APIs[apiName] != nil {
+			protocol = c.cfg.APIs[apiName].RPCProtocol
 		}
-	} else if operationBase != "" {
-		resolvedBase, err := config.ResolveOperationBaseURL(baseURL, operationBase)
-		if err != nil {
-			return fmt.Errorf("operation_base: %w", err)
+		var err error
+		if rpcReq, err = newRPCRequest(rpc, protocol, rpcBase); err != nil {
+			return err
 		}
-		rawURL = strings.TrimRight(resolvedBase, "/") + path
-	} else {
-		rawURL = apiName + path
-	}
-	if qs := encodeGeneratedQuery(query); qs != "" {
-		rawURL += "?" + qs
+		extraHeaders = append(extraHeaders, rpcReq.headers()...)
 	}
 
 	bodyArgs := args[bodyArgStart:]
@@ -1507,6 +1526,8 @@ func (c *CLI) runGeneratedOp(
 		bodyRequired:              bodyRequired,
 		rawBinaryBody:             rawBinaryBody,
 		explicitAPIName:           apiName,
+		rpc:                       rpcReq,
+		follow:                    follow,
 		operationAuth: &operationAuthPolicy{
 			OptionalAuth:           optionalAuth,
 			NoAuth:                 noAuth,
@@ -1633,6 +1654,170 @@ func generatedScalarTypeLabel(typ string) string {
 	}
 }
 
+// operationURL builds the request URL for an operation path whose parameters
+// are already substituted. When operation_base is set, its absolute path is
+// resolved against base_url using v1 semantics so generated operations can
+// escape a base URL sub-path.
+func (c *CLI) operationURL(cmd *cobra.Command, apiName, operationServer, path string, query []generatedQueryParam) (string, error) {
+	var rawURL string
+	baseURL, operationBase := c.generatedOperationBase(cmd, apiName)
+	if operationServer != "" {
+		apiCfg, err := c.requireAPI(apiName)
+		if err != nil {
+			return "", err
+		}
+		rawURL = strings.TrimRight(operationServer, "/") + path
+		_, rewritten, err := config.ApplyURLOverrides(rawURL, effectiveURLOverrides(apiCfg, c.profileFromCmd(cmd)))
+		if err != nil {
+			return "", fmt.Errorf("url_overrides: %w", err)
+		}
+		if !rewritten && !config.OperationOriginAllowed(operationServer, apiCfg.AllowedOperationOrigins) {
+			return "", fmt.Errorf("operation server %s is outside API base_url and is not allowed; add allowed_operation_origins[]: %s", operationServerOrigin(operationServer), suggestedOperationOrigin(operationServer))
+		}
+	} else if operationBase != "" {
+		resolvedBase, err := config.ResolveOperationBaseURL(baseURL, operationBase)
+		if err != nil {
+			return "", fmt.Errorf("operation_base: %w", err)
+		}
+		rawURL = strings.TrimRight(resolvedBase, "/") + path
+	} else {
+		rawURL = apiName + path
+	}
+	if qs := encodeGeneratedQuery(query); qs != "" {
+		rawURL += "?" + qs
+	}
+	return rawURL, nil
+}
+
+// operationArg is a parameter value for sendOperation. in places a
+// parameter the operation does not declare; it defaults to the query string.
+type operationArg struct {
+	name  string
+	in    string
+	value any
+}
+
+// sendOperation sends one request for op with parameter values given by name
+// rather than as command-line arguments, for callers such as workflows and
+// link following. It uses the same URL, Accept, auth, and response handling
+// as generated commands and returns the normalized response. prepared is
+// returned once the request was built, even when sending fails.
+func (c *CLI) sendOperation(ctx context.Context, cmd *cobra.Command, apiName, profile string, opts request.Options, authOpts authHandlerOptions, op spec.Operation, args []operationArg, body any, contentType string) (*output.Response, *preparedRequest, error) {
+	rawURL, headers, err := c.operationRequestURL(cmd, apiName, op, args)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	opts = cloneRequestOptions(opts)
+	opts.AcceptHeader = c.generatedOperationAcceptHeader(op.ResponseMediaTypes, op.ResponseMediaType)
+	if contentType != "" {
+		opts.ContentType = contentType
+	} else if opts.ContentType == "" && body != nil {
+		opts.ContentType = op.RequestMediaType
+	}
+	gf := globalFlagsFromContext(ctx)
+	prepared, err := c.prepareRequest(ctx, op.Method, rawURL, profile, opts, body, headers, op.NoAuth, authOpts, &operationAuthPolicy{
+		OptionalAuth:           op.OptionalAuth,
+		NoAuth:                 op.NoAuth,
+		CredentialAlternatives: op.CredentialAlternatives,
+		Override:               gf.Auth,
+	}, false, apiName)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer c.closePreparedTransport(prepared)
+	c.warnRetryUnsafe(op.Method, prepared.opts)
+	httpResp, err := c.sendPreparedRequest(ctx, op.Method, prepared)
+	if err != nil {
+		if isLocalRequestExecutionError(err) {
+			return nil, prepared, err
+		}
+		return nil, prepared, fmt.Errorf("network error for %s %s: %w", op.Method, redactedNetworkErrorURL(prepared.rawURL, prepared.opts.Server), err)
+	}
+	resp, err := c.normalizeHTTPResponse(httpResp, maxBodyBytes(cmd))
+	if err != nil {
+		return nil, prepared, responseBodyReadError(op.Method, prepared.rawURL, err)
+	}
+	c.normalizeResponseBody(resp, prepared)
+	if gf.Verbose >= 1 {
+		c.logVerboseResponseBody(resp)
+	}
+	return resp, prepared, nil
+}
+
+// operationRequestURL serializes args into op's URL and header parameters.
+// Every path parameter must be given.
+func (c *CLI) operationRequestURL(cmd *cobra.Command, apiName string, op spec.Operation, args []operationArg) (string, []string, error) {
+	path := op.Path
+	var query []generatedQueryParam
+	var headers []string
+	for _, arg := range args {
+		p := operationParamInfo(op, arg.name, arg.in)
+		values, err := operationParamValues(arg.value)
+		if err != nil {
+			return "", nil, fmt.Errorf("parameter %s: %w", arg.name, err)
+		}
+		path, query, headers, err = addGeneratedParam(path, query, headers, p, values)
+		if err != nil {
+			return "", nil, fmt.Errorf("parameter %s: %w", arg.name, err)
+		}
+	}
+	if missing := extractPathParamNames(path); len(missing) > 0 {
+		return "", nil, fmt.Errorf("operation %q needs path parameter %s", operationDisplayID(op), strings.Join(missing, ", "))
+	}
+	rawURL, err := c.operationURL(cmd, apiName, op.OperationServer, path, query)
+	if err != nil {
+		return "", nil, err
+	}
+	return rawURL, headers, nil
+}
+
+// operationParamInfo describes a parameter for serialization, using the
+// operation's declaration when it has one. Undeclared parameters use in,
+// defaulting to the query string.
+func operationParamInfo(op spec.Operation, name, in string) *paramInfo {
+	for _, p := range op.Parameters {
+		if p.Name == name && (in == "" || p.In == in) {
+			return &paramInfo{
+				name:             p.Name,
+				in:               p.In,
+				typ:              p.Type,
+				itemType:         p.ItemType,
+				style:            p.Style,
+				explode:          p.Explode,
+				allowReserved:    p.AllowReserved,
+				contentMediaType: p.ContentMediaType,
+			}
+		}
+	}
+	if in == "" {
+		in = "query"
+	}
+	return &paramInfo{name: name, in: in}
+}
+
+// operationParamValues converts a decoded parameter value to the string
+// values generated commands serialize.
+func operationParamValues(value any) ([]string, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			values = append(values, fmt.Sprint(item))
+		}
+		return values, nil
+	case map[string]any:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		return []string{string(data)}, nil
+	}
+	return []string{fmt.Sprint(value)}, nil
+}
+
 func (c *CLI) generatedOperationBase(cmd *cobra.Command, apiName string) (string, string) {
 	if c == nil || c.cfg == nil || c.cfg.APIs == nil || c.cfg.APIs[apiName] == nil {
 		return "", ""
@@ -0,0 +1,392 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/danielgtaylor/shorthand/v2"
+	"github.com/rest-sh/restish/v2/config"
+	"github.com/rest-sh/restish/v2/internal/output"
+	"github.com/rest-sh/restish/v2/internal/request"
+	"github.com/rest-sh/restish/v2/internal/spec"
+	"github.com/spf13/cobra"
+)
+
+// graphQLAccept prefers the GraphQL-over-HTTP response media type and falls
+// back to plain JSON for older servers.
+const graphQLAccept = "application/graphql-response+json, application/json;q=0.9"
+
+// graphQLRequest is one GraphQL-over-HTTP request. It is kept alongside the
+// encoded body so cursor pagination can resend it with a new $after value.
+type graphQLRequest struct {
+	Query         string
+	Variables     map[string]any
+	OperationName string
+}
+
+var graphQLAfterVariable = regexp.MustCompile(`\$after\s*:`)
+
+// body returns the JSON request body, replacing the after variable when
+// after is non-empty.
+func (r *graphQLRequest) body(after string) map[string]any {
+	body := map[string]any{"query": r.Query}
+	variables := r.Variables
+	if after != "" {
+		variables = make(map[string]any, len(r.Variables)+1)
+		for name, value := range r.Variables {
+			variables[name] = value
+		}
+		variables["after"] = after
+	}
+	if len(variables) > 0 {
+		body["variables"] = variables
+	}
+	if r.OperationName != "" {
+		body["operationName"] = r.OperationName
+	}
+	return body
+}
+
+// paginates reports whether the document declares the $after variable that
+// Relay cursor pagination sets.
+func (r *graphQLRequest) paginates() bool {
+	return graphQLAfterVariable.MatchString(r.Query)
+}
+
+// addGraphQLCommand registers the "graphql" subcommand on root.
+func (c *CLI) addGraphQLCommand(root *cobra.Command) {
+	name := c.commandNameOrDefault()
+	cmd := &cobra.Command{
+		Use:     "graphql <url> [query]",
+		Short:   "Send a GraphQL query or mutation",
+		Long:    graphQLLong,
+		GroupID: rootGroupHTTP,
+		Example: fmt.Sprintf(`  %s graphql example 'query { viewer { login } }'
+  %s graphql example @issues.graphql --var 'owner=rest-sh' --var 'first=50'
+  %s graphql https://api.example.com/graphql --introspect`, name, name, name),
+		Annotations: map[string]string{
+			requestHelpAnnotation: "true",
+		},
+		Args:              usageRangeArgs(1, 2),
+		ValidArgsFunction: c.completeHTTPURL(http.MethodPost),
+		RunE:              c.runGraphQLCmd,
+	}
+	cmd.Flags().StringArray("var", nil, `GraphQL variable in "name=value" format; the value is parsed as shorthand (repeatable)`)
+	cmd.Flags().String("operation-name", "", "Operation to run when the document defines several")
+	cmd.Flags().Bool("introspect", false, "Send the schema introspection query")
+	root.AddCommand(cmd)
+}
+
+func (c *CLI) runGraphQLCmd(cmd *cobra.Command, args []string) error {
+	introspect, _ := cmd.Flags().GetBool("introspect")
+	operationName, _ := cmd.Flags().GetString("operation-name")
+	var query string
+	switch {
+	case introspect && len(args) > 1:
+		return newUsageError(fmt.Errorf("--introspect does not take a query argument"))
+	case introspect:
+		query = spec.GraphQLIntrospectionQuery
+		operationName = "IntrospectionQuery"
+	case len(args) > 1:
+		query = args[1]
+		if path, ok := strings.CutPrefix(query, "@"); ok {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("reading GraphQL query: %w", err)
+			}
+			query = string(data)
+		}
+	case !output.IsTerminalReader(c.Stdin):
+		data, err := io.ReadAll(io.LimitReader(c.Stdin, maxBodyBytes(cmd)+1))
+		if err != nil {
+			return fmt.Errorf("reading GraphQL query from stdin: %w", err)
+		}
+		if int64(len(data)) > maxBodyBytes(cmd) {
+			return fmt.Errorf("GraphQL query on stdin exceeds %d bytes", maxBodyBytes(cmd))
+		}
+		query = string(data)
+	}
+	if strings.TrimSpace(query) == "" {
+		return newUsageError(fmt.Errorf("missing GraphQL query; pass it as an argument, as @file, or on stdin"))
+	}
+
+	rawVars, _ := cmd.Flags().GetStringArray("var")
+	variables := map[string]any{}
+	for _, raw := range rawVars {
+		name, value, ok := strings.Cut(raw, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return newUsageError(fmt.Errorf(`--var %q must be in "name=value" format`, raw))
+		}
+		parsed, err := shorthand.Unmarshal(value, shorthand.ParseOptions{EnableFileInput: true, EnableObjectDetection: true}, nil)
+		if err != nil {
+			return fmt.Errorf("--var %s: %w", name, err)
+		}
+		variables[name] = parsed
+	}
+	return c.runGraphQL(cmd, args[0], &graphQLRequest{Query: query, Variables: variables, OperationName: operationName}, "", nil)
+}
+
+// runGraphQL posts req to target through the normal request pipeline, so
+// profile auth, output formatting, and filters apply as for any request.
+func (c *CLI) runGraphQL(cmd *cobra.Command, target string, req *graphQLRequest, apiName string, auth *operationAuthPolicy) error {
+	return c.runHTTPWithOptions(cmd, http.MethodPost, []string{target}, false, nil, false, "", "application/json", requestBodyOptions{
+		acceptOverride:  graphQLAccept,
+		bodyOverrideSet: true,
+		bodyOverride:    req.body(""),
+		explicitAPIName: apiName,
+		operationAuth:   auth,
+		graphQL:         req,
+	})
+}
+
+// runGeneratedGraphQLOp sends the document of an operation converted from
+// an introspection result. Arguments and flags become variables, converted
+// to the JSON type their GraphQL type expects. Requests go to the API base
+// URL, which is the GraphQL endpoint, or to operation_base when set.
+func (c *CLI) runGeneratedGraphQLOp(cmd *cobra.Command, apiName string, op spec.Operation, required, optional []*paramInfo, args []string) error {
+	gql := op.GraphQL
+	variables := map[string]any{}
+	for i, p := range required {
+		if err := validateGeneratedParamValues(p, args[i:i+1], "argument "+p.flagName); err != nil {
+			return err
+		}
+		value, err := graphQLVariableValue(gql.Variables[p.name], args[i:i+1])
+		if err != nil {
+			return fmt.Errorf("argument %s: %w", p.flagName, err)
+		}
+		variables[p.name] = value
+	}
+	for _, p := range optional {
+		if !cmd.Flags().Changed(p.flagName) {
+			continue
+		}
+		values, err := generatedFlagValues(cmd, p)
+		if err != nil {
+			return err
+		}
+		if err := validateGeneratedParamValues(p, values, "--"+p.flagName); err != nil {
+			return err
+		}
+		value, err := graphQLVariableValue(gql.Variables[p.name], values)
+		if err != nil {
+			return fmt.Errorf("--%s: %w", p.flagName, err)
+		}
+		variables[p.name] = value
+	}
+
+	target := apiName
+	if baseURL, operationBase := c.generatedOperationBase(cmd, apiName); operationBase != "" {
+		resolved, err := config.ResolveOperationBaseURL(baseURL, operationBase)
+		if err != nil {
+			return fmt.Errorf("operation_base: %w", err)
+		}
+		target = resolved
+	}
+	gf := globalFlagsFromContext(requestContext(cmd))
+	return c.runGraphQL(cmd, target, &graphQLRequest{Query: gql.Document, Variables: variables}, apiName, &operationAuthPolicy{
+		OptionalAuth:           op.OptionalAuth,
+		NoAuth:                 op.NoAuth,
+		CredentialAlternatives: op.CredentialAlternatives,
+		Override:               gf.Auth,
+	})
+}
+
+// graphQLVariableValue converts command-line values to the JSON value a
+// variable of GraphQL type typ expects. Values starting with { or [ are
+// parsed as shorthand so input objects and lists can be passed inline.
+func graphQLVariableValue(typ string, values []string) (any, error) {
+	typ = strings.TrimSuffix(strings.TrimSpace(typ), "!")
+	if inner, ok := strings.CutPrefix(typ, "["); ok {
+		inner = strings.TrimSuffix(inner, "]")
+		if len(values) == 1 && strings.HasPrefix(strings.TrimSpace(values[0]), "[") {
+			return graphQLShorthandValue(values[0])
+		}
+		list := make([]any, 0, len(values))
+		for _, value := range values {
+			item, err := graphQLVariableValue(inner, []string{value})
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, item)
+		}
+		return list, nil
+	}
+	if len(values) == 0 {
+		return nil, nil
+	}
+	value := values[0]
+	switch typ {
+	case "Int":
+		return strconv.ParseInt(value, 10, 64)
+	case "Float":
+		return strconv.ParseFloat(value, 64)
+	case "Boolean":
+		return strconv.ParseBool(value)
+	case "String", "ID":
+		return value, nil
+	}
+	if trimmed := strings.TrimSpace(value); strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+		return graphQLShorthandValue(value)
+	}
+	return value, nil
+}
+
+func graphQLShorthandValue(value string) (any, error) {
+	parsed, err := shorthand.Unmarshal(value, shorthand.ParseOptions{EnableFileInput: true, EnableObjectDetection: true}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("parse GraphQL input: %w", err)
+	}
+	return parsed, nil
+}
+
+// graphQLErrorMessages returns a readable line for each entry of a GraphQL
+// response's errors array.
+func graphQLErrorMessages(body any) []string {
+	doc, ok := body.(map[string]any)
+	if !ok {
+		return nil
+	}
+	errs, _ := doc["errors"].([]any)
+	messages := make([]string, 0, len(errs))
+	for _, entry := range errs {
+		e, ok := entry.(map[string]any)
+		if !ok {
+			messages = append(messages, fmt.Sprint(entry))
+			continue
+		}
+		message := fmt.Sprint(e["message"])
+		if path, ok := e["path"].([]any); ok && len(path) > 0 {
+			parts := make([]string, 0, len(path))
+			for _, part := range path {
+				parts = append(parts, fmt.Sprint(part))
+			}
+			message += " (at " + strings.Join(parts, ".") + ")"
+		}
+		messages = append(messages, message)
+	}
+	return messages
+}
+
+// graphQLErrorsError turns a non-empty errors array into exit code 1 after
+// the response has been printed. GraphQL servers report most failures with
+// HTTP 200, so the status alone does not reveal them.
+func (c *CLI) graphQLErrorsError(cmd *cobra.Command, body any) error {
+	if globalFlagsFromContext(requestContext(cmd)).IgnoreStatus {
+		return nil
+	}
+	messages := graphQLErrorMessages(body)
+	if len(messages) == 0 {
+		return nil
+	}
+	for _, message := range messages {
+		c.warnf("GraphQL error: %s", message)
+	}
+	return &ExitCodeError{Code: 1}
+}
+
+// graphQLRawErrorsError is graphQLErrorsError for raw output, where the body
+// was written without decoding.
+func (c *CLI) graphQLRawErrorsError(cmd *cobra.Command, raw []byte) error {
+	var body any
+	if json.Unmarshal(raw, &body) != nil {
+		return nil
+	}
+	return c.graphQLErrorsError(cmd, body)
+}
+
+// graphQLConnection is a Relay connection found in a response: an object
+// with pageInfo plus a nodes or edges list.
+type graphQLConnection struct {
+	path    []string
+	items   string
+	cursor  string
+	hasNext bool
+}
+
+func (conn graphQLConnection) itemsPath() string {
+	return strings.Join(append(append([]string(nil), conn.path...), conn.items), ".")
+}
+
+// findGraphQLConnections walks the objects under data, without descending
+// into lists, and returns every connection it finds.
+func findGraphQLConnections(body any) []graphQLConnection {
+	doc, ok := body.(map[string]any)
+	if !ok {
+		return nil
+	}
+	var found []graphQLConnection
+	var walk func(value any, path []string)
+	walk = func(value any, path []string) {
+		m, ok := value.(map[string]any)
+		if !ok {
+			return
+		}
+		if pageInfo, ok := m["pageInfo"].(map[string]any); ok {
+			items := ""
+			if _, ok := m["nodes"].([]any); ok {
+				items = "nodes"
+			} else if _, ok := m["edges"].([]any); ok {
+				items = "edges"
+			}
+			if items != "" {
+				cursor, _ := pageInfo["endCursor"].(string)
+				hasNext, _ := pageInfo["hasNextPage"].(bool)
+				found = append(found, graphQLConnection{path: path, items: items, cursor: cursor, hasNext: hasNext})
+				return
+			}
+		}
+		keys := make([]string, 0, len(m))
+		for key := range m {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			walk(m[key], append(append([]string(nil), path...), key))
+		}
+	}
+	walk(doc["data"], []string{"data"})
+	return found
+}
+
+// nextGraphQLCursor returns the end cursor of the connection at itemsPath
+// when it has another page.
+func nextGraphQLCursor(body any, itemsPath string) string {
+	for _, conn := range findGraphQLConnections(body) {
+		if conn.itemsPath() == itemsPath && conn.hasNext {
+			return conn.cursor
+		}
+	}
+	return ""
+}
+
+// tryPaginateGraphQL follows Relay cursors when the document declares
+// $after and the response has exactly one connection with another page.
+// Items are merged at that connection's nodes or edges, like items_path.
+func (c *CLI) tryPaginateGraphQL(cmd *cobra.Command, firstResp *output.Response, firstURL string, opts request.Options, prepared *preparedRequest, req *graphQLRequest) (bool, error) {
+	gf := globalFlagsFromContext(requestContext(cmd))
+	if gf.NoPaginate || !req.paginates() || output.StatusToExitCode(firstResp.Status) != 0 || len(graphQLErrorMessages(firstResp.Body)) > 0 {
+		return false, nil
+	}
+	var next []graphQLConnection
+	for _, conn := range findGraphQLConnections(firstResp.Body) {
+		if conn.hasNext && conn.cursor != "" {
+			next = append(next, conn)
+		}
+	}
+	if len(next) != 1 {
+		if len(next) > 1 {
+			c.warnf("GraphQL response has %d connections with more pages; not following cursors", len(next))
+		}
+		return false, nil
+	}
+	pagCfg := &config.PaginationConfig{ItemsPath: next[0].itemsPath()}
+	return true, c.runPagination(cmd, firstResp, effectiveFirstURL(prepared, firstURL), next[0].cursor, opts, pagCfg, gf.Collect, gf.MaxPages, gf.MaxItems, prepared, paginationNextGraphQLCursor, req)
+}
@@ -0,0 +1,162 @@
+package cli_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/rest-sh/restish/v2/internal/cli"
+)
+
+const graphQLTestIntrospection = `{"data":{"__schema":{
+  "queryType":{"name":"Query"},"mutationType":null,"subscriptionType":null,
+  "types":[
+    {"kind":"OBJECT","name":"Query","fields":[
+      {"name":"users","description":"List users.","args":[
+        {"name":"first","type":{"kind":"SCALAR","name":"Int"}},
+        {"name":"after","type":{"kind":"SCALAR","name":"String"}}],
+       "type":{"kind":"NON_NULL","ofType":{"kind":"OBJECT","name":"UserConnection"}}}]},
+    {"kind":"OBJECT","name":"UserConnection","fields":[
+      {"name":"pageInfo","args":[],"type":{"kind":"NON_NULL","ofType":{"kind":"OBJECT","name":"PageInfo"}}},
+      {"name":"nodes","args":[],"type":{"kind":"LIST","ofType":{"kind":"OBJECT","name":"User"}}}]},
+    {"kind":"OBJECT","name":"PageInfo","fields":[
+      {"name":"hasNextPage","args":[],"type":{"kind":"NON_NULL","ofType":{"kind":"SCALAR","name":"Boolean"}}},
+      {"name":"endCursor","args":[],"type":{"kind":"SCALAR","name":"String"}}]},
+    {"kind":"OBJECT","name":"User","fields":[
+      {"name":"id","args":[],"type":{"kind":"NON_NULL","ofType":{"kind":"SCALAR","name":"ID"}}},
+      {"name":"login","args":[],"type":{"kind":"SCALAR","name":"String"}}]},
+    {"kind":"SCALAR","name":"Int"},{"kind":"SCALAR","name":"String"},
+    {"kind":"SCALAR","name":"ID"},{"kind":"SCALAR","name":"Boolean"}]}}}`
+
+type graphQLTestRequest struct {
+	Query     string         `json:"query"`
+	Variables map[string]any `json:"variables"`
+}
+
+func newGraphQLTestCLI(t *testing.T) (*cli.CLI, *bytes.Buffer, *bytes.Buffer) {
+	t.Helper()
+	c, stdout, stderr, _ := newSpecFileTestCLI(t, "gh", "https://api.example.com/graphql", "schema.json", graphQLTestIntrospection, `{"headers":["X-Tenant: acme"]}`)
+	return c, stdout, stderr
+}
+
+func decodeGraphQLTestRequest(t *testing.T, req *http.Request) graphQLTestRequest {
+	t.Helper()
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded graphQLTestRequest
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		t.Fatalf("request body is not JSON: %v\n%s", err, body)
+	}
+	return decoded
+}
+
+func TestGraphQLCommandSendsQueryAndTypedVariables(t *testing.T) {
+	c, stdout, _ := newGraphQLTestCLI(t)
+	var got graphQLTestRequest
+	useTransport(c, func(req *http.Request) (*http.Response, error) {
+		if req.Method != http.MethodPost || req.URL.String() != "https://api.example.com/graphql" {
+			t.Fatalf("request = %s %s", req.Method, req.URL)
+		}
+		if ct := req.Header.Get("Content-Type"); ct != "application/json" {
+			t.Fatalf("Content-Type = %q", ct)
+		}
+		if tenant := req.Header.Get("X-Tenant"); tenant != "acme" {
+			t.Fatalf("X-Tenant = %q, want profile header", tenant)
+		}
+		got = decodeGraphQLTestRequest(t, req)
+		return jsonResponse(http.StatusOK, `{"data":{"users":{"nodes":[{"login":"ada"}]}}}`), nil
+	})
+
+	query := `query($first: Int, $filter: Filter) { users(first: $first, filter: $filter) { nodes { login } } }`
+	if err := c.Run([]string{"restish", "graphql", "gh", query, "--var", "first=2", "--var", "filter={state: OPEN}", "-o", "json"}); err != nil {
+		t.Fatalf("graphql: %v", err)
+	}
+	if got.Query != query {
+		t.Fatalf("query = %q", got.Query)
+	}
+	if got.Variables["first"] != float64(2) {
+		t.Fatalf("first = %#v, want number 2", got.Variables["first"])
+	}
+	if filter, ok := got.Variables["filter"].(map[string]any); !ok || filter["state"] != "OPEN" {
+		t.Fatalf("filter = %#v", got.Variables["filter"])
+	}
+	if !strings.Contains(stdout.String(), "ada") {
+		t.Fatalf("stdout = %s", stdout.String())
+	}
+}
+
+func TestGraphQLCommandErrorsExitNonZero(t *testing.T) {
+	c, stdout, stderr := newGraphQLTestCLI(t)
+	useTransport(c, func(req *http.Request) (*http.Response, error) {
+		return jsonResponse(http.StatusOK, `{"data":{"users":null},"errors":[{"message":"rate limited","path":["users"]}]}`), nil
+	})
+
+	err := c.Run([]string{"restish", "graphql", "gh", "{ users { nodes { id } } }", "-o", "json"})
+	var exitErr *cli.ExitCodeError
+	if !errors.As(err, &exitErr) || exitErr.Code != 1 {
+		t.Fatalf("expected ExitCodeError{1}, got %v", err)
+	}
+	if !strings.Contains(stderr.String(), "GraphQL error: rate limited (at users)") {
+		t.Fatalf("stderr = %s", stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "rate limited") {
+		t.Fatalf("response body should still be printed, got %s", stdout.String())
+	}
+}
+
+func TestGraphQLCommandFollowsConnectionCursor(t *testing.T) {
+	c, stdout, _ := newGraphQLTestCLI(t)
+	var afters []any
+	useTransport(c, func(req *http.Request) (*http.Response, error) {
+		got := decodeGraphQLTestRequest(t, req)
+		afters = append(afters, got.Variables["after"])
+		if got.Variables["after"] == "c1" {
+			return jsonResponse(http.StatusOK, `{"data":{"users":{"pageInfo":{"hasNextPage":false,"endCursor":"c2"},"nodes":[{"id":"u2"}]}}}`), nil
+		}
+		return jsonResponse(http.StatusOK, `{"data":{"users":{"pageInfo":{"hasNextPage":true,"endCursor":"c1"},"nodes":[{"id":"u1"}]}}}`), nil
+	})
+
+	query := `query($after: String) { users(first: 1, after: $after) { pageInfo { hasNextPage endCursor } nodes { id } } }`
+	if err := c.Run([]string{"restish", "graphql", "gh", query, "-o", "json"}); err != nil {
+		t.Fatalf("graphql: %v", err)
+	}
+	if len(afters) != 2 || afters[0] != nil || afters[1] != "c1" {
+		t.Fatalf("after variables = %#v", afters)
+	}
+	for _, id := range []string{"u1", "u2"} {
+		if !strings.Contains(stdout.String(), id) {
+			t.Fatalf("stdout missing %s:\n%s", id, stdout.String())
+		}
+	}
+}
+
+func TestGraphQLGeneratedOperationPostsDocument(t *testing.T) {
+	c, stdout, _ := newGraphQLTestCLI(t)
+	var got graphQLTestRequest
+	useTransport(c, func(req *http.Request) (*http.Response, error) {
+		if req.Method != http.MethodPost || req.URL.String() != "https://api.example.com/graphql" {
+			t.Fatalf("request = %s %s", req.Method, req.URL)
+		}
+		got = decodeGraphQLTestRequest(t, req)
+		return jsonResponse(http.StatusOK, `{"data":{"users":{"pageInfo":{"hasNextPage":false},"nodes":[{"id":"u1","login":"ada"}]}}}`), nil
+	})
+
+	if err := c.Run([]string{"restish", "gh", "users", "--first", "5", "-o", "json"}); err != nil {
+		t.Fatalf("users: %v", err)
+	}
+	if !strings.HasPrefix(got.Query, "query users(") || !strings.Contains(got.Query, "nodes {") {
+		t.Fatalf("query = %q", got.Query)
+	}
+	if got.Variables["first"] != float64(5) {
+		t.Fatalf("variables = %#v", got.Variables)
+	}
+	if !strings.Contains(stdout.String(), "ada") {
+		t.Fatalf("stdout = %s", stdout.String())
+	}
+}
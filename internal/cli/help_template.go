@@ -38,6 +38,9 @@ var flagGroupOrder = []string{
 var defaultFlagGroups = map[string]string{
 	"rsh-header":             flagGroupRequest,
 	"rsh-query":              flagGroupRequest,
+	"rsh-include":            flagGroupRequest,
+	"rsh-fields":             flagGroupRequest,
+	"rsh-jsonapi-filter":     flagGroupRequest,
 	"rsh-server":             flagGroupRequest,
 	"rsh-content-type":       flagGroupRequest,
 	"rsh-timeout":            flagGroupRequest,
@@ -48,6 +51,7 @@ var defaultFlagGroups = map[string]string{
 	"rsh-print":         flagGroupOutput,
 	"rsh-filter":        flagGroupOutput,
 	"rsh-filter-lang":   flagGroupOutput,
+	"rsh-normalize":     flagGroupOutput,
 	"rsh-headers":       flagGroupOutput,
 	"rsh-status":        flagGroupOutput,
 	"rsh-columns":       flagGroupOutput,
@@ -70,6 +74,7 @@ var defaultFlagGroups = map[string]string{
 	"rsh-collect":     flagGroupPaging,
 	"rsh-max-pages":   flagGroupPaging,
 	"rsh-max-items":   flagGroupPaging,
+	"rsh-reconnect":   flagGroupPaging,
 
 	"rsh-no-cache":       flagGroupCache,
 	"rsh-retry":          flagGroupCache,
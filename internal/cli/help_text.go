@@ -21,13 +21,15 @@ const apiRemoveLong = "Remove a registered API from the local config.\n\n" +
 
 const apiSyncLong = "Force re-fetch of the cached OpenAPI spec for a named API.\n\n" +
 	"Use this after the API publishes new operations, updates parameter schemas, moves the discovered spec URL, or adds operation servers that generated commands should know about. Sync refreshes spec-derived API metadata, but preserves local profiles because they may contain credentials.\n\n" +
-	"By default, sync follows the same-origin spec source already recorded for the API. Use `--allow-cross-origin-spec` only when you trust a `Link` header or saved spec source that points to an OpenAPI document on another host. Private, loopback, link-local, and unspecified follow targets are still rejected unless the original API is already private/local. Use `api set` to update `spec_url`, or reconnect with `--spec`, when you need to name a private spec URL directly."
+	"By default, sync follows the same-origin spec source already recorded for the API. Use `--allow-cross-origin-spec` only when you trust a `Link` header or saved spec source that points to an OpenAPI document on another host. Private, loopback, link-local, and unspecified follow targets are still rejected unless the original API is already private/local. Use `api set` to update `spec_url`, or reconnect with `--spec`, when you need to name a private spec URL directly.\n\n" +
+	"Use `--overlay` with a single API to replace its saved `overlay_files` with the given OpenAPI Overlay documents before syncing."
 
 const apiConnectLong = "Connect Restish to an API, discover its OpenAPI description, and save a named API profile.\n\n" +
 	"Use this when repeated work against an API deserves generated commands, shell completion, auth setup, and profile-aware defaults.\n\n" +
 	"Common choices:\n\n" +
-	"- Use `--spec` when discovery is blocked, the API does not advertise its spec, or you want to pin setup to a known OpenAPI URL or local file.\n" +
+	"- Use `--spec` when discovery is blocked, the API does not advertise its spec, or you want to pin setup to a known OpenAPI URL or local file. `--spec` also accepts a Postman or Insomnia collection export, a Bruno collection directory, an AsyncAPI document, a protobuf descriptor set for Connect or Twirp services, or OData v4 `$metadata` XML, converted to OpenAPI.\n" +
 	"- Use `--allow-cross-origin-spec` only when you trust a `Link` header that points to an OpenAPI document on another host. Private, loopback, link-local, and unspecified follow targets are still rejected unless the original API is already private/local; use `--spec` when you need to name a private spec URL directly.\n" +
+	"- Use `--overlay` to patch the spec locally with OpenAPI Overlay documents, such as adding `x-cli-*` extensions to a vendor spec. Repeat it to apply several overlays in order; they are saved as `overlay_files`.\n" +
 	"- Use `--no-discover` to save a base URL without fetching a spec.\n" +
 	"- Use `--replace` when reconnecting should replace existing profiles with generated OpenAPI or `x-cli-config` profile defaults. Without it, existing profiles are preserved, while API-level discovery fields are refreshed from the new connect run.\n" +
 	"- Use `--yes` only for safe connect prompts you have already decided to accept in automation."
@@ -39,6 +41,14 @@ const apiSetLong = "Patch one registered API using Restish shorthand syntax.\n\n
 	"Use this for durable local overrides such as profile URLs, default headers, query parameters, auth settings, and server variables. Patches are applied to the saved config file; they do not update the remote API or the cached OpenAPI document.\n\n" +
 	"Run `restish api inspect <name>` first when you want to confirm the current config shape."
 
+const apiExportLong = "Export a registered API as a Postman, Insomnia, Bruno, or `.http` collection.\n\n" +
+	"The collection is built from the cached OpenAPI spec, so run `api sync` first if the API has changed. Each generated operation becomes a request, grouped by its first tag, with parameter examples and request body examples filled in. Requests use a `{{baseUrl}}` variable unless an operation server or `url_overrides` entry sends them elsewhere.\n\n" +
+	"Every profile becomes an environment holding its base URL, auth settings, and default header and query values; the `--rsh-profile` profile is listed first and supplies the collection's own auth. Secrets, secret references, and credential-like values are exported as empty placeholders to fill in after import.\n\n" +
+	"- `postman` writes a v2.1 collection. With `--out`, each profile is also written as a sibling `<name>.<profile>.postman_environment.json`.\n" +
+	"- `insomnia` writes a v4 export with one sub-environment per profile.\n" +
+	"- `bruno` writes a collection directory and requires `--out`, which must be new or empty.\n" +
+	"- `http` writes a file for the VS Code REST Client or JetBrains HTTP Client. On stdout it declares the selected profile's values as file variables; with `--out` they go to a sibling `http-client.env.json` instead."
+
 const apiAuthLong = "Manage auth material for a registered API profile.\n\n" +
 	"Use these commands when a generated OpenAPI command reports missing auth, when you want to see which credentials satisfy secured operations, or when cached OAuth tokens need to be cleared.\n\n" +
 	"Most commands honor `--rsh-profile` so you can inspect or update a non-default API profile."
@@ -47,20 +57,23 @@ const apiAuthAddLong = "Add or initialize a credential binding for an API profil
 	"Use this after `api auth inspect` reports a missing credential ID. When cached OpenAPI auth metadata is available, Restish can prefill auth settings and prompt for the parameters needed by that credential."
 
 const apiAuthRemoveLong = "Remove one credential binding from an API profile.\n\n" +
-	"This edits local Restish config only. It does not revoke remote tokens or delete cached OAuth tokens; run `api auth logout` when cached tokens should be cleared too."
+	"This edits local Restish config only. It does not revoke remote tokens or delete cached OAuth tokens; run `api auth logout --revoke` first when cached tokens should be revoked and cleared too."
 
 const apiAuthLogoutLong = "Delete cached API auth tokens.\n\n" +
 	"Use this when credentials changed, an OAuth grant should be refreshed, or a shared auth profile should forget cached tokens.\n\n" +
 	"- Pass an API name to clear the current `--rsh-profile` token cache entry.\n" +
 	"- Add `--all-profiles` to clear every profile for that API.\n" +
-	"- Use `--auth-profile` to clear a shared auth profile cache without naming an API."
+	"- Use `--auth-profile` to clear a shared auth profile cache without naming an API.\n\n" +
+	"Logout is local by default. Add `--revoke` to first revoke each cached OAuth refresh and access token at the provider's RFC 7009 revocation endpoint, taken from the `revocation_url` auth param or from `issuer_url` discovery; the result for each token is printed. Add `--end-session` to also print the OIDC RP-initiated logout URL, which ends the provider's browser session when opened. The local cache is cleared even when a server-side step fails, and the command then exits non-zero."
 
 const apiAuthGetLong = "Print curl-friendly auth material that Restish would apply for an API profile.\n\n" +
 	"Use this when another tool, such as curl, needs the configured auth without sending the target request through Restish. Header auth prints as `Name: value`; query auth prints as `?name=value`. Pass a credential ID when the profile has more than one configured credential, or use `--operation` to inspect operation-specific security requirements.\n\n" +
-	"Add `--print-header` to print the single resolved auth header as `Name: value` on stdout and exit non-zero for any non-header auth. This is the stable, parseable contract for shell scripts and external tools that need just the bearer header."
+	"Add `--print-header` to print the single resolved auth header as `Name: value` on stdout and exit non-zero for any non-header auth. This is the stable, parseable contract for shell scripts and external tools that need just the bearer header.\n\n" +
+	"Add `--claims` to print the decoded JWT header and claims of the access token, plus any cached OIDC ID token, as JSON. The signature is never printed or verified, and opaque tokens are an error."
 
 const apiAuthInspectLong = "Inspect auth readiness and material for an API profile.\n\n" +
-	"By default this shows configured credentials, generated-operation coverage, and the auth values Restish would apply. Use `--operation` for operation-specific OpenAPI security requirements or `--credential` for one credential binding. Add `--redact` before sharing output so sensitive header, token, and credential values are masked."
+	"By default this shows configured credentials, generated-operation coverage, and the auth values Restish would apply. Use `--operation` for operation-specific OpenAPI security requirements or `--credential` for one credential binding. Add `--redact` before sharing output so sensitive header, token, and credential values are masked.\n\n" +
+	"When the applied token is a JWT, inspect also summarizes its issuer, audience, scopes, issue time, and expiry, and warns when a token without a refresh path expires within five minutes, since long paginated requests may outlive it. Add `--claims` for the full decoded header and claims as JSON."
 
 const configLong = "Manage local Restish configuration.\n\n" +
 	"The config stores registered APIs, profiles, auth settings, plugin settings, cache preferences, and output theme choices. Use `config show` for a redacted summary, `config path` to locate the file, and `config set` for scripted changes."
@@ -126,11 +139,36 @@ const editLong = "Fetch a resource, edit it locally, then send the changed repre
 	"- Use `--no-editor` to print or patch the editable body without launching `$VISUAL` or `$EDITOR`.\n" +
 	"- Use `--yes` only after reviewing the diff in automation."
 
+const graphQLLong = "Send a GraphQL query or mutation to a GraphQL endpoint.\n\n" +
+	"Pass a full URL or a registered API short-name URL, then the query document as an argument, as `@file.graphql`, or on stdin. The request is a `POST` with a JSON body, so registered APIs use their profile headers and auth like any other request. Set variables with repeatable `--var name=value`; values are parsed as shorthand, so `--var first=10` sends a number and `--var 'input={title: Bug}'` sends an object.\n\n" +
+	"A response whose `errors` array is not empty is printed as usual, then each error is reported on stderr and Restish exits with code 1, even when the HTTP status is 200. When the document declares `$after` and the response has one Relay connection with `pageInfo.hasNextPage`, Restish follows `pageInfo.endCursor` and merges the `nodes` or `edges` of every page, using the same `--rsh-no-paginate`, `--rsh-max-pages`, and `--rsh-max-items` controls as other pagination.\n\n" +
+	"Use `--introspect` to fetch the schema. Registering an API whose base URL ends in `/graphql` introspects it automatically and generates one command per query and mutation field."
+
+const webSocketLong = "Open a WebSocket connection and stream the messages it receives.\n\n" +
+	"Pass a `ws://` or `wss://` URL, a full `http(s)://` URL, or a registered API short-name URL. The handshake is an ordinary request, so profile headers, auth, TLS settings, and `-v` work as for any other request. Offer subprotocols with repeatable `--subprotocol`; the handshake fails if the server picks one that was not offered.\n\n" +
+	"Each argument after the URL is parsed as shorthand and sent as one text message: strings are sent as-is and other values as JSON. Without message arguments, each stdin line is sent as a message, which also works interactively. The session stays open after input ends until the server closes it, `--idle-timeout` passes without a message, `--rsh-max-items` messages arrive, or you press Ctrl-C.\n\n" +
+	"Received messages are rendered like SSE and NDJSON events: JSON text is decoded, `-f` filters and `-o` formats apply per message, and `-o json --rsh-collect --rsh-max-items N` collects messages into one array. When stdout is redirected without output flags, each message is written as-is on its own line; otherwise binary messages are shown base64 encoded. Pings are answered automatically and `--ping-interval` sends keep-alive pings. A close by the server with a status other than 1000 or 1001 is reported on stderr and exits with code 1.\n\n" +
+	"Operations in an API spec that declare a `101` response generate commands that open a WebSocket session the same way, with arguments after the required parameters sent as messages."
+
+const workflowLong = "Run OpenAPI Arazzo workflows: multi-step API sequences whose steps pass outputs to each other.\n\n" +
+	"Use `workflow run` with an Arazzo 1.x document to run onboarding, provisioning, or teardown flows that span several calls, instead of chaining requests in shell scripts."
+
+const workflowRunLong = "Run one workflow from an Arazzo 1.x document (YAML or JSON) against registered APIs.\n\n" +
+	"Each OpenAPI source description is mapped to a registered API with the same name, to an API whose `spec_url` or `spec_files` point at the source URL, or explicitly with repeatable `--source SOURCE=API`. Steps resolve `operationId` and `operationPath` references through the API's cached operations, and every operation is resolved before the first request is sent. The workflow ID may be omitted when the document defines only one workflow.\n\n" +
+	"Pass workflow inputs with repeatable `--input NAME=VALUE`. Values are parsed as shorthand unless the inputs schema declares the property as a string; schema defaults apply and required inputs must be given.\n\n" +
+	"Runtime expressions such as `$inputs.team`, `$steps.create.outputs.id`, and `$response.body#/id` pass values between steps. Success criteria (simple, regex, and JSONPath) decide whether a step passed; steps without criteria pass on a 2xx status. `onSuccess` and `onFailure` actions end the workflow, go to another step or workflow, or retry the step after `retryAfter` seconds up to `retryLimit` times.\n\n" +
+	"Every step goes through the same request pipeline as generated commands, so the profile's auth, caching, retries, TLS settings, and `-v` apply. The run prints a report with the workflow outputs, each step's status, outputs, and retries, and every HTTP request sent; use `-o` to format it and `-f` to filter it like a response body, for example `-f body.outputs`. A failed workflow prints the report, reports the error on stderr, and exits with code 1."
+
+const runLong = "Run requests written in the `.http` format used by the VS Code REST Client and the JetBrains HTTP Client.\n\n" +
+	"Requests are separated by `###` lines and named with `# @name NAME` (or the text after `###`). Without a request name every request runs in file order; with one, only that request's response is printed. Define file variables with `@name = value` and use them as `{{name}}`. `{{$env NAME}}` reads an environment variable, `{{$dotenv NAME}}` reads the `.env` file next to the request file, and `{{$timestamp}}` and `{{$guid}}` are generated. A body of `< path` sends a file as-is, and `<@ path` expands placeholders in it first.\n\n" +
+	"`{{login.response.body.token}}` captures a value from the named request's response; `body.$.data[0].id` paths, `body.*` for the whole body, `headers.NAME`, and `request` in place of `response` are supported. A referenced request that has not run yet is sent first, once.\n\n" +
+	"URLs that start with a registered API name, such as `GET users/me`, resolve to that API, and every request uses the matching profile's auth, headers, caching, and retries. Responses are printed with the usual `-o`, `-f`, and `--rsh-print` handling. A response with an error status stops the run and sets the exit code as for other requests unless `--rsh-ignore-status-code` is set. Response handler scripts (`> {% ... %}`) and JetBrains environment files are not supported."
+
 const certLong = "Show the TLS certificate chain for an HTTPS server.\n\n" +
 	"Use this to inspect certificate subjects, issuers, DNS names, validity windows, and expiry timing with the same TLS-related flags Restish uses for requests. `--warn-days` exits non-zero when the leaf certificate expires soon, which is useful in monitoring scripts."
 
 const linksLong = "Perform a `GET` request and print hypermedia links found in the response.\n\n" +
-	"Restish extracts links from `Link` headers, HAL `_links`, JSON:API links, Siren links, and JSON-LD `@id` fields. Pass relation names after the URI to filter the output to specific rels."
+	"Restish extracts links from `Link` headers, HAL `_links`, JSON:API links, Siren links, OData next and delta links, and JSON-LD `@id` fields. When the URI belongs to a registered API, OpenAPI response links of the matching operation that lead to `GET` operations are added by link name. Pass relation names after the URI to filter the output to specific rels."
 
 const doctorLong = "Diagnose Restish configuration and runtime paths.\n\n" +
 	"Use this when Restish is reading the wrong config, permissions look suspicious, shell setup is incomplete, caches are in unexpected locations, or plugin discovery is confusing. Pass `-o json` for structured diagnostics."
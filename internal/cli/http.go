@@ -162,6 +162,15 @@ type requestBodyOptions struct {
 	rawBinaryBody             bool
 	bodyOverrideSet           bool
 	bodyOverride              any
+	// graphQL is set for GraphQL requests so the response errors array and
+	// Relay cursors are honored.
+	graphQL *graphQLRequest
+	// rpc is set for commands generated from a protobuf descriptor set so
+	// binary protobuf bodies can be encoded and decoded.
+	rpc *rpcRequest
+	// follow is set when a generated command follows OpenAPI links from
+	// its response instead of printing it.
+	follow *operationFollow
 }
 
 // runHTTPWithOptions executes one HTTP request through the full pipeline:
@@ -223,16 +232,37 @@ func (c *CLI) runHTTPWithOptions(cmd *cobra.Command, method string, args []strin
 			return fmt.Errorf("building request body: %w", err)
 		}
 	}
+	if bodyOpts.rpc != nil && bodyVal == nil {
+		// Every RPC call carries a message, even an empty one.
+		bodyVal = map[string]any{}
+	}
 	if bodyOpts.bodyRequired && bodyVal == nil {
 		return fmt.Errorf("request body is required; pass body arguments, pipe a body on stdin, or run %q for an example", cmd.CommandPath()+" --rsh-generate-body")
 	}
 	if len(bodyOpts.multipartPartContentTypes) > 0 && strings.HasPrefix(strings.ToLower(opts.ContentType), "multipart/form-data") {
 		bodyVal = content.MultipartBody{Value: bodyVal, ContentTypes: bodyOpts.multipartPartContentTypes}
 	}
+	var rpcBinaryContentType string
+	if bodyOpts.rpc != nil {
+		rpcBinaryContentType = bodyOpts.rpc.binaryContentType(opts.ContentType)
+	}
 	if bodyOpts.validationRequested && bodyVal != nil {
-		if err := validateGeneratedJSONBody(bodyVal, opts.ContentType, bodyOpts.validationMediaType, bodyOpts.validationSchema, bodyOpts.validationSchemaDialect, output.ColorEnabled(c.Stderr)); err != nil {
+		validationContentType := opts.ContentType
+		if rpcBinaryContentType != "" {
+			// The protobuf body is built from JSON, so check it as JSON.
+			validationContentType = ""
+		}
+		if err := validateGeneratedJSONBody(bodyVal, validationContentType, bodyOpts.validationMediaType, bodyOpts.validationSchema, bodyOpts.validationSchemaDialect, output.ColorEnabled(c.Stderr)); err != nil {
+			return err
+		}
+	}
+	if rpcBinaryContentType != "" && bodyVal != nil {
+		msg, err := bodyOpts.rpc.encodeBody(bodyVal)
+		if err != nil {
 			return err
 		}
+		bodyVal = msg
+		opts.ContentType = rpcBinaryContentType
 	}
 	inputSource := traceInputSource(bodyInfo, bodyVal != nil)
 	if bodyVal != nil {
@@ -340,11 +370,11 @@ func (c *CLI) runHTTPWithOptions(cmd *cobra.Command, method string, args []strin
 		_ = httpResp.Body.Close()
 		return err
 	}
-	if gf.Silent {
+	if gf.Silent && bodyOpts.graphQL == nil && bodyOpts.follow == nil {
 		_ = httpResp.Body.Close()
 		return c.statusError(cmd, httpResp.StatusCode)
 	}
-	if printSpec.rawBodyOnly() {
+	if printSpec.rawBodyOnly() && bodyOpts.follow == nil {
 		defer httpResp.Body.Close()
 		raw, err := c.rawResponseBodyBytes(httpResp, maxBodyBytes(cmd))
 		if err != nil {
@@ -359,9 +389,12 @@ func (c *CLI) runHTTPWithOptions(cmd *cobra.Command, method string, args []strin
 		if err := c.writeRawBytes(raw); err != nil {
 			return err
 		}
-		return c.statusError(cmd, httpResp.StatusCode)
+		if err := c.statusError(cmd, httpResp.StatusCode); err != nil || bodyOpts.graphQL == nil {
+			return err
+		}
+		return c.graphQLRawErrorsError(cmd, raw)
 	}
-	if c.canPrintWithoutResponseBody(gf, printSpec) {
+	if c.canPrintWithoutResponseBody(gf, printSpec) && bodyOpts.graphQL == nil && bodyOpts.follow == nil {
 		resp := responseMetadataOnly(httpResp)
 		if err := c.formatResponse(cmd, resp, prepared); err != nil {
 			_ = httpResp.Body.Close()
@@ -381,6 +414,12 @@ func (c *CLI) runHTTPWithOptions(cmd *cobra.Command, method string, args []strin
 	if err != nil {
 		return responseBodyReadError(method, rawURL, err)
 	}
+	if bodyOpts.rpc != nil {
+		if err := bodyOpts.rpc.decodeResponse(resp); err != nil {
+			return err
+		}
+	}
+	c.normalizeResponseBody(resp, prepared)
 	traceContentDecode(trace, output.Header(resp.Headers, "Content-Type"))
 	if v := globalFlagsFromContext(requestContext(cmd)).Verbose; v >= 1 {
 		c.logVerboseResponseBody(resp)
@@ -412,13 +451,24 @@ func (c *CLI) runHTTPWithOptions(cmd *cobra.Command, method string, args []strin
 		}
 	}
 
-	// Pagination: if this is a GET and there's a next link, paginate.
-	if method == "GET" && printSpec.includesResponseBody() && !printSpec.rawBodyOnly() && !gf.HeadersShorthand && !filterRequestsResponseMetadata(gf.Filter) {
-		var pagCfg *config.PaginationConfig
-		if apiName != "" && c.cfg != nil && c.cfg.APIs[apiName] != nil {
-			pagCfg = c.cfg.APIs[apiName].Pagination
+	if bodyOpts.follow != nil {
+		return c.followOperationLinks(cmd, resp, prepared, bodyOpts.follow)
+	}
+
+	// Pagination: if this is a GET and there's a next link, or a GraphQL
+	// request whose response has a next cursor, paginate.
+	if (method == "GET" || bodyOpts.graphQL != nil) && printSpec.includesResponseBody() && !printSpec.rawBodyOnly() && !gf.HeadersShorthand && !filterRequestsResponseMetadata(gf.Filter) {
+		var did bool
+		var err error
+		if bodyOpts.graphQL != nil {
+			did, err = c.tryPaginateGraphQL(cmd, resp, rawURL, opts, prepared, bodyOpts.graphQL)
+		} else {
+			var pagCfg *config.PaginationConfig
+			if apiName != "" && c.cfg != nil && c.cfg.APIs[apiName] != nil {
+				pagCfg = c.cfg.APIs[apiName].Pagination
+			}
+			did, err = c.tryPaginate(cmd, resp, rawURL, opts, pagCfg, prepared, bodyOpts.explicitAPIName != "")
 		}
-		did, err := c.tryPaginate(cmd, resp, rawURL, opts, pagCfg, prepared, bodyOpts.explicitAPIName != "")
 		if err != nil {
 			return err
 		}
@@ -431,7 +481,10 @@ func (c *CLI) runHTTPWithOptions(cmd *cobra.Command, method string, args []strin
 		return err
 	}
 
-	return c.statusError(cmd, resp.Status)
+	if err := c.statusError(cmd, resp.Status); err != nil || bodyOpts.graphQL == nil {
+		return err
+	}
+	return c.graphQLErrorsError(cmd, resp.Body)
 }
 
 func (c *CLI) statusError(cmd *cobra.Command, status int) error {
@@ -1862,10 +1915,15 @@ func (c *CLI) applyAPIProfile(rawURL, profileName string, opts request.Options,
 	// request-specific values replace matching profile defaults. Query params
 	// keep append semantics because repeated query keys are often intentional.
 	if match.profile != nil {
-		opts.Headers, err = mergeHeaderOptions(match.profile.Headers, opts.Headers)
+		profileHeaders, credentialHeaders, err := c.resolveProfileHeaders(match.profile.Headers)
 		if err != nil {
 			return rawURL, match.apiName, opts, err
 		}
+		opts.Headers, err = mergeHeaderOptions(profileHeaders, opts.Headers)
+		if err != nil {
+			return rawURL, match.apiName, opts, err
+		}
+		opts.CredentialHeaders = credentialHeaders
 		opts.Query = append(append([]string(nil), match.profile.Query...), opts.Query...)
 		callbacks := c.authOnRequest(match.apiName, profileName, match.profile, authOpts)
 		opts.OnRequest = callbacks.OnRequest
@@ -1873,7 +1931,11 @@ func (c *CLI) applyAPIProfile(rawURL, profileName string, opts request.Options,
 		if opts.TLSSignerName == "" {
 			opts.TLSSignerName = match.profile.TLSSigner
 		}
-		opts.TLSSignerParams = mergeTLSSignerParams(opts.TLSSignerParams, match.profile.TLSSignerParams)
+		signerParams, err := c.resolveSecretParams(match.profile.TLSSignerParams)
+		if err != nil {
+			return rawURL, match.apiName, opts, err
+		}
+		opts.TLSSignerParams = mergeTLSSignerParams(opts.TLSSignerParams, signerParams)
 		if opts.CACertPath == "" {
 			opts.CACertPath = match.profile.CACertPath
 		}
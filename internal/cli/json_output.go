@@ -59,6 +59,9 @@ func unsupportedResponseTransformFlagNames(cmd *cobra.Command, gf GlobalFlags) [
 	if gf.FilterLang != "" {
 		names = append(names, "--rsh-filter-lang")
 	}
+	if gf.Normalize != "" {
+		names = append(names, "--rsh-normalize")
+	}
 	if gf.HeadersShorthand {
 		names = append(names, "--rsh-headers")
 	}
@@ -83,5 +86,8 @@ func unsupportedResponseTransformFlagNames(cmd *cobra.Command, gf GlobalFlags) [
 	if gf.MaxItems != 0 {
 		names = append(names, "--rsh-max-items")
 	}
+	if gf.Reconnect {
+		names = append(names, "--rsh-reconnect")
+	}
 	return names
 }
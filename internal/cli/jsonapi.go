@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rest-sh/restish/v2/config"
+	"github.com/rest-sh/restish/v2/internal/output"
+)
+
+// Response normalizations selectable with --rsh-normalize or an API's
+// normalize setting.
+const (
+	responseNormalizeJSONAPI = "jsonapi"
+	responseNormalizeNone    = "none"
+)
+
+// jsonAPIQuery converts the JSON:API request helper flags to query options:
+// --rsh-include becomes include, --rsh-fields TYPE=FIELDS becomes
+// fields[TYPE], and --rsh-jsonapi-filter KEY=VALUE becomes filter[KEY].
+// Repeated --rsh-include and --rsh-fields values for one type are joined
+// with commas, as JSON:API expects.
+func jsonAPIQuery(include, fields, filters []string) ([]string, error) {
+	var query []string
+	var includes []string
+	for _, value := range include {
+		for _, path := range strings.Split(value, ",") {
+			if path = strings.TrimSpace(path); path != "" {
+				includes = append(includes, path)
+			}
+		}
+	}
+	if len(includes) > 0 {
+		query = append(query, "include="+strings.Join(includes, ","))
+	}
+
+	var types []string
+	fieldsByType := map[string][]string{}
+	for _, value := range fields {
+		typ, list, ok := strings.Cut(value, "=")
+		typ = strings.TrimSpace(typ)
+		if !ok || typ == "" {
+			return nil, fmt.Errorf("invalid --rsh-fields %q: expected TYPE=FIELD,FIELD", value)
+		}
+		if _, seen := fieldsByType[typ]; !seen {
+			types = append(types, typ)
+		}
+		for _, field := range strings.Split(list, ",") {
+			if field = strings.TrimSpace(field); field != "" {
+				fieldsByType[typ] = append(fieldsByType[typ], field)
+			}
+		}
+	}
+	for _, typ := range types {
+		query = append(query, "fields["+typ+"]="+strings.Join(fieldsByType[typ], ","))
+	}
+
+	for _, value := range filters {
+		key, filterValue, ok := strings.Cut(value, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid --rsh-jsonapi-filter %q: expected KEY=VALUE", value)
+		}
+		query = append(query, "filter["+key+"]="+filterValue)
+	}
+	return query, nil
+}
+
+func validateNormalizeFlag(value string) error {
+	if err := config.ValidateNormalize(value); err != nil {
+		return fmt.Errorf("invalid --rsh-normalize %q: %w", value, err)
+	}
+	return nil
+}
+
+// responseNormalization returns the normalization for a request to apiName.
+// --rsh-normalize wins over the API's normalize setting, and "none" turns
+// normalization off.
+func (c *CLI) responseNormalization(gf GlobalFlags, apiName string) string {
+	mode := gf.Normalize
+	if mode == "" && apiName != "" && c.cfg != nil && c.cfg.APIs[apiName] != nil {
+		mode = c.cfg.APIs[apiName].Normalize
+	}
+	if mode == responseNormalizeNone {
+		return ""
+	}
+	return mode
+}
+
+// normalizeResponseBody applies the request's response normalization. Body
+// links are read first, so pagination and the links filter still see the
+// document's links after it is flattened.
+func (c *CLI) normalizeResponseBody(resp *output.Response, prepared *preparedRequest) {
+	if resp == nil || prepared == nil || prepared.normalize != responseNormalizeJSONAPI {
+		return
+	}
+	c.ensureBodyLinks(resp)
+	if flat, ok := output.FlattenJSONAPI(resp.Body); ok {
+		resp.Body = flat
+	}
+}
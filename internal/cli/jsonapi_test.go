@@ -0,0 +1,113 @@
+package cli_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+)
+
+func jsonAPIResponse(r *http.Request, body string) *http.Response {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Proto:      "HTTP/1.1",
+		Header:     http.Header{"Content-Type": {"application/vnd.api+json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    r,
+	}
+}
+
+func TestJSONAPIHelperFlagsBuildQuery(t *testing.T) {
+	c, _, _ := newTestCLI(t)
+	var query map[string][]string
+	useTransport(c, func(r *http.Request) (*http.Response, error) {
+		query = r.URL.Query()
+		return jsonAPIResponse(r, `{"data":[]}`), nil
+	})
+	err := c.Run([]string{"restish", "get", "https://api.example.com/articles",
+		"--rsh-include", "author", "--rsh-include", "comments.author",
+		"--rsh-fields", "articles=title", "--rsh-fields", "articles=body", "--rsh-fields", "people=name",
+		"--rsh-jsonapi-filter", "status=published", "-o", "json"})
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	want := map[string]string{
+		"include":          "author,comments.author",
+		"fields[articles]": "title,body",
+		"fields[people]":   "name",
+		"filter[status]":   "published",
+	}
+	for key, value := range want {
+		if got := query[key]; len(got) != 1 || got[0] != value {
+			t.Errorf("%s = %q, want %q", key, got, value)
+		}
+	}
+
+	if err := c.Run([]string{"restish", "get", "https://api.example.com/articles", "--rsh-fields", "title"}); err == nil || !strings.Contains(err.Error(), "TYPE=FIELD") {
+		t.Fatalf("bad --rsh-fields err = %v", err)
+	}
+	if err := c.Run([]string{"restish", "get", "https://api.example.com/articles", "--rsh-normalize", "hal"}); err == nil || !strings.Contains(err.Error(), "--rsh-normalize") {
+		t.Fatalf("bad --rsh-normalize err = %v", err)
+	}
+}
+
+func TestJSONAPINormalizeFlattensPages(t *testing.T) {
+	c, out, _ := newTestCLI(t)
+	useTransport(c, func(r *http.Request) (*http.Response, error) {
+		if r.URL.Query().Get("page") == "2" {
+			return jsonAPIResponse(r, `{"data":[{"type":"articles","id":"2","attributes":{"title":"Second"}}]}`), nil
+		}
+		return jsonAPIResponse(r, `{
+			"data":[{"type":"articles","id":"1","attributes":{"title":"First"},
+				"relationships":{"author":{"data":{"type":"people","id":"9"}}}}],
+			"included":[{"type":"people","id":"9","attributes":{"name":"Dan"}}],
+			"links":{"next":"https://api.example.com/articles?page=2"}}`), nil
+	})
+	if err := c.Run([]string{"restish", "get", "https://api.example.com/articles", "--rsh-normalize", "jsonapi", "--rsh-collect", "-o", "json"}); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	var got []map[string]any
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("output is not a JSON array: %v\n%s", err, out.String())
+	}
+	if len(got) != 2 || got[0]["title"] != "First" || got[1]["title"] != "Second" {
+		t.Fatalf("records = %#v", got)
+	}
+	if author, _ := got[0]["author"].(map[string]any); author["name"] != "Dan" {
+		t.Fatalf("author = %#v", got[0]["author"])
+	}
+}
+
+func TestJSONAPIConfigNormalizeWrapsRequestBody(t *testing.T) {
+	c, out, _ := newTestCLI(t)
+	configBody := `{"apis":{"blog":{"base_url":"https://api.example.com","normalize":"jsonapi"}}}`
+	if err := os.WriteFile(c.Hooks().ConfigPath, []byte(configBody), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	var contentType string
+	var sent map[string]any
+	useTransport(c, func(r *http.Request) (*http.Response, error) {
+		contentType = r.Header.Get("Content-Type")
+		if err := json.NewDecoder(r.Body).Decode(&sent); err != nil {
+			t.Fatalf("request body: %v", err)
+		}
+		return jsonAPIResponse(r, `{"data":{"type":"articles","id":"7","attributes":{"title":"Hi"}}}`), nil
+	})
+	if err := c.Run([]string{"restish", "post", "blog/articles", "type: articles, title: Hi, author: {type: people, id: 9}", "-o", "json"}); err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	if !strings.HasPrefix(contentType, "application/vnd.api+json") {
+		t.Fatalf("Content-Type = %q", contentType)
+	}
+	data, _ := sent["data"].(map[string]any)
+	attributes, _ := data["attributes"].(map[string]any)
+	relationships, _ := data["relationships"].(map[string]any)
+	if data["type"] != "articles" || attributes["title"] != "Hi" || relationships["author"] == nil {
+		t.Fatalf("sent = %#v", sent)
+	}
+	if !strings.Contains(out.String(), `"id": "7"`) || strings.Contains(out.String(), "attributes") {
+		t.Fatalf("stdout = %s", out.String())
+	}
+}
@@ -0,0 +1,199 @@
+package cli
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// jwtExpiryWarningWindow is how close to expiry a token without a refresh
+// path must be before readiness checks warn. Long paginated requests can run
+// past a token that is valid when the first page is fetched.
+const jwtExpiryWarningWindow = 5 * time.Minute
+
+// decodedJWT holds the header and claims of a JWT. The signature is never
+// kept, verified, or printed.
+type decodedJWT struct {
+	Header map[string]any `json:"header"`
+	Claims map[string]any `json:"claims"`
+}
+
+// decodeJWT parses the header and claims of a compact JWS. It reports false
+// for opaque tokens and encrypted (five-part) JWTs.
+func decodeJWT(token string) (decodedJWT, bool) {
+	parts := strings.Split(strings.TrimSpace(token), ".")
+	if len(parts) != 3 {
+		return decodedJWT{}, false
+	}
+	var jwt decodedJWT
+	if !decodeJWTSegment(parts[0], &jwt.Header) || !decodeJWTSegment(parts[1], &jwt.Claims) {
+		return decodedJWT{}, false
+	}
+	if _, ok := jwt.Header["alg"].(string); !ok {
+		return decodedJWT{}, false
+	}
+	return jwt, true
+}
+
+func decodeJWTSegment(segment string, dst *map[string]any) bool {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(segment, "="))
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(data, dst) == nil && *dst != nil
+}
+
+// jwtTime returns a NumericDate claim such as exp or iat.
+func (j decodedJWT) jwtTime(name string) (time.Time, bool) {
+	v, ok := j.Claims[name].(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	sec, frac := math.Modf(v)
+	return time.Unix(int64(sec), int64(frac*1e9)).UTC(), true
+}
+
+// claimList flattens a string or string-array claim such as aud or scp.
+func (j decodedJWT) claimList(name string) []string {
+	switch v := j.Claims[name].(type) {
+	case string:
+		return []string{v}
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// scopes returns the token scopes from scope (space separated) or scp.
+func (j decodedJWT) scopes() string {
+	if scope, ok := j.Claims["scope"].(string); ok {
+		return scope
+	}
+	return strings.Join(j.claimList("scp"), " ")
+}
+
+// expiryIssue describes a token that is expired or expires within the
+// warning window, or returns "".
+func (j decodedJWT) expiryIssue(now time.Time) string {
+	exp, ok := j.jwtTime("exp")
+	if !ok {
+		return ""
+	}
+	return tokenExpiryIssue(exp, now)
+}
+
+func tokenExpiryIssue(exp, now time.Time) string {
+	remaining := exp.Sub(now)
+	switch {
+	case remaining <= 0:
+		return "token expired " + formatTokenLifetime(-remaining) + " ago"
+	case remaining < jwtExpiryWarningWindow:
+		return "token expires in " + formatTokenLifetime(remaining) + "; long paginated requests may outlive it"
+	}
+	return ""
+}
+
+func formatTokenLifetime(d time.Duration) string {
+	return d.Round(time.Second).String()
+}
+
+// authMaterialJWT finds a JWT in the headers an auth handler applied, such as
+// "Authorization: Bearer <jwt>" or an API-key header carrying a JWT.
+func authMaterialJWT(req *http.Request) (decodedJWT, bool) {
+	if req == nil {
+		return decodedJWT{}, false
+	}
+	for _, name := range sortedHeaderKeys(req.Header) {
+		for _, value := range req.Header[name] {
+			if _, token, ok := strings.Cut(value, " "); ok {
+				value = token
+			}
+			if jwt, ok := decodeJWT(value); ok {
+				return jwt, true
+			}
+		}
+	}
+	return decodedJWT{}, false
+}
+
+// authTokenClaims is the --claims JSON shape for one auth target.
+type authTokenClaims struct {
+	Credential  string      `json:"credential,omitempty"`
+	AccessToken *decodedJWT `json:"access_token,omitempty"`
+	IDToken     *decodedJWT `json:"id_token,omitempty"`
+}
+
+func (a authTokenClaims) empty() bool {
+	return a.AccessToken == nil && a.IDToken == nil
+}
+
+// authTokenClaimsFor decodes the JWT access token in req and any cached OIDC
+// ID token for the resolved OAuth configs.
+func (c *CLI) authTokenClaimsFor(req *http.Request, apiName, profileName string, resolved ...resolvedAuthConfig) authTokenClaims {
+	var claims authTokenClaims
+	if jwt, ok := authMaterialJWT(req); ok {
+		claims.AccessToken = &jwt
+	}
+	for _, r := range resolved {
+		if r.Config == nil {
+			continue
+		}
+		cached := c.cachedOAuthTokenEntry(r.Config.Type, r.CacheKey, apiName, profileName)
+		if cached == nil || cached.IDToken == "" {
+			continue
+		}
+		if jwt, ok := decodeJWT(cached.IDToken); ok {
+			claims.IDToken = &jwt
+			break
+		}
+	}
+	return claims
+}
+
+func printAuthTokenClaims(w io.Writer, claims authTokenClaims, now time.Time) {
+	if claims.AccessToken != nil {
+		printJWTSummary(w, "Access token", *claims.AccessToken, now)
+	}
+	if claims.IDToken != nil {
+		printJWTSummary(w, "ID token", *claims.IDToken, now)
+	}
+}
+
+func printJWTSummary(w io.Writer, label string, jwt decodedJWT, now time.Time) {
+	header := "alg " + fmt.Sprint(jwt.Header["alg"])
+	if kid, ok := jwt.Header["kid"].(string); ok && kid != "" {
+		header += ", kid " + kid
+	}
+	fmt.Fprintf(w, "%s: JWT (%s)\n", label, header)
+	if iss, ok := jwt.Claims["iss"].(string); ok {
+		fmt.Fprintf(w, "  iss: %s\n", iss)
+	}
+	if aud := jwt.claimList("aud"); len(aud) > 0 {
+		fmt.Fprintf(w, "  aud: %s\n", strings.Join(aud, ", "))
+	}
+	if scope := jwt.scopes(); scope != "" {
+		fmt.Fprintf(w, "  scope: %s\n", scope)
+	}
+	if iat, ok := jwt.jwtTime("iat"); ok {
+		fmt.Fprintf(w, "  iat: %s\n", iat.Format(time.RFC3339))
+	}
+	if exp, ok := jwt.jwtTime("exp"); ok {
+		remaining := exp.Sub(now)
+		lifetime := "expires in " + formatTokenLifetime(remaining)
+		if remaining <= 0 {
+			lifetime = "expired " + formatTokenLifetime(-remaining) + " ago"
+		}
+		fmt.Fprintf(w, "  exp: %s (%s)\n", exp.Format(time.RFC3339), lifetime)
+	}
+}
@@ -0,0 +1,148 @@
+package cli_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testJWTSignature = "c2lnbmF0dXJlLW5vdC1wcmludGVk"
+
+func testJWT(t *testing.T, claims map[string]any) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]any{"alg": "RS256", "kid": "key-1", "typ": "JWT"})
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload) + "." + testJWTSignature
+}
+
+func bearerJWTConfig(token string) string {
+	return fmt.Sprintf(`{
+		"apis": {
+			"myapi": {
+				"base_url": "https://api.example.com",
+				"profiles": {"default": {"auth": {"type": "bearer", "params": {"token": %q}}}}
+			}
+		}
+	}`, token)
+}
+
+func TestAPIAuthInspectDecodesJWTClaims(t *testing.T) {
+	exp := time.Now().Add(90 * time.Minute).Unix()
+	token := testJWT(t, map[string]any{
+		"iss":   "https://issuer.example.com",
+		"aud":   []string{"api://orders", "api://billing"},
+		"scp":   []string{"orders.read", "orders.write"},
+		"iat":   1700000000,
+		"exp":   exp,
+		"email": "alice@example.com",
+	})
+	c, out, _ := newTestCLI(t)
+	c.Hooks().ConfigPath = writeAPIConfig(t, bearerJWTConfig(token))
+
+	if err := c.Run([]string{"restish", "api", "auth", "inspect", "myapi", "--redact"}); err != nil {
+		t.Fatalf("api auth inspect: %v", err)
+	}
+	got := out.String()
+	for _, want := range []string{
+		"Access token: JWT (alg RS256, kid key-1)",
+		"  iss: https://issuer.example.com",
+		"  aud: api://orders, api://billing",
+		"  scope: orders.read orders.write",
+		"  iat: 2023-11-14T22:13:20Z",
+		"  exp: " + time.Unix(exp, 0).UTC().Format(time.RFC3339) + " (expires in 1h",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("inspect output missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, testJWTSignature) {
+		t.Fatalf("inspect --redact printed the JWT signature:\n%s", got)
+	}
+
+	out.Reset()
+	if err := c.Run([]string{"restish", "api", "auth", "get", "myapi", "--claims"}); err != nil {
+		t.Fatalf("api auth get --claims: %v", err)
+	}
+	if strings.Contains(out.String(), testJWTSignature) {
+		t.Fatalf("--claims printed the JWT signature:\n%s", out.String())
+	}
+	var claims struct {
+		AccessToken struct {
+			Header map[string]any `json:"header"`
+			Claims map[string]any `json:"claims"`
+		} `json:"access_token"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &claims); err != nil {
+		t.Fatalf("--claims output is not JSON: %v\n%s", err, out.String())
+	}
+	if claims.AccessToken.Header["kid"] != "key-1" || claims.AccessToken.Claims["email"] != "alice@example.com" {
+		t.Fatalf("claims = %+v", claims)
+	}
+}
+
+func TestAPIAuthGetClaimsRejectsOpaqueTokens(t *testing.T) {
+	c, _, _ := newTestCLI(t)
+	c.Hooks().ConfigPath = writeAPIConfig(t, bearerJWTConfig("opaque-token"))
+	err := c.Run([]string{"restish", "api", "auth", "get", "myapi", "--claims"})
+	if err == nil || !strings.Contains(err.Error(), "is not a JWT") {
+		t.Fatalf("err = %v, want not a JWT", err)
+	}
+}
+
+func TestAPIAuthInspectClaimsIncludesCachedIDToken(t *testing.T) {
+	now := time.Now()
+	accessToken := testJWT(t, map[string]any{"aud": "api://orders", "scope": "orders.read", "exp": now.Add(time.Hour).Unix()})
+	idToken := testJWT(t, map[string]any{"iss": "https://issuer.example.com", "aud": "cli-client", "sub": "alice"})
+	c, out, _ := newTestCLI(t)
+	useTransport(c, func(r *http.Request) (*http.Response, error) {
+		return jsonResponse(200, fmt.Sprintf(`{"access_token":%q,"id_token":%q,"token_type":"Bearer","expires_in":3600}`, accessToken, idToken)), nil
+	})
+	c.Hooks().ConfigPath = writeAPIConfig(t, `{
+		"apis": {
+			"myapi": {
+				"base_url": "https://api.example.com",
+				"profiles": {"default": {"auth": {"type": "oauth-client-credentials", "params": {"client_id": "id", "client_secret": "secret", "token_url": "https://auth.example.com/token"}}}}
+			}
+		}
+	}`)
+
+	if err := c.Run([]string{"restish", "api", "auth", "inspect", "myapi", "--claims"}); err != nil {
+		t.Fatalf("api auth inspect --claims: %v", err)
+	}
+	var got []struct {
+		AccessToken *struct {
+			Claims map[string]any `json:"claims"`
+		} `json:"access_token"`
+		IDToken *struct {
+			Claims map[string]any `json:"claims"`
+		} `json:"id_token"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("--claims output is not JSON: %v\n%s", err, out.String())
+	}
+	if len(got) != 1 || got[0].AccessToken == nil || got[0].AccessToken.Claims["aud"] != "api://orders" ||
+		got[0].IDToken == nil || got[0].IDToken.Claims["sub"] != "alice" {
+		t.Fatalf("claims = %s", out.String())
+	}
+}
+
+func TestAPIAuthInspectWarnsWhenBearerJWTExpiresSoon(t *testing.T) {
+	token := testJWT(t, map[string]any{"exp": time.Now().Add(2 * time.Minute).Unix()})
+	c, out, _ := newTestCLI(t)
+	c.Hooks().ConfigPath = writeAPIConfig(t, bearerJWTConfig(token))
+
+	if err := c.Run([]string{"restish", "api", "auth", "inspect", "myapi", "--redact"}); err != nil {
+		t.Fatalf("api auth inspect: %v", err)
+	}
+	if got := out.String(); !strings.Contains(got, "Generic request auth: configured (token expires in ") ||
+		!strings.Contains(got, "long paginated requests may outlive it") {
+		t.Fatalf("inspect output missing expiry warning:\n%s", got)
+	}
+}
@@ -62,6 +62,13 @@ func (c *CLI) runLinksCmd(cmd *cobra.Command, args []string) error {
 			}
 		}
 	}
+	// OpenAPI response links of the matching operation add relations the
+	// response itself does not carry.
+	for rel, href := range c.operationLinkRelations(cmd, "GET", resp, prepared) {
+		if _, ok := links[rel]; !ok {
+			links[rel] = href
+		}
+	}
 
 	// Filter to requested rels if specified.
 	if len(filterRels) > 0 {
@@ -953,3 +953,63 @@ func TestOAuthClientCredentials_UsesCachedTokenWithMissingEnvSecret(t *testing.T
 		t.Fatalf("Authorization = %q, want cached token", got)
 	}
 }
+
+func TestAuthLogout_RevokeReportsEachTokenAndClearsCache(t *testing.T) {
+	var revoked []string
+	c, out, _ := newTestCLI(t)
+	useTransport(c, func(r *http.Request) (*http.Response, error) {
+		if r.URL.String() != "https://oauth.example.com/revoke" {
+			return jsonResponse(404, `{}`), nil
+		}
+		if err := r.ParseForm(); err != nil {
+			t.Fatalf("ParseForm: %v", err)
+		}
+		if r.PostForm.Get("client_secret") != "mysecret" {
+			t.Errorf("revocation form = %#v", r.PostForm)
+		}
+		revoked = append(revoked, r.PostForm.Get("token_type_hint")+"="+r.PostForm.Get("token"))
+		if r.PostForm.Get("token_type_hint") == "access_token" {
+			return jsonResponse(503, `{"error":"temporarily_unavailable"}`), nil
+		}
+		return jsonResponse(200, ``), nil
+	})
+	cfg := `{"apis": {"myapi": {
+		"base_url": "https://api.example.com",
+		"profiles": {"default": {"auth": {
+			"type": "oauth-client-credentials",
+			"params": {
+				"client_id": "myid",
+				"client_secret": "mysecret",
+				"token_url": "https://oauth.example.com/token",
+				"revocation_url": "https://oauth.example.com/revoke",
+				"end_session_url": "https://oauth.example.com/logout",
+				"post_logout_redirect_uri": "https://app.example.com/"
+			}
+		}}}
+	}}}`
+	cacheFile := filepath.Join(t.TempDir(), "tokens.json")
+	_ = auth.NewTokenCache(cacheFile).Set("myapi:default", auth.CachedToken{AccessToken: "a1", RefreshToken: "r1", IDToken: "id1"})
+	c.Hooks().ConfigPath = writeAPIConfig(t, cfg)
+	c.Hooks().TokenCachePath = cacheFile
+
+	err := c.Run([]string{"restish", "api", "auth", "logout", "myapi", "--revoke", "--end-session"})
+	if err == nil || !strings.Contains(err.Error(), "local token cache was cleared") {
+		t.Fatalf("err = %v", err)
+	}
+	if strings.Join(revoked, ",") != "refresh_token=r1,access_token=a1" {
+		t.Fatalf("revoked = %v", revoked)
+	}
+	for _, want := range []string{
+		`Revoked refresh_token for "myapi" (profile "default")`,
+		`Could not revoke access_token for "myapi" (profile "default"): revocation endpoint returned 503`,
+		"https://oauth.example.com/logout?client_id=myid&id_token_hint=id1&post_logout_redirect_uri=https%3A%2F%2Fapp.example.com%2F",
+		`Cleared auth cache for "myapi" (profile "default")`,
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+	if got, _ := auth.NewTokenCache(cacheFile).Get("myapi:default"); got != nil {
+		t.Fatal("expected cache entry to be deleted after a failed revocation")
+	}
+}
@@ -0,0 +1,101 @@
+package cli_test
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/rest-sh/restish/v2/internal/cli"
+)
+
+const odataTestMetadata = `<edmx:Edmx Version="4.0" xmlns:edmx="http://docs.oasis-open.org/odata/ns/edmx">
+  <edmx:DataServices>
+    <Schema Namespace="Trippin" xmlns="http://docs.oasis-open.org/odata/ns/edm">
+      <EntityType Name="Person">
+        <Key><PropertyRef Name="UserName"/></Key>
+        <Property Name="UserName" Type="Edm.String" Nullable="false"/>
+        <Property Name="FirstName" Type="Edm.String"/>
+        <NavigationProperty Name="Friends" Type="Collection(Trippin.Person)"/>
+      </EntityType>
+      <EntityContainer Name="Container">
+        <EntitySet Name="People" EntityType="Trippin.Person"/>
+      </EntityContainer>
+    </Schema>
+  </edmx:DataServices>
+</edmx:Edmx>`
+
+func newODataTestCLI(t *testing.T) (*cli.CLI, *bytes.Buffer) {
+	t.Helper()
+	c, stdout, _, _ := newSpecFileTestCLI(t, "trip", "https://services.example.com/TripPin", "metadata.xml", odataTestMetadata, "")
+	return c, stdout
+}
+
+func TestODataGeneratedCommandsSendQueryOptionsAndKeys(t *testing.T) {
+	c, stdout := newODataTestCLI(t)
+	var urls []string
+	useTransport(c, func(req *http.Request) (*http.Response, error) {
+		urls = append(urls, req.URL.String())
+		if strings.Contains(req.URL.Path, "('") {
+			return jsonResponse(http.StatusOK, `{"UserName":"russellwhyte","FirstName":"Russell"}`), nil
+		}
+		return jsonResponse(http.StatusOK, `{"@odata.context":"$metadata#People","value":[{"UserName":"russellwhyte"}]}`), nil
+	})
+
+	if err := c.Run([]string{"restish", "trip", "list-people", "--select", "UserName,FirstName", "--top", "2", "--filter", "FirstName eq 'Russell'", "-o", "json"}); err != nil {
+		t.Fatalf("list-people: %v", err)
+	}
+	if err := c.Run([]string{"restish", "trip", "get-person", "russellwhyte", "--expand", "Friends", "-o", "json"}); err != nil {
+		t.Fatalf("get-person: %v", err)
+	}
+	if len(urls) != 2 {
+		t.Fatalf("urls = %v", urls)
+	}
+	list, err := http.NewRequest(http.MethodGet, urls[0], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := list.URL.Query()
+	if list.URL.Path != "/TripPin/People" || query.Get("$select") != "UserName,FirstName" || query.Get("$top") != "2" || query.Get("$filter") != "FirstName eq 'Russell'" {
+		t.Fatalf("list URL = %s", urls[0])
+	}
+	if !strings.HasPrefix(urls[1], "https://services.example.com/TripPin/People('russellwhyte')?") || !strings.Contains(urls[1], "%24expand=Friends") {
+		t.Fatalf("get URL = %s", urls[1])
+	}
+	if !strings.Contains(stdout.String(), "Russell") {
+		t.Fatalf("stdout = %s", stdout.String())
+	}
+}
+
+func TestODataBatchCommandSendsAndDecodesMultipart(t *testing.T) {
+	c, stdout := newODataTestCLI(t)
+	var sent string
+	useTransport(c, func(req *http.Request) (*http.Response, error) {
+		mediaType, params, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+		if err != nil || mediaType != "multipart/mixed" || params["boundary"] == "" {
+			t.Fatalf("Content-Type = %q", req.Header.Get("Content-Type"))
+		}
+		body, _ := io.ReadAll(req.Body)
+		sent = string(body)
+		reply := "--b\r\nContent-Type: application/http\r\nContent-ID: 1\r\n\r\n" +
+			"HTTP/1.1 200 OK\r\nContent-Type: application/json\r\n\r\n{\"FirstName\":\"Russell\"}\r\n--b--\r\n"
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Proto:      "HTTP/1.1",
+			Header:     http.Header{"Content-Type": {"multipart/mixed; boundary=b"}},
+			Body:       io.NopCloser(strings.NewReader(reply)),
+		}, nil
+	})
+
+	if err := c.Run([]string{"restish", "trip", "batch", "requests[]{id: 1, method: GET, url: People('russellwhyte')}", "-o", "json"}); err != nil {
+		t.Fatalf("batch: %v", err)
+	}
+	if !strings.Contains(sent, "GET People('russellwhyte') HTTP/1.1") || !strings.Contains(sent, "Content-Id: 1") {
+		t.Fatalf("batch body = %s", sent)
+	}
+	if !strings.Contains(stdout.String(), `"status": 200`) || !strings.Contains(stdout.String(), "Russell") {
+		t.Fatalf("stdout = %s", stdout.String())
+	}
+}
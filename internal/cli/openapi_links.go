@@ -0,0 +1,505 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pb33f/libopenapi/arazzo/expression"
+	"github.com/spf13/cobra"
+
+	"github.com/rest-sh/restish/v2/config"
+	"github.com/rest-sh/restish/v2/internal/output"
+	"github.com/rest-sh/restish/v2/internal/spec"
+)
+
+// operationFollow carries the OpenAPI links a generated command follows
+// after its own response, in the order given by --rsh-follow.
+type operationFollow struct {
+	apiName string
+	op      spec.Operation
+	names   []string
+}
+
+// addOperationFollowFlag registers --rsh-follow on a generated command whose
+// responses declare links.
+func addOperationFollowFlag(cmd *cobra.Command, links []spec.OperationLink) {
+	if len(links) == 0 {
+		return
+	}
+	cmd.Flags().StringArray("rsh-follow", nil, "Follow a response link to its target operation (repeatable to chain links)")
+	names := operationLinkNames(links)
+	_ = cmd.RegisterFlagCompletionFunc("rsh-follow", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return names, cobra.ShellCompDirectiveNoFileComp
+	})
+}
+
+// operationFollowFromFlags returns the links requested with --rsh-follow, or
+// nil when the flag is absent or unset.
+func operationFollowFromFlags(cmd *cobra.Command, apiName string, op spec.Operation) (*operationFollow, error) {
+	if cmd.Flags().Lookup("rsh-follow") == nil {
+		return nil, nil
+	}
+	names, err := cmd.Flags().GetStringArray("rsh-follow")
+	if err != nil || len(names) == 0 {
+		return nil, err
+	}
+	if _, ok := findOperationLink(op.Links, names[0]); !ok {
+		return nil, unknownOperationLinkError(op, names[0])
+	}
+	return &operationFollow{apiName: apiName, op: op, names: names}, nil
+}
+
+// followOperationLinks follows each requested link from resp in turn and
+// prints only the last response. An error status ends the chain early and
+// that response is printed instead.
+func (c *CLI) followOperationLinks(cmd *cobra.Command, resp *output.Response, prepared *preparedRequest, follow *operationFollow) error {
+	ctx := requestContext(cmd)
+	profile := c.profileFromCmd(cmd)
+	opts, err := c.httpOptsFromFlags(cmd)
+	if err != nil {
+		return err
+	}
+	authOpts, err := c.authHandlerOptionsFromCmd(cmd)
+	if err != nil {
+		return err
+	}
+	ops, err := c.apiOperations(cmd, follow.apiName, profile)
+	if err != nil {
+		return err
+	}
+
+	op := follow.op
+	for _, name := range follow.names {
+		if resp.Status >= 400 {
+			break
+		}
+		link, ok := findOperationLink(op.Links, name)
+		if !ok {
+			return unknownOperationLinkError(op, name)
+		}
+		if !operationLinkAppliesToStatus(link, resp.Status) {
+			return fmt.Errorf("link %q is declared for responses %s, but the response status was %d", name, strings.Join(link.Codes, ", "), resp.Status)
+		}
+		target, err := resolveOperationLinkTarget(ops, link)
+		if err != nil {
+			return fmt.Errorf("link %q: %w", name, err)
+		}
+		args, body, err := newLinkExpressionContext(op, prepared, resp).linkArguments(link)
+		if err != nil {
+			return fmt.Errorf("link %q: %w", name, err)
+		}
+		next, nextPrepared, err := c.sendOperation(ctx, cmd, follow.apiName, profile, opts, authOpts, target, args, body, "")
+		if err != nil {
+			return fmt.Errorf("link %q: %w", name, err)
+		}
+		op, resp, prepared = target, next, nextPrepared
+	}
+
+	if err := c.formatResponse(cmd, resp, prepared); err != nil {
+		return err
+	}
+	return c.statusError(cmd, resp.Status)
+}
+
+// apiOperations loads the operations of a registered API, preferring the
+// spec cache.
+func (c *CLI) apiOperations(cmd *cobra.Command, apiName, profile string) ([]spec.Operation, error) {
+	var apiCfg *config.APIConfig
+	if c.cfg != nil {
+		apiCfg = c.cfg.APIs[apiName]
+	}
+	if apiCfg == nil {
+		return nil, fmt.Errorf("API %q is not registered", apiName)
+	}
+	set, ok := c.cachedOperationSetForAPI(requestContext(cmd), apiName, apiCfg, profile)
+	if !ok {
+		return nil, fmt.Errorf("could not load operations for API %q", apiName)
+	}
+	return set.Operations, nil
+}
+
+func findOperationLink(links []spec.OperationLink, name string) (spec.OperationLink, bool) {
+	for _, link := range links {
+		if link.Name == name {
+			return link, true
+		}
+	}
+	return spec.OperationLink{}, false
+}
+
+func operationLinkNames(links []spec.OperationLink) []string {
+	names := make([]string, 0, len(links))
+	for _, link := range links {
+		names = append(names, link.Name)
+	}
+	return names
+}
+
+func unknownOperationLinkError(op spec.Operation, name string) error {
+	if len(op.Links) == 0 {
+		return fmt.Errorf("operation %q declares no links to follow with %q", operationDisplayID(op), name)
+	}
+	return fmt.Errorf("operation %q has no link %q; available: %s", operationDisplayID(op), name, strings.Join(operationLinkNames(op.Links), ", "))
+}
+
+// operationLinkAppliesToStatus reports whether one of the responses that
+// declares link matches status, including range codes such as "2XX".
+func operationLinkAppliesToStatus(link spec.OperationLink, status int) bool {
+	code := strconv.Itoa(status)
+	for _, declared := range link.Codes {
+		switch {
+		case declared == code, declared == "default":
+			return true
+		case len(declared) == 3 && strings.EqualFold(declared[1:], "XX") && declared[0] == code[0]:
+			return true
+		}
+	}
+	return false
+}
+
+// resolveOperationLinkTarget finds the operation a link points at, by
+// operationId or by a local operationRef such as "#/paths/~1users~1{id}/get".
+func resolveOperationLinkTarget(ops []spec.Operation, link spec.OperationLink) (spec.Operation, error) {
+	if link.OperationID != "" {
+		for _, op := range ops {
+			if op.ID == link.OperationID {
+				return op, nil
+			}
+		}
+		return spec.Operation{}, fmt.Errorf("target operation %q not found", link.OperationID)
+	}
+	ref := link.OperationRef
+	if ref == "" {
+		return spec.Operation{}, fmt.Errorf("no operationId or operationRef")
+	}
+	if !strings.HasPrefix(ref, "#/paths/") {
+		return spec.Operation{}, fmt.Errorf("operationRef %q points outside this API description", ref)
+	}
+	parts := strings.Split(strings.TrimPrefix(ref, "#/paths/"), "/")
+	if len(parts) != 2 {
+		return spec.Operation{}, fmt.Errorf("operationRef %q is not a path operation", ref)
+	}
+	path := expression.UnescapeJSONPointer(parts[0])
+	if unescaped, err := url.PathUnescape(path); err == nil {
+		path = unescaped
+	}
+	method := strings.ToUpper(parts[1])
+	var suffixMatch *spec.Operation
+	for i, op := range ops {
+		if op.Method != method {
+			continue
+		}
+		if op.Path == path {
+			return op, nil
+		}
+		// Operation paths include any server base path, so the spec path
+		// may only match as a suffix.
+		if suffixMatch == nil && strings.HasSuffix(op.Path, path) {
+			suffixMatch = &ops[i]
+		}
+	}
+	if suffixMatch != nil {
+		return *suffixMatch, nil
+	}
+	return spec.Operation{}, fmt.Errorf("target operation %s %s not found", method, path)
+}
+
+// linkExpressionContext holds the request and response values that link
+// runtime expressions such as "$response.body#/id" read from.
+type linkExpressionContext struct {
+	url             string
+	method          string
+	status          int
+	requestHeaders  http.Header
+	requestQuery    url.Values
+	requestPath     map[string]string
+	requestBody     any
+	responseHeaders map[string][]string
+	responseBody    any
+}
+
+func newLinkExpressionContext(op spec.Operation, prepared *preparedRequest, resp *output.Response) *linkExpressionContext {
+	x := &linkExpressionContext{
+		method:          op.Method,
+		status:          resp.Status,
+		requestHeaders:  http.Header{},
+		responseHeaders: resp.Headers,
+		responseBody:    resp.Body,
+	}
+	if prepared != nil {
+		x.url = prepared.rawURL
+		if prepared.actualRequest != nil {
+			x.url = prepared.actualRequest.URL.String()
+			x.requestHeaders = prepared.actualRequest.Header
+		}
+		if len(prepared.bodyRaw) > 0 {
+			if err := json.Unmarshal(prepared.bodyRaw, &x.requestBody); err != nil {
+				x.requestBody = string(prepared.bodyRaw)
+			}
+		}
+	}
+	if u, err := url.Parse(x.url); err == nil {
+		x.requestQuery = u.Query()
+		x.requestPath = operationPathParams(op.Path, u.EscapedPath())
+	}
+	return x
+}
+
+// linkArguments evaluates a link's parameters and request body. Parameter
+// names may be qualified by location, as in "path.id".
+func (x *linkExpressionContext) linkArguments(link spec.OperationLink) ([]operationArg, any, error) {
+	args := make([]operationArg, 0, len(link.Parameters))
+	for _, p := range link.Parameters {
+		value, err := x.evaluate(p.Value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("parameter %s: %w", p.Name, err)
+		}
+		arg := operationArg{name: p.Name, value: value}
+		if in, name, ok := strings.Cut(p.Name, "."); ok {
+			switch in {
+			case "path", "query", "header", "cookie":
+				arg.in, arg.name = in, name
+			}
+		}
+		args = append(args, arg)
+	}
+	if link.RequestBody == "" {
+		return args, nil, nil
+	}
+	body, err := x.evaluate(link.RequestBody)
+	if err != nil {
+		return nil, nil, fmt.Errorf("request body: %w", err)
+	}
+	if s, ok := body.(string); ok && !strings.HasPrefix(strings.TrimSpace(link.RequestBody), "$") {
+		// A constant body is written as JSON in the description.
+		var decoded any
+		if json.Unmarshal([]byte(s), &decoded) == nil {
+			body = decoded
+		}
+	}
+	return args, body, nil
+}
+
+// evaluate resolves a link value: a whole runtime expression keeps the
+// referenced value's type, a string with embedded "{$...}" expressions is
+// interpolated, and anything else is a constant.
+func (x *linkExpressionContext) evaluate(value string) (any, error) {
+	if strings.HasPrefix(value, "$") {
+		expr, err := expression.Parse(value)
+		if err != nil {
+			return nil, err
+		}
+		return x.evaluateExpression(expr)
+	}
+	if !strings.Contains(value, "{$") {
+		return value, nil
+	}
+	tokens, err := expression.ParseEmbedded(value)
+	if err != nil {
+		return nil, err
+	}
+	var b strings.Builder
+	for _, token := range tokens {
+		if !token.IsExpression {
+			b.WriteString(token.Literal)
+			continue
+		}
+		v, err := x.evaluateExpression(token.Expression)
+		if err != nil {
+			return nil, err
+		}
+		if s, ok := v.(string); ok {
+			b.WriteString(s)
+			continue
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(data)
+	}
+	return b.String(), nil
+}
+
+func (x *linkExpressionContext) evaluateExpression(expr expression.Expression) (any, error) {
+	switch expr.Type {
+	case expression.URL:
+		return x.url, nil
+	case expression.Method:
+		return x.method, nil
+	case expression.StatusCode:
+		return x.status, nil
+	case expression.RequestHeader:
+		if values := x.requestHeaders.Values(expr.Property); len(values) > 0 {
+			return values[0], nil
+		}
+		return nil, fmt.Errorf("request header %q not found", expr.Property)
+	case expression.RequestQuery:
+		if values, ok := x.requestQuery[expr.Property]; ok && len(values) > 0 {
+			return values[0], nil
+		}
+		return nil, fmt.Errorf("request query parameter %q not found", expr.Property)
+	case expression.RequestPath:
+		if v, ok := x.requestPath[expr.Property]; ok {
+			return v, nil
+		}
+		return nil, fmt.Errorf("request path parameter %q not found", expr.Property)
+	case expression.RequestBody:
+		if x.requestBody == nil {
+			return nil, fmt.Errorf("%s: request has no body", expr.Raw)
+		}
+		return jsonPointerValue(x.requestBody, expr.JSONPointer)
+	case expression.ResponseHeader:
+		if v := output.HeaderValues(x.responseHeaders, expr.Property); len(v) > 0 {
+			return v[0], nil
+		}
+		return nil, fmt.Errorf("response header %q not found", expr.Property)
+	case expression.ResponseBody:
+		if x.responseBody == nil {
+			return nil, fmt.Errorf("%s: response has no body", expr.Raw)
+		}
+		return jsonPointerValue(x.responseBody, expr.JSONPointer)
+	}
+	return nil, fmt.Errorf("%s is not available in links", expr.Raw)
+}
+
+// jsonPointerValue resolves an RFC 6901 pointer, such as "/items/0/id",
+// against a decoded body.
+func jsonPointerValue(v any, pointer string) (any, error) {
+	if pointer == "" {
+		return v, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("JSON pointer %q must start with /", pointer)
+	}
+	current := v
+	for _, segment := range strings.Split(pointer[1:], "/") {
+		segment = expression.UnescapeJSONPointer(segment)
+		switch node := current.(type) {
+		case map[string]any:
+			next, ok := node[segment]
+			if !ok {
+				return nil, fmt.Errorf("JSON pointer %q: %q not found", pointer, segment)
+			}
+			current = next
+		case []any:
+			i, err := strconv.Atoi(segment)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, fmt.Errorf("JSON pointer %q: index %q out of range", pointer, segment)
+			}
+			current = node[i]
+		default:
+			return nil, fmt.Errorf("JSON pointer %q: cannot index into %T", pointer, current)
+		}
+	}
+	return current, nil
+}
+
+// operationPathParams extracts path parameter values by aligning the
+// operation's path template with the end of requestPath, which may carry an
+// extra base path.
+func operationPathParams(template, requestPath string) map[string]string {
+	params := map[string]string{}
+	templateSegments := splitCleanPath(template)
+	requestSegments := splitCleanPath(requestPath)
+	offset := len(requestSegments) - len(templateSegments)
+	if offset < 0 {
+		return params
+	}
+	for i, segment := range templateSegments {
+		open := strings.Index(segment, "{")
+		end := strings.LastIndex(segment, "}")
+		if open < 0 || end < open {
+			continue
+		}
+		prefix, suffix := segment[:open], segment[end+1:]
+		value := requestSegments[offset+i]
+		if !strings.HasPrefix(value, prefix) || !strings.HasSuffix(value, suffix) || len(value) < len(prefix)+len(suffix) {
+			continue
+		}
+		value = value[len(prefix) : len(value)-len(suffix)]
+		if unescaped, err := url.PathUnescape(value); err == nil {
+			value = unescaped
+		}
+		params[segment[open+1:end]] = value
+	}
+	return params
+}
+
+// operationLinkRelations resolves the links of the operation that produced
+// resp into URLs, keyed by link name, for "restish links". Only links to GET
+// operations whose parameters all resolve are included.
+func (c *CLI) operationLinkRelations(cmd *cobra.Command, method string, resp *output.Response, prepared *preparedRequest) map[string]string {
+	if prepared == nil || prepared.apiName == "" {
+		return nil
+	}
+	profile := c.profileFromCmd(cmd)
+	op, ok := c.operationForGenericRequest(requestContext(cmd), method, prepared.rawURL, prepared.apiName, profile)
+	if !ok || len(op.Links) == 0 {
+		return nil
+	}
+	ops, err := c.apiOperations(cmd, prepared.apiName, profile)
+	if err != nil {
+		return nil
+	}
+	x := newLinkExpressionContext(op, prepared, resp)
+	relations := map[string]string{}
+	for _, link := range op.Links {
+		if !operationLinkAppliesToStatus(link, resp.Status) {
+			continue
+		}
+		target, err := resolveOperationLinkTarget(ops, link)
+		if err != nil || target.Method != http.MethodGet {
+			continue
+		}
+		args, _, err := x.linkArguments(link)
+		if err != nil {
+			continue
+		}
+		rawURL, _, err := c.operationRequestURL(cmd, prepared.apiName, target, args)
+		if err != nil {
+			continue
+		}
+		// Expand API short names so relations are usable outside Restish.
+		if match, ok, err := c.matchAPIProfile(rawURL, profile); err == nil && ok {
+			rawURL = match.rawURL
+		}
+		relations[link.Name] = rawURL
+	}
+	return relations
+}
+
+// appendOperationLinksHelp adds a Links section describing where a
+// command's response can lead.
+func appendOperationLinksHelp(long string, links []spec.OperationLink) string {
+	if len(links) == 0 {
+		return long
+	}
+	var b strings.Builder
+	if strings.TrimSpace(long) != "" {
+		b.WriteString(strings.TrimRight(long, "\n"))
+		b.WriteString("\n\n")
+	}
+	b.WriteString("Links (follow with --rsh-follow NAME):\n")
+	for _, link := range links {
+		target := link.OperationID
+		if target == "" {
+			target = link.OperationRef
+		}
+		fmt.Fprintf(&b, "  %s (%s) -> %s\n", link.Name, strings.Join(link.Codes, ", "), target)
+		if description := strings.TrimSpace(link.Description); description != "" {
+			fmt.Fprintf(&b, "    %s\n", strings.ReplaceAll(description, "\n", "\n    "))
+		}
+		for _, p := range link.Parameters {
+			fmt.Fprintf(&b, "    %s = %s\n", p.Name, p.Value)
+		}
+		if link.RequestBody != "" {
+			fmt.Fprintf(&b, "    body = %s\n", link.RequestBody)
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
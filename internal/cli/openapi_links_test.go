@@ -0,0 +1,162 @@
+package cli_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/rest-sh/restish/v2/internal/cli"
+)
+
+const openAPILinksTestSpec = `openapi: 3.1.0
+info: {title: Users, version: 1.0.0}
+paths:
+  /users:
+    post:
+      operationId: createUser
+      requestBody:
+        content:
+          application/json:
+            schema: {type: object}
+      responses:
+        "201":
+          description: created
+          links:
+            GetUserByUserId:
+              operationId: getUser
+              description: Fetch the new user.
+              parameters:
+                path.id: $response.body#/id
+  /users/{id}:
+    get:
+      operationId: getUser
+      parameters:
+        - {name: id, in: path, required: true, schema: {type: string}}
+      responses:
+        "200":
+          description: ok
+          links:
+            UserOrg:
+              operationRef: "#/paths/~1orgs~1{orgId}/get"
+              parameters:
+                orgId: $response.body#/org
+                via: user-{$request.path.id}
+  /orgs/{orgId}:
+    get:
+      operationId: getOrg
+      parameters:
+        - {name: orgId, in: path, required: true, schema: {type: string}}
+        - {name: via, in: query, schema: {type: string}}
+      responses:
+        "200": {description: ok}
+`
+
+// newOpenAPILinksTestCLI registers the users API from a spec whose responses
+// declare links.
+func newOpenAPILinksTestCLI(t *testing.T) (*cli.CLI, *bytes.Buffer, *bytes.Buffer) {
+	t.Helper()
+	c, stdout, stderr, _ := newSpecFileTestCLI(t, "users", "https://api.example.com", "openapi.yaml", openAPILinksTestSpec, "")
+	return c, stdout, stderr
+}
+
+// openAPILinksTransport serves the users API and records each request.
+func openAPILinksTransport(t *testing.T, sent *[]string) func(*http.Request) (*http.Response, error) {
+	return func(r *http.Request) (*http.Response, error) {
+		*sent = append(*sent, r.Method+" "+r.URL.String())
+		switch r.Method + " " + r.URL.Path {
+		case "POST /users":
+			var body map[string]any
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			if body["name"] != "Ada" {
+				t.Errorf("create body = %v", body)
+			}
+			return jsonResponse(http.StatusCreated, `{"id":"u1"}`), nil
+		case "GET /users/u1":
+			return jsonResponse(http.StatusOK, `{"id":"u1","org":"o9"}`), nil
+		case "GET /orgs/o9":
+			return jsonResponse(http.StatusOK, `{"name":"Acme"}`), nil
+		}
+		return jsonResponse(http.StatusNotFound, `{}`), nil
+	}
+}
+
+func TestGeneratedOperationFollowsLinkChain(t *testing.T) {
+	c, stdout, _ := newOpenAPILinksTestCLI(t)
+	var sent []string
+	useTransport(c, openAPILinksTransport(t, &sent))
+
+	args := []string{"restish", "users", "create-user", "name: Ada", "--rsh-follow", "GetUserByUserId", "--rsh-follow", "UserOrg", "-f", "body.name"}
+	if err := c.Run(args); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	want := []string{
+		"POST https://api.example.com/users",
+		"GET https://api.example.com/users/u1",
+		"GET https://api.example.com/orgs/o9?via=user-u1",
+	}
+	if strings.Join(sent, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("requests = %q", sent)
+	}
+	if stdout.String() != "Acme\n" {
+		t.Fatalf("stdout = %q, want only the last response", stdout.String())
+	}
+}
+
+func TestGeneratedOperationFollowStopsAtErrorStatus(t *testing.T) {
+	c, stdout, _ := newOpenAPILinksTestCLI(t)
+	var sent []string
+	useTransport(c, func(r *http.Request) (*http.Response, error) {
+		sent = append(sent, r.Method+" "+r.URL.Path)
+		return jsonResponse(http.StatusConflict, `{"title":"exists"}`), nil
+	})
+
+	err := c.Run([]string{"restish", "users", "create-user", "name: Ada", "--rsh-follow", "GetUserByUserId", "-f", "body.title"})
+	var exitErr *cli.ExitCodeError
+	if !errors.As(err, &exitErr) || len(sent) != 1 || stdout.String() != "exists\n" {
+		t.Fatalf("err = %v, sent = %q, stdout = %q", err, sent, stdout.String())
+	}
+
+	err = c.Run([]string{"restish", "users", "create-user", "name: Ada", "--rsh-follow", "GetUser"})
+	if err == nil || !strings.Contains(err.Error(), `has no link "GetUser"; available: GetUserByUserId`) {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestGeneratedOperationHelpListsLinks(t *testing.T) {
+	c, stdout, _ := newOpenAPILinksTestCLI(t)
+
+	if err := c.Run([]string{"restish", "users", "create-user", "--help"}); err != nil {
+		t.Fatalf("help: %v", err)
+	}
+	for _, want := range []string{
+		"Links (follow with --rsh-follow NAME):",
+		"GetUserByUserId (201) -> getUser",
+		"Fetch the new user.",
+		"path.id = $response.body#/id",
+		"--rsh-follow",
+	} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("help missing %q:\n%s", want, stdout.String())
+		}
+	}
+}
+
+func TestLinksCommandIncludesOpenAPILinks(t *testing.T) {
+	c, stdout, _ := newOpenAPILinksTestCLI(t)
+	var sent []string
+	useTransport(c, openAPILinksTransport(t, &sent))
+
+	if err := c.Run([]string{"restish", "links", "users/users/u1"}); err != nil {
+		t.Fatalf("links: %v", err)
+	}
+	var links map[string]string
+	if err := json.Unmarshal(stdout.Bytes(), &links); err != nil {
+		t.Fatalf("decode %q: %v", stdout.String(), err)
+	}
+	if links["UserOrg"] != "https://api.example.com/orgs/o9?via=user-u1" || len(sent) != 1 {
+		t.Fatalf("links = %v, sent = %q", links, sent)
+	}
+}
@@ -60,13 +60,13 @@ func (c *CLI) planOperationAuth(apiName, profileName string, prof *config.Profil
 				missing = append(missing, requirement.ID)
 				continue
 			}
-			credential := prof.Credentials[requirement.ID]
+			credentialID, credential := requirementCredential(prof, requirement)
 			if credential == nil {
 				alternativeMissing = true
 				missing = append(missing, requirement.ID)
 				continue
 			}
-			resolved, err := c.resolveCredentialAuth(apiName, profileName, requirement.ID, credential)
+			resolved, err := c.resolveCredentialAuth(apiName, profileName, credentialID, credential)
 			if err != nil {
 				return nil, false, err
 			}
@@ -222,6 +222,21 @@ func operationAuthConfiguredOverrideHint(prof *config.ProfileConfig, alternative
 	}
 }
 
+// requirementCredential returns the profile credential for an operation
+// requirement. Credentials are keyed by the requirement ID; a URI-referenced
+// security scheme may also be configured under its full URI.
+func requirementCredential(prof *config.ProfileConfig, requirement spec.CredentialRequirement) (string, *config.CredentialConfig) {
+	if credential := prof.Credentials[requirement.ID]; credential != nil {
+		return requirement.ID, credential
+	}
+	if requirement.External && requirement.Ref != "" {
+		if credential := prof.Credentials[requirement.Ref]; credential != nil {
+			return requirement.Ref, credential
+		}
+	}
+	return requirement.ID, nil
+}
+
 func (c *CLI) selectOperationAlternative(apiName, profileName string, prof *config.ProfileConfig, alternative spec.CredentialAlternative, transport request.Options) ([]selectedOperationAuth, []string, []string, error) {
 	selected := make([]selectedOperationAuth, 0, len(alternative))
 	var missing []string
@@ -237,12 +252,12 @@ func (c *CLI) selectOperationAlternative(apiName, profileName string, prof *conf
 			missing = append(missing, requirement.ID)
 			continue
 		}
-		credential := prof.Credentials[requirement.ID]
+		credentialID, credential := requirementCredential(prof, requirement)
 		if credential == nil {
 			missing = append(missing, requirement.ID)
 			continue
 		}
-		resolved, err := c.resolveCredentialAuth(apiName, profileName, requirement.ID, credential)
+		resolved, err := c.resolveCredentialAuth(apiName, profileName, credentialID, credential)
 		if err != nil {
 			return nil, nil, nil, err
 		}
@@ -404,7 +419,7 @@ func (c *CLI) operationAuthStep(apiName, profileName string, selected selectedOp
 }
 
 func (c *CLI) applyOperationAuthStep(req *http.Request, s operationAuthStep, force bool) error {
-	if c.applyCachedOAuthClientCredentials(req, s.authType, s.cacheKey, s.apiName, s.profileName, force) {
+	if c.applyCachedOAuthClientGrant(req, s.authType, s.cacheKey, s.apiName, s.profileName, force) {
 		return nil
 	}
 	params, err := c.buildAuthParams(s.rawParams)
@@ -420,7 +435,8 @@ func (c *CLI) applyOperationAuthStep(req *http.Request, s operationAuthStep, for
 		return err
 	}
 	preserveInsertedHeader := c.apiPreservesHeaderCase(s.apiName) && !authHeaderPresent(req.Header, s.authType, params)
-	if err := s.handler.Authenticate(req.Context(), req, c.authContext(req.Context(), s.apiName, s.profileName, params, s.cacheKey, force)); err != nil {
+	authCtx, ac := c.authContext(req.Context(), s.apiName, s.profileName, params, s.cacheKey, force)
+	if err := s.handler.Authenticate(authCtx, req, ac); err != nil {
 		return err
 	}
 	if preserveInsertedHeader {
@@ -583,8 +599,18 @@ func authMutationKey(ac *config.AuthConfig) string {
 		default:
 			return ""
 		}
-	case "bearer", "http-basic", "oauth-client-credentials", "oauth-authorization-code", "oauth-device-code":
+	case "bearer", "http-basic", "http-digest", "oauth-client-credentials", "oauth-token-exchange", "oauth-jwt-bearer", "oauth-authorization-code", "oauth-device-code":
 		return "header:authorization"
+	case "http-signature":
+		return "header:signature"
+	case "hmac":
+		if name := strings.ToLower(ac.Params["signature_header"]); name != "" {
+			return "header:" + name
+		}
+		if name := strings.ToLower(ac.Params["signature_query"]); name != "" {
+			return "query:" + name
+		}
+		return ""
 	case "external-tool":
 		// External tools may return complete header/query mutations, so the
 		// mutation target is not knowable from config alone.
@@ -16,20 +16,41 @@ type genericOperationAuthMatch struct {
 }
 
 func (c *CLI) operationAuthForGenericRequest(ctx context.Context, method, rawURL, apiName, profileName string) (genericOperationAuthMatch, bool) {
-	if method == "" || c.cfg == nil || c.cfg.APIs == nil {
+	best, ok := c.operationForGenericRequest(ctx, method, rawURL, apiName, profileName)
+	if !ok {
 		return genericOperationAuthMatch{}, false
 	}
+	if best.NoAuth {
+		return genericOperationAuthMatch{noAuth: true}, true
+	}
+	if len(best.CredentialAlternatives) == 0 && !best.OptionalAuth {
+		return genericOperationAuthMatch{}, false
+	}
+	return genericOperationAuthMatch{
+		policy: &operationAuthPolicy{
+			OptionalAuth:           best.OptionalAuth,
+			CredentialAlternatives: best.CredentialAlternatives,
+		},
+	}, true
+}
+
+// operationForGenericRequest finds the cached operation of apiName whose
+// route best matches method and rawURL. Ambiguous matches report false.
+func (c *CLI) operationForGenericRequest(ctx context.Context, method, rawURL, apiName, profileName string) (spec.Operation, bool) {
+	if method == "" || c.cfg == nil || c.cfg.APIs == nil {
+		return spec.Operation{}, false
+	}
 	apiCfg := c.cfg.APIs[apiName]
 	if apiCfg == nil {
-		return genericOperationAuthMatch{}, false
+		return spec.Operation{}, false
 	}
 	set, ok := c.cachedOperationSetForAPI(ctx, apiName, apiCfg, profileName)
 	if !ok || len(set.Operations) == 0 {
-		return genericOperationAuthMatch{}, false
+		return spec.Operation{}, false
 	}
 	requestPath, ok := requestURLPath(rawURL)
 	if !ok {
-		return genericOperationAuthMatch{}, false
+		return spec.Operation{}, false
 	}
 
 	method = strings.ToUpper(method)
@@ -57,20 +78,9 @@ func (c *CLI) operationAuthForGenericRequest(ctx context.Context, method, rawURL
 		ambiguous = false
 	}
 	if bestScore < 0 || ambiguous {
-		return genericOperationAuthMatch{}, false
+		return spec.Operation{}, false
 	}
-	if best.NoAuth {
-		return genericOperationAuthMatch{noAuth: true}, true
-	}
-	if len(best.CredentialAlternatives) == 0 && !best.OptionalAuth {
-		return genericOperationAuthMatch{}, false
-	}
-	return genericOperationAuthMatch{
-		policy: &operationAuthPolicy{
-			OptionalAuth:           best.OptionalAuth,
-			CredentialAlternatives: best.CredentialAlternatives,
-		},
-	}, true
+	return best, true
 }
 
 func requestURLPath(rawURL string) (string, bool) {
@@ -1,11 +1,13 @@
 package cli
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
@@ -35,6 +37,7 @@ func (c *CLI) tryPaginate(
 	}
 
 	c.ensureBodyLinks(firstResp)
+	pagCfg = odataPaginationConfig(pagCfg, firstResp.Body)
 	nextURL, err := resolveNextURL(firstResp, pagCfg, firstURL)
 	if err != nil {
 		return false, err
@@ -71,7 +74,7 @@ func (c *CLI) tryPaginate(
 	maxPages := gfPag.MaxPages
 	maxItems := gfPag.MaxItems
 
-	return true, c.runPagination(cmd, firstResp, firstURL, nextURL, opts, pagCfg, collect, maxPages, maxItems, prepared, mode)
+	return true, c.runPagination(cmd, firstResp, firstURL, nextURL, opts, pagCfg, collect, maxPages, maxItems, prepared, mode, nil)
 }
 
 type paginationNextMode int
@@ -79,6 +82,9 @@ type paginationNextMode int
 const (
 	paginationNextLink paginationNextMode = iota
 	paginationNextPageParam
+	// paginationNextGraphQLCursor resends graphQL with the next cursor as
+	// $after; the "next URL" passed through the loop is that cursor.
+	paginationNextGraphQLCursor
 )
 
 // runPagination drives the pagination loop starting from firstResp.
@@ -93,6 +99,7 @@ func (c *CLI) runPagination(
 	maxPages, maxItems int,
 	prepared *preparedRequest,
 	nextMode paginationNextMode,
+	graphQL *graphQLRequest,
 ) (retErr error) {
 	ctx := requestContext(cmd)
 	if err := c.paginationStatusError(cmd, 1, firstResp.Status); err != nil {
@@ -169,6 +176,8 @@ func (c *CLI) runPagination(
 	nextURL := firstNextURL
 	page := 1
 	visited := map[string]int{firstURL: 1}
+	visitedCursors := map[string]int{}
+	var pageErr error
 
 	for !done && nextURL != "" {
 		currentNextMode := nextMode
@@ -180,22 +189,43 @@ func (c *CLI) runPagination(
 			c.warnf("pagination stopped at --rsh-max-pages=%d; pass 0 for unlimited", maxPages)
 			break
 		}
-		if seenPage, ok := visited[nextURL]; ok {
-			c.warnf("pagination cycle detected at page %d URL %q; stopping", seenPage, nextURL)
-			break
-		}
-		if crosses, displayURL, reason := paginationCrossesOrigin(firstURL, nextURL); crosses {
-			c.warnf("pagination next URL %s; stopping before %q", reason, displayURL)
-			break
+		if currentNextMode == paginationNextGraphQLCursor {
+			if seenPage, ok := visitedCursors[nextURL]; ok {
+				c.warnf("pagination cycle detected at page %d cursor %q; stopping", seenPage, nextURL)
+				break
+			}
+		} else {
+			if seenPage, ok := visited[nextURL]; ok {
+				c.warnf("pagination cycle detected at page %d URL %q; stopping", seenPage, nextURL)
+				break
+			}
+			if crosses, displayURL, reason := paginationCrossesOrigin(firstURL, nextURL); crosses {
+				c.warnf("pagination next URL %s; stopping before %q", reason, displayURL)
+				break
+			}
 		}
 		page++
-		visited[nextURL] = page
 
 		pageOpts := opts
 		pageOpts.Query = nil
-		httpResp, err := request.Do(ctx, "GET", nextURL, nil, pageOpts)
-		if err != nil {
-			return fmt.Errorf("paginate page %d: %w", page, err)
+		var httpResp *http.Response
+		if currentNextMode == paginationNextGraphQLCursor {
+			visitedCursors[nextURL] = page
+			body, err := json.Marshal(graphQL.body(nextURL))
+			if err != nil {
+				return err
+			}
+			pageOpts.ContentType = "application/json"
+			httpResp, err = request.Do(ctx, http.MethodPost, firstURL, bytes.NewReader(body), pageOpts)
+			if err != nil {
+				return fmt.Errorf("paginate page %d: %w", page, err)
+			}
+		} else {
+			visited[nextURL] = page
+			httpResp, err = request.Do(ctx, "GET", nextURL, nil, pageOpts)
+			if err != nil {
+				return fmt.Errorf("paginate page %d: %w", page, err)
+			}
 		}
 		if currentNextMode == paginationNextPageParam && output.StatusToExitCode(httpResp.StatusCode) != 0 {
 			status := httpResp.StatusCode
@@ -208,12 +238,18 @@ func (c *CLI) runPagination(
 		if err != nil {
 			return fmt.Errorf("paginate page %d normalize: %w", page, err)
 		}
+		c.normalizeResponseBody(resp, prepared)
 		if v := globalFlagsFromContext(requestContext(cmd)).Verbose; v >= 1 {
 			c.logVerboseResponseBody(resp)
 		}
 		if err := c.paginationStatusError(cmd, page, resp.Status); err != nil {
 			return err
 		}
+		if currentNextMode == paginationNextGraphQLCursor && len(graphQLErrorMessages(resp.Body)) > 0 {
+			c.warnf("pagination page %d returned GraphQL errors; stopping", page)
+			pageErr = c.graphQLErrorsError(cmd, resp.Body)
+			break
+		}
 
 		items, filterErr = pageItems(resp.Body, pagCfg)
 		if filterErr != nil {
@@ -260,6 +296,8 @@ func (c *CLI) runPagination(
 				continue
 			}
 			nextURL, err = nextPageParamURL(nextURL, pagCfg.PageParam)
+		} else if currentNextMode == paginationNextGraphQLCursor {
+			nextURL = nextGraphQLCursor(resp.Body, pagCfg.ItemsPath)
 		} else {
 			nextURL, err = resolveNextURL(resp, pagCfg, nextURL)
 		}
@@ -274,15 +312,20 @@ func (c *CLI) runPagination(
 
 	if collect || !streamItems {
 		if perItemFilter {
-			return c.renderPaginatedFilteredItems(cmd, firstResp, allItems)
+			if err := c.renderPaginatedFilteredItems(cmd, firstResp, allItems); err != nil {
+				return err
+			}
+			return pageErr
 		}
 		synthetic := buildPaginatedResponse(firstResp, pagCfg, allItems)
 		if err := ctx.Err(); err != nil {
 			return err
 		}
-		return c.formatResponse(cmd, synthetic, prepared)
+		if err := c.formatResponse(cmd, synthetic, prepared); err != nil {
+			return err
+		}
 	}
-	return nil
+	return pageErr
 }
 
 func effectiveFirstURL(prepared *preparedRequest, fallback string) string {
@@ -700,6 +743,38 @@ func pageItems(body any, pagCfg *config.PaginationConfig) ([]any, error) {
 	return nil, nil
 }
 
+// odataPaginationConfig returns pagCfg with items_path "value" when body is an
+// OData collection and no items_path is configured, so pages merge their
+// value arrays rather than stacking whole page documents.
+func odataPaginationConfig(pagCfg *config.PaginationConfig, body any) *config.PaginationConfig {
+	if pagCfg != nil && pagCfg.ItemsPath != "" {
+		return pagCfg
+	}
+	m, ok := body.(map[string]any)
+	if !ok {
+		return pagCfg
+	}
+	if _, ok := m["value"].([]any); !ok {
+		return pagCfg
+	}
+	odata := false
+	for key := range m {
+		if strings.HasPrefix(key, "@odata.") || strings.HasPrefix(key, "odata.") || key == "@nextLink" || key == "@context" {
+			odata = true
+			break
+		}
+	}
+	if !odata {
+		return pagCfg
+	}
+	cfg := config.PaginationConfig{}
+	if pagCfg != nil {
+		cfg = *pagCfg
+	}
+	cfg.ItemsPath = "value"
+	return &cfg
+}
+
 // resolveNextURL returns the next-page URL from resp.Links or pagCfg.NextPath.
 func resolveNextURL(resp *output.Response, pagCfg *config.PaginationConfig, currentURL string) (string, error) {
 	// 1. Standard link relation "next".
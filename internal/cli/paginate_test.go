@@ -1495,3 +1495,39 @@ func TestPaginationLaterPageErrorCanBeIgnored(t *testing.T) {
 		t.Fatalf("expected both pages when status ignored, got %#v", values)
 	}
 }
+
+// TestPaginationODataNextLinkMergesValue verifies that OData collections
+// follow @odata.nextLink and merge each page's value array.
+func TestPaginationODataNextLinkMergesValue(t *testing.T) {
+	c, out, _ := newTestCLI(t)
+	var requests []string
+	useTransport(c, func(r *http.Request) (*http.Response, error) {
+		requests = append(requests, r.URL.RawQuery)
+		body := `{"@odata.context":"https://api.example.com/$metadata#People","value":[{"UserName":"ada"}],"@odata.nextLink":"https://api.example.com/People?$skiptoken=1"}`
+		if r.URL.Query().Get("$skiptoken") == "1" {
+			body = `{"@odata.context":"https://api.example.com/$metadata#People","value":[{"UserName":"bob"}]}`
+		}
+		return &http.Response{
+			StatusCode: 200,
+			Proto:      "HTTP/1.1",
+			Header:     http.Header{"Content-Type": []string{"application/json;odata.metadata=minimal"}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    r,
+		}, nil
+	})
+	if err := c.Run([]string{"restish", "get", "https://api.example.com/People", "--rsh-collect", "-o", "json"}); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("requests = %q, want two pages", requests)
+	}
+	var got struct {
+		Value []map[string]any `json:"value"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, out.String())
+	}
+	if len(got.Value) != 2 || got.Value[0]["UserName"] != "ada" || got.Value[1]["UserName"] != "bob" {
+		t.Fatalf("value = %#v", got.Value)
+	}
+}
@@ -74,7 +74,9 @@ func (c *CLI) autoPrintSpec(gf GlobalFlags, tty bool, kind printResponseKind) pr
 			color:  output.ColorEnabled(c.Stdout),
 		}
 	}
-	if kind != printValueResponse && untransformedRedirectOutput(gf) {
+	// Reconnected streams are rendered event by event so replayed events can be
+	// skipped; the raw body of each connection would repeat them.
+	if kind != printValueResponse && untransformedRedirectOutput(gf) && (kind != printStreamResponse || !gf.Reconnect) {
 		return printSpec{order: []rune{printRawBody}}
 	}
 	return printSpec{order: []rune{printRenderedBody}, pretty: true}
@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	authpkg "github.com/rest-sh/restish/v2/internal/auth"
+)
+
+type profileTokenContextKey struct{}
+
+// profileToken returns a token issued to another configured API profile, for
+// oauth-token-exchange subject and actor tokens and oauth-jwt-bearer
+// assertions. ref is "api" or "api:profile". The profile's own auth runs as
+// it would for a request to that API, so cached tokens are reused, refreshed,
+// or obtained interactively as usual. ID tokens come from the profile's cached
+// OAuth token after that auth has run.
+func (c *CLI) profileToken(ctx context.Context, ref, tokenType string) (string, error) {
+	apiName, profileName, _ := strings.Cut(strings.TrimSpace(ref), ":")
+	if profileName == "" {
+		profileName = "default"
+	}
+	if apiName == "" {
+		return "", fmt.Errorf("profile reference must be api or api:profile")
+	}
+	key := apiName + ":" + profileName
+	visiting, _ := ctx.Value(profileTokenContextKey{}).(map[string]bool)
+	if visiting[key] {
+		return "", fmt.Errorf("profile %s refers back to itself", key)
+	}
+	next := map[string]bool{key: true}
+	for k := range visiting {
+		next[k] = true
+	}
+	ctx = context.WithValue(ctx, profileTokenContextKey{}, next)
+
+	_, prof, err := c.apiProfileForAuth(apiName, profileName, false)
+	if err != nil {
+		return "", err
+	}
+	resolved, err := c.resolveProfileAuth(apiName, profileName, prof)
+	if err != nil {
+		return "", err
+	}
+	if resolved.Config == nil {
+		return "", fmt.Errorf("profile %s has no auth configured", key)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.authBaseURL(apiName, profileName), nil)
+	if err != nil {
+		return "", err
+	}
+	gf := globalFlagsFromContext(ctx)
+	callbacks := c.authOnRequest(apiName, profileName, prof, authHandlerOptions{NoBrowser: gf.NoBrowser, Verbose: gf.Verbose > 0})
+	if err := callbacks.OnRequest(req); err != nil {
+		return "", err
+	}
+	if tokenType == authpkg.TokenTypeIDToken {
+		cached := c.cachedOAuthTokenEntry(resolved.Config.Type, resolved.CacheKey, apiName, profileName)
+		if cached == nil || cached.IDToken == "" {
+			return "", fmt.Errorf("profile %s has no cached ID token; request the openid scope", key)
+		}
+		return cached.IDToken, nil
+	}
+	scheme, token, ok := strings.Cut(req.Header.Get("Authorization"), " ")
+	if !ok || !(strings.EqualFold(scheme, "Bearer") || strings.EqualFold(scheme, "DPoP")) || strings.TrimSpace(token) == "" {
+		return "", fmt.Errorf("profile %s did not produce a bearer token", key)
+	}
+	return strings.TrimSpace(token), nil
+}
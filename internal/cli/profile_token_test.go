@@ -0,0 +1,84 @@
+package cli_test
+
+import (
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTokenExchangeUsesAnotherProfilesToken(t *testing.T) {
+	c, _, _ := newTestCLI(t)
+	var subject, apiAuth string
+	useTransport(c, func(r *http.Request) (*http.Response, error) {
+		switch r.URL.Host + r.URL.Path {
+		case "sso.example.com/token":
+			return jsonResponse(200, `{"access_token":"sso-token","token_type":"Bearer","expires_in":3600}`), nil
+		case "sts.example.com/token":
+			_ = r.ParseForm()
+			subject = r.Form.Get("subject_token")
+			return jsonResponse(200, `{"access_token":"orders-token","token_type":"N_A","issued_token_type":"urn:ietf:params:oauth:token-type:access_token","expires_in":3600}`), nil
+		}
+		apiAuth = r.Header.Get("Authorization")
+		return jsonResponse(200, `{}`), nil
+	})
+	c.Hooks().ConfigPath = writeAPIConfig(t, `{
+		"apis": {
+			"sso": {
+				"base_url": "https://sso.example.com",
+				"profiles": {"default": {"auth": {"type": "oauth-client-credentials", "params": {"client_id": "id", "client_secret": "secret", "token_url": "https://sso.example.com/token"}}}}
+			},
+			"orders": {
+				"base_url": "https://orders.example.com",
+				"profiles": {"default": {"auth": {"type": "oauth-token-exchange", "params": {"subject_token_profile": "sso", "audience": "orders", "token_url": "https://sts.example.com/token"}}}}
+			},
+			"loop": {
+				"base_url": "https://loop.example.com",
+				"profiles": {"default": {"auth": {"type": "oauth-token-exchange", "params": {"subject_token_profile": "loop:default", "token_url": "https://sts.example.com/token"}}}}
+			}
+		}
+	}`)
+
+	if err := c.Run([]string{"restish", "get", "orders/items"}); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if subject != "sso-token" || apiAuth != "Bearer orders-token" {
+		t.Fatalf("subject_token = %q, Authorization = %q", subject, apiAuth)
+	}
+
+	err := c.Run([]string{"restish", "get", "loop/items"})
+	if err == nil || !strings.Contains(err.Error(), "refers back to itself") {
+		t.Fatalf("err = %v, want a profile cycle error", err)
+	}
+}
+
+func TestJWTBearerReadsAssertionFromFile(t *testing.T) {
+	c, _, _ := newTestCLI(t)
+	assertionPath := filepath.Join(t.TempDir(), "ci-token")
+	writeTestFile(t, assertionPath, "workload.jwt.token\n")
+	var assertion, apiAuth string
+	useTransport(c, func(r *http.Request) (*http.Response, error) {
+		if r.URL.Host == "auth.example.com" {
+			_ = r.ParseForm()
+			assertion = r.Form.Get("assertion")
+			return jsonResponse(200, `{"access_token":"ci-access","token_type":"Bearer","expires_in":600}`), nil
+		}
+		apiAuth = r.Header.Get("Authorization")
+		return jsonResponse(200, `{}`), nil
+	})
+	c.Hooks().ConfigPath = writeAPIConfig(t, `{
+		"apis": {
+			"deploy": {
+				"base_url": "https://deploy.example.com",
+				"profiles": {"default": {"auth": {"type": "oauth-jwt-bearer", "params": {"assertion": {"$file": "`+filepath.ToSlash(assertionPath)+`"}, "token_url": "https://auth.example.com/token"}}}}
+			}
+		}
+	}`)
+
+	if err := c.Run([]string{"restish", "get", "deploy/items"}); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if assertion != "workload.jwt.token" || apiAuth != "Bearer ci-access" {
+		t.Fatalf("assertion = %q, Authorization = %q", assertion, apiAuth)
+	}
+}
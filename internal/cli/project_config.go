@@ -337,6 +337,9 @@ func (c *CLI) validateProjectConfigNoInlineSecrets(project *projectConfigState,
 				continue
 			}
 			path := fmt.Sprintf("apis.%s.profiles.%s", apiName, profileName)
+			if err := validateProjectProfileSecretRefs(path, prof, filepath.Dir(project.Path)); err != nil {
+				return fmt.Errorf("project config %s: %w", project.Path, err)
+			}
 			if err := c.validateProjectProfileNoInlineSecrets(path, prof); err != nil {
 				return fmt.Errorf("project config %s: %w", project.Path, err)
 			}
@@ -345,17 +348,89 @@ func (c *CLI) validateProjectConfigNoInlineSecrets(project *projectConfigState,
 	return nil
 }
 
+// validateProjectProfileSecretRefs limits secret references in a project
+// config to $env and $file paths inside the project directory. Project
+// configs arrive with a cloned repository, so they must not run commands,
+// read the keyring, or read files elsewhere on the machine.
+func validateProjectProfileSecretRefs(path string, prof *config.ProfileConfig, baseDir string) error {
+	checkParams := func(paramsPath string, params map[string]string) error {
+		keys := make([]string, 0, len(params))
+		for key := range params {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if ref, ok := config.ParseSecretRef(params[key]); ok {
+				if err := validateProjectSecretRef(ref, baseDir); err != nil {
+					return fmt.Errorf("%s.%s: %w", paramsPath, key, err)
+				}
+			}
+		}
+		return nil
+	}
+	if prof.Auth != nil {
+		if err := checkParams(path+".auth.params", prof.Auth.Params); err != nil {
+			return err
+		}
+	}
+	credentialIDs := make([]string, 0, len(prof.Credentials))
+	for id := range prof.Credentials {
+		credentialIDs = append(credentialIDs, id)
+	}
+	sort.Strings(credentialIDs)
+	for _, id := range credentialIDs {
+		if credential := prof.Credentials[id]; credential != nil && credential.Auth != nil {
+			if err := checkParams(path+".credentials."+id+".auth.params", credential.Auth.Params); err != nil {
+				return err
+			}
+		}
+	}
+	if err := checkParams(path+".tls_signer_params", prof.TLSSignerParams); err != nil {
+		return err
+	}
+	for i, header := range prof.Headers {
+		if _, ref, ok := config.SplitSecretRefHeader(header); ok {
+			if err := validateProjectSecretRef(ref, baseDir); err != nil {
+				return fmt.Errorf("%s.headers[%d]: %w", path, i, err)
+			}
+		}
+	}
+	return nil
+}
+
+func validateProjectSecretRef(ref config.SecretRef, baseDir string) error {
+	switch ref.Kind() {
+	case "$env":
+		return nil
+	case "$file":
+		if filepath.IsAbs(ref.File) || strings.HasPrefix(ref.File, "~") {
+			return fmt.Errorf("project config $file reference %q must be a path relative to the project config", ref.File)
+		}
+		base := filepath.Clean(baseDir)
+		joined := filepath.Clean(filepath.Join(base, ref.File))
+		if rel, err := filepath.Rel(base, joined); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("project config $file reference %q must stay inside the project directory", ref.File)
+		}
+		return nil
+	default:
+		return fmt.Errorf("project config cannot use %s secret references; use $env or a $file inside the project, or move the reference to your user config", ref.Kind())
+	}
+}
+
 func (c *CLI) validateProjectProfileNoInlineSecrets(path string, prof *config.ProfileConfig) error {
 	if err := c.validateProjectAuthNoInlineSecrets(path+".auth", prof.Auth); err != nil {
 		return err
 	}
 	for i, header := range prof.Headers {
+		if _, _, ok := config.SplitSecretRefHeader(header); ok {
+			continue
+		}
 		name, value, err := request.ParseHeaderOption(header)
 		if err != nil {
 			return fmt.Errorf("%s.headers[%d]: %w", path, i, err)
 		}
 		if secrets.IsHeaderName(name) || secrets.IsHeaderValue(name, value) {
-			return fmt.Errorf("%s.headers[%d]: project config cannot contain credential-bearing header %q; use auth with env:NAME secret references instead", path, i, name)
+			return fmt.Errorf("%s.headers[%d]: project config cannot contain credential-bearing header %q; use a {\"name\": ..., \"value\": {\"$env\": \"NAME\"}} secret reference instead", path, i, name)
 		}
 	}
 	for i, query := range prof.Query {
@@ -394,13 +469,16 @@ func (c *CLI) validateProjectAuthNoInlineSecrets(path string, authCfg *config.Au
 		if value == "" {
 			continue
 		}
+		if _, ok := config.ParseSecretRef(value); ok {
+			continue
+		}
 		if strings.HasPrefix(value, "env:") {
 			if strings.TrimPrefix(value, "env:") == "" {
 				return fmt.Errorf("%s.params.%s: env secret source is missing a variable name", path, param.Name)
 			}
 			continue
 		}
-		return fmt.Errorf("%s.params.%s: project config cannot contain inline secret values; use env:NAME or omit the value", path, param.Name)
+		return fmt.Errorf("%s.params.%s: project config cannot contain inline secret values; use a secret reference such as {\"$env\": \"NAME\"} or omit the value", path, param.Name)
 	}
 	return nil
 }
@@ -436,14 +514,51 @@ func resolveProjectAPIPaths(apiCfg *config.APIConfig, baseDir string) {
 	if apiCfg == nil {
 		return
 	}
-	for i, file := range apiCfg.SpecFiles {
-		if file == "" || strings.Contains(file, "://") || filepath.IsAbs(file) {
+	for _, files := range [][]string{apiCfg.SpecFiles, apiCfg.OverlayFiles} {
+		for i, file := range files {
+			if file == "" || strings.Contains(file, "://") || filepath.IsAbs(file) {
+				continue
+			}
+			files[i] = filepath.Join(baseDir, file)
+		}
+	}
+	for _, prof := range apiCfg.Profiles {
+		if prof == nil {
 			continue
 		}
-		apiCfg.SpecFiles[i] = filepath.Join(baseDir, file)
+		if prof.Auth != nil {
+			resolveProjectSecretFileRefs(prof.Auth.Params, baseDir)
+		}
+		for _, credential := range prof.Credentials {
+			if credential != nil && credential.Auth != nil {
+				resolveProjectSecretFileRefs(credential.Auth.Params, baseDir)
+			}
+		}
+		resolveProjectSecretFileRefs(prof.TLSSignerParams, baseDir)
+		for i, header := range prof.Headers {
+			if name, ref, ok := config.SplitSecretRefHeader(header); ok && projectRelativeSecretFile(ref) {
+				ref.File = filepath.Join(baseDir, ref.File)
+				prof.Headers[i] = name + ": " + ref.Encode()
+			}
+		}
 	}
 }
 
+// resolveProjectSecretFileRefs anchors relative $file references to the
+// project config directory, matching spec_files.
+func resolveProjectSecretFileRefs(params map[string]string, baseDir string) {
+	for key, value := range params {
+		if ref, ok := config.ParseSecretRef(value); ok && projectRelativeSecretFile(ref) {
+			ref.File = filepath.Join(baseDir, ref.File)
+			params[key] = ref.Encode()
+		}
+	}
+}
+
+func projectRelativeSecretFile(ref config.SecretRef) bool {
+	return ref.File != "" && !filepath.IsAbs(ref.File) && !strings.HasPrefix(ref.File, "~")
+}
+
 func mergeProjectConfig(base, project *config.Config) *config.Config {
 	if project == nil {
 		return base
@@ -283,6 +283,41 @@ func TestProjectConfigRejectsInlineSecrets(t *testing.T) {
 }`,
 			want: "credential-bearing query parameter",
 		},
+		{
+			name: "cmd secret reference",
+			body: `{
+  "apis": {
+    "svc": {
+      "base_url": "https://project.example.com",
+      "profiles": {
+        "default": {
+          "auth": {
+            "type": "api-key",
+            "params": {"in": "header", "name": "X-API-Key", "value": {"$cmd": ["sh", "-c", "touch pwned"]}}
+          }
+        }
+      }
+    }
+  }
+}`,
+			want: "auth.params.value: project config cannot use $cmd secret references",
+		},
+		{
+			name: "file secret reference outside project",
+			body: `{
+  "apis": {
+    "svc": {
+      "base_url": "https://project.example.com",
+      "profiles": {
+        "default": {
+          "headers": [{"name": "X-Key", "value": {"$file": "secrets/../../../.ssh/id_rsa"}}]
+        }
+      }
+    }
+  }
+}`,
+			want: "headers[0]: project config $file reference \"secrets/../../../.ssh/id_rsa\" must stay inside the project directory",
+		},
 	}
 
 	for _, tt := range tests {
@@ -561,3 +596,44 @@ paths:
 		t.Fatalf("project API completion = %q, want generated command from trusted project config", out.String())
 	}
 }
+
+func TestProjectConfigAllowsSecretReferenceObjects(t *testing.T) {
+	_, _, projectDir := setupProjectConfigTest(t)
+	t.Setenv("PROJECT_API_KEY", "env-key")
+	writeProjectConfigTestFileMode(t, filepath.Join(projectDir, "secrets", "token"), "Bearer project-token\n", 0o600)
+	writeProjectConfigTestFileMode(t, filepath.Join(projectDir, ".restish.json"), `{
+  "apis": {
+    "svc": {
+      "base_url": "https://project.example.com",
+      "profiles": {
+        "default": {
+          "headers": [{"name": "Authorization", "value": {"$file": "secrets/token"}}],
+          "auth": {
+            "type": "api-key",
+            "params": {"in": "header", "name": "X-API-Key", "value": {"$env": "PROJECT_API_KEY"}}
+          }
+        }
+      }
+    }
+  }
+}`, 0o644)
+	t.Chdir(projectDir)
+
+	c, _, _ := newProjectConfigTestCLI(t)
+	if err := c.Run([]string{"restish", "config", "trust"}); err != nil {
+		t.Fatalf("config trust: %v", err)
+	}
+
+	var got http.Header
+	c, _, _ = newProjectConfigTestCLI(t)
+	useTransport(c, func(r *http.Request) (*http.Response, error) {
+		got = r.Header.Clone()
+		return jsonResponse(200, `{}`), nil
+	})
+	if err := c.Run([]string{"restish", "get", "svc/items"}); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if got.Get("Authorization") != "Bearer project-token" || got.Get("X-API-Key") != "env-key" {
+		t.Fatalf("headers = %v, want secrets resolved from project-relative file and env", got)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"mime"
 	"net/http"
 	"net/url"
+	"slices"
 	"strings"
 
 	"github.com/rest-sh/restish/v2/config"
@@ -23,6 +24,7 @@ type preparedRequest struct {
 	body            io.Reader
 	bodyRaw         []byte
 	bodyContentType string
+	normalize       string // response normalization, such as "jsonapi"
 	actualRequest   *http.Request
 	authEnabled     bool
 	closer          io.Closer
@@ -122,6 +124,7 @@ func (c *CLI) prepareRequest(
 	authEnabled := !noAuth && (opts.OnRequest != nil ||
 		opts.OnUnauthorized != nil ||
 		requestOptionHeadersContainCredentials(opts.Headers) ||
+		len(opts.CredentialHeaders) > 0 ||
 		requestOptionQueryContainsCredentials(opts.Query) ||
 		rawURLQueryContainsCredentials(rawURL))
 
@@ -133,11 +136,14 @@ func (c *CLI) prepareRequest(
 		filtered := opts.Headers[:0]
 		for _, h := range opts.Headers {
 			name, _, _ := strings.Cut(h, ":")
-			if !isSensitiveHeader(name) {
+			if !isSensitiveHeader(name) && !slices.ContainsFunc(opts.CredentialHeaders, func(credential string) bool {
+				return strings.EqualFold(credential, strings.TrimSpace(name))
+			}) {
 				filtered = append(filtered, h)
 			}
 		}
 		opts.Headers = filtered
+		opts.CredentialHeaders = nil
 		opts.Query = filterCredentialQueryParams(opts.Query)
 	}
 
@@ -187,6 +193,10 @@ func (c *CLI) prepareRequest(
 		prepared.actualRequest = preparedReq
 	}
 
+	normalize := c.responseNormalization(globalFlagsFromContext(ctx), apiName)
+	if normalize == responseNormalizeJSONAPI && opts.ContentType == "" && bodyValue != nil {
+		opts.ContentType = "jsonapi"
+	}
 	bodyRaw, bodyContentType, err := c.requestBodyBytes(opts.ContentType, bodyValue, rawBinaryBody, &opts.Headers)
 	if err != nil {
 		return nil, fmt.Errorf("encoding request body: %w", err)
@@ -203,6 +213,7 @@ func (c *CLI) prepareRequest(
 		body:            body,
 		bodyRaw:         bodyRaw,
 		bodyContentType: bodyContentType,
+		normalize:       normalize,
 		authEnabled:     authEnabled,
 		closer:          transportCloser,
 		stopClose:       stopTransportClose,
@@ -272,6 +283,9 @@ func cloneRequestOptions(opts request.Options) request.Options {
 	if len(opts.Headers) > 0 {
 		cloned.Headers = append([]string(nil), opts.Headers...)
 	}
+	if len(opts.CredentialHeaders) > 0 {
+		cloned.CredentialHeaders = append([]string(nil), opts.CredentialHeaders...)
+	}
 	if len(opts.Query) > 0 {
 		cloned.Query = append([]string(nil), opts.Query...)
 	}
@@ -362,7 +376,7 @@ func (c *CLI) sendPreparedRequest(ctx context.Context, method string, prepared *
 		}
 		return c.runRequestMiddlewarePlugins(req)
 	}
-	return request.Do(ctx, method, prepared.rawURL, bodyReader(), retryOpts)
+	return request.Do(withAuthChallenges(ctx, resp), method, prepared.rawURL, bodyReader(), retryOpts)
 }
 
 func (c *CLI) closePreparedTransport(prepared *preparedRequest) {
@@ -82,6 +82,10 @@ func (c *CLI) newRootCmd() *cobra.Command {
 	c.addGlobalFlags(root)
 	c.addHTTPCommands(root)
 	c.addEditCommand(root)
+	c.addGraphQLCommand(root)
+	c.addWebSocketCommand(root)
+	c.addWorkflowCommand(root)
+	c.addRunCommand(root)
 	c.addCertCommand(root)
 	c.addAPICommand(root)
 	c.addCacheCommand(root)
@@ -234,6 +238,9 @@ func (c *CLI) addGlobalFlags(root *cobra.Command) {
 	pf := root.PersistentFlags()
 	pf.StringArrayP("rsh-header", "H", nil, `Request header in "Name: Value" format (repeatable)`)
 	pf.StringArrayP("rsh-query", "q", nil, `Query parameter in "key=value" format (repeatable)`)
+	pf.StringArray("rsh-include", nil, "JSON:API related resources to include, e.g. author,comments (repeatable)")
+	pf.StringArray("rsh-fields", nil, `JSON:API sparse fieldset in "type=field,field" format, sent as fields[type] (repeatable)`)
+	pf.StringArray("rsh-jsonapi-filter", nil, `JSON:API filter in "key=value" format, sent as filter[key] (repeatable)`)
 	pf.StringP("rsh-server", "s", "", "Override scheme://host for all requests (e.g. https://staging.example.com)")
 	pf.StringP("rsh-output-format", "o", "auto", "Output format for rendered response bodies: "+output.FormatterNames(c.formatters)+" (use -o lines for shell-friendly filtered values; see --rsh-columns, --rsh-sort-by for table)")
 	pf.String("rsh-print", "auto", "Output parts to print: auto or any of H=request headers, B=request body, h=response headers, b=rendered body, p=pretty, c=color")
@@ -243,6 +250,7 @@ func (c *CLI) addGlobalFlags(root *cobra.Command) {
 	pf.StringP("rsh-content-type", "c", "", `Request body content type, e.g. json, yaml, cbor (default: json)`)
 	pf.StringP("rsh-filter", "f", "", "Filter/project the response using shorthand or jq (auto-detected)")
 	pf.String("rsh-filter-lang", "", "Force filter language: shorthand or jq")
+	pf.String("rsh-normalize", "", "Response normalization: jsonapi flattens JSON:API documents into plain records, none turns off the API's setting")
 	pf.Bool("rsh-headers", false, "Shorthand for -f headers")
 	pf.Bool("rsh-status", false, "Shorthand for -f status")
 	pf.CountP("rsh-verbose", "v", "Verbose output: -v shows request/response headers, -vv adds TLS details")
@@ -268,6 +276,7 @@ func (c *CLI) addGlobalFlags(root *cobra.Command) {
 	pf.Bool("rsh-no-paginate", false, "Disable automatic pagination (return only the first page)")
 	pf.Bool("rsh-collect", false, "Collect paginated items before filtering (default: filter/render items as they arrive)")
 	pf.Int("rsh-max-pages", 25, "Maximum number of pages to fetch (0 = unlimited)")
+	pf.Bool("rsh-reconnect", false, "Reconnect dropped SSE streams, resuming with Last-Event-ID")
 	pf.Int("rsh-max-items", 0, "Maximum number of paginated items or streamed events/lines to process (0 = unlimited)")
 	pf.Int("rsh-max-body-size", 0, fmt.Sprintf("Maximum response body size in MiB (0 = default %d MiB)", output.DefaultMaxBodyBytes/(1024*1024)))
 	pf.String("rsh-config", "", "Path to the restish config file (overrides RSH_CONFIG and the platform default)")
@@ -0,0 +1,160 @@
+package cli
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/url"
+	"strings"
+
+	"github.com/rest-sh/restish/v2/internal/output"
+	"github.com/rest-sh/restish/v2/internal/spec"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// rpcRequest is the protocol state of a command generated from a protobuf
+// descriptor set. JSON bodies go out unchanged; binary protobuf bodies are
+// encoded and decoded with the method's message types.
+type rpcRequest struct {
+	method string
+	input  protoreflect.MessageDescriptor
+	output protoreflect.MessageDescriptor
+	// twirp selects Twirp's binary media type and skips the Connect protocol
+	// header.
+	twirp bool
+}
+
+// newRPCRequest resolves the message types of rpc from the spec's shared
+// descriptors. protocol is the API's rpc_protocol setting; when it is empty,
+// a "twirp" path segment in baseURL, the API or operation base URL, selects
+// the Twirp protocol, as in https://api.example.com/twirp.
+func newRPCRequest(rpc *spec.RPCOperation, protocol, baseURL string) (*rpcRequest, error) {
+	if rpc.API == nil {
+		return nil, fmt.Errorf("RPC %s/%s: descriptors: missing from the spec", rpc.Service, rpc.Method)
+	}
+	data, err := base64.StdEncoding.DecodeString(rpc.API.Descriptors)
+	if err != nil {
+		return nil, fmt.Errorf("RPC %s/%s: descriptors: %w", rpc.Service, rpc.Method, err)
+	}
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("RPC %s/%s: descriptors: %w", rpc.Service, rpc.Method, err)
+	}
+	files, err := protodesc.NewFiles(&set)
+	if err != nil {
+		return nil, fmt.Errorf("RPC %s/%s: descriptors: %w", rpc.Service, rpc.Method, err)
+	}
+	message := func(name string) (protoreflect.MessageDescriptor, error) {
+		d, err := files.FindDescriptorByName(protoreflect.FullName(name))
+		if err != nil {
+			return nil, fmt.Errorf("RPC %s/%s: message %s: %w", rpc.Service, rpc.Method, name, err)
+		}
+		md, ok := d.(protoreflect.MessageDescriptor)
+		if !ok {
+			return nil, fmt.Errorf("RPC %s/%s: %s is not a message", rpc.Service, rpc.Method, name)
+		}
+		return md, nil
+	}
+	twirp := protocol == "twirp"
+	if protocol == "" {
+		twirp = isTwirpBaseURL(baseURL)
+	}
+	r := &rpcRequest{method: rpc.Service + "/" + rpc.Method, twirp: twirp}
+	if r.input, err = message(rpc.Input); err != nil {
+		return nil, err
+	}
+	if r.output, err = message(rpc.Output); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func isTwirpBaseURL(baseURL string) bool {
+	if !strings.Contains(baseURL, "://") {
+		baseURL = "https://" + baseURL
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return false
+	}
+	for _, segment := range strings.Split(u.Path, "/") {
+		if strings.EqualFold(segment, "twirp") {
+			return true
+		}
+	}
+	return false
+}
+
+// headers returns the request headers the protocol requires.
+func (r *rpcRequest) headers() []string {
+	if r.twirp {
+		return nil
+	}
+	return []string{"Connect-Protocol-Version: 1"}
+}
+
+// binaryContentType returns the protocol's binary media type when
+// contentType selects protobuf, and "" otherwise.
+func (r *rpcRequest) binaryContentType(contentType string) string {
+	if !isProtobufMediaType(contentType) {
+		return ""
+	}
+	if r.twirp {
+		return "application/protobuf"
+	}
+	return "application/proto"
+}
+
+// encodeBody converts a decoded JSON body to the input message, read with the
+// protojson field names.
+func (r *rpcRequest) encodeBody(value any) (proto.Message, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	msg := dynamicpb.NewMessage(r.input)
+	if err := protojson.Unmarshal(data, msg); err != nil {
+		return nil, fmt.Errorf("request body is not a valid %s: %w", r.input.FullName(), err)
+	}
+	return msg, nil
+}
+
+// decodeResponse replaces a successful binary protobuf response body with its
+// protojson form, so it renders like a JSON response.
+func (r *rpcRequest) decodeResponse(resp *output.Response) error {
+	if resp.Status < 200 || resp.Status >= 300 || !isProtobufMediaType(output.Header(resp.Headers, "Content-Type")) {
+		return nil
+	}
+	msg := dynamicpb.NewMessage(r.output)
+	if err := proto.Unmarshal(resp.Raw, msg); err != nil {
+		return fmt.Errorf("decoding %s response as %s: %w", r.method, r.output.FullName(), err)
+	}
+	data, err := protojson.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	var body any
+	if err := json.Unmarshal(data, &body); err != nil {
+		return err
+	}
+	resp.Body = body
+	return nil
+}
+
+func isProtobufMediaType(mediaType string) bool {
+	mt, _, err := mime.ParseMediaType(mediaType)
+	if err != nil {
+		mt = strings.TrimSpace(mediaType)
+	}
+	switch strings.ToLower(mt) {
+	case "protobuf", "application/proto", "application/protobuf", "application/x-protobuf":
+		return true
+	}
+	return false
+}
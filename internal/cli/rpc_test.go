@@ -0,0 +1,172 @@
+package cli_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/rest-sh/restish/v2/internal/cli"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// rpcTestFile describes:
+//
+//	package acme.widgets.v1;
+//	message GetWidgetRequest { string widget_id = 1; }
+//	message Widget { string id = 1; int64 count = 2; }
+//	service WidgetService { rpc GetWidget(GetWidgetRequest) returns (Widget); }
+func rpcTestFile() *descriptorpb.FileDescriptorProto {
+	field := func(name, jsonName string, number int32, typ descriptorpb.FieldDescriptorProto_Type) *descriptorpb.FieldDescriptorProto {
+		return &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(jsonName),
+			Number:   proto.Int32(number),
+			Type:     typ.Enum(),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		}
+	}
+	return &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("acme/widgets/v1/widgets.proto"),
+		Package: proto.String("acme.widgets.v1"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{Name: proto.String("GetWidgetRequest"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("widget_id", "widgetId", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+			}},
+			{Name: proto.String("Widget"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("id", "id", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+				field("count", "count", 2, descriptorpb.FieldDescriptorProto_TYPE_INT64),
+			}},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("WidgetService"),
+			Method: []*descriptorpb.MethodDescriptorProto{{
+				Name:       proto.String("GetWidget"),
+				InputType:  proto.String(".acme.widgets.v1.GetWidgetRequest"),
+				OutputType: proto.String(".acme.widgets.v1.Widget"),
+			}},
+		}},
+	}
+}
+
+// newRPCTestCLI configures a widgets API from rpcTestFile. protocol, when set,
+// is the API's rpc_protocol.
+func newRPCTestCLI(t *testing.T, baseURL, protocol string) (*cli.CLI, *bytes.Buffer, protoreflect.FileDescriptor) {
+	t.Helper()
+	fdp := rpcTestFile()
+	fd, err := protodesc.NewFile(fdp, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := proto.Marshal(&descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{fdp}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, stdout, _, _ := newSpecFileTestCLI(t, "widgets", baseURL, "widgets.binpb", string(data), "")
+	if protocol != "" {
+		configBody, err := os.ReadFile(c.Hooks().ConfigPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		configBody = bytes.Replace(configBody, []byte(`"base_url":`), []byte(`"rpc_protocol":`+strconv.Quote(protocol)+`,"base_url":`), 1)
+		if err := os.WriteFile(c.Hooks().ConfigPath, configBody, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return c, stdout, fd
+}
+
+func TestRPCCommandSendsConnectJSON(t *testing.T) {
+	c, stdout, _ := newRPCTestCLI(t, "https://api.example.com", "")
+	var body map[string]any
+	useTransport(c, func(req *http.Request) (*http.Response, error) {
+		if req.Method != http.MethodPost || req.URL.String() != "https://api.example.com/acme.widgets.v1.WidgetService/GetWidget" {
+			t.Fatalf("request = %s %s", req.Method, req.URL)
+		}
+		if got := req.Header.Get("Connect-Protocol-Version"); got != "1" {
+			t.Fatalf("Connect-Protocol-Version = %q", got)
+		}
+		if ct := req.Header.Get("Content-Type"); ct != "application/json" {
+			t.Fatalf("Content-Type = %q", ct)
+		}
+		data, _ := io.ReadAll(req.Body)
+		if err := json.Unmarshal(data, &body); err != nil {
+			t.Fatalf("request body: %v\n%s", err, data)
+		}
+		return jsonResponse(http.StatusOK, `{"id":"w1","count":"3"}`), nil
+	})
+
+	if err := c.Run([]string{"restish", "widgets", "get-widget", "widgetId: w1", "-o", "json"}); err != nil {
+		t.Fatalf("get-widget: %v", err)
+	}
+	if body["widgetId"] != "w1" {
+		t.Fatalf("body = %#v", body)
+	}
+	if !strings.Contains(stdout.String(), `"w1"`) {
+		t.Fatalf("stdout = %s", stdout.String())
+	}
+}
+
+func TestRPCCommandSendsAndDecodesBinaryProtobuf(t *testing.T) {
+	for _, tc := range []struct {
+		name, baseURL, protocol, url, contentType string
+		connect                                   bool
+	}{
+		{"connect", "https://api.example.com", "", "https://api.example.com/acme.widgets.v1.WidgetService/GetWidget", "application/proto", true},
+		{"twirp", "https://api.example.com/twirp", "", "https://api.example.com/twirp/acme.widgets.v1.WidgetService/GetWidget", "application/protobuf", false},
+		{"twirp setting", "https://api.example.com/rpc", "twirp", "https://api.example.com/rpc/acme.widgets.v1.WidgetService/GetWidget", "application/protobuf", false},
+		{"connect setting", "https://api.example.com/twirp", "connect", "https://api.example.com/twirp/acme.widgets.v1.WidgetService/GetWidget", "application/proto", true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c, stdout, fd := newRPCTestCLI(t, tc.baseURL, tc.protocol)
+			input := fd.Messages().ByName("GetWidgetRequest")
+			output := fd.Messages().ByName("Widget")
+			useTransport(c, func(req *http.Request) (*http.Response, error) {
+				if req.URL.String() != tc.url {
+					t.Fatalf("URL = %s", req.URL)
+				}
+				if got := req.Header.Get("Connect-Protocol-Version") != ""; got != tc.connect {
+					t.Fatalf("Connect-Protocol-Version sent = %v", got)
+				}
+				if ct := req.Header.Get("Content-Type"); ct != tc.contentType {
+					t.Fatalf("Content-Type = %q", ct)
+				}
+				data, _ := io.ReadAll(req.Body)
+				msg := dynamicpb.NewMessage(input)
+				if err := proto.Unmarshal(data, msg); err != nil {
+					t.Fatalf("request is not a GetWidgetRequest: %v", err)
+				}
+				widget := dynamicpb.NewMessage(output)
+				widget.Set(output.Fields().ByName("id"), msg.Get(input.Fields().ByName("widget_id")))
+				widget.Set(output.Fields().ByName("count"), protoreflect.ValueOfInt64(3))
+				resp, _ := proto.Marshal(widget)
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Proto:      "HTTP/1.1",
+					Header:     http.Header{"Content-Type": []string{tc.contentType}},
+					Body:       io.NopCloser(bytes.NewReader(resp)),
+				}, nil
+			})
+
+			if err := c.Run([]string{"restish", "widgets", "get-widget", "-c", "protobuf", "widgetId: w1", "-o", "json"}); err != nil {
+				t.Fatalf("get-widget: %v", err)
+			}
+			var got map[string]any
+			if err := json.Unmarshal([]byte(stdout.String()), &got); err != nil {
+				t.Fatalf("stdout: %v\n%s", err, stdout.String())
+			}
+			if got["id"] != "w1" || got["count"] != "3" {
+				t.Fatalf("response = %#v", got)
+			}
+		})
+	}
+}
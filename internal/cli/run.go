@@ -0,0 +1,432 @@
+package cli
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rest-sh/restish/v2/internal/filter"
+	"github.com/rest-sh/restish/v2/internal/httpfile"
+	"github.com/rest-sh/restish/v2/internal/output"
+	"github.com/rest-sh/restish/v2/internal/request"
+	"github.com/spf13/cobra"
+)
+
+// maxHTTPFileVariableDepth bounds nested file variable expansion so a
+// variable that refers to itself fails instead of looping.
+const maxHTTPFileVariableDepth = 16
+
+// addRunCommand registers the "run" command on root.
+func (c *CLI) addRunCommand(root *cobra.Command) {
+	name := c.commandNameOrDefault()
+	root.AddCommand(&cobra.Command{
+		Use:     "run <file.http> [request-name]",
+		Short:   "Run requests from a .http or .rest file",
+		Long:    runLong,
+		GroupID: rootGroupHTTP,
+		Example: fmt.Sprintf(`  %s run api.http
+  %s run api.http login -f body.token
+  %s run smoke.http --rsh-ignore-status-code -o json`, name, name, name),
+		Annotations: map[string]string{
+			requestHelpAnnotation: "true",
+		},
+		Args: usageRangeArgs(1, 2),
+		RunE: c.runHTTPFileCmd,
+	})
+}
+
+// httpFileResult is a sent request kept for {{name.response...}} and
+// {{name.request...}} placeholders.
+type httpFileResult struct {
+	resp          *output.Response
+	requestHeader http.Header
+	requestBody   any
+}
+
+// httpFileRun executes the requests of one file. Requests referenced by a
+// placeholder run on demand, once, before the request that needs them.
+type httpFileRun struct {
+	c        *CLI
+	cmd      *cobra.Command
+	file     *httpfile.File
+	dir      string
+	opts     request.Options
+	authOpts authHandlerOptions
+	profile  string
+	// show reports whether a request's response is printed. Requests that
+	// only run because another request references them stay quiet.
+	show    func(*httpfile.Request) bool
+	results map[*httpfile.Request]*httpFileResult
+	running map[*httpfile.Request]bool
+	dotenv  map[string]string
+}
+
+func (c *CLI) runHTTPFileCmd(cmd *cobra.Command, args []string) error {
+	gf := globalFlagsFromContext(requestContext(cmd))
+	if err := c.validateHTTPOutputFlags(cmd, gf); err != nil {
+		return err
+	}
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("reading request file: %w", err)
+	}
+	file, err := httpfile.Parse(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("parsing %s: %w", args[0], err)
+	}
+	if len(file.Requests) == 0 {
+		return fmt.Errorf("%s has no requests", args[0])
+	}
+	targets := file.Requests
+	if len(args) > 1 {
+		req := httpFileRequestByName(file, args[1])
+		if req == nil {
+			return newUsageError(fmt.Errorf("unknown request %q; available: %s", args[1], strings.Join(httpFileRequestNames(file), ", ")))
+		}
+		targets = []*httpfile.Request{req}
+	}
+
+	c.requestExecutionStarted = true
+	ensureRequestTrace(cmd)
+	opts, err := c.httpOptsFromFlags(cmd)
+	if err != nil {
+		return err
+	}
+	authOpts, err := c.authHandlerOptionsFromCmd(cmd)
+	if err != nil {
+		return err
+	}
+	x := &httpFileRun{
+		c:        c,
+		cmd:      cmd,
+		file:     file,
+		dir:      filepath.Dir(args[0]),
+		opts:     opts,
+		authOpts: authOpts,
+		profile:  c.profileFromCmd(cmd),
+		results:  map[*httpfile.Request]*httpFileResult{},
+		running:  map[*httpfile.Request]bool{},
+	}
+	x.show = func(req *httpfile.Request) bool {
+		return len(args) == 1 || req == targets[0]
+	}
+	for _, req := range targets {
+		if _, err := x.run(req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func httpFileRequestByName(file *httpfile.File, name string) *httpfile.Request {
+	for _, req := range file.Requests {
+		if req.Name == name {
+			return req
+		}
+	}
+	return nil
+}
+
+func httpFileRequestNames(file *httpfile.File) []string {
+	var names []string
+	for _, req := range file.Requests {
+		if req.Name != "" {
+			names = append(names, req.Name)
+		}
+	}
+	if len(names) == 0 {
+		return []string{"(no named requests; add \"# @name NAME\" above a request)"}
+	}
+	return names
+}
+
+// run sends req unless it already ran, printing its response when shown.
+// Non-success statuses stop the run unless --rsh-ignore-status-code is set.
+func (x *httpFileRun) run(req *httpfile.Request) (*httpFileResult, error) {
+	if result := x.results[req]; result != nil {
+		return result, nil
+	}
+	if x.running[req] {
+		return nil, fmt.Errorf("request %s refers to its own response", req.Label())
+	}
+	x.running[req] = true
+	defer delete(x.running, req)
+
+	c, ctx := x.c, requestContext(x.cmd)
+	rawURL, err := x.expand(req.URL, 0)
+	if err != nil {
+		return nil, fmt.Errorf("request %s: %w", req.Label(), err)
+	}
+	var headers []string
+	var contentType string
+	for _, h := range req.Headers {
+		value, err := x.expand(h.Value, 0)
+		if err != nil {
+			return nil, fmt.Errorf("request %s: header %s: %w", req.Label(), h.Name, err)
+		}
+		if strings.EqualFold(h.Name, "Content-Type") {
+			contentType = value
+		}
+		headers = append(headers, h.Name+": "+value)
+	}
+	body, err := x.body(req)
+	if err != nil {
+		return nil, fmt.Errorf("request %s: %w", req.Label(), err)
+	}
+
+	prepared, err := c.prepareRequest(ctx, req.Method, rawURL, x.profile, x.opts, nil, headers, false, x.authOpts, nil, false, "")
+	if err != nil {
+		return nil, err
+	}
+	defer c.closePreparedTransport(prepared)
+	// The body is sent exactly as written; it is not re-encoded from a
+	// value the way shorthand input is.
+	if len(body) > 0 {
+		prepared.body = bytes.NewReader(body)
+		prepared.bodyRaw = body
+		prepared.bodyContentType = contentType
+	}
+	c.warnRetryUnsafe(req.Method, prepared.opts)
+	httpResp, err := c.sendPreparedRequest(ctx, req.Method, prepared)
+	if err != nil {
+		if isLocalRequestExecutionError(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("network error for %s %s: %w", req.Method, redactedNetworkErrorURL(prepared.rawURL, prepared.opts.Server), err)
+	}
+	resp, err := c.normalizeHTTPResponse(httpResp, maxBodyBytes(x.cmd))
+	if err != nil {
+		return nil, responseBodyReadError(req.Method, prepared.rawURL, err)
+	}
+	c.normalizeResponseBody(resp, prepared)
+	if globalFlagsFromContext(ctx).Verbose >= 1 {
+		c.logVerboseResponseBody(resp)
+	}
+
+	// Request captures describe the request as sent, after profile headers,
+	// auth, and middleware, not the text in the file.
+	result := &httpFileResult{resp: resp, requestBody: httpFileBodyValue(body)}
+	if sent := prepared.actualRequest; sent != nil {
+		result.requestHeader = sent.Header
+		if sentBody, ok := httpFileSentBody(sent); ok {
+			result.requestBody = httpFileBodyValue(sentBody)
+		}
+	}
+	x.results[req] = result
+
+	if x.show(req) {
+		if err := c.formatResponse(x.cmd, resp, prepared); err != nil {
+			return nil, err
+		}
+		return result, c.statusError(x.cmd, resp.Status)
+	}
+	if c.statusError(x.cmd, resp.Status) != nil {
+		return nil, fmt.Errorf("request %s returned HTTP %d", req.Label(), resp.Status)
+	}
+	return result, nil
+}
+
+// body returns the expanded inline body or the contents of a "< path"
+// body file.
+func (x *httpFileRun) body(req *httpfile.Request) ([]byte, error) {
+	if req.BodyFile == "" {
+		if req.Body == "" {
+			return nil, nil
+		}
+		body, err := x.expand(req.Body, 0)
+		return []byte(body), err
+	}
+	path := req.BodyFile
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(x.dir, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading body file: %w", err)
+	}
+	if !req.ExpandBodyFile {
+		return data, nil
+	}
+	body, err := x.expand(string(data), 0)
+	return []byte(body), err
+}
+
+func (x *httpFileRun) expand(s string, depth int) (string, error) {
+	return httpfile.Expand(s, func(name string) (string, error) {
+		return x.resolve(name, depth)
+	})
+}
+
+// resolve returns the value of one placeholder: a system variable such as
+// $env or $dotenv, a captured request or response value, or a file
+// variable, whose own placeholders are expanded in turn.
+func (x *httpFileRun) resolve(name string, depth int) (string, error) {
+	if strings.HasPrefix(name, "$") {
+		return x.systemVariable(name)
+	}
+	if value, ok := x.file.Variables[name]; ok {
+		if depth >= maxHTTPFileVariableDepth {
+			return "", fmt.Errorf("variable %q expands too deeply; check for a variable that refers to itself", name)
+		}
+		return x.expand(value, depth+1)
+	}
+	parts := strings.SplitN(name, ".", 4)
+	if len(parts) >= 3 && (parts[1] == "response" || parts[1] == "request") {
+		if req := httpFileRequestByName(x.file, parts[0]); req != nil {
+			return x.capture(req, parts[1:])
+		}
+		return "", fmt.Errorf("{{%s}} refers to unknown request %q; name it with \"# @name %s\"", name, parts[0], parts[0])
+	}
+	return "", fmt.Errorf("undefined variable {{%s}}; define it with \"@%s = value\"", name, name)
+}
+
+// capture reads a value from a named request, sending it first if needed.
+// The path is "response|request", "body|headers", then a header name or a
+// body path such as "token", "$.data[0].id", or "*" for the whole body.
+func (x *httpFileRun) capture(req *httpfile.Request, path []string) (string, error) {
+	result, err := x.run(req)
+	if err != nil {
+		return "", err
+	}
+	ref := req.Name + "." + strings.Join(path, ".")
+	var body any
+	var headers map[string][]string
+	if path[0] == "response" {
+		body, headers = result.resp.Body, result.resp.Headers
+	} else {
+		body, headers = result.requestBody, result.requestHeader
+	}
+	switch path[1] {
+	case "headers":
+		if len(path) < 3 {
+			return "", fmt.Errorf("{{%s}} needs a header name", ref)
+		}
+		return output.Header(headers, path[2]), nil
+	case "body":
+		expr := ""
+		if len(path) == 3 {
+			expr = strings.TrimPrefix(path[2], "$")
+		}
+		if expr == "" || expr == "*" {
+			return httpFileValueString(body)
+		}
+		if !strings.HasPrefix(expr, ".") && !strings.HasPrefix(expr, "[") {
+			expr = "." + expr
+		}
+		value, err := filter.Apply("body"+expr, map[string]any{"body": body}, filter.LangShorthand)
+		if err != nil {
+			return "", fmt.Errorf("{{%s}}: %w", ref, err)
+		}
+		if value == nil {
+			return "", fmt.Errorf("{{%s}} not found in %s body", ref, path[0])
+		}
+		return httpFileValueString(value)
+	}
+	return "", fmt.Errorf("{{%s}}: expected body or headers after %s", ref, path[0])
+}
+
+// systemVariable resolves the built-in {{$...}} variables.
+func (x *httpFileRun) systemVariable(name string) (string, error) {
+	kind, arg, _ := strings.Cut(name, " ")
+	arg = strings.TrimSpace(arg)
+	if rest, ok := strings.CutPrefix(kind, "$env."); ok {
+		kind, arg = "$env", rest
+	}
+	switch kind {
+	case "$env", "$processEnv":
+		value, ok := os.LookupEnv(arg)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", arg)
+		}
+		return value, nil
+	case "$dotenv":
+		if x.dotenv == nil {
+			path := filepath.Join(x.dir, ".env")
+			f, err := os.Open(path)
+			if err != nil {
+				return "", fmt.Errorf("{{%s}}: %w", name, err)
+			}
+			values, err := httpfile.ParseDotenv(f)
+			_ = f.Close()
+			if err != nil {
+				return "", fmt.Errorf("reading %s: %w", path, err)
+			}
+			x.dotenv = values
+		}
+		value, ok := x.dotenv[arg]
+		if !ok {
+			return "", fmt.Errorf("%s is not set in %s", arg, filepath.Join(x.dir, ".env"))
+		}
+		return value, nil
+	case "$timestamp":
+		return strconv.FormatInt(time.Now().Unix(), 10), nil
+	case "$guid", "$uuid", "$random.uuid":
+		return randomUUID()
+	}
+	return "", fmt.Errorf("unsupported system variable {{%s}}", name)
+}
+
+// randomUUID returns a random version 4 UUID.
+func randomUUID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+// httpFileSentBody rereads the body of a sent request. It reports false
+// when the body cannot be replayed.
+func httpFileSentBody(req *http.Request) ([]byte, bool) {
+	if req.GetBody == nil {
+		return nil, req.Body == nil || req.Body == http.NoBody
+	}
+	rc, err := req.GetBody()
+	if err != nil {
+		return nil, false
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	return data, err == nil
+}
+
+// httpFileBodyValue decodes a sent request body for capture, keeping it as
+// text when it is not JSON.
+func httpFileBodyValue(body []byte) any {
+	if len(body) == 0 {
+		return nil
+	}
+	var value any
+	if err := json.Unmarshal(body, &value); err == nil {
+		return value
+	}
+	return string(body)
+}
+
+// httpFileValueString formats a captured value for substitution: strings
+// as-is, everything else as JSON.
+func httpFileValueString(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
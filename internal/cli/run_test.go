@@ -0,0 +1,131 @@
+package cli_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rest-sh/restish/v2/internal/cli"
+)
+
+const runTestFile = `@user = {{$env RUN_TEST_USER}}
+
+### login
+POST users/login
+Content-Type: application/json
+
+{"user": "{{user}}", "password": "{{$dotenv RUN_TEST_PASSWORD}}"}
+
+###
+# @name me
+GET https://api.example.com/me
+Authorization: Bearer {{login.response.body.token}}
+X-Session: {{login.response.headers.X-Session}}
+X-User: {{login.request.body.user}}
+X-Login-Type: {{login.request.headers.Content-Type}}
+`
+
+// newRunTestCLI writes a request file and .env next to it and registers the
+// users API with a profile header.
+func newRunTestCLI(t *testing.T, file string) (*cli.CLI, *bytes.Buffer, *bytes.Buffer, string) {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "api.http")
+	if err := os.WriteFile(path, []byte(file), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".env"), []byte("RUN_TEST_PASSWORD=s3cret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	c, stdout, stderr, _ := newSpecFileTestCLI(t, "users", "https://api.example.com", "", "", `{"headers":["X-Tenant: acme"]}`)
+	return c, stdout, stderr, path
+}
+
+func TestRunHTTPFileCapturesResponsesAcrossRequests(t *testing.T) {
+	t.Setenv("RUN_TEST_USER", "ada")
+	c, stdout, _, path := newRunTestCLI(t, runTestFile)
+	var loginBody string
+	var meAuth, meSession, meUser, meLoginType string
+	useTransport(c, func(r *http.Request) (*http.Response, error) {
+		if r.Header.Get("X-Tenant") != "acme" {
+			t.Errorf("%s %s missing profile header", r.Method, r.URL)
+		}
+		switch r.URL.String() {
+		case "https://api.example.com/login":
+			data, _ := io.ReadAll(r.Body)
+			loginBody = string(data)
+			resp := jsonResponse(http.StatusOK, `{"token":"t0k"}`)
+			resp.Header.Set("X-Session", "s1")
+			return resp, nil
+		case "https://api.example.com/me":
+			meAuth, meSession = r.Header.Get("Authorization"), r.Header.Get("X-Session")
+			meUser, meLoginType = r.Header.Get("X-User"), r.Header.Get("X-Login-Type")
+			return jsonResponse(http.StatusOK, `{"name":"Ada"}`), nil
+		}
+		t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		return nil, nil
+	})
+
+	if err := c.Run([]string{"restish", "run", path, "me", "-f", "body.name"}); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if loginBody != `{"user": "ada", "password": "s3cret"}` {
+		t.Fatalf("login body = %q", loginBody)
+	}
+	if meAuth != "Bearer t0k" || meSession != "s1" {
+		t.Fatalf("me headers = %q, %q", meAuth, meSession)
+	}
+	if meUser != "ada" || meLoginType != "application/json" {
+		t.Fatalf("request captures = %q, %q, want the sent login body and headers", meUser, meLoginType)
+	}
+	if stdout.String() != "Ada\n" {
+		t.Fatalf("stdout = %q, want only the selected response", stdout.String())
+	}
+}
+
+func TestRunHTTPFileStopsOnErrorStatus(t *testing.T) {
+	t.Setenv("RUN_TEST_USER", "ada")
+	file := strings.Replace(runTestFile, "{{login.response.body.token}}", "static", 1)
+	file = strings.Replace(file, "X-Session: {{login.response.headers.X-Session}}\n", "", 1)
+	c, _, _, path := newRunTestCLI(t, file)
+	var sent []string
+	useTransport(c, func(r *http.Request) (*http.Response, error) {
+		sent = append(sent, r.URL.Path)
+		if r.URL.Path == "/login" {
+			return jsonResponse(http.StatusUnauthorized, `{"title":"bad password"}`), nil
+		}
+		return jsonResponse(http.StatusOK, `{}`), nil
+	})
+
+	err := c.Run([]string{"restish", "run", path, "-o", "json"})
+	var exitErr *cli.ExitCodeError
+	if !errors.As(err, &exitErr) || len(sent) != 1 {
+		t.Fatalf("err = %v, sent = %q", err, sent)
+	}
+	sent = nil
+	if err := c.Run([]string{"restish", "run", path, "-o", "json", "--rsh-ignore-status-code"}); err != nil || len(sent) != 2 {
+		t.Fatalf("ignore status: err = %v, sent = %q", err, sent)
+	}
+}
+
+func TestRunHTTPFileReportsUnknownNames(t *testing.T) {
+	c, _, _, path := newRunTestCLI(t, "GET https://api.example.com/{{missing}}\n")
+	useTransport(c, func(r *http.Request) (*http.Response, error) {
+		t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		return nil, nil
+	})
+
+	err := c.Run([]string{"restish", "run", path})
+	if err == nil || !strings.Contains(err.Error(), "undefined variable {{missing}}") {
+		t.Fatalf("err = %v", err)
+	}
+	err = c.Run([]string{"restish", "run", path, "login"})
+	if err == nil || !strings.Contains(err.Error(), `unknown request "login"`) {
+		t.Fatalf("err = %v", err)
+	}
+}
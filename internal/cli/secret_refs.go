@@ -0,0 +1,222 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/rest-sh/restish/v2/config"
+)
+
+// resolveSecretRef resolves a config secret reference. It runs only when a
+// request needs the value; resolved secrets are never written back to config.
+func (c *CLI) resolveSecretRef(ref config.SecretRef) (string, error) {
+	switch ref.Kind() {
+	case "$env":
+		value, ok := os.LookupEnv(ref.Env)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", ref.Env)
+		}
+		return value, nil
+	case "$file":
+		data, err := os.ReadFile(expandHomePath(ref.File))
+		if err != nil {
+			return "", fmt.Errorf("$file secret: %w", err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	case "$cmd":
+		return c.runSecretProcess(func(ctx context.Context) *exec.Cmd {
+			return exec.CommandContext(ctx, ref.Cmd[0], ref.Cmd[1:]...)
+		})
+	case "$keyring":
+		service, account, err := ref.KeyringService()
+		if err != nil {
+			return "", err
+		}
+		return c.keyringSecret(service, account)
+	default:
+		return "", fmt.Errorf("empty secret reference")
+	}
+}
+
+func (c *CLI) keyringSecret(service, account string) (string, error) {
+	if c.hooks.KeyringFunc != nil {
+		return c.hooks.KeyringFunc(service, account)
+	}
+	name, args, err := keyringLookupCommand(runtime.GOOS, service, account)
+	if err != nil {
+		return "", err
+	}
+	value, err := c.runSecretProcess(func(ctx context.Context) *exec.Cmd {
+		return exec.CommandContext(ctx, name, args...)
+	})
+	if err != nil {
+		return "", fmt.Errorf("$keyring %s/%s: %w", service, account, err)
+	}
+	if value == "" {
+		return "", fmt.Errorf("$keyring %s/%s: no secret found", service, account)
+	}
+	return value, nil
+}
+
+// keyringLookupCommand returns the platform keyring CLI invocation. Shelling
+// out keeps Restish free of cgo and D-Bus dependencies; both tools ship with
+// the desktop keyrings they front.
+func keyringLookupCommand(goos, service, account string) (string, []string, error) {
+	switch goos {
+	case "darwin":
+		return "security", []string{"find-generic-password", "-s", service, "-a", account, "-w"}, nil
+	case "windows":
+		return "", nil, fmt.Errorf("$keyring is not supported on Windows; use $cmd with a credential helper instead")
+	default:
+		return "secret-tool", []string{"lookup", "service", service, "account", account}, nil
+	}
+}
+
+// resolveProfileHeaders resolves persistent headers whose values are secret
+// references and returns the names of those headers so requests can redact
+// them.
+func (c *CLI) resolveProfileHeaders(headers []string) ([]string, []string, error) {
+	var resolved []string
+	var credentialNames []string
+	for i, header := range headers {
+		name, ref, ok := config.SplitSecretRefHeader(header)
+		if !ok {
+			continue
+		}
+		if resolved == nil {
+			resolved = append([]string(nil), headers...)
+		}
+		value, err := c.resolveSecretRef(ref)
+		if err != nil {
+			return nil, nil, fmt.Errorf("header %s: %w", name, err)
+		}
+		resolved[i] = name + ": " + value
+		credentialNames = append(credentialNames, name)
+	}
+	if resolved == nil {
+		return headers, nil, nil
+	}
+	return resolved, credentialNames, nil
+}
+
+// resolveSecretParams resolves secret references in a plugin param map such
+// as tls_signer_params.
+func (c *CLI) resolveSecretParams(params map[string]string) (map[string]string, error) {
+	var resolved map[string]string
+	for key, value := range params {
+		ref, ok := config.ParseSecretRef(value)
+		if !ok {
+			continue
+		}
+		if resolved == nil {
+			resolved = make(map[string]string, len(params))
+			for k, v := range params {
+				resolved[k] = v
+			}
+		}
+		secret, err := c.resolveSecretRef(ref)
+		if err != nil {
+			return nil, fmt.Errorf("tls_signer_params %q: %w", key, err)
+		}
+		resolved[key] = secret
+	}
+	if resolved == nil {
+		return params, nil
+	}
+	return resolved, nil
+}
+
+// secretValueSource describes where a config value comes from without
+// revealing it, or "" when the value is stored inline.
+func secretValueSource(value string) string {
+	if ref, ok := config.ParseSecretRef(value); ok {
+		return ref.Source()
+	}
+	switch {
+	case strings.HasPrefix(value, "env:"):
+		return "env " + strings.TrimPrefix(value, "env:")
+	case strings.HasPrefix(value, "command:"):
+		return "command"
+	default:
+		return ""
+	}
+}
+
+// authSecretSources lists "param from source" entries for auth params that
+// reference external secrets.
+func authSecretSources(ac *config.AuthConfig) []string {
+	if ac == nil {
+		return nil
+	}
+	var sources []string
+	for key, value := range ac.Params {
+		if source := secretValueSource(value); source != "" {
+			sources = append(sources, key+" from "+source)
+		}
+	}
+	sort.Strings(sources)
+	return sources
+}
+
+// profileSecretSources lists secret sources for a profile's auth, credential,
+// header, and tls-signer settings, prefixed with their config location.
+func (c *CLI) profileSecretSources(apiName, profileName string, prof *config.ProfileConfig) []string {
+	if prof == nil {
+		return nil
+	}
+	var sources []string
+	if resolved, err := c.resolveProfileAuth(apiName, profileName, prof); err == nil {
+		for _, source := range authSecretSources(resolved.Config) {
+			sources = append(sources, "auth."+source)
+		}
+	}
+	var ids []string
+	for id := range prof.Credentials {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		resolved, err := c.resolveCredentialAuth(apiName, profileName, id, prof.Credentials[id])
+		if err != nil {
+			continue
+		}
+		for _, source := range authSecretSources(resolved.Config) {
+			sources = append(sources, "credentials."+id+"."+source)
+		}
+	}
+	for _, header := range prof.Headers {
+		if name, ref, ok := config.SplitSecretRefHeader(header); ok {
+			sources = append(sources, "headers."+name+" from "+ref.Source())
+		}
+	}
+	var signerSources []string
+	for key, value := range prof.TLSSignerParams {
+		if source := secretValueSource(value); source != "" {
+			signerSources = append(signerSources, "tls_signer_params."+key+" from "+source)
+		}
+	}
+	sort.Strings(signerSources)
+	return append(sources, signerSources...)
+}
+
+// secretRefReadinessIssue reports a statically detectable problem with a
+// secret reference. $cmd and $keyring are not executed here, so only their
+// shape is checked.
+func secretRefReadinessIssue(ref config.SecretRef) string {
+	switch ref.Kind() {
+	case "$env":
+		if _, ok := os.LookupEnv(ref.Env); !ok {
+			return "env missing: " + ref.Env
+		}
+	case "$file":
+		if _, err := os.Stat(expandHomePath(ref.File)); err != nil {
+			return "file missing: " + ref.File
+		}
+	}
+	return ""
+}
@@ -0,0 +1,146 @@
+package cli_test
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSecretReferencesResolveAuthParamsAndHeaders(t *testing.T) {
+	t.Setenv("RESTISH_TEST_PASSWORD", "env-password")
+	userFile := filepath.Join(t.TempDir(), "user")
+	if err := os.WriteFile(userFile, []byte("file-user\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	var rr requestRecorder
+	c, _, errBuf := newTestCLI(t)
+	useTransport(c, func(r *http.Request) (*http.Response, error) {
+		rr.capture(r)
+		return &http.Response{
+			StatusCode: 200,
+			Proto:      "HTTP/1.1",
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       http.NoBody,
+			Request:    r,
+		}, nil
+	})
+	var lookups []string
+	c.Hooks().KeyringFunc = func(service, account string) (string, error) {
+		lookups = append(lookups, service+"|"+account)
+		return "keyring-key", nil
+	}
+	c.Hooks().ConfigPath = writeAPIConfig(t, fmt.Sprintf(`{
+		"apis": {
+			"myapi": {
+				"base_url": "https://api.example.com",
+				"profiles": {
+					"default": {
+						"headers": [{"name": "X-API-Key", "value": {"$keyring": "restish/api.example.com/alice"}}],
+						"auth": {
+							"type": "http-basic",
+							"params": {"username": {"$file": %q}, "password": {"$env": "RESTISH_TEST_PASSWORD"}}
+						}
+					}
+				}
+			}
+		}
+	}`, userFile))
+
+	if err := c.Run([]string{"restish", "get", "-v", "myapi/items"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	req := rr.Last()
+	want := "Basic " + base64.StdEncoding.EncodeToString([]byte("file-user:env-password"))
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("Authorization = %q, want %q", got, want)
+	}
+	if got := req.Header.Get("X-API-Key"); got != "keyring-key" {
+		t.Errorf("X-API-Key = %q, want keyring-key", got)
+	}
+	if len(lookups) != 1 || lookups[0] != "restish/api.example.com|alice" {
+		t.Errorf("keyring lookups = %v", lookups)
+	}
+	stderr := errBuf.String()
+	if strings.Contains(stderr, "keyring-key") || !strings.Contains(stderr, "> X-Api-Key: <redacted>") {
+		t.Fatalf("verbose output did not redact secret header:\n%s", stderr)
+	}
+}
+
+func TestSecretReferenceMissingEnvFailsRequest(t *testing.T) {
+	t.Setenv("RESTISH_TEST_MISSING", "")
+	os.Unsetenv("RESTISH_TEST_MISSING")
+	sent := false
+	c, _, _ := newTestCLI(t)
+	useTransport(c, func(r *http.Request) (*http.Response, error) {
+		sent = true
+		return jsonResponse(200, `{}`), nil
+	})
+	c.Hooks().ConfigPath = writeAPIConfig(t, `{
+		"apis": {
+			"myapi": {
+				"base_url": "https://api.example.com",
+				"profiles": {
+					"default": {
+						"headers": [{"name": "X-API-Key", "value": {"$env": "RESTISH_TEST_MISSING"}}]
+					}
+				}
+			}
+		}
+	}`)
+
+	err := c.Run([]string{"restish", "get", "myapi/items"})
+	if err == nil || !strings.Contains(err.Error(), "RESTISH_TEST_MISSING is not set") {
+		t.Fatalf("err = %v, want missing env error", err)
+	}
+	if sent {
+		t.Fatal("request sent without its secret header")
+	}
+}
+
+func TestSecretReferencesReportSourcesNotValues(t *testing.T) {
+	t.Setenv("RESTISH_TEST_TOKEN", "env-token-value")
+	c, out, _ := newTestCLI(t)
+	c.Hooks().KeyringFunc = func(service, account string) (string, error) {
+		t.Fatalf("keyring read while reporting sources: %s/%s", service, account)
+		return "", nil
+	}
+	if err := os.WriteFile(c.Hooks().ConfigPath, []byte(`{
+		"apis": {
+			"myapi": {
+				"base_url": "https://api.example.com",
+				"profiles": {
+					"default": {
+						"headers": [{"name": "X-Tenant-Key", "value": {"$keyring": "restish/tenant"}}],
+						"auth": {"type": "bearer", "params": {"token": {"$env": "RESTISH_TEST_TOKEN"}}}
+					}
+				}
+			}
+		}
+	}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Run([]string{"restish", "config", "show", "-o", "json"}); err != nil {
+		t.Fatalf("config show: %v", err)
+	}
+	got := out.String()
+	if strings.Contains(got, "env-token-value") || !strings.Contains(got, `"$env": "RESTISH_TEST_TOKEN"`) || !strings.Contains(got, `"$keyring": "restish/tenant"`) {
+		t.Fatalf("config show did not report secret sources:\n%s", got)
+	}
+
+	out.Reset()
+	if err := c.Run([]string{"restish", "api", "auth", "inspect", "--redact", "myapi"}); err != nil {
+		t.Fatalf("api auth inspect: %v", err)
+	}
+	got = out.String()
+	if strings.Contains(got, "env-token-value") ||
+		!strings.Contains(got, "auth.token from $env RESTISH_TEST_TOKEN") ||
+		!strings.Contains(got, "headers.X-Tenant-Key from $keyring restish/tenant") {
+		t.Fatalf("api auth inspect did not report secret sources:\n%s", got)
+	}
+}
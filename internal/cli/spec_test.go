@@ -1,11 +1,14 @@
 package cli_test
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"io"
 	"net/http"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"sync/atomic"
 	"testing"
@@ -47,6 +50,33 @@ func newSpecTestCLI(t *testing.T, apiName, baseURL string) *cli.CLI {
 	return c
 }
 
+// newSpecFileTestCLI returns a test CLI with apiName registered at baseURL.
+// When spec is not empty it is written to specName in a temp dir and used as
+// the API's spec file; the returned path is that file. profileJSON, when not
+// empty, is the API's default profile, e.g. {"headers":["X-Tenant: acme"]}.
+func newSpecFileTestCLI(t *testing.T, apiName, baseURL, specName, spec, profileJSON string) (*cli.CLI, *bytes.Buffer, *bytes.Buffer, string) {
+	t.Helper()
+	api := `"base_url":` + strconv.Quote(baseURL)
+	var specPath string
+	if spec != "" {
+		specPath = filepath.Join(t.TempDir(), specName)
+		if err := os.WriteFile(specPath, []byte(spec), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		api += `,"spec_files":[` + strconv.Quote(specPath) + `]`
+	}
+	if profileJSON != "" {
+		api += `,"profiles":{"default":` + profileJSON + `}`
+	}
+	c, stdout, stderr := newTestCLI(t)
+	c.Hooks().SpecCachePath = t.TempDir()
+	configBody := `{"apis":{` + strconv.Quote(apiName) + `:{` + api + `}}}`
+	if err := os.WriteFile(c.Hooks().ConfigPath, []byte(configBody), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return c, stdout, stderr, specPath
+}
+
 // writeFile writes data to path.
 func writeFile(path string, data []byte) error {
 	return os.WriteFile(path, data, 0o600)
@@ -0,0 +1,194 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/rest-sh/restish/v2/internal/request"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// sseMaxReconnectFailures is how many reconnect attempts in a row may fail
+	// or deliver no new events before --rsh-reconnect gives up.
+	sseMaxReconnectFailures = 10
+	sseMaxReconnectDelay    = 30 * time.Second
+	// sseSeenIDLimit bounds the ids remembered for replay deduplication.
+	sseSeenIDLimit = 10000
+)
+
+// errSSEStream marks transport errors while reading an event stream. They end
+// the stream, or trigger a reconnect with --rsh-reconnect.
+var errSSEStream = errors.New("SSE stream error")
+
+// sseResume is the state --rsh-reconnect carries from one SSE connection to
+// the next.
+type sseResume struct {
+	lastID  string
+	retry   time.Duration
+	count   int
+	stopped bool // --rsh-max-items was reached
+	fresh   bool // the current connection emitted at least one new event
+	seen    map[string]struct{}
+	order   []string
+}
+
+// remember records id as emitted and reports whether it was new. The oldest
+// ids are forgotten once sseSeenIDLimit is reached.
+func (r *sseResume) remember(id string) bool {
+	if _, ok := r.seen[id]; ok {
+		return false
+	}
+	if r.seen == nil {
+		r.seen = map[string]struct{}{}
+	}
+	if len(r.order) >= sseSeenIDLimit {
+		delete(r.seen, r.order[0])
+		r.order = r.order[1:]
+	}
+	r.seen[id] = struct{}{}
+	r.order = append(r.order, id)
+	return true
+}
+
+// delay returns how long to wait before reconnect attempt n (1-based): the
+// server's advertised retry delay when there is one, otherwise exponential
+// backoff from base.
+func (r *sseResume) delay(n int, base time.Duration) time.Duration {
+	if r.retry > 0 {
+		return r.retry
+	}
+	if base <= 0 {
+		base = time.Second
+	}
+	d := base
+	for i := 1; i < n && d < sseMaxReconnectDelay; i++ {
+		d *= 2
+	}
+	return min(d, sseMaxReconnectDelay)
+}
+
+// readSSEStream reads events from resp. With --rsh-reconnect, whenever the
+// connection ends it re-issues the request with Last-Event-ID and keeps
+// passing events to the same emit function, so the output session spans every
+// connection.
+func (c *CLI) readSSEStream(cmd *cobra.Command, resp *http.Response, prepared *preparedRequest, wrapData bool, emit func(streamItem) error) error {
+	gf := globalFlagsFromContext(requestContext(cmd))
+	if !gf.Reconnect || prepared == nil {
+		return c.readSSEItems(cmd, resp.Body, wrapData, gf.MaxItems, nil, emit)
+	}
+
+	ctx := requestContext(cmd)
+	method := http.MethodGet
+	if resp.Request != nil && resp.Request.Method != "" {
+		method = resp.Request.Method
+	}
+	resume := &sseResume{}
+	body := resp.Body
+	failures := 0
+	for {
+		err := c.readSSEItems(cmd, body, wrapData, gf.MaxItems, resume, emit)
+		if body != resp.Body {
+			_ = body.Close()
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil && !errors.Is(err, errSSEStream) {
+			return err
+		}
+		if resume.stopped {
+			return nil
+		}
+		if resume.fresh {
+			failures = 0
+			resume.fresh = false
+		}
+		reason := "SSE stream ended"
+		if err != nil {
+			reason = err.Error()
+		}
+
+		for {
+			failures++
+			if failures > sseMaxReconnectFailures {
+				return fmt.Errorf("SSE stream: giving up after %d reconnect attempts without new events (last: %s)", sseMaxReconnectFailures, reason)
+			}
+			delay := resume.delay(failures, prepared.opts.RetryBaseDelay)
+			if resume.lastID != "" {
+				c.warnf("%s; reconnecting in %s with Last-Event-ID %q", reason, delay, resume.lastID)
+			} else {
+				c.warnf("%s; reconnecting in %s", reason, delay)
+			}
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+
+			next, retry, err := c.reconnectSSE(ctx, method, prepared, resume.lastID)
+			if err == nil && next == nil {
+				// 204 No Content: the server asked us to stop reconnecting.
+				return nil
+			}
+			if err == nil {
+				body = next
+				break
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if !retry {
+				return err
+			}
+			reason = err.Error()
+		}
+	}
+}
+
+// reconnectSSE re-sends prepared with a Last-Event-ID header. Auth runs again
+// for the new request, so expired OAuth tokens are refreshed. It returns a nil
+// body without error when the server answers 204, and reports whether a
+// failure is worth retrying.
+func (c *CLI) reconnectSSE(ctx context.Context, method string, prepared *preparedRequest, lastID string) (io.ReadCloser, bool, error) {
+	next := *prepared
+	next.opts = cloneRequestOptions(prepared.opts)
+	next.opts.Transport = prepared.opts.Transport
+	if lastID != "" {
+		headers, err := mergeHeaderOptions(next.opts.Headers, []string{"Last-Event-ID: " + lastID})
+		if err != nil {
+			return nil, false, err
+		}
+		next.opts.Headers = headers
+	}
+
+	resp, err := c.sendPreparedRequest(ctx, method, &next)
+	if err != nil {
+		return nil, true, fmt.Errorf("network error for %s %s: %w", method, redactedNetworkErrorURL(prepared.rawURL, prepared.opts.Server), err)
+	}
+	switch {
+	case resp.StatusCode == http.StatusNoContent:
+		_ = resp.Body.Close()
+		return nil, false, nil
+	case resp.StatusCode >= 300:
+		_ = resp.Body.Close()
+		retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusRequestTimeout
+		return nil, retry, fmt.Errorf("SSE reconnect failed: server returned %d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+	case streamingContentType(resp.Header.Get("Content-Type")) != "sse":
+		_ = resp.Body.Close()
+		return nil, false, fmt.Errorf("SSE reconnect failed: expected text/event-stream, got %q", resp.Header.Get("Content-Type"))
+	}
+	request.DisableResponseBodyDeadline(resp)
+	body, err := c.decompressedResponseBody(resp)
+	if err != nil {
+		_ = resp.Body.Close()
+		return nil, false, fmt.Errorf("decompressing response: %w", err)
+	}
+	return body, false, nil
+}
@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/rest-sh/restish/v2/internal/output"
 	"github.com/spf13/cobra"
@@ -107,7 +108,7 @@ func (c *CLI) handleSSE(cmd *cobra.Command, resp *http.Response, prepared *prepa
 	base := streamBaseResponse(resp)
 	return c.runPrintSpec(cmd, base, prepared, spec, func() (err error) {
 		if collectStreamingJSON(gf) {
-			return c.collectSSE(cmd, resp)
+			return c.collectSSE(cmd, resp, prepared)
 		}
 		renderer, err := c.newValueRendererWithPrint(cmd, base, gf.Filter != "", spec)
 		if err != nil {
@@ -119,7 +120,7 @@ func (c *CLI) handleSSE(cmd *cobra.Command, resp *http.Response, prepared *prepa
 			}
 		}()
 
-		return c.readSSEItems(cmd, resp.Body, gf.Filter != "", gf.MaxItems, func(item streamItem) error {
+		return c.readSSEStream(cmd, resp, prepared, gf.Filter != "", func(item streamItem) error {
 			return c.renderStreamValue(cmd, renderer, item.value, item.parsedJSON)
 		})
 	})
@@ -159,7 +160,11 @@ func sseStreamItem(data, eventName, eventID string, retryMs int, wrapData bool)
 	return streamItem{value: item, parsedJSON: itemParsedJSON}
 }
 
-func (c *CLI) readSSEItems(cmd *cobra.Command, r io.Reader, wrapData bool, maxItems int, emit func(streamItem) error) error {
+// readSSEItems parses events from r and passes each one to emit. When resume
+// is non-nil, the item count carries over from earlier connections, the last
+// event id and retry delay are recorded, and events whose id was already
+// emitted are skipped.
+func (c *CLI) readSSEItems(cmd *cobra.Command, r io.Reader, wrapData bool, maxItems int, resume *sseResume, emit func(streamItem) error) error {
 	scanner := bufio.NewScanner(r)
 	lineLimit := maxStreamLineBytes(cmd)
 	eventLimit := maxSSEEventBytes(cmd)
@@ -167,22 +172,42 @@ func (c *CLI) readSSEItems(cmd *cobra.Command, r io.Reader, wrapData bool, maxIt
 
 	var eventName string
 	var eventID string
+	var hasID bool
 	var retryMs int
 	var data strings.Builder
 	count := 0
 	stoppedByMax := false
+	if resume != nil {
+		count = resume.count
+		defer func() {
+			resume.count = count
+			resume.stopped = stoppedByMax
+		}()
+	}
 
 	flush := func() error {
 		if data.Len() == 0 && eventName == "" && eventID == "" && retryMs == 0 {
 			return nil
 		}
-		item := sseStreamItem(data.String(), eventName, eventID, retryMs, wrapData)
-		if err := emit(item); err != nil {
-			return err
+		// Like EventSource, the id to resume from only changes when an
+		// event is dispatched, never for one still being received.
+		if resume != nil && hasID && !strings.ContainsRune(eventID, 0) {
+			resume.lastID = eventID
+		}
+		replayed := resume != nil && eventID != "" && !resume.remember(eventID)
+		if !replayed {
+			item := sseStreamItem(data.String(), eventName, eventID, retryMs, wrapData)
+			if err := emit(item); err != nil {
+				return err
+			}
+			count++
+			if resume != nil {
+				resume.fresh = true
+			}
 		}
-		count++
 		eventName = ""
 		eventID = ""
+		hasID = false
 		retryMs = 0
 		data.Reset()
 		if maxItems > 0 && count >= maxItems {
@@ -229,9 +254,13 @@ func (c *CLI) readSSEItems(cmd *cobra.Command, r io.Reader, wrapData bool, maxIt
 			eventName = value
 		case "id":
 			eventID = value
+			hasID = true
 		case "retry":
 			if retry, err := strconv.Atoi(value); err == nil {
 				retryMs = retry
+				if resume != nil && retry >= 0 {
+					resume.retry = time.Duration(retry) * time.Millisecond
+				}
 			}
 		}
 	}
@@ -240,9 +269,12 @@ func (c *CLI) readSSEItems(cmd *cobra.Command, r io.Reader, wrapData bool, maxIt
 		if strings.Contains(err.Error(), "token too long") {
 			return fmt.Errorf("SSE stream line exceeds %d bytes", lineLimit)
 		}
-		return fmt.Errorf("SSE stream error: %w", err)
+		return fmt.Errorf("%w: %w", errSSEStream, err)
 	}
-	if !stoppedByMax {
+	// An event cut off by the end of the stream is incomplete. When
+	// reconnecting it is dropped, so the server resends it in full after the
+	// last dispatched id.
+	if !stoppedByMax && resume == nil {
 		if err := flush(); err != nil {
 			return err
 		}
@@ -300,10 +332,10 @@ func (c *CLI) collectNDJSON(cmd *cobra.Command, resp *http.Response) error {
 	return c.renderValue(cmd, items, false)
 }
 
-func (c *CLI) collectSSE(cmd *cobra.Command, resp *http.Response) error {
+func (c *CLI) collectSSE(cmd *cobra.Command, resp *http.Response, prepared *preparedRequest) error {
 	gf := globalFlagsFromContext(requestContext(cmd))
 	items := make([]any, 0, gf.MaxItems)
-	if err := c.readSSEItems(cmd, resp.Body, gf.Filter != "", gf.MaxItems, func(item streamItem) error {
+	if err := c.readSSEStream(cmd, resp, prepared, gf.Filter != "", func(item streamItem) error {
 		value, err := c.filterBodyValue(cmd, item.value)
 		if err != nil {
 			return err
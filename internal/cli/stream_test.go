@@ -1020,3 +1020,103 @@ func stripANSI(s string) string {
 	}
 	return out.String()
 }
+
+func TestSSEReconnectResumesWithLastEventIDAndSkipsReplays(t *testing.T) {
+	app := newTestApp(t)
+	app.WriteConfig(`{"apis":{"feed":{"base_url":"https://api.example.com","profiles":{"default":{
+  "auth":{"type":"bearer","params":{"token":"s3cr3t"}}}}}}}`)
+	var lastEventIDs []string
+	app.UseTransport(func(r *http.Request) (*http.Response, error) {
+		if got := r.Header.Get("Authorization"); got != "Bearer s3cr3t" {
+			t.Errorf("request %d Authorization = %q", len(lastEventIDs)+1, got)
+		}
+		lastEventIDs = append(lastEventIDs, r.Header.Get("Last-Event-ID"))
+		switch len(lastEventIDs) {
+		case 1:
+			return textResponse(http.StatusOK, "text/event-stream", "retry: 1\nid: 1\ndata: {\"n\":1}\n\nid: 2\ndata: {\"n\":2}\n\n", r), nil
+		case 2:
+			return textResponse(http.StatusOK, "text/event-stream", "id: 2\ndata: {\"n\":2}\n\nid: 3\ndata: {\"n\":3}\n\n", r), nil
+		default:
+			return textResponse(http.StatusNoContent, "text/event-stream", "", r), nil
+		}
+	})
+
+	app.Run("get", "https://api.example.com/events", "--rsh-reconnect", "-f", "body.data.n", "-o", "lines")
+
+	if got := app.Stdout.String(); got != "1\n2\n3\n" {
+		t.Fatalf("stdout = %q", got)
+	}
+	if want := []string{"", "2", "3"}; fmt.Sprint(lastEventIDs) != fmt.Sprint(want) {
+		t.Fatalf("Last-Event-ID headers = %q, want %q", lastEventIDs, want)
+	}
+	if got := app.Stderr.String(); !strings.Contains(got, `reconnecting in 1ms with Last-Event-ID "2"`) {
+		t.Fatalf("stderr = %q", got)
+	}
+}
+
+func TestSSEReconnectDropsEventCutOffMidStream(t *testing.T) {
+	app := newTestApp(t)
+	var lastEventIDs []string
+	app.UseTransport(func(r *http.Request) (*http.Response, error) {
+		lastEventIDs = append(lastEventIDs, r.Header.Get("Last-Event-ID"))
+		switch len(lastEventIDs) {
+		case 1:
+			// The connection drops after event 2's id and first data line.
+			return textResponse(http.StatusOK, "text/event-stream", "retry: 1\nid: 1\ndata: one\n\nid: 2\ndata: tw", r), nil
+		case 2:
+			return textResponse(http.StatusOK, "text/event-stream", "id: 2\ndata: two\n\n", r), nil
+		default:
+			return textResponse(http.StatusNoContent, "text/event-stream", "", r), nil
+		}
+	})
+
+	app.Run("get", "https://api.example.com/events", "--rsh-reconnect", "-f", "body.data", "-o", "lines")
+
+	if got := app.Stdout.String(); got != "one\ntwo\n" {
+		t.Fatalf("stdout = %q, want the cut-off event once, in full", got)
+	}
+	if want := []string{"", "1", "2"}; fmt.Sprint(lastEventIDs) != fmt.Sprint(want) {
+		t.Fatalf("Last-Event-ID headers = %q, want %q", lastEventIDs, want)
+	}
+}
+
+func TestSSEReconnectCountsMaxItemsAcrossConnections(t *testing.T) {
+	app := newTestApp(t)
+	calls := 0
+	app.UseTransport(func(r *http.Request) (*http.Response, error) {
+		calls++
+		return textResponse(http.StatusOK, "text/event-stream", fmt.Sprintf("id: %d\ndata: %d\n\n", calls, calls), r), nil
+	})
+
+	app.Run("get", "https://api.example.com/events", "--rsh-reconnect", "--rsh-max-items", "3", "-f", "body.data", "-o", "lines")
+
+	if got := app.Stdout.String(); got != "1\n2\n3\n" {
+		t.Fatalf("stdout = %q", got)
+	}
+	if calls != 3 {
+		t.Fatalf("calls = %d, want 3", calls)
+	}
+}
+
+func TestSSEReconnectStopsOnClientError(t *testing.T) {
+	app := newTestApp(t)
+	calls := 0
+	app.UseTransport(func(r *http.Request) (*http.Response, error) {
+		calls++
+		if calls > 1 {
+			return jsonResponse(http.StatusForbidden, `{"detail":"revoked"}`), nil
+		}
+		return textResponse(http.StatusOK, "text/event-stream", sseBody(`{"n":1}`), r), nil
+	})
+
+	err := app.RunErr("get", "https://api.example.com/events", "--rsh-reconnect", "-o", "ndjson")
+	if err == nil || !strings.Contains(err.Error(), "403") {
+		t.Fatalf("expected 403 reconnect error, got %v", err)
+	}
+	if calls != 2 {
+		t.Fatalf("calls = %d, want 2", calls)
+	}
+	if got := app.Stdout.String(); got != "{\"n\":1}\n" {
+		t.Fatalf("stdout = %q", got)
+	}
+}
@@ -0,0 +1,151 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/rest-sh/restish/v2/auth"
+)
+
+const (
+	tokenCacheKeyringService = "restish"
+	tokenCacheKeyringAccount = "token-cache-key"
+	tokenCachePassphraseEnv  = "RSH_TOKEN_CACHE_PASSPHRASE"
+)
+
+// tokenStore returns the OAuth token cache for this invocation, encrypted
+// according to token_cache.encryption. The store is shared so a passphrase is
+// requested and stretched at most once per process.
+func (c *CLI) tokenStore() *auth.TokenCache {
+	path := c.tokenCachePath()
+	encryption := c.tokenCacheEncryption()
+	keyFile := c.tokenCacheKeyFile()
+	sig := path + "\x00" + encryption + "\x00" + keyFile
+	if c.tokenCache != nil && c.tokenCacheSig == sig {
+		return c.tokenCache
+	}
+	var tc *auth.TokenCache
+	switch encryption {
+	case "keyring":
+		tc = auth.NewEncryptedTokenCache(path, auth.StaticTokenCacheKey("keyring", func() ([]byte, error) {
+			return c.tokenCacheKeyringKey(path)
+		}))
+	case "passphrase":
+		tc = auth.NewEncryptedTokenCache(path, auth.PassphraseTokenCacheKey(c.tokenCachePassphrase))
+	case "key-file":
+		key := auth.KeyFileTokenCacheKey(keyFile)
+		if err := checkTokenCacheKeyFile(path, keyFile); err != nil {
+			key = auth.StaticTokenCacheKey("key-file", func() ([]byte, error) { return nil, err })
+		}
+		tc = auth.NewEncryptedTokenCache(path, key)
+	default:
+		tc = auth.NewTokenCache(path)
+	}
+	c.tokenCache = tc
+	c.tokenCacheSig = sig
+	return tc
+}
+
+func (c *CLI) tokenCacheEncryption() string {
+	if c.cfg == nil || c.cfg.TokenCache.Encryption == "none" {
+		return ""
+	}
+	return c.cfg.TokenCache.Encryption
+}
+
+func (c *CLI) tokenCacheKeyFile() string {
+	if c.cfg == nil || c.cfg.TokenCache.KeyFile == "" {
+		return ""
+	}
+	return expandHomePath(c.cfg.TokenCache.KeyFile)
+}
+
+// checkTokenCacheKeyFile refuses a key-file key stored in the token cache's
+// directory: anyone who can read the cache can read the key too, so the
+// encryption would protect nothing.
+func checkTokenCacheKeyFile(cachePath, keyFile string) error {
+	if keyFile == "" {
+		return fmt.Errorf("token_cache.key_file is required for key-file encryption")
+	}
+	cacheDir, err := filepath.Abs(filepath.Dir(cachePath))
+	if err != nil {
+		return err
+	}
+	keyPath, err := filepath.Abs(keyFile)
+	if err != nil {
+		return err
+	}
+	if rel, err := filepath.Rel(cacheDir, keyPath); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("token_cache.key_file %s is inside the token cache directory %s; move the key elsewhere, such as a separate volume", keyFile, cacheDir)
+	}
+	return nil
+}
+
+// tokenCacheKeyringKey loads the cache key from the OS keyring, creating one
+// on first use. A new key is only generated while no keyring-encrypted cache
+// exists, so a locked or unavailable keyring never orphans cached tokens.
+func (c *CLI) tokenCacheKeyringKey(path string) ([]byte, error) {
+	value, err := c.keyringSecret(tokenCacheKeyringService, tokenCacheKeyringAccount)
+	if err == nil {
+		return auth.DecodeTokenCacheKey(value)
+	}
+	if backend, statErr := auth.TokenCacheFileBackend(path); statErr != nil || backend == "keyring" {
+		return nil, err
+	}
+	key, encoded, genErr := auth.NewTokenCacheKey()
+	if genErr != nil {
+		return nil, genErr
+	}
+	if storeErr := c.storeKeyringSecret(tokenCacheKeyringService, tokenCacheKeyringAccount, encoded); storeErr != nil {
+		return nil, fmt.Errorf("store new key in keyring: %w", storeErr)
+	}
+	return key, nil
+}
+
+func (c *CLI) tokenCachePassphrase() (string, error) {
+	if value := os.Getenv(tokenCachePassphraseEnv); value != "" {
+		return value, nil
+	}
+	if !c.canPromptCode() {
+		return "", fmt.Errorf("set %s or run interactively to unlock the token cache", tokenCachePassphraseEnv)
+	}
+	ctx := c.runCtx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return c.Secret(ctx, "Token cache passphrase")
+}
+
+func (c *CLI) storeKeyringSecret(service, account, value string) error {
+	if c.hooks.KeyringStoreFunc != nil {
+		return c.hooks.KeyringStoreFunc(service, account, value)
+	}
+	name, args, stdin, err := keyringStoreCommand(runtime.GOOS, service, account, value)
+	if err != nil {
+		return err
+	}
+	_, err = c.runSecretProcess(func(ctx context.Context) *exec.Cmd {
+		cmd := exec.CommandContext(ctx, name, args...)
+		cmd.Stdin = strings.NewReader(stdin)
+		return cmd
+	})
+	return err
+}
+
+// keyringStoreCommand returns the platform keyring CLI invocation that stores
+// value. The value is passed on stdin so it never appears in a process list.
+func keyringStoreCommand(goos, service, account, value string) (string, []string, string, error) {
+	switch goos {
+	case "darwin":
+		return "security", []string{"-i"}, fmt.Sprintf("add-generic-password -U -s %q -a %q -w %q\n", service, account, value), nil
+	case "windows":
+		return "", nil, "", fmt.Errorf("keyring token cache encryption is not supported on Windows; use key-file or passphrase")
+	default:
+		return "secret-tool", []string{"store", "--label=Restish " + account, "service", service, "account", account}, value, nil
+	}
+}
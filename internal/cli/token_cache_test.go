@@ -0,0 +1,185 @@
+package cli_test
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/rest-sh/restish/v2/auth"
+)
+
+const encryptedTokenCacheConfig = `{
+	"token_cache": {"encryption": "%s"},
+	"apis": {
+		"myapi": {
+			"base_url": "https://api.example.com",
+			"profiles": {
+				"default": {
+					"auth": {
+						"type": "oauth-client-credentials",
+						"params": {"client_id": "id", "client_secret": "secret", "token_url": "https://auth.example.com/token"}
+					}
+				}
+			}
+		}
+	}
+}`
+
+func TestTokenCacheKeyringEncryptionCreatesKeyOnFirstUse(t *testing.T) {
+	c, out, _ := newTestCLI(t)
+	tokenRequests := 0
+	useTransport(c, func(r *http.Request) (*http.Response, error) {
+		if r.URL.Host == "auth.example.com" {
+			tokenRequests++
+			return jsonResponse(200, `{"access_token":"cached-access-token","token_type":"Bearer","expires_in":3600}`), nil
+		}
+		return jsonResponse(200, `{}`), nil
+	})
+	keyring := map[string]string{}
+	c.Hooks().KeyringFunc = func(service, account string) (string, error) {
+		if v, ok := keyring[service+"/"+account]; ok {
+			return v, nil
+		}
+		return "", errors.New("no secret found")
+	}
+	c.Hooks().KeyringStoreFunc = func(service, account, value string) error {
+		keyring[service+"/"+account] = value
+		return nil
+	}
+	writeTestFile(t, c.Hooks().ConfigPath, strings.Replace(encryptedTokenCacheConfig, "%s", "keyring", 1))
+
+	for range 2 {
+		if err := c.Run([]string{"restish", "get", "myapi/items"}); err != nil {
+			t.Fatalf("get: %v", err)
+		}
+	}
+	if tokenRequests != 1 {
+		t.Fatalf("token requests = %d, want the cached token reused", tokenRequests)
+	}
+	if _, ok := keyring["restish/token-cache-key"]; !ok {
+		t.Fatalf("keyring = %v, want a generated token cache key", keyring)
+	}
+	data, err := os.ReadFile(c.Hooks().TokenCachePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("cached-access-token")) {
+		t.Fatal("token cache stored the access token in plaintext")
+	}
+	if backend, _ := auth.TokenCacheFileBackend(c.Hooks().TokenCachePath); backend != "keyring" {
+		t.Fatalf("cache backend = %q, want keyring", backend)
+	}
+
+	// A keyring that cannot be read must not replace the key of an existing
+	// encrypted cache.
+	delete(keyring, "restish/token-cache-key")
+	c2, _, _ := newTestCLI(t)
+	c2.Hooks().ConfigPath = c.Hooks().ConfigPath
+	c2.Hooks().TokenCachePath = c.Hooks().TokenCachePath
+	c2.Hooks().KeyringFunc = c.Hooks().KeyringFunc
+	c2.Hooks().KeyringStoreFunc = c.Hooks().KeyringStoreFunc
+	useTransport(c2, func(r *http.Request) (*http.Response, error) { return jsonResponse(200, `{}`), nil })
+	if err := c2.Run([]string{"restish", "get", "myapi/items"}); err == nil {
+		t.Fatal("expected an error when the keyring key is missing")
+	}
+	if len(keyring) != 0 {
+		t.Fatalf("keyring = %v, want no replacement key", keyring)
+	}
+
+	out.Reset()
+	if err := c.Run([]string{"restish", "doctor"}); err != nil {
+		t.Fatalf("doctor: %v", err)
+	}
+	if got := out.String(); !strings.Contains(got, "Token cache encryption: keyring") {
+		t.Fatalf("doctor output missing encryption backend:\n%s", got)
+	}
+}
+
+func TestTokenCachePassphraseEncryptionMigratesPlaintext(t *testing.T) {
+	t.Setenv("RSH_TOKEN_CACHE_PASSPHRASE", "correct horse")
+	c, out, _ := newTestCLI(t)
+	tokenRequests := 0
+	var gotAuth string
+	useTransport(c, func(r *http.Request) (*http.Response, error) {
+		if r.URL.Host == "auth.example.com" {
+			tokenRequests++
+			return jsonResponse(200, `{"access_token":"plaintext-token","token_type":"Bearer","expires_in":3600}`), nil
+		}
+		gotAuth = r.Header.Get("Authorization")
+		return jsonResponse(200, `{}`), nil
+	})
+	writeTestFile(t, c.Hooks().ConfigPath, strings.Replace(encryptedTokenCacheConfig, "%s", "none", 1))
+	if err := c.Run([]string{"restish", "get", "myapi/items"}); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if backend, _ := auth.TokenCacheFileBackend(c.Hooks().TokenCachePath); backend != "plaintext" {
+		t.Fatalf("cache backend = %q, want plaintext", backend)
+	}
+
+	writeTestFile(t, c.Hooks().ConfigPath, strings.Replace(encryptedTokenCacheConfig, "%s", "passphrase", 1))
+	out.Reset()
+	if err := c.Run([]string{"restish", "doctor"}); err != nil {
+		t.Fatalf("doctor: %v", err)
+	}
+	if got := out.String(); !strings.Contains(got, "Token cache encryption: passphrase (plaintext cache will be encrypted on next use)") {
+		t.Fatalf("doctor output missing migration note:\n%s", got)
+	}
+
+	if err := c.Run([]string{"restish", "get", "myapi/items"}); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if tokenRequests != 1 || gotAuth != "Bearer plaintext-token" {
+		t.Fatalf("token requests = %d, Authorization = %q, want the migrated token", tokenRequests, gotAuth)
+	}
+	data, _ := os.ReadFile(c.Hooks().TokenCachePath)
+	if bytes.Contains(data, []byte("plaintext-token")) {
+		t.Fatal("token cache was not encrypted after migration")
+	}
+	if backend, _ := auth.TokenCacheFileBackend(c.Hooks().TokenCachePath); backend != "passphrase" {
+		t.Fatalf("cache backend = %q, want passphrase", backend)
+	}
+}
+
+func TestTokenCacheKeyFileMustLiveOutsideTheCacheDirectory(t *testing.T) {
+	c, out, _ := newTestCLI(t)
+	useTransport(c, func(r *http.Request) (*http.Response, error) {
+		if r.URL.Host == "auth.example.com" {
+			return jsonResponse(200, `{"access_token":"cached-access-token","token_type":"Bearer","expires_in":3600}`), nil
+		}
+		return jsonResponse(200, `{}`), nil
+	})
+	besideCache := filepath.Join(filepath.Dir(c.Hooks().TokenCachePath), "token-cache.key")
+	configFor := func(keyFile string) string {
+		return strings.Replace(encryptedTokenCacheConfig, `"encryption": "%s"`, `"encryption": "key-file", "key_file": `+strconv.Quote(keyFile), 1)
+	}
+
+	writeTestFile(t, c.Hooks().ConfigPath, configFor(besideCache))
+	err := c.Run([]string{"restish", "get", "myapi/items"})
+	if err == nil || !strings.Contains(err.Error(), "inside the token cache directory") {
+		t.Fatalf("err = %v, want the key beside the cache refused", err)
+	}
+	if _, statErr := os.Stat(besideCache); !errors.Is(statErr, os.ErrNotExist) {
+		t.Fatalf("key file was created beside the cache: %v", statErr)
+	}
+	out.Reset()
+	if err := c.Run([]string{"restish", "doctor"}); err != nil {
+		t.Fatalf("doctor: %v", err)
+	}
+	if got := out.String(); !strings.Contains(got, "Token cache encryption: key-file (token_cache.key_file") {
+		t.Fatalf("doctor output does not flag the key location:\n%s", got)
+	}
+
+	keyFile := filepath.Join(t.TempDir(), "restish.key")
+	writeTestFile(t, c.Hooks().ConfigPath, configFor(keyFile))
+	if err := c.Run([]string{"restish", "get", "myapi/items"}); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if backend, _ := auth.TokenCacheFileBackend(c.Hooks().TokenCachePath); backend != "key-file" {
+		t.Fatalf("cache backend = %q, want key-file", backend)
+	}
+}
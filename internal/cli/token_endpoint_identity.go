@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	authpkg "github.com/rest-sh/restish/v2/internal/auth"
+	"github.com/rest-sh/restish/v2/internal/request"
+)
+
+// tokenEndpointIdentity is a profile's TLS client identity used to
+// authenticate to OAuth token endpoints with private_key_jwt or RFC 8705
+// mTLS. It is loaded at most once per run because a tls-signer plugin is a
+// subprocess, and released when Run returns.
+type tokenEndpointIdentity struct {
+	opts    request.Options
+	optsErr error
+
+	once   sync.Once
+	cfg    *tls.Config
+	cert   *tls.Certificate
+	closer io.Closer
+	err    error
+}
+
+// tokenEndpointIdentity returns the shared TLS client identity for an API
+// profile, merging --rsh-client-cert style flags over profile settings the
+// same way requests to the API do.
+func (c *CLI) tokenEndpointIdentity(ctx context.Context, apiName, profileName string) *tokenEndpointIdentity {
+	opts, err := c.discoveryTransportOptions(ctx, c.cfg.APIs[apiName], profileName)
+	if err != nil {
+		return &tokenEndpointIdentity{optsErr: err}
+	}
+	key := discoveryTransportShareKeyFromOptions(opts)
+	if id := c.tokenEndpointIdentities[key]; id != nil {
+		return id
+	}
+	id := &tokenEndpointIdentity{opts: opts}
+	if c.tokenEndpointIdentities == nil {
+		c.tokenEndpointIdentities = map[discoveryTransportShareKey]*tokenEndpointIdentity{}
+	}
+	c.tokenEndpointIdentities[key] = id
+	return id
+}
+
+func (c *CLI) closeTokenEndpointIdentities() {
+	for _, id := range c.tokenEndpointIdentities {
+		if id.closer != nil {
+			_ = id.closer.Close()
+		}
+	}
+	c.tokenEndpointIdentities = nil
+}
+
+func (id *tokenEndpointIdentity) load() {
+	id.once.Do(func() {
+		if id.optsErr != nil {
+			id.err = id.optsErr
+			return
+		}
+		if id.opts.ClientCertPath == "" && id.opts.ClientKeyPath == "" && id.opts.TLSSignerPath == "" {
+			id.err = fmt.Errorf("the profile has no client_cert and client_key or tls_signer")
+			return
+		}
+		id.cfg, id.closer, id.err = request.TLSConfigWithCleanupFromOptions(id.opts)
+		if id.err != nil {
+			return
+		}
+		if len(id.cfg.Certificates) > 0 {
+			id.cert = &id.cfg.Certificates[0]
+			return
+		}
+		id.cert, id.err = id.cfg.GetClientCertificate(&tls.CertificateRequestInfo{})
+	})
+}
+
+// certificate returns the identity for private_key_jwt assertions.
+func (id *tokenEndpointIdentity) certificate() (*tls.Certificate, error) {
+	id.load()
+	return id.cert, id.err
+}
+
+// httpClient returns a token endpoint client that presents the identity
+// during the TLS handshake, for tls_client_auth and
+// self_signed_tls_client_auth.
+func (id *tokenEndpointIdentity) httpClient(base http.RoundTripper) *http.Client {
+	var (
+		once      sync.Once
+		transport http.RoundTripper
+		err       error
+	)
+	return &http.Client{Transport: tokenEndpointRoundTripper(func(req *http.Request) (*http.Response, error) {
+		once.Do(func() {
+			id.load()
+			if err = id.err; err != nil {
+				return
+			}
+			tr, ok := base.(*http.Transport)
+			if !ok {
+				err = fmt.Errorf("custom base transport does not support TLS client certificates")
+				return
+			}
+			cloned := tr.Clone()
+			cloned.TLSClientConfig = id.cfg
+			transport = cloned
+		})
+		if err != nil {
+			return nil, fmt.Errorf("token endpoint mTLS: %w", err)
+		}
+		return transport.RoundTrip(req)
+	})}
+}
+
+type tokenEndpointRoundTripper func(*http.Request) (*http.Response, error)
+
+func (f tokenEndpointRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// withTokenEndpointIdentity attaches the profile's TLS client identity when
+// the auth params authenticate to the token endpoint with it, returning the
+// context and token endpoint HTTP client the handler should use.
+func (c *CLI) withTokenEndpointIdentity(ctx context.Context, apiName, profileName string, params map[string]string, client *http.Client) (context.Context, *http.Client) {
+	if !authpkg.UsesProfileClientCertificate(params) || c.cfg == nil {
+		return ctx, client
+	}
+	id := c.tokenEndpointIdentity(ctx, apiName, profileName)
+	ctx = authpkg.WithClientCertificate(ctx, id.certificate)
+	if authpkg.IsTLSClientAuthMethod(params["auth_method"]) {
+		client = id.httpClient(c.baseHTTPTransport())
+	}
+	return ctx, client
+}
@@ -0,0 +1,196 @@
+package cli_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newClientIdentityFiles writes a self-signed ECDSA client certificate and
+// key, returning their paths and the certificate DER.
+func newClientIdentityFiles(t *testing.T) (certPath, keyPath string, der []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "restish-client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err = x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create cert: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+	dir := t.TempDir()
+	certPath = filepath.Join(dir, "client.crt")
+	keyPath = filepath.Join(dir, "client.key")
+	writeTestFile(t, certPath, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})))
+	writeTestFile(t, keyPath, string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})))
+	return certPath, keyPath, der
+}
+
+// newTokenEndpointServer starts a TLS server that asks for client
+// certificates, returning it and the path of its CA certificate.
+func newTokenEndpointServer(t *testing.T, handler http.HandlerFunc) (*httptest.Server, string) {
+	t.Helper()
+	server := httptest.NewUnstartedServer(handler)
+	server.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	caPath := filepath.Join(t.TempDir(), "ca.pem")
+	writeTestFile(t, caPath, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})))
+	return server, caPath
+}
+
+func TestPrivateKeyJWTSignsWithProfileClientCertificate(t *testing.T) {
+	certPath, keyPath, certDER := newClientIdentityFiles(t)
+	var form map[string][]string
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		form = r.PostForm
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"jwt-client-token","token_type":"Bearer","expires_in":3600}`))
+	}))
+	defer tokenServer.Close()
+	var apiAuth string
+	server, caPath := newTokenEndpointServer(t, func(w http.ResponseWriter, r *http.Request) {
+		apiAuth = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{}`))
+	})
+	c, _, _ := newTestCLI(t)
+	c.Hooks().ConfigPath = writeAPIConfig(t, `{
+		"apis": {
+			"svc": {
+				"base_url": "`+server.URL+`",
+				"profiles": {"default": {
+					"client_cert": "`+filepath.ToSlash(certPath)+`",
+					"client_key": "`+filepath.ToSlash(keyPath)+`",
+					"ca_cert": "`+filepath.ToSlash(caPath)+`",
+					"auth": {"type": "oauth-client-credentials", "params": {"client_id": "svc-client", "auth_method": "private_key_jwt", "token_url": "`+tokenServer.URL+`/token"}}
+				}}
+			}
+		}
+	}`)
+
+	if err := c.Run([]string{"restish", "get", "svc/items"}); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if apiAuth != "Bearer jwt-client-token" {
+		t.Fatalf("Authorization = %q", apiAuth)
+	}
+	if got := strings.Join(form["client_assertion_type"], ","); got != "urn:ietf:params:oauth:client-assertion-type:jwt-bearer" {
+		t.Fatalf("client_assertion_type = %q", got)
+	}
+	if _, ok := form["client_secret"]; ok {
+		t.Fatalf("client_secret sent with private_key_jwt: %#v", form)
+	}
+	parts := strings.Split(strings.Join(form["client_assertion"], ""), ".")
+	if len(parts) != 3 {
+		t.Fatalf("client_assertion is not a JWS: %#v", form)
+	}
+	var header map[string]string
+	var claims map[string]any
+	decodeJWTSegment(t, parts[0], &header)
+	decodeJWTSegment(t, parts[1], &claims)
+	sum := sha256.Sum256(certDER)
+	if header["alg"] != "ES256" || header["x5t#S256"] != base64.RawURLEncoding.EncodeToString(sum[:]) {
+		t.Fatalf("header = %#v", header)
+	}
+	if claims["iss"] != "svc-client" || claims["sub"] != "svc-client" || claims["aud"] != tokenServer.URL+"/token" {
+		t.Fatalf("claims = %#v", claims)
+	}
+}
+
+func TestTLSClientAuthPresentsProfileCertificateToTokenEndpoint(t *testing.T) {
+	certPath, keyPath, certDER := newClientIdentityFiles(t)
+	var tokenPeer []byte
+	var clientID string
+	server, caPath := newTokenEndpointServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/token" {
+			if len(r.TLS.PeerCertificates) > 0 {
+				tokenPeer = r.TLS.PeerCertificates[0].Raw
+			}
+			_ = r.ParseForm()
+			clientID = r.PostForm.Get("client_id")
+			_, _ = w.Write([]byte(`{"access_token":"mtls-token","token_type":"Bearer","expires_in":3600}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"auth":"` + r.Header.Get("Authorization") + `"}`))
+	})
+	c, out, _ := newTestCLI(t)
+	c.Hooks().ConfigPath = writeAPIConfig(t, `{
+		"apis": {
+			"svc": {
+				"base_url": "`+server.URL+`",
+				"profiles": {"default": {
+					"client_cert": "`+filepath.ToSlash(certPath)+`",
+					"client_key": "`+filepath.ToSlash(keyPath)+`",
+					"ca_cert": "`+filepath.ToSlash(caPath)+`",
+					"auth": {"type": "oauth-client-credentials", "params": {"client_id": "svc-client", "auth_method": "self_signed_tls_client_auth", "token_url": "`+server.URL+`/token"}}
+				}}
+			}
+		}
+	}`)
+
+	if err := c.Run([]string{"restish", "get", "svc/items"}); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if string(tokenPeer) != string(certDER) {
+		t.Fatal("token endpoint did not receive the profile client certificate")
+	}
+	if clientID != "svc-client" || !strings.Contains(out.String(), "Bearer mtls-token") {
+		t.Fatalf("client_id = %q, output = %s", clientID, out.String())
+	}
+}
+
+func TestTLSClientAuthRequiresProfileCertificate(t *testing.T) {
+	c, _, _ := newTestCLI(t)
+	c.Hooks().ConfigPath = writeAPIConfig(t, `{
+		"apis": {
+			"svc": {
+				"base_url": "https://svc.example.com",
+				"profiles": {"default": {"auth": {"type": "oauth-client-credentials", "params": {"client_id": "svc-client", "auth_method": "tls_client_auth", "token_url": "https://auth.example.com/token"}}}}
+			}
+		}
+	}`)
+	err := c.Run([]string{"restish", "get", "svc/items"})
+	if err == nil || !strings.Contains(err.Error(), "no client_cert and client_key or tls_signer") {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func decodeJWTSegment(t *testing.T, segment string, v any) {
+	t.Helper()
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		t.Fatalf("decode segment: %v", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatalf("unmarshal segment: %v", err)
+	}
+}
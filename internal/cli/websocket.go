@@ -0,0 +1,557 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/danielgtaylor/shorthand/v2"
+	"github.com/rest-sh/restish/v2/config"
+	"github.com/rest-sh/restish/v2/internal/output"
+	"github.com/rest-sh/restish/v2/internal/request"
+	"github.com/rest-sh/restish/v2/internal/spec"
+	"github.com/rest-sh/restish/v2/internal/websocket"
+	"github.com/spf13/cobra"
+)
+
+// webSocketCloseWait is how long Restish waits for the server to answer its
+// close frame before dropping the connection.
+const webSocketCloseWait = 2 * time.Second
+
+// webSocketOptions carries the request details a generated WebSocket
+// operation resolves from its parameters and security requirements.
+type webSocketOptions struct {
+	extraHeaders  []string
+	noAuth        bool
+	apiName       string
+	operationAuth *operationAuthPolicy
+	// validate checks an outbound message against the operation's message
+	// schema when --rsh-validate is set.
+	validate func(message []byte) error
+}
+
+// addWebSocketCommand registers the "ws" subcommand on root.
+func (c *CLI) addWebSocketCommand(root *cobra.Command) {
+	name := c.commandNameOrDefault()
+	cmd := &cobra.Command{
+		Use:     "ws <url> [message...]",
+		Short:   "Open a WebSocket connection",
+		Long:    webSocketLong,
+		GroupID: rootGroupHTTP,
+		Example: fmt.Sprintf(`  %s ws wss://realtime.example.com/feed
+  %s ws example/events 'type: subscribe, channel: orders' -f body.data
+  tail -f commands.ndjson | %s ws example/control --subprotocol v2.control`, name, name, name),
+		Annotations: map[string]string{
+			requestHelpAnnotation: "true",
+		},
+		Args:              usageMinimumNArgs(1),
+		ValidArgsFunction: c.completeHTTPURL(http.MethodGet),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.runWebSocket(cmd, args[0], args[1:], webSocketOptions{})
+		},
+	}
+	addWebSocketFlags(cmd)
+	root.AddCommand(cmd)
+}
+
+// addWebSocketFlags registers the session flags shared by "ws" and generated
+// WebSocket operations.
+func addWebSocketFlags(cmd *cobra.Command) {
+	cmd.Flags().StringArray("subprotocol", nil, "WebSocket subprotocol to offer, in preference order (repeatable)")
+	cmd.Flags().Duration("ping-interval", 0, "Send a ping this often to keep the connection alive (0 disables)")
+	cmd.Flags().Duration("idle-timeout", 0, "Close the connection after this long without a message (0 waits for the server)")
+}
+
+// runWebSocket performs the WebSocket handshake through the request pipeline,
+// so profile headers, auth, and TLS settings apply as for any request, then
+// sends messages and renders each received message as a stream item.
+func (c *CLI) runWebSocket(cmd *cobra.Command, rawURL string, messageArgs []string, wsOpts webSocketOptions) error {
+	gf := globalFlagsFromContext(requestContext(cmd))
+	if err := c.validateHTTPOutputFlags(cmd, gf); err != nil {
+		return err
+	}
+	if err := validateStreamingOutputMode(cmd); err != nil {
+		return err
+	}
+	messages, err := webSocketArgMessages(messageArgs)
+	if err != nil {
+		return err
+	}
+	if wsOpts.validate != nil {
+		for i, message := range messages {
+			if err := wsOpts.validate(message); err != nil {
+				return fmt.Errorf("message %d: %w", i+1, err)
+			}
+		}
+	}
+	protocols, _ := cmd.Flags().GetStringArray("subprotocol")
+	for i := range protocols {
+		protocols[i] = strings.TrimSpace(protocols[i])
+	}
+	pingInterval, _ := cmd.Flags().GetDuration("ping-interval")
+	idleTimeout, _ := cmd.Flags().GetDuration("idle-timeout")
+
+	c.requestExecutionStarted = true
+	trace := ensureRequestTrace(cmd)
+	opts, err := c.httpOptsFromFlags(cmd)
+	if err != nil {
+		return err
+	}
+	// A handshake response is never reusable, and the cache would consume
+	// the upgraded connection while trying to store it.
+	opts.NoCache = true
+	key, err := websocket.NewKey()
+	if err != nil {
+		return err
+	}
+	headers := append(websocket.HandshakeHeaders(key, protocols), wsOpts.extraHeaders...)
+	profileName := c.profileFromCmd(cmd)
+	authOpts, err := c.authHandlerOptionsFromCmd(cmd)
+	if err != nil {
+		return err
+	}
+	prepared, err := c.prepareRequest(requestContext(cmd), http.MethodGet, webSocketHTTPURL(rawURL), profileName, opts, nil, headers, wsOpts.noAuth, authOpts, wsOpts.operationAuth, false, wsOpts.apiName)
+	if err != nil {
+		return err
+	}
+	defer c.closePreparedTransport(prepared)
+	c.populateRequestTrace(trace, prepared.apiName, profileName, "", prepared)
+	trace.RenderBefore(c.Stderr, gf.Verbose)
+
+	httpResp, err := c.sendPreparedRequest(requestContext(cmd), http.MethodGet, prepared)
+	if err != nil {
+		if isLocalRequestExecutionError(err) {
+			return err
+		}
+		networkURL := redactedNetworkErrorURL(prepared.rawURL, prepared.opts.Server)
+		if hint := networkErrorHint(err); hint != "" {
+			return fmt.Errorf("network error for WebSocket %s: %w\nhint: %s", networkURL, err, hint)
+		}
+		return fmt.Errorf("network error for WebSocket %s: %w", networkURL, err)
+	}
+	trace.Step("HTTP")
+	if httpResp.StatusCode != http.StatusSwitchingProtocols {
+		return c.webSocketHandshakeRejected(cmd, httpResp, prepared)
+	}
+	request.DisableResponseBodyDeadline(httpResp)
+	rw, ok := request.UpgradedConn(httpResp)
+	if !ok {
+		_ = httpResp.Body.Close()
+		return errors.New("WebSocket handshake: the HTTP transport does not expose upgraded connections")
+	}
+	defer rw.Close()
+	selected, err := websocket.CheckHandshake(httpResp, key, protocols)
+	if err != nil {
+		return fmt.Errorf("WebSocket handshake: %w", err)
+	}
+	if selected != "" && gf.Verbose >= 1 {
+		style := humanTextStyleFor(c.Stderr)
+		fmt.Fprintf(c.Stderr, "%s %s %s\n", style.info("*"), style.key("Subprotocol:"), selected)
+	}
+
+	session := newWebSocketSession(requestContext(cmd), websocket.NewConn(rw, true, maxBodyBytes(cmd)), rw)
+	defer session.stop()
+	readStdin := len(messageArgs) == 0 && c.Stdin != nil
+	go session.send(c, gf.Verbose, messages, readStdin, wsOpts.validate)
+	if pingInterval > 0 {
+		go session.keepAlive(pingInterval)
+	}
+	if idleTimeout > 0 {
+		session.idleAfter(idleTimeout)
+	}
+	return c.renderWebSocketMessages(cmd, httpResp, prepared, session)
+}
+
+// webSocketHandshakeRejected reports a handshake the server answered with an
+// ordinary HTTP response. Error responses are printed like any other so the
+// server's explanation is visible.
+func (c *CLI) webSocketHandshakeRejected(cmd *cobra.Command, httpResp *http.Response, prepared *preparedRequest) error {
+	defer httpResp.Body.Close()
+	if httpResp.StatusCode < 400 {
+		return fmt.Errorf("WebSocket handshake with %s failed: server responded %d without switching protocols", redactedNetworkErrorURL(prepared.rawURL, prepared.opts.Server), httpResp.StatusCode)
+	}
+	resp, err := c.normalizeHTTPResponse(httpResp, maxBodyBytes(cmd))
+	if err != nil {
+		return err
+	}
+	if err := c.formatResponse(cmd, resp, prepared); err != nil {
+		return err
+	}
+	return c.statusError(cmd, resp.Status)
+}
+
+// renderWebSocketMessages reads messages until the session ends and renders
+// each one through the shared stream path, so filters, output formats,
+// --rsh-max-items, and --rsh-collect behave as for SSE and NDJSON.
+func (c *CLI) renderWebSocketMessages(cmd *cobra.Command, httpResp *http.Response, prepared *preparedRequest, session *webSocketSession) error {
+	gf := globalFlagsFromContext(requestContext(cmd))
+	base := streamBaseResponse(httpResp)
+	spec, err := c.resolvePrintSpec(gf, c.stdoutIsTerminal(), printStreamResponse)
+	if err != nil {
+		return err
+	}
+	if gf.Silent {
+		return session.read(gf.MaxItems, c.warnf, func(websocket.Message) error { return nil })
+	}
+	if !spec.includesResponseBody() {
+		session.close(websocket.CloseNormal)
+		return c.runPrintSpec(cmd, base, prepared, spec, nil)
+	}
+	if spec.rawBodyOnly() {
+		return session.read(gf.MaxItems, c.warnf, func(msg websocket.Message) error {
+			if _, err := c.Stdout.Write(msg.Data); err != nil {
+				return err
+			}
+			if !msg.Binary && !strings.HasSuffix(string(msg.Data), "\n") {
+				if _, err := io.WriteString(c.Stdout, "\n"); err != nil {
+					return err
+				}
+			}
+			return c.flushStdout()
+		})
+	}
+	return c.runPrintSpec(cmd, base, prepared, spec, func() (err error) {
+		if collectStreamingJSON(gf) {
+			items := make([]any, 0, gf.MaxItems)
+			if err := session.read(gf.MaxItems, c.warnf, func(msg websocket.Message) error {
+				value, _ := webSocketMessageValue(msg)
+				filtered, err := c.filterBodyValue(cmd, value)
+				if err != nil {
+					return err
+				}
+				items = append(items, filtered)
+				return nil
+			}); err != nil {
+				return err
+			}
+			return c.renderValue(cmd, items, false)
+		}
+		renderer, err := c.newValueRendererWithPrint(cmd, base, gf.Filter != "", spec)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if closeErr := renderer.Close(); err == nil && closeErr != nil {
+				err = closeErr
+			}
+		}()
+		return session.read(gf.MaxItems, c.warnf, func(msg websocket.Message) error {
+			value, parsedJSON := webSocketMessageValue(msg)
+			return c.renderStreamValue(cmd, renderer, value, parsedJSON)
+		})
+	})
+}
+
+// webSocketMessageValue converts a received message to a stream item. Text
+// messages that hold JSON are decoded; binary messages are base64 encoded so
+// every output format can show them.
+func webSocketMessageValue(msg websocket.Message) (any, bool) {
+	if msg.Binary {
+		return base64.StdEncoding.EncodeToString(msg.Data), false
+	}
+	return parseJSONOrString(string(msg.Data))
+}
+
+// webSocketArgMessages parses each positional argument as shorthand into one
+// message. Strings are sent as-is and everything else as compact JSON.
+func webSocketArgMessages(args []string) ([][]byte, error) {
+	messages := make([][]byte, 0, len(args))
+	for i, arg := range args {
+		value, parseErr := shorthand.Unmarshal(arg, shorthand.ParseOptions{EnableFileInput: true, EnableObjectDetection: true}, nil)
+		if parseErr != nil {
+			return nil, fmt.Errorf("message %d: %w", i+1, parseErr)
+		}
+		if s, ok := value.(string); ok {
+			messages = append(messages, []byte(s))
+			continue
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("message %d: %w", i+1, err)
+		}
+		messages = append(messages, data)
+	}
+	return messages, nil
+}
+
+// webSocketHTTPURL maps ws:// and wss:// URLs to the http:// and https://
+// URLs the handshake request is sent to. Other targets, such as API short
+// names, pass through unchanged.
+func webSocketHTTPURL(rawURL string) string {
+	lower := strings.ToLower(rawURL)
+	switch {
+	case strings.HasPrefix(lower, "ws://"):
+		return "http://" + rawURL[len("ws://"):]
+	case strings.HasPrefix(lower, "wss://"):
+		return "https://" + rawURL[len("wss://"):]
+	}
+	return rawURL
+}
+
+// webSocketSession tracks one open connection: who started closing it and
+// the timers that end it.
+type webSocketSession struct {
+	ctx     context.Context
+	conn    *websocket.Conn
+	rw      io.Closer
+	closing atomic.Bool
+
+	closeOnce sync.Once
+	stopCtx   func() bool
+	done      chan struct{}
+
+	mu   sync.Mutex
+	idle *time.Timer
+	wait time.Duration
+}
+
+func newWebSocketSession(ctx context.Context, conn *websocket.Conn, rw io.Closer) *webSocketSession {
+	s := &webSocketSession{ctx: ctx, conn: conn, rw: rw, done: make(chan struct{})}
+	s.stopCtx = context.AfterFunc(ctx, func() { s.close(websocket.CloseGoingAway) })
+	return s
+}
+
+// close starts the closing handshake and drops the connection if the server
+// does not answer within webSocketCloseWait.
+func (s *webSocketSession) close(code int) {
+	s.closeOnce.Do(func() {
+		s.closing.Store(true)
+		_ = s.conn.Close(code, "")
+		time.AfterFunc(webSocketCloseWait, func() { _ = s.rw.Close() })
+	})
+}
+
+func (s *webSocketSession) stop() {
+	s.stopCtx()
+	s.mu.Lock()
+	if s.idle != nil {
+		s.idle.Stop()
+	}
+	s.mu.Unlock()
+	close(s.done)
+}
+
+// send writes the argument messages, then stdin lines when readStdin is set.
+// Stdin lines that fail validate are skipped with a warning. Input ending does
+// not close the session; replies keep arriving until the server closes, a
+// limit is reached, or the user interrupts.
+func (s *webSocketSession) send(c *CLI, verbose int, messages [][]byte, readStdin bool, validate func([]byte) error) {
+	write := func(data []byte) bool {
+		if verbose >= 1 {
+			c.logVerboseBody("> message", data, "")
+		}
+		if err := s.conn.WriteText(data); err != nil {
+			if !s.closing.Load() && s.ctx.Err() == nil {
+				c.warnf("sending WebSocket message: %v", err)
+			}
+			return false
+		}
+		return true
+	}
+	for _, message := range messages {
+		if !write(message) {
+			return
+		}
+	}
+	if !readStdin {
+		return
+	}
+	err := readLines(c.Stdin, func(line string) bool {
+		if strings.TrimSpace(line) == "" {
+			return true
+		}
+		if validate != nil {
+			if err := validate([]byte(line)); err != nil {
+				c.warnf("skipping WebSocket message from stdin: %v", err)
+				return true
+			}
+		}
+		return write([]byte(line))
+	})
+	if err != nil && !s.closing.Load() {
+		c.warnf("reading WebSocket messages from stdin: %v", err)
+	}
+}
+
+func (s *webSocketSession) keepAlive(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			if s.conn.Ping(nil) != nil {
+				return
+			}
+		}
+	}
+}
+
+func (s *webSocketSession) idleAfter(timeout time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.wait = timeout
+	s.idle = time.AfterFunc(timeout, func() { s.close(websocket.CloseNormal) })
+}
+
+func (s *webSocketSession) resetIdle() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.idle != nil {
+		s.idle.Reset(s.wait)
+	}
+}
+
+// read delivers messages to emit until the session ends. A close the user or
+// a limit started ends it quietly; an abnormal close by the server is
+// returned as exit code 1 with its status code and reason.
+func (s *webSocketSession) read(maxItems int, warnf func(string, ...any), emit func(websocket.Message) error) error {
+	count := 0
+	for {
+		msg, err := s.conn.ReadMessage()
+		if err != nil {
+			if ctxErr := s.ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			if s.closing.Load() {
+				return nil
+			}
+			var closeErr *websocket.CloseError
+			if errors.As(err, &closeErr) {
+				if closeErr.Normal() {
+					return nil
+				}
+				return &ExitCodeError{Code: 1, Cause: closeErr}
+			}
+			return fmt.Errorf("WebSocket read: %w", err)
+		}
+		s.resetIdle()
+		if err := emit(msg); err != nil {
+			s.close(websocket.CloseNormal)
+			return err
+		}
+		count++
+		if maxItems > 0 && count >= maxItems {
+			warnf("streaming stopped at --rsh-max-items=%d; pass 0 for unlimited", maxItems)
+			s.close(websocket.CloseNormal)
+			return nil
+		}
+	}
+}
+
+// runGeneratedWebSocketOp opens a session for an operation that declares a
+// 101 response. Parameters fill the handshake URL and headers as for any
+// generated operation; remaining arguments are messages.
+func (c *CLI) runGeneratedWebSocketOp(cmd *cobra.Command, apiName string, op spec.Operation, required, optional []*paramInfo, args []string) error {
+	path := op.Path
+	var query []generatedQueryParam
+	var extraHeaders []string
+	for i, p := range required {
+		if err := validateGeneratedParamValues(p, args[i:i+1], "argument "+p.flagName); err != nil {
+			return err
+		}
+		var err error
+		path, query, extraHeaders, err = addGeneratedParam(path, query, extraHeaders, p, args[i:i+1])
+		if err != nil {
+			return err
+		}
+	}
+	for _, p := range optional {
+		if !cmd.Flags().Changed(p.flagName) {
+			continue
+		}
+		values, err := generatedFlagValues(cmd, p)
+		if err != nil {
+			return err
+		}
+		if err := validateGeneratedParamValues(p, values, "--"+p.flagName); err != nil {
+			return err
+		}
+		path, query, extraHeaders, err = addGeneratedParam(path, query, extraHeaders, p, values)
+		if err != nil {
+			return err
+		}
+	}
+
+	rawURL := apiName + path
+	if op.OperationServer != "" {
+		apiCfg, err := c.requireAPI(apiName)
+		if err != nil {
+			return err
+		}
+		rawURL = strings.TrimRight(op.OperationServer, "/") + path
+		_, rewritten, err := config.ApplyURLOverrides(rawURL, effectiveURLOverrides(apiCfg, c.profileFromCmd(cmd)))
+		if err != nil {
+			return fmt.Errorf("url_overrides: %w", err)
+		}
+		if !rewritten && !config.OperationOriginAllowed(op.OperationServer, apiCfg.AllowedOperationOrigins) {
+			return fmt.Errorf("operation server %s is outside API base_url and is not allowed; add allowed_operation_origins[]: %s", operationServerOrigin(op.OperationServer), suggestedOperationOrigin(op.OperationServer))
+		}
+	} else if baseURL, operationBase := c.generatedOperationBase(cmd, apiName); operationBase != "" {
+		resolvedBase, err := config.ResolveOperationBaseURL(baseURL, operationBase)
+		if err != nil {
+			return fmt.Errorf("operation_base: %w", err)
+		}
+		rawURL = strings.TrimRight(resolvedBase, "/") + path
+	}
+	if qs := encodeGeneratedQuery(query); qs != "" {
+		rawURL += "?" + qs
+	}
+
+	gf := globalFlagsFromContext(requestContext(cmd))
+	var validate func([]byte) error
+	if validateMessages, _ := cmd.Flags().GetBool("rsh-validate"); validateMessages {
+		message, err := c.generatedBodyExampleRequest(op.Help, "")
+		if err != nil {
+			return err
+		}
+		if message == nil {
+			message = &spec.OperationBodyHelp{}
+		}
+		color := output.ColorEnabled(c.Stderr)
+		validate = func(data []byte) error {
+			value, _ := parseJSONOrString(string(data))
+			return validateGeneratedJSONBody(value, "", message.MediaType, message.JSONSchema, message.JSONSchemaDialect, color)
+		}
+	}
+	return c.runWebSocket(cmd, rawURL, args[len(required):], webSocketOptions{
+		extraHeaders: extraHeaders,
+		noAuth:       op.NoAuth,
+		apiName:      apiName,
+		operationAuth: &operationAuthPolicy{
+			OptionalAuth:           op.OptionalAuth,
+			NoAuth:                 op.NoAuth,
+			CredentialAlternatives: op.CredentialAlternatives,
+			Override:               gf.Auth,
+		},
+		validate: validate,
+	})
+}
+
+// readLines calls fn with each line of r, without the line ending, until fn
+// returns false or r is exhausted.
+func readLines(r io.Reader, fn func(string) bool) error {
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" && !fn(strings.TrimRight(line, "\r\n")) {
+			return nil
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,288 @@
+package cli_test
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/rest-sh/restish/v2/internal/cli"
+	"github.com/rest-sh/restish/v2/internal/websocket"
+)
+
+// useWebSocketServer answers handshakes with a 101 response whose body is one
+// end of a loopback TCP connection, and runs serve on the other end.
+func useWebSocketServer(t *testing.T, c *cli.CLI, check func(*http.Request), serve func(*websocket.Conn)) {
+	t.Helper()
+	useTransport(c, func(req *http.Request) (*http.Response, error) {
+		if check != nil {
+			check(req)
+		}
+		if !strings.EqualFold(req.Header.Get("Upgrade"), "websocket") || req.Header.Get("Sec-WebSocket-Version") != "13" {
+			t.Errorf("request is not a WebSocket handshake: %v", req.Header)
+		}
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			return nil, err
+		}
+		defer ln.Close()
+		accepted := make(chan net.Conn, 1)
+		go func() {
+			conn, _ := ln.Accept()
+			accepted <- conn
+		}()
+		client, err := net.Dial("tcp", ln.Addr().String())
+		if err != nil {
+			return nil, err
+		}
+		server := <-accepted
+		go func() {
+			defer server.Close()
+			serve(websocket.NewConn(server, false, 0))
+		}()
+		header := http.Header{}
+		header.Set("Connection", "Upgrade")
+		header.Set("Upgrade", "websocket")
+		header.Set("Sec-WebSocket-Accept", websocket.AcceptKey(req.Header.Get("Sec-WebSocket-Key")))
+		if offered := req.Header.Get("Sec-WebSocket-Protocol"); offered != "" {
+			protocol, _, _ := strings.Cut(offered, ",")
+			header.Set("Sec-WebSocket-Protocol", strings.TrimSpace(protocol))
+		}
+		return &http.Response{
+			StatusCode: http.StatusSwitchingProtocols,
+			Proto:      "HTTP/1.1",
+			Header:     header,
+			Body:       client,
+			Request:    req,
+		}, nil
+	})
+}
+
+func writeWebSocketTestConfig(t *testing.T, c *cli.CLI, extra string) {
+	t.Helper()
+	configBody := `{"apis":{"rt":{"base_url":"https://api.example.com"` + extra + `,"profiles":{"default":{
+  "headers":["X-Tenant: acme"],"auth":{"type":"bearer","params":{"token":"s3cr3t"}}}}}}}`
+	if err := os.WriteFile(c.Hooks().ConfigPath, []byte(configBody), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWebSocketSendsMessagesWithProfileAuthAndRendersFrames(t *testing.T) {
+	c, stdout, stderr := newTestCLI(t)
+	writeWebSocketTestConfig(t, c, "")
+	received := make(chan string, 1)
+	closed := make(chan error, 1)
+	useWebSocketServer(t, c, func(req *http.Request) {
+		if req.Method != http.MethodGet || req.URL.String() != "https://api.example.com/feed" {
+			t.Errorf("handshake = %s %s", req.Method, req.URL)
+		}
+		if got := req.Header.Get("Authorization"); got != "Bearer s3cr3t" {
+			t.Errorf("Authorization = %q", got)
+		}
+		if got := req.Header.Get("X-Tenant"); got != "acme" {
+			t.Errorf("X-Tenant = %q", got)
+		}
+		if got := req.Header.Get("Sec-WebSocket-Protocol"); got != "v2.feed, v1.feed" {
+			t.Errorf("Sec-WebSocket-Protocol = %q", got)
+		}
+	}, func(conn *websocket.Conn) {
+		msg, err := conn.ReadMessage()
+		if err != nil {
+			closed <- err
+			return
+		}
+		received <- string(msg.Data)
+		_ = conn.Ping([]byte("hb"))
+		_ = conn.WriteText([]byte(`{"n":1}`))
+		_ = conn.WriteText([]byte(`{"n":2}`))
+		_ = conn.WriteText([]byte(`{"n":3}`))
+		for {
+			if _, err := conn.ReadMessage(); err != nil {
+				closed <- err
+				return
+			}
+		}
+	})
+
+	err := c.Run([]string{"restish", "ws", "wss://api.example.com/feed", "type: subscribe, channel: orders",
+		"--subprotocol", "v2.feed", "--subprotocol", "v1.feed", "-f", "body.n", "-o", "lines", "--rsh-max-items", "2"})
+	if err != nil {
+		t.Fatalf("ws: %v\nstderr: %s", err, stderr.String())
+	}
+	if got := <-received; got != `{"channel":"orders","type":"subscribe"}` {
+		t.Fatalf("sent message = %s", got)
+	}
+	if got := stdout.String(); got != "1\n2\n" {
+		t.Fatalf("stdout = %q", got)
+	}
+	if !strings.Contains(stderr.String(), "--rsh-max-items=2") {
+		t.Fatalf("stderr = %s", stderr.String())
+	}
+	var closeErr *websocket.CloseError
+	if err := <-closed; !errors.As(err, &closeErr) || closeErr.Code != websocket.CloseNormal {
+		t.Fatalf("server saw %v, want a normal close", err)
+	}
+}
+
+func TestWebSocketAbnormalServerCloseExitsNonZero(t *testing.T) {
+	c, stdout, _ := newTestCLI(t)
+	useWebSocketServer(t, c, nil, func(conn *websocket.Conn) {
+		_ = conn.WriteText([]byte("hello"))
+		_ = conn.Close(websocket.CloseInternalError, "shutting down")
+		_, _ = conn.ReadMessage()
+	})
+
+	err := c.Run([]string{"restish", "ws", "ws://realtime.example.com/socket"})
+	var exitErr *cli.ExitCodeError
+	if !errors.As(err, &exitErr) || exitErr.Code != 1 {
+		t.Fatalf("expected ExitCodeError{1}, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "1011") || !strings.Contains(err.Error(), "shutting down") {
+		t.Fatalf("error = %v", err)
+	}
+	if got := stdout.String(); got != "hello\n" {
+		t.Fatalf("stdout = %q", got)
+	}
+}
+
+func TestWebSocketSendsStdinLines(t *testing.T) {
+	c, stdout, _ := newTestCLI(t)
+	c.Stdin = strings.NewReader("{\"op\":\"a\"}\n\n{\"op\":\"b\"}\n")
+	useWebSocketServer(t, c, nil, func(conn *websocket.Conn) {
+		for range 2 {
+			msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			_ = conn.WriteText(append([]byte("ack "), msg.Data...))
+		}
+		_ = conn.Close(websocket.CloseNormal, "")
+		_, _ = conn.ReadMessage()
+	})
+
+	if err := c.Run([]string{"restish", "ws", "ws://realtime.example.com/socket"}); err != nil {
+		t.Fatalf("ws: %v", err)
+	}
+	if got := stdout.String(); got != "ack {\"op\":\"a\"}\nack {\"op\":\"b\"}\n" {
+		t.Fatalf("stdout = %q", got)
+	}
+}
+
+func TestWebSocketRejectedHandshakePrintsResponse(t *testing.T) {
+	c, stdout, _ := newTestCLI(t)
+	useTransport(c, func(req *http.Request) (*http.Response, error) {
+		return jsonResponse(http.StatusUnauthorized, `{"detail":"token expired"}`), nil
+	})
+
+	if err := c.Run([]string{"restish", "ws", "wss://realtime.example.com/socket"}); err == nil {
+		t.Fatal("expected an error for a 401 handshake response")
+	}
+	if !strings.Contains(stdout.String(), "token expired") {
+		t.Fatalf("stdout = %q", stdout.String())
+	}
+}
+
+func TestWebSocketGeneratedOperationFromSwitchingProtocolsResponse(t *testing.T) {
+	specPath := filepath.Join(t.TempDir(), "openapi.yaml")
+	if err := os.WriteFile(specPath, []byte(`openapi: 3.1.0
+info: {title: Realtime, version: "1"}
+paths:
+  /rooms/{room}/events:
+    get:
+      operationId: roomEvents
+      parameters:
+        - {name: room, in: path, required: true, schema: {type: string}}
+        - {name: since, in: query, schema: {type: integer}}
+      responses:
+        "101":
+          description: Switching to WebSocket
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c, stdout, _ := newTestCLI(t)
+	c.Hooks().SpecCachePath = t.TempDir()
+	writeWebSocketTestConfig(t, c, `,"spec_files":[`+strconv.Quote(specPath)+`]`)
+	received := make(chan string, 1)
+	useWebSocketServer(t, c, func(req *http.Request) {
+		if req.URL.String() != "https://api.example.com/rooms/lobby/events?since=5" {
+			t.Errorf("handshake URL = %s", req.URL)
+		}
+		if got := req.Header.Get("Authorization"); got != "Bearer s3cr3t" {
+			t.Errorf("Authorization = %q", got)
+		}
+	}, func(conn *websocket.Conn) {
+		msg, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		received <- string(msg.Data)
+		_ = conn.WriteText([]byte("welcome"))
+		_, _ = conn.ReadMessage()
+	})
+
+	if err := c.Run([]string{"restish", "rt", "room-events", "lobby", "hello", "--since", "5", "--rsh-max-items", "1"}); err != nil {
+		t.Fatalf("room-events: %v", err)
+	}
+	if got := <-received; got != "hello" {
+		t.Fatalf("sent message = %q", got)
+	}
+	if !strings.Contains(stdout.String(), "welcome") {
+		t.Fatalf("stdout = %q", stdout.String())
+	}
+}
+
+func TestWebSocketGeneratedAsyncAPIOperationValidatesMessages(t *testing.T) {
+	specPath := filepath.Join(t.TempDir(), "asyncapi.yaml")
+	if err := os.WriteFile(specPath, []byte(`asyncapi: 3.0.0
+info: {title: Chat, version: "1"}
+servers:
+  public: {host: api.example.com, protocol: wss}
+channels:
+  room:
+    address: /rooms/{room}
+    messages:
+      chat:
+        payload:
+          type: object
+          required: [text]
+          properties:
+            text: {type: string}
+operations:
+  chat:
+    action: receive
+    channel: {$ref: '#/channels/room'}
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c, stdout, _ := newTestCLI(t)
+	c.Hooks().SpecCachePath = t.TempDir()
+	writeWebSocketTestConfig(t, c, `,"spec_files":[`+strconv.Quote(specPath)+`]`)
+	received := make(chan string, 1)
+	useWebSocketServer(t, c, nil, func(conn *websocket.Conn) {
+		msg, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		received <- string(msg.Data)
+		_ = conn.WriteText([]byte(`{"text":"hi back"}`))
+		_, _ = conn.ReadMessage()
+	})
+
+	err := c.Run([]string{"restish", "rt", "chat", "lobby", "txt: hi", "--rsh-validate"})
+	if err == nil || !strings.Contains(err.Error(), "message 1:") || !strings.Contains(err.Error(), "text") {
+		t.Fatalf("expected message validation error, got %v", err)
+	}
+	if err := c.Run([]string{"restish", "rt", "chat", "lobby", "text: hi", "--rsh-validate", "--rsh-max-items", "1"}); err != nil {
+		t.Fatalf("chat: %v", err)
+	}
+	if got := <-received; got != `{"text":"hi"}` {
+		t.Fatalf("sent message = %q", got)
+	}
+	if !strings.Contains(stdout.String(), "hi back") {
+		t.Fatalf("stdout = %q", stdout.String())
+	}
+}
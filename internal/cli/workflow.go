@@ -0,0 +1,615 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/danielgtaylor/shorthand/v2"
+	"github.com/pb33f/libopenapi"
+	"github.com/pb33f/libopenapi/arazzo"
+	higharazzo "github.com/pb33f/libopenapi/datamodel/high/arazzo"
+	"github.com/rest-sh/restish/v2/internal/output"
+	"github.com/rest-sh/restish/v2/internal/request"
+	"github.com/rest-sh/restish/v2/internal/spec"
+	"github.com/spf13/cobra"
+)
+
+// workflowSourcePrefix starts Arazzo runtime expressions that name a source
+// description, as in "$sourceDescriptions.users.createUser".
+const workflowSourcePrefix = "$sourceDescriptions."
+
+// addWorkflowCommand registers the "workflow" command group on root.
+func (c *CLI) addWorkflowCommand(root *cobra.Command) {
+	name := c.commandNameOrDefault()
+	workflowCmd := &cobra.Command{
+		Use:     "workflow",
+		Short:   "Run multi-step Arazzo workflows against registered APIs",
+		Long:    workflowLong,
+		GroupID: rootGroupHTTP,
+		Example: fmt.Sprintf(`  %s workflow run onboarding.arazzo.yaml
+  %s workflow run onboarding.arazzo.yaml create-account --input email=ada@example.com`, name, name),
+		RunE: unknownSubcommandRun("workflow"),
+	}
+	runCmd := &cobra.Command{
+		Use:   "run <arazzo-file> [workflowId]",
+		Short: "Run an Arazzo workflow and print a step report",
+		Long:  workflowRunLong,
+		Example: fmt.Sprintf(`  %s workflow run provision.arazzo.yaml --input team=platform --input seats=5
+  %s workflow run teardown.arazzo.yaml remove-team --input team=platform -f body.outputs
+  %s workflow run flows.arazzo.yaml --source users=staging-users -o json`, name, name, name),
+		Annotations: map[string]string{
+			requestHelpAnnotation: "true",
+		},
+		Args: usageRangeArgs(1, 2),
+		RunE: c.runWorkflowCmd,
+	}
+	runCmd.Flags().StringArray("input", nil, `Workflow input in "name=value" format; the value is parsed as shorthand (repeatable)`)
+	runCmd.Flags().StringArray("source", nil, `Map a source description to a registered API in "source=api" format (repeatable)`)
+	workflowCmd.AddCommand(runCmd)
+	root.AddCommand(workflowCmd)
+}
+
+// workflowSource is a source description mapped to a registered API.
+type workflowSource struct {
+	name    string
+	apiName string
+	ops     spec.OperationSet
+}
+
+// workflowTarget is the operation a step calls.
+type workflowTarget struct {
+	source *workflowSource
+	op     spec.Operation
+}
+
+// workflowExecutor sends Arazzo steps through the normal request pipeline,
+// so profile auth, caching, retries, and TLS settings apply to every step.
+type workflowExecutor struct {
+	c        *CLI
+	cmd      *cobra.Command
+	opts     request.Options
+	authOpts authHandlerOptions
+	profile  string
+	sources  map[string]*workflowSource
+	order    []*workflowSource
+	// paramIn holds the "in" of step parameters by operation reference and
+	// name, since the engine passes step parameters by name only.
+	paramIn map[string]map[string]string
+	// requests reports every HTTP request sent, including retries and the
+	// steps of nested workflows.
+	requests []any
+}
+
+func (c *CLI) runWorkflowCmd(cmd *cobra.Command, args []string) error {
+	gf := globalFlagsFromContext(requestContext(cmd))
+	if err := c.validateHTTPOutputFlags(cmd, gf); err != nil {
+		return err
+	}
+	doc, err := loadArazzoDocument(args[0])
+	if err != nil {
+		return err
+	}
+	wf, err := selectArazzoWorkflow(doc, args[1:])
+	if err != nil {
+		return err
+	}
+	rawInputs, _ := cmd.Flags().GetStringArray("input")
+	inputs, err := workflowInputs(doc, wf, rawInputs)
+	if err != nil {
+		return err
+	}
+	rawSources, _ := cmd.Flags().GetStringArray("source")
+	mapping, err := parseKVStrings(rawSources)
+	if err != nil {
+		return newUsageError(fmt.Errorf("--source: %w", err))
+	}
+
+	c.requestExecutionStarted = true
+	ensureRequestTrace(cmd)
+	opts, err := c.httpOptsFromFlags(cmd)
+	if err != nil {
+		return err
+	}
+	authOpts, err := c.authHandlerOptionsFromCmd(cmd)
+	if err != nil {
+		return err
+	}
+	x := &workflowExecutor{
+		c:        c,
+		cmd:      cmd,
+		opts:     opts,
+		authOpts: authOpts,
+		profile:  c.profileFromCmd(cmd),
+		sources:  map[string]*workflowSource{},
+		paramIn:  workflowParamLocations(doc),
+	}
+	if err := x.mapSources(requestContext(cmd), doc, filepath.Dir(args[0]), mapping); err != nil {
+		return err
+	}
+	// Resolve every operation before sending anything, so a typo in step
+	// ten does not leave a provisioning run half done.
+	if err := x.checkOperations(doc); err != nil {
+		return err
+	}
+	addDefaultSuccessCriteria(doc)
+
+	var resolved []*arazzo.ResolvedSource
+	for _, source := range x.order {
+		resolved = append(resolved, &arazzo.ResolvedSource{Name: source.name, Type: "openapi"})
+	}
+	engine := arazzo.NewEngine(doc, x, resolved)
+	result, err := engine.RunWorkflow(requestContext(cmd), wf.WorkflowId, inputs)
+	if err != nil {
+		return err
+	}
+	report, err := c.filterBodyValue(cmd, workflowReport(result, x.requests))
+	if err != nil {
+		return err
+	}
+	if err := c.renderValue(cmd, report, gf.Filter != ""); err != nil {
+		return err
+	}
+	if !result.Success {
+		if !gf.IgnoreStatus {
+			c.warnf("workflow %q failed: %v", result.WorkflowId, result.Error)
+		}
+		return &ExitCodeError{Code: 1}
+	}
+	return nil
+}
+
+// loadArazzoDocument reads and validates an Arazzo 1.x document in YAML or
+// JSON.
+func loadArazzoDocument(path string) (*higharazzo.Arazzo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading Arazzo document: %w", err)
+	}
+	doc, err := libopenapi.NewArazzoDocument(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing Arazzo document %s: %w", path, err)
+	}
+	if !strings.HasPrefix(doc.Arazzo, "1.") {
+		return nil, fmt.Errorf("%s is not an Arazzo 1.x document (arazzo: %q)", path, doc.Arazzo)
+	}
+	if result := arazzo.Validate(doc); result != nil && result.HasErrors() {
+		return nil, fmt.Errorf("invalid Arazzo document %s: %w", path, result)
+	}
+	return doc, nil
+}
+
+// selectArazzoWorkflow returns the named workflow, or the only workflow
+// when no name is given.
+func selectArazzoWorkflow(doc *higharazzo.Arazzo, args []string) (*higharazzo.Workflow, error) {
+	var ids []string
+	for _, wf := range doc.Workflows {
+		if len(args) > 0 && wf.WorkflowId == args[0] {
+			return wf, nil
+		}
+		ids = append(ids, wf.WorkflowId)
+	}
+	if len(args) == 0 && len(doc.Workflows) == 1 {
+		return doc.Workflows[0], nil
+	}
+	if len(args) == 0 {
+		return nil, newUsageError(fmt.Errorf("the document defines several workflows; pass one of: %s", strings.Join(ids, ", ")))
+	}
+	return nil, newUsageError(fmt.Errorf("unknown workflow %q; available: %s", args[0], strings.Join(ids, ", ")))
+}
+
+// workflowInputs parses --input values and applies the defaults and
+// required list of the workflow's inputs schema. Values are shorthand,
+// except for properties declared as strings, which are taken verbatim.
+func workflowInputs(doc *higharazzo.Arazzo, wf *higharazzo.Workflow, raw []string) (map[string]any, error) {
+	var schema map[string]any
+	if wf.Inputs != nil {
+		if err := wf.Inputs.Decode(&schema); err != nil {
+			return nil, fmt.Errorf("workflow %q inputs schema: %w", wf.WorkflowId, err)
+		}
+	}
+	if ref, ok := schema["$ref"].(string); ok && doc.Components != nil && doc.Components.Inputs != nil {
+		if node, found := doc.Components.Inputs.Get(strings.TrimPrefix(ref, "#/components/inputs/")); found {
+			schema = nil
+			if err := node.Decode(&schema); err != nil {
+				return nil, fmt.Errorf("workflow %q inputs schema: %w", wf.WorkflowId, err)
+			}
+		}
+	}
+	properties, _ := schema["properties"].(map[string]any)
+	inputs := map[string]any{}
+	for _, item := range raw {
+		name, value, ok := strings.Cut(item, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, newUsageError(fmt.Errorf(`--input %q must be in "name=value" format`, item))
+		}
+		if property, _ := properties[name].(map[string]any); property["type"] == "string" {
+			inputs[name] = value
+			continue
+		}
+		parsed, err := shorthand.Unmarshal(value, shorthand.ParseOptions{EnableFileInput: true, EnableObjectDetection: true}, nil)
+		if err != nil {
+			return nil, fmt.Errorf("--input %s: %w", name, err)
+		}
+		inputs[name] = parsed
+	}
+	for name, value := range properties {
+		property, _ := value.(map[string]any)
+		if def, ok := property["default"]; ok {
+			if _, set := inputs[name]; !set {
+				inputs[name] = def
+			}
+		}
+	}
+	required, _ := schema["required"].([]any)
+	var missing []string
+	for _, name := range required {
+		if _, ok := inputs[fmt.Sprint(name)]; !ok {
+			missing = append(missing, fmt.Sprint(name))
+		}
+	}
+	if len(missing) > 0 {
+		return nil, newUsageError(fmt.Errorf("workflow %q needs input %s; pass --input NAME=VALUE", wf.WorkflowId, strings.Join(missing, ", ")))
+	}
+	return inputs, nil
+}
+
+// mapSources maps each OpenAPI source description to a registered API: an
+// explicit --source mapping, an API with the same name, or an API whose
+// spec_url or spec_files point at the source URL.
+func (x *workflowExecutor) mapSources(ctx context.Context, doc *higharazzo.Arazzo, docDir string, mapping map[string]string) error {
+	for name := range mapping {
+		if !hasSourceDescription(doc.SourceDescriptions, name) {
+			return newUsageError(fmt.Errorf("--source %s: the document has no source description %q", name, name))
+		}
+	}
+	for _, sd := range doc.SourceDescriptions {
+		if sd.Type != "" && sd.Type != "openapi" {
+			continue
+		}
+		apiName := mapping[sd.Name]
+		if apiName == "" {
+			apiName = x.c.workflowSourceAPI(sd, docDir)
+		}
+		if apiName == "" {
+			return fmt.Errorf("source description %q (%s) does not match a registered API; register it as %q or pass --source %s=API", sd.Name, sd.URL, sd.Name, sd.Name)
+		}
+		set, err := x.c.workflowOperationSet(ctx, apiName, x.profile)
+		if err != nil {
+			return fmt.Errorf("source description %q: %w", sd.Name, err)
+		}
+		source := &workflowSource{name: sd.Name, apiName: apiName, ops: set}
+		x.sources[sd.Name] = source
+		x.order = append(x.order, source)
+	}
+	return nil
+}
+
+func hasSourceDescription(sources []*higharazzo.SourceDescription, name string) bool {
+	for _, sd := range sources {
+		if sd.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// workflowSourceAPI finds the registered API for a source description by
+// name, then by spec location.
+func (c *CLI) workflowSourceAPI(sd *higharazzo.SourceDescription, docDir string) string {
+	if c.cfg == nil {
+		return ""
+	}
+	if c.cfg.APIs[sd.Name] != nil {
+		return sd.Name
+	}
+	location := sd.URL
+	if u, err := url.Parse(location); err != nil || u.Scheme == "" {
+		location = filepath.Clean(filepath.Join(docDir, location))
+	}
+	names := make([]string, 0, len(c.cfg.APIs))
+	for name := range c.cfg.APIs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		api := c.cfg.APIs[name]
+		if api.SpecURL != "" && api.SpecURL == sd.URL {
+			return name
+		}
+		for _, file := range api.SpecFiles {
+			if file == sd.URL || filepath.Clean(file) == location {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// workflowOperationSet returns an API's operations from the spec cache,
+// discovering the spec when it is not cached yet.
+func (c *CLI) workflowOperationSet(ctx context.Context, apiName, profileName string) (spec.OperationSet, error) {
+	apiCfg, err := c.requireAPI(apiName)
+	if err != nil {
+		return spec.OperationSet{}, err
+	}
+	opOpts := spec.OperationOptions{
+		BaseURL:         effectiveProfileBaseURL(apiCfg, profileName),
+		OperationBase:   effectiveOperationBase(apiCfg, profileName),
+		ServerVariables: effectiveServerVariables(apiCfg, profileName),
+	}
+	stateName := c.apiStateName(apiName)
+	if set, _, ok := spec.LoadOperationSetFromCacheStatus(c.specCacheDir(), stateName, Version, apiCfg.SpecFiles, opOpts, true); ok {
+		return set, nil
+	}
+	s, err := spec.LoadStaleFromCache(c.specCacheDir(), stateName, Version, apiCfg.SpecFiles, c.loaders)
+	if err != nil || s == nil {
+		s, err = c.discoverSpecForProfile(ctx, apiName, profileName, false, 0)
+	}
+	if err != nil {
+		return spec.OperationSet{}, err
+	}
+	if s == nil {
+		return spec.OperationSet{}, fmt.Errorf("API %q has no spec; run %q", apiName, c.commandNameOrDefault()+" api sync "+apiName)
+	}
+	set, err := s.OperationSet(opOpts)
+	if err != nil {
+		return spec.OperationSet{}, err
+	}
+	_ = spec.StoreOperationSetInCache(c.specCacheDir(), stateName, Version, opOpts, set)
+	return set, nil
+}
+
+// checkOperations resolves the operation of every step up front.
+func (x *workflowExecutor) checkOperations(doc *higharazzo.Arazzo) error {
+	for _, wf := range doc.Workflows {
+		for _, step := range wf.Steps {
+			if step.OperationId == "" && step.OperationPath == "" {
+				continue
+			}
+			if _, err := x.resolve(step.OperationId, step.OperationPath); err != nil {
+				return fmt.Errorf("workflow %q step %q: %w", wf.WorkflowId, step.StepId, err)
+			}
+		}
+	}
+	return nil
+}
+
+// resolve finds the operation for an operationId, which may be qualified
+// with a source description, or for an operationPath JSON pointer.
+func (x *workflowExecutor) resolve(operationID, operationPath string) (*workflowTarget, error) {
+	var target *workflowTarget
+	var err error
+	if operationID != "" {
+		target, err = x.resolveOperationID(operationID)
+	} else {
+		target, err = x.resolveOperationPath(operationPath)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if target.op.GraphQL != nil || target.op.RPC != nil || target.op.WebSocket {
+		return nil, fmt.Errorf("operation %q is not a plain HTTP operation and cannot run in a workflow", operationDisplayID(target.op))
+	}
+	return target, nil
+}
+
+func (x *workflowExecutor) resolveOperationID(ref string) (*workflowTarget, error) {
+	candidates := x.order
+	operationID := ref
+	if rest, ok := strings.CutPrefix(ref, workflowSourcePrefix); ok {
+		name, id, found := strings.Cut(rest, ".")
+		source := x.sources[name]
+		if !found || source == nil {
+			return nil, fmt.Errorf("operationId %q names an unknown source description", ref)
+		}
+		candidates = []*workflowSource{source}
+		operationID = id
+	}
+	var matches []*workflowTarget
+	for _, source := range candidates {
+		for _, op := range source.ops.Operations {
+			if op.ID == operationID {
+				matches = append(matches, &workflowTarget{source: source, op: op})
+			}
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("operation %q not found in %s", operationID, workflowSourceNames(candidates))
+	case 1:
+		return matches[0], nil
+	}
+	return nil, fmt.Errorf("operation %q is defined by several sources; qualify it as %s<source>.%s", operationID, workflowSourcePrefix, operationID)
+}
+
+// resolveOperationPath resolves references like
+// "{$sourceDescriptions.users.url}#/paths/~1users~1{id}/get".
+func (x *workflowExecutor) resolveOperationPath(ref string) (*workflowTarget, error) {
+	location, pointer, ok := strings.Cut(ref, "#")
+	segments := strings.Split(strings.TrimPrefix(pointer, "/"), "/")
+	if !ok || len(segments) != 3 || segments[0] != "paths" {
+		return nil, fmt.Errorf("operationPath %q must look like {$sourceDescriptions.NAME.url}#/paths/PATH/METHOD", ref)
+	}
+	path := strings.NewReplacer("~1", "/", "~0", "~").Replace(segments[1])
+	method := strings.ToUpper(segments[2])
+	candidates := x.order
+	if _, rest, found := strings.Cut(location, workflowSourcePrefix); found {
+		name, _, _ := strings.Cut(rest, ".")
+		source := x.sources[name]
+		if source == nil {
+			return nil, fmt.Errorf("operationPath %q names an unknown source description", ref)
+		}
+		candidates = []*workflowSource{source}
+	}
+	for _, source := range candidates {
+		for _, op := range source.ops.Operations {
+			// Operation paths include any server base path prefix.
+			if op.Method == method && (op.Path == path || strings.HasSuffix(op.Path, path)) {
+				return &workflowTarget{source: source, op: op}, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("operation %s %s not found in %s", method, path, workflowSourceNames(candidates))
+}
+
+func workflowSourceNames(sources []*workflowSource) string {
+	names := make([]string, 0, len(sources))
+	for _, source := range sources {
+		names = append(names, fmt.Sprintf("%s (API %s)", source.name, source.apiName))
+	}
+	return strings.Join(names, ", ")
+}
+
+// workflowParamLocations indexes the "in" of every step parameter by
+// operation reference and name.
+func workflowParamLocations(doc *higharazzo.Arazzo) map[string]map[string]string {
+	index := map[string]map[string]string{}
+	for _, wf := range doc.Workflows {
+		for _, step := range wf.Steps {
+			ref := step.OperationId + step.OperationPath
+			if ref == "" {
+				continue
+			}
+			for _, param := range step.Parameters {
+				name, in := param.Name, param.In
+				if component, ok := strings.CutPrefix(param.Reference, "$components.parameters."); ok && doc.Components != nil && doc.Components.Parameters != nil {
+					if shared, found := doc.Components.Parameters.Get(component); found {
+						name, in = shared.Name, shared.In
+					}
+				}
+				if name == "" || in == "" {
+					continue
+				}
+				if index[ref] == nil {
+					index[ref] = map[string]string{}
+				}
+				index[ref][name] = in
+			}
+		}
+	}
+	return index
+}
+
+// addDefaultSuccessCriteria makes operation steps without successCriteria
+// succeed only on a 2xx status, so a failed call stops the run instead of
+// silently feeding an error body to later steps.
+func addDefaultSuccessCriteria(doc *higharazzo.Arazzo) {
+	for _, wf := range doc.Workflows {
+		for _, step := range wf.Steps {
+			if step.WorkflowId == "" && len(step.SuccessCriteria) == 0 {
+				step.SuccessCriteria = []*higharazzo.Criterion{{Context: "$statusCode", Condition: `^2\d\d$`, Type: "regex"}}
+			}
+		}
+	}
+}
+
+// Execute sends one step's request and returns its normalized response.
+func (x *workflowExecutor) Execute(ctx context.Context, req *arazzo.ExecutionRequest) (*arazzo.ExecutionResponse, error) {
+	start := time.Now()
+	target, err := x.resolve(req.OperationID, req.OperationPath)
+	if err != nil {
+		return nil, err
+	}
+	op := target.op
+	resp, rawURL, err := x.send(ctx, target, req)
+	report := map[string]any{
+		"api":         target.source.apiName,
+		"operation":   operationDisplayID(op),
+		"method":      op.Method,
+		"url":         rawURL,
+		"duration_ms": time.Since(start).Milliseconds(),
+	}
+	x.requests = append(x.requests, report)
+	if err != nil {
+		report["error"] = err.Error()
+		return nil, err
+	}
+	report["status"] = resp.Status
+	return &arazzo.ExecutionResponse{
+		StatusCode: resp.Status,
+		Headers:    resp.Headers,
+		Body:       resp.Body,
+		URL:        rawURL,
+		Method:     op.Method,
+	}, nil
+}
+
+func (x *workflowExecutor) send(ctx context.Context, target *workflowTarget, req *arazzo.ExecutionRequest) (*output.Response, string, error) {
+	names := make([]string, 0, len(req.Parameters))
+	for name := range req.Parameters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	locations := x.paramIn[req.OperationID+req.OperationPath]
+	args := make([]operationArg, 0, len(names))
+	for _, name := range names {
+		args = append(args, operationArg{name: name, in: locations[name], value: req.Parameters[name]})
+	}
+	resp, prepared, err := x.c.sendOperation(ctx, x.cmd, target.source.apiName, x.profile, x.opts, x.authOpts, target.op, args, req.RequestBody, req.ContentType)
+	// The report goes to stdout and CI logs, so query credentials added by
+	// auth or the profile are redacted like other diagnostic URLs.
+	var actualURL string
+	if prepared != nil {
+		actualURL = redactedNetworkErrorURL(prepared.rawURL, prepared.opts.Server)
+		if prepared.actualRequest != nil {
+			actualURL = request.RedactedRequestURL(prepared.actualRequest)
+		}
+	}
+	return resp, actualURL, err
+}
+
+// workflowReport builds the structured run report.
+func workflowReport(result *arazzo.WorkflowResult, requests []any) map[string]any {
+	steps := make([]any, 0, len(result.Steps))
+	failedStep := ""
+	for _, step := range result.Steps {
+		item := map[string]any{
+			"step_id":     step.StepId,
+			"success":     step.Success,
+			"duration_ms": step.Duration.Milliseconds(),
+		}
+		if step.StatusCode != 0 {
+			item["status"] = step.StatusCode
+		}
+		if step.Retries > 0 {
+			item["retries"] = step.Retries
+		}
+		if len(step.Outputs) > 0 {
+			item["outputs"] = step.Outputs
+		}
+		if step.Error != nil {
+			item["error"] = step.Error.Error()
+			failedStep = step.StepId
+		}
+		steps = append(steps, item)
+	}
+	if requests == nil {
+		requests = []any{}
+	}
+	outputs := result.Outputs
+	if outputs == nil {
+		outputs = map[string]any{}
+	}
+	report := map[string]any{
+		"workflow_id": result.WorkflowId,
+		"success":     result.Success,
+		"duration_ms": result.Duration.Milliseconds(),
+		"steps":       steps,
+		"requests":    requests,
+		"outputs":     outputs,
+	}
+	if result.Error != nil {
+		report["error"] = result.Error.Error()
+		if failedStep != "" {
+			report["failed_step"] = failedStep
+		}
+	}
+	return report
+}
@@ -0,0 +1,242 @@
+package cli_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rest-sh/restish/v2/internal/cli"
+)
+
+const workflowTestSpec = `openapi: 3.1.0
+info: {title: Users, version: 1.0.0}
+paths:
+  /users:
+    post:
+      operationId: createUser
+      requestBody:
+        content:
+          application/json:
+            schema: {type: object}
+      responses:
+        "201": {description: created}
+  /users/{id}:
+    get:
+      operationId: getUser
+      parameters:
+        - {name: id, in: path, required: true, schema: {type: string}}
+        - {name: expand, in: query, schema: {type: string}}
+      responses:
+        "200": {description: ok}
+`
+
+const workflowTestDocument = `arazzo: 1.0.1
+info: {title: Onboarding, version: 1.0.0}
+sourceDescriptions:
+  - {name: accounts, url: ./openapi.yaml, type: openapi}
+workflows:
+  - workflowId: onboard
+    inputs:
+      type: object
+      required: [email]
+      properties:
+        email: {type: string}
+        expand: {type: string, default: teams}
+    steps:
+      - stepId: create
+        operationId: createUser
+        requestBody:
+          contentType: application/json
+          payload: {email: $inputs.email}
+        successCriteria:
+          - condition: $statusCode == 201
+        outputs:
+          id: $response.body#/id
+      - stepId: fetch
+        operationId: $sourceDescriptions.accounts.getUser
+        parameters:
+          - {name: id, in: path, value: $steps.create.outputs.id}
+          - {name: expand, in: query, value: $inputs.expand}
+        onFailure:
+          - {name: wait, type: retry, retryLimit: 2}
+        outputs:
+          status: $response.body#/status
+    outputs:
+      id: $steps.create.outputs.id
+      status: $steps.fetch.outputs.status
+`
+
+// workflowTestProfile sends a tenant header the tests check for.
+const workflowTestProfile = `{"headers":["X-Tenant: acme"]}`
+
+// newWorkflowTestCLI registers the users API from a spec file next to the
+// Arazzo document and returns the document path. profileJSON is the API's
+// default profile.
+func newWorkflowTestCLI(t *testing.T, document, profileJSON string) (*cli.CLI, *bytes.Buffer, *bytes.Buffer, string) {
+	t.Helper()
+	c, stdout, stderr, specPath := newSpecFileTestCLI(t, "users", "https://api.example.com", "openapi.yaml", workflowTestSpec, profileJSON)
+	docPath := filepath.Join(filepath.Dir(specPath), "onboarding.arazzo.yaml")
+	if err := os.WriteFile(docPath, []byte(document), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return c, stdout, stderr, docPath
+}
+
+func TestWorkflowRunPassesOutputsBetweenStepsAndRetries(t *testing.T) {
+	c, stdout, _, docPath := newWorkflowTestCLI(t, workflowTestDocument, workflowTestProfile)
+	var created map[string]any
+	var fetchURLs []string
+	useTransport(c, func(r *http.Request) (*http.Response, error) {
+		if r.Header.Get("X-Tenant") != "acme" {
+			t.Errorf("%s %s missing profile header", r.Method, r.URL)
+		}
+		if r.Method == http.MethodPost {
+			_ = json.NewDecoder(r.Body).Decode(&created)
+			return jsonResponse(http.StatusCreated, `{"id":"u1"}`), nil
+		}
+		fetchURLs = append(fetchURLs, r.URL.String())
+		if len(fetchURLs) == 1 {
+			return jsonResponse(http.StatusNotFound, `{"title":"not yet"}`), nil
+		}
+		return jsonResponse(http.StatusOK, `{"id":"u1","status":"active"}`), nil
+	})
+
+	if err := c.Run([]string{"restish", "workflow", "run", docPath, "--input", "email=ada@example.com", "-o", "json"}); err != nil {
+		t.Fatalf("workflow run: %v", err)
+	}
+	if created["email"] != "ada@example.com" {
+		t.Fatalf("create body = %#v", created)
+	}
+	if len(fetchURLs) != 2 || fetchURLs[1] != "https://api.example.com/users/u1?expand=teams" {
+		t.Fatalf("fetch URLs = %q", fetchURLs)
+	}
+	var report struct {
+		WorkflowID string         `json:"workflow_id"`
+		Success    bool           `json:"success"`
+		Outputs    map[string]any `json:"outputs"`
+		Steps      []struct {
+			StepID  string `json:"step_id"`
+			Status  int    `json:"status"`
+			Retries int    `json:"retries"`
+		} `json:"steps"`
+		Requests []struct {
+			Operation string `json:"operation"`
+			Status    int    `json:"status"`
+		} `json:"requests"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+		t.Fatalf("report is not JSON: %v\n%s", err, stdout.String())
+	}
+	if !report.Success || report.WorkflowID != "onboard" || report.Outputs["id"] != "u1" || report.Outputs["status"] != "active" {
+		t.Fatalf("report = %s", stdout.String())
+	}
+	last := report.Steps[len(report.Steps)-1]
+	if last.StepID != "fetch" || last.Status != 200 || last.Retries != 1 {
+		t.Fatalf("steps = %+v", report.Steps)
+	}
+	if len(report.Requests) != 3 || report.Requests[0].Operation != "createUser" || report.Requests[1].Status != 404 {
+		t.Fatalf("requests = %+v", report.Requests)
+	}
+}
+
+func TestWorkflowRunFailsOnNon2xxWithoutCriteria(t *testing.T) {
+	document := strings.Replace(workflowTestDocument, "        onFailure:\n          - {name: wait, type: retry, retryLimit: 2}\n", "", 1)
+	c, stdout, stderr, docPath := newWorkflowTestCLI(t, document, workflowTestProfile)
+	useTransport(c, func(r *http.Request) (*http.Response, error) {
+		if r.Method == http.MethodPost {
+			return jsonResponse(http.StatusCreated, `{"id":"u1"}`), nil
+		}
+		return jsonResponse(http.StatusNotFound, `{"title":"missing"}`), nil
+	})
+
+	err := c.Run([]string{"restish", "workflow", "run", docPath, "onboard", "--input", "email=ada@example.com", "-o", "json"})
+	var exitErr *cli.ExitCodeError
+	if !errors.As(err, &exitErr) || exitErr.Code != 1 {
+		t.Fatalf("err = %v, want exit code 1", err)
+	}
+	var report map[string]any
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+		t.Fatalf("report is not JSON: %v\n%s", err, stdout.String())
+	}
+	if report["success"] != false || report["failed_step"] != "fetch" {
+		t.Fatalf("report = %s", stdout.String())
+	}
+	if !strings.Contains(stderr.String(), `workflow "onboard" failed`) {
+		t.Fatalf("stderr = %s", stderr.String())
+	}
+}
+
+func TestWorkflowRunRedactsQueryCredentialsInReport(t *testing.T) {
+	c, stdout, _, docPath := newWorkflowTestCLI(t, workflowTestDocument, `{"auth":{"type":"api-key","params":{"in":"query","name":"tenant","value":"s3cret"}}}`)
+	var sent []string
+	useTransport(c, func(r *http.Request) (*http.Response, error) {
+		sent = append(sent, r.URL.String())
+		if r.Method == http.MethodPost {
+			return jsonResponse(http.StatusCreated, `{"id":"u1"}`), nil
+		}
+		return jsonResponse(http.StatusOK, `{"status":"active"}`), nil
+	})
+
+	if err := c.Run([]string{"restish", "workflow", "run", docPath, "--input", "email=ada@example.com", "-o", "json"}); err != nil {
+		t.Fatalf("workflow run: %v", err)
+	}
+	if len(sent) == 0 || !strings.Contains(sent[0], "tenant=s3cret") {
+		t.Fatalf("sent = %q, want the API key in the query", sent)
+	}
+	if strings.Contains(stdout.String(), "s3cret") {
+		t.Fatalf("report leaks the API key:\n%s", stdout.String())
+	}
+	if !strings.Contains(stdout.String(), "tenant=%3Credacted%3E") {
+		t.Fatalf("report does not show the redacted parameter:\n%s", stdout.String())
+	}
+}
+
+func TestWorkflowRunChecksOperationsAndInputsBeforeSending(t *testing.T) {
+	document := strings.Replace(workflowTestDocument, "operationId: $sourceDescriptions.accounts.getUser", "operationId: getAccount", 1)
+	c, _, _, docPath := newWorkflowTestCLI(t, document, workflowTestProfile)
+	useTransport(c, func(r *http.Request) (*http.Response, error) {
+		t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		return nil, nil
+	})
+
+	err := c.Run([]string{"restish", "workflow", "run", docPath, "--input", "email=ada@example.com"})
+	if err == nil || !strings.Contains(err.Error(), `step "fetch": operation "getAccount" not found`) {
+		t.Fatalf("err = %v", err)
+	}
+	err = c.Run([]string{"restish", "workflow", "run", docPath})
+	if err == nil || !strings.Contains(err.Error(), "needs input email") {
+		t.Fatalf("missing input err = %v", err)
+	}
+}
+
+func TestWorkflowRunMapsSourcesExplicitly(t *testing.T) {
+	document := strings.Replace(workflowTestDocument, "url: ./openapi.yaml", "url: https://specs.example.com/accounts.yaml", 1)
+	c, stdout, _, docPath := newWorkflowTestCLI(t, document, workflowTestProfile)
+	var requests int
+	useTransport(c, func(r *http.Request) (*http.Response, error) {
+		requests++
+		if r.Method == http.MethodPost {
+			return jsonResponse(http.StatusCreated, `{"id":"u1"}`), nil
+		}
+		return jsonResponse(http.StatusOK, `{"status":"active"}`), nil
+	})
+
+	err := c.Run([]string{"restish", "workflow", "run", docPath, "--input", "email=ada@example.com"})
+	if err == nil || !strings.Contains(err.Error(), "--source accounts=API") {
+		t.Fatalf("unmapped source err = %v", err)
+	}
+	if err := c.Run([]string{"restish", "workflow", "run", docPath, "--input", "email=ada@example.com", "--source", "accounts=users", "-f", "body.outputs.status"}); err != nil {
+		t.Fatalf("workflow run: %v", err)
+	}
+	if requests != 2 {
+		t.Fatalf("requests = %d", requests)
+	}
+	if stdout.String() != "active\n" {
+		t.Fatalf("filtered report = %q", stdout.String())
+	}
+}
@@ -735,7 +735,7 @@ func TestSaveConfigShorthand_ArrayAppendInsertDeleteAndSwap(t *testing.T) {
 		data, _ := os.ReadFile(path)
 		t.Fatalf("missing default profile after patch:\n%s", data)
 	}
-	got := cfg.APIs["myapi"].Profiles["default"].Headers
+	got := []string(cfg.APIs["myapi"].Profiles["default"].Headers)
 	want := []string{"Z: 0", "B: 2", "C: 3"}
 	if !reflect.DeepEqual(got, want) {
 		t.Fatalf("headers = %#v, want %#v", got, want)
@@ -766,7 +766,7 @@ func TestSaveConfigShorthand_SwapIsRootedAtAPI(t *testing.T) {
 	if err != nil {
 		t.Fatalf("load config: %v", err)
 	}
-	got := cfg.APIs["myapi"].Profiles["default"].Headers
+	got := []string(cfg.APIs["myapi"].Profiles["default"].Headers)
 	want := []string{"B: 2", "A: 1"}
 	if !reflect.DeepEqual(got, want) {
 		t.Fatalf("headers = %#v, want %#v", got, want)
@@ -0,0 +1,281 @@
+package content
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"sort"
+	"strings"
+)
+
+// HTTP batches, such as OData `$batch`, carry one embedded HTTP request or
+// response per `application/http` part of a multipart/mixed body, with
+// atomic change sets as nested multipart/mixed parts. Both directions use the
+// shape of the OData 4.01 JSON batch format, so a batch reads and writes the
+// same whichever wire format the server speaks:
+//
+//	{requests: [{id, method, url, headers, body, atomicityGroup}]}
+//	{responses: [{id, status, headers, body, atomicityGroup}]}
+
+// marshalBatch encodes a {requests: [...]} value as multipart/mixed and
+// returns it with its boundary. Strings and bytes are sent unchanged, with the
+// boundary read from their first delimiter line.
+func marshalBatch(v any) ([]byte, string, error) {
+	switch t := v.(type) {
+	case string:
+		return marshalRawBatch([]byte(t))
+	case []byte:
+		return marshalRawBatch(t)
+	}
+	doc, ok := v.(map[string]any)
+	if !ok {
+		return nil, "", errors.New("batch bodies must be an object with a requests array")
+	}
+	requests, ok := doc["requests"].([]any)
+	if !ok {
+		return nil, "", errors.New("batch bodies must be an object with a requests array")
+	}
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for i := 0; i < len(requests); {
+		req, ok := requests[i].(map[string]any)
+		if !ok {
+			return nil, "", fmt.Errorf("batch request %d must be an object", i+1)
+		}
+		group, _ := req["atomicityGroup"].(string)
+		if group == "" {
+			if err := writeBatchRequest(w, req, i); err != nil {
+				return nil, "", err
+			}
+			i++
+			continue
+		}
+		// Consecutive requests in one atomicity group form a change set.
+		var changeset bytes.Buffer
+		cw := multipart.NewWriter(&changeset)
+		for ; i < len(requests); i++ {
+			req, ok := requests[i].(map[string]any)
+			if !ok {
+				return nil, "", fmt.Errorf("batch request %d must be an object", i+1)
+			}
+			if g, _ := req["atomicityGroup"].(string); g != group {
+				break
+			}
+			if err := writeBatchRequest(cw, req, i); err != nil {
+				return nil, "", err
+			}
+		}
+		if err := cw.Close(); err != nil {
+			return nil, "", err
+		}
+		part, err := w.CreatePart(textproto.MIMEHeader{"Content-Type": {"multipart/mixed; boundary=" + cw.Boundary()}})
+		if err != nil {
+			return nil, "", err
+		}
+		if _, err := part.Write(changeset.Bytes()); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, "", err
+	}
+	return body.Bytes(), "multipart/mixed; boundary=" + w.Boundary(), nil
+}
+
+func marshalRawBatch(data []byte) ([]byte, string, error) {
+	boundary := sniffMultipartBoundary(data)
+	if boundary == "" {
+		return nil, "", errors.New("raw batch body must start with a --boundary line")
+	}
+	return data, "multipart/mixed; boundary=" + boundary, nil
+}
+
+func writeBatchRequest(w *multipart.Writer, req map[string]any, index int) error {
+	method, _ := req["method"].(string)
+	if method == "" {
+		method = http.MethodGet
+	}
+	target, _ := req["url"].(string)
+	if target == "" {
+		return fmt.Errorf("batch request %d is missing url", index+1)
+	}
+	header := textproto.MIMEHeader{
+		"Content-Type":              {"application/http"},
+		"Content-Transfer-Encoding": {"binary"},
+	}
+	if id := batchString(req["id"]); id != "" {
+		header.Set("Content-ID", id)
+	}
+	part, err := w.CreatePart(header)
+	if err != nil {
+		return err
+	}
+
+	headers := map[string]string{}
+	if raw, ok := req["headers"].(map[string]any); ok {
+		for name, value := range raw {
+			headers[http.CanonicalHeaderKey(name)] = batchString(value)
+		}
+	}
+	var payload []byte
+	switch body := req["body"].(type) {
+	case nil:
+	case string:
+		payload = []byte(body)
+	default:
+		if payload, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("batch request %d body: %w", index+1, err)
+		}
+		if headers["Content-Type"] == "" {
+			headers["Content-Type"] = "application/json"
+		}
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%s %s HTTP/1.1\r\n", strings.ToUpper(method), target)
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&b, "%s: %s\r\n", name, headers[name])
+	}
+	b.WriteString("\r\n")
+	b.Write(payload)
+	_, err = part.Write(b.Bytes())
+	return err
+}
+
+// unmarshalBatch decodes a multipart/mixed batch response into
+// {responses: [...]}. Parts that are not embedded HTTP responses keep their
+// part headers and body.
+func unmarshalBatch(data []byte, contentType string) (any, error) {
+	_, params, _ := mime.ParseMediaType(contentType)
+	boundary := params["boundary"]
+	if boundary == "" {
+		boundary = sniffMultipartBoundary(data)
+	}
+	if boundary == "" {
+		return nil, errors.New("multipart/mixed body has no boundary")
+	}
+	responses, err := readBatchParts(bytes.NewReader(data), boundary, "")
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"responses": responses}, nil
+}
+
+func readBatchParts(r io.Reader, boundary, group string) ([]any, error) {
+	mr := multipart.NewReader(r, boundary)
+	var out []any
+	for {
+		part, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			return out, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading batch part: %w", err)
+		}
+		mediaType, params, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		switch {
+		case mediaType == "multipart/mixed" && params["boundary"] != "":
+			nested, err := readBatchParts(part, params["boundary"], params["boundary"])
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, nested...)
+			continue
+		case mediaType == "application/http":
+			resp, err := http.ReadResponse(bufio.NewReader(part), nil)
+			if err != nil {
+				return nil, fmt.Errorf("reading batch response %d: %w", len(out)+1, err)
+			}
+			body, err := io.ReadAll(resp.Body)
+			_ = resp.Body.Close()
+			if err != nil {
+				return nil, fmt.Errorf("reading batch response %d: %w", len(out)+1, err)
+			}
+			item := map[string]any{"status": resp.StatusCode, "headers": batchHeaders(resp.Header)}
+			if value := decodeBatchBody(resp.Header.Get("Content-Type"), body); value != nil {
+				item["body"] = value
+			}
+			out = append(out, withBatchIdentity(item, part.Header, group))
+		default:
+			body, err := io.ReadAll(part)
+			if err != nil {
+				return nil, fmt.Errorf("reading batch part %d: %w", len(out)+1, err)
+			}
+			item := map[string]any{"headers": batchHeaders(http.Header(part.Header))}
+			if value := decodeBatchBody(part.Header.Get("Content-Type"), body); value != nil {
+				item["body"] = value
+			}
+			out = append(out, withBatchIdentity(item, part.Header, group))
+		}
+	}
+}
+
+func withBatchIdentity(item map[string]any, header textproto.MIMEHeader, group string) map[string]any {
+	if id := header.Get("Content-ID"); id != "" {
+		item["id"] = id
+	}
+	if group != "" {
+		item["atomicityGroup"] = group
+	}
+	return item
+}
+
+func batchHeaders(header http.Header) map[string]any {
+	out := make(map[string]any, len(header))
+	for name, values := range header {
+		out[strings.ToLower(name)] = strings.Join(values, ", ")
+	}
+	return out
+}
+
+func decodeBatchBody(contentType string, body []byte) any {
+	if len(body) == 0 {
+		return nil
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") {
+		var v any
+		if json.Unmarshal(body, &v) == nil {
+			return v
+		}
+	}
+	if b, ok := Printable(body); ok {
+		return string(b)
+	}
+	return body
+}
+
+// sniffMultipartBoundary returns the boundary from the first delimiter line
+// of a multipart body, for bodies whose Content-Type lost its parameters.
+func sniffMultipartBoundary(data []byte) string {
+	line, _, _ := bytes.Cut(bytes.TrimLeft(data, "\r\n"), []byte("\n"))
+	line = bytes.TrimRight(line, "\r \t")
+	if !bytes.HasPrefix(line, []byte("--")) || len(line) <= 2 {
+		return ""
+	}
+	return string(line[2:])
+}
+
+func batchString(v any) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	default:
+		return fmt.Sprint(t)
+	}
+}
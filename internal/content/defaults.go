@@ -27,8 +27,9 @@ type MultipartBody struct {
 	ContentTypes map[string]string
 }
 
-// Default returns a Registry pre-loaded with JSON, YAML, CBOR, msgpack, Ion,
-// plain text, and gzip/deflate/brotli encodings.
+// Default returns a Registry pre-loaded with JSON, JSON:API, YAML, CBOR,
+// msgpack, Ion, protobuf, multipart/mixed batches, plain text, and
+// gzip/deflate/brotli encodings.
 func Default() *Registry {
 	r := New()
 
@@ -52,6 +53,23 @@ func Default() *Registry {
 		},
 	})
 
+	// JSON:API responses decode as JSON. Requests wrap shorthand in the
+	// data/type/attributes envelope; see marshalJSONAPI. The plain JSON entry
+	// already accepts JSON:API responses, so this one is not advertised.
+	r.AddContentType(&ContentType{
+		Name:      "jsonapi",
+		MIMETypes: []string{"application/vnd.api+json"},
+		Quality:   0,
+		Marshal:   marshalJSONAPI,
+		Unmarshal: func(data []byte) (any, error) {
+			var v any
+			if err := json.Unmarshal(data, &v); err != nil {
+				return nil, err
+			}
+			return v, nil
+		},
+	})
+
 	r.AddContentType(&ContentType{
 		Name:      "ndjson",
 		MIMETypes: []string{"application/x-ndjson", "application/ndjson", "application/jsonl", "application/jsonlines"},
@@ -204,6 +222,16 @@ func Default() *Registry {
 		},
 	})
 
+	r.AddContentType(&ContentType{
+		Name:      "protobuf",
+		MIMETypes: []string{"application/proto", "application/protobuf", "application/x-protobuf"},
+		// Zero quality keeps protobuf out of the default Accept header, since
+		// responses can only be decoded fully with a message type.
+		Quality:   0,
+		Marshal:   marshalProtobuf,
+		Unmarshal: unmarshalProtobufWire,
+	})
+
 	r.AddContentType(&ContentType{
 		Name:      "multipart",
 		MIMETypes: []string{"multipart/form-data"},
@@ -221,6 +249,23 @@ func Default() *Registry {
 		},
 	})
 
+	r.AddContentType(&ContentType{
+		Name:      "batch",
+		MIMETypes: []string{"multipart/mixed"},
+		// Batch responses only come back for batch requests, so there is no
+		// reason to advertise them.
+		Quality:            0,
+		MarshalContentType: marshalBatch,
+		Marshal: func(v any) ([]byte, error) {
+			data, _, err := marshalBatch(v)
+			return data, err
+		},
+		Unmarshal: func(data []byte) (any, error) {
+			return unmarshalBatch(data, "")
+		},
+		UnmarshalContentType: unmarshalBatch,
+	})
+
 	r.AddContentType(&ContentType{
 		Name:      "sse",
 		MIMETypes: []string{"text/event-stream"},
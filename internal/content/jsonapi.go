@@ -0,0 +1,153 @@
+package content
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// marshalJSONAPI encodes v as a JSON:API request document. Values that
+// already have a top-level data member, or atomic operations, are sent
+// unchanged. Otherwise each
+// object is wrapped as a resource: type, id, and lid stay at the resource
+// level, values that are resource identifiers ({type, id} objects or arrays
+// of them) become relationships, and everything else becomes attributes. An
+// array of objects becomes an array of resources.
+func marshalJSONAPI(v any) ([]byte, error) {
+	switch t := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []byte(t), nil
+	case []byte:
+		return t, nil
+	case map[string]any:
+		_, hasData := t["data"]
+		_, hasOperations := t["atomic:operations"]
+		if hasData || hasOperations {
+			return json.Marshal(t)
+		}
+		resource, err := jsonAPIResource(t, 0)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(map[string]any{"data": resource})
+	case []any:
+		resources := make([]any, 0, len(t))
+		for i, item := range t {
+			fields, ok := item.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("JSON:API resource %d must be an object", i+1)
+			}
+			resource, err := jsonAPIResource(fields, i+1)
+			if err != nil {
+				return nil, err
+			}
+			resources = append(resources, resource)
+		}
+		return json.Marshal(map[string]any{"data": resources})
+	}
+	return nil, errors.New("JSON:API bodies must be an object or an array of objects")
+}
+
+func jsonAPIResource(fields map[string]any, index int) (map[string]any, error) {
+	typ, _ := fields["type"].(string)
+	if typ == "" {
+		if index > 0 {
+			return nil, fmt.Errorf("JSON:API resource %d needs a type, for example 'type: articles'", index)
+		}
+		return nil, errors.New("JSON:API bodies need a resource type, for example 'type: articles'")
+	}
+	resource := map[string]any{"type": typ}
+	attributes := map[string]any{}
+	relationships := map[string]any{}
+	for name, value := range fields {
+		switch {
+		case name == "type":
+		case name == "id" || name == "lid":
+			resource[name] = jsonAPIIDString(value)
+		case name == "meta":
+			resource[name] = value
+		case isJSONAPIIdentifier(value):
+			relationships[name] = map[string]any{"data": jsonAPIIdentifier(value)}
+		case isJSONAPIIdentifierList(value):
+			list := value.([]any)
+			ids := make([]any, 0, len(list))
+			for _, item := range list {
+				ids = append(ids, jsonAPIIdentifier(item))
+			}
+			relationships[name] = map[string]any{"data": ids}
+		default:
+			attributes[name] = value
+		}
+	}
+	if len(attributes) > 0 {
+		resource["attributes"] = attributes
+	}
+	if len(relationships) > 0 {
+		resource["relationships"] = relationships
+	}
+	return resource, nil
+}
+
+// isJSONAPIIdentifier reports whether v looks like a resource identifier: an
+// object with only a type, an id or lid, and optional meta.
+func isJSONAPIIdentifier(v any) bool {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return false
+	}
+	if _, ok := m["type"].(string); !ok {
+		return false
+	}
+	_, hasID := m["id"]
+	_, hasLID := m["lid"]
+	if !hasID && !hasLID {
+		return false
+	}
+	for name := range m {
+		switch name {
+		case "type", "id", "lid", "meta":
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func isJSONAPIIdentifierList(v any) bool {
+	list, ok := v.([]any)
+	if !ok || len(list) == 0 {
+		return false
+	}
+	for _, item := range list {
+		if !isJSONAPIIdentifier(item) {
+			return false
+		}
+	}
+	return true
+}
+
+// jsonAPIIdentifier copies an identifier with its id as a string, since
+// shorthand reads numeric ids as numbers and JSON:API ids are strings.
+func jsonAPIIdentifier(v any) map[string]any {
+	m := v.(map[string]any)
+	out := make(map[string]any, len(m))
+	for name, value := range m {
+		if name == "id" || name == "lid" {
+			value = jsonAPIIDString(value)
+		}
+		out[name] = value
+	}
+	return out
+}
+
+func jsonAPIIDString(v any) any {
+	switch t := v.(type) {
+	case string, nil:
+		return t
+	case float64, int, int64, uint64, json.Number:
+		return fmt.Sprint(t)
+	}
+	return v
+}
@@ -0,0 +1,107 @@
+package content
+
+import (
+	"errors"
+	"strconv"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+// marshalProtobuf encodes v as binary protobuf. Only values that already
+// carry a message type can be encoded, so generated RPC commands convert the
+// request body to a message before it reaches the registry.
+func marshalProtobuf(v any) ([]byte, error) {
+	switch t := v.(type) {
+	case nil:
+		return nil, nil
+	case proto.Message:
+		return proto.Marshal(t)
+	case []byte:
+		return t, nil
+	}
+	return nil, errors.New("binary protobuf bodies need a message type; use a command generated from a protobuf descriptor set")
+}
+
+// unmarshalProtobufWire decodes binary protobuf without a schema, like
+// `protoc --decode_raw`: fields are keyed by number, repeated fields become
+// arrays, and length-delimited values are shown as nested messages when they
+// parse as one, as text when printable, and as bytes otherwise.
+func unmarshalProtobufWire(data []byte) (any, error) {
+	out, ok := decodeProtobufWire(data, 0)
+	if !ok {
+		return nil, errors.New("invalid protobuf wire format")
+	}
+	return out, nil
+}
+
+const maxProtobufWireDepth = 32
+
+func decodeProtobufWire(data []byte, depth int) (map[string]any, bool) {
+	out := map[string]any{}
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return nil, false
+		}
+		data = data[n:]
+		var value any
+		switch typ {
+		case protowire.VarintType:
+			v, m := protowire.ConsumeVarint(data)
+			if m < 0 {
+				return nil, false
+			}
+			value, n = v, m
+		case protowire.Fixed32Type:
+			v, m := protowire.ConsumeFixed32(data)
+			if m < 0 {
+				return nil, false
+			}
+			value, n = v, m
+		case protowire.Fixed64Type:
+			v, m := protowire.ConsumeFixed64(data)
+			if m < 0 {
+				return nil, false
+			}
+			value, n = v, m
+		case protowire.BytesType:
+			v, m := protowire.ConsumeBytes(data)
+			if m < 0 {
+				return nil, false
+			}
+			value, n = protobufWireBytes(v, depth), m
+		default:
+			// Groups are long deprecated; skip them whole.
+			m := protowire.ConsumeFieldValue(num, typ, data)
+			if m < 0 {
+				return nil, false
+			}
+			data = data[m:]
+			continue
+		}
+		data = data[n:]
+		key := strconv.Itoa(int(num))
+		switch existing := out[key].(type) {
+		case nil:
+			out[key] = value
+		case []any:
+			out[key] = append(existing, value)
+		default:
+			out[key] = []any{existing, value}
+		}
+	}
+	return out, true
+}
+
+func protobufWireBytes(v []byte, depth int) any {
+	if depth < maxProtobufWireDepth && len(v) > 0 {
+		if nested, ok := decodeProtobufWire(v, depth+1); ok {
+			return nested
+		}
+	}
+	if b, ok := Printable(v); ok {
+		return string(b)
+	}
+	return v
+}
@@ -31,7 +31,9 @@ type ContentType struct {
 	// Suffixes lists structured syntax suffixes handled by this entry, such as
 	// "+json" or "+cbor".
 	Suffixes []string
-	// Quality is the Accept header q-value (0–1). Higher = preferred.
+	// Quality is the Accept header q-value (0–1). Higher = preferred. Types
+	// with zero quality are left out of AcceptHeader and are only used when
+	// selected explicitly.
 	Quality float32
 	// Marshal encodes v into bytes.
 	Marshal func(v any) ([]byte, error)
@@ -41,6 +43,10 @@ type ContentType struct {
 	MarshalContentType func(v any) ([]byte, string, error)
 	// Unmarshal decodes data into a Go value.
 	Unmarshal func(data []byte) (any, error)
+	// UnmarshalContentType optionally decodes data with the full Content-Type
+	// value, for formats like multipart whose parameters (for example a
+	// boundary) are needed to decode. It takes precedence over Unmarshal.
+	UnmarshalContentType func(data []byte, contentType string) (any, error)
 }
 
 // Encoding describes how to decompress a single Content-Encoding.
@@ -144,6 +150,9 @@ func (r *Registry) AcceptHeader() string {
 	var entries []qualityEntry
 	seen := make(map[string]int)
 	for _, ct := range r.contentTypes {
+		if ct.Quality <= 0 {
+			continue
+		}
 		for _, mt := range ct.MIMETypes {
 			key := canonicalMediaType(mt)
 			if idx, ok := seen[key]; ok {
@@ -225,7 +234,13 @@ func (r *Registry) Decode(mimeType string, data []byte) (any, error) {
 		}
 		return data, nil
 	}
-	v, err := ct.Unmarshal(data)
+	var v any
+	var err error
+	if ct.UnmarshalContentType != nil {
+		v, err = ct.UnmarshalContentType(data, mimeType)
+	} else {
+		v, err = ct.Unmarshal(data)
+	}
 	if err != nil {
 		return nil, err
 	}
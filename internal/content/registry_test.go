@@ -13,6 +13,7 @@ import (
 	"mime/multipart"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -722,3 +723,104 @@ func TestMsgpackMalformedFixextReturnsError(t *testing.T) {
 		})
 	}
 }
+
+func TestProtobufDecodesWireFormatWithoutSchema(t *testing.T) {
+	// {1: 150, 2: "testing", 3: {1: 1}, 4: [5, 6]}
+	data := []byte{0x08, 0x96, 0x01, 0x12, 0x07, 't', 'e', 's', 't', 'i', 'n', 'g', 0x1a, 0x02, 0x08, 0x01, 0x20, 0x05, 0x20, 0x06}
+	out, err := reg.Decode("application/proto", data)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	got, _ := json.Marshal(out)
+	if want := `{"1":150,"2":"testing","3":{"1":1},"4":[5,6]}`; string(got) != want {
+		t.Fatalf("decode = %s, want %s", got, want)
+	}
+	if _, err := reg.Decode("application/x-protobuf", []byte{0x0a, 0x05, 'a'}); err == nil {
+		t.Fatal("expected truncated protobuf to return an error")
+	}
+	if _, err := reg.Encode("application/protobuf", map[string]any{"a": 1}); err == nil {
+		t.Fatal("expected protobuf encode without a message type to fail")
+	}
+	if h := reg.AcceptHeader(); strings.Contains(h, "proto") {
+		t.Fatalf("protobuf should only be sent when requested, got Accept %q", h)
+	}
+}
+
+func TestBatchRoundTripsODataMultipart(t *testing.T) {
+	data, contentType, err := reg.EncodeWithType("multipart/mixed", map[string]any{"requests": []any{
+		map[string]any{"id": "1", "url": "People('ada')"},
+		map[string]any{"id": "2", "method": "post", "url": "People", "body": map[string]any{"UserName": "bob"}, "atomicityGroup": "g1"},
+		map[string]any{"id": "3", "method": "delete", "url": "People('eve')", "atomicityGroup": "g1"},
+	}})
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	_, params, _ := mime.ParseMediaType(contentType)
+	if params["boundary"] == "" {
+		t.Fatalf("Content-Type = %q, want a boundary", contentType)
+	}
+	for _, want := range []string{"GET People('ada') HTTP/1.1\r\n", "POST People HTTP/1.1\r\nContent-Type: application/json\r\n\r\n{\"UserName\":\"bob\"}", "Content-Id: 3", "Content-Type: multipart/mixed; boundary="} {
+		if !bytes.Contains(data, []byte(want)) {
+			t.Fatalf("batch request missing %q:\n%s", want, data)
+		}
+	}
+
+	response := "--batch_1\r\n" +
+		"Content-Type: application/http\r\n\r\n" +
+		"HTTP/1.1 200 OK\r\nContent-Type: application/json\r\n\r\n{\"UserName\":\"ada\"}\r\n" +
+		"--batch_1\r\n" +
+		"Content-Type: multipart/mixed; boundary=changeset_1\r\n\r\n" +
+		"--changeset_1\r\n" +
+		"Content-Type: application/http\r\nContent-ID: 2\r\n\r\n" +
+		"HTTP/1.1 204 No Content\r\n\r\n\r\n" +
+		"--changeset_1--\r\n" +
+		"--batch_1--\r\n"
+	out, err := reg.Decode("multipart/mixed; boundary=batch_1", []byte(response))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	got, _ := json.Marshal(out)
+	want := `{"responses":[{"body":{"UserName":"ada"},"headers":{"content-type":"application/json"},"status":200},{"atomicityGroup":"changeset_1","headers":{},"id":"2","status":204}]}`
+	if string(got) != want {
+		t.Fatalf("decode = %s\nwant     %s", got, want)
+	}
+	if sniffed, err := reg.Decode("multipart/mixed", []byte(response)); err != nil || !reflect.DeepEqual(sniffed, out) {
+		t.Fatalf("decode without boundary parameter = %#v, %v", sniffed, err)
+	}
+}
+
+func TestJSONAPIWrapsShorthandBody(t *testing.T) {
+	r := content.Default()
+	data, err := r.Encode("application/vnd.api+json", map[string]any{
+		"type":   "articles",
+		"title":  "Hello",
+		"author": map[string]any{"type": "people", "id": float64(9)},
+		"tags":   []any{map[string]any{"type": "tags", "id": "go"}},
+	})
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{"data": map[string]any{
+		"type":       "articles",
+		"attributes": map[string]any{"title": "Hello"},
+		"relationships": map[string]any{
+			"author": map[string]any{"data": map[string]any{"type": "people", "id": "9"}},
+			"tags":   map[string]any{"data": []any{map[string]any{"type": "tags", "id": "go"}}},
+		},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("body = %s", data)
+	}
+
+	envelope := map[string]any{"data": map[string]any{"type": "articles", "id": "1"}}
+	if data, err := r.Encode("application/vnd.api+json", envelope); err != nil || string(data) != `{"data":{"id":"1","type":"articles"}}` {
+		t.Fatalf("envelope = %s, %v", data, err)
+	}
+	if _, err := r.Encode("application/vnd.api+json", map[string]any{"title": "Hello"}); err == nil || !strings.Contains(err.Error(), "type") {
+		t.Fatalf("missing type err = %v", err)
+	}
+}
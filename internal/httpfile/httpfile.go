@@ -0,0 +1,254 @@
+// Package httpfile parses request files in the format shared by the VS Code
+// REST Client and the JetBrains HTTP Client.
+//
+// A file holds requests separated by "###" lines. Each request has an
+// optional "# @name" line, a request line, headers, a blank line, and a body.
+// "@name = value" lines define file variables. Placeholders such as
+// {{host}} are left in place by Parse; callers expand them with Expand once
+// they know how to resolve variables, environment lookups, and responses
+// captured from earlier requests.
+package httpfile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// File is a parsed request file.
+type File struct {
+	// Variables holds "@name = value" definitions. As in both editors they
+	// apply to the whole file wherever they are written; a later definition
+	// of the same name wins.
+	Variables map[string]string
+	Requests  []*Request
+}
+
+// Request is one request block.
+type Request struct {
+	// Name comes from "# @name NAME", or from the text after "###" when the
+	// block has no @name line. It is empty for unnamed requests.
+	Name string
+	// Line is the 1-based line number of the request line.
+	Line    int
+	Method  string
+	URL     string
+	Headers []Header
+	// Body is the inline body with trailing blank lines removed.
+	Body string
+	// BodyFile is set when the body is a "< path" file reference. The path
+	// is relative to the request file. ExpandBodyFile is true for "<@ path",
+	// whose contents are expanded like an inline body.
+	BodyFile       string
+	ExpandBodyFile bool
+}
+
+// Header is a request header line. Order and duplicates are preserved.
+type Header struct {
+	Name  string
+	Value string
+}
+
+// Label identifies the request in messages.
+func (r *Request) Label() string {
+	if r.Name != "" {
+		return fmt.Sprintf("%q", r.Name)
+	}
+	return fmt.Sprintf("at line %d", r.Line)
+}
+
+var (
+	variableLine = regexp.MustCompile(`^@([A-Za-z_][\w.-]*)\s*=\s*(.*)$`)
+	nameLine     = regexp.MustCompile(`^(?:#|//)\s*@name\s*=?\s*(\S+)`)
+	methodToken  = regexp.MustCompile(`^[A-Z]+$`)
+	httpVersion  = regexp.MustCompile(`\s+HTTP/\d(?:\.\d)?$`)
+)
+
+// unsupportedMethods are JetBrains request kinds that are not plain HTTP.
+var unsupportedMethods = map[string]bool{
+	"GRAPHQL":   true,
+	"GRPC":      true,
+	"WEBSOCKET": true,
+}
+
+type section int
+
+const (
+	sectionStart section = iota
+	sectionHeaders
+	sectionBody
+)
+
+// Parse reads a request file.
+func Parse(r io.Reader) (*File, error) {
+	file := &File{Variables: map[string]string{}}
+	var (
+		current *Request
+		state   = sectionStart
+		name    string
+		body    []string
+		lineNo  int
+	)
+	finish := func() {
+		if current != nil {
+			setBody(current, body)
+			file.Requests = append(file.Requests, current)
+		}
+		current, state, name, body = nil, sectionStart, "", nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if lineNo == 1 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "###") {
+			finish()
+			name = strings.TrimSpace(strings.TrimLeft(trimmed, "#"))
+			continue
+		}
+
+		switch state {
+		case sectionStart:
+			switch {
+			case trimmed == "":
+			case nameLine.MatchString(trimmed):
+				name = nameLine.FindStringSubmatch(trimmed)[1]
+			case strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "//"):
+			case variableLine.MatchString(trimmed):
+				m := variableLine.FindStringSubmatch(trimmed)
+				file.Variables[m[1]] = strings.TrimSpace(m[2])
+			default:
+				req, err := parseRequestLine(trimmed, lineNo)
+				if err != nil {
+					return nil, err
+				}
+				req.Name = name
+				current, state = req, sectionHeaders
+			}
+		case sectionHeaders:
+			switch {
+			case trimmed == "":
+				state = sectionBody
+			case strings.HasPrefix(trimmed, "?") || strings.HasPrefix(trimmed, "&"):
+				// Long query strings may continue on indented lines.
+				current.URL += trimmed
+			case strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "//"):
+			default:
+				headerName, value, ok := strings.Cut(trimmed, ":")
+				if !ok || strings.TrimSpace(headerName) == "" {
+					return nil, fmt.Errorf("line %d: invalid header %q; separate the body from the headers with a blank line", lineNo, trimmed)
+				}
+				current.Headers = append(current.Headers, Header{Name: strings.TrimSpace(headerName), Value: strings.TrimSpace(value)})
+			}
+		case sectionBody:
+			switch {
+			case strings.HasPrefix(trimmed, "<>"):
+				// JetBrains links to a saved earlier response here; it has
+				// no effect on the request.
+			case strings.HasPrefix(trimmed, ">"):
+				return nil, fmt.Errorf("line %d: response handler scripts and output redirects are not supported", lineNo)
+			default:
+				body = append(body, line)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	finish()
+	return file, nil
+}
+
+func parseRequestLine(line string, lineNo int) (*Request, error) {
+	req := &Request{Line: lineNo, Method: "GET"}
+	line = httpVersion.ReplaceAllString(line, "")
+	if method, rest, ok := strings.Cut(line, " "); ok && methodToken.MatchString(method) {
+		if unsupportedMethods[method] {
+			return nil, fmt.Errorf("line %d: %s requests are not supported", lineNo, method)
+		}
+		req.Method = method
+		line = strings.TrimSpace(rest)
+	}
+	if line == "" {
+		return nil, fmt.Errorf("line %d: request line has no URL", lineNo)
+	}
+	req.URL = line
+	return req, nil
+}
+
+func setBody(req *Request, lines []string) {
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 1 {
+		first := strings.TrimSpace(lines[0])
+		switch {
+		case strings.HasPrefix(first, "<@"):
+			req.BodyFile = strings.TrimSpace(first[2:])
+			req.ExpandBodyFile = true
+			return
+		case strings.HasPrefix(first, "< "):
+			req.BodyFile = strings.TrimSpace(first[2:])
+			return
+		}
+	}
+	req.Body = strings.Join(lines, "\n")
+}
+
+// Expand replaces each {{placeholder}} in s with resolve's result for the
+// trimmed text between the braces. Text without a closing "}}" is kept.
+func Expand(s string, resolve func(string) (string, error)) (string, error) {
+	var b strings.Builder
+	for {
+		start := strings.Index(s, "{{")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(s[start+2:], "}}")
+		if end < 0 {
+			break
+		}
+		value, err := resolve(strings.TrimSpace(s[start+2 : start+2+end]))
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(s[:start])
+		b.WriteString(value)
+		s = s[start+2+end+2:]
+	}
+	b.WriteString(s)
+	return b.String(), nil
+}
+
+// ParseDotenv reads KEY=VALUE lines from a .env file. Blank lines, comments,
+// and an "export " prefix are ignored, and matching quotes around a value
+// are removed.
+func ParseDotenv(r io.Reader) (map[string]string, error) {
+	values := map[string]string{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		values[strings.TrimSpace(key)] = value
+	}
+	return values, scanner.Err()
+}
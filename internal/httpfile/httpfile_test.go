@@ -0,0 +1,119 @@
+package httpfile_test
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rest-sh/restish/v2/internal/httpfile"
+)
+
+const sampleFile = `@host = https://api.example.com
+@token = {{login.response.body.token}}
+
+### Log in
+# @name login
+POST {{host}}/login HTTP/1.1
+Content-Type: application/json
+
+{
+  "user": "ada"
+}
+
+###
+// list users
+GET {{host}}/users
+    ?limit=10
+    &sort=name
+Authorization: Bearer {{token}}
+# a comment between headers
+
+### upload
+PUT {{host}}/avatar
+Content-Type: image/png
+
+< ./avatar.png
+
+###
+{{host}}/health
+`
+
+func TestParseSplitsRequestsAndVariables(t *testing.T) {
+	file, err := httpfile.Parse(strings.NewReader(sampleFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.Variables["host"] != "https://api.example.com" || file.Variables["token"] != "{{login.response.body.token}}" {
+		t.Fatalf("variables = %#v", file.Variables)
+	}
+	if len(file.Requests) != 4 {
+		t.Fatalf("got %d requests", len(file.Requests))
+	}
+
+	login := file.Requests[0]
+	if login.Name != "login" || login.Method != "POST" || login.URL != "{{host}}/login" || login.Line != 6 {
+		t.Fatalf("login = %+v", login)
+	}
+	if !reflect.DeepEqual(login.Headers, []httpfile.Header{{Name: "Content-Type", Value: "application/json"}}) {
+		t.Fatalf("login headers = %+v", login.Headers)
+	}
+	if login.Body != "{\n  \"user\": \"ada\"\n}" {
+		t.Fatalf("login body = %q", login.Body)
+	}
+
+	list := file.Requests[1]
+	if list.Name != "" || list.URL != "{{host}}/users?limit=10&sort=name" || len(list.Headers) != 1 || list.Body != "" {
+		t.Fatalf("list = %+v", list)
+	}
+
+	upload := file.Requests[2]
+	if upload.Name != "upload" || upload.BodyFile != "./avatar.png" || upload.ExpandBodyFile || upload.Body != "" {
+		t.Fatalf("upload = %+v", upload)
+	}
+
+	health := file.Requests[3]
+	if health.Method != "GET" || health.URL != "{{host}}/health" || health.Label() != "at line 28" {
+		t.Fatalf("health = %+v (%s)", health, health.Label())
+	}
+}
+
+func TestParseRejectsUnsupportedSyntax(t *testing.T) {
+	for _, tc := range []struct {
+		input string
+		want  string
+	}{
+		{"GET https://example.com\n\n> {% client.global.set(\"x\", 1) %}\n", "line 3: response handler"},
+		{"GRAPHQL https://example.com/graphql\n", "GRAPHQL requests are not supported"},
+		{"GET https://example.com\nnot a header\n", "line 2: invalid header"},
+	} {
+		if _, err := httpfile.Parse(strings.NewReader(tc.input)); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("Parse(%q) error = %v, want %q", tc.input, err, tc.want)
+		}
+	}
+}
+
+func TestExpandResolvesPlaceholders(t *testing.T) {
+	got, err := httpfile.Expand("{{ host }}/users/{{id}}?q={{unclosed", func(name string) (string, error) {
+		return "<" + name + ">", nil
+	})
+	if err != nil || got != "<host>/users/<id>?q={{unclosed" {
+		t.Fatalf("Expand = %q, %v", got, err)
+	}
+	_, err = httpfile.Expand("{{missing}}", func(name string) (string, error) {
+		return "", fmt.Errorf("undefined variable %q", name)
+	})
+	if err == nil || !strings.Contains(err.Error(), `"missing"`) {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestParseDotenv(t *testing.T) {
+	values, err := httpfile.ParseDotenv(strings.NewReader("# secrets\nexport API_KEY=abc\nQUOTED=\"a b\"\n\nBROKEN\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(values, map[string]string{"API_KEY": "abc", "QUOTED": "a b"}) {
+		t.Fatalf("values = %#v", values)
+	}
+}
@@ -1,6 +1,6 @@
 // Package hypermedia provides parsers that extract typed links from HTTP
 // responses in various hypermedia formats (Link headers, HAL, TSJ, JSON:API,
-// Siren). All URIs are resolved to absolute form before being returned.
+// Siren, OData). All URIs are resolved to absolute form before being returned.
 package hypermedia
 
 import (
@@ -48,6 +48,7 @@ func DefaultParsers() []Parser {
 		TSJParser{},
 		JSONAPIParser{},
 		SirenParser{},
+		ODataParser{},
 	}
 }
 
@@ -283,6 +283,27 @@ func TestSirenParserRequiresClassOrEntities(t *testing.T) {
 	}
 }
 
+// ─── OData tests ──────────────────────────────────────────────────────────────
+
+func TestODataParser(t *testing.T) {
+	body := map[string]any{
+		"@odata.context":   "https://api.example.com/$metadata#People",
+		"@odata.nextLink":  "People?$skiptoken=8",
+		"@odata.deltaLink": "https://api.example.com/People?$deltatoken=1",
+		"value":            []any{},
+	}
+	links := hypermedia.Parse(base, nil, body, []hypermedia.Parser{hypermedia.ODataParser{}})
+	if links["next"] != "https://api.example.com/People?$skiptoken=8" {
+		t.Errorf("next: got %q", links["next"])
+	}
+	if links["delta"] != "https://api.example.com/People?$deltatoken=1" {
+		t.Errorf("delta: got %q", links["delta"])
+	}
+	if links := (hypermedia.ODataParser{}).ParseLinks(base, nil, map[string]any{"@nextLink": "/People?page=2"}); len(links) != 1 || links[0].URI != "https://api.example.com/People?page=2" {
+		t.Errorf("4.01 @nextLink: got %#v", links)
+	}
+}
+
 func TestResolveRejectsMalformedURL(t *testing.T) {
 	links := hypermedia.Parse(base, nil, map[string]any{
 		"_links": map[string]any{
@@ -278,3 +278,33 @@ func (SirenParser) ParseLinks(baseURL *url.URL, _ http.Header, body any) []Link
 	}
 	return result
 }
+
+// ─── OData ────────────────────────────────────────────────────────────────────
+
+// ODataParser extracts the next page and delta links of an OData collection
+// response. OData 4.0 uses `@odata.nextLink`, 4.01 also allows the bare
+// `@nextLink`, and OData 3.0 JSON uses `odata.nextLink`.
+type ODataParser struct{}
+
+var odataLinkAnnotations = []struct{ rel, name string }{
+	{"next", "odata.nextLink"},
+	{"next", "@nextLink"},
+	{"next", "@odata.nextLink"},
+	{"delta", "odata.deltaLink"},
+	{"delta", "@deltaLink"},
+	{"delta", "@odata.deltaLink"},
+}
+
+func (ODataParser) ParseLinks(baseURL *url.URL, _ http.Header, body any) []Link {
+	m, ok := body.(map[string]any)
+	if !ok {
+		return nil
+	}
+	var result []Link
+	for _, annotation := range odataLinkAnnotations {
+		if href, ok := m[annotation.name].(string); ok && href != "" {
+			result = append(result, Link{Rel: annotation.rel, URI: resolve(baseURL, href)})
+		}
+	}
+	return result
+}
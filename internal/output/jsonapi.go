@@ -0,0 +1,169 @@
+package output
+
+// maxJSONAPIDepth bounds how deeply included resources are inlined into one
+// another, so large or deeply linked compound documents stay readable.
+const maxJSONAPIDepth = 8
+
+// FlattenJSONAPI converts a JSON:API document into plain records. Each
+// resource becomes one object with its id, type, and attributes side by side;
+// relationships are replaced with the related records from included, or with
+// their {id, type} identifiers when the related resource was not included.
+// A collection document becomes an array of records and a single-resource
+// document becomes one record. Top-level links are expected to have been
+// read already; top-level meta and resource links are dropped. Documents
+// without primary data, such as error documents, are returned unchanged.
+func FlattenJSONAPI(body any) (any, bool) {
+	doc, ok := body.(map[string]any)
+	if !ok {
+		return body, false
+	}
+	data, ok := doc["data"]
+	if !ok || !isJSONAPIData(data) {
+		return body, false
+	}
+
+	f := jsonAPIFlattener{index: map[jsonAPIKey]map[string]any{}}
+	included, _ := doc["included"].([]any)
+	for _, resources := range [][]any{jsonAPIResources(data), included} {
+		for _, item := range resources {
+			if resource, ok := item.(map[string]any); ok {
+				if key, ok := jsonAPIIdentity(resource); ok {
+					if _, seen := f.index[key]; !seen {
+						f.index[key] = resource
+					}
+				}
+			}
+		}
+	}
+
+	switch t := data.(type) {
+	case nil:
+		return nil, true
+	case []any:
+		out := make([]any, 0, len(t))
+		for _, item := range t {
+			resource, _ := item.(map[string]any)
+			out = append(out, f.flatten(resource, 0, map[jsonAPIKey]bool{}))
+		}
+		return out, true
+	default:
+		return f.flatten(t.(map[string]any), 0, map[jsonAPIKey]bool{}), true
+	}
+}
+
+type jsonAPIKey struct{ typ, id string }
+
+type jsonAPIFlattener struct {
+	index map[jsonAPIKey]map[string]any
+}
+
+// flatten returns the record for one resource. visiting holds the resources
+// on the current path so reference cycles end in identifiers.
+func (f jsonAPIFlattener) flatten(resource map[string]any, depth int, visiting map[jsonAPIKey]bool) map[string]any {
+	out := map[string]any{}
+	if resource == nil {
+		return out
+	}
+	key, _ := jsonAPIIdentity(resource)
+	visiting[key] = true
+	defer delete(visiting, key)
+
+	if id, ok := resource["id"]; ok {
+		out["id"] = id
+	}
+	if lid, ok := resource["lid"]; ok && out["id"] == nil {
+		out["lid"] = lid
+	}
+	out["type"] = resource["type"]
+	if attributes, ok := resource["attributes"].(map[string]any); ok {
+		for name, value := range attributes {
+			out[name] = value
+		}
+	}
+	if relationships, ok := resource["relationships"].(map[string]any); ok {
+		for name, value := range relationships {
+			relationship, ok := value.(map[string]any)
+			if !ok {
+				continue
+			}
+			data, ok := relationship["data"]
+			if !ok {
+				// A links-only relationship has nothing to inline.
+				continue
+			}
+			switch t := data.(type) {
+			case nil:
+				out[name] = nil
+			case []any:
+				related := make([]any, 0, len(t))
+				for _, item := range t {
+					related = append(related, f.related(item, depth, visiting))
+				}
+				out[name] = related
+			default:
+				out[name] = f.related(t, depth, visiting)
+			}
+		}
+	}
+	if meta, ok := resource["meta"]; ok {
+		if _, taken := out["meta"]; !taken {
+			out["meta"] = meta
+		}
+	}
+	return out
+}
+
+func (f jsonAPIFlattener) related(identifier any, depth int, visiting map[jsonAPIKey]bool) any {
+	ref, ok := identifier.(map[string]any)
+	if !ok {
+		return identifier
+	}
+	key, ok := jsonAPIIdentity(ref)
+	if resource := f.index[key]; ok && resource != nil && !visiting[key] && depth < maxJSONAPIDepth {
+		return f.flatten(resource, depth+1, visiting)
+	}
+	out := map[string]any{"id": ref["id"], "type": ref["type"]}
+	if meta, ok := ref["meta"]; ok {
+		out["meta"] = meta
+	}
+	return out
+}
+
+// isJSONAPIData reports whether data is JSON:API primary data: null, a
+// resource object, or an array of resource objects.
+func isJSONAPIData(data any) bool {
+	switch t := data.(type) {
+	case nil:
+		return true
+	case map[string]any:
+		_, ok := t["type"].(string)
+		return ok
+	case []any:
+		for _, item := range t {
+			if !isJSONAPIData(item) || item == nil {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func jsonAPIResources(data any) []any {
+	switch t := data.(type) {
+	case []any:
+		return t
+	case map[string]any:
+		return []any{t}
+	}
+	return nil
+}
+
+func jsonAPIIdentity(resource map[string]any) (jsonAPIKey, bool) {
+	typ, _ := resource["type"].(string)
+	id, _ := resource["id"].(string)
+	if id == "" {
+		id, _ = resource["lid"].(string)
+	}
+	return jsonAPIKey{typ: typ, id: id}, typ != "" && id != ""
+}
@@ -626,3 +626,43 @@ func TestAutoFormatter_ImageBodyRendersImageWithoutHeaders(t *testing.T) {
 		t.Errorf("expected auto image output to render the image inline")
 	}
 }
+
+func TestFlattenJSONAPI(t *testing.T) {
+	var doc any
+	if err := json.Unmarshal([]byte(`{
+		"data": [
+			{"type": "articles", "id": "1", "attributes": {"title": "Hello"},
+			 "relationships": {
+				"author": {"data": {"type": "people", "id": "9"}},
+				"comments": {"data": [{"type": "comments", "id": "5"}]},
+				"related": {"links": {"related": "/articles/1/related"}}
+			 }}
+		],
+		"included": [
+			{"type": "people", "id": "9", "attributes": {"name": "Dan"},
+			 "relationships": {"articles": {"data": [{"type": "articles", "id": "1"}]}}}
+		],
+		"links": {"next": "/articles?page=2"}
+	}`), &doc); err != nil {
+		t.Fatal(err)
+	}
+	flat, ok := output.FlattenJSONAPI(doc)
+	if !ok {
+		t.Fatal("expected a JSON:API document")
+	}
+	got, _ := json.Marshal(flat)
+	want := `[{"author":{"articles":[{"id":"1","type":"articles"}],"id":"9","name":"Dan","type":"people"},"comments":[{"id":"5","type":"comments"}],"id":"1","title":"Hello","type":"articles"}]`
+	if string(got) != want {
+		t.Fatalf("flattened = %s", got)
+	}
+
+	single, ok := output.FlattenJSONAPI(map[string]any{"data": map[string]any{"type": "people", "id": "9", "attributes": map[string]any{"name": "Dan"}}})
+	if record, _ := single.(map[string]any); !ok || record["name"] != "Dan" || record["id"] != "9" {
+		t.Fatalf("single = %v, %v", single, ok)
+	}
+
+	errorsDoc := map[string]any{"errors": []any{map[string]any{"status": "404"}}}
+	if same, ok := output.FlattenJSONAPI(errorsDoc); ok || same.(map[string]any)["errors"] == nil {
+		t.Fatalf("errors document = %v, %v", same, ok)
+	}
+}
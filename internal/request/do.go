@@ -50,6 +50,10 @@ func (c *closeAfterBody) Close() error {
 	return err
 }
 
+func (c *closeAfterBody) Unwrap() io.ReadCloser {
+	return c.ReadCloser
+}
+
 func (c *closeAfterBody) DisableDeadline() bool {
 	if d, ok := c.ReadCloser.(interface{ DisableDeadline() bool }); ok {
 		return d.DisableDeadline()
@@ -78,6 +82,10 @@ func (b *deadlineBody) Close() error {
 	return b.ReadCloser.Close()
 }
 
+func (b *deadlineBody) Unwrap() io.ReadCloser {
+	return b.ReadCloser
+}
+
 func (b *deadlineBody) DisableDeadline() bool {
 	if b.stopTimer == nil {
 		return false
@@ -101,10 +109,39 @@ func DisableResponseBodyDeadline(resp *http.Response) bool {
 	return false
 }
 
+// UpgradedConn returns the bidirectional stream of a 101 Switching Protocols
+// response. net/http exposes it as a writable response body; reads and Close
+// go through the wrappers Do installed so transport cleanup still runs.
+func UpgradedConn(resp *http.Response) (io.ReadWriteCloser, bool) {
+	if resp == nil || resp.Body == nil || resp.StatusCode != http.StatusSwitchingProtocols {
+		return nil, false
+	}
+	var body io.ReadCloser = resp.Body
+	for {
+		if w, ok := body.(io.Writer); ok {
+			return upgradedConn{ReadCloser: resp.Body, Writer: w}, true
+		}
+		u, ok := body.(interface{ Unwrap() io.ReadCloser })
+		if !ok {
+			return nil, false
+		}
+		body = u.Unwrap()
+	}
+}
+
+type upgradedConn struct {
+	io.ReadCloser
+	io.Writer
+}
+
 // Options controls per-request behavior derived from CLI flags.
 type Options struct {
 	// Headers is a list of "Name: Value" header strings to add to the request.
 	Headers []string
+	// CredentialHeaders names Headers entries whose values are secrets resolved
+	// from config, so they are redacted and stripped like auth headers even
+	// when the header name is not generally sensitive.
+	CredentialHeaders []string
 	// Query is a list of "key=value" query parameter strings to append.
 	Query []string
 	// Server overrides the scheme and host (e.g. "https://staging.example.com").
@@ -257,6 +294,9 @@ func Do(ctx context.Context, method, rawURL string, body io.Reader, opts Options
 		}
 		addRequestHeader(req.Header, name, value, opts.PreserveHeaderCase)
 	}
+	for _, name := range opts.CredentialHeaders {
+		MarkCredentialHeader(req, name)
+	}
 
 	// Append extra query parameters.
 	if len(opts.Query) > 0 {
@@ -281,7 +321,7 @@ func Do(ctx context.Context, method, rawURL string, body io.Reader, opts Options
 	if opts.OnBeforeRequest != nil {
 		opts.OnBeforeRequest(req)
 	}
-	if opts.CacheNamespace == "" && (requestHasCredentialHeaders(req) || HasCredentialQuery(req.URL)) {
+	if opts.CacheNamespace == "" && (requestHasCredentialHeaders(req) || len(opts.CredentialHeaders) > 0 || HasCredentialQuery(req.URL)) {
 		// This late cache bypass only affects callers that have not already built
 		// opts.Transport. The CLI decides its cache namespace before constructing
 		// the shared transport so authenticated API-profile requests can cache
@@ -966,6 +966,59 @@ func TestDo_HeaderTimeoutOnlyBodyDeadlineCanBeDisabled(t *testing.T) {
 	}
 }
 
+func TestUpgradedConnWritesThroughDoWrappers(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Upgrade") != "echo" {
+			http.Error(w, "upgrade required", http.StatusUpgradeRequired)
+			return
+		}
+		conn, rw, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			t.Errorf("hijack: %v", err)
+			return
+		}
+		defer conn.Close()
+		_, _ = rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: echo\r\n\r\n")
+		_ = rw.Flush()
+		line, _ := rw.ReadString('\n')
+		_, _ = rw.WriteString("echo " + line)
+		_ = rw.Flush()
+	}))
+	defer srv.Close()
+
+	resp, err := request.Do(context.Background(), "GET", srv.URL, nil, request.Options{
+		Headers:           []string{"Connection: Upgrade", "Upgrade: echo"},
+		Timeout:           time.Second,
+		HeaderTimeoutOnly: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	request.DisableResponseBodyDeadline(resp)
+	conn, ok := request.UpgradedConn(resp)
+	if !ok {
+		t.Fatalf("UpgradedConn not available for %d response", resp.StatusCode)
+	}
+	if _, err := io.WriteString(conn, "hello\n"); err != nil {
+		t.Fatal(err)
+	}
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line != "echo hello\n" {
+		t.Fatalf("read %q", line)
+	}
+}
+
+func TestUpgradedConnRejectsOrdinaryResponse(t *testing.T) {
+	resp := &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("{}"))}
+	if _, ok := request.UpgradedConn(resp); ok {
+		t.Fatal("UpgradedConn accepted a 200 response")
+	}
+}
+
 type readerFunc func([]byte) (int, error)
 
 func (f readerFunc) Read(p []byte) (int, error) {
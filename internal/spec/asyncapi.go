@@ -0,0 +1,1058 @@
+package spec
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// AsyncAPILoader loads AsyncAPI 2.x and 3.x documents by converting them to
+// OpenAPI 3.1. A channel served over WebSocket becomes a GET operation with a
+// 101 response, so the CLI opens a session for it; a channel served over HTTP
+// becomes a GET operation returning text/event-stream for the messages the
+// client receives and a POST operation for the messages it sends. Message
+// payloads become the request body and response schemas, and security schemes
+// carry over as OpenAPI security schemes plus x-cli-config. Channels that are
+// only served over other protocols, such as Kafka or MQTT, are dropped with a
+// warning. Raw holds the converted document, so cached specs reload without
+// the AsyncAPI loader.
+type AsyncAPILoader struct{}
+
+func (AsyncAPILoader) Priority() int { return 20 }
+
+// Detect returns true for documents with an AsyncAPI 2.x or 3.x version
+// marker.
+func (AsyncAPILoader) Detect(contentType string, body []byte) bool {
+	return asyncAPIVersion(body) != ""
+}
+
+// LoadWithOptions converts an AsyncAPI document to OpenAPI and loads the
+// result. Conversion warnings are reported with the operation warnings.
+func (AsyncAPILoader) LoadWithOptions(body []byte, opts LoadOptions) (*APISpec, error) {
+	raw, warnings, err := convertAsyncAPI(body)
+	if err != nil {
+		return nil, &LoadError{Errors: []string{fmt.Sprintf("AsyncAPI: %v", err)}}
+	}
+	opts.ContentType = "application/yaml"
+	loaded, err := OpenAPILoader{}.LoadWithOptions(raw, opts)
+	if err != nil {
+		return nil, err
+	}
+	loaded.ContentType = "application/yaml"
+	for _, warning := range warnings {
+		loaded.loadWarnings = append(loaded.loadWarnings, "AsyncAPI conversion: "+warning)
+	}
+	return loaded, nil
+}
+
+// asyncAPIVersion returns the asyncapi version of body when it is a supported
+// 2.x or 3.x document, and "" otherwise.
+func asyncAPIVersion(body []byte) string {
+	if !bytes.Contains(body, []byte("asyncapi")) {
+		return ""
+	}
+	var doc struct {
+		AsyncAPI string `yaml:"asyncapi"`
+	}
+	if yaml.Unmarshal(body, &doc) != nil {
+		return ""
+	}
+	if strings.HasPrefix(doc.AsyncAPI, "2.") || strings.HasPrefix(doc.AsyncAPI, "3.") {
+		return doc.AsyncAPI
+	}
+	return ""
+}
+
+// asyncAPIConverter translates one AsyncAPI document. The client-side view is
+// used throughout: "receive" holds messages the server sends to the client,
+// and "send" holds messages the client sends to the server.
+type asyncAPIConverter struct {
+	root               *yaml.Node
+	v3                 bool
+	defaultContentType string
+	servers            []*asyncAPIServer
+	schemes            map[string]*yaml.Node
+	schemeOrder        []string
+	usedSchemes        []string
+	operationIDs       map[string]int
+	warnings           []string
+	warned             map[string]bool
+}
+
+type asyncAPIServer struct {
+	name string
+	// url is the http:// or https:// URL handshakes and requests are sent to.
+	url       string
+	kind      string // "ws" or "http"
+	variables *yaml.Node
+	// security is the converted OpenAPI security requirement list, or nil.
+	security *yaml.Node
+}
+
+type asyncAPIChannel struct {
+	key     string
+	address string
+	node    *yaml.Node
+	servers []string
+	receive asyncAPIDirection
+	send    asyncAPIDirection
+}
+
+// asyncAPIDirection collects the operations on a channel that move messages
+// one way.
+type asyncAPIDirection struct {
+	present     bool
+	id          string
+	summary     string
+	description string
+	tags        []string
+	messages    []*yaml.Node
+	security    *yaml.Node
+	bindings    *yaml.Node
+}
+
+func convertAsyncAPI(body []byte) ([]byte, []string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(body, &doc); err != nil {
+		return nil, nil, err
+	}
+	root := &doc
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+		root = doc.Content[0]
+	}
+	if root.Kind != yaml.MappingNode {
+		return nil, nil, fmt.Errorf("document must be an object")
+	}
+	c := &asyncAPIConverter{
+		root:               root,
+		v3:                 strings.HasPrefix(yamlString(root, "asyncapi"), "3."),
+		defaultContentType: yamlString(root, "defaultContentType"),
+		schemes:            map[string]*yaml.Node{},
+		operationIDs:       map[string]int{},
+		warned:             map[string]bool{},
+	}
+	if c.defaultContentType == "" {
+		c.defaultContentType = "application/json"
+	}
+	out, err := yaml.Marshal(c.document())
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, c.warnings, nil
+}
+
+func (c *asyncAPIConverter) warnf(format string, args ...any) {
+	warning := fmt.Sprintf(format, args...)
+	if !c.warned[warning] {
+		c.warned[warning] = true
+		c.warnings = append(c.warnings, warning)
+	}
+}
+
+func (c *asyncAPIConverter) document() *yaml.Node {
+	out := yamlMapping()
+	yamlSet(out, "openapi", yamlScalar("3.1.0"))
+	info := yamlMapping()
+	srcInfo := yamlGet(c.root, "info")
+	yamlCopyFields(info, srcInfo, "title", "version", "description", "contact", "license")
+	if yamlGet(info, "title") == nil {
+		yamlSet(info, "title", yamlScalar("AsyncAPI"))
+	}
+	if yamlGet(info, "version") == nil {
+		yamlSet(info, "version", yamlScalar("1.0.0"))
+	}
+	yamlSet(out, "info", info)
+
+	c.convertSecuritySchemes()
+	c.convertServers()
+	var defaultServer *asyncAPIServer
+	for _, server := range c.servers {
+		if server.kind != "" {
+			defaultServer = server
+			break
+		}
+	}
+	if defaultServer != nil {
+		yamlSet(out, "servers", asyncAPIServerList(defaultServer))
+	}
+
+	tags := c.root
+	if c.v3 {
+		tags = srcInfo
+	}
+	if list := yamlGet(tags, "tags"); list != nil && list.Kind == yaml.SequenceNode {
+		yamlSet(out, "tags", list)
+	}
+	if docs := yamlGet(c.root, "externalDocs"); docs != nil {
+		yamlSet(out, "externalDocs", docs)
+	}
+
+	paths := yamlMapping()
+	channels := c.channels()
+	if len(channels) == 0 {
+		c.warnf("document has no channels")
+	}
+	for _, ch := range channels {
+		c.addChannel(paths, ch, defaultServer)
+	}
+	yamlSet(out, "paths", paths)
+
+	components := yamlMapping()
+	if schemas := c.componentSchemas(); schemas != nil {
+		yamlSet(components, "schemas", schemas)
+	}
+	if len(c.usedSchemes) > 0 {
+		securitySchemes := yamlMapping()
+		for _, id := range c.usedSchemes {
+			yamlSet(securitySchemes, id, c.schemes[id])
+		}
+		yamlSet(components, "securitySchemes", securitySchemes)
+	}
+	if len(components.Content) > 0 {
+		yamlSet(out, "components", components)
+	}
+	yamlCopyExtensions(out, c.root)
+	if yamlGet(out, "x-cli-config") == nil && len(c.usedSchemes) > 0 {
+		profile := yamlMapping()
+		yamlSet(profile, "security", yamlScalar(c.usedSchemes[0]))
+		profiles := yamlMapping()
+		yamlSet(profiles, "default", profile)
+		xcli := yamlMapping()
+		yamlSet(xcli, "profiles", profiles)
+		yamlSet(out, "x-cli-config", xcli)
+	}
+	return out
+}
+
+// resolve follows local $ref chains and returns the referenced node. External
+// references cannot be followed and resolve to nil with a warning.
+func (c *asyncAPIConverter) resolve(n *yaml.Node) *yaml.Node {
+	for range 16 {
+		ref := yamlString(n, "$ref")
+		if ref == "" {
+			return n
+		}
+		target := asyncAPIPointer(c.root, ref)
+		if target == nil {
+			c.warnf("unresolved reference %q was dropped", ref)
+			return nil
+		}
+		n = target
+	}
+	return nil
+}
+
+// asyncAPIPointer returns the node a same-document reference such as
+// "#/components/messages/Chat" points to, or nil.
+func asyncAPIPointer(root *yaml.Node, ref string) *yaml.Node {
+	pointer, ok := strings.CutPrefix(ref, "#/")
+	if !ok {
+		return nil
+	}
+	n := root
+	for _, token := range strings.Split(pointer, "/") {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		if unescaped, err := url.PathUnescape(token); err == nil {
+			token = unescaped
+		}
+		switch n.Kind {
+		case yaml.MappingNode:
+			n = yamlGet(n, token)
+		case yaml.SequenceNode:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(n.Content) {
+				return nil
+			}
+			n = n.Content[i]
+		default:
+			return nil
+		}
+		if n == nil {
+			return nil
+		}
+	}
+	return n
+}
+
+// convertSecuritySchemes converts components.securitySchemes. Schemes with no
+// HTTP equivalent are dropped with a warning.
+func (c *asyncAPIConverter) convertSecuritySchemes() {
+	schemes := yamlGet(yamlGet(c.root, "components"), "securitySchemes")
+	if schemes == nil || schemes.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(schemes.Content); i += 2 {
+		id := schemes.Content[i].Value
+		if scheme := c.securityScheme(id, c.resolve(schemes.Content[i+1])); scheme != nil {
+			c.schemes[id] = scheme
+			c.schemeOrder = append(c.schemeOrder, id)
+		}
+	}
+}
+
+func (c *asyncAPIConverter) securityScheme(id string, src *yaml.Node) *yaml.Node {
+	if src == nil {
+		return nil
+	}
+	scheme := yamlMapping()
+	typ := yamlString(src, "type")
+	switch typ {
+	case "http":
+		yamlSet(scheme, "type", yamlScalar("http"))
+		yamlCopyFields(scheme, src, "scheme", "bearerFormat")
+	case "userPassword":
+		yamlSet(scheme, "type", yamlScalar("http"))
+		yamlSet(scheme, "scheme", yamlScalar("basic"))
+	case "httpApiKey":
+		in := yamlString(src, "in")
+		if yamlString(src, "name") == "" || (in != "header" && in != "query" && in != "cookie") {
+			c.warnf("security scheme %q: httpApiKey needs a name and in: header, query, or cookie; dropped", id)
+			return nil
+		}
+		yamlSet(scheme, "type", yamlScalar("apiKey"))
+		yamlCopyFields(scheme, src, "name", "in")
+	case "oauth2":
+		flows := yamlMapping()
+		srcFlows := yamlGet(src, "flows")
+		for _, name := range []string{"implicit", "password", "clientCredentials", "authorizationCode"} {
+			srcFlow := yamlGet(srcFlows, name)
+			if srcFlow == nil {
+				continue
+			}
+			flow := yamlMapping()
+			yamlCopyFields(flow, srcFlow, "authorizationUrl", "tokenUrl", "refreshUrl")
+			scopes := yamlGet(srcFlow, "availableScopes")
+			if scopes == nil {
+				scopes = yamlGet(srcFlow, "scopes")
+			}
+			if scopes == nil {
+				scopes = yamlMapping()
+			}
+			yamlSet(flow, "scopes", scopes)
+			yamlSet(flows, name, flow)
+		}
+		if len(flows.Content) == 0 {
+			c.warnf("security scheme %q: oauth2 without flows; dropped", id)
+			return nil
+		}
+		yamlSet(scheme, "type", yamlScalar("oauth2"))
+		yamlSet(scheme, "flows", flows)
+	case "openIdConnect":
+		yamlSet(scheme, "type", yamlScalar("openIdConnect"))
+		yamlCopyFields(scheme, src, "openIdConnectUrl")
+	default:
+		c.warnf("security scheme %q: type %q has no HTTP equivalent; dropped", id, typ)
+		return nil
+	}
+	yamlCopyFields(scheme, src, "description")
+	return scheme
+}
+
+// security converts a server or operation security list. AsyncAPI 2.x uses
+// OpenAPI-style requirement objects; 3.x lists security schemes, any one of
+// which suffices, with required scopes on the scheme itself.
+func (c *asyncAPIConverter) security(src *yaml.Node) *yaml.Node {
+	if src == nil || src.Kind != yaml.SequenceNode {
+		return nil
+	}
+	out := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	for _, item := range src.Content {
+		requirement := yamlMapping()
+		if c.v3 {
+			id := ""
+			if ref := yamlString(item, "$ref"); strings.HasPrefix(ref, "#/components/securitySchemes/") {
+				id = strings.TrimPrefix(ref, "#/components/securitySchemes/")
+			}
+			scheme := c.resolve(item)
+			if id == "" && scheme != nil {
+				// Inline scheme: register it under a name derived from its type.
+				id = c.inlineSchemeID(scheme)
+			}
+			if _, ok := c.schemes[id]; !ok {
+				continue
+			}
+			scopes := yamlGet(scheme, "scopes")
+			if scopes == nil || scopes.Kind != yaml.SequenceNode {
+				scopes = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Style: yaml.FlowStyle}
+			}
+			yamlSet(requirement, id, scopes)
+			c.useScheme(id)
+		} else {
+			for i := 0; item.Kind == yaml.MappingNode && i+1 < len(item.Content); i += 2 {
+				id := item.Content[i].Value
+				if _, ok := c.schemes[id]; !ok {
+					c.warnf("security requirement %q names an unsupported or undeclared scheme; dropped", id)
+					requirement = nil
+					break
+				}
+				yamlSet(requirement, id, item.Content[i+1])
+				c.useScheme(id)
+			}
+		}
+		if requirement != nil && len(requirement.Content) > 0 {
+			out.Content = append(out.Content, requirement)
+		}
+	}
+	if len(src.Content) > 0 && len(out.Content) == 0 {
+		return nil
+	}
+	return out
+}
+
+func (c *asyncAPIConverter) inlineSchemeID(src *yaml.Node) string {
+	typ := yamlString(src, "type")
+	base := typ + "Auth"
+	for n := 1; ; n++ {
+		id := base
+		if n > 1 {
+			id = fmt.Sprintf("%s%d", base, n)
+		}
+		existing, ok := c.schemes[id]
+		if !ok {
+			scheme := c.securityScheme(id, src)
+			if scheme == nil {
+				return ""
+			}
+			c.schemes[id] = scheme
+			c.schemeOrder = append(c.schemeOrder, id)
+			return id
+		}
+		if converted := c.securityScheme(id, src); converted != nil && schemeKey(converted) == schemeKey(existing) {
+			return id
+		}
+	}
+}
+
+func (c *asyncAPIConverter) useScheme(id string) {
+	for _, used := range c.usedSchemes {
+		if used == id {
+			return
+		}
+	}
+	c.usedSchemes = append(c.usedSchemes, id)
+}
+
+// convertServers records every server with its HTTP URL and kind. Servers on
+// protocols other than WebSocket and HTTP keep an empty kind.
+func (c *asyncAPIConverter) convertServers() {
+	servers := yamlGet(c.root, "servers")
+	if servers == nil || servers.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(servers.Content); i += 2 {
+		name := servers.Content[i].Value
+		src := c.resolve(servers.Content[i+1])
+		if src == nil {
+			continue
+		}
+		protocol := strings.ToLower(yamlString(src, "protocol"))
+		server := &asyncAPIServer{name: name}
+		secure := false
+		switch protocol {
+		case "ws", "wss":
+			server.kind, secure = "ws", protocol == "wss"
+		case "http", "https", "sse":
+			server.kind, secure = "http", protocol == "https"
+		}
+		var rawURL string
+		if c.v3 {
+			rawURL = yamlString(src, "host") + yamlString(src, "pathname")
+		} else {
+			rawURL = yamlString(src, "url")
+		}
+		if scheme, rest, ok := strings.Cut(rawURL, "://"); ok {
+			secure = secure || scheme == "wss" || scheme == "https"
+			rawURL = rest
+		}
+		if secure {
+			server.url = "https://" + rawURL
+		} else {
+			server.url = "http://" + rawURL
+		}
+		server.url = strings.TrimRight(server.url, "/")
+		server.variables = yamlGet(src, "variables")
+		server.security = c.security(yamlGet(src, "security"))
+		if server.kind == "" {
+			c.warnf("server %q: protocol %q is not WebSocket or HTTP; channels served only there are dropped", name, protocol)
+		}
+		c.servers = append(c.servers, server)
+	}
+}
+
+func asyncAPIServerList(server *asyncAPIServer) *yaml.Node {
+	node := yamlMapping()
+	yamlSet(node, "url", yamlScalar(server.url))
+	if server.variables != nil && server.variables.Kind == yaml.MappingNode {
+		vars := yamlMapping()
+		for i := 0; i+1 < len(server.variables.Content); i += 2 {
+			v := yamlMapping()
+			yamlCopyFields(v, server.variables.Content[i+1], "enum", "default", "description")
+			if yamlGet(v, "default") == nil {
+				if enum := yamlStrings(yamlGet(v, "enum")); len(enum) > 0 {
+					yamlSet(v, "default", yamlScalar(enum[0]))
+				} else {
+					yamlSet(v, "default", yamlScalar(""))
+				}
+			}
+			yamlSet(vars, server.variables.Content[i].Value, v)
+		}
+		yamlSet(node, "variables", vars)
+	}
+	list := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	list.Content = append(list.Content, node)
+	return list
+}
+
+// channels gathers the channels and the operations on them in document
+// order.
+func (c *asyncAPIConverter) channels() []*asyncAPIChannel {
+	src := yamlGet(c.root, "channels")
+	if src == nil || src.Kind != yaml.MappingNode {
+		return nil
+	}
+	var channels []*asyncAPIChannel
+	byKey := map[string]*asyncAPIChannel{}
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		key := src.Content[i].Value
+		node := c.resolve(src.Content[i+1])
+		if node == nil {
+			continue
+		}
+		ch := &asyncAPIChannel{key: key, address: key, node: node}
+		if c.v3 {
+			address := yamlGet(node, "address")
+			if address == nil || address.Tag == "!!null" || address.Value == "" {
+				c.warnf("channel %q: no address; dropped", key)
+				continue
+			}
+			ch.address = address.Value
+			for _, ref := range asyncAPIItems(yamlGet(node, "servers")) {
+				if name, ok := strings.CutPrefix(yamlString(ref, "$ref"), "#/servers/"); ok {
+					ch.servers = append(ch.servers, name)
+				}
+			}
+		} else {
+			ch.servers = yamlStrings(yamlGet(node, "servers"))
+			c.addDirection(&ch.receive, yamlGet(node, "subscribe"), nil)
+			c.addDirection(&ch.send, yamlGet(node, "publish"), nil)
+		}
+		channels = append(channels, ch)
+		byKey[key] = ch
+	}
+	if !c.v3 {
+		return channels
+	}
+
+	ops := yamlGet(c.root, "operations")
+	for i := 0; ops != nil && i+1 < len(ops.Content); i += 2 {
+		id := ops.Content[i].Value
+		op := c.resolve(ops.Content[i+1])
+		if op == nil {
+			continue
+		}
+		chRef := yamlString(yamlGet(op, "channel"), "$ref")
+		ch := byKey[strings.TrimPrefix(chRef, "#/channels/")]
+		if ch == nil {
+			c.warnf("operation %q: channel %q not found; dropped", id, chRef)
+			continue
+		}
+		var messages []*yaml.Node
+		if refs := yamlGet(op, "messages"); refs != nil && len(refs.Content) > 0 {
+			messages = refs.Content
+		} else if all := yamlGet(ch.node, "messages"); all != nil {
+			for j := 1; j < len(all.Content); j += 2 {
+				messages = append(messages, all.Content[j])
+			}
+		}
+		if yamlString(op, "operationId") == "" {
+			yamlSet(op, "operationId", yamlScalar(id))
+		}
+		switch yamlString(op, "action") {
+		case "send":
+			c.addDirection(&ch.receive, op, messages)
+		case "receive":
+			c.addDirection(&ch.send, op, messages)
+		default:
+			c.warnf("operation %q: unknown action %q; dropped", id, yamlString(op, "action"))
+		}
+	}
+	return channels
+}
+
+// addDirection merges an AsyncAPI operation into dir. For 2.x the messages
+// come from the operation's message field; 3.x passes them in.
+func (c *asyncAPIConverter) addDirection(dir *asyncAPIDirection, op *yaml.Node, messages []*yaml.Node) {
+	op = c.resolve(op)
+	if op == nil {
+		return
+	}
+	dir.present = true
+	if dir.id == "" {
+		dir.id = yamlString(op, "operationId")
+	}
+	if dir.summary == "" {
+		dir.summary = yamlString(op, "summary")
+	}
+	if dir.description == "" {
+		dir.description = yamlString(op, "description")
+	}
+	for _, tag := range asyncAPIItems(yamlGet(op, "tags")) {
+		if name := yamlString(c.resolve(tag), "name"); name != "" {
+			dir.tags = append(dir.tags, name)
+		}
+	}
+	if dir.security == nil {
+		dir.security = c.security(yamlGet(op, "security"))
+	}
+	if dir.bindings == nil {
+		dir.bindings = c.resolve(yamlGet(op, "bindings"))
+	}
+	if messages == nil {
+		message := c.resolve(yamlGet(op, "message"))
+		if oneOf := yamlGet(message, "oneOf"); oneOf != nil && oneOf.Kind == yaml.SequenceNode {
+			messages = oneOf.Content
+		} else if message != nil {
+			messages = []*yaml.Node{message}
+		}
+	}
+	for _, m := range messages {
+		if resolved := c.resolve(m); resolved != nil {
+			dir.messages = append(dir.messages, resolved)
+		}
+	}
+}
+
+// channelServer picks the first WebSocket or HTTP server the channel is
+// available on.
+func (c *asyncAPIConverter) channelServer(ch *asyncAPIChannel) *asyncAPIServer {
+	for _, server := range c.servers {
+		if server.kind == "" {
+			continue
+		}
+		if len(ch.servers) == 0 {
+			return server
+		}
+		for _, name := range ch.servers {
+			if name == server.name {
+				return server
+			}
+		}
+	}
+	return nil
+}
+
+// asyncAPIItems returns the items of a sequence node, or nil for anything
+// else.
+func asyncAPIItems(n *yaml.Node) []*yaml.Node {
+	if n == nil || n.Kind != yaml.SequenceNode {
+		return nil
+	}
+	return n.Content
+}
+
+var asyncAPIParamPattern = regexp.MustCompile(`\{([^{}]+)\}`)
+
+func (c *asyncAPIConverter) addChannel(paths *yaml.Node, ch *asyncAPIChannel, defaultServer *asyncAPIServer) {
+	if !ch.receive.present && !ch.send.present {
+		c.warnf("channel %q: no operations; dropped", ch.key)
+		return
+	}
+	server := c.channelServer(ch)
+	if server == nil {
+		c.warnf("channel %q: not served over WebSocket or HTTP; dropped", ch.key)
+		return
+	}
+	path := ch.address
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	item := yamlGet(paths, path)
+	if item == nil {
+		item = yamlMapping()
+		yamlSet(paths, path, item)
+	}
+	params := c.channelParameters(ch, path)
+
+	op := func(id string, dirs ...*asyncAPIDirection) *yaml.Node {
+		node := yamlMapping()
+		var tags []string
+		var summaries, descriptions []string
+		var security *yaml.Node
+		for _, dir := range dirs {
+			tags = append(tags, dir.tags...)
+			if dir.summary != "" {
+				summaries = append(summaries, dir.summary)
+			}
+			if dir.description != "" {
+				descriptions = append(descriptions, dir.description)
+			}
+			if security == nil {
+				security = dir.security
+			}
+		}
+		if len(tags) > 0 {
+			tagList := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+			seen := map[string]bool{}
+			for _, tag := range tags {
+				if !seen[tag] {
+					seen[tag] = true
+					tagList.Content = append(tagList.Content, yamlScalar(tag))
+				}
+			}
+			yamlSet(node, "tags", tagList)
+		}
+		summary := strings.Join(summaries, " / ")
+		if summary == "" {
+			summary = yamlString(ch.node, "summary")
+		}
+		if summary != "" {
+			yamlSet(node, "summary", yamlScalar(summary))
+		}
+		if desc := yamlString(ch.node, "description"); desc != "" {
+			descriptions = append([]string{desc}, descriptions...)
+		}
+		if len(descriptions) > 0 {
+			yamlSet(node, "description", yamlScalar(strings.Join(descriptions, "\n\n")))
+		}
+		yamlSet(node, "operationId", yamlScalar(c.operationID(id)))
+		if server != defaultServer {
+			yamlSet(node, "servers", asyncAPIServerList(server))
+		}
+		if len(params.Content) > 0 {
+			yamlSet(node, "parameters", params)
+		}
+		if security == nil {
+			security = server.security
+		}
+		if security != nil {
+			yamlSet(node, "security", security)
+		}
+		return node
+	}
+
+	slug := collectionSlug(ch.key)
+	if server.kind == "ws" {
+		id := ch.receive.id
+		if id == "" {
+			id = ch.send.id
+		}
+		if id == "" {
+			id = slug
+		}
+		node := op(id, &ch.receive, &ch.send)
+		if body := c.messageContent(ch.send.messages, ""); body != nil {
+			requestBody := yamlMapping()
+			yamlSet(requestBody, "description", yamlScalar("Message sent over the WebSocket"))
+			yamlSet(requestBody, "content", body)
+			yamlSet(node, "requestBody", requestBody)
+		}
+		switching := yamlMapping()
+		yamlSet(switching, "description", yamlScalar("Switching to WebSocket; messages received follow"))
+		if content := c.messageContent(ch.receive.messages, ""); content != nil {
+			yamlSet(switching, "content", content)
+		}
+		responses := yamlMapping()
+		yamlSet(responses, "101", switching)
+		yamlSet(node, "responses", responses)
+		yamlSet(item, "get", node)
+		return
+	}
+
+	if ch.receive.present {
+		id := ch.receive.id
+		if id == "" {
+			id = slug
+		}
+		node := op(id, &ch.receive)
+		ok := yamlMapping()
+		yamlSet(ok, "description", yamlScalar("Event stream of received messages"))
+		mediaType := mimeEventStream
+		for _, m := range ch.receive.messages {
+			if ct := yamlString(m, "contentType"); isStreamingMediaType(ct) {
+				mediaType = ct
+				break
+			}
+		}
+		content := c.messageContent(ch.receive.messages, mediaType)
+		if content == nil {
+			content = yamlMapping()
+			yamlSet(content, mediaType, yamlMapping())
+		}
+		yamlSet(ok, "content", content)
+		responses := yamlMapping()
+		yamlSet(responses, "200", ok)
+		yamlSet(node, "responses", responses)
+		yamlSet(item, c.httpMethod(&ch.receive, "get"), node)
+	}
+	if ch.send.present {
+		id := ch.send.id
+		if id == "" {
+			id = slug
+			if ch.receive.present {
+				id = slug + "-send"
+			}
+		}
+		node := op(id, &ch.send)
+		if content := c.messageContent(ch.send.messages, ""); content != nil {
+			requestBody := yamlMapping()
+			yamlSet(requestBody, "required", yamlBool(true))
+			yamlSet(requestBody, "content", content)
+			yamlSet(node, "requestBody", requestBody)
+		}
+		accepted := yamlMapping()
+		yamlSet(accepted, "description", yamlScalar("Message accepted"))
+		responses := yamlMapping()
+		yamlSet(responses, "200", accepted)
+		yamlSet(node, "responses", responses)
+		method := c.httpMethod(&ch.send, "post")
+		if yamlGet(item, method) != nil {
+			c.warnf("channel %q: both directions use HTTP %s; keeping the first", ch.key, strings.ToUpper(method))
+			return
+		}
+		yamlSet(item, method, node)
+	}
+}
+
+const mimeEventStream = "text/event-stream"
+
+func isStreamingMediaType(ct string) bool {
+	switch strings.ToLower(strings.TrimSpace(ct)) {
+	case mimeEventStream, "application/x-ndjson", "application/ndjson", "application/jsonl", "application/jsonlines":
+		return true
+	}
+	return false
+}
+
+// httpMethod returns the method from an operation's HTTP binding, or
+// fallback.
+func (c *asyncAPIConverter) httpMethod(dir *asyncAPIDirection, fallback string) string {
+	method := strings.ToLower(yamlString(yamlGet(dir.bindings, "http"), "method"))
+	if collectionMethods[method] {
+		return method
+	}
+	return fallback
+}
+
+func (c *asyncAPIConverter) operationID(base string) string {
+	c.operationIDs[base]++
+	if n := c.operationIDs[base]; n > 1 {
+		return fmt.Sprintf("%s-%d", base, n)
+	}
+	return base
+}
+
+// channelParameters converts the address parameters, plus the query and
+// header schemas of a WebSocket or HTTP channel binding, to OpenAPI
+// parameters.
+func (c *asyncAPIConverter) channelParameters(ch *asyncAPIChannel, path string) *yaml.Node {
+	list := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	declared := c.resolve(yamlGet(ch.node, "parameters"))
+	for _, m := range asyncAPIParamPattern.FindAllStringSubmatch(path, -1) {
+		name := m[1]
+		src := c.resolve(yamlGet(declared, name))
+		param := yamlMapping()
+		yamlSet(param, "name", yamlScalar(name))
+		yamlSet(param, "in", yamlScalar("path"))
+		yamlSet(param, "required", yamlBool(true))
+		yamlCopyFields(param, src, "description")
+		schema := c.schema(yamlGet(src, "schema"), 0)
+		if schema == nil {
+			// AsyncAPI 3.x parameters are always strings with optional enum
+			// and default values.
+			schema = yamlMapping()
+			yamlSet(schema, "type", yamlScalar("string"))
+			yamlCopyFields(schema, src, "enum", "default")
+		}
+		yamlSet(param, "schema", schema)
+		if examples := yamlStrings(yamlGet(src, "examples")); len(examples) > 0 {
+			yamlSet(param, "example", yamlScalar(examples[0]))
+		}
+		list.Content = append(list.Content, param)
+	}
+
+	seen := map[string]bool{}
+	addObject := func(src *yaml.Node, in string) {
+		schema := c.resolve(src)
+		props := yamlGet(schema, "properties")
+		if props == nil || props.Kind != yaml.MappingNode {
+			return
+		}
+		required := map[string]bool{}
+		for _, name := range yamlStrings(yamlGet(schema, "required")) {
+			required[name] = true
+		}
+		for i := 0; i+1 < len(props.Content); i += 2 {
+			name := props.Content[i].Value
+			if seen[in+":"+strings.ToLower(name)] || (in == "header" && skipCollectionHeader(name)) {
+				continue
+			}
+			seen[in+":"+strings.ToLower(name)] = true
+			param := yamlMapping()
+			yamlSet(param, "name", yamlScalar(name))
+			yamlSet(param, "in", yamlScalar(in))
+			if required[name] {
+				yamlSet(param, "required", yamlBool(true))
+			}
+			propSchema := c.schema(props.Content[i+1], 0)
+			if desc := yamlString(propSchema, "description"); desc != "" {
+				yamlSet(param, "description", yamlScalar(desc))
+			}
+			if propSchema == nil {
+				propSchema = yamlMapping()
+			}
+			yamlSet(param, "schema", propSchema)
+			list.Content = append(list.Content, param)
+		}
+	}
+	ws := yamlGet(c.resolve(yamlGet(ch.node, "bindings")), "ws")
+	addObject(yamlGet(ws, "query"), "query")
+	addObject(yamlGet(ws, "headers"), "header")
+	addObject(yamlGet(yamlGet(ch.receive.bindings, "http"), "query"), "query")
+	addObject(yamlGet(yamlGet(ch.send.bindings, "http"), "query"), "query")
+	return list
+}
+
+// messageContent builds an OpenAPI content map for messages. Several messages
+// become a oneOf of their payloads. mediaType overrides the messages' own
+// content types, as for an event stream that carries JSON messages.
+func (c *asyncAPIConverter) messageContent(messages []*yaml.Node, mediaType string) *yaml.Node {
+	if len(messages) == 0 {
+		return nil
+	}
+	var payloads []*yaml.Node
+	var example *yaml.Node
+	for _, m := range messages {
+		if mediaType == "" {
+			mediaType = yamlString(m, "contentType")
+		}
+		if payload := c.payloadSchema(m); payload != nil {
+			payloads = append(payloads, payload)
+		}
+		if example == nil {
+			if examples := yamlGet(m, "examples"); examples != nil && len(examples.Content) > 0 {
+				example = yamlGet(examples.Content[0], "payload")
+			}
+		}
+	}
+	if mediaType == "" {
+		mediaType = c.defaultContentType
+	}
+	media := yamlMapping()
+	switch len(payloads) {
+	case 0:
+	case 1:
+		yamlSet(media, "schema", payloads[0])
+	default:
+		schema := yamlMapping()
+		list := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		list.Content = payloads
+		yamlSet(schema, "oneOf", list)
+		yamlSet(media, "schema", schema)
+	}
+	if example != nil {
+		yamlSet(media, "example", example)
+	}
+	content := yamlMapping()
+	yamlSet(content, mediaType, media)
+	return content
+}
+
+// payloadSchema returns the converted payload schema of a message. Payloads
+// in non-JSON schema formats, such as Avro, are dropped with a warning.
+func (c *asyncAPIConverter) payloadSchema(message *yaml.Node) *yaml.Node {
+	payload := c.resolve(yamlGet(message, "payload"))
+	format := yamlString(message, "schemaFormat")
+	if c.v3 && yamlGet(payload, "schemaFormat") != nil {
+		format = yamlString(payload, "schemaFormat")
+		payload = c.resolve(yamlGet(payload, "schema"))
+	}
+	if payload == nil {
+		return nil
+	}
+	if format != "" && !isJSONSchemaFormat(format) {
+		name := yamlString(message, "name")
+		if name == "" {
+			name = yamlString(message, "title")
+		}
+		c.warnf("message %q: payload schema format %q is not supported; schema dropped", name, format)
+		return nil
+	}
+	return c.schema(payload, 0)
+}
+
+func isJSONSchemaFormat(format string) bool {
+	format = strings.ToLower(format)
+	for _, prefix := range []string{"application/vnd.aai.asyncapi", "application/schema+json", "application/schema+yaml", "application/vnd.oai.openapi"} {
+		if strings.HasPrefix(format, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// schema copies a JSON Schema node. References to components.schemas are kept
+// since the converted document carries those schemas; other same-document
+// references are inlined, and external references are left for the OpenAPI
+// loader to resolve.
+func (c *asyncAPIConverter) schema(n *yaml.Node, depth int) *yaml.Node {
+	if n == nil {
+		return nil
+	}
+	if ref := yamlString(n, "$ref"); ref != "" && n.Kind == yaml.MappingNode {
+		if !strings.HasPrefix(ref, "#/") || strings.HasPrefix(ref, "#/components/schemas/") {
+			return n
+		}
+		if depth > 8 {
+			c.warnf("reference %q nests too deeply; replaced with an empty schema", ref)
+			return yamlMapping()
+		}
+		target := c.resolve(n)
+		if target == nil {
+			return yamlMapping()
+		}
+		return c.schema(target, depth+1)
+	}
+	switch n.Kind {
+	case yaml.MappingNode:
+		out := yamlMapping()
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			out.Content = append(out.Content, n.Content[i], c.schema(n.Content[i+1], depth))
+		}
+		return out
+	case yaml.SequenceNode:
+		out := &yaml.Node{Kind: yaml.SequenceNode, Tag: n.Tag, Style: n.Style}
+		for _, item := range n.Content {
+			out.Content = append(out.Content, c.schema(item, depth))
+		}
+		return out
+	}
+	return n
+}
+
+// componentSchemas copies components.schemas, unwrapping 3.x multi-format
+// schema objects.
+func (c *asyncAPIConverter) componentSchemas() *yaml.Node {
+	schemas := yamlGet(yamlGet(c.root, "components"), "schemas")
+	if schemas == nil || schemas.Kind != yaml.MappingNode || len(schemas.Content) == 0 {
+		return nil
+	}
+	out := yamlMapping()
+	for i := 0; i+1 < len(schemas.Content); i += 2 {
+		schema := schemas.Content[i+1]
+		if c.v3 && yamlGet(schema, "schemaFormat") != nil {
+			if !isJSONSchemaFormat(yamlString(schema, "schemaFormat")) {
+				c.warnf("schema %q: format %q is not supported; schema dropped", schemas.Content[i].Value, yamlString(schema, "schemaFormat"))
+				yamlSet(out, schemas.Content[i].Value, yamlMapping())
+				continue
+			}
+			schema = yamlGet(schema, "schema")
+		}
+		yamlSet(out, schemas.Content[i].Value, c.schema(schema, 0))
+	}
+	return out
+}
@@ -0,0 +1,256 @@
+package spec
+
+import (
+	"strings"
+	"testing"
+)
+
+const asyncAPIChat2 = `asyncapi: 2.6.0
+info:
+  title: Chat
+  version: 1.0.0
+defaultContentType: application/json
+servers:
+  public:
+    url: chat.example.com/v1
+    protocol: wss
+    security:
+      - bearer: []
+  events:
+    url: https://events.example.com
+    protocol: https
+  broker:
+    url: kafka.example.com:9092
+    protocol: kafka
+channels:
+  rooms/{room}:
+    servers: [public]
+    parameters:
+      room:
+        description: Room name
+        schema:
+          type: string
+    bindings:
+      ws:
+        query:
+          type: object
+          properties:
+            since:
+              type: integer
+              description: Replay messages after this sequence
+    subscribe:
+      operationId: roomMessages
+      summary: Receive room messages
+      message:
+        $ref: '#/components/messages/Chat'
+    publish:
+      operationId: sendRoomMessage
+      message:
+        $ref: '#/components/messages/Chat'
+  /notifications:
+    servers: [events]
+    subscribe:
+      operationId: notifications
+      message:
+        payload:
+          type: object
+          properties:
+            level:
+              type: string
+  audit:
+    servers: [broker]
+    subscribe:
+      message:
+        payload:
+          type: string
+components:
+  schemas:
+    ChatMessage:
+      type: object
+      required: [text]
+      properties:
+        text:
+          type: string
+  messages:
+    Chat:
+      name: chat
+      payload:
+        $ref: '#/components/schemas/ChatMessage'
+      examples:
+        - payload:
+            text: hello
+  securitySchemes:
+    bearer:
+      type: http
+      scheme: bearer
+    cert:
+      type: X509
+`
+
+func TestAsyncAPILoaderConvertsV2(t *testing.T) {
+	loaded, err := load("application/yaml", []byte(asyncAPIChat2), DefaultLoaders())
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	set, err := loaded.OperationSet(OperationOptions{BaseURL: "https://chat.example.com/v1"})
+	if err != nil {
+		t.Fatalf("operations: %v", err)
+	}
+	warnings := strings.Join(set.Warnings, "\n")
+	for _, want := range []string{`protocol "kafka"`, `channel "audit": not served over WebSocket or HTTP`, `security scheme "cert"`} {
+		if !strings.Contains(warnings, want) {
+			t.Fatalf("warnings = %v, want %q", set.Warnings, want)
+		}
+	}
+	if len(set.Operations) != 2 {
+		t.Fatalf("operations = %#v", set.Operations)
+	}
+
+	room := operationByID(t, set.Operations, "roomMessages")
+	if !room.WebSocket || room.Method != "GET" || room.Path != "/rooms/{room}" || room.Summary != "Receive room messages" {
+		t.Fatalf("roomMessages = %s %s websocket=%v summary=%q", room.Method, room.Path, room.WebSocket, room.Summary)
+	}
+	if len(room.Parameters) != 2 || room.Parameters[0].Name != "room" || room.Parameters[0].Desc != "Room name" || room.Parameters[1].Name != "since" || room.Parameters[1].In != "query" {
+		t.Fatalf("roomMessages params = %#v", room.Parameters)
+	}
+	if !room.HasBody || room.Help.Request == nil || !strings.Contains(room.Help.Request.Schema, "text") || room.Help.Request.JSONSchema == nil {
+		t.Fatalf("roomMessages send schema = %v %#v", room.HasBody, room.Help.Request)
+	}
+	if len(room.Help.Responses) != 1 || room.Help.Responses[0].Codes[0] != "101" || !strings.Contains(room.Help.Responses[0].Schema, "text") {
+		t.Fatalf("roomMessages receive help = %#v", room.Help.Responses)
+	}
+	requireCredential(t, room, [][]CredentialRequirement{{
+		{ID: "bearer", Ref: "#/components/securitySchemes/bearer", Kind: "http-bearer", Source: "openapi"},
+	}})
+
+	notifications := operationByID(t, set.Operations, "notifications")
+	if notifications.WebSocket || notifications.OperationServer != "https://events.example.com" || notifications.ResponseMediaType != "text/event-stream" {
+		t.Fatalf("notifications = websocket=%v server=%q media=%q", notifications.WebSocket, notifications.OperationServer, notifications.ResponseMediaType)
+	}
+	if len(notifications.Help.Responses) != 1 || !strings.Contains(notifications.Help.Responses[0].Schema, "level") {
+		t.Fatalf("notifications help = %#v", notifications.Help.Responses)
+	}
+
+	cfg, err := ReadXCLIConfig(loaded)
+	if err != nil || cfg == nil {
+		t.Fatalf("ReadXCLIConfig = %#v, %v", cfg, err)
+	}
+	profile := cfg.Resolve(loaded).Profiles["default"]
+	if profile == nil || profile.Auth == nil || profile.Auth.Type != "bearer" {
+		t.Fatalf("default profile auth = %#v", profile)
+	}
+}
+
+const asyncAPIPrices3 = `asyncapi: 3.0.0
+info:
+  title: Prices
+  version: 2.0.0
+servers:
+  live:
+    host: prices.example.com
+    pathname: /stream
+    protocol: https
+    security:
+      - $ref: '#/components/securitySchemes/oauth'
+channels:
+  ticker:
+    address: 'tickers/{symbol}'
+    parameters:
+      symbol:
+        enum: [BTC, ETH]
+        default: BTC
+    messages:
+      tick:
+        contentType: application/x-ndjson
+        payload:
+          type: object
+          properties:
+            price:
+              type: number
+      avroTick:
+        schemaFormat: application/vnd.apache.avro;version=1.9.0
+        payload:
+          type: record
+  orders:
+    address: /orders
+    messages:
+      order:
+        payload:
+          schemaFormat: application/schema+json;version=draft-07
+          schema:
+            type: object
+            properties:
+              qty:
+                type: integer
+operations:
+  watchTicker:
+    action: send
+    channel:
+      $ref: '#/channels/ticker'
+    messages:
+      - $ref: '#/channels/ticker/messages/tick'
+  placeOrder:
+    action: receive
+    channel:
+      $ref: '#/channels/orders'
+    security:
+      - type: httpApiKey
+        name: X-Key
+        in: header
+components:
+  securitySchemes:
+    oauth:
+      type: oauth2
+      flows:
+        clientCredentials:
+          tokenUrl: https://auth.example.com/token
+          availableScopes:
+            prices:read: Read prices
+      scopes: [prices:read]
+x-cli-config:
+  profiles:
+    default:
+      headers:
+        - 'X-Client: cli'
+`
+
+func TestAsyncAPILoaderConvertsV3(t *testing.T) {
+	if !(AsyncAPILoader{}).Detect("", []byte(asyncAPIPrices3)) || (AsyncAPILoader{}).Detect("", []byte("asyncapi: 1.2.0\n")) {
+		t.Fatal("Detect should accept AsyncAPI 3.x and reject 1.x")
+	}
+	loaded, err := load("application/yaml", []byte(asyncAPIPrices3), DefaultLoaders())
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	ops, err := loaded.Operations(OperationOptions{BaseURL: "https://prices.example.com/stream"})
+	if err != nil {
+		t.Fatalf("operations: %v", err)
+	}
+
+	watch := operationByID(t, ops, "watchTicker")
+	if watch.Method != "GET" || watch.Path != "/tickers/{symbol}" || watch.ResponseMediaType != "application/x-ndjson" {
+		t.Fatalf("watchTicker = %s %s %q", watch.Method, watch.Path, watch.ResponseMediaType)
+	}
+	if len(watch.Parameters) != 1 || !strings.Contains(strings.Join(watch.Parameters[0].Enum, ","), "ETH") {
+		t.Fatalf("watchTicker params = %#v", watch.Parameters)
+	}
+	requireCredential(t, watch, [][]CredentialRequirement{{
+		{ID: "oauth", Ref: "#/components/securitySchemes/oauth", Kind: "oauth2", Needs: []string{"prices:read"}, Source: "openapi"},
+	}})
+
+	order := operationByID(t, ops, "placeOrder")
+	if order.Method != "POST" || !order.HasBody || order.Help.Request == nil || !strings.Contains(order.Help.Request.Schema, "qty") {
+		t.Fatalf("placeOrder = %s body=%v %#v", order.Method, order.HasBody, order.Help.Request)
+	}
+	requireCredential(t, order, [][]CredentialRequirement{{
+		{ID: "httpApiKeyAuth", Ref: "#/components/securitySchemes/httpApiKeyAuth", Kind: "api-key", In: "header", Name: "X-Key", Source: "openapi"},
+	}})
+
+	cfg, err := ReadXCLIConfig(loaded)
+	if err != nil || cfg == nil {
+		t.Fatalf("ReadXCLIConfig = %#v, %v", cfg, err)
+	}
+	if profile := cfg.Resolve(loaded).Profiles["default"]; profile == nil || len(profile.Headers) != 1 {
+		t.Fatalf("x-cli-config should pass through unchanged, got %#v", profile)
+	}
+}
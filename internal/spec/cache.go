@@ -22,6 +22,7 @@ type cacheEntry struct {
 	Schema     int              `cbor:"schema,omitempty"`
 	Spec       cachedRaw        `cbor:"spec,omitempty"`
 	SpecFiles  []cachedSpecFile `cbor:"spec_files,omitempty"`
+	Overlays   []cachedSpecFile `cbor:"overlays,omitempty"`
 	Operations []opsBlob        `cbor:"operations,omitempty"`
 
 	// Legacy v2-dev fields. Keep them readable so older raw-only cache entries
@@ -59,10 +60,11 @@ type opsBlob struct {
 	Info               APIInfo             `cbor:"info,omitempty"`
 	Operations         []Operation         `cbor:"operations"`
 	XCLIExtensions     XCLIExtensionReport `cbor:"x_cli_extensions,omitempty"`
+	RPC                *RPCAPI             `cbor:"rpc,omitempty"`
 }
 
 const currentCacheSchema = 2
-const currentOperationCacheSchema = 12
+const currentOperationCacheSchema = 17
 
 // OperationCacheStatus describes the freshness of cached operation metadata.
 type OperationCacheStatus struct {
@@ -111,6 +113,9 @@ func readCacheEntry(cacheDir, apiName, version string, allowExpired bool) (*cach
 	if !allowExpired && !e.ExpiresAt.IsZero() && time.Now().After(e.ExpiresAt) {
 		return nil, false // TTL expired
 	}
+	if cachedOverlaysChanged(&e) {
+		return nil, false // a local overlay was edited; Raw is out of date
+	}
 	e.normalize()
 	return &e, true
 }
@@ -184,6 +189,16 @@ func (e *cacheEntry) loadOptions() LoadOptions {
 	}
 }
 
+// load reparses the cached raw spec. The raw bytes already include any
+// overlays, which are carried over so a later StoreSpecInCache keeps them.
+func (e *cacheEntry) load(loaders []Loader, opts LoadOptions) (*APISpec, error) {
+	spec, err := loadWithOptions(e.contentType(), e.raw(), loaders, opts)
+	if spec != nil {
+		spec.overlays = e.Overlays
+	}
+	return spec, err
+}
+
 // LoadFromCache reads the cached spec for apiName, re-parses it using loaders,
 // and returns the result. Returns nil, nil when the cache is empty or expired.
 func LoadFromCache(cacheDir, apiName, version string, specFiles []string, loaders []Loader) (*APISpec, error) {
@@ -208,7 +223,7 @@ func loadFromCache(cacheDir, apiName, version string, specFiles []string, loader
 	if specFilesChangedSince(specFiles, entry.FetchedAt) {
 		return nil, nil
 	}
-	return loadWithOptions(entry.contentType(), entry.raw(), loaders, entry.loadOptions())
+	return entry.load(loaders, entry.loadOptions())
 }
 
 // LoadOperationSetFromCache reads extracted operations and API metadata for a
@@ -262,10 +277,12 @@ func LoadOperationSetFromCacheStatus(cacheDir, apiName, version string, specFile
 				Info:           blob.Info,
 				Operations:     append([]Operation(nil), blob.Operations...),
 				XCLIExtensions: blob.XCLIExtensions,
+				RPC:            blob.RPC,
 			}
 			if blob.Operations == nil {
 				set.Operations = []Operation{}
 			}
+			set.linkRPC()
 			return set, status, true
 		}
 	}
@@ -335,6 +352,7 @@ func StoreSpecInCache(cacheDir, apiName, version string, apiSpec *APISpec, specF
 		},
 	}
 	entry.SpecFiles = cacheSpecFileMetadata(specFiles)
+	entry.Overlays = apiSpec.overlays
 	if set.Operations != nil {
 		entry.upsertOperationSet(cacheOperationOptions(opts), set)
 	}
@@ -393,6 +411,7 @@ func (e *cacheEntry) upsertOperationSet(opts OperationOptions, set OperationSet)
 		Info:               set.Info,
 		Operations:         append([]Operation(nil), set.Operations...),
 		XCLIExtensions:     set.XCLIExtensions,
+		RPC:                set.RPC,
 	}
 	for i := range e.Operations {
 		if e.Operations[i].BaseURL == opts.BaseURL &&
@@ -0,0 +1,948 @@
+package spec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// CollectionLoader loads API client collections by converting them to
+// OpenAPI 3.0: Postman v2.1 collections, Insomnia v4 exports, and Bruno
+// collection directories (bundled by readLocalFile). Folders become tags,
+// requests become operations, variables used in request URLs become server
+// variables or parameter examples, and collection auth becomes security
+// schemes plus x-cli-config. Raw holds the converted OpenAPI document, so
+// cached specs reload without the collection loader.
+type CollectionLoader struct{}
+
+func (CollectionLoader) Priority() int { return 20 }
+
+// Detect returns true for bodies with a collection format marker.
+func (CollectionLoader) Detect(contentType string, body []byte) bool {
+	return collectionFormat(body) != ""
+}
+
+// LoadWithOptions converts a collection to OpenAPI and loads the result.
+// Conversion warnings are reported with the operation warnings.
+func (CollectionLoader) LoadWithOptions(body []byte, opts LoadOptions) (*APISpec, error) {
+	format := collectionFormat(body)
+	var c *collection
+	var err error
+	switch format {
+	case "postman":
+		c, err = parsePostmanCollection(body)
+	case "insomnia":
+		c, err = parseInsomniaExport(body)
+	case "bruno":
+		c, err = parseBrunoBundle(body)
+	default:
+		return nil, &LoadError{Errors: []string{"unrecognized API client collection"}}
+	}
+	if err != nil {
+		return nil, &LoadError{Errors: []string{fmt.Sprintf("%s collection: %v", collectionFormatNames[format], err)}}
+	}
+	raw, warnings, err := c.openAPI()
+	if err != nil {
+		return nil, &LoadError{Errors: []string{fmt.Sprintf("%s collection: %v", collectionFormatNames[format], err)}}
+	}
+	opts.ContentType = "application/yaml"
+	loaded, err := OpenAPILoader{}.LoadWithOptions(raw, opts)
+	if err != nil {
+		return nil, err
+	}
+	loaded.ContentType = "application/yaml"
+	for _, warning := range warnings {
+		loaded.loadWarnings = append(loaded.loadWarnings, collectionFormatNames[format]+" conversion: "+warning)
+	}
+	return loaded, nil
+}
+
+var collectionFormatNames = map[string]string{
+	"postman":  "Postman",
+	"insomnia": "Insomnia",
+	"bruno":    "Bruno",
+}
+
+// collectionFormat sniffs the collection format of body, or returns "". A
+// cheap byte check guards the structural check so OpenAPI documents that
+// merely mention a collection format are not misdetected.
+func collectionFormat(body []byte) string {
+	switch {
+	case bytes.Contains(body, []byte("schema.getpostman.com/json/collection/v2")):
+		var doc struct {
+			Info struct {
+				Schema string `json:"schema"`
+			} `json:"info"`
+		}
+		if json.Unmarshal(body, &doc) == nil && strings.Contains(doc.Info.Schema, "schema.getpostman.com") {
+			return "postman"
+		}
+	case bytes.Contains(body, []byte("__export_format")):
+		var doc struct {
+			Type   string `yaml:"_type"`
+			Format int    `yaml:"__export_format"`
+		}
+		if yaml.Unmarshal(body, &doc) == nil && doc.Type == "export" && doc.Format >= 4 {
+			return "insomnia"
+		}
+	case bytes.HasPrefix(body, []byte(`{"`+brunoBundleKey+`":`)):
+		return "bruno"
+	}
+	return ""
+}
+
+// collection is the format-neutral model the collection parsers produce.
+type collection struct {
+	Title       string
+	Description string
+	Folders     map[string]string // folder path → description
+	Variables   map[string]collectionVariable
+	Auth        *collectionAuth
+	Requests    []collectionRequest
+
+	warnings   []string
+	schemeAuth []collectionSchemeAuth
+}
+
+// collectionSchemeAuth remembers which auth setting produced a security
+// scheme so x-cli-config can carry over its non-secret parameters.
+type collectionSchemeAuth struct {
+	id   string
+	auth *collectionAuth
+}
+
+type collectionVariable struct {
+	Value  string
+	Secret bool
+}
+
+type collectionRequest struct {
+	Name        string
+	Description string
+	Folder      string
+	Method      string
+	URL         string
+	Query       []collectionParam
+	PathParams  []collectionParam
+	Headers     []collectionParam
+	Body        *collectionBody
+	// Auth overrides the collection auth; nil inherits it.
+	Auth      *collectionAuth
+	Responses []collectionResponse
+}
+
+type collectionParam struct {
+	Name        string
+	Value       string
+	Description string
+	Disabled    bool
+	File        bool
+}
+
+type collectionBody struct {
+	MediaType string
+	Raw       string
+	Fields    []collectionParam
+}
+
+type collectionResponse struct {
+	Code      string
+	MediaType string
+	Body      string
+}
+
+// collectionAuth is a collection or request auth setting. Type is one of
+// none, bearer, basic, digest, apikey, or oauth2; Params uses restish auth
+// parameter names plus in/name for API keys and the OAuth endpoints.
+type collectionAuth struct {
+	Type   string
+	Params map[string]string
+}
+
+func (c *collection) warnf(format string, args ...any) {
+	warning := fmt.Sprintf(format, args...)
+	for _, existing := range c.warnings {
+		if existing == warning {
+			return
+		}
+	}
+	c.warnings = append(c.warnings, warning)
+}
+
+var collectionVarPattern = regexp.MustCompile(`\{\{\s*(?:_\.)?([A-Za-z0-9_.\-]+)\s*\}\}`)
+
+// collectionVarNames returns the variables referenced by s in order.
+func collectionVarNames(s string) []string {
+	var names []string
+	for _, m := range collectionVarPattern.FindAllStringSubmatch(s, -1) {
+		names = append(names, m[1])
+	}
+	return names
+}
+
+// onlyCollectionVar returns the variable name when s is exactly one variable
+// reference.
+func onlyCollectionVar(s string) (string, bool) {
+	m := collectionVarPattern.FindStringSubmatchIndex(strings.TrimSpace(s))
+	if m == nil || m[0] != 0 || m[1] != len(strings.TrimSpace(s)) {
+		return "", false
+	}
+	trimmed := strings.TrimSpace(s)
+	return trimmed[m[2]:m[3]], true
+}
+
+// value returns s with known non-secret variables substituted. Secret or
+// unknown variables leave ok false so callers can drop the value.
+func (c *collection) value(s string) (string, bool) {
+	ok := true
+	out := collectionVarPattern.ReplaceAllStringFunc(s, func(ref string) string {
+		name := collectionVarPattern.FindStringSubmatch(ref)[1]
+		v, known := c.Variables[name]
+		if !known || v.Secret {
+			ok = false
+			return ref
+		}
+		return v.Value
+	})
+	return out, ok
+}
+
+// splitCollectionURL splits a collection request URL into its server part,
+// path, and raw query string. The server keeps variable references intact.
+func splitCollectionURL(raw string) (server, path, query string) {
+	raw = strings.TrimSpace(raw)
+	if i := strings.IndexByte(raw, '#'); i >= 0 {
+		raw = raw[:i]
+	}
+	if i := strings.IndexByte(raw, '?'); i >= 0 {
+		raw, query = raw[:i], raw[i+1:]
+	}
+	prefix, rest := "", raw
+	if i := strings.Index(rest, "://"); i >= 0 {
+		prefix, rest = rest[:i+3], rest[i+3:]
+	}
+	if i := strings.IndexByte(rest, '/'); i >= 0 {
+		server, path = prefix+rest[:i], rest[i:]
+	} else {
+		server, path = prefix+rest, "/"
+	}
+	if prefix == "" && server != "" && !strings.HasPrefix(server, "{{") {
+		server = "https://" + server
+	}
+	return strings.TrimRight(server, "/"), path, query
+}
+
+// parseCollectionQuery parses a raw query string into parameters.
+func parseCollectionQuery(query string) []collectionParam {
+	var params []collectionParam
+	for _, pair := range strings.Split(query, "&") {
+		if pair == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(pair, "=")
+		params = append(params, collectionParam{Name: name, Value: value})
+	}
+	return params
+}
+
+// openAPI renders the collection as an OpenAPI 3.0 YAML document.
+func (c *collection) openAPI() ([]byte, []string, error) {
+	if len(c.Requests) == 0 {
+		c.warnf("collection has no requests")
+	}
+	doc := yamlMapping()
+	yamlSet(doc, "openapi", yamlScalar("3.0.3"))
+	info := yamlMapping()
+	title := c.Title
+	if title == "" {
+		title = "Collection"
+	}
+	yamlSet(info, "title", yamlScalar(title))
+	if c.Description != "" {
+		yamlSet(info, "description", yamlScalar(c.Description))
+	}
+	yamlSet(info, "version", yamlScalar("1.0.0"))
+	yamlSet(doc, "info", info)
+
+	servers := c.serverOrder()
+	if len(servers) > 0 {
+		yamlSet(doc, "servers", c.serverList(servers[:1]))
+	}
+
+	schemes := map[string]*yaml.Node{}
+	var schemeOrder []string
+	if id := c.securityScheme(c.Auth, schemes, &schemeOrder); id != "" {
+		yamlSet(doc, "security", collectionSecurity(id))
+	}
+
+	shared := c.sharedHeaders()
+	sharedNames := map[string]bool{}
+	for _, h := range shared {
+		sharedNames[strings.ToLower(h.Name)] = true
+	}
+
+	paths := yamlMapping()
+	operationIDs := map[string]int{}
+	var tags []string
+	seenTags := map[string]bool{}
+	for i := range c.Requests {
+		req := &c.Requests[i]
+		server, path, rawQuery := splitCollectionURL(req.URL)
+		path, pathParams := c.openAPIPath(path, req.PathParams)
+		method := strings.ToLower(req.Method)
+		if method == "" {
+			method = "get"
+		}
+		if !collectionMethods[method] {
+			c.warnf("request %q: unsupported method %q", req.Name, req.Method)
+			continue
+		}
+		item := yamlGet(paths, path)
+		if item == nil {
+			item = yamlMapping()
+			yamlSet(paths, path, item)
+		}
+		if yamlGet(item, method) != nil {
+			c.warnf("request %q: duplicate %s %s; keeping the first request", req.Name, strings.ToUpper(method), path)
+			continue
+		}
+		op := yamlMapping()
+		if req.Folder != "" {
+			tagList := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+			tagList.Content = append(tagList.Content, yamlScalar(req.Folder))
+			yamlSet(op, "tags", tagList)
+			if !seenTags[req.Folder] {
+				seenTags[req.Folder] = true
+				tags = append(tags, req.Folder)
+			}
+		}
+		if req.Name != "" {
+			yamlSet(op, "summary", yamlScalar(req.Name))
+		}
+		if req.Description != "" {
+			yamlSet(op, "description", yamlScalar(req.Description))
+		}
+		yamlSet(op, "operationId", yamlScalar(collectionOperationID(req, method, path, operationIDs)))
+		if len(servers) > 0 && server != "" && server != servers[0] {
+			yamlSet(op, "servers", c.serverList([]string{server}))
+		}
+		if params := c.parameters(req, pathParams, rawQuery, sharedNames); len(params.Content) > 0 {
+			yamlSet(op, "parameters", params)
+		}
+		if body := c.requestBody(req); body != nil {
+			yamlSet(op, "requestBody", body)
+		}
+		yamlSet(op, "responses", c.responses(req))
+		if req.Auth != nil && req.Auth != c.Auth {
+			if req.Auth.Type == "none" {
+				yamlSet(op, "security", &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Style: yaml.FlowStyle})
+			} else if id := c.securityScheme(req.Auth, schemes, &schemeOrder); id != "" {
+				yamlSet(op, "security", collectionSecurity(id))
+			}
+		}
+		yamlSet(item, method, op)
+	}
+	if len(tags) > 0 {
+		tagNodes := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, tag := range tags {
+			node := yamlMapping()
+			yamlSet(node, "name", yamlScalar(tag))
+			if desc := c.Folders[tag]; desc != "" {
+				yamlSet(node, "description", yamlScalar(desc))
+			}
+			tagNodes.Content = append(tagNodes.Content, node)
+		}
+		yamlSet(doc, "tags", tagNodes)
+	}
+	yamlSet(doc, "paths", paths)
+	if len(schemeOrder) > 0 {
+		securitySchemes := yamlMapping()
+		for _, id := range schemeOrder {
+			yamlSet(securitySchemes, id, schemes[id])
+		}
+		components := yamlMapping()
+		yamlSet(components, "securitySchemes", securitySchemes)
+		yamlSet(doc, "components", components)
+	}
+	if xcli := c.xcliConfig(shared); xcli != nil {
+		yamlSet(doc, "x-cli-config", xcli)
+	}
+
+	out, err := yaml.Marshal(doc)
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, c.warnings, nil
+}
+
+var collectionMethods = map[string]bool{
+	"get": true, "put": true, "post": true, "delete": true,
+	"options": true, "head": true, "patch": true, "trace": true,
+}
+
+// serverOrder returns the distinct request servers, most used first.
+func (c *collection) serverOrder() []string {
+	counts := map[string]int{}
+	var order []string
+	for _, req := range c.Requests {
+		server, _, _ := splitCollectionURL(req.URL)
+		if server == "" {
+			continue
+		}
+		if counts[server] == 0 {
+			order = append(order, server)
+		}
+		counts[server]++
+	}
+	sort.SliceStable(order, func(i, j int) bool { return counts[order[i]] > counts[order[j]] })
+	return order
+}
+
+// serverList renders servers, turning variable references into server
+// variables whose defaults come from the collection. Secret values are never
+// copied into the document.
+func (c *collection) serverList(servers []string) *yaml.Node {
+	list := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	for _, server := range servers {
+		node := yamlMapping()
+		yamlSet(node, "url", yamlScalar(collectionVarPattern.ReplaceAllString(server, "{$1}")))
+		if names := collectionVarNames(server); len(names) > 0 {
+			vars := yamlMapping()
+			for _, name := range names {
+				v := yamlMapping()
+				value := c.Variables[name]
+				if value.Secret {
+					value.Value = ""
+				}
+				yamlSet(v, "default", yamlScalar(value.Value))
+				yamlSet(vars, name, v)
+			}
+			yamlSet(node, "variables", vars)
+		}
+		list.Content = append(list.Content, node)
+	}
+	return list
+}
+
+// openAPIPath converts ":name" segments and variable references to path
+// templates and returns the path parameters in order.
+func (c *collection) openAPIPath(path string, known []collectionParam) (string, []collectionParam) {
+	byName := map[string]collectionParam{}
+	for _, p := range known {
+		byName[p.Name] = p
+	}
+	var params []collectionParam
+	seen := map[string]bool{}
+	add := func(name string, p collectionParam) {
+		if !seen[name] {
+			seen[name] = true
+			p.Name = name
+			params = append(params, p)
+		}
+	}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") && len(segment) > 1 {
+			name := segment[1:]
+			segments[i] = "{" + name + "}"
+			add(name, byName[name])
+			continue
+		}
+		for _, name := range collectionVarNames(segment) {
+			p := byName[name]
+			if p.Value == "" {
+				if v, ok := c.Variables[name]; ok && !v.Secret {
+					p.Value = v.Value
+				}
+			}
+			add(name, p)
+		}
+		segments[i] = collectionVarPattern.ReplaceAllString(segment, "{$1}")
+	}
+	path = strings.Join(segments, "/")
+	if path == "" {
+		path = "/"
+	}
+	return path, params
+}
+
+// skipCollectionHeader reports whether a request header is managed by
+// restish itself (content negotiation, auth, transport) rather than being an
+// API parameter.
+func skipCollectionHeader(name string) bool {
+	switch strings.ToLower(name) {
+	case "", "content-type", "accept", "authorization", "content-length", "host", "user-agent", "cookie":
+		return true
+	}
+	return false
+}
+
+func (c *collection) parameters(req *collectionRequest, pathParams []collectionParam, rawQuery string, shared map[string]bool) *yaml.Node {
+	list := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	for _, p := range pathParams {
+		list.Content = append(list.Content, c.parameter(p, "path"))
+	}
+	query := req.Query
+	if len(query) == 0 {
+		query = parseCollectionQuery(rawQuery)
+	}
+	seen := map[string]bool{}
+	for _, p := range query {
+		if p.Name == "" || seen[p.Name] {
+			continue
+		}
+		seen[p.Name] = true
+		list.Content = append(list.Content, c.parameter(p, "query"))
+	}
+	for _, p := range req.Headers {
+		if skipCollectionHeader(p.Name) || p.Disabled || shared[strings.ToLower(p.Name)] {
+			continue
+		}
+		if seen["header:"+strings.ToLower(p.Name)] {
+			continue
+		}
+		seen["header:"+strings.ToLower(p.Name)] = true
+		list.Content = append(list.Content, c.parameter(p, "header"))
+	}
+	return list
+}
+
+func (c *collection) parameter(p collectionParam, in string) *yaml.Node {
+	node := yamlMapping()
+	yamlSet(node, "name", yamlScalar(p.Name))
+	yamlSet(node, "in", yamlScalar(in))
+	if p.Description != "" {
+		yamlSet(node, "description", yamlScalar(p.Description))
+	}
+	if in == "path" {
+		yamlSet(node, "required", yamlBool(true))
+	}
+	schema := yamlMapping()
+	yamlSet(schema, "type", yamlScalar("string"))
+	yamlSet(node, "schema", schema)
+	if value, ok := c.value(p.Value); ok && value != "" && !p.Disabled {
+		yamlSet(node, "example", yamlScalar(value))
+	}
+	return node
+}
+
+func (c *collection) requestBody(req *collectionRequest) *yaml.Node {
+	body := req.Body
+	if body == nil || (body.Raw == "" && len(body.Fields) == 0) {
+		return nil
+	}
+	mediaType := body.MediaType
+	for _, h := range req.Headers {
+		if strings.EqualFold(h.Name, "Content-Type") && !h.Disabled && h.Value != "" && body.Raw != "" {
+			mediaType = h.Value
+		}
+	}
+	if mediaType == "" {
+		mediaType = "application/json"
+	}
+	media := yamlMapping()
+	if len(body.Fields) > 0 {
+		schema := yamlMapping()
+		yamlSet(schema, "type", yamlScalar("object"))
+		props := yamlMapping()
+		example := yamlMapping()
+		for _, f := range body.Fields {
+			if f.Name == "" {
+				continue
+			}
+			prop := yamlMapping()
+			yamlSet(prop, "type", yamlScalar("string"))
+			if f.File {
+				yamlSet(prop, "format", yamlScalar("binary"))
+			}
+			if f.Description != "" {
+				yamlSet(prop, "description", yamlScalar(f.Description))
+			}
+			yamlSet(props, f.Name, prop)
+			if value, ok := c.value(f.Value); ok && !f.File && !f.Disabled && value != "" {
+				yamlSet(example, f.Name, yamlScalar(value))
+			}
+		}
+		yamlSet(schema, "properties", props)
+		yamlSet(media, "schema", schema)
+		if len(example.Content) > 0 {
+			yamlSet(media, "example", example)
+		}
+	} else if example, schema := c.bodyExample(body.Raw, mediaType); example != nil {
+		if schema != nil {
+			yamlSet(media, "schema", schema)
+		}
+		yamlSet(media, "example", example)
+	}
+	content := yamlMapping()
+	yamlSet(content, mediaType, media)
+	node := yamlMapping()
+	yamlSet(node, "content", content)
+	return node
+}
+
+// bodyExample returns a body example and, for JSON, a schema inferred from it.
+// Variable references in JSON bodies are kept as strings.
+func (c *collection) bodyExample(raw, mediaType string) (*yaml.Node, *yaml.Node) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+	if strings.Contains(mediaType, "json") {
+		var value any
+		if err := json.Unmarshal([]byte(collectionJSONVars(raw)), &value); err == nil {
+			example := &yaml.Node{}
+			if err := example.Encode(value); err == nil {
+				return example, inferCollectionSchema(value)
+			}
+		}
+	}
+	return yamlScalar(raw), nil
+}
+
+// collectionJSONVars quotes bare variable references, such as {"id": {{id}}},
+// so the body still parses as JSON.
+func collectionJSONVars(raw string) string {
+	var out strings.Builder
+	inString := false
+	for i := 0; i < len(raw); i++ {
+		ch := raw[i]
+		if ch == '"' && (i == 0 || raw[i-1] != '\\') {
+			inString = !inString
+		}
+		if !inString && strings.HasPrefix(raw[i:], "{{") {
+			if end := strings.Index(raw[i:], "}}"); end > 0 {
+				out.WriteString(strconv.Quote(raw[i : i+end+2]))
+				i += end + 1
+				continue
+			}
+		}
+		out.WriteByte(ch)
+	}
+	return out.String()
+}
+
+func inferCollectionSchema(value any) *yaml.Node {
+	schema := yamlMapping()
+	switch v := value.(type) {
+	case map[string]any:
+		yamlSet(schema, "type", yamlScalar("object"))
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		props := yamlMapping()
+		for _, k := range keys {
+			yamlSet(props, k, inferCollectionSchema(v[k]))
+		}
+		if len(keys) > 0 {
+			yamlSet(schema, "properties", props)
+		}
+	case []any:
+		yamlSet(schema, "type", yamlScalar("array"))
+		items := yamlMapping()
+		if len(v) > 0 {
+			items = inferCollectionSchema(v[0])
+		}
+		yamlSet(schema, "items", items)
+	case string:
+		yamlSet(schema, "type", yamlScalar("string"))
+	case bool:
+		yamlSet(schema, "type", yamlScalar("boolean"))
+	case float64:
+		if v == float64(int64(v)) {
+			yamlSet(schema, "type", yamlScalar("integer"))
+		} else {
+			yamlSet(schema, "type", yamlScalar("number"))
+		}
+	}
+	return schema
+}
+
+func (c *collection) responses(req *collectionRequest) *yaml.Node {
+	responses := yamlMapping()
+	for _, r := range req.Responses {
+		code := r.Code
+		if code == "" {
+			code = "default"
+		}
+		if yamlGet(responses, code) != nil {
+			continue
+		}
+		node := yamlMapping()
+		desc := http.StatusText(atoiOrZero(code))
+		if desc == "" {
+			desc = "Response"
+		}
+		yamlSet(node, "description", yamlScalar(desc))
+		if r.Body != "" {
+			mediaType := r.MediaType
+			if mediaType == "" {
+				mediaType = "application/json"
+			}
+			media := yamlMapping()
+			if example, schema := c.bodyExample(r.Body, mediaType); example != nil {
+				if schema != nil {
+					yamlSet(media, "schema", schema)
+				}
+				yamlSet(media, "example", example)
+			}
+			content := yamlMapping()
+			yamlSet(content, mediaType, media)
+			yamlSet(node, "content", content)
+		}
+		yamlSet(responses, code, node)
+	}
+	if len(responses.Content) == 0 {
+		node := yamlMapping()
+		yamlSet(node, "description", yamlScalar("OK"))
+		yamlSet(responses, "200", node)
+	}
+	return responses
+}
+
+func atoiOrZero(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// securityScheme registers the OpenAPI security scheme for auth and returns
+// its ID, or "" when auth has no OpenAPI equivalent.
+func (c *collection) securityScheme(auth *collectionAuth, schemes map[string]*yaml.Node, order *[]string) string {
+	if auth == nil || auth.Type == "" || auth.Type == "none" {
+		return ""
+	}
+	scheme := yamlMapping()
+	var id string
+	switch auth.Type {
+	case "bearer":
+		id = "bearerAuth"
+		yamlSet(scheme, "type", yamlScalar("http"))
+		yamlSet(scheme, "scheme", yamlScalar("bearer"))
+	case "basic", "digest":
+		id = auth.Type + "Auth"
+		yamlSet(scheme, "type", yamlScalar("http"))
+		yamlSet(scheme, "scheme", yamlScalar(auth.Type))
+	case "apikey":
+		name, _ := c.value(auth.Params["name"])
+		if name == "" {
+			c.warnf("API key auth without a key name was dropped")
+			return ""
+		}
+		in := auth.Params["in"]
+		if in != "query" {
+			in = "header"
+		}
+		id = "apiKeyAuth"
+		yamlSet(scheme, "type", yamlScalar("apiKey"))
+		yamlSet(scheme, "in", yamlScalar(in))
+		yamlSet(scheme, "name", yamlScalar(name))
+	case "oauth2":
+		flow, ok := c.oauthFlow(auth)
+		if !ok {
+			return ""
+		}
+		id = "oauth2"
+		yamlSet(scheme, "type", yamlScalar("oauth2"))
+		yamlSet(scheme, "flows", flow)
+	default:
+		c.warnf("unsupported %s auth was dropped", auth.Type)
+		return ""
+	}
+	base := id
+	for n := 2; ; n++ {
+		existing, ok := schemes[id]
+		if !ok {
+			break
+		}
+		if schemeKey(existing) == schemeKey(scheme) {
+			return id
+		}
+		id = fmt.Sprintf("%s%d", base, n)
+	}
+	schemes[id] = scheme
+	*order = append(*order, id)
+	c.schemeAuth = append(c.schemeAuth, collectionSchemeAuth{id: id, auth: auth})
+	return id
+}
+
+func schemeKey(n *yaml.Node) string {
+	out, _ := yaml.Marshal(n)
+	return string(out)
+}
+
+func (c *collection) oauthFlow(auth *collectionAuth) (*yaml.Node, bool) {
+	flows := yamlMapping()
+	flow := yamlMapping()
+	tokenURL, _ := c.value(auth.Params["token_url"])
+	authorizeURL, _ := c.value(auth.Params["authorize_url"])
+	scopes := yamlMapping()
+	for _, scope := range strings.Fields(auth.Params["scopes"]) {
+		yamlSet(scopes, scope, yamlScalar(""))
+	}
+	switch auth.Params["grant_type"] {
+	case "authorization_code", "authorization_code_with_pkce", "":
+		if authorizeURL == "" || tokenURL == "" {
+			c.warnf("OAuth 2.0 authorization code auth without URLs was dropped")
+			return nil, false
+		}
+		yamlSet(flow, "authorizationUrl", yamlScalar(authorizeURL))
+		yamlSet(flow, "tokenUrl", yamlScalar(tokenURL))
+		yamlSet(flow, "scopes", scopes)
+		yamlSet(flows, "authorizationCode", flow)
+	case "client_credentials":
+		if tokenURL == "" {
+			c.warnf("OAuth 2.0 client credentials auth without a token URL was dropped")
+			return nil, false
+		}
+		yamlSet(flow, "tokenUrl", yamlScalar(tokenURL))
+		yamlSet(flow, "scopes", scopes)
+		yamlSet(flows, "clientCredentials", flow)
+	default:
+		c.warnf("unsupported OAuth 2.0 grant %q was dropped", auth.Params["grant_type"])
+		return nil, false
+	}
+	return flows, true
+}
+
+func collectionSecurity(id string) *yaml.Node {
+	requirement := yamlMapping()
+	yamlSet(requirement, id, &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Style: yaml.FlowStyle})
+	list := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	list.Content = append(list.Content, requirement)
+	return list
+}
+
+// collectionProfileParams are the auth parameters copied into x-cli-config.
+// Tokens, passwords, API key values, and client secrets are never copied; they
+// stay empty placeholders for the operator to fill.
+var collectionProfileParams = []string{"username", "client_id", "scopes"}
+
+// sharedHeaders returns headers sent with the same templated value by every
+// request, such as a tenant or API version header. They are promoted to
+// x-cli-config profile headers instead of per-operation parameters.
+func (c *collection) sharedHeaders() []collectionParam {
+	if len(c.Requests) == 0 {
+		return nil
+	}
+	var shared []collectionParam
+	for _, h := range c.Requests[0].Headers {
+		if h.Disabled || len(collectionVarNames(h.Value)) == 0 || skipCollectionHeader(h.Name) {
+			continue
+		}
+		everywhere := true
+		for _, req := range c.Requests[1:] {
+			found := false
+			for _, other := range req.Headers {
+				if strings.EqualFold(other.Name, h.Name) && other.Value == h.Value && !other.Disabled {
+					found = true
+					break
+				}
+			}
+			if !found {
+				everywhere = false
+				break
+			}
+		}
+		if everywhere {
+			shared = append(shared, h)
+		}
+	}
+	return shared
+}
+
+// xcliConfig builds an x-cli-config default profile from the collection auth
+// and shared headers. Non-secret settings such as usernames and client IDs
+// are carried over when they resolve to literal values, and header variables
+// become profile params.
+func (c *collection) xcliConfig(shared []collectionParam) *yaml.Node {
+	id := ""
+	var auth *collectionAuth
+	for _, sa := range c.schemeAuth {
+		if sa.auth == c.Auth {
+			id, auth = sa.id, sa.auth
+		}
+	}
+	if auth == nil && len(c.schemeAuth) > 0 {
+		id, auth = c.schemeAuth[0].id, c.schemeAuth[0].auth
+	}
+	if auth == nil && len(shared) == 0 {
+		return nil
+	}
+	profile := yamlMapping()
+	params := yamlMapping()
+	if len(shared) > 0 {
+		headers := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		seen := map[string]bool{}
+		for _, h := range shared {
+			headers.Content = append(headers.Content, yamlScalar(h.Name+": "+collectionVarPattern.ReplaceAllString(h.Value, "{$1}")))
+			for _, name := range collectionVarNames(h.Value) {
+				if seen[name] {
+					continue
+				}
+				seen[name] = true
+				v := c.Variables[name]
+				if v.Secret {
+					v.Value = ""
+				}
+				yamlSet(params, name, yamlScalar(v.Value))
+			}
+		}
+		yamlSet(profile, "headers", headers)
+	}
+	if auth != nil {
+		yamlSet(profile, "security", yamlScalar(id))
+		for _, k := range collectionProfileParams {
+			if value, ok := c.value(auth.Params[k]); ok && value != "" {
+				yamlSet(params, k, yamlScalar(value))
+			}
+		}
+	}
+	if len(params.Content) > 0 {
+		yamlSet(profile, "params", params)
+	}
+	profiles := yamlMapping()
+	yamlSet(profiles, "default", profile)
+	xcli := yamlMapping()
+	yamlSet(xcli, "profiles", profiles)
+	return xcli
+}
+
+// collectionOperationID derives a unique operation ID from the request name.
+func collectionOperationID(req *collectionRequest, method, path string, seen map[string]int) string {
+	base := collectionSlug(req.Name)
+	if base == "" {
+		base = collectionSlug(method + " " + path)
+	}
+	seen[base]++
+	if seen[base] == 1 {
+		return base
+	}
+	return fmt.Sprintf("%s-%d", base, seen[base])
+}
+
+func collectionSlug(s string) string {
+	var out strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && out.Len() > 0 {
+				out.WriteByte('-')
+			}
+			out.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+	return out.String()
+}
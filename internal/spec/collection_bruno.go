@@ -0,0 +1,376 @@
+package spec
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// brunoBundleKey marks the JSON bundle readLocalFile builds from a Bruno
+// collection directory: {"brunoCollection": {"<relative path>": "<content>"}}.
+// Bundling keeps the loader interface byte-oriented and lets the bundle flow
+// through the usual spec-file path.
+const brunoBundleKey = "brunoCollection"
+
+// brunoBundle reads the collection files of a Bruno directory.
+func brunoBundle(dir string) ([]byte, error) {
+	files := map[string]string{}
+	err := walkBrunoDir(dir, func(rel string, _ fs.FileInfo) error {
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(rel)))
+		if err != nil {
+			return err
+		}
+		files[rel] = string(data)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := files["bruno.json"]; !ok {
+		return nil, fmt.Errorf("%s is a directory without bruno.json", dir)
+	}
+	return json.Marshal(map[string]map[string]string{brunoBundleKey: files})
+}
+
+// walkBrunoDir calls fn for bruno.json and every .bru file in a collection,
+// skipping hidden and node_modules directories.
+func walkBrunoDir(dir string, fn func(rel string, info fs.FileInfo) error) error {
+	return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if p != dir && (strings.HasPrefix(name, ".") || name == "node_modules") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel != "bruno.json" && !strings.HasSuffix(name, ".bru") {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return fn(rel, info)
+	})
+}
+
+// localSpecStat returns the modification time and size used to invalidate
+// cached local specs. Bruno collection directories report their newest file
+// and total size so editing any request invalidates the cache.
+func localSpecStat(p string) (time.Time, int64, error) {
+	info, err := os.Stat(p)
+	if err != nil || !info.IsDir() {
+		if err != nil {
+			return time.Time{}, 0, err
+		}
+		return info.ModTime(), info.Size(), nil
+	}
+	modTime, size := info.ModTime(), int64(0)
+	err = walkBrunoDir(p, func(_ string, info fs.FileInfo) error {
+		if info.ModTime().After(modTime) {
+			modTime = info.ModTime()
+		}
+		size += info.Size()
+		return nil
+	})
+	return modTime, size, err
+}
+
+// bruFile is a parsed .bru file: named blocks of key/value pairs, text, or
+// list items.
+type bruFile struct {
+	Dicts map[string][]collectionParam
+	Texts map[string]string
+	Lists map[string][]string
+}
+
+func (f bruFile) value(block, key string) string {
+	for _, p := range f.Dicts[block] {
+		if p.Name == key && !p.Disabled {
+			return p.Value
+		}
+	}
+	return ""
+}
+
+// parseBru parses the Bru markup language. Dictionary blocks hold one
+// "key: value" pair per line ("~" disables a pair), text blocks such as
+// body:json and docs hold indented text, and list blocks use brackets.
+func parseBru(src string) bruFile {
+	f := bruFile{Dicts: map[string][]collectionParam{}, Texts: map[string]string{}, Lists: map[string][]string{}}
+	lines := strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], " \t")
+		var name, closer string
+		switch {
+		case strings.HasSuffix(line, "{"):
+			name, closer = strings.TrimSpace(strings.TrimSuffix(line, "{")), "}"
+		case strings.HasSuffix(line, "["):
+			name, closer = strings.TrimSpace(strings.TrimSuffix(line, "[")), "]"
+		default:
+			continue
+		}
+		if name == "" || strings.HasPrefix(lines[i], " ") {
+			continue
+		}
+		var body []string
+		for i++; i < len(lines) && strings.TrimRight(lines[i], " \t") != closer; i++ {
+			body = append(body, strings.TrimPrefix(lines[i], "  "))
+		}
+		switch {
+		case closer == "]":
+			for _, item := range body {
+				if item = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(item), ",")); item != "" {
+					f.Lists[name] = append(f.Lists[name], item)
+				}
+			}
+		case bruTextBlock(name):
+			f.Texts[name] = strings.Join(body, "\n")
+		default:
+			for _, item := range body {
+				item = strings.TrimSpace(item)
+				key, value, ok := strings.Cut(item, ":")
+				if !ok || key == "" {
+					continue
+				}
+				p := collectionParam{Name: strings.TrimSpace(key), Value: strings.TrimSpace(value)}
+				if strings.HasPrefix(p.Name, "~") {
+					p.Name, p.Disabled = strings.TrimPrefix(p.Name, "~"), true
+				}
+				if strings.HasPrefix(p.Value, "@file(") {
+					p.File = true
+				}
+				f.Dicts[name] = append(f.Dicts[name], p)
+			}
+		}
+	}
+	return f
+}
+
+func bruTextBlock(name string) bool {
+	switch name {
+	case "docs", "script:pre-request", "script:post-response", "tests",
+		"body", "body:json", "body:text", "body:xml", "body:sparql", "body:graphql", "body:graphql:vars":
+		return true
+	}
+	return false
+}
+
+// parseBrunoBundle converts a bundled Bruno collection. Variables come from
+// collection-level vars plus the first environment in name order; variables
+// listed under vars:secret are treated as secrets.
+func parseBrunoBundle(body []byte) (*collection, error) {
+	var bundle map[string]map[string]string
+	if err := json.Unmarshal(body, &bundle); err != nil {
+		return nil, err
+	}
+	files := bundle[brunoBundleKey]
+	var meta struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal([]byte(files["bruno.json"]), &meta); err != nil {
+		return nil, fmt.Errorf("bruno.json: %w", err)
+	}
+	c := &collection{
+		Title:     meta.Name,
+		Folders:   map[string]string{},
+		Variables: map[string]collectionVariable{},
+	}
+	root := parseBru(files["collection.bru"])
+	c.Description = root.Texts["docs"]
+	for _, p := range root.Dicts["vars:pre-request"] {
+		if !p.Disabled {
+			c.Variables[p.Name] = collectionVariable{Value: p.Value}
+		}
+	}
+	var envs []string
+	for rel := range files {
+		if strings.HasPrefix(rel, "environments/") && strings.HasSuffix(rel, ".bru") {
+			envs = append(envs, rel)
+		}
+	}
+	sort.Strings(envs)
+	if len(envs) > 0 {
+		env := parseBru(files[envs[0]])
+		for _, p := range env.Dicts["vars"] {
+			if !p.Disabled {
+				c.Variables[p.Name] = collectionVariable{Value: p.Value}
+			}
+		}
+		for _, name := range env.Lists["vars:secret"] {
+			c.Variables[name] = collectionVariable{Secret: true}
+		}
+	}
+	c.Auth = c.bruAuth(root, "")
+
+	type bruRequest struct {
+		rel string
+		seq int
+		f   bruFile
+	}
+	var requests []bruRequest
+	folderAuth := map[string]*collectionAuth{}
+	folderNames := map[string]string{}
+	for rel, content := range files {
+		dir := path.Dir(rel)
+		switch {
+		case !strings.HasSuffix(rel, ".bru") || rel == "collection.bru" || strings.HasPrefix(rel, "environments/"):
+		case path.Base(rel) == "folder.bru":
+			f := parseBru(content)
+			folderNames[dir] = f.value("meta", "name")
+			c.Folders[dir] = f.Texts["docs"]
+			if auth := c.bruAuth(f, ""); auth != nil {
+				folderAuth[dir] = auth
+			}
+		default:
+			f := parseBru(content)
+			seq, _ := strconv.Atoi(f.value("meta", "seq"))
+			requests = append(requests, bruRequest{rel: rel, seq: seq, f: f})
+		}
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		di, dj := path.Dir(requests[i].rel), path.Dir(requests[j].rel)
+		if di != dj {
+			return di < dj
+		}
+		if requests[i].seq != requests[j].seq {
+			return requests[i].seq < requests[j].seq
+		}
+		return requests[i].rel < requests[j].rel
+	})
+
+	// Folder tags use folder.bru display names when present.
+	folderPath := func(dir string) string {
+		if dir == "." {
+			return ""
+		}
+		var parts []string
+		for d := dir; d != "."; d = path.Dir(d) {
+			name := folderNames[d]
+			if name == "" {
+				name = path.Base(d)
+			}
+			parts = append([]string{name}, parts...)
+		}
+		return strings.Join(parts, " / ")
+	}
+	folders := map[string]string{}
+	for dir, desc := range c.Folders {
+		folders[folderPath(dir)] = desc
+	}
+	c.Folders = folders
+
+	for _, r := range requests {
+		name := r.f.value("meta", "name")
+		if name == "" {
+			name = strings.TrimSuffix(path.Base(r.rel), ".bru")
+		}
+		if kind := r.f.value("meta", "type"); kind != "" && kind != "http" {
+			c.warnf("%s request %q was dropped", kind, name)
+			continue
+		}
+		method := ""
+		for _, m := range []string{"get", "post", "put", "patch", "delete", "options", "head"} {
+			if _, ok := r.f.Dicts[m]; ok {
+				method = m
+				break
+			}
+		}
+		if method == "" {
+			c.warnf("request %q has no HTTP method", name)
+			continue
+		}
+		dir := path.Dir(r.rel)
+		auth := c.Auth
+		for d := dir; d != "."; d = path.Dir(d) {
+			if a := folderAuth[d]; a != nil {
+				auth = a
+				break
+			}
+		}
+		if a := c.bruAuth(r.f, method); a != nil {
+			auth = a
+		}
+		req := collectionRequest{
+			Name:        name,
+			Description: r.f.Texts["docs"],
+			Folder:      folderPath(dir),
+			Method:      method,
+			URL:         r.f.value(method, "url"),
+			Query:       r.f.Dicts["params:query"],
+			PathParams:  r.f.Dicts["params:path"],
+			Headers:     r.f.Dicts["headers"],
+			Auth:        auth,
+		}
+		req.Body = bruBody(r.f, r.f.value(method, "body"))
+		c.Requests = append(c.Requests, req)
+	}
+	return c, nil
+}
+
+func bruBody(f bruFile, mode string) *collectionBody {
+	switch mode {
+	case "json", "text", "xml":
+		mediaType := map[string]string{"json": "application/json", "text": "text/plain", "xml": "application/xml"}[mode]
+		return &collectionBody{MediaType: mediaType, Raw: f.Texts["body:"+mode]}
+	case "form-urlencoded":
+		return &collectionBody{MediaType: "application/x-www-form-urlencoded", Fields: f.Dicts["body:form-urlencoded"]}
+	case "multipart-form":
+		return &collectionBody{MediaType: "multipart/form-data", Fields: f.Dicts["body:multipart-form"]}
+	case "graphql":
+		raw, _ := json.Marshal(map[string]string{"query": f.Texts["body:graphql"]})
+		return &collectionBody{MediaType: "application/json", Raw: string(raw)}
+	}
+	return nil
+}
+
+// bruAuth reads the auth mode of a request (from its method block) or of a
+// collection or folder (from its auth block). Inherit returns nil.
+func (c *collection) bruAuth(f bruFile, method string) *collectionAuth {
+	mode := f.value("auth", "mode")
+	if method != "" {
+		mode = f.value(method, "auth")
+	}
+	switch mode {
+	case "", "inherit":
+		return nil
+	case "none":
+		return &collectionAuth{Type: "none"}
+	case "bearer":
+		return &collectionAuth{Type: "bearer", Params: map[string]string{"token": f.value("auth:bearer", "token")}}
+	case "basic", "digest":
+		block := "auth:" + mode
+		return &collectionAuth{Type: mode, Params: map[string]string{"username": f.value(block, "username"), "password": f.value(block, "password")}}
+	case "apikey":
+		in := "header"
+		if f.value("auth:apikey", "placement") == "queryparams" {
+			in = "query"
+		}
+		return &collectionAuth{Type: "apikey", Params: map[string]string{"name": f.value("auth:apikey", "key"), "value": f.value("auth:apikey", "value"), "in": in}}
+	case "oauth2":
+		return &collectionAuth{Type: "oauth2", Params: map[string]string{
+			"grant_type":    f.value("auth:oauth2", "grant_type"),
+			"authorize_url": f.value("auth:oauth2", "authorization_url"),
+			"token_url":     f.value("auth:oauth2", "access_token_url"),
+			"client_id":     f.value("auth:oauth2", "client_id"),
+			"client_secret": f.value("auth:oauth2", "client_secret"),
+			"scopes":        f.value("auth:oauth2", "scope"),
+		}}
+	}
+	return &collectionAuth{Type: mode}
+}
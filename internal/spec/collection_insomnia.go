@@ -0,0 +1,227 @@
+package spec
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// insomniaExport is the Insomnia v4 export format: a flat resource list whose
+// parentId fields form the workspace → folder → request tree.
+type insomniaExport struct {
+	Resources []insomniaResource `json:"resources"`
+}
+
+type insomniaResource struct {
+	ID             string              `json:"_id"`
+	Type           string              `json:"_type"`
+	ParentID       string              `json:"parentId"`
+	Name           string              `json:"name"`
+	Description    string              `json:"description"`
+	MetaSortKey    float64             `json:"metaSortKey"`
+	Method         string              `json:"method"`
+	URL            string              `json:"url"`
+	Body           insomniaBody        `json:"body"`
+	Parameters     []insomniaParam     `json:"parameters"`
+	PathParameters []insomniaParam     `json:"pathParameters"`
+	Headers        []insomniaParam     `json:"headers"`
+	Authentication map[string]any      `json:"authentication"`
+	Data           map[string]any      `json:"data"`
+	Environment    map[string]any      `json:"environment"`
+	Children       []*insomniaResource `json:"-"`
+}
+
+type insomniaBody struct {
+	MimeType string          `json:"mimeType"`
+	Text     string          `json:"text"`
+	Params   []insomniaParam `json:"params"`
+}
+
+type insomniaParam struct {
+	Name        string `json:"name"`
+	Value       string `json:"value"`
+	Description string `json:"description"`
+	Disabled    bool   `json:"disabled"`
+	Type        string `json:"type"`
+}
+
+func (p insomniaParam) param() collectionParam {
+	return collectionParam{
+		Name:        p.Name,
+		Value:       p.Value,
+		Description: p.Description,
+		Disabled:    p.Disabled,
+		File:        p.Type == "file",
+	}
+}
+
+// parseInsomniaExport converts an Insomnia v4 export, in JSON or YAML.
+// Variables come from the base environment of the first workspace;
+// sub-environments are alternatives the operator picks between, so they are
+// not merged in.
+func parseInsomniaExport(body []byte) (*collection, error) {
+	if trimmed := strings.TrimSpace(string(body)); !strings.HasPrefix(trimmed, "{") {
+		var doc any
+		if err := yaml.Unmarshal(body, &doc); err != nil {
+			return nil, err
+		}
+		converted, err := json.Marshal(doc)
+		if err != nil {
+			return nil, err
+		}
+		body = converted
+	}
+	var export insomniaExport
+	if err := json.Unmarshal(body, &export); err != nil {
+		return nil, err
+	}
+	byID := map[string]*insomniaResource{}
+	var workspace *insomniaResource
+	for i := range export.Resources {
+		r := &export.Resources[i]
+		byID[r.ID] = r
+		if r.Type == "workspace" && workspace == nil {
+			workspace = r
+		}
+	}
+	if workspace == nil {
+		return nil, fmt.Errorf("export has no workspace")
+	}
+	for i := range export.Resources {
+		r := &export.Resources[i]
+		if parent := byID[r.ParentID]; parent != nil {
+			parent.Children = append(parent.Children, r)
+		}
+	}
+
+	c := &collection{
+		Title:       workspace.Name,
+		Description: workspace.Description,
+		Folders:     map[string]string{},
+		Variables:   map[string]collectionVariable{},
+	}
+	for _, child := range workspace.Children {
+		if child.Type == "environment" {
+			flattenInsomniaData("", child.Data, c.Variables)
+			break
+		}
+	}
+	c.Auth = c.insomniaAuth(workspace.Authentication)
+	c.addInsomniaResources(workspace.Children, nil, c.Auth)
+	return c, nil
+}
+
+// flattenInsomniaData flattens nested environment data into dotted variable
+// names, matching Insomnia's {{ _.a.b }} references.
+func flattenInsomniaData(prefix string, data map[string]any, out map[string]collectionVariable) {
+	for k, v := range data {
+		name := k
+		if prefix != "" {
+			name = prefix + "." + k
+		}
+		if nested, ok := v.(map[string]any); ok {
+			flattenInsomniaData(name, nested, out)
+			continue
+		}
+		out[name] = collectionVariable{Value: postmanString(v)}
+	}
+}
+
+func (c *collection) addInsomniaResources(resources []*insomniaResource, folders []string, inherited *collectionAuth) {
+	sorted := append([]*insomniaResource(nil), resources...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].MetaSortKey < sorted[j].MetaSortKey })
+	for _, r := range sorted {
+		auth := inherited
+		if a := c.insomniaAuth(r.Authentication); a != nil {
+			auth = a
+		}
+		switch r.Type {
+		case "request_group":
+			path := append(append([]string(nil), folders...), r.Name)
+			c.Folders[strings.Join(path, " / ")] = r.Description
+			for k, v := range r.Environment {
+				if _, ok := c.Variables[k]; !ok {
+					c.Variables[k] = collectionVariable{Value: postmanString(v)}
+				}
+			}
+			c.addInsomniaResources(r.Children, path, auth)
+		case "request":
+			req := collectionRequest{
+				Name:        r.Name,
+				Description: r.Description,
+				Folder:      strings.Join(folders, " / "),
+				Method:      r.Method,
+				URL:         r.URL,
+				Auth:        auth,
+			}
+			for _, p := range r.Parameters {
+				req.Query = append(req.Query, p.param())
+			}
+			for _, p := range r.PathParameters {
+				req.PathParams = append(req.PathParams, p.param())
+			}
+			for _, h := range r.Headers {
+				req.Headers = append(req.Headers, h.param())
+			}
+			if r.Body.Text != "" || len(r.Body.Params) > 0 {
+				mediaType := r.Body.MimeType
+				if mediaType == "application/graphql" {
+					raw, _ := json.Marshal(map[string]string{"query": r.Body.Text})
+					req.Body = &collectionBody{MediaType: "application/json", Raw: string(raw)}
+				} else {
+					req.Body = &collectionBody{MediaType: mediaType, Raw: r.Body.Text}
+					for _, p := range r.Body.Params {
+						req.Body.Fields = append(req.Body.Fields, p.param())
+					}
+				}
+			}
+			c.Requests = append(c.Requests, req)
+		case "grpc_request", "websocket_request":
+			c.warnf("%s %q was dropped", strings.ReplaceAll(r.Type, "_", " "), r.Name)
+		}
+	}
+}
+
+// insomniaAuth maps Insomnia authentication settings to the collection auth
+// model. An empty object means the resource inherits its parent's auth.
+func (c *collection) insomniaAuth(auth map[string]any) *collectionAuth {
+	if len(auth) == 0 {
+		return nil
+	}
+	p := map[string]string{}
+	for k, v := range auth {
+		p[k] = postmanString(v)
+	}
+	if p["disabled"] == "true" {
+		return &collectionAuth{Type: "none"}
+	}
+	switch p["type"] {
+	case "", "inherit":
+		return nil
+	case "none":
+		return &collectionAuth{Type: "none"}
+	case "bearer":
+		return &collectionAuth{Type: "bearer", Params: map[string]string{"token": p["token"]}}
+	case "basic", "digest":
+		return &collectionAuth{Type: p["type"], Params: map[string]string{"username": p["username"], "password": p["password"]}}
+	case "apikey":
+		in := "header"
+		if p["addTo"] == "queryParams" {
+			in = "query"
+		}
+		return &collectionAuth{Type: "apikey", Params: map[string]string{"name": p["key"], "value": p["value"], "in": in}}
+	case "oauth2":
+		return &collectionAuth{Type: "oauth2", Params: map[string]string{
+			"grant_type":    p["grantType"],
+			"authorize_url": p["authorizationUrl"],
+			"token_url":     p["accessTokenUrl"],
+			"client_id":     p["clientId"],
+			"client_secret": p["clientSecret"],
+			"scopes":        p["scope"],
+		}}
+	}
+	return &collectionAuth{Type: p["type"]}
+}
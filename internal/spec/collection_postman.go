@@ -0,0 +1,356 @@
+package spec
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// postmanCollection is the subset of the Postman Collection v2.1 format used
+// for conversion. Collection v2.0 files also decode, except that their auth
+// parameters are objects instead of key/value lists.
+type postmanCollection struct {
+	Info struct {
+		Name        string             `json:"name"`
+		Description postmanDescription `json:"description"`
+	} `json:"info"`
+	Item     []postmanItem     `json:"item"`
+	Variable []postmanKeyValue `json:"variable"`
+	Auth     *postmanAuth      `json:"auth"`
+}
+
+type postmanItem struct {
+	Name        string             `json:"name"`
+	Description postmanDescription `json:"description"`
+	Item        []postmanItem      `json:"item"`
+	Auth        *postmanAuth       `json:"auth"`
+	Request     *postmanRequest    `json:"request"`
+	Response    []postmanResponse  `json:"response"`
+}
+
+type postmanRequest struct {
+	Method      string             `json:"method"`
+	Description postmanDescription `json:"description"`
+	URL         postmanURL         `json:"url"`
+	Header      []postmanKeyValue  `json:"header"`
+	Body        *postmanBody       `json:"body"`
+	Auth        *postmanAuth       `json:"auth"`
+}
+
+type postmanResponse struct {
+	Code   int               `json:"code"`
+	Header []postmanKeyValue `json:"header"`
+	Body   string            `json:"body"`
+}
+
+type postmanKeyValue struct {
+	Key         string             `json:"key"`
+	Value       any                `json:"value"`
+	Type        string             `json:"type"`
+	Disabled    bool               `json:"disabled"`
+	Description postmanDescription `json:"description"`
+}
+
+func (kv postmanKeyValue) param() collectionParam {
+	return collectionParam{
+		Name:        kv.Key,
+		Value:       postmanString(kv.Value),
+		Description: string(kv.Description),
+		Disabled:    kv.Disabled,
+		File:        kv.Type == "file",
+	}
+}
+
+type postmanBody struct {
+	Mode       string            `json:"mode"`
+	Raw        string            `json:"raw"`
+	URLEncoded []postmanKeyValue `json:"urlencoded"`
+	FormData   []postmanKeyValue `json:"formdata"`
+	GraphQL    *struct {
+		Query     string `json:"query"`
+		Variables string `json:"variables"`
+	} `json:"graphql"`
+	Options struct {
+		Raw struct {
+			Language string `json:"language"`
+		} `json:"raw"`
+	} `json:"options"`
+}
+
+// postmanAuth holds auth settings keyed by type, e.g. {"type": "bearer",
+// "bearer": [{"key": "token", "value": "{{token}}"}]}.
+type postmanAuth struct {
+	Type   string
+	Params map[string]string
+}
+
+func (a *postmanAuth) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(raw["type"], &a.Type); err != nil {
+		return fmt.Errorf("auth type: %w", err)
+	}
+	a.Params = map[string]string{}
+	settings, ok := raw[a.Type]
+	if !ok {
+		return nil
+	}
+	var list []postmanKeyValue
+	if err := json.Unmarshal(settings, &list); err == nil {
+		for _, kv := range list {
+			a.Params[kv.Key] = postmanString(kv.Value)
+		}
+		return nil
+	}
+	var object map[string]any
+	if err := json.Unmarshal(settings, &object); err != nil {
+		return fmt.Errorf("%s auth: %w", a.Type, err)
+	}
+	for k, v := range object {
+		a.Params[k] = postmanString(v)
+	}
+	return nil
+}
+
+// postmanDescription is a description string or {"content": "..."} object.
+type postmanDescription string
+
+func (d *postmanDescription) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*d = postmanDescription(s)
+		return nil
+	}
+	var object struct {
+		Content string `json:"content"`
+	}
+	if err := json.Unmarshal(data, &object); err != nil {
+		return err
+	}
+	*d = postmanDescription(object.Content)
+	return nil
+}
+
+// postmanURL is a raw URL string or a structured URL object.
+type postmanURL struct {
+	Raw      string
+	Query    []postmanKeyValue
+	Variable []postmanKeyValue
+}
+
+func (u *postmanURL) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &u.Raw); err == nil {
+		return nil
+	}
+	var object struct {
+		Raw      string            `json:"raw"`
+		Protocol string            `json:"protocol"`
+		Host     any               `json:"host"`
+		Port     string            `json:"port"`
+		Path     any               `json:"path"`
+		Query    []postmanKeyValue `json:"query"`
+		Variable []postmanKeyValue `json:"variable"`
+	}
+	if err := json.Unmarshal(data, &object); err != nil {
+		return err
+	}
+	u.Raw = object.Raw
+	if u.Raw == "" {
+		host := postmanJoin(object.Host, ".")
+		if object.Port != "" {
+			host += ":" + object.Port
+		}
+		if object.Protocol != "" {
+			host = object.Protocol + "://" + host
+		}
+		u.Raw = host + "/" + strings.TrimPrefix(postmanJoin(object.Path, "/"), "/")
+	}
+	u.Query = object.Query
+	u.Variable = object.Variable
+	return nil
+}
+
+// postmanJoin joins a host or path that is either a string or a list of
+// segments.
+func postmanJoin(v any, sep string) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case []any:
+		parts := make([]string, 0, len(v))
+		for _, part := range v {
+			if s, ok := part.(string); ok {
+				parts = append(parts, s)
+			} else if object, ok := part.(map[string]any); ok {
+				parts = append(parts, postmanString(object["value"]))
+			}
+		}
+		return strings.Join(parts, sep)
+	}
+	return ""
+}
+
+func postmanString(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		out, _ := json.Marshal(v)
+		return string(out)
+	}
+}
+
+// parsePostmanCollection converts a Postman Collection v2.x document.
+func parsePostmanCollection(body []byte) (*collection, error) {
+	var pc postmanCollection
+	if err := json.Unmarshal(body, &pc); err != nil {
+		return nil, err
+	}
+	c := &collection{
+		Title:       pc.Info.Name,
+		Description: string(pc.Info.Description),
+		Folders:     map[string]string{},
+		Variables:   map[string]collectionVariable{},
+	}
+	for _, v := range pc.Variable {
+		if v.Disabled {
+			continue
+		}
+		c.Variables[v.Key] = collectionVariable{Value: postmanString(v.Value), Secret: v.Type == "secret"}
+	}
+	c.Auth = c.postmanAuth(pc.Auth)
+	c.addPostmanItems(pc.Item, nil, c.Auth)
+	return c, nil
+}
+
+func (c *collection) addPostmanItems(items []postmanItem, folders []string, inherited *collectionAuth) {
+	for _, item := range items {
+		auth := inherited
+		if item.Auth != nil {
+			auth = c.postmanAuth(item.Auth)
+		}
+		if item.Request == nil {
+			path := append(append([]string(nil), folders...), item.Name)
+			c.Folders[strings.Join(path, " / ")] = string(item.Description)
+			c.addPostmanItems(item.Item, path, auth)
+			continue
+		}
+		req := item.Request
+		if req.Auth != nil {
+			auth = c.postmanAuth(req.Auth)
+		}
+		out := collectionRequest{
+			Name:        item.Name,
+			Description: string(req.Description),
+			Folder:      strings.Join(folders, " / "),
+			Method:      req.Method,
+			URL:         req.URL.Raw,
+			Auth:        auth,
+		}
+		if out.Description == "" {
+			out.Description = string(item.Description)
+		}
+		for _, q := range req.URL.Query {
+			out.Query = append(out.Query, q.param())
+		}
+		for _, v := range req.URL.Variable {
+			out.PathParams = append(out.PathParams, v.param())
+		}
+		for _, h := range req.Header {
+			out.Headers = append(out.Headers, h.param())
+		}
+		out.Body = c.postmanBody(item.Name, req.Body)
+		for _, resp := range item.Response {
+			r := collectionResponse{Body: resp.Body}
+			if resp.Code != 0 {
+				r.Code = fmt.Sprint(resp.Code)
+			}
+			for _, h := range resp.Header {
+				if strings.EqualFold(h.Key, "Content-Type") {
+					r.MediaType, _, _ = strings.Cut(postmanString(h.Value), ";")
+				}
+			}
+			out.Responses = append(out.Responses, r)
+		}
+		c.Requests = append(c.Requests, out)
+	}
+}
+
+func (c *collection) postmanBody(name string, body *postmanBody) *collectionBody {
+	if body == nil {
+		return nil
+	}
+	switch body.Mode {
+	case "raw":
+		mediaType := map[string]string{
+			"json":       "application/json",
+			"xml":        "application/xml",
+			"html":       "text/html",
+			"javascript": "application/javascript",
+			"text":       "text/plain",
+		}[body.Options.Raw.Language]
+		if mediaType == "" {
+			mediaType = "application/json"
+		}
+		return &collectionBody{MediaType: mediaType, Raw: body.Raw}
+	case "urlencoded":
+		out := &collectionBody{MediaType: "application/x-www-form-urlencoded"}
+		for _, f := range body.URLEncoded {
+			out.Fields = append(out.Fields, f.param())
+		}
+		return out
+	case "formdata":
+		out := &collectionBody{MediaType: "multipart/form-data"}
+		for _, f := range body.FormData {
+			out.Fields = append(out.Fields, f.param())
+		}
+		return out
+	case "graphql":
+		if body.GraphQL == nil {
+			return nil
+		}
+		raw, _ := json.Marshal(map[string]string{"query": body.GraphQL.Query})
+		return &collectionBody{MediaType: "application/json", Raw: string(raw)}
+	case "", "none":
+		return nil
+	default:
+		c.warnf("request %q: %s body was dropped", name, body.Mode)
+		return nil
+	}
+}
+
+// postmanAuth maps Postman auth settings to the collection auth model.
+func (c *collection) postmanAuth(auth *postmanAuth) *collectionAuth {
+	if auth == nil {
+		return nil
+	}
+	p := auth.Params
+	switch auth.Type {
+	case "noauth":
+		return &collectionAuth{Type: "none"}
+	case "bearer":
+		return &collectionAuth{Type: "bearer", Params: map[string]string{"token": p["token"]}}
+	case "basic", "digest":
+		return &collectionAuth{Type: auth.Type, Params: map[string]string{"username": p["username"], "password": p["password"]}}
+	case "apikey":
+		in := p["in"]
+		if in == "" {
+			in = "header"
+		}
+		return &collectionAuth{Type: "apikey", Params: map[string]string{"name": p["key"], "value": p["value"], "in": in}}
+	case "oauth2":
+		return &collectionAuth{Type: "oauth2", Params: map[string]string{
+			"grant_type":    p["grant_type"],
+			"authorize_url": p["authUrl"],
+			"token_url":     p["accessTokenUrl"],
+			"client_id":     p["clientId"],
+			"client_secret": p["clientSecret"],
+			"scopes":        p["scope"],
+		}}
+	}
+	return &collectionAuth{Type: auth.Type}
+}
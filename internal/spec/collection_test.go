@@ -0,0 +1,377 @@
+package spec
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const postmanWidgets = `{
+  "info": {
+    "name": "Widgets",
+    "description": {"content": "Partner widget API"},
+    "schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+  },
+  "variable": [
+    {"key": "baseUrl", "value": "https://api.example.com/v1"},
+    {"key": "tenant", "value": "acme"},
+    {"key": "apiToken", "value": "s3cr3t", "type": "secret"}
+  ],
+  "auth": {"type": "bearer", "bearer": [{"key": "token", "value": "{{apiToken}}", "type": "string"}]},
+  "item": [
+    {
+      "name": "Widgets",
+      "description": "Manage widgets",
+      "item": [
+        {
+          "name": "List widgets",
+          "request": {
+            "method": "GET",
+            "header": [{"key": "X-Tenant", "value": "{{tenant}}"}],
+            "url": {
+              "raw": "{{baseUrl}}/widgets?limit=10",
+              "host": ["{{baseUrl}}"],
+              "path": ["widgets"],
+              "query": [{"key": "limit", "value": "10", "description": "Page size"}]
+            }
+          },
+          "response": [
+            {"name": "OK", "code": 200, "header": [{"key": "Content-Type", "value": "application/json"}], "body": "[{\"id\": 1, \"name\": \"gear\"}]"}
+          ]
+        },
+        {
+          "name": "Get widget",
+          "request": {
+            "method": "GET",
+            "header": [{"key": "X-Tenant", "value": "{{tenant}}"}],
+            "url": {
+              "raw": "{{baseUrl}}/widgets/:id",
+              "host": ["{{baseUrl}}"],
+              "path": ["widgets", ":id"],
+              "variable": [{"key": "id", "value": "42", "description": "Widget ID"}]
+            }
+          }
+        },
+        {
+          "name": "Create widget",
+          "request": {
+            "method": "POST",
+            "header": [
+              {"key": "X-Tenant", "value": "{{tenant}}"},
+              {"key": "Content-Type", "value": "application/json"}
+            ],
+            "body": {"mode": "raw", "raw": "{\"name\": \"gear\", \"size\": 3}", "options": {"raw": {"language": "json"}}},
+            "url": "{{baseUrl}}/widgets"
+          }
+        }
+      ]
+    },
+    {
+      "name": "Health",
+      "request": {
+        "method": "GET",
+        "header": [{"key": "X-Tenant", "value": "{{tenant}}"}],
+        "auth": {"type": "noauth"},
+        "url": "{{baseUrl}}/health"
+      }
+    }
+  ]
+}`
+
+func TestCollectionLoaderConvertsPostman(t *testing.T) {
+	loaded, err := load("application/json", []byte(postmanWidgets), DefaultLoaders())
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if strings.Contains(string(loaded.Raw), "s3cr3t") {
+		t.Fatal("secret collection variables must not be copied into the converted spec")
+	}
+	ops, err := loaded.Operations(OperationOptions{BaseURL: "https://api.example.com"})
+	if err != nil {
+		t.Fatalf("operations: %v", err)
+	}
+	list := operationByID(t, ops, "list-widgets")
+	if list.Path != "/v1/widgets" || !reflect.DeepEqual(list.Tags, []string{"Widgets"}) {
+		t.Fatalf("list-widgets path/tags = %q/%v", list.Path, list.Tags)
+	}
+	if len(list.Parameters) != 1 || list.Parameters[0].Name != "limit" || list.Parameters[0].Desc != "Page size" {
+		t.Fatalf("list-widgets params = %#v", list.Parameters)
+	}
+	if list.ResponseMediaType != "application/json" {
+		t.Fatalf("list-widgets response media type = %q", list.ResponseMediaType)
+	}
+	requireCredential(t, list, [][]CredentialRequirement{{
+		{ID: "bearerAuth", Ref: "#/components/securitySchemes/bearerAuth", Kind: "http-bearer", Source: "openapi"},
+	}})
+
+	get := operationByID(t, ops, "get-widget")
+	if get.Path != "/v1/widgets/{id}" || len(get.Parameters) != 1 || !get.Parameters[0].Required {
+		t.Fatalf("get-widget = %q %#v", get.Path, get.Parameters)
+	}
+	create := operationByID(t, ops, "create-widget")
+	if !create.HasBody || create.RequestMediaType != "application/json" || !strings.Contains(create.Help.Request.Schema, "size") {
+		t.Fatalf("create-widget body = %v %q %#v", create.HasBody, create.RequestMediaType, create.Help.Request)
+	}
+	if health := operationByID(t, ops, "health"); !health.NoAuth || len(health.Tags) != 0 {
+		t.Fatalf("health should opt out of auth without a tag: %#v", health)
+	}
+
+	cfg, err := ReadXCLIConfig(loaded)
+	if err != nil || cfg == nil {
+		t.Fatalf("ReadXCLIConfig = %#v, %v", cfg, err)
+	}
+	profile := cfg.Resolve(loaded).Profiles["default"]
+	if profile == nil || profile.Auth == nil || profile.Auth.Type != "bearer" || profile.Auth.Params["token"] != "" {
+		t.Fatalf("default profile auth = %#v", profile)
+	}
+	if !reflect.DeepEqual(profile.Headers, []string{"X-Tenant: acme"}) {
+		t.Fatalf("shared tenant header should become a profile header, got %#v", profile.Headers)
+	}
+}
+
+const insomniaWidgets = `_type: export
+__export_format: 4
+__export_source: insomnia.desktop.app:v2023.5.8
+resources:
+  - _id: wrk_1
+    _type: workspace
+    name: Widgets
+    description: Partner widget API
+  - _id: env_1
+    _type: environment
+    parentId: wrk_1
+    name: Base Environment
+    data:
+      api:
+        host: https://api.example.com
+  - _id: fld_1
+    _type: request_group
+    parentId: wrk_1
+    name: Admin
+    authentication:
+      type: basic
+      username: admin
+      password: hunter2
+  - _id: req_1
+    _type: request
+    parentId: fld_1
+    name: Delete widget
+    method: DELETE
+    url: "{{ _.api.host }}/widgets/{{ _.widgetId }}"
+    metaSortKey: 2
+  - _id: req_2
+    _type: request
+    parentId: fld_1
+    name: Upload icon
+    method: POST
+    url: "{{ _.api.host }}/icons"
+    metaSortKey: 1
+    body:
+      mimeType: multipart/form-data
+      params:
+        - name: file
+          type: file
+          fileName: /tmp/icon.png
+        - name: label
+          value: gear
+  - _id: ws_1
+    _type: websocket_request
+    parentId: wrk_1
+    name: Live updates
+`
+
+func TestCollectionLoaderConvertsInsomnia(t *testing.T) {
+	loaded, err := load("application/yaml", []byte(insomniaWidgets), DefaultLoaders())
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	var warned []string
+	ops, err := loaded.Operations(OperationOptions{
+		BaseURL: "https://api.example.com",
+		Warnf: func(format string, args ...any) {
+			warned = append(warned, fmt.Sprintf(format, args...))
+		},
+	})
+	if err != nil {
+		t.Fatalf("operations: %v", err)
+	}
+	if len(ops) != 2 || ops[0].ID != "upload-icon" {
+		t.Fatalf("operations should follow metaSortKey order, got %#v", ops)
+	}
+	del := operationByID(t, ops, "delete-widget")
+	if del.Path != "/widgets/{widgetId}" || !reflect.DeepEqual(del.Tags, []string{"Admin"}) {
+		t.Fatalf("delete-widget path/tags = %q/%v", del.Path, del.Tags)
+	}
+	requireCredential(t, del, [][]CredentialRequirement{{
+		{ID: "basicAuth", Ref: "#/components/securitySchemes/basicAuth", Kind: "http-basic", Source: "openapi"},
+	}})
+	if upload := operationByID(t, ops, "upload-icon"); upload.RequestMediaType != "multipart/form-data" {
+		t.Fatalf("upload-icon media type = %q", upload.RequestMediaType)
+	}
+	if strings.Contains(string(loaded.Raw), "hunter2") {
+		t.Fatal("passwords must not be copied into the converted spec")
+	}
+	cfg, _ := ReadXCLIConfig(loaded)
+	if profile := cfg.Resolve(loaded).Profiles["default"]; profile == nil || profile.Auth.Params["username"] != "admin" {
+		t.Fatalf("default profile should carry the username, got %#v", profile)
+	}
+	want := []string{`Insomnia conversion: websocket request "Live updates" was dropped`}
+	if !reflect.DeepEqual(warned, want) {
+		t.Fatalf("warnings = %#v, want %#v", warned, want)
+	}
+}
+
+func TestParseBru(t *testing.T) {
+	f := parseBru(`meta {
+  name: Create widget
+  seq: 2
+}
+
+post {
+  url: {{baseUrl}}/widgets?dry=true
+  body: json
+  auth: inherit
+}
+
+params:query {
+  dry: true
+  ~verbose: 1
+}
+
+body:json {
+  {
+    "name": "gear"
+  }
+}
+
+vars:secret [
+  token,
+  clientSecret
+]
+`)
+	if f.value("meta", "name") != "Create widget" || f.value("post", "url") != "{{baseUrl}}/widgets?dry=true" {
+		t.Fatalf("dict blocks = %#v", f.Dicts)
+	}
+	if q := f.Dicts["params:query"]; len(q) != 2 || !q[1].Disabled || q[1].Name != "verbose" {
+		t.Fatalf("params:query = %#v", q)
+	}
+	if f.Texts["body:json"] != "{\n  \"name\": \"gear\"\n}" {
+		t.Fatalf("body:json = %q", f.Texts["body:json"])
+	}
+	if !reflect.DeepEqual(f.Lists["vars:secret"], []string{"token", "clientSecret"}) {
+		t.Fatalf("vars:secret = %#v", f.Lists["vars:secret"])
+	}
+}
+
+func writeBrunoCollection(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for rel, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", rel, err)
+		}
+	}
+}
+
+func TestDiscoverLoadsBrunoDirectory(t *testing.T) {
+	dir := t.TempDir()
+	writeBrunoCollection(t, dir, map[string]string{
+		"bruno.json": `{"version": "1", "name": "Widgets", "type": "collection"}`,
+		"collection.bru": `auth {
+  mode: apikey
+}
+
+auth:apikey {
+  key: X-API-Key
+  value: {{apiKey}}
+  placement: header
+}
+`,
+		"environments/prod.bru": `vars {
+  baseUrl: https://api.example.com
+}
+vars:secret [
+  apiKey
+]
+`,
+		"widgets/folder.bru": `meta {
+  name: Widget Admin
+}
+`,
+		"widgets/list.bru": `meta {
+  name: List widgets
+  type: http
+  seq: 1
+}
+
+get {
+  url: {{baseUrl}}/widgets
+  body: none
+  auth: inherit
+}
+`,
+	})
+	past := time.Now().Add(-time.Hour)
+	for _, rel := range []string{".", "bruno.json", "collection.bru", "environments/prod.bru", "widgets/folder.bru", "widgets/list.bru"} {
+		if err := os.Chtimes(filepath.Join(dir, filepath.FromSlash(rel)), past, past); err != nil {
+			t.Fatalf("chtimes: %v", err)
+		}
+	}
+
+	cacheDir := t.TempDir()
+	cfg := DiscoverConfig{
+		APIName:   "widgets",
+		BaseURL:   "https://api.example.com",
+		SpecFiles: []string{dir},
+		CacheDir:  cacheDir,
+		Version:   "v2.0.0",
+	}
+	loaded, err := Discover(context.Background(), cfg, DefaultLoaders())
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+	opts := OperationOptions{BaseURL: cfg.BaseURL}
+	ops, err := loaded.Operations(opts)
+	if err != nil {
+		t.Fatalf("operations: %v", err)
+	}
+	list := operationByID(t, ops, "list-widgets")
+	if list.Path != "/widgets" || !reflect.DeepEqual(list.Tags, []string{"Widget Admin"}) {
+		t.Fatalf("list-widgets path/tags = %q/%v", list.Path, list.Tags)
+	}
+	requireCredential(t, list, [][]CredentialRequirement{{
+		{ID: "apiKeyAuth", Ref: "#/components/securitySchemes/apiKeyAuth", Kind: "api-key", In: "header", Name: "X-API-Key", Source: "openapi"},
+	}})
+
+	if _, ok := LoadOperationSetFromCache(cacheDir, "widgets", "v2.0.0", cfg.SpecFiles, opts); !ok {
+		t.Fatal("expected converted Bruno collection in cache")
+	}
+	if err := os.WriteFile(filepath.Join(dir, "widgets", "list.bru"), []byte(`meta {
+  name: List all widgets
+}
+
+get {
+  url: {{baseUrl}}/widgets
+}
+`), 0o644); err != nil {
+		t.Fatalf("rewrite request: %v", err)
+	}
+	if _, ok := LoadOperationSetFromCache(cacheDir, "widgets", "v2.0.0", cfg.SpecFiles, opts); ok {
+		t.Fatal("editing a request file should invalidate the cached collection")
+	}
+}
+
+func TestCollectionFormatIgnoresOpenAPIMentions(t *testing.T) {
+	doc := `{"openapi": "3.1.0", "info": {"title": "Docs", "version": "1", "description": "Import schema.getpostman.com/json/collection/v2.1.0 files"}, "paths": {}}`
+	if format := collectionFormat([]byte(doc)); format != "" {
+		t.Fatalf("collectionFormat = %q", format)
+	}
+}
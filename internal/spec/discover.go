@@ -1,7 +1,9 @@
 package spec
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -59,6 +61,9 @@ type DiscoverConfig struct {
 	// URLs to load the spec from. Multiple files are deep-merged in order
 	// (later entries win on conflict). Network discovery is skipped entirely.
 	SpecFiles []string
+	// OverlayFiles is an ordered list of OpenAPI Overlay documents (local
+	// paths or URLs) applied to the loaded spec before it is cached.
+	OverlayFiles []string
 	// CacheDir is the directory for CBOR spec cache files.
 	CacheDir string
 	// OperationBase overrides operation URL generation and is included in the
@@ -74,6 +79,9 @@ type DiscoverConfig struct {
 	Transport http.RoundTripper
 	// Fetch, when set, is used for all HTTP fetches instead of Transport.
 	Fetch HTTPFetcher
+	// Post, when set, sends GraphQL introspection queries instead of
+	// Transport.
+	Post HTTPPoster
 	// AllowCrossOrigin permits Link-header-discovered spec URLs on other hosts.
 	// When false, only same-host discovered links are followed. Private/local
 	// cross-origin targets are still rejected unless the base URL is already in
@@ -94,9 +102,11 @@ type DiscoverConfig struct {
 // Discovery order (first success wins, network steps run in parallel):
 //  1. CBOR spec cache
 //  2. Explicit SpecURL (if configured)
-//  3. Link headers from a GET on BaseURL (service-desc / service-doc / describedby)
+//  3. Link headers from a GET on BaseURL (service-desc / service-doc / describedby),
+//     or the $metadata document of an OData service document
 //  4. Well-known paths /openapi.json and /openapi.yaml
 //  5. BaseURL body itself
+//  6. A GraphQL introspection query, when BaseURL ends in /graphql
 func Discover(ctx context.Context, cfg DiscoverConfig, loaders []Loader) (*APISpec, error) {
 	if _, hasDeadline := ctx.Deadline(); !hasDeadline {
 		var cancel context.CancelFunc
@@ -119,7 +129,7 @@ func Discover(ctx context.Context, cfg DiscoverConfig, loaders []Loader) (*APISp
 			opts.Transport = effectiveTransport(cfg)
 			opts.Fetch = effectiveFetcher(cfg)
 			opts.Trace = cfg.Trace
-			if spec, err := loadWithOptions(entry.contentType(), entry.raw(), loaders, opts); err == nil && spec != nil {
+			if spec, err := entry.load(loaders, opts); err == nil && spec != nil {
 				return spec, nil
 			}
 		}
@@ -132,6 +142,9 @@ loadFresh:
 		if err != nil {
 			return nil, err
 		}
+		if spec, err = applyOverlays(ctx, cfg, spec, loaders); err != nil {
+			return nil, err
+		}
 		if spec != nil && cfg.CacheDir != "" {
 			opts := OperationOptions{BaseURL: cfg.BaseURL, OperationBase: cfg.OperationBase, ServerVariables: cfg.ServerVariables, Warnf: cfg.Warnf}
 			set, _ := spec.OperationSet(opts)
@@ -149,6 +162,7 @@ loadFresh:
 				},
 			}
 			entry.SpecFiles = cacheSpecFileMetadata(cfg.SpecFiles)
+			entry.Overlays = spec.overlays
 			if set.Operations != nil {
 				entry.upsertOperationSet(cacheOperationOptions(opts), set)
 			}
@@ -167,6 +181,9 @@ loadFresh:
 	if spec != nil && cfg.SpecURL != "" && spec.RequestedURL == "" {
 		spec.RequestedURL = cleanSourceURL(cfg.SpecURL)
 	}
+	if spec, err = applyOverlays(ctx, cfg, spec, loaders); err != nil {
+		return nil, err
+	}
 
 	// Cache the result.
 	if cfg.CacheDir != "" && spec != nil {
@@ -191,6 +208,7 @@ loadFresh:
 				LocalPath:        spec.LocalPath,
 				AllowCrossOrigin: spec.AllowCrossOrigin,
 			},
+			Overlays: spec.overlays,
 		}
 		if set.Operations != nil {
 			entry.upsertOperationSet(cacheOperationOptions(opts), set)
@@ -227,6 +245,9 @@ func cacheSourceMatches(cfg DiscoverConfig, entry *cacheEntry) bool {
 	if entry.Spec.DiscoveryBaseURL != "" && !sourceURLMatches(entry.Spec.DiscoveryBaseURL, cfg.BaseURL) {
 		return false
 	}
+	if !cacheOverlaysMatch(cfg.OverlayFiles, entry) {
+		return false
+	}
 	if len(cfg.SpecFiles) > 0 {
 		return cacheSpecFilesMatch(cfg.SpecFiles, entry)
 	}
@@ -280,10 +301,10 @@ func cacheSpecFileMetadata(specFiles []string) []cachedSpecFile {
 			path, err := localPathFromSource(src)
 			if err == nil {
 				meta.Path = path
-				if info, statErr := os.Stat(path); statErr == nil {
-					meta.ModTime = info.ModTime()
-					meta.ModTimeUnixNano = info.ModTime().UnixNano()
-					meta.Size = info.Size()
+				if modTime, size, statErr := localSpecStat(path); statErr == nil {
+					meta.ModTime = modTime
+					meta.ModTimeUnixNano = modTime.UnixNano()
+					meta.Size = size
 				}
 			}
 		} else {
@@ -339,8 +360,8 @@ func specFilesChangedSince(specFiles []string, fetchedAt time.Time) bool {
 		if err != nil {
 			return true
 		}
-		info, err := os.Stat(path)
-		if err != nil || info.ModTime().After(fetchedAt) {
+		modTime, _, err := localSpecStat(path)
+		if err != nil || modTime.After(fetchedAt) {
 			return true
 		}
 	}
@@ -418,7 +439,15 @@ func discoverFromNetwork(ctx context.Context, cfg DiscoverConfig, loaders []Load
 	if cfg.SpecURL != "" {
 		u := cfg.SpecURL
 		launch(0, u, func() (string, []byte, time.Duration, string, error) {
-			ct, body, ttl, sourceURL, err := fetchBytes(ctx, u, tr, fetch, cfg.Trace)
+			var ct, sourceURL string
+			var body []byte
+			var ttl time.Duration
+			var err error
+			if isGraphQLEndpoint(u) {
+				ct, body, sourceURL, err = introspectGraphQL(ctx, u, tr, effectivePoster(cfg), cfg.Trace)
+			} else {
+				ct, body, ttl, sourceURL, err = fetchBytes(ctx, u, tr, fetch, cfg.Trace)
+			}
 			if errors.Is(err, errNoSpecCandidate) {
 				return "", nil, 0, sourceURL, fmt.Errorf("GET %s: 404 Not Found", sourceURL)
 			}
@@ -454,6 +483,14 @@ func discoverFromNetwork(ctx context.Context, cfg DiscoverConfig, loaders []Load
 		return ct, body, ttl, sourceURL, nil
 	})
 
+	// GraphQL endpoints describe themselves through introspection.
+	if isGraphQLEndpoint(baseURL) {
+		launch(1, baseURL, func() (string, []byte, time.Duration, string, error) {
+			ct, body, sourceURL, err := introspectGraphQL(ctx, baseURL, tr, effectivePoster(cfg), cfg.Trace)
+			return ct, body, 0, sourceURL, err
+		})
+	}
+
 	// Well-known paths.
 	for _, path := range wellKnownSpecPaths {
 		u := joinURL(cfg.BaseURL, path)
@@ -554,7 +591,8 @@ func fetchWithLinks(ctx context.Context, rawURL string, tr http.RoundTripper, fe
 	}
 
 	ttl = cacheTTL(resp)
-	links = filterDiscoveredSpecLinks(sourceURL, extractSpecLinks(sourceURL, resp.Header), allowCrossOrigin)
+	links = append(extractSpecLinks(sourceURL, resp.Header), odataMetadataLinks(sourceURL, resp.Header, body)...)
+	links = filterDiscoveredSpecLinks(sourceURL, links, allowCrossOrigin)
 	return ct, body, ttl, sourceURL, links, nil
 }
 
@@ -662,6 +700,21 @@ func effectiveFetcher(cfg DiscoverConfig) HTTPFetcher {
 	}
 }
 
+func effectivePoster(cfg DiscoverConfig) HTTPPoster {
+	if cfg.Post != nil {
+		return cfg.Post
+	}
+	return func(ctx context.Context, rawURL, contentType string, body []byte, tr http.RoundTripper) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, rawURL, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("Accept", graphQLAccept)
+		return tr.RoundTrip(req)
+	}
+}
+
 // cacheTTL extracts the cache duration from a response's Cache-Control header.
 func cacheTTL(resp *http.Response) time.Duration {
 	cc := resp.Header.Get("Cache-Control")
@@ -701,6 +754,23 @@ func extractSpecLinks(baseURL string, h http.Header) []string {
 	return out
 }
 
+// odataMetadataLinks returns the $metadata URL of an OData service document:
+// the @odata.context URL without its fragment, or $metadata under the
+// service root when only the OData-Version header marks the response.
+func odataMetadataLinks(baseURL string, h http.Header, body []byte) []string {
+	var doc struct {
+		Context string `json:"@odata.context"`
+	}
+	if json.Unmarshal(body, &doc) == nil && doc.Context != "" {
+		context, _, _ := strings.Cut(doc.Context, "#")
+		return []string{resolveRef(baseURL, context)}
+	}
+	if h.Get("OData-Version") != "" {
+		return []string{strings.TrimSuffix(baseURL, "/") + "/$metadata"}
+	}
+	return nil
+}
+
 func isSpecLinkRel(rel string) bool {
 	switch strings.ToLower(rel) {
 	case "service-desc", "service-doc", "describedby":
@@ -870,11 +940,17 @@ func loadSpecFiles(ctx context.Context, cfg DiscoverConfig, loaders []Loader) (*
 		if err != nil {
 			return nil, fmt.Errorf("spec file %q: %w", displaySrc, err)
 		}
+		if parseFileDescriptorSet(data) != nil {
+			return nil, fmt.Errorf("spec file %q: protobuf descriptor sets cannot be merged with other spec files; build one set with every service instead", displaySrc)
+		}
 		data, err = resolveOpenAPIExternalRefs(data, opts)
 		if err != nil {
 			return nil, fmt.Errorf("spec file %q: %w", displaySrc, err)
 		}
 
+		if collectionFormat(data) != "" {
+			return nil, fmt.Errorf("spec file %q: API client collections cannot be merged with other spec files; use overlay_files to patch the converted spec", displaySrc)
+		}
 		var doc map[string]any
 		if err := yaml.Unmarshal(data, &doc); err != nil {
 			return nil, fmt.Errorf("spec file %q: parse: %w", displaySrc, err)
@@ -922,12 +998,20 @@ func isLocalPath(s string) bool {
 }
 
 // readLocalFile reads a local spec file, stripping any leading "file://" prefix.
-// The content-type is inferred from the file extension.
+// The content-type is inferred from the file extension. A directory is read as
+// a Bruno collection bundle.
 func readLocalFile(path string) (contentType string, data []byte, err error) {
 	path, err = localPathFromSource(path)
 	if err != nil {
 		return "", nil, err
 	}
+	if info, statErr := os.Stat(path); statErr == nil && info.IsDir() {
+		data, err = brunoBundle(path)
+		if err != nil {
+			return "", nil, err
+		}
+		return "application/json", data, nil
+	}
 	data, err = os.ReadFile(path)
 	if err != nil {
 		return "", nil, err
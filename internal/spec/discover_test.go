@@ -1424,6 +1424,34 @@ func TestDiscover_LinkHeader(t *testing.T) {
 	}
 }
 
+func TestDiscover_ODataServiceDocumentContext(t *testing.T) {
+	tr := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		switch r.URL.Path {
+		case "/TripPin/":
+			headers := http.Header{}
+			headers.Set("OData-Version", "4.0")
+			return httpResponse(200, "application/json", `{"@odata.context":"https://services.example.com/TripPin/$metadata","value":[{"name":"People","url":"People"}]}`, headers), nil
+		case "/TripPin/$metadata":
+			return httpResponse(200, "application/xml", tripPinMetadata, nil), nil
+		default:
+			return httpResponse(404, "text/plain", "not found", nil), nil
+		}
+	})
+
+	cfg := DiscoverConfig{
+		APIName:   "trippin",
+		BaseURL:   "https://services.example.com/TripPin/",
+		Transport: tr,
+	}
+	result, err := Discover(context.Background(), cfg, DefaultLoaders())
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+	if result.SourceURL != "https://services.example.com/TripPin/$metadata" {
+		t.Fatalf("SourceURL = %q", result.SourceURL)
+	}
+}
+
 func TestDiscover_LinkHeaderCrossOriginRequiresOptIn(t *testing.T) {
 	oldLookup := lookupIPAddr
 	lookupIPAddr = func(ctx context.Context, host string) ([]net.IPAddr, error) {
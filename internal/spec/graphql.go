@@ -0,0 +1,668 @@
+package spec
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"github.com/rest-sh/restish/v2/internal/request"
+	"go.yaml.in/yaml/v3"
+)
+
+// GraphQLIntrospectionQuery is the introspection query whose result the
+// GraphQLLoader converts. Discovery posts it to GraphQL endpoints, and
+// `restish graphql --introspect` sends it on request.
+const GraphQLIntrospectionQuery = `query IntrospectionQuery {
+  __schema {
+    queryType { name }
+    mutationType { name }
+    subscriptionType { name }
+    types {
+      kind
+      name
+      description
+      fields(includeDeprecated: true) {
+        name
+        description
+        args { ...InputValue }
+        type { ...TypeRef }
+        isDeprecated
+        deprecationReason
+      }
+      inputFields { ...InputValue }
+      enumValues(includeDeprecated: true) { name description isDeprecated }
+      possibleTypes { kind name }
+    }
+  }
+}
+
+fragment InputValue on __InputValue {
+  name
+  description
+  type { ...TypeRef }
+  defaultValue
+}
+
+fragment TypeRef on __Type {
+  kind
+  name
+  ofType { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name } } } } } } }
+}
+`
+
+// graphQLSelectionDepth is how many levels of nested object fields the
+// default selection set of a generated operation descends into. Connection
+// wrappers do not count as a level.
+const graphQLSelectionDepth = 2
+
+// GraphQLLoader loads GraphQL introspection results by converting them to
+// OpenAPI 3.0. Each query and mutation field becomes a POST operation under
+// the synthetic /query/{field} or /mutation/{field} path, its arguments become
+// query parameters so they surface as flags, and the x-graphql extension
+// carries the GraphQL document the CLI actually sends. Raw holds the converted
+// document, so overlays can rename operations or edit their documents.
+type GraphQLLoader struct{}
+
+func (GraphQLLoader) Priority() int { return 20 }
+
+// Detect returns true for introspection results, bare or wrapped in a GraphQL
+// response's data member.
+func (GraphQLLoader) Detect(contentType string, body []byte) bool {
+	return parseGraphQLIntrospection(body) != nil
+}
+
+// LoadWithOptions converts an introspection result to OpenAPI and loads the
+// result. Conversion warnings are reported with the operation warnings.
+func (GraphQLLoader) LoadWithOptions(body []byte, opts LoadOptions) (*APISpec, error) {
+	schema := parseGraphQLIntrospection(body)
+	if schema == nil {
+		return nil, &LoadError{Errors: []string{"GraphQL introspection: missing __schema"}}
+	}
+	raw, warnings, err := schema.openAPI()
+	if err != nil {
+		return nil, &LoadError{Errors: []string{fmt.Sprintf("GraphQL introspection: %v", err)}}
+	}
+	opts.ContentType = "application/yaml"
+	loaded, err := OpenAPILoader{}.LoadWithOptions(raw, opts)
+	if err != nil {
+		return nil, err
+	}
+	loaded.ContentType = "application/yaml"
+	for _, warning := range warnings {
+		loaded.loadWarnings = append(loaded.loadWarnings, "GraphQL conversion: "+warning)
+	}
+	return loaded, nil
+}
+
+// GraphQLOperation is the GraphQL request behind an operation converted from
+// an introspection result, read from the x-graphql extension.
+type GraphQLOperation struct {
+	// Type is the root operation type: query or mutation.
+	Type string `yaml:"type"`
+	// Field is the root field the operation selects.
+	Field string `yaml:"field"`
+	// Document is the GraphQL document sent as the request query.
+	Document string `yaml:"document"`
+	// Variables maps each declared variable to its GraphQL type, such as
+	// "Int" or "[ID!]!", so argument values can be converted before sending.
+	Variables map[string]string `yaml:"variables"`
+}
+
+type graphQLSchema struct {
+	Description      string                `json:"description"`
+	QueryType        *graphQLNamedRef      `json:"queryType"`
+	MutationType     *graphQLNamedRef      `json:"mutationType"`
+	SubscriptionType *graphQLNamedRef      `json:"subscriptionType"`
+	Types            []graphQLIntrospected `json:"types"`
+
+	types map[string]*graphQLIntrospected
+}
+
+type graphQLNamedRef struct {
+	Name string `json:"name"`
+}
+
+type graphQLIntrospected struct {
+	Kind          string              `json:"kind"`
+	Name          string              `json:"name"`
+	Description   string              `json:"description"`
+	Fields        []graphQLField      `json:"fields"`
+	InputFields   []graphQLInputValue `json:"inputFields"`
+	EnumValues    []graphQLEnumValue  `json:"enumValues"`
+	PossibleTypes []graphQLNamedRef   `json:"possibleTypes"`
+}
+
+type graphQLField struct {
+	Name              string              `json:"name"`
+	Description       string              `json:"description"`
+	Args              []graphQLInputValue `json:"args"`
+	Type              graphQLTypeRef      `json:"type"`
+	IsDeprecated      bool                `json:"isDeprecated"`
+	DeprecationReason string              `json:"deprecationReason"`
+}
+
+type graphQLInputValue struct {
+	Name         string         `json:"name"`
+	Description  string         `json:"description"`
+	Type         graphQLTypeRef `json:"type"`
+	DefaultValue *string        `json:"defaultValue"`
+}
+
+type graphQLEnumValue struct {
+	Name         string `json:"name"`
+	IsDeprecated bool   `json:"isDeprecated"`
+}
+
+type graphQLTypeRef struct {
+	Kind   string          `json:"kind"`
+	Name   string          `json:"name"`
+	OfType *graphQLTypeRef `json:"ofType"`
+}
+
+// String renders the reference in GraphQL type syntax, e.g. "[ID!]!".
+func (t graphQLTypeRef) String() string {
+	switch {
+	case t.Kind == "NON_NULL" && t.OfType != nil:
+		return t.OfType.String() + "!"
+	case t.Kind == "LIST" && t.OfType != nil:
+		return "[" + t.OfType.String() + "]"
+	}
+	return t.Name
+}
+
+// nullable strips an outer NON_NULL wrapper.
+func (t graphQLTypeRef) nullable() graphQLTypeRef {
+	if t.Kind == "NON_NULL" && t.OfType != nil {
+		return *t.OfType
+	}
+	return t
+}
+
+// named returns the innermost named type.
+func (t graphQLTypeRef) named() string {
+	for ref := &t; ref != nil; ref = ref.OfType {
+		if ref.Name != "" {
+			return ref.Name
+		}
+	}
+	return ""
+}
+
+// required reports whether the argument must be passed: non-null without a
+// default value.
+func (v graphQLInputValue) required() bool {
+	return v.Type.Kind == "NON_NULL" && v.DefaultValue == nil
+}
+
+// parseGraphQLIntrospection returns the schema from an introspection result,
+// or nil. A cheap byte check guards the JSON decode so other documents are not
+// parsed twice.
+func parseGraphQLIntrospection(body []byte) *graphQLSchema {
+	if !bytes.Contains(body, []byte(`"__schema"`)) {
+		return nil
+	}
+	var doc struct {
+		Data struct {
+			Schema *graphQLSchema `json:"__schema"`
+		} `json:"data"`
+		Schema *graphQLSchema `json:"__schema"`
+	}
+	if json.Unmarshal(body, &doc) != nil {
+		return nil
+	}
+	schema := doc.Data.Schema
+	if schema == nil {
+		schema = doc.Schema
+	}
+	if schema == nil || len(schema.Types) == 0 {
+		return nil
+	}
+	schema.types = make(map[string]*graphQLIntrospected, len(schema.Types))
+	for i := range schema.Types {
+		schema.types[schema.Types[i].Name] = &schema.Types[i]
+	}
+	return schema
+}
+
+func (s *graphQLSchema) openAPI() ([]byte, []string, error) {
+	var warnings []string
+	doc := yamlMapping()
+	yamlSet(doc, "openapi", yamlScalar("3.0.3"))
+	info := yamlMapping()
+	yamlSet(info, "title", yamlScalar("GraphQL API"))
+	if s.Description != "" {
+		yamlSet(info, "description", yamlScalar(s.Description))
+	}
+	yamlSet(info, "version", yamlScalar("1.0.0"))
+	yamlSet(doc, "info", info)
+
+	paths := yamlMapping()
+	tags := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	operationIDs := map[string]bool{}
+	roots := []struct {
+		kind string
+		ref  *graphQLNamedRef
+		desc string
+	}{
+		{"query", s.QueryType, "Read data with GraphQL queries."},
+		{"mutation", s.MutationType, "Change data with GraphQL mutations."},
+	}
+	for _, root := range roots {
+		if root.ref == nil {
+			continue
+		}
+		rootType := s.types[root.ref.Name]
+		if rootType == nil {
+			warnings = append(warnings, fmt.Sprintf("%s type %q is not in the schema", root.kind, root.ref.Name))
+			continue
+		}
+		if len(rootType.Fields) == 0 {
+			continue
+		}
+		tag := yamlMapping()
+		yamlSet(tag, "name", yamlScalar(root.kind))
+		yamlSet(tag, "description", yamlScalar(root.desc))
+		tags.Content = append(tags.Content, tag)
+		for i := range rootType.Fields {
+			field := &rootType.Fields[i]
+			if strings.HasPrefix(field.Name, "__") {
+				continue
+			}
+			item := yamlMapping()
+			yamlSet(item, "post", s.operation(root.kind, field, operationIDs))
+			yamlSet(paths, "/"+root.kind+"/"+field.Name, item)
+		}
+	}
+	if s.SubscriptionType != nil {
+		if sub := s.types[s.SubscriptionType.Name]; sub != nil && len(sub.Fields) > 0 {
+			warnings = append(warnings, fmt.Sprintf("skipped %d subscription fields; subscriptions are not supported", len(sub.Fields)))
+		}
+	}
+	if len(paths.Content) == 0 {
+		warnings = append(warnings, "schema has no query or mutation fields")
+	}
+	if len(tags.Content) > 0 {
+		yamlSet(doc, "tags", tags)
+	}
+	yamlSet(doc, "paths", paths)
+
+	out, err := yaml.Marshal(doc)
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, warnings, nil
+}
+
+// operation converts one root field to an OpenAPI operation.
+func (s *graphQLSchema) operation(kind string, field *graphQLField, operationIDs map[string]bool) *yaml.Node {
+	op := yamlMapping()
+	tags := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	tags.Content = append(tags.Content, yamlScalar(kind))
+	yamlSet(op, "tags", tags)
+	summary, description := graphQLSummary(field.Description)
+	if summary == "" {
+		summary = fmt.Sprintf("GraphQL %s %s", kind, field.Name)
+	}
+	yamlSet(op, "summary", yamlScalar(summary))
+	if description != "" {
+		yamlSet(op, "description", yamlScalar(description))
+	}
+	operationID := field.Name
+	if operationIDs[operationID] {
+		operationID = kind + strings.ToUpper(field.Name[:1]) + field.Name[1:]
+	}
+	operationIDs[operationID] = true
+	yamlSet(op, "operationId", yamlScalar(operationID))
+	if field.IsDeprecated {
+		yamlSet(op, "deprecated", yamlBool(true))
+	}
+
+	params := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	variables := yamlMapping()
+	for _, arg := range field.Args {
+		params.Content = append(params.Content, s.parameter(arg))
+		yamlSet(variables, arg.Name, yamlScalar(arg.Type.String()))
+	}
+	if len(params.Content) > 0 {
+		yamlSet(op, "parameters", params)
+	}
+
+	response := yamlMapping()
+	yamlSet(response, "description", yamlScalar("GraphQL response with "+field.Type.String()+" data"))
+	responses := yamlMapping()
+	yamlSet(responses, "200", response)
+	yamlSet(op, "responses", responses)
+
+	ext := yamlMapping()
+	yamlSet(ext, "type", yamlScalar(kind))
+	yamlSet(ext, "field", yamlScalar(field.Name))
+	yamlSet(ext, "document", yamlScalar(s.document(kind, field)))
+	if len(variables.Content) > 0 {
+		yamlSet(ext, "variables", variables)
+	}
+	yamlSet(op, "x-graphql", ext)
+	return op
+}
+
+// parameter converts a field argument to a query parameter. Input objects
+// and custom scalars are strings the CLI parses as shorthand or JSON.
+func (s *graphQLSchema) parameter(arg graphQLInputValue) *yaml.Node {
+	param := yamlMapping()
+	yamlSet(param, "name", yamlScalar(arg.Name))
+	yamlSet(param, "in", yamlScalar("query"))
+	desc := arg.Description
+	if t := s.types[arg.Type.named()]; t != nil && t.Kind == "INPUT_OBJECT" {
+		desc = strings.TrimSpace(desc + "\n\nGraphQL input " + arg.Type.String() + "; pass shorthand or JSON, e.g. '{field: value}'.")
+	}
+	if arg.DefaultValue != nil {
+		desc = strings.TrimSpace(desc + " (default: " + *arg.DefaultValue + ")")
+	}
+	if desc != "" {
+		yamlSet(param, "description", yamlScalar(desc))
+	}
+	if arg.required() {
+		yamlSet(param, "required", yamlBool(true))
+	}
+	yamlSet(param, "schema", s.schema(arg.Type.nullable()))
+	return param
+}
+
+func (s *graphQLSchema) schema(ref graphQLTypeRef) *yaml.Node {
+	schema := yamlMapping()
+	if ref.Kind == "LIST" && ref.OfType != nil {
+		yamlSet(schema, "type", yamlScalar("array"))
+		items := yamlMapping()
+		inner := ref.OfType.nullable()
+		if inner.Kind == "LIST" {
+			yamlSet(items, "type", yamlScalar("string"))
+		} else {
+			items = s.schema(inner)
+		}
+		yamlSet(schema, "items", items)
+		return schema
+	}
+	switch ref.Name {
+	case "Int":
+		yamlSet(schema, "type", yamlScalar("integer"))
+	case "Float":
+		yamlSet(schema, "type", yamlScalar("number"))
+	case "Boolean":
+		yamlSet(schema, "type", yamlScalar("boolean"))
+	default:
+		yamlSet(schema, "type", yamlScalar("string"))
+		if t := s.types[ref.Name]; t != nil && t.Kind == "ENUM" && len(t.EnumValues) > 0 {
+			values := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+			for _, value := range t.EnumValues {
+				if !value.IsDeprecated {
+					values.Content = append(values.Content, yamlScalar(value.Name))
+				}
+			}
+			if len(values.Content) > 0 {
+				yamlSet(schema, "enum", values)
+			}
+		}
+	}
+	return schema
+}
+
+// document renders the GraphQL document for a root field: every argument
+// becomes a variable, and the selection set comes from graphQLSelectionDepth.
+func (s *graphQLSchema) document(kind string, field *graphQLField) string {
+	var b strings.Builder
+	b.WriteString(kind + " " + field.Name)
+	if len(field.Args) > 0 {
+		decls := make([]string, 0, len(field.Args))
+		for _, arg := range field.Args {
+			decls = append(decls, "$"+arg.Name+": "+arg.Type.String())
+		}
+		b.WriteString("(" + strings.Join(decls, ", ") + ")")
+	}
+	b.WriteString(" {\n  " + field.Name)
+	if len(field.Args) > 0 {
+		uses := make([]string, 0, len(field.Args))
+		for _, arg := range field.Args {
+			uses = append(uses, arg.Name+": $"+arg.Name)
+		}
+		b.WriteString("(" + strings.Join(uses, ", ") + ")")
+	}
+	if t := s.types[field.Type.named()]; t != nil && graphQLCompositeKind(t.Kind) {
+		b.WriteString(" {\n" + s.selection(t, graphQLSelectionDepth, "    ") + "  }")
+	}
+	b.WriteString("\n}\n")
+	return b.String()
+}
+
+// selection renders the default selection set for t. Scalar and enum fields
+// are always selected; object fields only while depth remains. Fields with
+// required arguments and nested connections are skipped because the
+// generated document cannot supply their arguments. A root connection selects
+// its page info and nodes (or edges) so cursor pagination works.
+func (s *graphQLSchema) selection(t *graphQLIntrospected, depth int, indent string) string {
+	var b strings.Builder
+	if t.Kind == "UNION" {
+		b.WriteString(indent + "__typename\n")
+		names := make([]string, 0, len(t.PossibleTypes))
+		for _, possible := range t.PossibleTypes {
+			names = append(names, possible.Name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			member := s.types[name]
+			if member == nil {
+				continue
+			}
+			if inner := s.selection(member, 0, indent+"  "); inner != indent+"  __typename\n" {
+				b.WriteString(indent + "... on " + name + " {\n" + inner + indent + "}\n")
+			}
+		}
+		return b.String()
+	}
+	if s.connection(t) {
+		return s.connectionSelection(t, depth, indent)
+	}
+	for _, field := range t.Fields {
+		if field.IsDeprecated || strings.HasPrefix(field.Name, "__") || graphQLHasRequiredArgs(field) {
+			continue
+		}
+		child := s.types[field.Type.named()]
+		switch {
+		case child == nil:
+			continue
+		case !graphQLCompositeKind(child.Kind):
+			b.WriteString(indent + field.Name + "\n")
+		case depth > 0 && !s.connection(child):
+			b.WriteString(indent + field.Name + " {\n" + s.selection(child, depth-1, indent+"  ") + indent + "}\n")
+		}
+	}
+	if b.Len() == 0 {
+		return indent + "__typename\n"
+	}
+	return b.String()
+}
+
+func (s *graphQLSchema) connectionSelection(t *graphQLIntrospected, depth int, indent string) string {
+	var b strings.Builder
+	for _, field := range t.Fields {
+		if field.IsDeprecated || graphQLHasRequiredArgs(field) {
+			continue
+		}
+		child := s.types[field.Type.named()]
+		if child == nil {
+			continue
+		}
+		switch {
+		case !graphQLCompositeKind(child.Kind):
+			b.WriteString(indent + field.Name + "\n")
+		case field.Name == "pageInfo":
+			b.WriteString(indent + "pageInfo {\n")
+			for _, name := range []string{"hasNextPage", "endCursor"} {
+				if graphQLTypeHasField(child, name) {
+					b.WriteString(indent + "  " + name + "\n")
+				}
+			}
+			b.WriteString(indent + "}\n")
+		}
+	}
+	nodes := s.connectionField(t, "nodes")
+	if nodes != nil {
+		b.WriteString(indent + "nodes {\n" + s.selection(nodes, depth, indent+"  ") + indent + "}\n")
+		return b.String()
+	}
+	edges := s.connectionField(t, "edges")
+	if edges == nil {
+		return b.String()
+	}
+	b.WriteString(indent + "edges {\n")
+	if graphQLTypeHasField(edges, "cursor") {
+		b.WriteString(indent + "  cursor\n")
+	}
+	if node := s.connectionField(edges, "node"); node != nil {
+		b.WriteString(indent + "  node {\n" + s.selection(node, depth, indent+"    ") + indent + "  }\n")
+	}
+	b.WriteString(indent + "}\n")
+	return b.String()
+}
+
+// connection reports whether t is a Relay-style connection: it has pageInfo
+// plus nodes or edges.
+func (s *graphQLSchema) connection(t *graphQLIntrospected) bool {
+	if t == nil || t.Kind != "OBJECT" || s.connectionField(t, "pageInfo") == nil {
+		return false
+	}
+	return s.connectionField(t, "nodes") != nil || s.connectionField(t, "edges") != nil
+}
+
+// connectionField returns the composite type of t's field name, or nil.
+func (s *graphQLSchema) connectionField(t *graphQLIntrospected, name string) *graphQLIntrospected {
+	for _, field := range t.Fields {
+		if field.Name != name {
+			continue
+		}
+		if child := s.types[field.Type.named()]; child != nil && graphQLCompositeKind(child.Kind) {
+			return child
+		}
+	}
+	return nil
+}
+
+func graphQLTypeHasField(t *graphQLIntrospected, name string) bool {
+	for _, field := range t.Fields {
+		if field.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func graphQLCompositeKind(kind string) bool {
+	return kind == "OBJECT" || kind == "INTERFACE" || kind == "UNION"
+}
+
+func graphQLHasRequiredArgs(field graphQLField) bool {
+	for _, arg := range field.Args {
+		if arg.required() {
+			return true
+		}
+	}
+	return false
+}
+
+// graphQLSummary splits a GraphQL description into a one-line summary and
+// the full description when it has more than one line.
+func graphQLSummary(description string) (string, string) {
+	description = strings.TrimSpace(description)
+	summary, _, multiline := strings.Cut(description, "\n")
+	if !multiline {
+		return description, ""
+	}
+	return strings.TrimSpace(summary), description
+}
+
+// opExtGraphQL reads the x-graphql extension from an operation, or returns
+// nil when it is absent or has no document.
+func opExtGraphQL(op *v3.Operation) *GraphQLOperation {
+	if op.Extensions == nil {
+		return nil
+	}
+	gql := extValue[*GraphQLOperation](op.Extensions.GetOrZero("x-graphql"))
+	if gql == nil || strings.TrimSpace(gql.Document) == "" {
+		return nil
+	}
+	return gql
+}
+
+// graphQLAccept prefers the GraphQL-over-HTTP response media type and falls
+// back to plain JSON for older servers.
+const graphQLAccept = "application/graphql-response+json, application/json;q=0.9"
+
+// isGraphQLEndpoint reports whether rawURL looks like a GraphQL endpoint: its
+// path ends in /graphql.
+func isGraphQLEndpoint(rawURL string) bool {
+	normalized, err := request.Normalize(rawURL, "")
+	if err != nil {
+		return false
+	}
+	u, err := url.Parse(normalized)
+	if err != nil {
+		return false
+	}
+	path := strings.ToLower(strings.TrimRight(u.Path, "/"))
+	return path == "/graphql" || strings.HasSuffix(path, "/graphql")
+}
+
+// introspectGraphQL posts GraphQLIntrospectionQuery to rawURL and returns
+// the response for the GraphQLLoader. It returns errNoSpecCandidate for 404s
+// so a miss does not mask other discovery errors.
+func introspectGraphQL(ctx context.Context, rawURL string, tr http.RoundTripper, post HTTPPoster, trace func(format string, args ...any)) (string, []byte, string, error) {
+	displayURL := cleanSourceURL(rawURL)
+	tracef(trace, "POST GraphQL introspection %s", displayURL)
+	payload, err := json.Marshal(map[string]any{"query": GraphQLIntrospectionQuery, "operationName": "IntrospectionQuery"})
+	if err != nil {
+		return "", nil, displayURL, err
+	}
+	resp, err := post(ctx, rawURL, "application/json", payload, tr)
+	if err != nil {
+		return "", nil, displayURL, fmt.Errorf("POST %s: %w", displayURL, cleanErrorForDisplay(err, rawURL, displayURL))
+	}
+	if resp == nil {
+		return "", nil, displayURL, fmt.Errorf("POST %s: no response", displayURL)
+	}
+	if resp.Body != nil {
+		defer resp.Body.Close()
+	}
+	sourceURL := effectiveResponseSourceURL(rawURL, resp)
+	if resp.StatusCode == http.StatusNotFound {
+		return "", nil, sourceURL, errNoSpecCandidate
+	}
+	var body []byte
+	if resp.Body != nil {
+		body, err = io.ReadAll(io.LimitReader(resp.Body, maxSpecBytes+1))
+		if err != nil {
+			return "", nil, sourceURL, err
+		}
+	}
+	if int64(len(body)) > maxSpecBytes {
+		return "", nil, sourceURL, fmt.Errorf("introspection result from %s exceeds limit of %d bytes", sourceURL, maxSpecBytes)
+	}
+	if parseGraphQLIntrospection(body) == nil {
+		var failed struct {
+			Errors []struct {
+				Message string `json:"message"`
+			} `json:"errors"`
+		}
+		if json.Unmarshal(body, &failed) == nil && len(failed.Errors) > 0 {
+			return "", nil, sourceURL, fmt.Errorf("POST %s: GraphQL introspection failed: %s", sourceURL, failed.Errors[0].Message)
+		}
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return "", nil, sourceURL, fmt.Errorf("POST %s: %s", sourceURL, resp.Status)
+		}
+	}
+	return "application/json", body, sourceURL, nil
+}
@@ -0,0 +1,235 @@
+package spec
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const graphQLIntrospection = `{"data": {"__schema": {
+  "queryType": {"name": "Query"},
+  "mutationType": {"name": "Mutation"},
+  "subscriptionType": {"name": "Subscription"},
+  "types": [
+    {"kind": "OBJECT", "name": "Query", "fields": [
+      {"name": "viewer", "description": "The signed-in user.", "args": [],
+       "type": {"kind": "NON_NULL", "ofType": {"kind": "OBJECT", "name": "User"}}},
+      {"name": "users", "description": "List users.\nResults are paginated.", "args": [
+        {"name": "first", "type": {"kind": "SCALAR", "name": "Int"}, "defaultValue": "20"},
+        {"name": "after", "type": {"kind": "SCALAR", "name": "String"}},
+        {"name": "role", "type": {"kind": "ENUM", "name": "Role"}}],
+       "type": {"kind": "NON_NULL", "ofType": {"kind": "OBJECT", "name": "UserConnection"}}},
+      {"name": "search", "args": [
+        {"name": "term", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "String"}}},
+        {"name": "ids", "type": {"kind": "LIST", "ofType": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "ID"}}}}],
+       "type": {"kind": "LIST", "ofType": {"kind": "UNION", "name": "SearchResult"}}},
+      {"name": "legacy", "args": [], "isDeprecated": true, "type": {"kind": "SCALAR", "name": "String"}}
+    ]},
+    {"kind": "OBJECT", "name": "Mutation", "fields": [
+      {"name": "createRepository", "args": [
+        {"name": "input", "type": {"kind": "NON_NULL", "ofType": {"kind": "INPUT_OBJECT", "name": "CreateRepositoryInput"}}}],
+       "type": {"kind": "OBJECT", "name": "Repository"}}
+    ]},
+    {"kind": "OBJECT", "name": "Subscription", "fields": [
+      {"name": "events", "args": [], "type": {"kind": "SCALAR", "name": "String"}}
+    ]},
+    {"kind": "OBJECT", "name": "User", "fields": [
+      {"name": "login", "args": [], "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "String"}}},
+      {"name": "role", "args": [], "type": {"kind": "ENUM", "name": "Role"}},
+      {"name": "followers", "args": [{"name": "first", "type": {"kind": "SCALAR", "name": "Int"}}],
+       "type": {"kind": "NON_NULL", "ofType": {"kind": "OBJECT", "name": "UserConnection"}}},
+      {"name": "repository", "args": [{"name": "name", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "String"}}}],
+       "type": {"kind": "OBJECT", "name": "Repository"}},
+      {"name": "bestFriend", "args": [], "type": {"kind": "OBJECT", "name": "User"}}
+    ]},
+    {"kind": "OBJECT", "name": "Repository", "fields": [
+      {"name": "name", "args": [], "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "String"}}},
+      {"name": "owner", "args": [], "type": {"kind": "NON_NULL", "ofType": {"kind": "OBJECT", "name": "User"}}}
+    ]},
+    {"kind": "OBJECT", "name": "UserConnection", "fields": [
+      {"name": "totalCount", "args": [], "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "Int"}}},
+      {"name": "pageInfo", "args": [], "type": {"kind": "NON_NULL", "ofType": {"kind": "OBJECT", "name": "PageInfo"}}},
+      {"name": "nodes", "args": [], "type": {"kind": "LIST", "ofType": {"kind": "OBJECT", "name": "User"}}}
+    ]},
+    {"kind": "OBJECT", "name": "PageInfo", "fields": [
+      {"name": "hasNextPage", "args": [], "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "Boolean"}}},
+      {"name": "hasPreviousPage", "args": [], "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "Boolean"}}},
+      {"name": "endCursor", "args": [], "type": {"kind": "SCALAR", "name": "String"}}
+    ]},
+    {"kind": "UNION", "name": "SearchResult", "possibleTypes": [{"kind": "OBJECT", "name": "User"}, {"kind": "OBJECT", "name": "Repository"}]},
+    {"kind": "ENUM", "name": "Role", "enumValues": [{"name": "ADMIN"}, {"name": "MEMBER"}, {"name": "OWNER", "isDeprecated": true}]},
+    {"kind": "INPUT_OBJECT", "name": "CreateRepositoryInput", "inputFields": [
+      {"name": "name", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "String"}}}
+    ]},
+    {"kind": "SCALAR", "name": "String"},
+    {"kind": "SCALAR", "name": "Int"},
+    {"kind": "SCALAR", "name": "Boolean"},
+    {"kind": "SCALAR", "name": "ID"}
+  ]
+}}}`
+
+func TestGraphQLLoaderConvertsIntrospection(t *testing.T) {
+	loaded, err := load("application/json", []byte(graphQLIntrospection), DefaultLoaders())
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	set, err := loaded.OperationSet(OperationOptions{BaseURL: "https://api.example.com/graphql"})
+	if err != nil {
+		t.Fatalf("operations: %v", err)
+	}
+	if !strings.Contains(strings.Join(set.Warnings, "\n"), "skipped 1 subscription fields") {
+		t.Fatalf("warnings = %v", set.Warnings)
+	}
+
+	viewer := operationByID(t, set.Operations, "viewer")
+	if viewer.Method != "POST" || viewer.Summary != "The signed-in user." || !reflect.DeepEqual(viewer.Tags, []string{"query"}) {
+		t.Fatalf("viewer = %s %q %v", viewer.Method, viewer.Summary, viewer.Tags)
+	}
+	wantViewer := `query viewer {
+  viewer {
+    login
+    role
+    bestFriend {
+      login
+      role
+      bestFriend {
+        login
+        role
+      }
+    }
+  }
+}
+`
+	if viewer.GraphQL == nil || viewer.GraphQL.Type != "query" || viewer.GraphQL.Field != "viewer" || viewer.GraphQL.Document != wantViewer {
+		t.Fatalf("viewer GraphQL = %#v", viewer.GraphQL)
+	}
+
+	users := operationByID(t, set.Operations, "users")
+	if users.Summary != "List users." || len(users.Parameters) != 3 {
+		t.Fatalf("users = %q %#v", users.Summary, users.Parameters)
+	}
+	first, role := users.Parameters[0], users.Parameters[2]
+	if first.In != "query" || first.Type != "integer" || first.Required || !strings.Contains(first.Desc, "(default: 20)") {
+		t.Fatalf("first param = %#v", first)
+	}
+	if !reflect.DeepEqual(role.Enum, []string{"ADMIN", "MEMBER"}) {
+		t.Fatalf("role enum = %v", role.Enum)
+	}
+	wantUsers := `query users($first: Int, $after: String, $role: Role) {
+  users(first: $first, after: $after, role: $role) {
+    totalCount
+    pageInfo {
+      hasNextPage
+      endCursor
+    }
+    nodes {
+      login
+      role
+      bestFriend {
+        login
+        role
+        bestFriend {
+          login
+          role
+        }
+      }
+    }
+  }
+}
+`
+	if users.GraphQL.Document != wantUsers {
+		t.Fatalf("users document:\n%s", users.GraphQL.Document)
+	}
+	if !reflect.DeepEqual(users.GraphQL.Variables, map[string]string{"first": "Int", "after": "String", "role": "Role"}) {
+		t.Fatalf("users variables = %#v", users.GraphQL.Variables)
+	}
+
+	search := operationByID(t, set.Operations, "search")
+	if !search.Parameters[0].Required || search.Parameters[1].Type != "array" || search.GraphQL.Variables["ids"] != "[ID!]" {
+		t.Fatalf("search params = %#v %#v", search.Parameters, search.GraphQL.Variables)
+	}
+	if !strings.Contains(search.GraphQL.Document, "__typename\n    ... on Repository {\n      name\n    }\n    ... on User {\n      login\n      role\n    }") {
+		t.Fatalf("search union selection:\n%s", search.GraphQL.Document)
+	}
+	if legacy := operationByID(t, set.Operations, "legacy"); !legacy.Deprecated || strings.Contains(legacy.GraphQL.Document, "{\n    ") {
+		t.Fatalf("legacy = %#v", legacy)
+	}
+
+	create := operationByID(t, set.Operations, "createRepository")
+	if create.GraphQL.Type != "mutation" || !create.Parameters[0].Required || !strings.Contains(create.Parameters[0].Desc, "GraphQL input CreateRepositoryInput!") {
+		t.Fatalf("createRepository = %#v", create)
+	}
+	if !strings.Contains(create.GraphQL.Document, "mutation createRepository($input: CreateRepositoryInput!)") {
+		t.Fatalf("createRepository document:\n%s", create.GraphQL.Document)
+	}
+}
+
+func TestGraphQLLoaderDetect(t *testing.T) {
+	bare := strings.Replace(strings.TrimSuffix(graphQLIntrospection, "}"), `{"data": `, "", 1)
+	for name, body := range map[string]string{"wrapped": graphQLIntrospection, "bare": bare} {
+		if !(GraphQLLoader{}).Detect("application/json", []byte(body)) {
+			t.Fatalf("%s introspection result not detected", name)
+		}
+	}
+	openapi := `{"openapi": "3.0.3", "info": {"title": "x", "version": "1"}, "paths": {}, "x-note": "mentions \"__schema\""}`
+	if (GraphQLLoader{}).Detect("application/json", []byte(openapi)) {
+		t.Fatal("OpenAPI document mentioning __schema must not be detected")
+	}
+}
+
+func TestDiscoverIntrospectsGraphQLEndpoint(t *testing.T) {
+	var posts []string
+	post := func(ctx context.Context, rawURL, contentType string, body []byte, tr http.RoundTripper) (*http.Response, error) {
+		posts = append(posts, rawURL)
+		if contentType != "application/json" || !strings.Contains(string(body), "IntrospectionQuery") {
+			return nil, fmt.Errorf("unexpected introspection request %s %s", contentType, body)
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Status:     "200 OK",
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(graphQLIntrospection)),
+		}, nil
+	}
+	fetch := func(ctx context.Context, rawURL string, tr http.RoundTripper) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusNotFound, Status: "404 Not Found", Body: io.NopCloser(strings.NewReader(""))}, nil
+	}
+	cfg := DiscoverConfig{
+		APIName:  "gql",
+		BaseURL:  "https://api.example.com/graphql",
+		CacheDir: t.TempDir(),
+		Version:  "v2",
+		Fetch:    fetch,
+		Post:     post,
+	}
+	loaded, err := Discover(context.Background(), cfg, DefaultLoaders())
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+	if loaded.SourceURL != "https://api.example.com/graphql" || !reflect.DeepEqual(posts, []string{"https://api.example.com/graphql"}) {
+		t.Fatalf("SourceURL = %q, posts = %v", loaded.SourceURL, posts)
+	}
+
+	// A saved spec_url pointing at the endpoint is introspected on sync.
+	cfg.SpecURL = loaded.SourceURL
+	cfg.ForceRefresh = true
+	if _, err := Discover(context.Background(), cfg, DefaultLoaders()); err != nil || len(posts) != 2 {
+		t.Fatalf("Discover with spec_url: %v, posts = %v", err, posts)
+	}
+
+	post = func(context.Context, string, string, []byte, http.RoundTripper) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Status:     "200 OK",
+			Body:       io.NopCloser(strings.NewReader(`{"errors": [{"message": "introspection is disabled"}]}`)),
+		}, nil
+	}
+	cfg.Post = post
+	if _, err := Discover(context.Background(), cfg, DefaultLoaders()); err == nil || !strings.Contains(err.Error(), "introspection is disabled") {
+		t.Fatalf("disabled introspection error = %v", err)
+	}
+}
@@ -0,0 +1,1028 @@
+package spec
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// ODataLoader loads OData v4 CSDL documents, the XML a service returns from
+// its $metadata resource, by converting them to OpenAPI 3.1. Each entity set
+// becomes list, create, get, update, and delete operations under its
+// resource path, with the OData system query options as flags; singletons,
+// function imports, and action imports become operations of their own, and
+// $batch becomes an operation that sends and reads multipart/mixed batches.
+// Raw holds the converted document, so cached specs reload without the OData
+// loader.
+type ODataLoader struct{}
+
+func (ODataLoader) Priority() int { return 20 }
+
+// Detect returns true for CSDL XML documents of any OData version, so older
+// versions fail with a clear error instead of as unknown documents.
+func (ODataLoader) Detect(contentType string, body []byte) bool {
+	_, ok := parseCSDL(body)
+	return ok
+}
+
+// LoadWithOptions converts a CSDL document to OpenAPI and loads the result.
+// Conversion warnings are reported with the operation warnings.
+func (ODataLoader) LoadWithOptions(body []byte, opts LoadOptions) (*APISpec, error) {
+	doc, ok := parseCSDL(body)
+	if !ok {
+		return nil, &LoadError{Errors: []string{"OData $metadata: not a CSDL document"}}
+	}
+	if !strings.HasPrefix(doc.Version, "4.") {
+		return nil, &LoadError{Errors: []string{fmt.Sprintf("OData $metadata: CSDL version %q is not supported; only OData v4 services can be loaded", doc.Version)}}
+	}
+	raw, warnings, err := newODataConverter(doc).openAPI()
+	if err != nil {
+		return nil, &LoadError{Errors: []string{fmt.Sprintf("OData $metadata: %v", err)}}
+	}
+	opts.ContentType = "application/yaml"
+	loaded, err := OpenAPILoader{}.LoadWithOptions(raw, opts)
+	if err != nil {
+		return nil, err
+	}
+	loaded.ContentType = "application/yaml"
+	for _, warning := range warnings {
+		loaded.loadWarnings = append(loaded.loadWarnings, "OData conversion: "+warning)
+	}
+	return loaded, nil
+}
+
+const (
+	odataCoreNamespace         = "Org.OData.Core.V1"
+	odataCapabilitiesNamespace = "Org.OData.Capabilities.V1"
+)
+
+// The CSDL XML elements the converter reads. Element names match in any
+// namespace, so the edmx and edm prefixes do not matter.
+type csdlEdmx struct {
+	XMLName    xml.Name
+	Version    string          `xml:"Version,attr"`
+	References []csdlReference `xml:"Reference"`
+	Schemas    []csdlSchema    `xml:"DataServices>Schema"`
+}
+
+type csdlReference struct {
+	Includes []struct {
+		Namespace string `xml:"Namespace,attr"`
+		Alias     string `xml:"Alias,attr"`
+	} `xml:"Include"`
+}
+
+type csdlSchema struct {
+	Namespace       string            `xml:"Namespace,attr"`
+	Alias           string            `xml:"Alias,attr"`
+	EntityTypes     []csdlStructured  `xml:"EntityType"`
+	ComplexTypes    []csdlStructured  `xml:"ComplexType"`
+	EnumTypes       []csdlEnum        `xml:"EnumType"`
+	TypeDefinitions []csdlTypeDef     `xml:"TypeDefinition"`
+	Functions       []csdlOperation   `xml:"Function"`
+	Actions         []csdlOperation   `xml:"Action"`
+	Containers      []csdlContainer   `xml:"EntityContainer"`
+	Annotations     []csdlAnnotations `xml:"Annotations"`
+}
+
+type csdlStructured struct {
+	Name                 string            `xml:"Name,attr"`
+	BaseType             string            `xml:"BaseType,attr"`
+	Key                  []csdlPropertyRef `xml:"Key>PropertyRef"`
+	Properties           []csdlProperty    `xml:"Property"`
+	NavigationProperties []csdlProperty    `xml:"NavigationProperty"`
+	Annotations          []csdlAnnotation  `xml:"Annotation"`
+}
+
+type csdlPropertyRef struct {
+	Name string `xml:"Name,attr"`
+}
+
+type csdlProperty struct {
+	Name        string           `xml:"Name,attr"`
+	Type        string           `xml:"Type,attr"`
+	Annotations []csdlAnnotation `xml:"Annotation"`
+}
+
+type csdlEnum struct {
+	Name    string `xml:"Name,attr"`
+	IsFlags bool   `xml:"IsFlags,attr"`
+	Members []struct {
+		Name string `xml:"Name,attr"`
+	} `xml:"Member"`
+	Annotations []csdlAnnotation `xml:"Annotation"`
+}
+
+type csdlTypeDef struct {
+	Name           string `xml:"Name,attr"`
+	UnderlyingType string `xml:"UnderlyingType,attr"`
+}
+
+type csdlOperation struct {
+	Name        string           `xml:"Name,attr"`
+	IsBound     bool             `xml:"IsBound,attr"`
+	Parameters  []csdlProperty   `xml:"Parameter"`
+	ReturnType  *csdlProperty    `xml:"ReturnType"`
+	Annotations []csdlAnnotation `xml:"Annotation"`
+}
+
+type csdlContainer struct {
+	Name            string           `xml:"Name,attr"`
+	EntitySets      []csdlEntitySet  `xml:"EntitySet"`
+	Singletons      []csdlEntitySet  `xml:"Singleton"`
+	FunctionImports []csdlImport     `xml:"FunctionImport"`
+	ActionImports   []csdlImport     `xml:"ActionImport"`
+	Annotations     []csdlAnnotation `xml:"Annotation"`
+}
+
+// csdlEntitySet is an entity set or a singleton; sets name their type in
+// EntityType and singletons in Type.
+type csdlEntitySet struct {
+	Name        string           `xml:"Name,attr"`
+	EntityType  string           `xml:"EntityType,attr"`
+	Type        string           `xml:"Type,attr"`
+	Annotations []csdlAnnotation `xml:"Annotation"`
+}
+
+type csdlImport struct {
+	Name        string           `xml:"Name,attr"`
+	Function    string           `xml:"Function,attr"`
+	Action      string           `xml:"Action,attr"`
+	Annotations []csdlAnnotation `xml:"Annotation"`
+}
+
+type csdlAnnotations struct {
+	Target      string           `xml:"Target,attr"`
+	Annotations []csdlAnnotation `xml:"Annotation"`
+}
+
+type csdlAnnotation struct {
+	Term         string `xml:"Term,attr"`
+	StringAttr   string `xml:"String,attr"`
+	StringElem   string `xml:"String"`
+	RecordValues []struct {
+		Property string `xml:"Property,attr"`
+		BoolAttr string `xml:"Bool,attr"`
+		BoolElem string `xml:"Bool"`
+	} `xml:"Record>PropertyValue"`
+}
+
+// parseCSDL decodes a CSDL document. A cheap byte check guards the XML decode
+// so other documents are not parsed twice.
+func parseCSDL(body []byte) (*csdlEdmx, bool) {
+	if !bytes.Contains(body, []byte("Edmx")) {
+		return nil, false
+	}
+	var doc csdlEdmx
+	if xml.Unmarshal(body, &doc) != nil || doc.XMLName.Local != "Edmx" {
+		return nil, false
+	}
+	return &doc, true
+}
+
+type odataConverter struct {
+	doc         *csdlEdmx
+	aliases     map[string]string
+	structured  map[string]*csdlStructured
+	entityTypes map[string]bool
+	enums       map[string]*csdlEnum
+	typeDefs    map[string]string
+	functions   map[string][]*csdlOperation
+	actions     map[string][]*csdlOperation
+	annotations map[string][]csdlAnnotation
+
+	schemas      *yaml.Node
+	seen         map[string]bool
+	operationIDs map[string]bool
+	warnings     []string
+}
+
+func newODataConverter(doc *csdlEdmx) *odataConverter {
+	c := &odataConverter{
+		doc:          doc,
+		aliases:      map[string]string{},
+		structured:   map[string]*csdlStructured{},
+		entityTypes:  map[string]bool{},
+		enums:        map[string]*csdlEnum{},
+		typeDefs:     map[string]string{},
+		functions:    map[string][]*csdlOperation{},
+		actions:      map[string][]*csdlOperation{},
+		annotations:  map[string][]csdlAnnotation{},
+		schemas:      yamlMapping(),
+		seen:         map[string]bool{},
+		operationIDs: map[string]bool{},
+	}
+	for _, ref := range doc.References {
+		for _, include := range ref.Includes {
+			if include.Alias != "" {
+				c.aliases[include.Alias] = include.Namespace
+			}
+		}
+	}
+	for i := range doc.Schemas {
+		if schema := &doc.Schemas[i]; schema.Alias != "" {
+			c.aliases[schema.Alias] = schema.Namespace
+		}
+	}
+	for i := range doc.Schemas {
+		schema := &doc.Schemas[i]
+		ns := schema.Namespace + "."
+		for j := range schema.EntityTypes {
+			c.structured[ns+schema.EntityTypes[j].Name] = &schema.EntityTypes[j]
+			c.entityTypes[ns+schema.EntityTypes[j].Name] = true
+		}
+		for j := range schema.ComplexTypes {
+			c.structured[ns+schema.ComplexTypes[j].Name] = &schema.ComplexTypes[j]
+		}
+		for j := range schema.EnumTypes {
+			c.enums[ns+schema.EnumTypes[j].Name] = &schema.EnumTypes[j]
+		}
+		for _, def := range schema.TypeDefinitions {
+			c.typeDefs[ns+def.Name] = def.UnderlyingType
+		}
+		for j := range schema.Functions {
+			c.functions[ns+schema.Functions[j].Name] = append(c.functions[ns+schema.Functions[j].Name], &schema.Functions[j])
+		}
+		for j := range schema.Actions {
+			c.actions[ns+schema.Actions[j].Name] = append(c.actions[ns+schema.Actions[j].Name], &schema.Actions[j])
+		}
+		for _, group := range schema.Annotations {
+			target := c.qualify(group.Target)
+			c.annotations[target] = append(c.annotations[target], group.Annotations...)
+		}
+	}
+	return c
+}
+
+// qualify replaces a leading schema alias in a qualified name or annotation
+// target with its namespace.
+func (c *odataConverter) qualify(name string) string {
+	for alias, namespace := range c.aliases {
+		if strings.HasPrefix(name, alias+".") {
+			return namespace + strings.TrimPrefix(name, alias)
+		}
+	}
+	return name
+}
+
+// annotation returns the first annotation with the given namespace and term
+// name, looking at inline annotations and then at Annotations elements
+// aimed at target.
+func (c *odataConverter) annotation(target string, inline []csdlAnnotation, namespace, term string) *csdlAnnotation {
+	for _, list := range [][]csdlAnnotation{inline, c.annotations[target]} {
+		for i := range list {
+			if c.qualify(list[i].Term) == namespace+"."+term {
+				return &list[i]
+			}
+		}
+	}
+	return nil
+}
+
+func (c *odataConverter) description(target string, inline []csdlAnnotation) string {
+	a := c.annotation(target, inline, odataCoreNamespace, "Description")
+	if a == nil {
+		return ""
+	}
+	if a.StringAttr != "" {
+		return a.StringAttr
+	}
+	return strings.TrimSpace(a.StringElem)
+}
+
+// restricted reports whether a Capabilities restriction, such as
+// InsertRestrictions, sets its property, such as Insertable, to false.
+func (c *odataConverter) restricted(target string, inline []csdlAnnotation, term, property string) bool {
+	a := c.annotation(target, inline, odataCapabilitiesNamespace, term)
+	if a == nil {
+		return false
+	}
+	for _, value := range a.RecordValues {
+		if value.Property == property {
+			return strings.TrimSpace(value.BoolAttr+value.BoolElem) == "false"
+		}
+	}
+	return false
+}
+
+func (c *odataConverter) openAPI() ([]byte, []string, error) {
+	doc := yamlMapping()
+	yamlSet(doc, "openapi", yamlScalar("3.1.0"))
+	paths := yamlMapping()
+	tags := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	var title string
+
+	for i := range c.doc.Schemas {
+		schema := &c.doc.Schemas[i]
+		for j := range schema.Containers {
+			container := &schema.Containers[j]
+			if title == "" {
+				title = container.Name
+			}
+			prefix := schema.Namespace + "." + container.Name + "/"
+
+			// Item operations are named after the entity type unless several
+			// sets share it.
+			setsPerType := map[string]int{}
+			for _, set := range container.EntitySets {
+				setsPerType[c.qualify(set.EntityType)]++
+			}
+			for k := range container.EntitySets {
+				set := &container.EntitySets[k]
+				typeName := c.qualify(set.EntityType)
+				entity := c.structured[typeName]
+				if entity == nil || !c.entityTypes[typeName] {
+					c.warnings = append(c.warnings, fmt.Sprintf("entity set %s: unknown entity type %q; skipped", set.Name, set.EntityType))
+					continue
+				}
+				itemName := odataLocalName(typeName)
+				if setsPerType[typeName] > 1 {
+					itemName = set.Name
+				}
+				tags.Content = append(tags.Content, odataTag(set.Name, c.description(prefix+set.Name, set.Annotations)))
+				c.entitySet(paths, prefix+set.Name, set, typeName, itemName)
+			}
+			for k := range container.Singletons {
+				singleton := &container.Singletons[k]
+				typeName := c.qualify(singleton.Type)
+				if c.structured[typeName] == nil {
+					c.warnings = append(c.warnings, fmt.Sprintf("singleton %s: unknown type %q; skipped", singleton.Name, singleton.Type))
+					continue
+				}
+				tags.Content = append(tags.Content, odataTag(singleton.Name, c.description(prefix+singleton.Name, singleton.Annotations)))
+				c.singleton(paths, prefix+singleton.Name, singleton, typeName)
+			}
+			for k := range container.FunctionImports {
+				c.functionImport(paths, prefix, &container.FunctionImports[k])
+			}
+			for k := range container.ActionImports {
+				c.actionImport(paths, prefix, &container.ActionImports[k])
+			}
+		}
+	}
+	if len(paths.Content) == 0 {
+		return nil, nil, fmt.Errorf("no entity container with entity sets, singletons, or operation imports")
+	}
+	bound := 0
+	for _, overloads := range c.functions {
+		for _, op := range overloads {
+			if op.IsBound {
+				bound++
+			}
+		}
+	}
+	for _, overloads := range c.actions {
+		for _, op := range overloads {
+			if op.IsBound {
+				bound++
+			}
+		}
+	}
+	if bound > 0 {
+		c.warnings = append(c.warnings, fmt.Sprintf("skipped %d bound functions and actions; call them with a plain request on the bound resource", bound))
+	}
+	batch := yamlMapping()
+	yamlSet(batch, "post", c.batchOperation())
+	yamlSet(paths, "/$batch", batch)
+
+	info := yamlMapping()
+	if title == "" {
+		title = "OData service"
+	}
+	yamlSet(info, "title", yamlScalar(title))
+	yamlSet(info, "version", yamlScalar(c.doc.Version))
+	yamlSet(doc, "info", info)
+	if len(tags.Content) > 0 {
+		yamlSet(doc, "tags", tags)
+	}
+	yamlSet(doc, "paths", paths)
+	if len(c.schemas.Content) > 0 {
+		components := yamlMapping()
+		yamlSet(components, "schemas", c.schemas)
+		yamlSet(doc, "components", components)
+	}
+
+	out, err := yaml.Marshal(doc)
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, c.warnings, nil
+}
+
+// entitySet adds the collection and entity operations of one entity set.
+// Capabilities restrictions drop the operations a set does not allow.
+func (c *odataConverter) entitySet(paths *yaml.Node, target string, set *csdlEntitySet, typeName, itemName string) {
+	collection := yamlMapping()
+	if !c.restricted(target, set.Annotations, "ReadRestrictions", "Readable") {
+		op := c.operation(set.Name, "list"+set.Name, "List "+set.Name, c.description(target, set.Annotations))
+		yamlSet(op, "parameters", c.queryOptions(typeName, true))
+		yamlSet(op, "responses", odataResponses("200", set.Name, c.collectionSchema(c.typeSchema(typeName))))
+		yamlSet(collection, "get", op)
+	}
+	if !c.restricted(target, set.Annotations, "InsertRestrictions", "Insertable") {
+		op := c.operation(set.Name, "create"+itemName, "Create "+odataArticle(odataLocalName(typeName))+" in "+set.Name, "")
+		yamlSet(op, "requestBody", odataRequestBody(c.typeSchema(typeName)))
+		yamlSet(op, "responses", odataResponses("201", "Created "+odataLocalName(typeName), c.typeSchema(typeName)))
+		yamlSet(collection, "post", op)
+	}
+	if len(collection.Content) > 0 {
+		yamlSet(paths, "/"+set.Name, collection)
+	}
+
+	keys := c.keys(typeName)
+	if len(keys) == 0 {
+		c.warnings = append(c.warnings, fmt.Sprintf("entity set %s: entity type %s has no key; skipped entity operations", set.Name, typeName))
+		return
+	}
+	path, params := c.keyPath(set.Name, keys)
+	entity := yamlMapping()
+	yamlSet(entity, "parameters", params)
+	local := odataLocalName(typeName)
+	if !c.restricted(target, set.Annotations, "ReadRestrictions", "Readable") {
+		op := c.operation(set.Name, "get"+itemName, "Get "+odataArticle(local)+" from "+set.Name, "")
+		yamlSet(op, "parameters", c.queryOptions(typeName, false))
+		yamlSet(op, "responses", odataResponses("200", local, c.typeSchema(typeName)))
+		yamlSet(entity, "get", op)
+	}
+	if !c.restricted(target, set.Annotations, "UpdateRestrictions", "Updatable") {
+		op := c.operation(set.Name, "update"+itemName, "Update "+odataArticle(local)+" in "+set.Name, "Sends the given properties as a PATCH; other properties keep their values.")
+		yamlSet(op, "requestBody", odataRequestBody(c.typeSchema(typeName)))
+		yamlSet(op, "responses", odataResponses("204", "Updated", nil))
+		yamlSet(entity, "patch", op)
+	}
+	if !c.restricted(target, set.Annotations, "DeleteRestrictions", "Deletable") {
+		op := c.operation(set.Name, "delete"+itemName, "Delete "+odataArticle(local)+" from "+set.Name, "")
+		yamlSet(op, "responses", odataResponses("204", "Deleted", nil))
+		yamlSet(entity, "delete", op)
+	}
+	if len(entity.Content) > 2 {
+		yamlSet(paths, path, entity)
+	}
+}
+
+func (c *odataConverter) singleton(paths *yaml.Node, target string, singleton *csdlEntitySet, typeName string) {
+	item := yamlMapping()
+	op := c.operation(singleton.Name, "get"+singleton.Name, "Get "+singleton.Name, c.description(target, singleton.Annotations))
+	yamlSet(op, "parameters", c.queryOptions(typeName, false))
+	yamlSet(op, "responses", odataResponses("200", singleton.Name, c.typeSchema(typeName)))
+	yamlSet(item, "get", op)
+	if !c.restricted(target, singleton.Annotations, "UpdateRestrictions", "Updatable") {
+		op := c.operation(singleton.Name, "update"+singleton.Name, "Update "+singleton.Name, "Sends the given properties as a PATCH; other properties keep their values.")
+		yamlSet(op, "requestBody", odataRequestBody(c.typeSchema(typeName)))
+		yamlSet(op, "responses", odataResponses("204", "Updated", nil))
+		yamlSet(item, "patch", op)
+	}
+	yamlSet(paths, "/"+singleton.Name, item)
+}
+
+// functionImport adds a GET operation that passes the function's parameters
+// inline, as in /GetNearestAirport(lat={lat},lon={lon}). Functions with
+// structured or collection parameters need parameter aliases and are skipped.
+func (c *odataConverter) functionImport(paths *yaml.Node, prefix string, imp *csdlImport) {
+	fn := c.unbound(c.functions[c.qualify(imp.Function)])
+	if fn == nil {
+		c.warnings = append(c.warnings, fmt.Sprintf("function import %s: unknown unbound function %q; skipped", imp.Name, imp.Function))
+		return
+	}
+	params := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	inline := make([]string, 0, len(fn.Parameters))
+	for _, p := range fn.Parameters {
+		if !c.primitive(p.Type) {
+			c.warnings = append(c.warnings, fmt.Sprintf("function import %s: parameter %s has non-primitive type %s; skipped", imp.Name, p.Name, p.Type))
+			return
+		}
+		inline = append(inline, p.Name+"="+c.keyLiteral(p.Type, p.Name))
+		params.Content = append(params.Content, c.pathParam(p.Name, p.Type, c.description("", p.Annotations)))
+	}
+	op := c.operation("", imp.Name, "Call function "+imp.Name, c.description(prefix+imp.Name, append(imp.Annotations, fn.Annotations...)))
+	if len(params.Content) > 0 {
+		yamlSet(op, "parameters", params)
+	}
+	yamlSet(op, "responses", odataResponses("200", imp.Name+" result", c.returnSchema(fn.ReturnType)))
+	item := yamlMapping()
+	yamlSet(item, "get", op)
+	yamlSet(paths, "/"+imp.Name+"("+strings.Join(inline, ",")+")", item)
+}
+
+// actionImport adds a POST operation whose body holds the action parameters.
+func (c *odataConverter) actionImport(paths *yaml.Node, prefix string, imp *csdlImport) {
+	action := c.unbound(c.actions[c.qualify(imp.Action)])
+	if action == nil {
+		c.warnings = append(c.warnings, fmt.Sprintf("action import %s: unknown unbound action %q; skipped", imp.Name, imp.Action))
+		return
+	}
+	op := c.operation("", imp.Name, "Call action "+imp.Name, c.description(prefix+imp.Name, append(imp.Annotations, action.Annotations...)))
+	if len(action.Parameters) > 0 {
+		body := yamlMapping()
+		yamlSet(body, "type", yamlScalar("object"))
+		props := yamlMapping()
+		for _, p := range action.Parameters {
+			schema := c.typeSchema(p.Type)
+			if desc := c.description("", p.Annotations); desc != "" {
+				schema = odataDescribed(schema, desc)
+			}
+			yamlSet(props, p.Name, schema)
+		}
+		yamlSet(body, "properties", props)
+		yamlSet(op, "requestBody", odataRequestBody(body))
+	}
+	if action.ReturnType != nil {
+		yamlSet(op, "responses", odataResponses("200", imp.Name+" result", c.returnSchema(action.ReturnType)))
+	} else {
+		yamlSet(op, "responses", odataResponses("204", "Done", nil))
+	}
+	item := yamlMapping()
+	yamlSet(item, "post", op)
+	yamlSet(paths, "/"+imp.Name, item)
+}
+
+func (c *odataConverter) unbound(overloads []*csdlOperation) *csdlOperation {
+	for _, op := range overloads {
+		if !op.IsBound {
+			return op
+		}
+	}
+	return nil
+}
+
+// batchOperation describes POST $batch. The body and result use the shape of
+// the OData 4.01 JSON batch format, which the multipart/mixed content type
+// converts to and from the wire format every v4 service accepts.
+func (c *odataConverter) batchOperation() *yaml.Node {
+	op := c.operation("", "batch", "Send several requests in one batch", "Each request names a method and a URL relative to the service root. Requests that share an atomicityGroup form a change set that succeeds or fails as a whole.")
+	request := yamlMapping()
+	yamlSet(request, "type", yamlScalar("object"))
+	requestProps := yamlMapping()
+	for _, name := range []string{"id", "method", "url", "atomicityGroup"} {
+		yamlSet(requestProps, name, protobufScalarSchema("string", ""))
+	}
+	yamlSet(requestProps, "headers", odataObjectSchema())
+	yamlSet(requestProps, "body", yamlMapping())
+	yamlSet(request, "properties", requestProps)
+	required := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Style: yaml.FlowStyle}
+	required.Content = append(required.Content, yamlScalar("url"))
+	yamlSet(request, "required", required)
+	body := yamlMapping()
+	yamlSet(body, "type", yamlScalar("object"))
+	bodyProps := yamlMapping()
+	yamlSet(bodyProps, "requests", odataArray(request))
+	yamlSet(body, "properties", bodyProps)
+	yamlSet(op, "requestBody", odataMediaBody("multipart/mixed", body))
+
+	response := yamlMapping()
+	yamlSet(response, "type", yamlScalar("object"))
+	responseProps := yamlMapping()
+	yamlSet(responseProps, "id", protobufScalarSchema("string", ""))
+	yamlSet(responseProps, "status", protobufScalarSchema("integer", ""))
+	yamlSet(responseProps, "headers", odataObjectSchema())
+	yamlSet(responseProps, "body", yamlMapping())
+	yamlSet(responseProps, "atomicityGroup", protobufScalarSchema("string", ""))
+	yamlSet(response, "properties", responseProps)
+	result := yamlMapping()
+	yamlSet(result, "type", yamlScalar("object"))
+	resultProps := yamlMapping()
+	yamlSet(resultProps, "responses", odataArray(response))
+	yamlSet(result, "properties", resultProps)
+	ok := yamlMapping()
+	yamlSet(ok, "description", yamlScalar("Batch responses"))
+	content := yamlMapping()
+	media := yamlMapping()
+	yamlSet(media, "schema", result)
+	yamlSet(content, "multipart/mixed", media)
+	yamlSet(ok, "content", content)
+	responses := yamlMapping()
+	yamlSet(responses, "200", ok)
+	yamlSet(op, "responses", responses)
+	return op
+}
+
+func (c *odataConverter) operation(tag, operationID, summary, description string) *yaml.Node {
+	op := yamlMapping()
+	if tag != "" {
+		tags := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		tags.Content = append(tags.Content, yamlScalar(tag))
+		yamlSet(op, "tags", tags)
+	}
+	yamlSet(op, "summary", yamlScalar(summary))
+	if description != "" {
+		yamlSet(op, "description", yamlScalar(description))
+	}
+	for id, n := operationID, 2; ; n++ {
+		if !c.operationIDs[id] {
+			operationID = id
+			break
+		}
+		id = fmt.Sprintf("%s%d", operationID, n)
+	}
+	c.operationIDs[operationID] = true
+	yamlSet(op, "operationId", yamlScalar(operationID))
+	return op
+}
+
+// queryOptions returns the system query option parameters. Collections get
+// the full set; single entities only $select and $expand. Each is exposed as
+// a flag without the $ prefix through x-cli-name.
+func (c *odataConverter) queryOptions(typeName string, collection bool) *yaml.Node {
+	var properties, navigation []string
+	for _, p := range c.properties(typeName) {
+		properties = append(properties, p.Name)
+	}
+	for _, p := range c.navigationProperties(typeName) {
+		navigation = append(navigation, p.Name)
+	}
+	params := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	list := func(name, desc string, values []string) {
+		items := protobufScalarSchema("string", "")
+		if len(values) > 0 {
+			enum := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Style: yaml.FlowStyle}
+			for _, value := range values {
+				enum.Content = append(enum.Content, yamlScalar(value))
+			}
+			yamlSet(items, "enum", enum)
+		}
+		param := odataQueryParam(name, desc, odataArray(items))
+		yamlSet(param, "style", yamlScalar("form"))
+		yamlSet(param, "explode", yamlBool(false))
+		params.Content = append(params.Content, param)
+	}
+	list("$select", "Properties to return", properties)
+	if len(navigation) > 0 {
+		list("$expand", "Related entities to include inline", navigation)
+	}
+	if !collection {
+		return params
+	}
+	params.Content = append(params.Content, odataQueryParam("$filter", "Filter expression, such as \"Price lt 10 and contains(Name,'red')\"", protobufScalarSchema("string", "")))
+	orderBy := protobufScalarSchema("string", "")
+	params.Content = append(params.Content, odataQueryParam("$orderby", "Sort order, such as \"Name desc\"", odataArray(orderBy)))
+	yamlSet(params.Content[len(params.Content)-1], "style", yamlScalar("form"))
+	yamlSet(params.Content[len(params.Content)-1], "explode", yamlBool(false))
+	top := protobufScalarSchema("integer", "")
+	yamlSet(top, "minimum", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: "0"})
+	params.Content = append(params.Content, odataQueryParam("$top", "Maximum number of entities to return", top))
+	params.Content = append(params.Content, odataQueryParam("$count", "Include the total number of matching entities as @odata.count", protobufScalarSchema("boolean", "")))
+	return params
+}
+
+func odataQueryParam(name, desc string, schema *yaml.Node) *yaml.Node {
+	param := yamlMapping()
+	yamlSet(param, "name", yamlScalar(name))
+	yamlSet(param, "in", yamlScalar("query"))
+	yamlSet(param, "description", yamlScalar(desc))
+	yamlSet(param, "schema", schema)
+	yamlSet(param, "x-cli-name", yamlScalar(strings.TrimPrefix(name, "$")))
+	return param
+}
+
+// keyPath returns the entity path template and key parameters, such as
+// /People('{UserName}') or /OrderItems(OrderID={OrderID},ItemNo={ItemNo}).
+func (c *odataConverter) keyPath(setName string, keys []csdlProperty) (string, *yaml.Node) {
+	params := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	if len(keys) == 1 {
+		params.Content = append(params.Content, c.pathParam(keys[0].Name, keys[0].Type, c.description("", keys[0].Annotations)))
+		return "/" + setName + "(" + c.keyLiteral(keys[0].Type, keys[0].Name) + ")", params
+	}
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		parts = append(parts, key.Name+"="+c.keyLiteral(key.Type, key.Name))
+		params.Content = append(params.Content, c.pathParam(key.Name, key.Type, c.description("", key.Annotations)))
+	}
+	return "/" + setName + "(" + strings.Join(parts, ",") + ")", params
+}
+
+// keyLiteral returns the URL literal template for a key or function
+// parameter: strings are quoted, other primitives are not.
+func (c *odataConverter) keyLiteral(typeName, name string) string {
+	switch c.underlying(typeName) {
+	case "Edm.String":
+		return "'{" + name + "}'"
+	}
+	if c.enums[c.qualify(typeName)] != nil {
+		return "'{" + name + "}'"
+	}
+	return "{" + name + "}"
+}
+
+func (c *odataConverter) pathParam(name, typeName, desc string) *yaml.Node {
+	param := yamlMapping()
+	yamlSet(param, "name", yamlScalar(name))
+	yamlSet(param, "in", yamlScalar("path"))
+	yamlSet(param, "required", yamlBool(true))
+	if desc != "" {
+		yamlSet(param, "description", yamlScalar(desc))
+	}
+	yamlSet(param, "schema", c.typeSchema(typeName))
+	return param
+}
+
+// keys returns the key properties of an entity type, inherited keys
+// included.
+func (c *odataConverter) keys(typeName string) []csdlProperty {
+	byName := map[string]csdlProperty{}
+	for _, p := range c.properties(typeName) {
+		byName[p.Name] = p
+	}
+	for t, depth := c.structured[typeName], 0; t != nil && depth < 32; t, depth = c.structured[c.qualify(t.BaseType)], depth+1 {
+		if len(t.Key) == 0 {
+			continue
+		}
+		keys := make([]csdlProperty, 0, len(t.Key))
+		for _, ref := range t.Key {
+			if p, ok := byName[ref.Name]; ok {
+				keys = append(keys, p)
+			}
+		}
+		return keys
+	}
+	return nil
+}
+
+// properties returns the structural properties of a type, base types first.
+func (c *odataConverter) properties(typeName string) []csdlProperty {
+	var chain []*csdlStructured
+	for t, depth := c.structured[typeName], 0; t != nil && depth < 32; t, depth = c.structured[c.qualify(t.BaseType)], depth+1 {
+		chain = append(chain, t)
+	}
+	var out []csdlProperty
+	for i := len(chain) - 1; i >= 0; i-- {
+		out = append(out, chain[i].Properties...)
+	}
+	return out
+}
+
+func (c *odataConverter) navigationProperties(typeName string) []csdlProperty {
+	var chain []*csdlStructured
+	for t, depth := c.structured[typeName], 0; t != nil && depth < 32; t, depth = c.structured[c.qualify(t.BaseType)], depth+1 {
+		chain = append(chain, t)
+	}
+	var out []csdlProperty
+	for i := len(chain) - 1; i >= 0; i-- {
+		out = append(out, chain[i].NavigationProperties...)
+	}
+	return out
+}
+
+// structuredSchema adds the component schema for an entity or complex type.
+func (c *odataConverter) structuredSchema(typeName string) {
+	if c.seen[typeName] {
+		return
+	}
+	c.seen[typeName] = true
+	t := c.structured[typeName]
+	schema := yamlMapping()
+	yamlSet(schema, "type", yamlScalar("object"))
+	if desc := c.description(typeName, t.Annotations); desc != "" {
+		yamlSet(schema, "description", yamlScalar(desc))
+	}
+	props := yamlMapping()
+	for _, p := range c.properties(typeName) {
+		prop := c.typeSchema(p.Type)
+		if desc := c.description(typeName+"/"+p.Name, p.Annotations); desc != "" {
+			prop = odataDescribed(prop, desc)
+		}
+		yamlSet(props, p.Name, prop)
+	}
+	for _, p := range c.navigationProperties(typeName) {
+		desc := c.description(typeName+"/"+p.Name, p.Annotations)
+		desc = strings.TrimSpace(desc + " Related " + strings.TrimSuffix(strings.TrimPrefix(p.Type, "Collection("), ")") + "; include it with --expand " + p.Name + ".")
+		yamlSet(props, p.Name, odataDescribed(c.typeSchema(p.Type), desc))
+	}
+	if len(props.Content) > 0 {
+		yamlSet(schema, "properties", props)
+	}
+	yamlSet(c.schemas, typeName, schema)
+}
+
+func (c *odataConverter) enumSchema(typeName string) {
+	if c.seen[typeName] {
+		return
+	}
+	c.seen[typeName] = true
+	enum := c.enums[typeName]
+	schema := protobufScalarSchema("string", "")
+	desc := c.description(typeName, enum.Annotations)
+	if enum.IsFlags {
+		desc = strings.TrimSpace(desc + " Flags; combine members with commas.")
+	} else {
+		values := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Style: yaml.FlowStyle}
+		for _, member := range enum.Members {
+			values.Content = append(values.Content, yamlScalar(member.Name))
+		}
+		yamlSet(schema, "enum", values)
+	}
+	if desc != "" {
+		yamlSet(schema, "description", yamlScalar(desc))
+	}
+	yamlSet(c.schemas, typeName, schema)
+}
+
+// typeSchema converts a CSDL type reference to a JSON schema following the
+// OData JSON format. Edm.Int64 and Edm.Decimal may be sent as strings by
+// IEEE754Compatible services, so both accept strings too.
+func (c *odataConverter) typeSchema(typeName string) *yaml.Node {
+	if inner, ok := strings.CutPrefix(typeName, "Collection("); ok {
+		return odataArray(c.typeSchema(strings.TrimSuffix(inner, ")")))
+	}
+	qualified := c.qualify(typeName)
+	switch {
+	case c.structured[qualified] != nil:
+		c.structuredSchema(qualified)
+		return odataRef(qualified)
+	case c.enums[qualified] != nil:
+		c.enumSchema(qualified)
+		return odataRef(qualified)
+	}
+	switch underlying := c.underlying(typeName); underlying {
+	case "Edm.String":
+		return protobufScalarSchema("string", "")
+	case "Edm.Boolean":
+		return protobufScalarSchema("boolean", "")
+	case "Edm.Byte", "Edm.SByte", "Edm.Int16", "Edm.Int32":
+		return protobufScalarSchema("integer", "int32")
+	case "Edm.Int64":
+		return protobufInt64Schema("int64")
+	case "Edm.Decimal":
+		schema := yamlMapping()
+		types := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Style: yaml.FlowStyle}
+		types.Content = append(types.Content, yamlScalar("number"), yamlScalar("string"))
+		yamlSet(schema, "type", types)
+		yamlSet(schema, "format", yamlScalar("decimal"))
+		return schema
+	case "Edm.Single", "Edm.Double":
+		return protobufScalarSchema("number", strings.ToLower(strings.TrimPrefix(underlying, "Edm.")))
+	case "Edm.Guid":
+		return protobufScalarSchema("string", "uuid")
+	case "Edm.DateTimeOffset":
+		return protobufScalarSchema("string", "date-time")
+	case "Edm.Date":
+		return protobufScalarSchema("string", "date")
+	case "Edm.TimeOfDay":
+		return protobufScalarSchema("string", "time")
+	case "Edm.Duration":
+		return protobufScalarSchema("string", "duration")
+	case "Edm.Binary", "Edm.Stream":
+		return protobufBytesSchema()
+	}
+	if strings.HasPrefix(typeName, "Edm.Geography") || strings.HasPrefix(typeName, "Edm.Geometry") {
+		return odataDescribed(odataObjectSchema(), "GeoJSON "+strings.TrimPrefix(typeName, "Edm."))
+	}
+	return yamlMapping()
+}
+
+// underlying resolves type definitions to their primitive type.
+func (c *odataConverter) underlying(typeName string) string {
+	qualified := c.qualify(typeName)
+	for depth := 0; depth < 32; depth++ {
+		next, ok := c.typeDefs[qualified]
+		if !ok {
+			break
+		}
+		qualified = c.qualify(next)
+	}
+	return qualified
+}
+
+func (c *odataConverter) primitive(typeName string) bool {
+	qualified := c.qualify(typeName)
+	return strings.HasPrefix(c.underlying(typeName), "Edm.") || c.enums[qualified] != nil
+}
+
+// returnSchema describes an operation result. Entities come back as they
+// are; collections and primitive values are wrapped in a value member.
+func (c *odataConverter) returnSchema(ret *csdlProperty) *yaml.Node {
+	if ret == nil {
+		return nil
+	}
+	if inner, ok := strings.CutPrefix(ret.Type, "Collection("); ok {
+		return c.collectionSchema(c.typeSchema(strings.TrimSuffix(inner, ")")))
+	}
+	if c.structured[c.qualify(ret.Type)] != nil {
+		return c.typeSchema(ret.Type)
+	}
+	schema := yamlMapping()
+	yamlSet(schema, "type", yamlScalar("object"))
+	props := yamlMapping()
+	yamlSet(props, "@odata.context", protobufScalarSchema("string", ""))
+	yamlSet(props, "value", c.typeSchema(ret.Type))
+	yamlSet(schema, "properties", props)
+	return schema
+}
+
+// collectionSchema wraps item schemas in the OData collection envelope,
+// whose @odata.nextLink drives pagination.
+func (c *odataConverter) collectionSchema(items *yaml.Node) *yaml.Node {
+	schema := yamlMapping()
+	yamlSet(schema, "type", yamlScalar("object"))
+	props := yamlMapping()
+	yamlSet(props, "@odata.context", protobufScalarSchema("string", ""))
+	yamlSet(props, "@odata.count", protobufScalarSchema("integer", ""))
+	yamlSet(props, "@odata.nextLink", protobufScalarSchema("string", ""))
+	yamlSet(props, "value", odataArray(items))
+	yamlSet(schema, "properties", props)
+	return schema
+}
+
+func odataResponses(code, desc string, schema *yaml.Node) *yaml.Node {
+	response := yamlMapping()
+	yamlSet(response, "description", yamlScalar(desc))
+	if schema != nil {
+		content := yamlMapping()
+		media := yamlMapping()
+		yamlSet(media, "schema", schema)
+		yamlSet(content, "application/json", media)
+		yamlSet(response, "content", content)
+	}
+	errorResponse := yamlMapping()
+	yamlSet(errorResponse, "description", yamlScalar("OData error"))
+	errorContent := yamlMapping()
+	errorMedia := yamlMapping()
+	yamlSet(errorMedia, "schema", odataErrorSchema())
+	yamlSet(errorContent, "application/json", errorMedia)
+	yamlSet(errorResponse, "content", errorContent)
+	responses := yamlMapping()
+	yamlSet(responses, code, response)
+	yamlSet(responses, "default", errorResponse)
+	return responses
+}
+
+func odataErrorSchema() *yaml.Node {
+	inner := yamlMapping()
+	yamlSet(inner, "type", yamlScalar("object"))
+	props := yamlMapping()
+	for _, name := range []string{"code", "message", "target"} {
+		yamlSet(props, name, protobufScalarSchema("string", ""))
+	}
+	yamlSet(props, "details", odataArray(odataObjectSchema()))
+	yamlSet(inner, "properties", props)
+	schema := yamlMapping()
+	yamlSet(schema, "type", yamlScalar("object"))
+	outer := yamlMapping()
+	yamlSet(outer, "error", inner)
+	yamlSet(schema, "properties", outer)
+	return schema
+}
+
+func odataRequestBody(schema *yaml.Node) *yaml.Node {
+	return odataMediaBody("application/json", schema)
+}
+
+func odataMediaBody(mediaType string, schema *yaml.Node) *yaml.Node {
+	body := yamlMapping()
+	yamlSet(body, "required", yamlBool(true))
+	content := yamlMapping()
+	media := yamlMapping()
+	yamlSet(media, "schema", schema)
+	yamlSet(content, mediaType, media)
+	yamlSet(body, "content", content)
+	return body
+}
+
+func odataRef(typeName string) *yaml.Node {
+	ref := yamlMapping()
+	yamlSet(ref, "$ref", yamlScalar("#/components/schemas/"+typeName))
+	return ref
+}
+
+func odataArray(items *yaml.Node) *yaml.Node {
+	schema := yamlMapping()
+	yamlSet(schema, "type", yamlScalar("array"))
+	yamlSet(schema, "items", items)
+	return schema
+}
+
+func odataObjectSchema() *yaml.Node {
+	return protobufScalarSchema("object", "")
+}
+
+// odataDescribed adds a description to schema. A $ref schema gets a sibling
+// description, which OpenAPI 3.1 allows.
+func odataDescribed(schema *yaml.Node, desc string) *yaml.Node {
+	yamlSet(schema, "description", yamlScalar(desc))
+	return schema
+}
+
+func odataTag(name, desc string) *yaml.Node {
+	tag := yamlMapping()
+	yamlSet(tag, "name", yamlScalar(name))
+	if desc != "" {
+		yamlSet(tag, "description", yamlScalar(desc))
+	}
+	return tag
+}
+
+func odataLocalName(qualified string) string {
+	if i := strings.LastIndex(qualified, "."); i >= 0 {
+		return qualified[i+1:]
+	}
+	return qualified
+}
+
+func odataArticle(noun string) string {
+	if noun != "" && strings.ContainsRune("AEIOUaeiou", rune(noun[0])) {
+		return "an " + noun
+	}
+	return "a " + noun
+}
@@ -0,0 +1,154 @@
+package spec
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// tripPinMetadata is a trimmed version of the TripPin sample service's
+// $metadata, with a capability restriction and an out-of-line description.
+const tripPinMetadata = `<?xml version="1.0" encoding="utf-8"?>
+<edmx:Edmx Version="4.0" xmlns:edmx="http://docs.oasis-open.org/odata/ns/edmx">
+  <edmx:Reference Uri="https://oasis-tcs.github.io/odata-vocabularies/vocabularies/Org.OData.Core.V1.xml">
+    <edmx:Include Namespace="Org.OData.Core.V1" Alias="Core"/>
+  </edmx:Reference>
+  <edmx:DataServices>
+    <Schema Namespace="Trippin" xmlns="http://docs.oasis-open.org/odata/ns/edm">
+      <EnumType Name="PersonGender">
+        <Member Name="Male" Value="0"/>
+        <Member Name="Female" Value="1"/>
+      </EnumType>
+      <ComplexType Name="Location">
+        <Property Name="Address" Type="Edm.String"/>
+      </ComplexType>
+      <EntityType Name="Person">
+        <Key><PropertyRef Name="UserName"/></Key>
+        <Property Name="UserName" Type="Edm.String" Nullable="false"/>
+        <Property Name="FirstName" Type="Edm.String"/>
+        <Property Name="Gender" Type="Trippin.PersonGender"/>
+        <Property Name="Age" Type="Edm.Int64"/>
+        <Property Name="AddressInfo" Type="Collection(Trippin.Location)"/>
+        <NavigationProperty Name="Friends" Type="Collection(Trippin.Person)"/>
+      </EntityType>
+      <EntityType Name="Airline">
+        <Key><PropertyRef Name="AirlineCode"/></Key>
+        <Property Name="AirlineCode" Type="Edm.String" Nullable="false"/>
+        <Property Name="Name" Type="Edm.String"/>
+      </EntityType>
+      <EntityType Name="Trip">
+        <Key><PropertyRef Name="TripId"/></Key>
+        <Property Name="TripId" Type="Edm.Int32" Nullable="false"/>
+        <Property Name="Budget" Type="Edm.Single"/>
+      </EntityType>
+      <Function Name="GetNearestAirport">
+        <Parameter Name="lat" Type="Edm.Double" Nullable="false"/>
+        <Parameter Name="lon" Type="Edm.Double" Nullable="false"/>
+        <ReturnType Type="Trippin.Airline"/>
+      </Function>
+      <Function Name="GetFavoriteAirline" IsBound="true">
+        <Parameter Name="person" Type="Trippin.Person"/>
+        <ReturnType Type="Trippin.Airline"/>
+      </Function>
+      <Action Name="ResetDataSource"/>
+      <EntityContainer Name="Container">
+        <EntitySet Name="People" EntityType="Trippin.Person">
+          <Annotation Term="Core.Description" String="People who travel."/>
+        </EntitySet>
+        <EntitySet Name="Airlines" EntityType="Trippin.Airline">
+          <Annotation Term="Org.OData.Capabilities.V1.DeleteRestrictions">
+            <Record><PropertyValue Property="Deletable" Bool="false"/></Record>
+          </Annotation>
+        </EntitySet>
+        <EntitySet Name="Trips" EntityType="Trippin.Trip"/>
+        <Singleton Name="Me" Type="Trippin.Person"/>
+        <FunctionImport Name="GetNearestAirport" Function="Trippin.GetNearestAirport"/>
+        <ActionImport Name="ResetDataSource" Action="Trippin.ResetDataSource"/>
+      </EntityContainer>
+      <Annotations Target="Trippin.Person/FirstName">
+        <Annotation Term="Core.Description" String="Given name."/>
+      </Annotations>
+    </Schema>
+  </edmx:DataServices>
+</edmx:Edmx>`
+
+func TestODataLoaderConvertsCSDL(t *testing.T) {
+	if !(ODataLoader{}).Detect("application/xml", []byte(tripPinMetadata)) || (ODataLoader{}).Detect("", []byte("<html>Edmx</html>")) {
+		t.Fatal("Detect should accept CSDL documents only")
+	}
+	loaded, err := load("application/xml", []byte(tripPinMetadata), DefaultLoaders())
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	set, err := loaded.OperationSet(OperationOptions{BaseURL: "https://services.example.com/TripPin"})
+	if err != nil {
+		t.Fatalf("operations: %v", err)
+	}
+	if !strings.Contains(strings.Join(set.Warnings, "\n"), "OData conversion: skipped 1 bound functions and actions") {
+		t.Fatalf("warnings = %v", set.Warnings)
+	}
+
+	list := operationByID(t, set.Operations, "listPeople")
+	if list.Method != "GET" || list.Path != "/People" || list.Description != "People who travel." {
+		t.Fatalf("list = %s %s %q", list.Method, list.Path, list.Description)
+	}
+	flags := map[string]Param{}
+	for _, p := range list.Parameters {
+		flags[p.XCLI.Name] = p
+	}
+	for _, name := range []string{"select", "filter", "expand", "orderby", "top", "count"} {
+		if p, ok := flags[name]; !ok || p.Name != "$"+name || p.In != "query" {
+			t.Fatalf("missing --%s query option: %#v", name, list.Parameters)
+		}
+	}
+	if flags["select"].Type != "array" || !strings.Contains(flags["select"].Schema, "AddressInfo") || !strings.Contains(flags["expand"].Schema, "Friends") {
+		t.Fatalf("select = %#v, expand = %#v", flags["select"], flags["expand"])
+	}
+	if flags["top"].Type != "integer" || flags["count"].Type != "boolean" {
+		t.Fatalf("top = %q, count = %q", flags["top"].Type, flags["count"].Type)
+	}
+	if len(list.Help.Responses) == 0 || !strings.Contains(list.Help.Responses[0].Schema, "Given name.") {
+		t.Fatalf("list response help = %#v", list.Help.Responses)
+	}
+
+	get := operationByID(t, set.Operations, "getPerson")
+	if get.Path != "/People('{UserName}')" || len(get.Parameters) == 0 || get.Parameters[0].In != "path" {
+		t.Fatalf("get = %s %#v", get.Path, get.Parameters)
+	}
+	if trip := operationByID(t, set.Operations, "getTrip"); trip.Path != "/Trips({TripId})" {
+		t.Fatalf("trip path = %s", trip.Path)
+	}
+	if update := operationByID(t, set.Operations, "updatePerson"); update.Method != "PATCH" || !update.HasBody {
+		t.Fatalf("update = %s body=%v", update.Method, update.HasBody)
+	}
+	if create := operationByID(t, set.Operations, "createAirline"); create.Method != "POST" || create.Path != "/Airlines" {
+		t.Fatalf("create = %s %s", create.Method, create.Path)
+	}
+	for _, op := range set.Operations {
+		if op.ID == "deleteAirline" {
+			t.Fatal("DeleteRestrictions should drop deleteAirline")
+		}
+	}
+	if me := operationByID(t, set.Operations, "getMe"); me.Path != "/Me" {
+		t.Fatalf("singleton path = %s", me.Path)
+	}
+	if fn := operationByID(t, set.Operations, "GetNearestAirport"); fn.Method != "GET" || fn.Path != "/GetNearestAirport(lat={lat},lon={lon})" {
+		t.Fatalf("function = %s %s", fn.Method, fn.Path)
+	}
+	if action := operationByID(t, set.Operations, "ResetDataSource"); action.Method != "POST" || action.HasBody {
+		t.Fatalf("action = %s body=%v", action.Method, action.HasBody)
+	}
+	batch := operationByID(t, set.Operations, "batch")
+	if batch.Method != "POST" || batch.Path != "/$batch" || batch.RequestMediaType != "multipart/mixed" {
+		t.Fatalf("batch = %s %s %q", batch.Method, batch.Path, batch.RequestMediaType)
+	}
+}
+
+func TestODataLoaderRejectsV2Metadata(t *testing.T) {
+	body := `<edmx:Edmx Version="1.0" xmlns:edmx="http://schemas.microsoft.com/ado/2007/06/edmx"><edmx:DataServices/></edmx:Edmx>`
+	_, err := load("application/xml", []byte(body), DefaultLoaders())
+	var loadErr *LoadError
+	if !errors.As(err, &loadErr) || !strings.Contains(err.Error(), "only OData v4") {
+		t.Fatalf("err = %v", err)
+	}
+}
@@ -20,7 +20,8 @@ import (
 	"go.yaml.in/yaml/v3"
 )
 
-// OpenAPILoader handles OpenAPI 3.0 and 3.1 specifications.
+// OpenAPILoader handles OpenAPI 3.x specifications. Swagger 2.0 documents are
+// converted to OpenAPI 3.0 first.
 type OpenAPILoader struct{}
 
 func (OpenAPILoader) Priority() int { return 10 }
@@ -52,18 +53,27 @@ func (OpenAPILoader) Detect(contentType string, body []byte) bool {
 		bytes.Contains(low, []byte("swagger:"))
 }
 
-// Load parses body as an OpenAPI 3.x document.
+// Load parses body as an OpenAPI 3.x or Swagger 2.0 document.
 func (OpenAPILoader) Load(body []byte) (*APISpec, error) {
 	return OpenAPILoader{}.LoadWithOptions(body, LoadOptions{})
 }
 
 // LoadWithOptions parses body as an OpenAPI 3.x document, using source
-// metadata to resolve supported external references.
+// metadata to resolve supported external references. Swagger 2.0 documents
+// are converted to OpenAPI 3.0 and the conversion warnings are reported with
+// the operation warnings. Raw holds the converted OpenAPI document, so
+// overlays target OpenAPI 3 paths and cached specs reload without conversion.
 func (OpenAPILoader) LoadWithOptions(body []byte, opts LoadOptions) (*APISpec, error) {
+	parseBody := body
+	var loadWarnings []string
 	if isSwagger2Document(body) {
-		return nil, &LoadError{Errors: []string{"Swagger/OpenAPI 2.0 is not supported; Restish requires OpenAPI 3.x"}}
+		converted, warnings, err := convertSwagger2(body)
+		if err != nil {
+			return nil, &LoadError{Errors: []string{"Swagger 2.0 conversion: " + err.Error()}}
+		}
+		parseBody, loadWarnings = converted, warnings
 	}
-	resolvedBody, err := resolveOpenAPIExternalRefs(body, opts)
+	resolvedBody, err := resolveOpenAPIExternalRefs(parseBody, opts)
 	if err != nil {
 		return nil, &LoadError{Errors: []string{err.Error()}}
 	}
@@ -71,7 +81,7 @@ func (OpenAPILoader) LoadWithOptions(body []byte, opts LoadOptions) (*APISpec, e
 	if err != nil {
 		return nil, &LoadError{Errors: []string{err.Error()}}
 	}
-	return &APISpec{Raw: body, Document: doc}, nil
+	return &APISpec{Raw: parseBody, Document: doc, loadWarnings: loadWarnings}, nil
 }
 
 func sanitizeOpenAPIDescriptionRefs(body []byte) ([]byte, error) {
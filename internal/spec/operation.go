@@ -87,6 +87,31 @@ type OperationResponseHelp struct {
 	Description string
 }
 
+// OperationLink is an OpenAPI Link Object declared on one of an operation's
+// responses. It describes how values from the response feed another
+// operation, such as passing a created resource's ID to its GET operation.
+type OperationLink struct {
+	Name string
+	// Codes are the response status codes that declare the link.
+	Codes []string
+	// OperationID names the target operation. OperationRef is set instead
+	// when the link points at the target with a JSON reference.
+	OperationID  string
+	OperationRef string
+	// Parameters map target parameter names, optionally qualified by
+	// location as in "path.id", to runtime expressions or constants.
+	Parameters  []OperationLinkParam
+	RequestBody string
+	Description string
+}
+
+// OperationLinkParam is one parameter of an OperationLink, in declaration
+// order.
+type OperationLinkParam struct {
+	Name  string
+	Value string
+}
+
 // OperationHelp stores pre-rendered help snippets for generated commands.
 type OperationHelp struct {
 	Request   *OperationBodyHelp
@@ -157,8 +182,19 @@ type Operation struct {
 	// RequestMultipartContentTypes maps multipart/form-data property names to
 	// per-part Content-Type values from the OpenAPI encoding object.
 	RequestMultipartContentTypes map[string]string
-	XCLI                         OperationXCLI
-	Help                         OperationHelp
+	// GraphQL is set for operations converted from a GraphQL introspection
+	// result; the CLI sends its document instead of calling Path.
+	GraphQL *GraphQLOperation
+	// RPC is set for operations converted from a protobuf descriptor set; the
+	// CLI uses it to speak Connect or Twirp and to encode binary bodies.
+	RPC *RPCOperation
+	// WebSocket is true for GET operations that declare a 101 Switching
+	// Protocols response; the CLI opens a WebSocket session for them.
+	WebSocket bool
+	// Links are the OpenAPI links declared on the operation's responses.
+	Links []OperationLink
+	XCLI  OperationXCLI
+	Help  OperationHelp
 }
 
 // OperationSet is the extracted operation list plus API-level metadata needed
@@ -168,6 +204,9 @@ type OperationSet struct {
 	Operations     []Operation
 	Warnings       []string
 	XCLIExtensions XCLIExtensionReport
+	// RPC is set for specs converted from a protobuf descriptor set and holds
+	// the descriptors shared by every operation's RPC.
+	RPC *RPCAPI
 }
 
 // OperationSet returns all operations with top-level API metadata. The result
@@ -186,7 +225,13 @@ func (s *APISpec) OperationSet(opts OperationOptions) (OperationSet, error) {
 	if err != nil {
 		return OperationSet{}, err
 	}
-	return OperationSet{Info: info, Operations: ops, Warnings: warnings, XCLIExtensions: xcliExtensions}, nil
+	rpc, err := s.RPC()
+	if err != nil {
+		return OperationSet{}, err
+	}
+	set := OperationSet{Info: info, Operations: ops, Warnings: warnings, XCLIExtensions: xcliExtensions, RPC: rpc}
+	set.linkRPC()
+	return set, nil
 }
 
 // Operations returns all HTTP operations extracted from the spec's V3 model,
@@ -241,8 +286,12 @@ func (s *APISpec) buildOperations(opts OperationOptions) ([]Operation, []string,
 	// Use a non-nil empty slice so callers can distinguish "no paths in spec"
 	// (nil return) from "paths exist but all were filtered" (empty non-nil slice).
 	ops := make([]Operation, 0)
-	warnings := make([]string, 0)
+	warnings := append(make([]string, 0, len(s.loadWarnings)), s.loadWarnings...)
 	warningsSeen := map[string]bool{}
+	for _, warning := range warnings {
+		warningsSeen[warning] = true
+	}
+	schemes := newSecuritySchemeIndex(&model.Model)
 	for rawPath, pathItem := range model.Model.Paths.PathItems.FromOldest() {
 		if pathItem == nil {
 			continue
@@ -278,7 +327,7 @@ func (s *APISpec) buildOperations(opts OperationOptions) ([]Operation, []string,
 				}
 			}
 			fullPath := joinOperationPath(basePath, rawPath)
-			op := extractOperation(mo.Method, fullPath, pathParams, mo.Op, model.Model.Security, securitySchemes(model.Model.Components), openAPIJSONSchemaDialect(model.Model))
+			op := extractOperation(mo.Method, fullPath, pathParams, mo.Op, model.Model.Security, schemes, openAPIJSONSchemaDialect(model.Model))
 			op.OperationServer = operationServer
 			if op.XCLI.Ignore {
 				continue
@@ -302,7 +351,7 @@ func emitOperationWarnings(warnf func(format string, args ...any), warnings []st
 }
 
 // extractOperation converts a single libopenapi operation to the neutral form.
-func extractOperation(method, path string, pathParams []*v3.Parameter, op *v3.Operation, docSecurity []*base.SecurityRequirement, schemes map[string]*v3.SecurityScheme, schemaDialect string) Operation {
+func extractOperation(method, path string, pathParams []*v3.Parameter, op *v3.Operation, docSecurity []*base.SecurityRequirement, schemes securitySchemeIndex, schemaDialect string) Operation {
 	effectiveSecurity := docSecurity
 	if op.Security != nil {
 		effectiveSecurity = op.Security
@@ -322,6 +371,10 @@ func extractOperation(method, path string, pathParams []*v3.Parameter, op *v3.Op
 		RequestMediaType:   preferredRequestMediaType(op),
 		ResponseMediaType:  preferredOperationResponseMediaType(op),
 		ResponseMediaTypes: operationResponseMediaTypes(op),
+		GraphQL:            opExtGraphQL(op),
+		RPC:                opExtRPC(op),
+		WebSocket:          method == "GET" && operationUpgradesToWebSocket(op),
+		Links:              operationLinks(op),
 		XCLI: OperationXCLI{
 			Ignore:      OpExtBool(op, "x-cli-ignore"),
 			Hidden:      OpExtBool(op, "x-cli-hidden"),
@@ -395,7 +448,7 @@ func securitySchemes(components *v3.Components) map[string]*v3.SecurityScheme {
 	return out
 }
 
-func credentialAlternatives(requirements []*base.SecurityRequirement, schemes map[string]*v3.SecurityScheme) (bool, []CredentialAlternative) {
+func credentialAlternatives(requirements []*base.SecurityRequirement, schemes securitySchemeIndex) (bool, []CredentialAlternative) {
 	if len(requirements) == 0 {
 		return false, nil
 	}
@@ -412,18 +465,18 @@ func credentialAlternatives(requirements []*base.SecurityRequirement, schemes ma
 			continue
 		}
 		alternative := make(CredentialAlternative, 0, requirementCount)
-		for id, needs := range requirement.Requirements.FromOldest() {
-			scheme := schemes[id]
+		for key, needs := range requirement.Requirements.FromOldest() {
+			id, scheme := schemes.resolve(key)
 			requirement := CredentialRequirement{
 				ID:         id,
-				Ref:        credentialRequirementRef(id, scheme),
+				Ref:        credentialRequirementRef(key, scheme),
 				Kind:       credentialRequirementKind(scheme),
 				Needs:      append([]string(nil), needs...),
 				In:         credentialRequirementIn(scheme),
 				Name:       credentialRequirementName(scheme),
 				Source:     "openapi",
-				External:   credentialRequirementExternal(id, scheme),
-				Undeclared: scheme == nil && !isAbsoluteURI(id),
+				External:   credentialRequirementExternal(key, scheme),
+				Undeclared: scheme == nil && !isSecuritySchemeURIRef(key),
 				Deprecated: scheme != nil && scheme.Deprecated,
 			}
 			sort.Strings(requirement.Needs)
@@ -456,10 +509,10 @@ func credentialRequirementRef(id string, scheme *v3.SecurityScheme) string {
 	if scheme != nil && scheme.Reference != "" {
 		return scheme.Reference
 	}
-	if isAbsoluteURI(id) {
+	if isSecuritySchemeURIRef(id) {
 		return id
 	}
-	return "#/components/securitySchemes/" + jsonPointerEscape(id)
+	return securitySchemeRefPrefix + jsonPointerEscape(id)
 }
 
 func credentialRequirementKind(scheme *v3.SecurityScheme) string {
@@ -479,6 +532,8 @@ func credentialRequirementKind(scheme *v3.SecurityScheme) string {
 		switch strings.ToLower(scheme.Scheme) {
 		case "basic":
 			return "http-basic"
+		case "digest":
+			return "http-digest"
 		case "bearer":
 			return "http-bearer"
 		default:
@@ -490,7 +545,7 @@ func credentialRequirementKind(scheme *v3.SecurityScheme) string {
 }
 
 func credentialRequirementExternal(id string, scheme *v3.SecurityScheme) bool {
-	return isAbsoluteURI(id) || (scheme != nil && isAbsoluteURI(scheme.Reference))
+	return isSecuritySchemeURIRef(id) || (scheme != nil && isAbsoluteURI(scheme.Reference))
 }
 
 func isAbsoluteURI(value string) bool {
@@ -1080,6 +1135,56 @@ func preferredOperationResponseMediaType(op *v3.Operation) string {
 	return ""
 }
 
+// operationUpgradesToWebSocket reports whether op declares a 101 Switching
+// Protocols response, the OpenAPI way to describe a WebSocket handshake.
+func operationUpgradesToWebSocket(op *v3.Operation) bool {
+	if op == nil || op.Responses == nil || op.Responses.Codes == nil {
+		return false
+	}
+	return op.Responses.Codes.GetOrZero("101") != nil
+}
+
+// operationLinks collects the links of every response. A link declared with
+// the same name on several responses is listed once with all their codes.
+func operationLinks(op *v3.Operation) []OperationLink {
+	if op == nil || op.Responses == nil {
+		return nil
+	}
+	var links []OperationLink
+	index := map[string]int{}
+	for _, code := range responseCodes(op) {
+		resp := responseForCode(op, code)
+		if resp == nil || resp.Links == nil {
+			continue
+		}
+		for name, link := range resp.Links.FromOldest() {
+			if link == nil {
+				continue
+			}
+			if i, ok := index[name]; ok {
+				links[i].Codes = append(links[i].Codes, code)
+				continue
+			}
+			out := OperationLink{
+				Name:         name,
+				Codes:        []string{code},
+				OperationID:  link.OperationId,
+				OperationRef: link.OperationRef,
+				RequestBody:  link.RequestBody,
+				Description:  link.Description,
+			}
+			if link.Parameters != nil {
+				for param, value := range link.Parameters.FromOldest() {
+					out.Parameters = append(out.Parameters, OperationLinkParam{Name: param, Value: value})
+				}
+			}
+			index[name] = len(links)
+			links = append(links, out)
+		}
+	}
+	return links
+}
+
 func operationResponseMediaTypes(op *v3.Operation) []string {
 	if op == nil || op.Responses == nil {
 		return nil
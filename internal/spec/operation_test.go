@@ -83,6 +83,76 @@ paths:
 	}
 }
 
+func TestOperationsExtractsResponseLinks(t *testing.T) {
+	raw := `openapi: "3.1.0"
+info:
+  title: Test
+  version: "1.0.0"
+components:
+  links:
+    UserRepos:
+      operationRef: "#/paths/~1users~1{userId}~1repos/get"
+      parameters:
+        userId: $response.body#/id
+paths:
+  /users:
+    post:
+      operationId: createUser
+      responses:
+        "201":
+          description: Created
+          links:
+            GetUserByUserId:
+              operationId: getUser
+              description: Fetch the new user.
+              parameters:
+                path.userId: $response.body#/id
+                expand: teams
+            UserRepos:
+              $ref: "#/components/links/UserRepos"
+        "200":
+          description: Existing user
+          links:
+            GetUserByUserId:
+              operationId: getUser
+              parameters:
+                userId: $response.body#/id
+  /users/{userId}:
+    get:
+      operationId: getUser
+      responses:
+        "200":
+          description: OK`
+	loaded, err := load("application/yaml", []byte(raw), DefaultLoaders())
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	ops, err := loaded.Operations(OperationOptions{BaseURL: "https://api.example.com"})
+	if err != nil {
+		t.Fatalf("operations: %v", err)
+	}
+	want := []OperationLink{
+		{
+			Name:        "GetUserByUserId",
+			Codes:       []string{"200", "201"},
+			OperationID: "getUser",
+			Parameters:  []OperationLinkParam{{Name: "userId", Value: "$response.body#/id"}},
+		},
+		{
+			Name:         "UserRepos",
+			Codes:        []string{"201"},
+			OperationRef: "#/paths/~1users~1{userId}~1repos/get",
+			Parameters:   []OperationLinkParam{{Name: "userId", Value: "$response.body#/id"}},
+		},
+	}
+	if !reflect.DeepEqual(ops[0].Links, want) {
+		t.Fatalf("links = %#v", ops[0].Links)
+	}
+	if len(ops[1].Links) != 0 {
+		t.Fatalf("getUser links = %#v", ops[1].Links)
+	}
+}
+
 func TestOperationsCarriesRequestJSONSchemaDialect(t *testing.T) {
 	raw := `openapi: "3.1.0"
 jsonSchemaDialect: "http://json-schema.org/draft-07/schema#"
@@ -464,6 +534,63 @@ paths:
 	}})
 }
 
+func TestOperationsResolvesURIReferencedSecuritySchemes(t *testing.T) {
+	raw := `openapi: "3.2.0"
+info:
+  title: Test
+  version: "1.0.0"
+components:
+  securitySchemes:
+    PartnerKey:
+      type: apiKey
+      in: header
+      name: X-Partner-Key
+paths:
+  /local:
+    get:
+      operationId: local
+      security:
+        - "#/components/securitySchemes/PartnerKey": []
+      responses:
+        "200":
+          description: OK
+  /shared:
+    get:
+      operationId: shared
+      security:
+        - "https://auth.example.com/schemes.yaml#/components/securitySchemes/TenantOAuth": [tenant:read]
+      responses:
+        "200":
+          description: OK
+  /clash:
+    get:
+      operationId: clash
+      security:
+        - "https://auth.example.com/schemes.yaml#/components/securitySchemes/PartnerKey": []
+      responses:
+        "200":
+          description: OK`
+	loaded, err := load("application/yaml", []byte(raw), DefaultLoaders())
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	ops, err := loaded.Operations(OperationOptions{})
+	if err != nil {
+		t.Fatalf("operations: %v", err)
+	}
+
+	requireCredential(t, operationByID(t, ops, "local"), [][]CredentialRequirement{{
+		{ID: "PartnerKey", Ref: "#/components/securitySchemes/PartnerKey", Kind: "api-key", In: "header", Name: "X-Partner-Key", Source: "openapi", External: true},
+	}})
+	requireCredential(t, operationByID(t, ops, "shared"), [][]CredentialRequirement{{
+		{ID: "TenantOAuth", Ref: "https://auth.example.com/schemes.yaml#/components/securitySchemes/TenantOAuth", Kind: "unknown", Needs: []string{"tenant:read"}, Source: "openapi", External: true},
+	}})
+	clashRef := "https://auth.example.com/schemes.yaml#/components/securitySchemes/PartnerKey"
+	requireCredential(t, operationByID(t, ops, "clash"), [][]CredentialRequirement{{
+		{ID: clashRef, Ref: clashRef, Kind: "unknown", Source: "openapi", External: true},
+	}})
+}
+
 func TestOperationsUsesConfiguredServerVariables(t *testing.T) {
 	raw := `openapi: "3.1.0"
 info:
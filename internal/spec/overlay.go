@@ -0,0 +1,129 @@
+package spec
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+
+	"github.com/pb33f/libopenapi"
+	"github.com/pb33f/libopenapi/overlay"
+)
+
+// applyOverlays applies OpenAPI Overlay 1.0 documents to a loaded spec in
+// order and reparses the patched document with the spec's original load
+// options. Overlay actions that match nothing become load warnings, since a
+// stale overlay usually means the upstream spec changed shape.
+func applyOverlays(ctx context.Context, cfg DiscoverConfig, apiSpec *APISpec, loaders []Loader) (*APISpec, error) {
+	if apiSpec == nil || len(cfg.OverlayFiles) == 0 {
+		return apiSpec, nil
+	}
+	tr := effectiveTransport(cfg)
+	fetch := effectiveFetcher(cfg)
+	body := apiSpec.Raw
+	var warnings []string
+	files := make([]cachedSpecFile, 0, len(cfg.OverlayFiles))
+	for _, src := range cfg.OverlayFiles {
+		displaySrc := specFileDisplaySource(src)
+		var data []byte
+		var err error
+		if isLocalPath(src) {
+			_, data, err = readLocalFile(src)
+		} else {
+			_, data, _, _, err = fetchBytes(ctx, src, tr, fetch, cfg.Trace)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("overlay %q: %w", displaySrc, err)
+		}
+		ov, err := libopenapi.NewOverlayDocument(data)
+		if err != nil {
+			return nil, fmt.Errorf("overlay %q: %w", displaySrc, err)
+		}
+		result, err := overlay.Apply(body, ov)
+		if err != nil {
+			return nil, fmt.Errorf("overlay %q: %w", displaySrc, err)
+		}
+		for _, w := range result.Warnings {
+			warnings = append(warnings, fmt.Sprintf("overlay %s: target %q: %s", displaySrc, w.Target, w.Message))
+		}
+		body = result.Bytes
+		files = append(files, cachedOverlayFile(src, data))
+	}
+
+	patched, err := loadWithOptions("application/yaml", body, loaders, LoadOptions{
+		Context:          ctx,
+		RequestedURL:     apiSpec.RequestedURL,
+		SourceURL:        apiSpec.SourceURL,
+		LocalPath:        apiSpec.LocalPath,
+		AllowCrossOrigin: apiSpec.AllowCrossOrigin,
+		Transport:        tr,
+		Fetch:            fetch,
+		Trace:            cfg.Trace,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("overlays: %w", err)
+	}
+	if patched == nil {
+		return nil, fmt.Errorf("overlays: unsupported API spec: expected an OpenAPI 3.x document after applying overlays")
+	}
+	// Raw is already converted for non-OpenAPI 3 sources, so the reload above
+	// cannot report their conversion warnings again.
+	patched.loadWarnings = append(append(append([]string(nil), apiSpec.loadWarnings...), patched.loadWarnings...), warnings...)
+	patched.overlays = files
+	return patched, nil
+}
+
+// cachedOverlayFile records an overlay's source and content hash. The hash is
+// part of the cache key: editing an overlay invalidates the cached spec even
+// when size and modification time happen to match.
+func cachedOverlayFile(src string, data []byte) cachedSpecFile {
+	sum := sha256.Sum256(data)
+	meta := cachedSpecFile{Source: cleanSourceURL(src), SHA256: hex.EncodeToString(sum[:])}
+	if isLocalPath(src) {
+		meta.Source = src
+		meta.Local = true
+		if path, err := localPathFromSource(src); err == nil {
+			meta.Path = path
+		}
+	}
+	return meta
+}
+
+// cacheOverlaysMatch reports whether the overlays recorded in a cache entry
+// are the configured ones.
+func cacheOverlaysMatch(overlayFiles []string, entry *cacheEntry) bool {
+	if len(overlayFiles) != len(entry.Overlays) {
+		return false
+	}
+	for i, src := range overlayFiles {
+		want := cleanSourceURL(src)
+		if isLocalPath(src) {
+			want = src
+		}
+		if entry.Overlays[i].Source != want {
+			return false
+		}
+	}
+	return true
+}
+
+// cachedOverlaysChanged reports whether a local overlay recorded in a cache
+// entry was edited or removed since the entry was written. Remote overlays
+// are refreshed with the spec.
+func cachedOverlaysChanged(entry *cacheEntry) bool {
+	for _, meta := range entry.Overlays {
+		if !meta.Local {
+			continue
+		}
+		data, err := os.ReadFile(meta.Path)
+		if err != nil {
+			return true
+		}
+		sum := sha256.Sum256(data)
+		if hex.EncodeToString(sum[:]) != meta.SHA256 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,237 @@
+package spec
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const overlayVendorSpec = `openapi: "3.1.0"
+info:
+  title: Vendor
+  version: "1.0.0"
+components:
+  securitySchemes:
+    token:
+      type: http
+      scheme: bearer
+paths:
+  /widgets:
+    get:
+      operationId: listWidgetsV2Beta
+      responses:
+        "200":
+          description: OK
+  /internal/debug:
+    get:
+      operationId: debugDump
+      responses:
+        "200":
+          description: OK
+`
+
+const overlayVendorFixes = `overlay: 1.0.0
+info:
+  title: Vendor fixes
+  version: "1"
+actions:
+  - target: $.paths['/widgets'].get
+    update:
+      x-cli-name: list-widgets
+      x-cli-aliases: [ls]
+  - target: $.paths['/internal/debug'].get
+    update:
+      x-cli-ignore: true
+  - target: $
+    update:
+      security:
+        - token: []
+  - target: $.paths['/gone'].get
+    update:
+      x-cli-ignore: true
+`
+
+func TestDiscoverAppliesOverlays(t *testing.T) {
+	dir := t.TempDir()
+	specPath := filepath.Join(dir, "vendor.yaml")
+	overlayPath := filepath.Join(dir, "fixes.yaml")
+	if err := os.WriteFile(specPath, []byte(overlayVendorSpec), 0o644); err != nil {
+		t.Fatalf("write spec: %v", err)
+	}
+	if err := os.WriteFile(overlayPath, []byte(overlayVendorFixes), 0o644); err != nil {
+		t.Fatalf("write overlay: %v", err)
+	}
+
+	loaded, err := Discover(context.Background(), DiscoverConfig{
+		APIName:      "vendor",
+		BaseURL:      "https://api.example.com",
+		SpecFiles:    []string{specPath},
+		OverlayFiles: []string{overlayPath},
+	}, DefaultLoaders())
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+	var warned []string
+	ops, err := loaded.Operations(OperationOptions{
+		BaseURL: "https://api.example.com",
+		Warnf: func(format string, args ...any) {
+			warned = append(warned, fmt.Sprintf(format, args...))
+		},
+	})
+	if err != nil {
+		t.Fatalf("Operations: %v", err)
+	}
+	list := operationByID(t, ops, "listWidgetsV2Beta")
+	if list.XCLI.Name != "list-widgets" || !reflect.DeepEqual(list.XCLI.Aliases, []string{"ls"}) {
+		t.Fatalf("overlay x-cli fields = %#v", list.XCLI)
+	}
+	requireCredential(t, list, [][]CredentialRequirement{{
+		{ID: "token", Ref: "#/components/securitySchemes/token", Kind: "http-bearer", Source: "openapi"},
+	}})
+	if len(ops) != 1 {
+		t.Fatalf("overlay should ignore debugDump, got %d operations", len(ops))
+	}
+	want := []string{fmt.Sprintf(`overlay %s: target "$.paths['/gone'].get": target matched zero nodes`, overlayPath)}
+	if !reflect.DeepEqual(warned, want) {
+		t.Fatalf("warnings = %#v, want %#v", warned, want)
+	}
+}
+
+func TestDiscoverAppliesOverlaysToConvertedSwagger2(t *testing.T) {
+	dir := t.TempDir()
+	specPath := filepath.Join(dir, "pets.yaml")
+	overlayPath := filepath.Join(dir, "fixes.yaml")
+	if err := os.WriteFile(specPath, []byte(swagger2PetStore), 0o644); err != nil {
+		t.Fatalf("write spec: %v", err)
+	}
+	// The request body only exists after conversion, so this overlay fails
+	// to match if it is applied to the Swagger 2.0 document.
+	fixes := `overlay: 1.0.0
+info:
+  title: Pet fixes
+  version: "1"
+actions:
+  - target: $.paths['/pets'].post.requestBody
+    update:
+      x-cli-name: pet
+  - target: $.paths['/pets'].post
+    update:
+      x-cli-name: add
+`
+	if err := os.WriteFile(overlayPath, []byte(fixes), 0o644); err != nil {
+		t.Fatalf("write overlay: %v", err)
+	}
+
+	loaded, err := Discover(context.Background(), DiscoverConfig{
+		APIName:      "pets",
+		BaseURL:      "https://api.example.com",
+		SpecFiles:    []string{specPath},
+		OverlayFiles: []string{overlayPath},
+	}, DefaultLoaders())
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+	var warned []string
+	ops, err := loaded.Operations(OperationOptions{
+		BaseURL: "https://api.example.com",
+		Warnf: func(format string, args ...any) {
+			warned = append(warned, fmt.Sprintf(format, args...))
+		},
+	})
+	if err != nil {
+		t.Fatalf("Operations: %v", err)
+	}
+	if create := operationByID(t, ops, "createPet"); create.XCLI.Name != "add" {
+		t.Fatalf("overlay x-cli-name = %q", create.XCLI.Name)
+	}
+	want := []string{`Swagger 2.0 conversion: parameter "fields": unsupported collectionFormat "tsv"`}
+	if !reflect.DeepEqual(warned, want) {
+		t.Fatalf("warnings = %#v, want %#v", warned, want)
+	}
+}
+
+func TestDiscoverRejectsInvalidOverlay(t *testing.T) {
+	dir := t.TempDir()
+	specPath := filepath.Join(dir, "vendor.yaml")
+	overlayPath := filepath.Join(dir, "fixes.yaml")
+	if err := os.WriteFile(specPath, []byte(overlayVendorSpec), 0o644); err != nil {
+		t.Fatalf("write spec: %v", err)
+	}
+	if err := os.WriteFile(overlayPath, []byte("overlay: 1.0.0\ninfo: {title: Broken, version: \"1\"}\nactions: []\n"), 0o644); err != nil {
+		t.Fatalf("write overlay: %v", err)
+	}
+
+	_, err := Discover(context.Background(), DiscoverConfig{
+		APIName:      "vendor",
+		BaseURL:      "https://api.example.com",
+		SpecFiles:    []string{specPath},
+		OverlayFiles: []string{overlayPath},
+	}, DefaultLoaders())
+	if err == nil {
+		t.Fatal("expected invalid overlay error")
+	}
+}
+
+func TestOverlayChangesInvalidateSpecCache(t *testing.T) {
+	dir := t.TempDir()
+	specPath := filepath.Join(dir, "vendor.yaml")
+	overlayPath := filepath.Join(dir, "fixes.yaml")
+	if err := os.WriteFile(specPath, []byte(overlayVendorSpec), 0o644); err != nil {
+		t.Fatalf("write spec: %v", err)
+	}
+	if err := os.WriteFile(overlayPath, []byte(overlayVendorFixes), 0o644); err != nil {
+		t.Fatalf("write overlay: %v", err)
+	}
+	past := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(specPath, past, past); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+
+	cacheDir := t.TempDir()
+	cfg := DiscoverConfig{
+		APIName:      "vendor",
+		BaseURL:      "https://api.example.com",
+		SpecFiles:    []string{specPath},
+		OverlayFiles: []string{overlayPath},
+		CacheDir:     cacheDir,
+		Version:      "v2.0.0",
+	}
+	if _, err := Discover(context.Background(), cfg, DefaultLoaders()); err != nil {
+		t.Fatalf("first Discover: %v", err)
+	}
+	opts := OperationOptions{BaseURL: cfg.BaseURL}
+	set, ok := LoadOperationSetFromCache(cacheDir, "vendor", "v2.0.0", cfg.SpecFiles, opts)
+	if !ok || operationByID(t, set.Operations, "listWidgetsV2Beta").XCLI.Name != "list-widgets" {
+		t.Fatalf("expected overlaid operations in cache, got ok=%v", ok)
+	}
+
+	if err := os.WriteFile(overlayPath, []byte(`overlay: 1.0.0
+info: {title: Vendor fixes, version: "2"}
+actions:
+  - target: $.paths['/widgets'].get
+    update:
+      x-cli-name: widgets
+`), 0o644); err != nil {
+		t.Fatalf("rewrite overlay: %v", err)
+	}
+	if _, ok := LoadOperationSetFromCache(cacheDir, "vendor", "v2.0.0", cfg.SpecFiles, opts); ok {
+		t.Fatal("edited overlay should invalidate cached operations")
+	}
+
+	cfg.OverlayFiles = nil
+	loaded, err := Discover(context.Background(), cfg, DefaultLoaders())
+	if err != nil {
+		t.Fatalf("Discover without overlays: %v", err)
+	}
+	ops, err := loaded.Operations(opts)
+	if err != nil {
+		t.Fatalf("Operations: %v", err)
+	}
+	if name := operationByID(t, ops, "listWidgetsV2Beta").XCLI.Name; name != "" {
+		t.Fatalf("removing overlays should rebuild from the vendor spec, got x-cli-name %q", name)
+	}
+}
@@ -0,0 +1,506 @@
+package spec
+
+import (
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"strings"
+
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"go.yaml.in/yaml/v3"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// ProtobufLoader loads protobuf FileDescriptorSet files, as written by
+// `buf build -o api.binpb` or `protoc --include_imports -o`, by converting
+// them to OpenAPI 3.1. Each unary RPC method becomes a POST operation at
+// /{package.Service}/{Method}, the path Connect and Twirp both use under the
+// API base URL. Request and response messages become JSON schemas that follow
+// the protojson mapping. Each operation's x-rpc extension names its method and
+// messages, and the document-level x-rpc extension carries the descriptor set
+// once for every operation, so the CLI can send and read binary protobuf
+// bodies. Raw holds the converted document, so cached specs reload without the
+// protobuf loader.
+type ProtobufLoader struct{}
+
+func (ProtobufLoader) Priority() int { return 20 }
+
+// Detect returns true for bodies that decode as a FileDescriptorSet.
+func (ProtobufLoader) Detect(contentType string, body []byte) bool {
+	return parseFileDescriptorSet(body) != nil
+}
+
+// LoadWithOptions converts a descriptor set to OpenAPI and loads the result.
+// Conversion warnings are reported with the operation warnings.
+func (ProtobufLoader) LoadWithOptions(body []byte, opts LoadOptions) (*APISpec, error) {
+	set := parseFileDescriptorSet(body)
+	if set == nil {
+		return nil, &LoadError{Errors: []string{"protobuf descriptor set: not a FileDescriptorSet"}}
+	}
+	raw, warnings, err := convertFileDescriptorSet(set)
+	if err != nil {
+		return nil, &LoadError{Errors: []string{fmt.Sprintf("protobuf descriptor set: %v", err)}}
+	}
+	opts.ContentType = "application/yaml"
+	loaded, err := OpenAPILoader{}.LoadWithOptions(raw, opts)
+	if err != nil {
+		return nil, err
+	}
+	loaded.ContentType = "application/yaml"
+	for _, warning := range warnings {
+		loaded.loadWarnings = append(loaded.loadWarnings, "protobuf conversion: "+warning)
+	}
+	return loaded, nil
+}
+
+// RPCOperation is the protobuf RPC behind an operation converted from a
+// descriptor set, read from the x-rpc extension.
+type RPCOperation struct {
+	// Service is the fully qualified service name, such as
+	// "acme.widgets.v1.WidgetService".
+	Service string `yaml:"service"`
+	// Method is the RPC method name.
+	Method string `yaml:"method"`
+	// Input and Output are the fully qualified request and response message
+	// names, defined in the descriptors of the spec's RPCAPI.
+	Input  string `yaml:"input"`
+	Output string `yaml:"output"`
+	// API references the spec's shared RPC metadata. OperationSet links it
+	// after extraction and after cache reads; it is never stored per
+	// operation.
+	API *RPCAPI `yaml:"-" json:"-" cbor:"-"`
+}
+
+// RPCAPI is the document-level x-rpc extension of a spec converted from a
+// descriptor set. It holds what every RPC operation shares, so the descriptors
+// are stored once per spec rather than on each operation.
+type RPCAPI struct {
+	// Descriptors is a base64-encoded FileDescriptorSet holding every file of
+	// the converted set, dependencies first.
+	Descriptors string `yaml:"descriptors" cbor:"descriptors"`
+}
+
+// parseFileDescriptorSet returns body decoded as a FileDescriptorSet, or nil
+// when body is not one. Every file must be named like a .proto file, which
+// keeps arbitrary bytes that happen to decode from being mistaken for a set.
+func parseFileDescriptorSet(body []byte) *descriptorpb.FileDescriptorSet {
+	// Field 1 (file), wire type 2 (length-delimited).
+	if len(body) == 0 || body[0] != 0x0a {
+		return nil
+	}
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(body, &set); err != nil || len(set.GetFile()) == 0 {
+		return nil
+	}
+	for _, file := range set.GetFile() {
+		if !strings.HasSuffix(file.GetName(), ".proto") {
+			return nil
+		}
+	}
+	return &set
+}
+
+type protobufConverter struct {
+	schemas      *yaml.Node
+	seen         map[protoreflect.FullName]bool
+	operationIDs map[string]bool
+	warnings     []string
+}
+
+func convertFileDescriptorSet(set *descriptorpb.FileDescriptorSet) ([]byte, []string, error) {
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w (build it with imports included, such as buf build or protoc --include_imports)", err)
+	}
+	c := &protobufConverter{
+		schemas:      yamlMapping(),
+		seen:         map[protoreflect.FullName]bool{},
+		operationIDs: map[string]bool{},
+	}
+
+	doc := yamlMapping()
+	yamlSet(doc, "openapi", yamlScalar("3.1.0"))
+	paths := yamlMapping()
+	tags := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	var packages []string
+	var all []protoreflect.FileDescriptor
+	for _, fileProto := range set.GetFile() {
+		file, err := files.FindFileByPath(fileProto.GetName())
+		if err != nil {
+			return nil, nil, err
+		}
+		all = append(all, file)
+		services := file.Services()
+		if services.Len() > 0 && !slices.Contains(packages, string(file.Package())) {
+			packages = append(packages, string(file.Package()))
+		}
+		for i := 0; i < services.Len(); i++ {
+			service := services.Get(i)
+			tag := yamlMapping()
+			yamlSet(tag, "name", yamlScalar(string(service.Name())))
+			if comment := protobufComment(service); comment != "" {
+				yamlSet(tag, "description", yamlScalar(comment))
+			}
+			tags.Content = append(tags.Content, tag)
+			methods := service.Methods()
+			for j := 0; j < methods.Len(); j++ {
+				method := methods.Get(j)
+				if method.IsStreamingClient() || method.IsStreamingServer() {
+					c.warnings = append(c.warnings, fmt.Sprintf("method %s: streaming RPCs are not supported; skipped", method.FullName()))
+					continue
+				}
+				op, err := c.operation(service, method)
+				if err != nil {
+					return nil, nil, err
+				}
+				item := yamlMapping()
+				yamlSet(item, "post", op)
+				yamlSet(paths, "/"+string(service.FullName())+"/"+string(method.Name()), item)
+			}
+		}
+	}
+	if len(paths.Content) == 0 {
+		return nil, nil, fmt.Errorf("no services with unary methods")
+	}
+
+	info := yamlMapping()
+	yamlSet(info, "title", yamlScalar(strings.Join(packages, ", ")))
+	yamlSet(info, "version", yamlScalar("1.0.0"))
+	yamlSet(doc, "info", info)
+	yamlSet(doc, "tags", tags)
+	yamlSet(doc, "paths", paths)
+	components := yamlMapping()
+	yamlSet(components, "schemas", c.schemas)
+	yamlSet(doc, "components", components)
+	descriptors, err := protobufDescriptorSet(all)
+	if err != nil {
+		return nil, nil, err
+	}
+	rpc := yamlMapping()
+	yamlSet(rpc, "descriptors", yamlScalar(descriptors))
+	yamlSet(doc, "x-rpc", rpc)
+
+	out, err := yaml.Marshal(doc)
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, c.warnings, nil
+}
+
+// operation converts one unary method to an OpenAPI operation.
+func (c *protobufConverter) operation(service protoreflect.ServiceDescriptor, method protoreflect.MethodDescriptor) (*yaml.Node, error) {
+	op := yamlMapping()
+	tags := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	tags.Content = append(tags.Content, yamlScalar(string(service.Name())))
+	yamlSet(op, "tags", tags)
+	summary, description := graphQLSummary(protobufComment(method))
+	if summary == "" {
+		summary = fmt.Sprintf("Call %s.%s", service.Name(), method.Name())
+	}
+	yamlSet(op, "summary", yamlScalar(summary))
+	if description != "" {
+		yamlSet(op, "description", yamlScalar(description))
+	}
+	operationID := string(method.Name())
+	if c.operationIDs[operationID] {
+		operationID = string(service.Name()) + operationID
+	}
+	c.operationIDs[operationID] = true
+	yamlSet(op, "operationId", yamlScalar(operationID))
+	if opts, ok := method.Options().(*descriptorpb.MethodOptions); ok && opts.GetDeprecated() {
+		yamlSet(op, "deprecated", yamlBool(true))
+	}
+
+	requestBody := yamlMapping()
+	yamlSet(requestBody, "content", c.messageContent(method.Input(), "application/json", "application/proto"))
+	yamlSet(op, "requestBody", requestBody)
+
+	ok := yamlMapping()
+	yamlSet(ok, "description", yamlScalar(string(method.Output().Name())))
+	yamlSet(ok, "content", c.messageContent(method.Output(), "application/json"))
+	errorResponse := yamlMapping()
+	yamlSet(errorResponse, "description", yamlScalar("RPC error"))
+	errorContent := yamlMapping()
+	errorMedia := yamlMapping()
+	yamlSet(errorMedia, "schema", protobufErrorSchema())
+	yamlSet(errorContent, "application/json", errorMedia)
+	yamlSet(errorResponse, "content", errorContent)
+	responses := yamlMapping()
+	yamlSet(responses, "200", ok)
+	yamlSet(responses, "default", errorResponse)
+	yamlSet(op, "responses", responses)
+
+	ext := yamlMapping()
+	yamlSet(ext, "service", yamlScalar(string(service.FullName())))
+	yamlSet(ext, "method", yamlScalar(string(method.Name())))
+	yamlSet(ext, "input", yamlScalar(string(method.Input().FullName())))
+	yamlSet(ext, "output", yamlScalar(string(method.Output().FullName())))
+	yamlSet(op, "x-rpc", ext)
+	return op, nil
+}
+
+// messageContent returns a content map with the protojson schema of message
+// under each media type. Request bodies also list application/proto so it can
+// be selected as the request content type; responses come back in the
+// request's encoding, so they only list JSON.
+func (c *protobufConverter) messageContent(message protoreflect.MessageDescriptor, mediaTypes ...string) *yaml.Node {
+	content := yamlMapping()
+	for _, mediaType := range mediaTypes {
+		media := yamlMapping()
+		yamlSet(media, "schema", c.fieldSchema(nil, message))
+		yamlSet(content, mediaType, media)
+	}
+	return content
+}
+
+// protobufErrorSchema describes Connect and Twirp JSON error bodies. Connect
+// reports the text in message and Twirp in msg.
+func protobufErrorSchema() *yaml.Node {
+	props := yamlMapping()
+	for _, name := range []string{"code", "message", "msg"} {
+		prop := yamlMapping()
+		yamlSet(prop, "type", yamlScalar("string"))
+		yamlSet(props, name, prop)
+	}
+	details := yamlMapping()
+	yamlSet(details, "type", yamlScalar("array"))
+	yamlSet(details, "items", yamlMapping())
+	yamlSet(props, "details", details)
+	schema := yamlMapping()
+	yamlSet(schema, "type", yamlScalar("object"))
+	yamlSet(schema, "properties", props)
+	return schema
+}
+
+// protobufDescriptorSet returns the base64 FileDescriptorSet holding files
+// and their imports, dependencies first. Source info is dropped since only the
+// types are needed to encode and decode messages.
+func protobufDescriptorSet(files []protoreflect.FileDescriptor) (string, error) {
+	set := &descriptorpb.FileDescriptorSet{}
+	added := map[string]bool{}
+	var add func(file protoreflect.FileDescriptor)
+	add = func(file protoreflect.FileDescriptor) {
+		if added[file.Path()] {
+			return
+		}
+		added[file.Path()] = true
+		imports := file.Imports()
+		for i := 0; i < imports.Len(); i++ {
+			add(imports.Get(i).FileDescriptor)
+		}
+		fileProto := protodesc.ToFileDescriptorProto(file)
+		fileProto.SourceCodeInfo = nil
+		set.File = append(set.File, fileProto)
+	}
+	for _, file := range files {
+		add(file)
+	}
+	data, err := proto.Marshal(set)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// protobufComment returns the leading comment of d from the source info, if
+// the descriptor set was built with it.
+func protobufComment(d protoreflect.Descriptor) string {
+	loc := d.ParentFile().SourceLocations().ByDescriptor(d)
+	return strings.TrimSpace(loc.LeadingComments)
+}
+
+// protobufWellKnown maps well-known types to their protojson representation.
+var protobufWellKnown = map[protoreflect.FullName]func() *yaml.Node{
+	"google.protobuf.Timestamp": func() *yaml.Node { return protobufScalarSchema("string", "date-time") },
+	"google.protobuf.Duration":  func() *yaml.Node { return protobufScalarSchema("string", "duration") },
+	"google.protobuf.FieldMask": func() *yaml.Node { return protobufScalarSchema("string", "") },
+	"google.protobuf.Struct":    func() *yaml.Node { return protobufScalarSchema("object", "") },
+	"google.protobuf.ListValue": func() *yaml.Node { return protobufScalarSchema("array", "") },
+	"google.protobuf.Value":     yamlMapping,
+	"google.protobuf.Empty":     func() *yaml.Node { return protobufScalarSchema("object", "") },
+	"google.protobuf.Any": func() *yaml.Node {
+		schema := protobufScalarSchema("object", "")
+		props := yamlMapping()
+		yamlSet(props, "@type", protobufScalarSchema("string", ""))
+		yamlSet(schema, "properties", props)
+		return schema
+	},
+	"google.protobuf.BoolValue":   func() *yaml.Node { return protobufScalarSchema("boolean", "") },
+	"google.protobuf.StringValue": func() *yaml.Node { return protobufScalarSchema("string", "") },
+	"google.protobuf.BytesValue":  func() *yaml.Node { return protobufBytesSchema() },
+	"google.protobuf.Int32Value":  func() *yaml.Node { return protobufScalarSchema("integer", "int32") },
+	"google.protobuf.UInt32Value": func() *yaml.Node { return protobufScalarSchema("integer", "uint32") },
+	"google.protobuf.Int64Value":  func() *yaml.Node { return protobufInt64Schema("int64") },
+	"google.protobuf.UInt64Value": func() *yaml.Node { return protobufInt64Schema("uint64") },
+	"google.protobuf.FloatValue":  func() *yaml.Node { return protobufScalarSchema("number", "float") },
+	"google.protobuf.DoubleValue": func() *yaml.Node { return protobufScalarSchema("number", "double") },
+}
+
+// fieldSchema returns the schema for a singular value of field, or for
+// message when field is nil. Messages are referenced from
+// components.schemas by full name, which also handles recursive types.
+func (c *protobufConverter) fieldSchema(field protoreflect.FieldDescriptor, message protoreflect.MessageDescriptor) *yaml.Node {
+	if field != nil {
+		switch field.Kind() {
+		case protoreflect.BoolKind:
+			return protobufScalarSchema("boolean", "")
+		case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+			return protobufScalarSchema("integer", "int32")
+		case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+			return protobufScalarSchema("integer", "uint32")
+		case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+			return protobufInt64Schema("int64")
+		case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+			return protobufInt64Schema("uint64")
+		case protoreflect.FloatKind:
+			return protobufScalarSchema("number", "float")
+		case protoreflect.DoubleKind:
+			return protobufScalarSchema("number", "double")
+		case protoreflect.StringKind:
+			return protobufScalarSchema("string", "")
+		case protoreflect.BytesKind:
+			return protobufBytesSchema()
+		case protoreflect.EnumKind:
+			return c.enumSchema(field.Enum())
+		}
+		message = field.Message()
+	}
+	if wellKnown := protobufWellKnown[message.FullName()]; wellKnown != nil {
+		return wellKnown()
+	}
+	name := string(message.FullName())
+	if !c.seen[message.FullName()] {
+		c.seen[message.FullName()] = true
+		c.messageSchema(message)
+	}
+	ref := yamlMapping()
+	yamlSet(ref, "$ref", yamlScalar("#/components/schemas/"+name))
+	return ref
+}
+
+// messageSchema adds the object schema for message to components.schemas.
+func (c *protobufConverter) messageSchema(message protoreflect.MessageDescriptor) {
+	schema := protobufScalarSchema("object", "")
+	// Reserve the slot first so nested references keep document order.
+	yamlSet(c.schemas, string(message.FullName()), schema)
+	if comment := protobufComment(message); comment != "" {
+		yamlSet(schema, "description", yamlScalar(comment))
+	}
+	props := yamlMapping()
+	fields := message.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		var prop *yaml.Node
+		switch {
+		case field.IsMap():
+			prop = protobufScalarSchema("object", "")
+			yamlSet(prop, "additionalProperties", c.fieldSchema(field.MapValue(), nil))
+		case field.IsList():
+			prop = protobufScalarSchema("array", "")
+			yamlSet(prop, "items", c.fieldSchema(field, nil))
+		default:
+			prop = c.fieldSchema(field, nil)
+		}
+		description := protobufComment(field)
+		if oneof := field.ContainingOneof(); oneof != nil && !oneof.IsSynthetic() {
+			note := fmt.Sprintf("Only one of the %s fields may be set.", oneof.Name())
+			description = strings.TrimSpace(description + "\n\n" + note)
+		}
+		if description != "" {
+			if yamlGet(prop, "$ref") != nil {
+				// Siblings of $ref are allowed in OpenAPI 3.1.
+				prop = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: append([]*yaml.Node(nil), prop.Content...)}
+			}
+			yamlSet(prop, "description", yamlScalar(description))
+		}
+		if opts, ok := field.Options().(*descriptorpb.FieldOptions); ok && opts.GetDeprecated() {
+			yamlSet(prop, "deprecated", yamlBool(true))
+		}
+		yamlSet(props, field.JSONName(), prop)
+	}
+	if len(props.Content) > 0 {
+		yamlSet(schema, "properties", props)
+	}
+}
+
+func (c *protobufConverter) enumSchema(enum protoreflect.EnumDescriptor) *yaml.Node {
+	if enum.FullName() == "google.protobuf.NullValue" {
+		return protobufScalarSchema("null", "")
+	}
+	schema := protobufScalarSchema("string", "")
+	values := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	for i := 0; i < enum.Values().Len(); i++ {
+		values.Content = append(values.Content, yamlScalar(string(enum.Values().Get(i).Name())))
+	}
+	yamlSet(schema, "enum", values)
+	if comment := protobufComment(enum); comment != "" {
+		yamlSet(schema, "description", yamlScalar(comment))
+	}
+	return schema
+}
+
+func protobufScalarSchema(typ, format string) *yaml.Node {
+	schema := yamlMapping()
+	yamlSet(schema, "type", yamlScalar(typ))
+	if format != "" {
+		yamlSet(schema, "format", yamlScalar(format))
+	}
+	return schema
+}
+
+// protobufInt64Schema accepts numbers and strings, since protojson writes
+// 64-bit integers as strings but reads either.
+func protobufInt64Schema(format string) *yaml.Node {
+	schema := yamlMapping()
+	types := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Style: yaml.FlowStyle}
+	types.Content = append(types.Content, yamlScalar("string"), yamlScalar("integer"))
+	yamlSet(schema, "type", types)
+	yamlSet(schema, "format", yamlScalar(format))
+	return schema
+}
+
+func protobufBytesSchema() *yaml.Node {
+	schema := protobufScalarSchema("string", "")
+	yamlSet(schema, "contentEncoding", yamlScalar("base64"))
+	return schema
+}
+
+func opExtRPC(op *v3.Operation) *RPCOperation {
+	if op.Extensions == nil {
+		return nil
+	}
+	rpc := extValue[*RPCOperation](op.Extensions.GetOrZero("x-rpc"))
+	if rpc == nil || rpc.Service == "" || rpc.Method == "" {
+		return nil
+	}
+	return rpc
+}
+
+// RPC returns the document-level x-rpc extension, or nil when the spec was not
+// converted from a descriptor set.
+func (s *APISpec) RPC() (*RPCAPI, error) {
+	model, err := s.V3Model()
+	if err != nil || model == nil || model.Model.Extensions == nil {
+		return nil, err
+	}
+	rpc := extValue[*RPCAPI](model.Model.Extensions.GetOrZero("x-rpc"))
+	if rpc == nil || rpc.Descriptors == "" {
+		return nil, nil
+	}
+	return rpc, nil
+}
+
+// linkRPC points each RPC operation at the set's shared RPC metadata. Operations
+// are copied so a cached set is never modified in place.
+func (set *OperationSet) linkRPC() {
+	for i := range set.Operations {
+		if rpc := set.Operations[i].RPC; rpc != nil {
+			linked := *rpc
+			linked.API = set.RPC
+			set.Operations[i].RPC = &linked
+		}
+	}
+}
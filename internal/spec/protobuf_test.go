@@ -0,0 +1,168 @@
+package spec
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// widgetDescriptorSet builds the descriptor set buf would write for:
+//
+//	service WidgetService {
+//	  // Fetch one widget.
+//	  rpc GetWidget(GetWidgetRequest) returns (Widget);
+//	  rpc WatchWidgets(GetWidgetRequest) returns (stream Widget);
+//	}
+func widgetDescriptorSet(t *testing.T) []byte {
+	t.Helper()
+	field := func(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type, typeName string, label descriptorpb.FieldDescriptorProto_Label) *descriptorpb.FieldDescriptorProto {
+		f := &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			Number:   proto.Int32(number),
+			Type:     typ.Enum(),
+			Label:    label.Enum(),
+			JsonName: proto.String(protobufJSONName(name)),
+		}
+		if typeName != "" {
+			f.TypeName = proto.String(typeName)
+		}
+		return f
+	}
+	optional := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+	repeated := descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+	file := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("acme/widgets/v1/widgets.proto"),
+		Package:    proto.String("acme.widgets.v1"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/protobuf/timestamp.proto"},
+		EnumType: []*descriptorpb.EnumDescriptorProto{{
+			Name: proto.String("Color"),
+			Value: []*descriptorpb.EnumValueDescriptorProto{
+				{Name: proto.String("COLOR_UNSPECIFIED"), Number: proto.Int32(0)},
+				{Name: proto.String("COLOR_RED"), Number: proto.Int32(1)},
+			},
+		}},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Widget"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("id", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, "", optional),
+					field("count", 2, descriptorpb.FieldDescriptorProto_TYPE_INT64, "", optional),
+					field("tags", 3, descriptorpb.FieldDescriptorProto_TYPE_STRING, "", repeated),
+					field("color", 4, descriptorpb.FieldDescriptorProto_TYPE_ENUM, ".acme.widgets.v1.Color", optional),
+					field("created_at", 5, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".google.protobuf.Timestamp", optional),
+					field("labels", 6, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".acme.widgets.v1.Widget.LabelsEntry", repeated),
+				},
+				NestedType: []*descriptorpb.DescriptorProto{{
+					Name: proto.String("LabelsEntry"),
+					Field: []*descriptorpb.FieldDescriptorProto{
+						field("key", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, "", optional),
+						field("value", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING, "", optional),
+					},
+					Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+				}},
+			},
+			{
+				Name: proto.String("GetWidgetRequest"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("widget_id", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, "", optional),
+				},
+			},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("WidgetService"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				{Name: proto.String("GetWidget"), InputType: proto.String(".acme.widgets.v1.GetWidgetRequest"), OutputType: proto.String(".acme.widgets.v1.Widget")},
+				{Name: proto.String("WatchWidgets"), InputType: proto.String(".acme.widgets.v1.GetWidgetRequest"), OutputType: proto.String(".acme.widgets.v1.Widget"), ServerStreaming: proto.Bool(true)},
+			},
+		}},
+		SourceCodeInfo: &descriptorpb.SourceCodeInfo{Location: []*descriptorpb.SourceCodeInfo_Location{{
+			Path:            []int32{6, 0, 2, 0},
+			Span:            []int32{10, 2, 50},
+			LeadingComments: proto.String(" Fetch one widget.\n"),
+		}}},
+	}
+	data, err := proto.Marshal(&descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{
+		protodesc.ToFileDescriptorProto(timestamppb.File_google_protobuf_timestamp_proto),
+		file,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// protobufJSONName is protoc's lowerCamelCase json_name for a field name.
+func protobufJSONName(name string) string {
+	parts := strings.Split(name, "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+func TestProtobufLoaderConvertsDescriptorSet(t *testing.T) {
+	body := widgetDescriptorSet(t)
+	if !(ProtobufLoader{}).Detect("application/octet-stream", body) || (ProtobufLoader{}).Detect("", []byte("\nopenapi: 3.1.0\n")) {
+		t.Fatal("Detect should accept descriptor sets only")
+	}
+	loaded, err := load("application/octet-stream", body, DefaultLoaders())
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	set, err := loaded.OperationSet(OperationOptions{BaseURL: "https://api.example.com"})
+	if err != nil {
+		t.Fatalf("operations: %v", err)
+	}
+	if len(set.Operations) != 1 || !strings.Contains(strings.Join(set.Warnings, "\n"), "WatchWidgets: streaming RPCs are not supported") {
+		t.Fatalf("operations = %d, warnings = %v", len(set.Operations), set.Warnings)
+	}
+
+	op := set.Operations[0]
+	if op.ID != "GetWidget" || op.Method != "POST" || op.Path != "/acme.widgets.v1.WidgetService/GetWidget" || op.Summary != "Fetch one widget." {
+		t.Fatalf("op = %s %s %s %q", op.ID, op.Method, op.Path, op.Summary)
+	}
+	if op.RequestMediaType != "application/json" || op.Help.Request == nil || !strings.Contains(op.Help.Request.Schema, "widgetId") {
+		t.Fatalf("request = %q %#v", op.RequestMediaType, op.Help.Request)
+	}
+	if len(op.Help.Responses) == 0 {
+		t.Fatal("expected response help")
+	}
+	response := op.Help.Responses[0].Schema
+	for _, want := range []string{"createdAt", "COLOR_RED", "labels"} {
+		if !strings.Contains(response, want) {
+			t.Fatalf("response schema missing %q:\n%s", want, response)
+		}
+	}
+	if op.RPC == nil || op.RPC.Service != "acme.widgets.v1.WidgetService" || op.RPC.Input != "acme.widgets.v1.GetWidgetRequest" || op.RPC.Output != "acme.widgets.v1.Widget" {
+		t.Fatalf("RPC = %#v", op.RPC)
+	}
+	if set.RPC == nil || set.RPC.Descriptors == "" || op.RPC.API != set.RPC {
+		t.Fatalf("operation RPC should reference the spec's descriptors: %#v, %#v", op.RPC.API, set.RPC)
+	}
+	if n := strings.Count(string(loaded.Raw), "descriptors:"); n != 1 {
+		t.Fatalf("converted document stores descriptors %d times, want once", n)
+	}
+
+	dir := t.TempDir()
+	opts := OperationOptions{BaseURL: "https://api.example.com"}
+	entry := &cacheEntry{Version: "v1", FetchedAt: time.Now(), ExpiresAt: time.Now().Add(time.Hour), Spec: cachedRaw{ContentType: loaded.ContentType, Raw: loaded.Raw}}
+	entry.upsertOperationSet(opts, set)
+	if err := writeCache(dir, "widgets", entry); err != nil {
+		t.Fatalf("writeCache: %v", err)
+	}
+	cached, ok := LoadOperationSetFromCache(dir, "widgets", "v1", nil, opts)
+	if !ok {
+		t.Fatal("expected operations cache hit")
+	}
+	if cached.RPC == nil || cached.RPC.Descriptors != set.RPC.Descriptors || cached.Operations[0].RPC.API != cached.RPC {
+		t.Fatalf("cached RPC = %#v, operation RPC = %#v", cached.RPC, cached.Operations[0].RPC)
+	}
+}
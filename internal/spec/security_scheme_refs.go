@@ -0,0 +1,116 @@
+package spec
+
+import (
+	"net/url"
+	"strings"
+
+	base "github.com/pb33f/libopenapi/datamodel/high/base"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+const securitySchemeRefPrefix = "#/components/securitySchemes/"
+
+// securitySchemeIndex resolves Security Requirement keys. Besides component
+// names, OpenAPI 3.2 lets a key be a URI reference to a Security Scheme
+// Object. Such keys keep the URI as their canonical ref and get a short
+// display ID for config and diagnostics.
+type securitySchemeIndex struct {
+	schemes map[string]*v3.SecurityScheme
+	// displayIDs maps URI-reference keys to their display IDs.
+	displayIDs map[string]string
+}
+
+func newSecuritySchemeIndex(model *v3.Document) securitySchemeIndex {
+	x := securitySchemeIndex{schemes: securitySchemes(model.Components), displayIDs: map[string]string{}}
+	var refs []string
+	seen := map[string]bool{}
+	collect := func(requirements []*base.SecurityRequirement) {
+		for _, requirement := range requirements {
+			if requirement == nil || requirement.Requirements == nil {
+				continue
+			}
+			for key := range requirement.Requirements.FromOldest() {
+				if !seen[key] && x.schemes[key] == nil && isSecuritySchemeURIRef(key) {
+					seen[key] = true
+					refs = append(refs, key)
+				}
+			}
+		}
+	}
+	collect(model.Security)
+	if model.Paths != nil && model.Paths.PathItems != nil {
+		for _, item := range model.Paths.PathItems.FromOldest() {
+			for _, method := range PathItemMethods(item) {
+				if method.Op != nil {
+					collect(method.Op.Security)
+				}
+			}
+		}
+	}
+
+	// A pointer's last segment is a readable ID unless it names a different
+	// local scheme or another URI shares it.
+	leafCount := map[string]int{}
+	for _, ref := range refs {
+		if leaf := securitySchemeRefLeaf(ref); leaf != "" {
+			leafCount[leaf]++
+		}
+	}
+	for _, ref := range refs {
+		if name, ok := localSecuritySchemeRef(ref); ok && x.schemes[name] != nil {
+			x.displayIDs[ref] = name
+			continue
+		}
+		if leaf := securitySchemeRefLeaf(ref); leaf != "" && leafCount[leaf] == 1 && x.schemes[leaf] == nil {
+			x.displayIDs[ref] = leaf
+		}
+	}
+	return x
+}
+
+// resolve returns the display ID and, when it is declared in this document,
+// the security scheme for a requirement key.
+func (x securitySchemeIndex) resolve(key string) (string, *v3.SecurityScheme) {
+	if scheme := x.schemes[key]; scheme != nil {
+		return key, scheme
+	}
+	if name, ok := localSecuritySchemeRef(key); ok && x.schemes[name] != nil {
+		return name, x.schemes[name]
+	}
+	if id := x.displayIDs[key]; id != "" {
+		return id, nil
+	}
+	return key, nil
+}
+
+// isSecuritySchemeURIRef reports whether a requirement key is a URI
+// reference. Component names are limited to ^[a-zA-Z0-9.\-_]+$, so any other
+// character marks a URI.
+func isSecuritySchemeURIRef(key string) bool {
+	return isAbsoluteURI(key) || strings.ContainsAny(key, "#/")
+}
+
+// localSecuritySchemeRef returns the component name addressed by a
+// same-document reference such as "#/components/securitySchemes/OAuth".
+func localSecuritySchemeRef(key string) (string, bool) {
+	if !strings.HasPrefix(key, securitySchemeRefPrefix) {
+		return "", false
+	}
+	name := jsonPointerUnescape(strings.TrimPrefix(key, securitySchemeRefPrefix))
+	return name, name != "" && !strings.Contains(name, "/")
+}
+
+// securitySchemeRefLeaf returns the last JSON Pointer segment of a URI
+// reference's fragment, or "" when the fragment is not a pointer.
+func securitySchemeRefLeaf(key string) string {
+	u, err := url.Parse(key)
+	if err != nil || !strings.HasPrefix(u.Fragment, "/") {
+		return ""
+	}
+	return jsonPointerUnescape(u.Fragment[strings.LastIndex(u.Fragment, "/")+1:])
+}
+
+func jsonPointerUnescape(value string) string {
+	value = strings.ReplaceAll(value, "~1", "/")
+	return strings.ReplaceAll(value, "~0", "~")
+}
@@ -30,12 +30,14 @@ func (s *APISpec) SecuritySchemeSummaries() ([]SecuritySchemeSummary, error) {
 		return nil, err
 	}
 
+	index := newSecuritySchemeIndex(&model.Model)
 	global := map[string]bool{}
 	for _, req := range model.Model.Security {
 		if req == nil || req.Requirements == nil {
 			continue
 		}
-		for id := range req.Requirements.FromOldest() {
+		for key := range req.Requirements.FromOldest() {
+			id, _ := index.resolve(key)
 			global[id] = true
 		}
 	}
@@ -92,7 +94,7 @@ func oauthFlowSummary(scheme *v3high.SecurityScheme) string {
 	if scheme.Flows.ClientCredentials != nil {
 		flows = append(flows, "clientCredentials")
 	}
-	if scheme.Flows.Device != nil {
+	if oauthDeviceFlow(scheme.Flows) != nil {
 		flows = append(flows, "deviceCode")
 	}
 	if scheme.Flows.Implicit != nil {
@@ -29,6 +29,10 @@ type Loader interface {
 // need to replace the network stack.
 type HTTPFetcher func(ctx context.Context, rawURL string, transport http.RoundTripper) (*http.Response, error)
 
+// HTTPPoster sends a POST with the given body and returns a response whose
+// body the caller must close. Discovery uses it for GraphQL introspection.
+type HTTPPoster func(ctx context.Context, rawURL, contentType string, body []byte, transport http.RoundTripper) (*http.Response, error)
+
 // LoadOptions carries source metadata needed by loaders that resolve external
 // references. Plain loaders may ignore it.
 type LoadOptions struct {
@@ -47,7 +51,8 @@ type LoadOptions struct {
 type APISpec struct {
 	// ContentType is the MIME type the spec was fetched with.
 	ContentType string
-	// Raw is the original spec bytes (JSON or YAML).
+	// Raw is the original spec bytes (JSON or YAML), with any configured
+	// overlays already applied.
 	Raw []byte
 	// Document is the libopenapi parsed representation.
 	Document libopenapi.Document
@@ -58,6 +63,13 @@ type APISpec struct {
 	LocalPath        string
 	AllowCrossOrigin bool
 
+	// loadWarnings are loader diagnostics, such as Swagger 2.0 conversion
+	// notes, reported along with the operation warnings.
+	loadWarnings []string
+	// overlays records the overlay documents applied to Raw so the spec cache
+	// can detect when they change.
+	overlays []cachedSpecFile
+
 	// modelOnce guards lazy construction of the V3 model.
 	modelOnce   sync.Once
 	modelResult *libopenapi.DocumentModel[v3.Document]
@@ -151,7 +163,7 @@ func (s *APISpec) Info() (APIInfo, error) {
 
 // DefaultLoaders returns the built-in set of loaders.
 func DefaultLoaders() []Loader {
-	return []Loader{OpenAPILoader{}}
+	return []Loader{OpenAPILoader{}, CollectionLoader{}, GraphQLLoader{}, AsyncAPILoader{}, ProtobufLoader{}, ODataLoader{}}
 }
 
 // load tries each loader (highest priority first) and returns the first match.
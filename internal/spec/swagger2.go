@@ -0,0 +1,668 @@
+package spec
+
+import (
+	"fmt"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// swagger2Methods are the Swagger 2.0 path item keys that hold operations.
+var swagger2Methods = map[string]bool{
+	"get": true, "put": true, "post": true, "delete": true,
+	"options": true, "head": true, "patch": true,
+}
+
+// swagger2RefPrefixes maps Swagger 2.0 local ref targets to their OpenAPI
+// 3.0 components.
+var swagger2RefPrefixes = []struct{ from, to string }{
+	{"#/definitions/", "#/components/schemas/"},
+	{"#/parameters/", "#/components/parameters/"},
+	{"#/responses/", "#/components/responses/"},
+}
+
+// swagger2Converter translates a Swagger 2.0 document into an equivalent
+// OpenAPI 3.0 document so the normal OpenAPI loader can build operations from
+// it. Anything that has no 3.0 equivalent is dropped with a warning.
+type swagger2Converter struct {
+	root     *yaml.Node
+	consumes []string
+	produces []string
+	warnings []string
+	warned   map[string]bool
+}
+
+// convertSwagger2 returns body converted to OpenAPI 3.0 YAML along with
+// conversion warnings.
+func convertSwagger2(body []byte) ([]byte, []string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(body, &doc); err != nil {
+		return nil, nil, err
+	}
+	root := &doc
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+		root = doc.Content[0]
+	}
+	if root.Kind != yaml.MappingNode {
+		return nil, nil, fmt.Errorf("Swagger 2.0 document must be an object")
+	}
+	c := &swagger2Converter{
+		root:     root,
+		consumes: yamlStrings(yamlGet(root, "consumes")),
+		produces: yamlStrings(yamlGet(root, "produces")),
+		warned:   map[string]bool{},
+	}
+	out, err := yaml.Marshal(c.document())
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, c.warnings, nil
+}
+
+func (c *swagger2Converter) warnf(format string, args ...any) {
+	warning := "Swagger 2.0 conversion: " + fmt.Sprintf(format, args...)
+	if !c.warned[warning] {
+		c.warned[warning] = true
+		c.warnings = append(c.warnings, warning)
+	}
+}
+
+func (c *swagger2Converter) document() *yaml.Node {
+	out := yamlMapping()
+	yamlSet(out, "openapi", yamlScalar("3.0.3"))
+	if info := yamlGet(c.root, "info"); info != nil {
+		yamlSet(out, "info", info)
+	}
+	if servers := c.servers(); servers != nil {
+		yamlSet(out, "servers", servers)
+	}
+	for i := 0; i+1 < len(c.root.Content); i += 2 {
+		key, value := c.root.Content[i].Value, c.root.Content[i+1]
+		switch key {
+		case "swagger", "info", "host", "basePath", "schemes", "consumes", "produces",
+			"definitions", "parameters", "responses", "securityDefinitions":
+		case "tags", "externalDocs", "security":
+			yamlSet(out, key, value)
+		case "paths":
+			yamlSet(out, key, c.paths(value))
+		default:
+			if strings.HasPrefix(key, "x-") {
+				yamlSet(out, key, value)
+				continue
+			}
+			c.warnf("dropped unknown top-level field %q", key)
+		}
+	}
+	if components := c.components(); len(components.Content) > 0 {
+		yamlSet(out, "components", components)
+	}
+	return out
+}
+
+// servers derives OpenAPI servers from host, basePath, and schemes. Without
+// schemes the server is scheme-relative, matching Swagger's rule that the
+// scheme defaults to the one used to fetch the document.
+func (c *swagger2Converter) servers() *yaml.Node {
+	host := yamlString(c.root, "host")
+	basePath := yamlString(c.root, "basePath")
+	if host == "" && basePath == "" {
+		return nil
+	}
+	var urls []string
+	switch schemes := yamlStrings(yamlGet(c.root, "schemes")); {
+	case host == "":
+		urls = append(urls, basePath)
+	case len(schemes) == 0:
+		urls = append(urls, "//"+host+basePath)
+	default:
+		for _, scheme := range schemes {
+			if scheme != "http" && scheme != "https" {
+				c.warnf("dropped unsupported scheme %q", scheme)
+				continue
+			}
+			urls = append(urls, scheme+"://"+host+basePath)
+		}
+	}
+	if len(urls) == 0 {
+		return nil
+	}
+	servers := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, u := range urls {
+		server := yamlMapping()
+		yamlSet(server, "url", yamlScalar(u))
+		servers.Content = append(servers.Content, server)
+	}
+	return servers
+}
+
+func (c *swagger2Converter) components() *yaml.Node {
+	out := yamlMapping()
+	if definitions := yamlGet(c.root, "definitions"); definitions != nil && definitions.Kind == yaml.MappingNode {
+		schemas := yamlMapping()
+		for i := 0; i+1 < len(definitions.Content); i += 2 {
+			yamlSet(schemas, definitions.Content[i].Value, c.schema(definitions.Content[i+1]))
+		}
+		yamlSet(out, "schemas", schemas)
+	}
+	if parameters := yamlGet(c.root, "parameters"); parameters != nil && parameters.Kind == yaml.MappingNode {
+		// Body and formData parameters become request bodies, so they are
+		// inlined where operations reference them instead.
+		converted := yamlMapping()
+		for i := 0; i+1 < len(parameters.Content); i += 2 {
+			param := parameters.Content[i+1]
+			if in := yamlString(param, "in"); in == "body" || in == "formData" {
+				continue
+			}
+			yamlSet(converted, parameters.Content[i].Value, c.parameter(param))
+		}
+		if len(converted.Content) > 0 {
+			yamlSet(out, "parameters", converted)
+		}
+	}
+	if responses := yamlGet(c.root, "responses"); responses != nil && responses.Kind == yaml.MappingNode {
+		converted := yamlMapping()
+		for i := 0; i+1 < len(responses.Content); i += 2 {
+			yamlSet(converted, responses.Content[i].Value, c.response(responses.Content[i+1], c.produces))
+		}
+		yamlSet(out, "responses", converted)
+	}
+	if definitions := yamlGet(c.root, "securityDefinitions"); definitions != nil && definitions.Kind == yaml.MappingNode {
+		schemes := yamlMapping()
+		for i := 0; i+1 < len(definitions.Content); i += 2 {
+			if scheme := c.securityScheme(definitions.Content[i].Value, definitions.Content[i+1]); scheme != nil {
+				yamlSet(schemes, definitions.Content[i].Value, scheme)
+			}
+		}
+		yamlSet(out, "securitySchemes", schemes)
+	}
+	return out
+}
+
+func (c *swagger2Converter) securityScheme(name string, def *yaml.Node) *yaml.Node {
+	out := yamlMapping()
+	switch kind := yamlString(def, "type"); kind {
+	case "basic":
+		yamlSet(out, "type", yamlScalar("http"))
+		yamlSet(out, "scheme", yamlScalar("basic"))
+	case "apiKey":
+		yamlSet(out, "type", yamlScalar("apiKey"))
+		yamlSet(out, "in", yamlScalar(yamlString(def, "in")))
+		yamlSet(out, "name", yamlScalar(yamlString(def, "name")))
+	case "oauth2":
+		flowName := map[string]string{
+			"implicit":    "implicit",
+			"password":    "password",
+			"application": "clientCredentials",
+			"accessCode":  "authorizationCode",
+		}[yamlString(def, "flow")]
+		if flowName == "" {
+			c.warnf("security scheme %q has unsupported OAuth flow %q", name, yamlString(def, "flow"))
+			return nil
+		}
+		flow := yamlMapping()
+		if flowName == "implicit" || flowName == "authorizationCode" {
+			yamlSet(flow, "authorizationUrl", yamlScalar(yamlString(def, "authorizationUrl")))
+		}
+		if flowName != "implicit" {
+			yamlSet(flow, "tokenUrl", yamlScalar(yamlString(def, "tokenUrl")))
+		}
+		scopes := yamlGet(def, "scopes")
+		if scopes == nil {
+			scopes = yamlMapping()
+		}
+		yamlSet(flow, "scopes", scopes)
+		flows := yamlMapping()
+		yamlSet(flows, flowName, flow)
+		yamlSet(out, "type", yamlScalar("oauth2"))
+		yamlSet(out, "flows", flows)
+	default:
+		c.warnf("security scheme %q has unsupported type %q", name, kind)
+		return nil
+	}
+	yamlCopyFields(out, def, "description")
+	yamlCopyExtensions(out, def)
+	return out
+}
+
+func (c *swagger2Converter) paths(paths *yaml.Node) *yaml.Node {
+	out := yamlMapping()
+	if paths == nil || paths.Kind != yaml.MappingNode {
+		return out
+	}
+	for i := 0; i+1 < len(paths.Content); i += 2 {
+		key, item := paths.Content[i].Value, paths.Content[i+1]
+		if strings.HasPrefix(key, "x-") {
+			yamlSet(out, key, item)
+			continue
+		}
+		yamlSet(out, key, c.pathItem(key, item))
+	}
+	return out
+}
+
+func (c *swagger2Converter) pathItem(path string, item *yaml.Node) *yaml.Node {
+	out := yamlMapping()
+	if item == nil || item.Kind != yaml.MappingNode {
+		return out
+	}
+	// Path-level parameters may include body and formData parameters, which
+	// OpenAPI 3.0 only allows per operation, so they are merged into each
+	// operation.
+	pathParams := yamlGet(item, "parameters")
+	for i := 0; i+1 < len(item.Content); i += 2 {
+		key, value := item.Content[i].Value, item.Content[i+1]
+		switch {
+		case key == "parameters":
+		case key == "$ref":
+			c.warnf("path %s uses an unsupported path item $ref", path)
+		case swagger2Methods[key]:
+			yamlSet(out, key, c.operation(strings.ToUpper(key)+" "+path, value, pathParams))
+		case strings.HasPrefix(key, "x-"):
+			yamlSet(out, key, value)
+		default:
+			c.warnf("path %s: dropped unknown field %q", path, key)
+		}
+	}
+	return out
+}
+
+func (c *swagger2Converter) operation(label string, op, pathParams *yaml.Node) *yaml.Node {
+	out := yamlMapping()
+	if op == nil || op.Kind != yaml.MappingNode {
+		return out
+	}
+	consumes := c.consumes
+	if value := yamlGet(op, "consumes"); value != nil {
+		consumes = yamlStrings(value)
+	}
+	produces := c.produces
+	if value := yamlGet(op, "produces"); value != nil {
+		produces = yamlStrings(value)
+	}
+	for i := 0; i+1 < len(op.Content); i += 2 {
+		key, value := op.Content[i].Value, op.Content[i+1]
+		switch key {
+		case "tags", "summary", "description", "externalDocs", "operationId", "deprecated", "security":
+			yamlSet(out, key, value)
+		case "consumes", "produces", "parameters", "responses":
+		case "schemes":
+			c.warnf("%s: operation-level schemes are ignored", label)
+		default:
+			if strings.HasPrefix(key, "x-") {
+				yamlSet(out, key, value)
+				continue
+			}
+			c.warnf("%s: dropped unknown field %q", label, key)
+		}
+	}
+
+	params, requestBody := c.operationParameters(label, pathParams, yamlGet(op, "parameters"), consumes)
+	if len(params.Content) > 0 {
+		yamlSet(out, "parameters", params)
+	}
+	if requestBody != nil {
+		yamlSet(out, "requestBody", requestBody)
+	}
+	responses := yamlMapping()
+	if value := yamlGet(op, "responses"); value != nil && value.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(value.Content); i += 2 {
+			code, response := value.Content[i].Value, value.Content[i+1]
+			if strings.HasPrefix(code, "x-") {
+				yamlSet(responses, code, response)
+				continue
+			}
+			yamlSet(responses, code, c.response(response, produces))
+		}
+	}
+	yamlSet(out, "responses", responses)
+	return out
+}
+
+// operationParameters merges path and operation parameters, with operation
+// parameters overriding path parameters of the same name and location, and
+// splits body and formData parameters out into a request body.
+func (c *swagger2Converter) operationParameters(label string, pathParams, opParams *yaml.Node, consumes []string) (*yaml.Node, *yaml.Node) {
+	type entry struct {
+		param *yaml.Node
+		ref   string
+	}
+	var order []string
+	byKey := map[string]entry{}
+	for _, list := range []*yaml.Node{pathParams, opParams} {
+		if list == nil || list.Kind != yaml.SequenceNode {
+			continue
+		}
+		for _, raw := range list.Content {
+			param, ref := c.resolveParameter(raw)
+			if param == nil {
+				c.warnf("%s: parameter %q could not be resolved", label, ref)
+				continue
+			}
+			key := yamlString(param, "in") + ":" + yamlString(param, "name")
+			if _, ok := byKey[key]; !ok {
+				order = append(order, key)
+			}
+			byKey[key] = entry{param: param, ref: ref}
+		}
+	}
+
+	params := &yaml.Node{Kind: yaml.SequenceNode}
+	var body *yaml.Node
+	var form []*yaml.Node
+	for _, key := range order {
+		e := byKey[key]
+		switch yamlString(e.param, "in") {
+		case "body":
+			body = e.param
+		case "formData":
+			form = append(form, e.param)
+		default:
+			if ref := swagger2Ref(e.ref); ref != e.ref {
+				refNode := yamlMapping()
+				yamlSet(refNode, "$ref", yamlScalar(ref))
+				params.Content = append(params.Content, refNode)
+				continue
+			}
+			params.Content = append(params.Content, c.parameter(e.param))
+		}
+	}
+	switch {
+	case body != nil && len(form) > 0:
+		c.warnf("%s: formData parameters are ignored alongside a body parameter", label)
+		return params, c.bodyRequest(body, consumes)
+	case body != nil:
+		return params, c.bodyRequest(body, consumes)
+	case len(form) > 0:
+		return params, c.formRequest(form, consumes)
+	}
+	return params, nil
+}
+
+// resolveParameter follows a reference to a global parameter. Global
+// parameters are needed inline because body and formData parameters turn into
+// request bodies.
+func (c *swagger2Converter) resolveParameter(param *yaml.Node) (*yaml.Node, string) {
+	ref := mappingRefValue(param)
+	if ref == "" {
+		return param, ""
+	}
+	if !strings.HasPrefix(ref, "#/parameters/") {
+		return nil, ref
+	}
+	name := jsonPointerUnescape(strings.TrimPrefix(ref, "#/parameters/"))
+	return yamlGet(yamlGet(c.root, "parameters"), name), ref
+}
+
+// swagger2SchemaFields are the parameter, header, and items fields that move
+// into an OpenAPI 3.0 schema.
+var swagger2SchemaFields = map[string]bool{
+	"type": true, "format": true, "items": true, "default": true,
+	"maximum": true, "exclusiveMaximum": true, "minimum": true, "exclusiveMinimum": true,
+	"maxLength": true, "minLength": true, "pattern": true,
+	"maxItems": true, "minItems": true, "uniqueItems": true,
+	"enum": true, "multipleOf": true,
+}
+
+func (c *swagger2Converter) parameter(param *yaml.Node) *yaml.Node {
+	out := yamlMapping()
+	in := yamlString(param, "in")
+	name := yamlString(param, "name")
+	schema := yamlMapping()
+	for i := 0; i+1 < len(param.Content); i += 2 {
+		key, value := param.Content[i].Value, param.Content[i+1]
+		switch {
+		case key == "name" || key == "in" || key == "description" || key == "required":
+			yamlSet(out, key, value)
+		case key == "allowEmptyValue":
+			if in == "query" {
+				yamlSet(out, key, value)
+			}
+		case key == "x-example":
+			yamlSet(out, "example", value)
+		case swagger2SchemaFields[key]:
+			yamlSet(schema, key, value)
+		case strings.HasPrefix(key, "x-"):
+			yamlSet(out, key, value)
+		}
+	}
+	if yamlString(schema, "type") == "file" {
+		c.warnf("parameter %q: file type is only supported for formData parameters", name)
+	}
+	if yamlString(schema, "type") == "array" {
+		c.setCollectionFormat(out, in, name, yamlString(param, "collectionFormat"))
+	}
+	yamlSet(out, "schema", c.schema(schema))
+	return out
+}
+
+// setCollectionFormat maps a Swagger array serialization to an OpenAPI 3.0
+// style. The Swagger default is csv, which differs from the OpenAPI 3.0
+// default for query and cookie parameters.
+func (c *swagger2Converter) setCollectionFormat(out *yaml.Node, in, name, format string) {
+	style, explode := "", false
+	switch format {
+	case "", "csv":
+		if in == "query" {
+			style = "form"
+		}
+	case "multi":
+		if in != "query" {
+			c.warnf("parameter %q: collectionFormat multi is only valid for query parameters", name)
+			return
+		}
+		style, explode = "form", true
+	case "ssv":
+		style = "spaceDelimited"
+	case "pipes":
+		style = "pipeDelimited"
+	default:
+		c.warnf("parameter %q: unsupported collectionFormat %q", name, format)
+		return
+	}
+	if style == "" {
+		return
+	}
+	if (style == "spaceDelimited" || style == "pipeDelimited") && in != "query" {
+		c.warnf("parameter %q: collectionFormat %s is only supported for query parameters", name, format)
+		return
+	}
+	yamlSet(out, "style", yamlScalar(style))
+	yamlSet(out, "explode", yamlBool(explode))
+}
+
+func (c *swagger2Converter) bodyRequest(param *yaml.Node, consumes []string) *yaml.Node {
+	out := yamlMapping()
+	yamlCopyFields(out, param, "description", "required")
+	yamlCopyExtensions(out, param)
+	schema := yamlGet(param, "schema")
+	if schema == nil {
+		schema = yamlMapping()
+	}
+	if len(consumes) == 0 {
+		consumes = []string{"application/json"}
+	}
+	content := yamlMapping()
+	for _, mediaType := range consumes {
+		media := yamlMapping()
+		yamlSet(media, "schema", c.schema(schema))
+		yamlSet(content, mediaType, media)
+	}
+	yamlSet(out, "content", content)
+	return out
+}
+
+func (c *swagger2Converter) formRequest(params []*yaml.Node, consumes []string) *yaml.Node {
+	schema := yamlMapping()
+	yamlSet(schema, "type", yamlScalar("object"))
+	properties := yamlMapping()
+	required := &yaml.Node{Kind: yaml.SequenceNode}
+	hasFile := false
+	for _, param := range params {
+		name := yamlString(param, "name")
+		property := yamlMapping()
+		for i := 0; i+1 < len(param.Content); i += 2 {
+			key, value := param.Content[i].Value, param.Content[i+1]
+			if swagger2SchemaFields[key] || key == "description" {
+				yamlSet(property, key, value)
+			}
+		}
+		if yamlString(property, "type") == "file" {
+			hasFile = true
+		}
+		yamlSet(properties, name, c.schema(property))
+		if yamlString(param, "required") == "true" {
+			required.Content = append(required.Content, yamlScalar(name))
+		}
+	}
+	yamlSet(schema, "properties", properties)
+	if len(required.Content) > 0 {
+		yamlSet(schema, "required", required)
+	}
+
+	var mediaTypes []string
+	for _, mediaType := range consumes {
+		base := strings.ToLower(strings.TrimSpace(strings.Split(mediaType, ";")[0]))
+		if base == "multipart/form-data" || base == "application/x-www-form-urlencoded" {
+			mediaTypes = append(mediaTypes, mediaType)
+		}
+	}
+	if len(mediaTypes) == 0 {
+		if hasFile {
+			mediaTypes = []string{"multipart/form-data"}
+		} else {
+			mediaTypes = []string{"application/x-www-form-urlencoded"}
+		}
+	}
+	content := yamlMapping()
+	for _, mediaType := range mediaTypes {
+		media := yamlMapping()
+		yamlSet(media, "schema", cloneYAMLNode(schema))
+		yamlSet(content, mediaType, media)
+	}
+	out := yamlMapping()
+	if len(required.Content) > 0 {
+		yamlSet(out, "required", yamlBool(true))
+	}
+	yamlSet(out, "content", content)
+	return out
+}
+
+func (c *swagger2Converter) response(response *yaml.Node, produces []string) *yaml.Node {
+	if ref := mappingRefValue(response); ref != "" {
+		out := yamlMapping()
+		yamlSet(out, "$ref", yamlScalar(swagger2Ref(ref)))
+		return out
+	}
+	out := yamlMapping()
+	description := yamlGet(response, "description")
+	if description == nil {
+		description = yamlScalar("")
+	}
+	yamlSet(out, "description", description)
+	if headers := yamlGet(response, "headers"); headers != nil && headers.Kind == yaml.MappingNode {
+		converted := yamlMapping()
+		for i := 0; i+1 < len(headers.Content); i += 2 {
+			converted.Content = append(converted.Content, yamlScalar(headers.Content[i].Value), c.header(headers.Content[i+1]))
+		}
+		yamlSet(out, "headers", converted)
+	}
+	if schema := yamlGet(response, "schema"); schema != nil {
+		if len(produces) == 0 {
+			produces = []string{"application/json"}
+		}
+		examples := yamlGet(response, "examples")
+		content := yamlMapping()
+		for _, mediaType := range produces {
+			media := yamlMapping()
+			yamlSet(media, "schema", c.schema(schema))
+			if example := yamlGet(examples, mediaType); example != nil {
+				yamlSet(media, "example", example)
+			}
+			yamlSet(content, mediaType, media)
+		}
+		yamlSet(out, "content", content)
+	}
+	yamlCopyExtensions(out, response)
+	return out
+}
+
+func (c *swagger2Converter) header(header *yaml.Node) *yaml.Node {
+	out := yamlMapping()
+	schema := yamlMapping()
+	for i := 0; i+1 < len(header.Content); i += 2 {
+		key, value := header.Content[i].Value, header.Content[i+1]
+		switch {
+		case key == "description":
+			yamlSet(out, key, value)
+		case swagger2SchemaFields[key]:
+			yamlSet(schema, key, value)
+		case strings.HasPrefix(key, "x-"):
+			yamlSet(out, key, value)
+		}
+	}
+	yamlSet(out, "schema", c.schema(schema))
+	return out
+}
+
+// schema returns a copy of a Swagger 2.0 schema with its refs and the
+// 2.0-only keywords translated.
+func (c *swagger2Converter) schema(n *yaml.Node) *yaml.Node {
+	out := cloneYAMLNode(n)
+	c.convertSchema(out)
+	return out
+}
+
+func (c *swagger2Converter) convertSchema(n *yaml.Node) {
+	if n == nil || n.Kind != yaml.MappingNode {
+		return
+	}
+	if ref := mappingRefValue(n); ref != "" {
+		yamlSet(n, "$ref", yamlScalar(swagger2Ref(ref)))
+		return
+	}
+	binary := false
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key, value := n.Content[i], n.Content[i+1]
+		switch key.Value {
+		case "x-nullable":
+			key.Value = "nullable"
+		case "type":
+			if value.Value == "file" {
+				value.Value = "string"
+				binary = true
+			}
+		case "discriminator":
+			if value.Kind == yaml.ScalarNode {
+				discriminator := yamlMapping()
+				yamlSet(discriminator, "propertyName", yamlScalar(value.Value))
+				n.Content[i+1] = discriminator
+			}
+		case "properties":
+			if value.Kind == yaml.MappingNode {
+				for j := 1; j < len(value.Content); j += 2 {
+					c.convertSchema(value.Content[j])
+				}
+			}
+		case "items", "additionalProperties", "not":
+			c.convertSchema(value)
+		case "allOf", "anyOf", "oneOf":
+			for _, child := range value.Content {
+				c.convertSchema(child)
+			}
+		}
+	}
+	if binary {
+		yamlSet(n, "format", yamlScalar("binary"))
+	}
+}
+
+// swagger2Ref rewrites a local Swagger 2.0 ref to its OpenAPI 3.0 component.
+// External refs are resolved against their own documents and stay unchanged.
+func swagger2Ref(ref string) string {
+	for _, prefix := range swagger2RefPrefixes {
+		if strings.HasPrefix(ref, prefix.from) {
+			return prefix.to + strings.TrimPrefix(ref, prefix.from)
+		}
+	}
+	return ref
+}
@@ -0,0 +1,227 @@
+package spec
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const swagger2PetStore = `swagger: "2.0"
+info:
+  title: Pets
+  version: "1.0.0"
+host: api.example.com
+basePath: /v1
+schemes: [https]
+consumes: [application/json]
+produces: [application/json]
+x-cli-config:
+  profiles:
+    default:
+      headers:
+        - "X-Client: restish"
+securityDefinitions:
+  apiKey:
+    type: apiKey
+    in: header
+    name: X-API-Key
+  oauth:
+    type: oauth2
+    flow: accessCode
+    authorizationUrl: https://auth.example.com/authorize
+    tokenUrl: https://auth.example.com/token
+    scopes:
+      pets:write: Modify pets
+security:
+  - apiKey: []
+parameters:
+  limit:
+    name: limit
+    in: query
+    type: integer
+    default: 20
+  pet:
+    name: pet
+    in: body
+    required: true
+    schema:
+      $ref: "#/definitions/Pet"
+definitions:
+  Pet:
+    type: object
+    required: [name]
+    properties:
+      name:
+        type: string
+      tag:
+        type: string
+        x-nullable: true
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      x-cli-name: ls
+      parameters:
+        - $ref: "#/parameters/limit"
+        - name: tags
+          in: query
+          type: array
+          items:
+            type: string
+        - name: ids
+          in: query
+          type: array
+          collectionFormat: multi
+          items:
+            type: integer
+        - name: fields
+          in: query
+          type: array
+          collectionFormat: tsv
+          items:
+            type: string
+      responses:
+        "200":
+          description: OK
+          schema:
+            type: array
+            items:
+              $ref: "#/definitions/Pet"
+    post:
+      operationId: createPet
+      security:
+        - oauth: [pets:write]
+      parameters:
+        - $ref: "#/parameters/pet"
+      responses:
+        "201":
+          description: Created
+  /pets/{petId}/photo:
+    parameters:
+      - name: petId
+        in: path
+        required: true
+        type: string
+    put:
+      operationId: uploadPhoto
+      consumes: [multipart/form-data]
+      parameters:
+        - name: file
+          in: formData
+          required: true
+          type: file
+        - name: caption
+          in: formData
+          type: string
+      responses:
+        "204":
+          description: Uploaded
+`
+
+func TestOpenAPILoader_ConvertsSwagger2(t *testing.T) {
+	loaded, err := load("application/yaml", []byte(swagger2PetStore), DefaultLoaders())
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if isSwagger2Document(loaded.Raw) || !strings.Contains(string(loaded.Raw), "openapi: 3.0.3") {
+		t.Fatalf("Raw should hold the converted OpenAPI document:\n%s", loaded.Raw)
+	}
+	var warned []string
+	ops, err := loaded.Operations(OperationOptions{
+		BaseURL: "https://api.example.com",
+		Warnf: func(format string, args ...any) {
+			warned = append(warned, fmt.Sprintf(format, args...))
+		},
+	})
+	if err != nil {
+		t.Fatalf("operations: %v", err)
+	}
+
+	list := operationByID(t, ops, "listPets")
+	if list.Path != "/v1/pets" || list.XCLI.Name != "ls" {
+		t.Fatalf("listPets path/name = %q/%q", list.Path, list.XCLI.Name)
+	}
+	params := map[string]Param{}
+	for _, p := range list.Parameters {
+		params[p.Name] = p
+	}
+	if params["limit"].Type != "integer" || params["limit"].Default != "20" {
+		t.Fatalf("limit param = %#v", params["limit"])
+	}
+	if p := params["tags"]; p.Style != "form" || p.Explode == nil || *p.Explode {
+		t.Fatalf("csv tags param should be form/explode=false, got %#v", p)
+	}
+	if p := params["ids"]; p.Style != "form" || p.Explode == nil || !*p.Explode {
+		t.Fatalf("multi ids param should be form/explode=true, got %#v", p)
+	}
+	if list.ResponseMediaType != "application/json" {
+		t.Fatalf("listPets response media type = %q", list.ResponseMediaType)
+	}
+	requireCredential(t, list, [][]CredentialRequirement{{
+		{ID: "apiKey", Ref: "#/components/securitySchemes/apiKey", Kind: "api-key", In: "header", Name: "X-API-Key", Source: "openapi"},
+	}})
+
+	create := operationByID(t, ops, "createPet")
+	if !create.HasBody || !create.BodyRequired || create.RequestMediaType != "application/json" {
+		t.Fatalf("createPet body = %v/%v/%q", create.HasBody, create.BodyRequired, create.RequestMediaType)
+	}
+	if create.Help.Request == nil || !strings.Contains(create.Help.Request.Schema, "name") {
+		t.Fatalf("createPet request help = %#v", create.Help.Request)
+	}
+	requireCredential(t, create, [][]CredentialRequirement{{
+		{ID: "oauth", Ref: "#/components/securitySchemes/oauth", Kind: "oauth2", Needs: []string{"pets:write"}, Source: "openapi"},
+	}})
+
+	upload := operationByID(t, ops, "uploadPhoto")
+	if upload.Path != "/v1/pets/{petId}/photo" || upload.RequestMediaType != "multipart/form-data" || !upload.BodyRequired {
+		t.Fatalf("uploadPhoto = %q %q required=%v", upload.Path, upload.RequestMediaType, upload.BodyRequired)
+	}
+	if len(upload.Parameters) != 1 || upload.Parameters[0].Name != "petId" || !upload.Parameters[0].Required {
+		t.Fatalf("uploadPhoto params = %#v", upload.Parameters)
+	}
+
+	want := []string{`Swagger 2.0 conversion: parameter "fields": unsupported collectionFormat "tsv"`}
+	if !reflect.DeepEqual(warned, want) {
+		t.Fatalf("warnings = %#v, want %#v", warned, want)
+	}
+}
+
+func TestSwagger2SecuritySchemesAndXCLIConfig(t *testing.T) {
+	doc := loadDoc(t, swagger2PetStore)
+	model, err := doc.V3Model()
+	if err != nil {
+		t.Fatalf("V3Model: %v", err)
+	}
+	oauth := model.Model.Components.SecuritySchemes.GetOrZero("oauth")
+	if oauth == nil || oauth.Flows == nil || oauth.Flows.AuthorizationCode == nil ||
+		oauth.Flows.AuthorizationCode.TokenUrl != "https://auth.example.com/token" {
+		t.Fatalf("oauth scheme = %#v", oauth)
+	}
+	tag := model.Model.Components.Schemas.GetOrZero("Pet").Schema().Properties.GetOrZero("tag").Schema()
+	if tag.Nullable == nil || !*tag.Nullable {
+		t.Fatal("x-nullable should become nullable")
+	}
+	cfg, err := ReadXCLIConfig(doc)
+	if err != nil || cfg == nil || cfg.Profiles["default"] == nil {
+		t.Fatalf("ReadXCLIConfig = %#v, %v", cfg, err)
+	}
+}
+
+func TestConvertSwagger2ServersWithoutSchemes(t *testing.T) {
+	out, warnings, err := convertSwagger2([]byte(`swagger: "2.0"
+info: {title: T, version: "1"}
+host: api.example.com
+basePath: /base
+paths: {}
+`))
+	if err != nil {
+		t.Fatalf("convertSwagger2: %v", err)
+	}
+	if len(warnings) != 0 {
+		t.Fatalf("warnings = %#v", warnings)
+	}
+	if !strings.Contains(string(out), "url: //api.example.com/base") {
+		t.Fatalf("converted document should use a scheme-relative server:\n%s", out)
+	}
+}
@@ -102,7 +102,9 @@ func ReadXCLIConfig(s *APISpec) (*XCLIConfig, error) {
 // FallbackXCLIConfig derives an XCLIConfig from the document's referenced
 // security schemes when the spec does not define x-cli-config. The first scheme
 // named in the document-level security requirements is preferred, followed by
-// operation-level security requirements.
+// operation-level security requirements. Deprecated schemes are only chosen
+// when no supported scheme remains current. Requirement keys that reference a
+// local scheme by URI resolve to its component name.
 //
 // Returns nil when no supported auth scheme can be derived.
 func FallbackXCLIConfig(s *APISpec) *XCLIConfig {
@@ -116,17 +118,23 @@ func FallbackXCLIConfig(s *APISpec) *XCLIConfig {
 	}
 
 	schemes := model.Model.Components.SecuritySchemes
-	preferredNames := referencedFallbackSecuritySchemeNames(model.Model)
+	preferredNames := referencedFallbackSecuritySchemeNames(model.Model, newSecuritySchemeIndex(&model.Model))
 	if len(preferredNames) == 0 {
 		return nil
 	}
 
 	var chosenName string
 	var chosenScheme *v3high.SecurityScheme
-	for _, name := range preferredNames {
-		if scheme := schemes.GetOrZero(name); fallbackSchemeSupported(scheme) {
-			chosenName = name
-			chosenScheme = scheme
+	for _, allowDeprecated := range []bool{false, true} {
+		for _, name := range preferredNames {
+			scheme := schemes.GetOrZero(name)
+			if fallbackSchemeSupported(scheme) && (allowDeprecated || !scheme.Deprecated) {
+				chosenName = name
+				chosenScheme = scheme
+				break
+			}
+		}
+		if chosenScheme != nil {
 			break
 		}
 	}
@@ -202,7 +210,7 @@ func fallbackSchemeSupported(scheme *v3high.SecurityScheme) bool {
 	return SchemeToXCLIAuth(scheme, nil) != nil || SchemeToXCLIAPIKeyProfile(scheme) != nil
 }
 
-func referencedFallbackSecuritySchemeNames(model v3high.Document) []string {
+func referencedFallbackSecuritySchemeNames(model v3high.Document, index securitySchemeIndex) []string {
 	seen := map[string]bool{}
 	names := []string{}
 	add := func(name string) {
@@ -217,7 +225,8 @@ func referencedFallbackSecuritySchemeNames(model v3high.Document) []string {
 			if req == nil || req.Requirements == nil {
 				continue
 			}
-			for name := range req.Requirements.FromOldest() {
+			for key := range req.Requirements.FromOldest() {
+				name, _ := index.resolve(key)
 				add(name)
 			}
 		}
@@ -260,6 +269,10 @@ func SchemeToXCLIAuth(scheme *v3high.SecurityScheme, params map[string]string) *
 			authType = "http-basic"
 			p["username"] = ""
 			p["password"] = ""
+		case "digest":
+			authType = "http-digest"
+			p["username"] = ""
+			p["password"] = ""
 		case "bearer":
 			authType = "bearer"
 			p["token"] = ""
@@ -288,13 +301,12 @@ func SchemeToXCLIAuth(scheme *v3high.SecurityScheme, params map[string]string) *
 			if scheme.OAuth2MetadataUrl != "" {
 				p["oauth2_metadata_url"] = scheme.OAuth2MetadataUrl
 			}
-		} else if scheme.Flows.Device != nil {
-			device := scheme.Flows.Device
+		} else if device := oauthDeviceFlow(scheme.Flows); device != nil {
 			authType = "oauth-device-code"
 			p["client_id"] = ""
-			p["token_url"] = device.TokenUrl
-			if device.AuthorizationUrl != "" {
-				p["device_authorization_url"] = device.AuthorizationUrl
+			p["token_url"] = device.TokenURL
+			if device.DeviceAuthorizationURL != "" {
+				p["device_authorization_url"] = device.DeviceAuthorizationURL
 			}
 			if scheme.OAuth2MetadataUrl != "" {
 				p["oauth2_metadata_url"] = scheme.OAuth2MetadataUrl
@@ -315,6 +327,35 @@ func SchemeToXCLIAuth(scheme *v3high.SecurityScheme, params map[string]string) *
 	return &XCLIAuth{Type: authType, Params: p}
 }
 
+// deviceFlow is an OAuth 2.0 Device Authorization flow (RFC 8628).
+type deviceFlow struct {
+	DeviceAuthorizationURL string `yaml:"deviceAuthorizationUrl"`
+	TokenURL               string `yaml:"tokenUrl"`
+}
+
+// oauthDeviceFlow returns the device flow of an OAuth Flows Object, or nil.
+// OpenAPI 3.2 names it deviceAuthorization with a deviceAuthorizationUrl.
+// libopenapi only models a pre-release "device" flow with an
+// authorizationUrl, so the published shape is decoded from the raw node and
+// the pre-release one is kept as a fallback.
+func oauthDeviceFlow(flows *v3high.OAuthFlows) *deviceFlow {
+	if flows == nil {
+		return nil
+	}
+	if low := flows.GoLow(); low != nil && low.RootNode != nil {
+		var raw struct {
+			DeviceAuthorization *deviceFlow `yaml:"deviceAuthorization"`
+		}
+		if err := low.RootNode.Decode(&raw); err == nil && raw.DeviceAuthorization != nil {
+			return raw.DeviceAuthorization
+		}
+	}
+	if flows.Device != nil {
+		return &deviceFlow{DeviceAuthorizationURL: flows.Device.AuthorizationUrl, TokenURL: flows.Device.TokenUrl}
+	}
+	return nil
+}
+
 // SchemeToXCLIAPIKeyProfile converts an OpenAPI apiKey security scheme into
 // first-class setup prompts that persist as profile headers or query params.
 func SchemeToXCLIAPIKeyProfile(scheme *v3high.SecurityScheme) *XCLIProfile {
@@ -184,6 +184,29 @@ components:
 	}
 }
 
+func TestSchemeToXCLIAuth_HTTPDigest(t *testing.T) {
+	raw := `
+openapi: "3.1.0"
+info:
+  title: Test
+  version: "1.0.0"
+paths: {}
+components:
+  securitySchemes:
+    digest:
+      type: http
+      scheme: digest`
+	doc := loadDoc(t, raw)
+	model, _ := doc.V3Model()
+	auth := SchemeToXCLIAuth(model.Model.Components.SecuritySchemes.GetOrZero("digest"), nil)
+	if auth == nil || auth.Type != "http-digest" {
+		t.Fatalf("auth = %#v, want http-digest", auth)
+	}
+	if _, ok := auth.Params["username"]; !ok {
+		t.Error("expected username param")
+	}
+}
+
 func TestSchemeToXCLIAuth_OAuth2AuthCode(t *testing.T) {
 	raw := `
 openapi: "3.1.0"
@@ -288,6 +311,42 @@ components:
 	}
 }
 
+func TestSchemeToXCLIAuth_OAuthDeviceAuthorizationFlow(t *testing.T) {
+	raw := `
+openapi: "3.2.0"
+info:
+  title: Test
+  version: "1.0.0"
+paths: {}
+components:
+  securitySchemes:
+    device:
+      type: oauth2
+      flows:
+        deviceAuthorization:
+          deviceAuthorizationUrl: https://auth.example.com/oauth/device
+          tokenUrl: https://auth.example.com/oauth/token
+          scopes:
+            read: Read access`
+	doc := loadDoc(t, raw)
+	model, err := doc.V3Model()
+	if err != nil || model == nil {
+		t.Fatalf("BuildV3Model: %v", err)
+	}
+	scheme := model.Model.Components.SecuritySchemes.GetOrZero("device")
+	auth := SchemeToXCLIAuth(scheme, nil)
+	if auth == nil || auth.Type != "oauth-device-code" {
+		t.Fatalf("auth = %#v, want oauth-device-code", auth)
+	}
+	if auth.Params["device_authorization_url"] != "https://auth.example.com/oauth/device" ||
+		auth.Params["token_url"] != "https://auth.example.com/oauth/token" {
+		t.Fatalf("Params = %#v", auth.Params)
+	}
+	if got := securitySchemeDetail(scheme); got != "oauth2 deviceCode" {
+		t.Fatalf("detail = %q, want oauth2 deviceCode", got)
+	}
+}
+
 func TestSchemeToXCLIAuth_APIKey(t *testing.T) {
 	raw := `
 openapi: "3.1.0"
@@ -545,6 +604,59 @@ components:
 	}
 }
 
+func TestFallbackXCLIConfig_PrefersNonDeprecatedScheme(t *testing.T) {
+	raw := `
+openapi: "3.2.0"
+info:
+  title: Test
+  version: "1.0.0"
+security:
+  - legacy: []
+  - "#/components/securitySchemes/bearer": []
+paths: {}
+components:
+  securitySchemes:
+    legacy:
+      type: apiKey
+      in: header
+      name: X-Legacy-Key
+      deprecated: true
+    bearer:
+      type: http
+      scheme: bearer`
+	doc := loadDoc(t, raw)
+	cfg := FallbackXCLIConfig(doc)
+	if cfg == nil {
+		t.Fatal("expected fallback config")
+	}
+	profile := cfg.Profiles["default"]
+	if profile == nil || profile.Security != "bearer" || profile.Auth == nil || profile.Auth.Type != "bearer" {
+		t.Fatalf("default profile = %#v, want bearer security", profile)
+	}
+}
+
+func TestFallbackXCLIConfig_UsesDeprecatedSchemeAsLastResort(t *testing.T) {
+	raw := `
+openapi: "3.2.0"
+info:
+  title: Test
+  version: "1.0.0"
+security:
+  - legacy: []
+paths: {}
+components:
+  securitySchemes:
+    legacy:
+      type: http
+      scheme: basic
+      deprecated: true`
+	doc := loadDoc(t, raw)
+	cfg := FallbackXCLIConfig(doc)
+	if cfg == nil || cfg.Profiles["default"] == nil || cfg.Profiles["default"].Security != "legacy" {
+		t.Fatalf("fallback config = %#v, want deprecated legacy scheme", cfg)
+	}
+}
+
 // ---- Resolve ---------------------------------------------------------------
 
 func TestResolve_SecurityToAuth(t *testing.T) {
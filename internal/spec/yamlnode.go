@@ -0,0 +1,87 @@
+package spec
+
+import (
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// yamlMapping returns an empty mapping node. It and the helpers below build
+// and read the YAML trees of documents that loaders convert to OpenAPI, such
+// as Swagger 2.0, Postman collections, and GraphQL schemas.
+func yamlMapping() *yaml.Node {
+	return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+}
+
+func yamlScalar(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
+func yamlBool(value bool) *yaml.Node {
+	if value {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: "true"}
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: "false"}
+}
+
+func yamlGet(n *yaml.Node, key string) *yaml.Node {
+	if n == nil || n.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func yamlString(n *yaml.Node, key string) string {
+	if value := yamlGet(n, key); value != nil && value.Kind == yaml.ScalarNode {
+		return value.Value
+	}
+	return ""
+}
+
+func yamlStrings(n *yaml.Node) []string {
+	if n == nil || n.Kind != yaml.SequenceNode {
+		return nil
+	}
+	out := make([]string, 0, len(n.Content))
+	for _, item := range n.Content {
+		if item.Kind == yaml.ScalarNode && item.Value != "" {
+			out = append(out, item.Value)
+		}
+	}
+	return out
+}
+
+// yamlSet sets key in mapping n, replacing an existing value.
+func yamlSet(n *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			n.Content[i+1] = value
+			return
+		}
+	}
+	n.Content = append(n.Content, yamlScalar(key), value)
+}
+
+func yamlCopyFields(dst, src *yaml.Node, keys ...string) {
+	for _, key := range keys {
+		if value := yamlGet(src, key); value != nil {
+			yamlSet(dst, key, value)
+		}
+	}
+}
+
+func yamlCopyExtensions(dst, src *yaml.Node) {
+	if src == nil || src.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		if strings.HasPrefix(src.Content[i].Value, "x-") {
+			yamlSet(dst, src.Content[i].Value, src.Content[i+1])
+		}
+	}
+}
@@ -0,0 +1,458 @@
+// Package websocket implements the RFC 6455 framing used by the ws command.
+//
+// The opening handshake is an ordinary HTTP/1.1 request, so callers send it
+// through the request pipeline (auth, profile headers, TLS) and hand the
+// upgraded connection to NewConn. Conn only speaks the base protocol: no
+// extensions are negotiated, so compressed or RSV-flagged frames are rejected.
+package websocket
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Frame opcodes.
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+)
+
+// Close status codes from RFC 6455 section 7.4.1.
+const (
+	CloseNormal          = 1000
+	CloseGoingAway       = 1001
+	CloseProtocolError   = 1002
+	CloseUnsupportedData = 1003
+	CloseNoStatus        = 1005
+	CloseAbnormal        = 1006
+	CloseInvalidPayload  = 1007
+	ClosePolicyViolation = 1008
+	CloseMessageTooBig   = 1009
+	CloseMandatoryExt    = 1010
+	CloseInternalError   = 1011
+)
+
+// DefaultMaxMessageBytes bounds an assembled message when NewConn is given
+// no explicit limit.
+const DefaultMaxMessageBytes = 32 << 20
+
+const maxControlPayload = 125
+
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// NewKey returns a random Sec-WebSocket-Key value.
+func NewKey() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("generating WebSocket key: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(b[:]), nil
+}
+
+// AcceptKey returns the Sec-WebSocket-Accept value a server must send for key.
+func AcceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// HandshakeHeaders returns the request headers that ask a server to upgrade
+// to WebSocket, offering protocols in preference order.
+func HandshakeHeaders(key string, protocols []string) []string {
+	headers := []string{
+		"Connection: Upgrade",
+		"Upgrade: websocket",
+		"Sec-WebSocket-Version: 13",
+		"Sec-WebSocket-Key: " + key,
+	}
+	if len(protocols) > 0 {
+		headers = append(headers, "Sec-WebSocket-Protocol: "+strings.Join(protocols, ", "))
+	}
+	return headers
+}
+
+// CheckHandshake validates a 101 Switching Protocols response to a handshake
+// sent with key and the offered protocols, and returns the subprotocol the
+// server selected, if any.
+func CheckHandshake(resp *http.Response, key string, protocols []string) (string, error) {
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		return "", fmt.Errorf("server responded %d %s instead of switching protocols", resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+	if !strings.EqualFold(resp.Header.Get("Upgrade"), "websocket") {
+		return "", fmt.Errorf("server upgraded to %q instead of websocket", resp.Header.Get("Upgrade"))
+	}
+	if got := resp.Header.Get("Sec-WebSocket-Accept"); got != AcceptKey(key) {
+		return "", errors.New("server sent an invalid Sec-WebSocket-Accept header")
+	}
+	if ext := resp.Header.Get("Sec-WebSocket-Extensions"); ext != "" {
+		return "", fmt.Errorf("server enabled extension %q that was not offered", ext)
+	}
+	selected := strings.TrimSpace(resp.Header.Get("Sec-WebSocket-Protocol"))
+	if selected != "" && !slices.Contains(protocols, selected) {
+		return "", fmt.Errorf("server selected subprotocol %q that was not offered", selected)
+	}
+	return selected, nil
+}
+
+// Message is one complete data message.
+type Message struct {
+	// Binary is true for binary messages and false for UTF-8 text.
+	Binary bool
+	Data   []byte
+}
+
+// CloseError reports that the peer closed the connection. Code is
+// CloseNoStatus when the close frame carried no status code.
+type CloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *CloseError) Error() string {
+	text := fmt.Sprintf("WebSocket closed with status %d", e.Code)
+	if name := CloseCodeText(e.Code); name != "" {
+		text += " (" + name + ")"
+	}
+	if e.Reason != "" {
+		text += ": " + e.Reason
+	}
+	return text
+}
+
+// Normal reports whether the close code describes an orderly shutdown.
+func (e *CloseError) Normal() bool {
+	return e.Code == CloseNormal || e.Code == CloseGoingAway || e.Code == CloseNoStatus
+}
+
+// CloseCodeText returns a short description of a registered close code.
+func CloseCodeText(code int) string {
+	switch code {
+	case CloseNormal:
+		return "normal closure"
+	case CloseGoingAway:
+		return "going away"
+	case CloseProtocolError:
+		return "protocol error"
+	case CloseUnsupportedData:
+		return "unsupported data"
+	case CloseNoStatus:
+		return "no status"
+	case CloseAbnormal:
+		return "abnormal closure"
+	case CloseInvalidPayload:
+		return "invalid payload"
+	case ClosePolicyViolation:
+		return "policy violation"
+	case CloseMessageTooBig:
+		return "message too big"
+	case CloseMandatoryExt:
+		return "mandatory extension"
+	case CloseInternalError:
+		return "internal error"
+	}
+	return ""
+}
+
+// Conn is a WebSocket connection over an upgraded stream. ReadMessage must be
+// called from one goroutine; writes are safe to call concurrently with it and
+// with each other.
+type Conn struct {
+	rw         io.ReadWriteCloser
+	br         *bufio.Reader
+	client     bool
+	maxMessage int64
+
+	writeMu   sync.Mutex
+	closeSent bool
+
+	// OnPong, when non-nil, is called from ReadMessage for each pong frame.
+	OnPong func(data []byte)
+}
+
+// NewConn wraps an upgraded connection. Client connections mask every frame
+// they send and reject masked frames from the server; server connections do
+// the reverse. maxMessageBytes limits assembled messages and defaults to
+// DefaultMaxMessageBytes when zero or negative.
+func NewConn(rw io.ReadWriteCloser, client bool, maxMessageBytes int64) *Conn {
+	if maxMessageBytes <= 0 {
+		maxMessageBytes = DefaultMaxMessageBytes
+	}
+	return &Conn{rw: rw, br: bufio.NewReader(rw), client: client, maxMessage: maxMessageBytes}
+}
+
+// WriteText sends a text message.
+func (c *Conn) WriteText(data []byte) error {
+	if !utf8.Valid(data) {
+		return errors.New("text message is not valid UTF-8")
+	}
+	return c.writeFrame(opText, data)
+}
+
+// WriteBinary sends a binary message.
+func (c *Conn) WriteBinary(data []byte) error {
+	return c.writeFrame(opBinary, data)
+}
+
+// Ping sends a ping frame with an optional payload of up to 125 bytes.
+func (c *Conn) Ping(data []byte) error {
+	if len(data) > maxControlPayload {
+		return errors.New("ping payload exceeds 125 bytes")
+	}
+	return c.writeFrame(opPing, data)
+}
+
+// Close sends a close frame with code and reason. A reason too long for a
+// control frame is cut at a character boundary so it stays valid UTF-8. Only
+// the first call sends a frame; the underlying connection stays open so the
+// peer's close frame can still be read. Use CloseNow to release it.
+func (c *Conn) Close(code int, reason string) error {
+	if limit := maxControlPayload - 2; len(reason) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(reason[cut]) {
+			cut--
+		}
+		reason = reason[:cut]
+	}
+	payload := make([]byte, 2, 2+len(reason))
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	payload = append(payload, reason...)
+	return c.writeFrame(opClose, payload)
+}
+
+// CloseNow closes the underlying connection without a closing handshake.
+func (c *Conn) CloseNow() error {
+	return c.rw.Close()
+}
+
+// ReadMessage returns the next data message, assembling fragments. Pings are
+// answered with pongs as they arrive. When the peer sends a close frame,
+// ReadMessage echoes it and returns a *CloseError. Protocol violations close
+// the connection with the matching status code and return an error.
+func (c *Conn) ReadMessage() (Message, error) {
+	var (
+		msg        Message
+		assembling bool
+	)
+	for {
+		fin, op, payload, err := c.readFrame()
+		if err != nil {
+			return Message{}, c.failRead(err)
+		}
+		switch op {
+		case opPing:
+			if err := c.writeFrame(opPong, payload); err != nil && !errors.Is(err, errCloseSent) {
+				return Message{}, err
+			}
+			continue
+		case opPong:
+			if c.OnPong != nil {
+				c.OnPong(payload)
+			}
+			continue
+		case opClose:
+			closeErr := parseClosePayload(payload)
+			echo := closeErr.Code
+			if echo == CloseNoStatus {
+				echo = CloseNormal
+			}
+			if !validCloseCode(closeErr.Code) {
+				echo = CloseProtocolError
+			}
+			_ = c.Close(echo, "")
+			return Message{}, closeErr
+		case opText, opBinary:
+			if assembling {
+				return Message{}, c.fail(CloseProtocolError, "new message started before the previous one finished")
+			}
+			msg = Message{Binary: op == opBinary, Data: payload}
+			assembling = true
+		case opContinuation:
+			if !assembling {
+				return Message{}, c.fail(CloseProtocolError, "continuation frame without a message")
+			}
+			if int64(len(msg.Data))+int64(len(payload)) > c.maxMessage {
+				return Message{}, c.fail(CloseMessageTooBig, fmt.Sprintf("message exceeds %d bytes", c.maxMessage))
+			}
+			msg.Data = append(msg.Data, payload...)
+		default:
+			return Message{}, c.fail(CloseProtocolError, fmt.Sprintf("unknown opcode %#x", op))
+		}
+		if !fin {
+			continue
+		}
+		if !msg.Binary && !utf8.Valid(msg.Data) {
+			return Message{}, c.fail(CloseInvalidPayload, "text message is not valid UTF-8")
+		}
+		return msg, nil
+	}
+}
+
+var errCloseSent = errors.New("close frame already sent")
+
+// protocolError is a read failure that must close the connection with code.
+type protocolError struct {
+	code int
+	msg  string
+}
+
+func (e *protocolError) Error() string { return e.msg }
+
+func (c *Conn) fail(code int, msg string) error {
+	_ = c.Close(code, msg)
+	return fmt.Errorf("WebSocket protocol error: %s", msg)
+}
+
+func (c *Conn) failRead(err error) error {
+	var perr *protocolError
+	if errors.As(err, &perr) {
+		return c.fail(perr.code, perr.msg)
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return &CloseError{Code: CloseAbnormal, Reason: "connection closed without a close frame"}
+	}
+	return err
+}
+
+func (c *Conn) readFrame() (fin bool, op byte, payload []byte, err error) {
+	var head [2]byte
+	if _, err := io.ReadFull(c.br, head[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin = head[0]&0x80 != 0
+	if head[0]&0x70 != 0 {
+		return false, 0, nil, &protocolError{CloseProtocolError, "reserved bits set without a negotiated extension"}
+	}
+	op = head[0] & 0x0F
+	masked := head[1]&0x80 != 0
+	if masked == c.client {
+		if c.client {
+			return false, 0, nil, &protocolError{CloseProtocolError, "server sent a masked frame"}
+		}
+		return false, 0, nil, &protocolError{CloseProtocolError, "client sent an unmasked frame"}
+	}
+	length := uint64(head[1] & 0x7F)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if op >= opClose {
+		if !fin {
+			return false, 0, nil, &protocolError{CloseProtocolError, "fragmented control frame"}
+		}
+		if length > maxControlPayload {
+			return false, 0, nil, &protocolError{CloseProtocolError, "control frame payload exceeds 125 bytes"}
+		}
+	}
+	if length > uint64(c.maxMessage) {
+		return false, 0, nil, &protocolError{CloseMessageTooBig, fmt.Sprintf("message exceeds %d bytes", c.maxMessage)}
+	}
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.br, mask[:]); err != nil {
+			return false, 0, nil, err
+		}
+	}
+	payload = make([]byte, length)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return false, 0, nil, err
+	}
+	if masked {
+		maskBytes(mask, payload)
+	}
+	return fin, op, payload, nil
+}
+
+func (c *Conn) writeFrame(op byte, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.closeSent {
+		return errCloseSent
+	}
+	if op == opClose {
+		c.closeSent = true
+	}
+
+	frame := make([]byte, 0, 14+len(payload))
+	frame = append(frame, 0x80|op)
+	maskBit := byte(0)
+	if c.client {
+		maskBit = 0x80
+	}
+	switch n := len(payload); {
+	case n <= 125:
+		frame = append(frame, maskBit|byte(n))
+	case n <= 0xFFFF:
+		frame = append(frame, maskBit|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame = append(frame, maskBit|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+	if c.client {
+		var mask [4]byte
+		if _, err := rand.Read(mask[:]); err != nil {
+			return fmt.Errorf("generating frame mask: %w", err)
+		}
+		frame = append(frame, mask[:]...)
+		start := len(frame)
+		frame = append(frame, payload...)
+		maskBytes(mask, frame[start:])
+	} else {
+		frame = append(frame, payload...)
+	}
+	_, err := c.rw.Write(frame)
+	return err
+}
+
+func maskBytes(mask [4]byte, b []byte) {
+	for i := range b {
+		b[i] ^= mask[i%4]
+	}
+}
+
+func parseClosePayload(payload []byte) *CloseError {
+	if len(payload) < 2 {
+		return &CloseError{Code: CloseNoStatus}
+	}
+	return &CloseError{
+		Code:   int(binary.BigEndian.Uint16(payload)),
+		Reason: strings.ToValidUTF8(string(payload[2:]), "�"),
+	}
+}
+
+// validCloseCode reports whether a peer may send code in a close frame.
+func validCloseCode(code int) bool {
+	switch {
+	case code == CloseNoStatus:
+		return true
+	case code >= 1000 && code <= 1014:
+		return code != 1004 && code != CloseAbnormal
+	case code >= 3000 && code <= 4999:
+		return true
+	}
+	return false
+}
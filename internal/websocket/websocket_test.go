@@ -0,0 +1,204 @@
+package websocket_test
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/rest-sh/restish/v2/internal/websocket"
+)
+
+func TestAcceptKeyMatchesRFCExample(t *testing.T) {
+	if got := websocket.AcceptKey("dGhlIHNhbXBsZSBub25jZQ=="); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("AcceptKey = %q", got)
+	}
+}
+
+func TestCheckHandshake(t *testing.T) {
+	key := "dGhlIHNhbXBsZSBub25jZQ=="
+	response := func(protocol string) *http.Response {
+		h := http.Header{}
+		h.Set("Upgrade", "websocket")
+		h.Set("Sec-WebSocket-Accept", websocket.AcceptKey(key))
+		if protocol != "" {
+			h.Set("Sec-WebSocket-Protocol", protocol)
+		}
+		return &http.Response{StatusCode: http.StatusSwitchingProtocols, Header: h}
+	}
+
+	selected, err := websocket.CheckHandshake(response("graphql-ws"), key, []string{"graphql-transport-ws", "graphql-ws"})
+	if err != nil || selected != "graphql-ws" {
+		t.Fatalf("selected = %q, err = %v", selected, err)
+	}
+	if _, err := websocket.CheckHandshake(response("mqtt"), key, []string{"graphql-ws"}); err == nil || !strings.Contains(err.Error(), `"mqtt"`) {
+		t.Fatalf("expected unoffered subprotocol error, got %v", err)
+	}
+	bad := response("")
+	bad.Header.Set("Sec-WebSocket-Accept", "nope")
+	if _, err := websocket.CheckHandshake(bad, key, nil); err == nil {
+		t.Fatal("expected invalid accept key error")
+	}
+	if _, err := websocket.CheckHandshake(&http.Response{StatusCode: http.StatusOK, Header: http.Header{}}, key, nil); err == nil {
+		t.Fatal("expected error for a non-101 response")
+	}
+}
+
+// tcpPair returns both ends of a loopback TCP connection. Unlike net.Pipe,
+// the kernel buffers writes, so both sides can send control frames at once.
+func tcpPair(t *testing.T) (client, server net.Conn) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, _ := ln.Accept()
+		accepted <- conn
+	}()
+	client, err = net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	server = <-accepted
+	if server == nil {
+		t.Fatal("accept failed")
+	}
+	t.Cleanup(func() {
+		_ = client.Close()
+		_ = server.Close()
+	})
+	return client, server
+}
+
+func newPair(t *testing.T) (client, server *websocket.Conn) {
+	t.Helper()
+	c, s := tcpPair(t)
+	return websocket.NewConn(c, true, 0), websocket.NewConn(s, false, 0)
+}
+
+func TestConnExchangesMessagesAndAnswersPings(t *testing.T) {
+	client, server := newPair(t)
+
+	pongs := make(chan string, 1)
+	server.OnPong = func(data []byte) { pongs <- string(data) }
+	serverErr := make(chan error, 1)
+	go func() {
+		msg, err := server.ReadMessage()
+		if err != nil {
+			serverErr <- err
+			return
+		}
+		if err := server.Ping([]byte("hb")); err != nil {
+			serverErr <- err
+			return
+		}
+		if err := server.WriteText(append([]byte("echo "), msg.Data...)); err != nil {
+			serverErr <- err
+			return
+		}
+		// Reading the close frame also delivers the client's pong.
+		_, err = server.ReadMessage()
+		serverErr <- err
+	}()
+
+	if err := client.WriteText([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	msg, err := client.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Binary || string(msg.Data) != "echo hello" {
+		t.Fatalf("message = %+v", msg)
+	}
+	if err := client.Close(websocket.CloseNormal, "bye"); err != nil {
+		t.Fatal(err)
+	}
+	var closeErr *websocket.CloseError
+	if err := <-serverErr; !errors.As(err, &closeErr) || closeErr.Code != websocket.CloseNormal || closeErr.Reason != "bye" {
+		t.Fatalf("server read = %v", err)
+	}
+	if got := <-pongs; got != "hb" {
+		t.Fatalf("pong payload = %q", got)
+	}
+}
+
+func TestConnCloseTruncatesReasonAtCharacterBoundary(t *testing.T) {
+	client, server := newPair(t)
+	serverErr := make(chan error, 1)
+	go func() {
+		_, err := server.ReadMessage()
+		serverErr <- err
+	}()
+
+	// 62 two-byte characters fill 124 bytes, one more than the 123 allowed.
+	reason := strings.Repeat("é", 62)
+	if err := client.Close(websocket.CloseGoingAway, reason); err != nil {
+		t.Fatal(err)
+	}
+	var closeErr *websocket.CloseError
+	if err := <-serverErr; !errors.As(err, &closeErr) || closeErr.Code != websocket.CloseGoingAway {
+		t.Fatalf("server read = %v", err)
+	}
+	if want := strings.Repeat("é", 61); closeErr.Reason != want {
+		t.Fatalf("reason = %q (%d bytes), want %d bytes", closeErr.Reason, len(closeErr.Reason), len(want))
+	}
+}
+
+func TestConnReassemblesFragmentsAndReportsCloseCode(t *testing.T) {
+	c, s := tcpPair(t)
+	client := websocket.NewConn(c, true, 0)
+
+	go func() {
+		// Unmasked server frames: "hel" (text, not final), "lo" (continuation,
+		// final), then a close with 1011 and a reason.
+		_, _ = s.Write([]byte{0x01, 3, 'h', 'e', 'l'})
+		_, _ = s.Write([]byte{0x80, 2, 'l', 'o'})
+		_, _ = s.Write(append([]byte{0x88, 6, 0x03, 0xF3}, "oops"...))
+		buf := make([]byte, 64)
+		_, _ = s.Read(buf)
+	}()
+
+	msg, err := client.ReadMessage()
+	if err != nil || string(msg.Data) != "hello" {
+		t.Fatalf("message = %q, err = %v", msg.Data, err)
+	}
+	_, err = client.ReadMessage()
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) || closeErr.Code != websocket.CloseInternalError || closeErr.Normal() {
+		t.Fatalf("close = %v", err)
+	}
+	if got := closeErr.Error(); got != "WebSocket closed with status 1011 (internal error): oops" {
+		t.Fatalf("close error = %q", got)
+	}
+}
+
+func TestConnRejectsMaskedServerFrames(t *testing.T) {
+	c, s := tcpPair(t)
+	client := websocket.NewConn(c, true, 0)
+
+	closeFrame := make(chan []byte, 1)
+	go func() {
+		_, _ = s.Write([]byte{0x81, 0x81, 1, 2, 3, 4, 'x' ^ 1})
+		buf := make([]byte, 64)
+		n, _ := s.Read(buf)
+		closeFrame <- buf[:n]
+	}()
+
+	if _, err := client.ReadMessage(); err == nil || !strings.Contains(err.Error(), "masked") {
+		t.Fatalf("expected masked frame error, got %v", err)
+	}
+	frame := <-closeFrame
+	if len(frame) < 8 || frame[0] != 0x88 {
+		t.Fatalf("expected a close frame, got %x", frame)
+	}
+	mask := frame[2:6]
+	code := int(frame[6]^mask[0])<<8 | int(frame[7]^mask[1])
+	if code != websocket.CloseProtocolError {
+		t.Fatalf("close code = %d, want %d", code, websocket.CloseProtocolError)
+	}
+}
@@ -185,7 +185,8 @@ type ConfigReadMsg struct {
 }
 
 // ConfigReadResponseMsg is the host reply to a ConfigReadMsg.
-// Auth secrets are intentionally excluded.
+// Auth secrets and headers set from secret references are intentionally
+// excluded.
 type ConfigReadResponseMsg struct {
 	Type      string   `cbor:"type"`
 	RequestID string   `cbor:"request_id,omitempty"`